@@ -9,17 +9,23 @@ import (
 	"syscall"
 	"time"
 
+	"ai-cv-summarize/internal/audit"
+	"ai-cv-summarize/internal/chaos"
 	"ai-cv-summarize/internal/config"
 	"ai-cv-summarize/internal/handlers"
 	"ai-cv-summarize/internal/llm"
 	"ai-cv-summarize/internal/rag"
+	"ai-cv-summarize/internal/ratelimit"
 	"ai-cv-summarize/internal/repositories"
 	"ai-cv-summarize/internal/services"
+	"ai-cv-summarize/internal/telemetry"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
@@ -32,55 +38,394 @@ func main() {
 	// Set Gin mode
 	gin.SetMode(cfg.Server.GinMode)
 
-	// Connect to MongoDB
-	mongoClient, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(cfg.MongoDB.URI))
+	// Tracing is installed first so MongoDB and LLM clients created below are instrumented
+	shutdownTracing, err := telemetry.Setup(context.Background(), &cfg.Tracing)
 	if err != nil {
-		log.Fatal("Failed to connect to MongoDB:", err)
+		log.Fatal("Failed to set up tracing:", err)
 	}
-	defer mongoClient.Disconnect(context.TODO())
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Printf("Warning: failed to flush traces: %v", err)
+		}
+	}()
 
-	// Get database
-	db := mongoClient.Database(cfg.MongoDB.Database)
+	// Fault injection for resilience testing in staging
+	var injector *chaos.Injector
+	if cfg.Chaos.Enabled {
+		injector = chaos.NewInjector(&cfg.Chaos)
+	}
 
-	// Connect to Redis
-	redisClient := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379", // Default Redis address
-	})
-	defer redisClient.Close()
+	// Initialize repositories
+	var repository repositories.Repository
+	switch cfg.Storage.Backend {
+	case "embedded":
+		// Embedded store needs no MongoDB, intended for local development and demos
+		embeddedRepository, err := repositories.NewEmbeddedRepository(cfg.Storage.Path)
+		if err != nil {
+			log.Fatal("Failed to open embedded storage:", err)
+		}
+		log.Printf("Using embedded storage at %s (single instance only)", cfg.Storage.Path)
+		repository = embeddedRepository
+	case "memory":
+		// Nothing survives a restart; for demos and tests
+		log.Println("Using in-memory storage (data is lost on restart, single instance only)")
+		repository = repositories.NewMemoryRepository()
+	case "mongodb":
+		// Connect to MongoDB
+		clientOptions := options.Client().ApplyURI(cfg.MongoDB.URI)
+		var monitor *event.CommandMonitor
+		if injector != nil {
+			monitor = injector.MongoMonitor()
+		}
+		clientOptions.SetMonitor(telemetry.MongoMonitor(monitor))
+		mongoClient, err := mongo.Connect(context.TODO(), clientOptions)
+		if err != nil {
+			log.Fatal("Failed to connect to MongoDB:", err)
+		}
+		defer mongoClient.Disconnect(context.TODO())
 
-	// Test Redis connection
-	if err := redisClient.Ping(context.TODO()).Err(); err != nil {
-		log.Fatal("Failed to connect to Redis:", err)
+		// Get database
+		db := mongoClient.Database(cfg.MongoDB.Database)
+		repository = repositories.NewMongoDBRepository(db)
+	case "postgres":
+		postgresRepository, err := repositories.NewPostgresRepository(context.TODO(), cfg.Postgres.URL)
+		if err != nil {
+			log.Fatal("Failed to connect to PostgreSQL:", err)
+		}
+		defer postgresRepository.Close()
+		log.Println("Using PostgreSQL storage")
+		repository = postgresRepository
+	default:
+		log.Fatalf("Unknown storage backend %q, must be mongodb, embedded, memory or postgres", cfg.Storage.Backend)
 	}
 
-	// Initialize repositories
-	repository := repositories.NewMongoDBRepository(db)
+	// Apply pending migrations, e.g. indexes, before anything queries the store
+	migrations, err := repository.Migrate(context.TODO())
+	if err != nil {
+		log.Printf("Warning: Failed to migrate database: %v", err)
+	}
+	for _, m := range migrations {
+		log.Printf("Applied migration %d: %s", m.Version, m.Description)
+	}
 
 	// Initialize database with default data
-	dbInitService := services.NewDatabaseInitService(repository)
+	dbInitService := services.NewDatabaseInitService(repository, cfg.Upload.UploadDir)
 	if err := dbInitService.InitializeDatabase(context.TODO()); err != nil {
 		log.Printf("Warning: Failed to initialize database: %v", err)
 	}
 
+	// Select queue backend: "redis", "memory", or "auto" (Redis with in-memory fallback)
+	var (
+		queueBackend services.QueueBackend
+		redisClient  redis.UniversalClient
+	)
+	if cfg.JobQueue.Backend != "memory" {
+		// Connect to Redis
+		client, err := services.NewRedisClient(cfg.Redis.URL)
+		if err != nil {
+			log.Fatal("Invalid REDIS_URL: ", err)
+		}
+		redisClient = client
+		defer redisClient.Close()
+
+		// Test Redis connection
+		if err := redisClient.Ping(context.TODO()).Err(); err != nil {
+			if cfg.JobQueue.Backend == "redis" {
+				log.Fatal("Failed to connect to Redis:", err)
+			}
+			log.Printf("Warning: Redis unavailable (%v), falling back to in-memory queue (single instance only)", err)
+			redisClient = nil
+		} else {
+			if injector != nil {
+				redisClient.AddHook(injector.RedisHook())
+			}
+			redisQueue := services.NewRedisQueueBackend(redisClient, cfg.JobQueue.ClaimTimeout)
+			if err := redisQueue.Setup(context.TODO()); err != nil {
+				log.Fatal("Failed to set up Redis queue:", err)
+			}
+			queueBackend = redisQueue
+		}
+	}
+	if queueBackend == nil {
+		queueBackend = services.NewMemoryQueueBackend()
+	}
+	log.Printf("Using %s queue backend", queueBackend.Name())
+
 	// Initialize LLM client
-	llmFactory := llm.NewLLMFactory()
-	llmClient := llmFactory.CreateClient(&cfg.OpenAI, &cfg.OpenRouter)
+	llmFactory := llm.NewLLMFactory(cfg.LLM.Provider)
+	llmProvider, llmModel := llmFactory.ActiveProvider(&cfg.OpenAI, &cfg.OpenRouter, &cfg.Gemini)
+	if llmProvider == llm.ProviderMock {
+		log.Println("Using the mock LLM provider: evaluations return canned, deterministic output")
+	}
+	if cfg.LLM.ContextWindow <= 0 {
+		cfg.LLM.ContextWindow = llm.ContextWindow(llmModel)
+	}
+	llmClient := llmFactory.CreateClient(&cfg.OpenAI, &cfg.OpenRouter, &cfg.Gemini)
+	// The rate limiter goes innermost, so injected faults, audits and traces still see every call it lets through
+	var requestsPerMinute, tokensPerMinute int
+	switch llmProvider {
+	case llm.ProviderOpenAI:
+		requestsPerMinute, tokensPerMinute = cfg.OpenAI.RequestsPerMinute, cfg.OpenAI.TokensPerMinute
+	case llm.ProviderOpenRouter:
+		requestsPerMinute, tokensPerMinute = cfg.OpenRouter.RequestsPerMinute, cfg.OpenRouter.TokensPerMinute
+	case llm.ProviderGemini:
+		requestsPerMinute, tokensPerMinute = cfg.Gemini.RequestsPerMinute, cfg.Gemini.TokensPerMinute
+	}
+	rateLimiter := ratelimit.NewLimiter(redisClient, llmProvider, requestsPerMinute, tokensPerMinute)
+	if rateLimiter.Enabled() {
+		llmClient = rateLimiter.WrapLLMClient(llmClient)
+		scope := "this instance only"
+		if rateLimiter.Shared() {
+			scope = "shared through Redis"
+		}
+		log.Printf("LLM rate limit: %d requests and %d tokens per minute (0 is unlimited), %s", requestsPerMinute, tokensPerMinute, scope)
+	}
+	// Local embeddings replace the provider's past the rate limiter, which only guards the provider account
+	if cfg.Embeddings.Provider == "onnx" {
+		embedder, err := llm.NewONNXEmbedder(&cfg.Embeddings)
+		if err != nil {
+			log.Fatal("Failed to load ONNX embedding model:", err)
+		}
+		llmClient = llm.WithEmbedder(llmClient, embedder)
+		log.Printf("Using local embedding model %s", embedder.EmbeddingModel())
+	}
+	if injector != nil {
+		llmClient = injector.WrapLLMClient(llmClient)
+	}
+	if cfg.Audit.Enabled {
+		llmClient = audit.WrapLLMClient(llmClient, repository, llmModel, cfg.Audit.MaxContent)
+	}
+	llmClient = telemetry.WrapLLMClient(llmClient)
+	// Cache hits skip the provider entirely, so they are neither audited nor traced as LLM calls
+	if cfg.Embeddings.CacheEnabled && llmProvider != llm.ProviderMock {
+		llmClient = rag.WrapEmbeddingCache(llmClient, repository, cfg.Embeddings.CacheTTL)
+	}
+	// Batches are split before the cache so each request only carries the texts it missed
+	llmClient = llm.WrapEmbeddingBatches(llmClient, cfg.Embeddings.BatchSize, cfg.Embeddings.Concurrency)
+
+	// modelClient creates a client for another model on the active provider, for the judge and experiment variants
+	modelClient := func(model string) llm.LLMClient {
+		openAIConfig, openRouterConfig, geminiConfig := cfg.OpenAI, cfg.OpenRouter, cfg.Gemini
+		openAIConfig.Model, openRouterConfig.Model, geminiConfig.Model = model, model, model
+		client := llmFactory.CreateClient(&openAIConfig, &openRouterConfig, &geminiConfig)
+		// Both models draw on the same provider account, so they share its limits
+		if rateLimiter.Enabled() {
+			client = rateLimiter.WrapLLMClient(client)
+		}
+		if injector != nil {
+			client = injector.WrapLLMClient(client)
+		}
+		if cfg.Audit.Enabled {
+			client = audit.WrapLLMClient(client, repository, model, cfg.Audit.MaxContent)
+		}
+		return telemetry.WrapLLMClient(client)
+	}
+
+	// The judge reviews evaluations with the evaluation model unless another model on the same provider is set
+	judgeClient := llmClient
+	if cfg.Judge.Enabled && cfg.Judge.Model != "" {
+		judgeClient = modelClient(cfg.Judge.Model)
+	}
+	if cfg.Judge.Enabled {
+		log.Printf("Evaluation judge enabled in %s mode", cfg.Judge.Mode)
+	}
+
+	// Select vector database: "scan" (MongoDB scan), "hnsw" (in-process graph), "qdrant" or "pgvector"
+	// (all three fall back to scan when unavailable)
+	scanVectorDB := rag.NewScanVectorDB(repository, llmClient)
+	var vectorDB rag.VectorDB = scanVectorDB
+	switch cfg.VectorDB.Backend {
+	case "scan":
+	case "hnsw":
+		// Searches scan until the graph is built
+		hnsw := rag.NewHNSWVectorDB(scanVectorDB, &cfg.VectorDB)
+		vectorDB = hnsw
+		go func() {
+			started := time.Now()
+			if err := hnsw.Build(context.Background()); err != nil {
+				log.Printf("Warning: failed to build HNSW index (%v), retrieval keeps using the MongoDB scan", err)
+				return
+			}
+			log.Printf("Built HNSW index in %v", time.Since(started).Round(time.Millisecond))
+		}()
+	case "qdrant":
+		qdrant := rag.NewQdrantVectorDB(&cfg.VectorDB)
+		if err := qdrant.Ping(context.TODO()); err != nil {
+			log.Printf("Warning: Qdrant unavailable (%v), falling back to MongoDB scan for retrieval", err)
+		} else {
+			vectorDB = qdrant
+		}
+	case "pgvector":
+		pgvector, err := rag.NewPgVectorDB(context.TODO(), cfg.Postgres.URL)
+		if err != nil {
+			log.Printf("Warning: pgvector unavailable (%v), falling back to MongoDB scan for retrieval", err)
+		} else {
+			defer pgvector.Close()
+			vectorDB = pgvector
+		}
+	default:
+		log.Fatalf("Unknown vector database backend %q, must be scan, hnsw, qdrant or pgvector", cfg.VectorDB.Backend)
+	}
+	log.Printf("Using %s vector database", vectorDB.Name())
+
+	// "seed-samples" loads demo data and exits without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "seed-samples" {
+		summary, err := dbInitService.LoadSampleData(context.TODO())
+		if err != nil {
+			log.Fatal("Failed to load sample data:", err)
+		}
+		log.Printf("Sample data loaded: %+v", *summary)
+		return
+	}
+
+	// "migrate-embeddings" re-embeds job descriptions left over from a previous embedding model and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate-embeddings" {
+		summary, err := rag.NewVectorStore(llmClient, repository, vectorDB, &cfg.VectorDB).MigrateEmbeddings(context.TODO())
+		if err != nil {
+			log.Fatal("Failed to migrate embeddings:", err)
+		}
+		log.Printf("Embedding migration finished: %+v", *summary)
+		if summary.Failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// "rebuild-index [--restart]" wipes and re-embeds every job description, resuming an interrupted rebuild by default
+	if len(os.Args) > 1 && os.Args[1] == "rebuild-index" {
+		restart := len(os.Args) > 2 && os.Args[2] == "--restart"
+		rebuilder := rag.NewIndexRebuilder(rag.NewVectorStore(llmClient, repository, vectorDB, &cfg.VectorDB), repository)
+		rebuild, err := rebuilder.Start(context.TODO(), rag.DefaultRebuildBatchSize, restart)
+		if err != nil {
+			log.Fatal("Failed to start vector index rebuild:", err)
+		}
+		if err := rebuilder.Run(context.TODO(), rebuild); err != nil {
+			log.Fatal("Vector index rebuild failed:", err)
+		}
+		log.Printf("Vector index rebuild finished: %d/%d documents, %d failed", rebuild.Processed, rebuild.Total, rebuild.Failed)
+		return
+	}
 
 	// Initialize services
-	fileService := services.NewFileService(cfg.Upload.UploadDir, cfg.Upload.MaxFileSize)
-	vectorStore := rag.NewVectorStore(llmClient, repository, &cfg.VectorDB)
-	evaluationService := services.NewEvaluationService(llmClient, repository, vectorStore, cfg)
-	jobQueue := services.NewJobQueue(redisClient, repository, evaluationService, cfg)
+	ocrService := services.NewOCRService(&cfg.OCR)
+	if ocrService.Enabled() {
+		if err := ocrService.Available(); err != nil {
+			log.Printf("Warning: OCR is enabled but unavailable, scanned PDFs will be rejected: %v", err)
+		} else {
+			log.Printf("OCR enabled for scanned PDFs (languages: %s)", cfg.OCR.Languages)
+		}
+	}
+	virusScanner := services.NewVirusScanner(&cfg.Antivirus)
+	if virusScanner.Enabled() {
+		pingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := virusScanner.Ping(pingCtx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: virus scanning is enabled but clamd is unreachable, uploads will be rejected: %v", err)
+		} else {
+			log.Printf("Virus scanning enabled (clamd at %s)", cfg.Antivirus.Address)
+		}
+	}
+	fileService := services.NewFileService(&cfg.Upload, ocrService, virusScanner, repository)
+	vectorStore := rag.NewVectorStore(llmClient, repository, vectorDB, &cfg.VectorDB)
+	scoringService := services.NewScoringService(repository)
+	promptService := services.NewPromptService(repository)
+	languageService := services.NewLanguageService(llmClient, promptService, cfg)
+
+	// The moderation endpoint is always OpenAI's, whichever provider serves the evaluations
+	var moderator services.Moderator
+	if cfg.Moderation.Provider == services.ModerationOpenAI {
+		moderator = llm.NewOpenAIClient(&cfg.OpenAI)
+	}
+	moderationService := services.NewModerationService(cfg, moderator)
+	if moderationService.Enabled() {
+		log.Printf("Using %s content moderation", cfg.Moderation.Provider)
+	}
+
+	githubService := services.NewGitHubService(&cfg.GitHub)
+	if githubService.Enabled() && cfg.GitHub.Token == "" {
+		log.Printf("GitHub analysis runs without GITHUB_TOKEN, limited to 60 API requests an hour")
+	}
+
+	experimentService := services.NewExperimentService(repository, promptService, modelClient)
+	evaluationService := services.NewEvaluationService(llmClient, judgeClient, repository, vectorStore, scoringService, promptService, languageService, moderationService, githubService, experimentService, cfg)
+	jobQueue := services.NewJobQueue(queueBackend, repository, evaluationService, cfg)
+
+	// Buffer evaluate requests in Redis during short database outages
+	var jobBuffer *services.JobBuffer
+	if redisClient != nil {
+		jobBuffer = services.NewJobBuffer(redisClient, repository, jobQueue, cfg.JobQueue.BufferTTL)
+	}
+
+	// Sandbox evaluations use the mock LLM end to end, including retrieval, and never call the GitHub API
+	mockClient := llm.NewMockClient()
+	sandboxVectorStore := rag.NewEphemeralVectorStore(mockClient, repository, &cfg.VectorDB)
+	sandboxEvaluationService := services.NewEvaluationService(mockClient, mockClient, repository, sandboxVectorStore, scoringService, promptService, services.NewLanguageService(mockClient, promptService, cfg), services.NewModerationService(cfg, nil), nil, nil, cfg)
+
+	goldenService := services.NewGoldenService(repository, evaluationService)
+	calibrationService := services.NewCalibrationService(repository, goldenService, redisClient, &cfg.Calibration)
+	indexRebuilder := rag.NewIndexRebuilder(vectorStore, repository)
+	retentionService := services.NewRetentionService(repository, fileService, cfg)
+	objectStorage := services.NewObjectStorage(&cfg.Objects)
+	uploadCleanupService := services.NewUploadCleanupService(repository, fileService, objectStorage, &cfg.Upload)
+	erasureService := services.NewErasureService(repository, fileService, vectorStore)
+	fairnessService := services.NewFairnessService(repository, scoringService)
 
 	// Initialize handlers
-	uploadHandler := handlers.NewUploadHandler(fileService)
-	evaluationHandler := handlers.NewEvaluationHandler(repository, evaluationService, jobQueue, fileService)
+	uploadHandler := handlers.NewUploadHandler(repository, fileService, objectStorage)
+	evaluationHandler := handlers.NewEvaluationHandler(repository, evaluationService, sandboxEvaluationService, jobQueue, jobBuffer, fileService, cfg.JobQueue.DuplicateWindow, cfg.JobQueue.ResultCacheTTL)
+	adminHandler := handlers.NewAdminHandler(repository, dbInitService, goldenService, calibrationService, evaluationService, retentionService, fairnessService, indexRebuilder)
+	promptHandler := handlers.NewPromptHandler(repository, promptService, evaluationService, sandboxEvaluationService)
+	jobDescriptionHandler := handlers.NewJobDescriptionHandler(repository, vectorStore)
+	referenceDocumentHandler := handlers.NewReferenceDocumentHandler(repository, vectorStore)
+	healthHandler := handlers.NewHealthHandler(repository, redisClient, llmClient, jobBuffer, &cfg.Health, llmProvider, llmModel)
+	organizationHandler := handlers.NewOrganizationHandler(repository, &cfg.Tenancy)
+	if cfg.Tenancy.Enabled && cfg.Tenancy.AdminAPIKey == "" {
+		log.Println("Warning: MULTI_TENANT is enabled without ADMIN_API_KEY; organizations cannot be managed")
+	}
+	rubricHandler := handlers.NewRubricHandler(repository)
+	privacyHandler := handlers.NewPrivacyHandler(repository, erasureService)
+	experimentHandler := handlers.NewExperimentHandler(repository, experimentService)
+	docsHandler := handlers.NewDocsHandler()
 
 	// Setup routes
-	router := setupRoutes(uploadHandler, evaluationHandler)
+	router := setupRoutes(cfg.Tracing.ServiceName, uploadHandler, evaluationHandler, adminHandler, promptHandler, jobDescriptionHandler, referenceDocumentHandler, healthHandler, organizationHandler, rubricHandler, privacyHandler, experimentHandler, docsHandler)
+
+	// Start job queue processor in background; cancelling workerCtx stops it after the current jobs
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	workersDone := make(chan struct{})
+	go func() {
+		jobQueue.ProcessJobs(workerCtx)
+		close(workersDone)
+	}()
+
+	// Requeue jobs left in processing by a crashed worker
+	go jobQueue.ReapStuckJobs(workerCtx, cfg.JobQueue.ReaperInterval)
+
+	// Push jobs whose enqueue failed and jobs that went missing from the queue
+	go jobQueue.DispatchOutbox(workerCtx)
+	go jobQueue.SweepLostJobs(workerCtx)
+
+	// Queue scheduled evaluations when they come due
+	go jobQueue.RunScheduler(workerCtx)
+
+	// Erase old job documents and purge deleted jobs
+	go retentionService.Run(workerCtx)
+
+	// Re-evaluate the golden set and alert on drift
+	go calibrationService.Run(workerCtx)
 
-	// Start job queue processor in background
-	go jobQueue.ProcessJobs()
+	// Delete uploads no job uses and abandoned direct uploads
+	go uploadCleanupService.Run(workerCtx)
+
+	// Flush buffered jobs once the database recovers
+	flushDone := make(chan struct{})
+	go func() {
+		if jobBuffer != nil {
+			jobBuffer.FlushLoop(workerCtx, 5*time.Second)
+		}
+		close(flushDone)
+	}()
 
 	// Start server
 	server := &http.Server{
@@ -108,20 +453,49 @@ func main() {
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+
+	// Stop taking new jobs and let in-flight evaluations finish, bounded by the shutdown grace period
+	log.Println("Waiting for job workers to finish...")
+	stopWorkers()
+	select {
+	case <-workersDone:
+	case <-time.After(cfg.JobQueue.ShutdownGrace):
+		log.Println("Timed out waiting for job workers; unfinished jobs will be requeued by the reaper")
 	}
 
+	// The deferred repository and Redis closes run once main returns, after the final flush
+	<-flushDone
+
 	log.Println("Server exited")
 }
 
-func setupRoutes(uploadHandler *handlers.UploadHandler, evaluationHandler *handlers.EvaluationHandler) *gin.Engine {
+func setupRoutes(
+	serviceName string,
+	uploadHandler *handlers.UploadHandler,
+	evaluationHandler *handlers.EvaluationHandler,
+	adminHandler *handlers.AdminHandler,
+	promptHandler *handlers.PromptHandler,
+	jobDescriptionHandler *handlers.JobDescriptionHandler,
+	referenceDocumentHandler *handlers.ReferenceDocumentHandler,
+	healthHandler *handlers.HealthHandler,
+	organizationHandler *handlers.OrganizationHandler,
+	rubricHandler *handlers.RubricHandler,
+	privacyHandler *handlers.PrivacyHandler,
+	experimentHandler *handlers.ExperimentHandler,
+	docsHandler *handlers.DocsHandler,
+) *gin.Engine {
 	router := gin.Default()
 
+	// Trace every request, continuing a trace started by the caller's traceparent header
+	router.Use(otelgin.Middleware(serviceName))
+
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, Idempotency-Key, traceparent, tracestate")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -131,23 +505,115 @@ func setupRoutes(uploadHandler *handlers.UploadHandler, evaluationHandler *handl
 		c.Next()
 	})
 
+	router.NoRoute(handlers.RouteNotFound)
+
 	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	router.GET("/health", healthHandler.Health)
+	router.GET("/healthz", healthHandler.Live)
+	router.GET("/readyz", healthHandler.Ready)
+	router.GET("/version", healthHandler.Version)
+
+	// API documentation
+	router.GET("/openapi.yaml", docsHandler.OpenAPI)
+	router.GET("/docs", docsHandler.SwaggerUI)
 
-	// API routes
+	// API routes; with MULTI_TENANT=true every request needs an organization or admin API key
 	api := router.Group("/api/v1")
+	api.Use(organizationHandler.Authenticate())
 	{
 		// Upload routes
 		api.POST("/upload", uploadHandler.UploadFiles)
 		api.POST("/upload-with-content", uploadHandler.UploadFilesWithContent)
+		api.POST("/upload/presign", uploadHandler.PresignUpload)
+		api.POST("/upload/confirm", uploadHandler.ConfirmUpload)
+		api.GET("/uploads", uploadHandler.ListUploads)
+		api.GET("/uploads/:id", uploadHandler.GetUpload)
+		api.DELETE("/uploads/:id", uploadHandler.DeleteUpload)
 
 		// Evaluation routes
 		api.POST("/evaluate", evaluationHandler.StartEvaluation)
+		api.POST("/evaluate-inline", evaluationHandler.StartInlineEvaluation)
+		api.POST("/evaluate-upload", evaluationHandler.StartUploadEvaluation)
+		api.POST("/evaluate-text", evaluationHandler.StartTextEvaluation)
+		api.POST("/evaluate/batch", evaluationHandler.StartBatchEvaluation)
+		api.POST("/evaluate/batch/zip", evaluationHandler.StartArchiveBatchEvaluation)
+		api.POST("/parse", evaluationHandler.ParseResume)
+		api.GET("/batch/:id", evaluationHandler.GetBatch)
 		api.GET("/result/:id", evaluationHandler.GetResult)
+		api.POST("/results:method", evaluationHandler.BatchGetResults) // POST /results:batchGet
 		api.GET("/job/:id", evaluationHandler.GetJobStatus)
+		api.DELETE("/job/:id", evaluationHandler.DeleteJob)
+		api.POST("/job/:id/reevaluate", evaluationHandler.ReevaluateJob)
+		api.POST("/job/:id/forget", privacyHandler.ForgetJob)
+		api.POST("/job/:id/feedback-letter", evaluationHandler.GenerateFeedbackLetter)
 		api.GET("/jobs", evaluationHandler.ListJobs)
+		api.GET("/jobs/export", evaluationHandler.ExportJobs)
+		api.GET("/candidates/:id/evaluations/diff", evaluationHandler.DiffEvaluations)
+		api.POST("/candidates/:id/forget", privacyHandler.ForgetCandidate)
+		api.GET("/erasures", privacyHandler.ListErasures)
+
+		// Prompt template routes
+		api.GET("/prompts", promptHandler.ListPrompts)
+		api.GET("/prompts/:name", promptHandler.GetPrompt)
+		// Prompt templates are shared by every organization, so only the admin may change them
+		api.PUT("/prompts/:name", organizationHandler.RequireAdmin(), promptHandler.UpdatePrompt)
+		api.DELETE("/prompts/:name", organizationHandler.RequireAdmin(), promptHandler.DeletePrompt)
+		api.POST("/prompts/:name/preview", promptHandler.PreviewPrompt)
+		api.GET("/prompts/:name/versions", promptHandler.ListPromptVersions)
+		api.GET("/prompts/:name/versions/:version", promptHandler.GetPromptVersion)
+		api.POST("/prompts/:name/versions/:version/activate", organizationHandler.RequireAdmin(), promptHandler.ActivatePromptVersion)
+
+		// Job description routes
+		api.POST("/job-descriptions", jobDescriptionHandler.CreateJobDescription)
+		api.GET("/job-descriptions", jobDescriptionHandler.ListJobDescriptions)
+		api.GET("/job-descriptions/:id", jobDescriptionHandler.GetJobDescription)
+		api.PUT("/job-descriptions/:id", jobDescriptionHandler.UpdateJobDescription)
+		api.DELETE("/job-descriptions/:id", jobDescriptionHandler.DeleteJobDescription)
+		api.PUT("/job-descriptions/:id/recommendation-thresholds", jobDescriptionHandler.SetRecommendationThresholds)
+		api.DELETE("/job-descriptions/:id/recommendation-thresholds", jobDescriptionHandler.DeleteRecommendationThresholds)
+
+		// Reference documents retrieved as context alongside job descriptions
+		api.POST("/rag/documents", referenceDocumentHandler.CreateReferenceDocument)
+		api.GET("/rag/documents", referenceDocumentHandler.ListReferenceDocuments)
+		api.GET("/rag/documents/:id", referenceDocumentHandler.GetReferenceDocument)
+		api.DELETE("/rag/documents/:id", referenceDocumentHandler.DeleteReferenceDocument)
+
+		// Scoring rubric routes
+		api.POST("/rubrics", rubricHandler.CreateRubric)
+		api.GET("/rubrics", rubricHandler.ListRubrics)
+
+		// Current organization routes
+		api.GET("/organization", organizationHandler.GetCurrentOrganization)
+		api.PUT("/organization/defaults", organizationHandler.UpdateCurrentDefaults)
+
+		// Admin routes
+		admin := api.Group("/admin")
+		admin.Use(organizationHandler.RequireAdmin())
+		admin.POST("/organizations", organizationHandler.CreateOrganization)
+		admin.GET("/organizations", organizationHandler.ListOrganizations)
+		admin.PUT("/organizations/:id", organizationHandler.UpdateOrganization)
+		admin.POST("/jobs/bulk-delete", adminHandler.BulkDeleteJobs)
+		admin.POST("/jobs/purge", adminHandler.PurgeJobs)
+		admin.GET("/usage", adminHandler.GetUsage)
+		admin.GET("/llm-calls", adminHandler.ListLLMCalls)
+		admin.POST("/jobs/:id/replay", adminHandler.ReplayJob)
+		admin.POST("/sample-data", adminHandler.LoadSampleData)
+		admin.POST("/golden", adminHandler.AddGoldenJob)
+		admin.GET("/golden", adminHandler.ListGoldenJobs)
+		admin.DELETE("/golden/:id", adminHandler.DeleteGoldenJob)
+		admin.POST("/golden/compare", adminHandler.CompareGoldenJobs)
+		admin.POST("/golden/calibrations", adminHandler.RunCalibration)
+		admin.GET("/golden/calibrations", adminHandler.ListCalibrationRuns)
+		admin.POST("/vector-index/rebuild", adminHandler.RebuildVectorIndex)
+		admin.GET("/vector-index/rebuild", adminHandler.GetVectorIndexRebuild)
+		admin.POST("/fairness-reports", adminHandler.StartFairnessReport)
+		admin.GET("/fairness-reports", adminHandler.ListFairnessReports)
+		admin.GET("/fairness-reports/:id", adminHandler.GetFairnessReport)
+		admin.POST("/experiments", experimentHandler.StartExperiment)
+		admin.GET("/experiments", experimentHandler.ListExperiments)
+		admin.GET("/experiments/:id", experimentHandler.GetExperiment)
+		admin.POST("/experiments/:id/stop", experimentHandler.StopExperiment)
+		admin.GET("/experiments/:id/comparison", experimentHandler.CompareExperiment)
 	}
 
 	return router
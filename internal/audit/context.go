@@ -0,0 +1,30 @@
+package audit
+
+import "context"
+
+// labels identify the job and pipeline step an LLM call is made for
+type labels struct {
+	jobID string
+	step  string
+}
+
+type labelsKey struct{}
+
+// WithJob labels LLM calls made with the returned context with a job ID
+func WithJob(ctx context.Context, jobID string) context.Context {
+	l := labelsFrom(ctx)
+	l.jobID = jobID
+	return context.WithValue(ctx, labelsKey{}, l)
+}
+
+// WithStep labels LLM calls made with the returned context with a pipeline step
+func WithStep(ctx context.Context, step string) context.Context {
+	l := labelsFrom(ctx)
+	l.step = step
+	return context.WithValue(ctx, labelsKey{}, l)
+}
+
+func labelsFrom(ctx context.Context) labels {
+	l, _ := ctx.Value(labelsKey{}).(labels)
+	return l
+}
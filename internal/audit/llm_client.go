@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+	"time"
+
+	"ai-cv-summarize/internal/llm"
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+)
+
+// LLM call operations
+const (
+	OperationEmbedding            = "embedding"
+	OperationEmbeddingBatch       = "embedding_batch"
+	OperationCompletion           = "completion"
+	OperationStructuredCompletion = "structured_completion"
+	OperationSchemaCompletion     = "schema_completion"
+)
+
+// WrapLLMClient records every call made through the client in the llm_calls collection. model is the
+// completion model the client is configured with; the model reported with the token usage takes precedence.
+// Prompts and responses are truncated to maxContent bytes, and left out when it is 0.
+func WrapLLMClient(next llm.LLMClient, repository repositories.Repository, model string, maxContent int) llm.LLMClient {
+	return &llmClient{next: next, repository: repository, model: model, maxContent: maxContent}
+}
+
+// llmClient wraps an LLM client with call logging
+type llmClient struct {
+	next       llm.LLMClient
+	repository repositories.Repository
+	model      string
+	maxContent int
+}
+
+// PromptHash identifies a prompt without storing it
+func PromptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// record runs one call and saves it. Saving is best effort, so failures are only logged.
+func (c *llmClient) record(ctx context.Context, call models.LLMCall, prompt string, fn func(ctx context.Context) (string, error)) (string, error) {
+	callCtx, recorder := llm.WithUsageRecorder(ctx)
+	startedAt := time.Now()
+	response, err := fn(callCtx)
+	call.LatencyMs = time.Since(startedAt).Milliseconds()
+	call.CreatedAt = startedAt
+
+	l := labelsFrom(ctx)
+	call.JobID, call.Step = l.jobID, l.step
+	call.PromptHash = PromptHash(prompt)
+	call.Prompt, call.PromptTruncated = c.truncate(prompt)
+	call.Response, call.ResponseTruncated = c.truncate(response)
+	for _, usage := range recorder.Usage() {
+		call.Model = usage.Model
+		call.PromptTokens += usage.PromptTokens
+		call.CompletionTokens += usage.CompletionTokens
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	if saveErr := c.repository.CreateLLMCall(context.WithoutCancel(ctx), &call); saveErr != nil {
+		log.Printf("Warning: failed to record LLM call: %v", saveErr)
+	}
+	return response, err
+}
+
+// truncate shortens content to maxContent bytes without splitting a UTF-8 sequence
+func (c *llmClient) truncate(content string) (string, bool) {
+	if len(content) <= c.maxContent {
+		return content, false
+	}
+	return strings.ToValidUTF8(content[:c.maxContent], ""), true
+}
+
+func (c *llmClient) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	var embedding []float64
+	call := models.LLMCall{Operation: OperationEmbedding, Model: c.next.EmbeddingModel()}
+	_, err := c.record(ctx, call, text, func(ctx context.Context) (response string, err error) {
+		embedding, err = c.next.GenerateEmbedding(ctx, text)
+		return "", err
+	})
+	return embedding, err
+}
+
+// GenerateEmbeddings records a batch as one call, its texts separated by blank lines
+func (c *llmClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	var embeddings [][]float64
+	call := models.LLMCall{Operation: OperationEmbeddingBatch, Model: c.next.EmbeddingModel()}
+	_, err := c.record(ctx, call, strings.Join(texts, "\n\n"), func(ctx context.Context) (response string, err error) {
+		embeddings, err = c.next.GenerateEmbeddings(ctx, texts)
+		return "", err
+	})
+	return embeddings, err
+}
+
+func (c *llmClient) EmbeddingModel() string {
+	return c.next.EmbeddingModel()
+}
+
+func (c *llmClient) Ping(ctx context.Context) error {
+	return c.next.Ping(ctx)
+}
+
+func (c *llmClient) GenerateCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
+	call := models.LLMCall{Operation: OperationCompletion, Model: c.model, Temperature: &temperature}
+	return c.record(ctx, call, prompt, func(ctx context.Context) (string, error) {
+		return c.next.GenerateCompletion(ctx, prompt, temperature)
+	})
+}
+
+func (c *llmClient) GenerateStructuredCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
+	call := models.LLMCall{Operation: OperationStructuredCompletion, Model: c.model, Temperature: &temperature}
+	return c.record(ctx, call, prompt, func(ctx context.Context) (string, error) {
+		return c.next.GenerateStructuredCompletion(ctx, prompt, temperature)
+	})
+}
+
+func (c *llmClient) GenerateSchemaCompletion(ctx context.Context, prompt string, schema *llm.Schema, temperature float32) (string, error) {
+	call := models.LLMCall{Operation: OperationSchemaCompletion, Model: c.model, Temperature: &temperature, Schema: schema.Name}
+	return c.record(ctx, call, prompt, func(ctx context.Context) (string, error) {
+		return c.next.GenerateSchemaCompletion(ctx, prompt, schema, temperature)
+	})
+}
+
+// The retry variants retry through the wrapper so every attempt is recorded
+func (c *llmClient) GenerateCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return llm.Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateCompletion(ctx, prompt, temperature)
+	})
+}
+
+func (c *llmClient) GenerateStructuredCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return llm.Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateStructuredCompletion(ctx, prompt, temperature)
+	})
+}
+
+func (c *llmClient) GenerateSchemaCompletionWithRetry(ctx context.Context, prompt string, schema *llm.Schema, temperature float32, maxRetries int) (string, error) {
+	return llm.Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateSchemaCompletion(ctx, prompt, schema, temperature)
+	})
+}
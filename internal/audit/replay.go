@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"ai-cv-summarize/internal/llm"
+	"ai-cv-summarize/internal/models"
+)
+
+// ErrNoRecording is returned by a replay client for a request it has no usable recorded response for
+var ErrNoRecording = errors.New("no recorded LLM response")
+
+// ReplayClient answers LLM requests from recorded calls instead of a provider. A request is matched to
+// the latest successful call with the same prompt hash, falling back to the latest successful call of the
+// same pipeline step when the prompt has changed since the recording. Embeddings come from the mock
+// client, since recorded calls do not keep vectors.
+type ReplayClient struct {
+	byHash     map[string]*models.LLMCall
+	byStep     map[string]*models.LLMCall
+	truncated  map[string]bool
+	embeddings llm.LLMClient
+
+	mu      sync.Mutex
+	changed map[string]bool
+}
+
+// NewReplayClient builds a replay client from recorded calls, as returned by the repository (newest first)
+func NewReplayClient(calls []*models.LLMCall) *ReplayClient {
+	c := &ReplayClient{
+		byHash:     make(map[string]*models.LLMCall),
+		byStep:     make(map[string]*models.LLMCall),
+		truncated:  make(map[string]bool),
+		embeddings: llm.NewMockClient(),
+		changed:    make(map[string]bool),
+	}
+
+	for _, call := range calls {
+		if call.Operation == OperationEmbedding || call.Operation == OperationEmbeddingBatch || call.Error != "" {
+			continue
+		}
+		// A cut-off response cannot be replayed; remember it so the error can say why
+		if call.ResponseTruncated {
+			c.truncated[call.Step] = true
+			continue
+		}
+		if _, ok := c.byHash[call.PromptHash]; !ok {
+			c.byHash[call.PromptHash] = call
+		}
+		if _, ok := c.byStep[call.Step]; !ok && call.Step != "" {
+			c.byStep[call.Step] = call
+		}
+	}
+
+	return c
+}
+
+// ChangedSteps lists the steps whose prompts differed from the recording, so their recorded responses
+// were replayed for a different prompt
+func (c *ReplayClient) ChangedSteps() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	steps := make([]string, 0, len(c.changed))
+	for step := range c.changed {
+		steps = append(steps, step)
+	}
+	sort.Strings(steps)
+	return steps
+}
+
+// respond returns the recorded response for a prompt
+func (c *ReplayClient) respond(ctx context.Context, prompt string) (string, error) {
+	if call, ok := c.byHash[PromptHash(prompt)]; ok {
+		return call.Response, nil
+	}
+
+	step := labelsFrom(ctx).step
+	if call, ok := c.byStep[step]; ok {
+		c.mu.Lock()
+		c.changed[step] = true
+		c.mu.Unlock()
+		return call.Response, nil
+	}
+
+	if c.truncated[step] {
+		return "", fmt.Errorf("%w for step %q: the recorded response was truncated, raise LLM_AUDIT_MAX_CONTENT", ErrNoRecording, step)
+	}
+	return "", fmt.Errorf("%w for step %q", ErrNoRecording, step)
+}
+
+func (c *ReplayClient) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	return c.embeddings.GenerateEmbedding(ctx, text)
+}
+
+func (c *ReplayClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	return c.embeddings.GenerateEmbeddings(ctx, texts)
+}
+
+func (c *ReplayClient) EmbeddingModel() string {
+	return c.embeddings.EmbeddingModel()
+}
+
+func (c *ReplayClient) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (c *ReplayClient) GenerateCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
+	return c.respond(ctx, prompt)
+}
+
+func (c *ReplayClient) GenerateStructuredCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
+	return c.respond(ctx, prompt)
+}
+
+func (c *ReplayClient) GenerateSchemaCompletion(ctx context.Context, prompt string, schema *llm.Schema, temperature float32) (string, error) {
+	return c.respond(ctx, prompt)
+}
+
+// Recorded responses do not change, so the retry variants make a single attempt
+func (c *ReplayClient) GenerateCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return c.respond(ctx, prompt)
+}
+
+func (c *ReplayClient) GenerateStructuredCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return c.respond(ctx, prompt)
+}
+
+func (c *ReplayClient) GenerateSchemaCompletionWithRetry(ctx context.Context, prompt string, schema *llm.Schema, temperature float32, maxRetries int) (string, error) {
+	return c.respond(ctx, prompt)
+}
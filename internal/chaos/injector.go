@@ -0,0 +1,102 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/llm"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// ErrInjectedRedisFailure is returned by Redis commands failed on purpose
+var ErrInjectedRedisFailure = errors.New("chaos: injected redis failure")
+
+// Injector decides when to inject faults based on the configured rates.
+// It is only meant for staging environments exercising retry and recovery paths.
+type Injector struct {
+	config *config.ChaosConfig
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func NewInjector(cfg *config.ChaosConfig) *Injector {
+	log.Printf("WARNING: fault injection enabled (llm timeout %.2f, redis error %.2f, mongo latency %.2f/%s)",
+		cfg.LLMTimeoutRate, cfg.RedisErrorRate, cfg.MongoLatencyRate, cfg.MongoLatency)
+
+	return &Injector{
+		config: cfg,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// hit reports whether a fault with the given rate should be injected now
+func (i *Injector) hit(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.rand.Float64() < rate
+}
+
+// MongoMonitor returns a command monitor that delays MongoDB commands at the configured rate.
+// Started events are published synchronously before a command is sent, so sleeping there adds latency.
+func (i *Injector) MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			if i.hit(i.config.MongoLatencyRate) {
+				time.Sleep(i.config.MongoLatency)
+			}
+		},
+	}
+}
+
+// RedisHook returns a go-redis hook failing commands at the configured rate
+func (i *Injector) RedisHook() redis.Hook {
+	return redisHook{injector: i}
+}
+
+type redisHook struct {
+	injector *Injector
+}
+
+func (h redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if h.injector.hit(h.injector.config.RedisErrorRate) {
+			cmd.SetErr(ErrInjectedRedisFailure)
+			return ErrInjectedRedisFailure
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if h.injector.hit(h.injector.config.RedisErrorRate) {
+			return ErrInjectedRedisFailure
+		}
+		return next(ctx, cmds)
+	}
+}
+
+// WrapLLMClient returns an LLM client that times out calls at the configured rate
+func (i *Injector) WrapLLMClient(client llm.LLMClient) llm.LLMClient {
+	return &llmClient{next: client, injector: i}
+}
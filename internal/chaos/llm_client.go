@@ -0,0 +1,83 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+
+	"ai-cv-summarize/internal/llm"
+)
+
+// llmClient wraps an LLM client and fails calls with a timeout at the configured rate
+type llmClient struct {
+	next     llm.LLMClient
+	injector *Injector
+}
+
+func (c *llmClient) fault() error {
+	if c.injector.hit(c.injector.config.LLMTimeoutRate) {
+		return fmt.Errorf("chaos: injected llm timeout: %w", context.DeadlineExceeded)
+	}
+	return nil
+}
+
+func (c *llmClient) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	if err := c.fault(); err != nil {
+		return nil, err
+	}
+	return c.next.GenerateEmbedding(ctx, text)
+}
+
+func (c *llmClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	if err := c.fault(); err != nil {
+		return nil, err
+	}
+	return c.next.GenerateEmbeddings(ctx, texts)
+}
+
+func (c *llmClient) EmbeddingModel() string {
+	return c.next.EmbeddingModel()
+}
+
+func (c *llmClient) Ping(ctx context.Context) error {
+	return c.next.Ping(ctx)
+}
+
+func (c *llmClient) GenerateCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
+	if err := c.fault(); err != nil {
+		return "", err
+	}
+	return c.next.GenerateCompletion(ctx, prompt, temperature)
+}
+
+func (c *llmClient) GenerateStructuredCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
+	if err := c.fault(); err != nil {
+		return "", err
+	}
+	return c.next.GenerateStructuredCompletion(ctx, prompt, temperature)
+}
+
+func (c *llmClient) GenerateSchemaCompletion(ctx context.Context, prompt string, schema *llm.Schema, temperature float32) (string, error) {
+	if err := c.fault(); err != nil {
+		return "", err
+	}
+	return c.next.GenerateSchemaCompletion(ctx, prompt, schema, temperature)
+}
+
+// The retry variants retry through the wrapper so injected faults exercise the retry loop
+func (c *llmClient) GenerateCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return llm.Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateCompletion(ctx, prompt, temperature)
+	})
+}
+
+func (c *llmClient) GenerateStructuredCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return llm.Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateStructuredCompletion(ctx, prompt, temperature)
+	})
+}
+
+func (c *llmClient) GenerateSchemaCompletionWithRetry(ctx context.Context, prompt string, schema *llm.Schema, temperature float32, maxRetries int) (string, error) {
+	return llm.Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateSchemaCompletion(ctx, prompt, schema, temperature)
+	})
+}
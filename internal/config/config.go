@@ -1,22 +1,48 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server     ServerConfig
-	MongoDB    MongoDBConfig
-	Redis      RedisConfig
-	OpenAI     OpenAIConfig
-	OpenRouter OpenRouterConfig
-	VectorDB   VectorDBConfig
-	Upload     UploadConfig
-	JobQueue   JobQueueConfig
+	Server      ServerConfig
+	MongoDB     MongoDBConfig
+	Storage     StorageConfig
+	Postgres    PostgresConfig
+	Redis       RedisConfig
+	LLM         LLMConfig
+	OpenAI      OpenAIConfig
+	OpenRouter  OpenRouterConfig
+	Gemini      GeminiConfig
+	VectorDB    VectorDBConfig
+	Upload      UploadConfig
+	Objects     ObjectStorageConfig
+	OCR         OCRConfig
+	Antivirus   AntivirusConfig
+	JobQueue    JobQueueConfig
+	Language    LanguageConfig
+	Chaos       ChaosConfig
+	Tenancy     TenancyConfig
+	Tracing     TracingConfig
+	Pricing     PricingConfig
+	Health      HealthConfig
+	Judge       JudgeConfig
+	Feedback    FeedbackConfig
+	Hiring      HiringConfig
+	Audit       AuditConfig
+	Embeddings  EmbeddingsConfig
+	Retention   RetentionConfig
+	Calibration CalibrationConfig
+	Privacy     PrivacyConfig
+	Moderation  ModerationConfig
+	GitHub      GitHubConfig
+	Plagiarism  PlagiarismConfig
 }
 
 type ServerConfig struct {
@@ -29,35 +55,380 @@ type MongoDBConfig struct {
 	Database string
 }
 
+type StorageConfig struct {
+	Backend string
+	Path    string
+}
+
+// PostgresConfig is used by the postgres storage backend and the pgvector vector database
+type PostgresConfig struct {
+	URL string
+}
+
 type RedisConfig struct {
 	URL string
 }
 
+// LLMConfig selects the LLM provider
+type LLMConfig struct {
+	// Provider is auto (the first of OpenAI, OpenRouter and Gemini with an API key), openai, openrouter, gemini or mock
+	Provider string
+	// ContextWindow is the tokens a prompt and its completion may use together; 0 looks it up from the model
+	ContextWindow int
+}
+
 type OpenAIConfig struct {
-	APIKey  string
-	BaseURL string
-	Model   string
+	APIKey         string
+	BaseURL        string
+	Model          string
+	EmbeddingModel string
+
+	// RequestsPerMinute and TokensPerMinute keep calls below the account's rate limits; zero disables each
+	RequestsPerMinute int
+	TokensPerMinute   int
 }
 
 type OpenRouterConfig struct {
+	APIKey         string
+	BaseURL        string
+	Model          string
+	EmbeddingModel string
+
+	// RequestsPerMinute and TokensPerMinute keep calls below the account's rate limits; zero disables each
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+type GeminiConfig struct {
 	APIKey  string
 	BaseURL string
-	Model   string
+	// Model is a Gemini model, or an OpenAI model name mapped to the Gemini model of the same tier
+	Model          string
+	EmbeddingModel string
+
+	// RequestsPerMinute and TokensPerMinute keep calls below the account's rate limits; zero disables each
+	RequestsPerMinute int
+	TokensPerMinute   int
 }
 
 type VectorDBConfig struct {
+	Backend    string // "scan" (MongoDB scan), "hnsw" (in-process graph), "qdrant" or "pgvector"
 	URL        string
+	APIKey     string
 	Collection string
+
+	// Documents are split into chunks of about ChunkSize tokens whose first ChunkOverlap tokens repeat the end
+	// of the previous chunk. Retrieval keeps the TopK best chunks (or whole job descriptions) per query scoring
+	// at least SimilarityThreshold, up to MaxContextTokens in total. Requests can override the last three.
+	ChunkSize           int
+	ChunkOverlap        int
+	TopK                int
+	SimilarityThreshold float64
+	MaxContextTokens    int
+
+	// The hnsw backend links each vector to HNSWM neighbours per level, considers HNSWEfConstruction
+	// candidates when inserting and HNSWEfSearch when searching; larger values trade speed for recall
+	HNSWM              int
+	HNSWEfConstruction int
+	HNSWEfSearch       int
 }
 
 type UploadConfig struct {
 	MaxFileSize int64
-	UploadDir   string
+	// MaxArchiveSize caps ZIP archives of applicant pools; each file inside is still capped by MaxFileSize
+	MaxArchiveSize int64
+	UploadDir      string
+	// OrphanTTL is how long an uploaded file may go unused by any evaluation job before it is deleted,
+	// along with abandoned direct uploads; 0 keeps them
+	OrphanTTL time.Duration
+	// CleanupInterval is how often orphaned uploads are looked for
+	CleanupInterval time.Duration
+}
+
+// ObjectStorageConfig configures the S3-compatible bucket browsers upload large files to directly
+type ObjectStorageConfig struct {
+	// Bucket enables direct uploads when set
+	Bucket string
+	// Prefix is prepended to the key of every uploaded object
+	Prefix string
+	// Endpoint defaults to the AWS S3 endpoint of Region; use https://storage.googleapis.com for Google Cloud Storage
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle puts the bucket in the URL path instead of the host name, as MinIO and some gateways require
+	PathStyle     bool
+	PresignExpiry time.Duration
+	Timeout       time.Duration
+}
+
+// OCRConfig controls text recognition for scanned PDFs, run with the Tesseract and pdftoppm binaries
+type OCRConfig struct {
+	Enabled bool
+	// MinTextLength is the number of letters below which a PDF is treated as scanned and OCRed
+	MinTextLength int
+	// Languages are the Tesseract language codes to recognize, e.g. "eng+ind"
+	Languages     string
+	TesseractPath string
+	PDFToPPMPath  string
+	DPI           int
+	MaxPages      int
+	Timeout       time.Duration
+}
+
+// AntivirusConfig controls scanning uploads with a ClamAV daemon
+type AntivirusConfig struct {
+	Enabled bool
+	// Address is clamd's TCP address (host:port) or, prefixed with unix:, the path of its socket
+	Address string
+	Timeout time.Duration
+	// QuarantineDir keeps infected uploads out of the upload directory for inspection
+	QuarantineDir string
 }
 
 type JobQueueConfig struct {
 	Timeout    time.Duration
 	MaxRetries int
+	Backend    string
+	BufferTTL  time.Duration
+
+	// Concurrency is the number of workers processing jobs in parallel
+	Concurrency int
+
+	// ClaimTimeout is how long a job popped from the Redis stream may stay unacked before another instance takes it over
+	ClaimTimeout time.Duration
+
+	// ReaperInterval is how often jobs stuck in processing past Timeout are requeued
+	ReaperInterval time.Duration
+
+	// DispatchInterval is how often jobs whose enqueue failed are pushed to the queue again, and how long a
+	// new job is left to its request before the dispatcher takes over
+	DispatchInterval time.Duration
+
+	// SchedulerInterval is how often scheduled jobs that are due are moved onto the queue
+	SchedulerInterval time.Duration
+
+	// SweepInterval is how often queued jobs missing from the queue backend are re-enqueued; a queued job
+	// must also be untouched for this long before it is considered lost
+	SweepInterval time.Duration
+
+	// DuplicateWindow is how far back a resubmitted CV returns the prior job; zero disables detection
+	DuplicateWindow time.Duration
+
+	// ResultCacheTTL is how long a completed result is returned for resubmissions of the same content; zero disables it
+	ResultCacheTTL time.Duration
+
+	// ShutdownGrace is how long shutdown waits for in-flight evaluations before leaving them to the reaper
+	ShutdownGrace time.Duration
+}
+
+// minShutdownGrace is the shortest wait for in-flight evaluations on shutdown, so a zero JOB_TIMEOUT
+// (no evaluation timeout) does not skip the drain
+const minShutdownGrace = 30 * time.Second
+
+// LanguageConfig lists the document languages evaluated directly; others are rejected or translated
+type LanguageConfig struct {
+	Supported          []string
+	TranslationEnabled bool
+}
+
+// ChaosConfig controls fault injection for resilience testing; never enable in production
+type ChaosConfig struct {
+	Enabled          bool
+	LLMTimeoutRate   float64
+	RedisErrorRate   float64
+	MongoLatencyRate float64
+	MongoLatency     time.Duration
+}
+
+// TenancyConfig controls API key authentication and per-organization data isolation
+type TenancyConfig struct {
+	// Enabled requires an organization or admin API key on every API request
+	Enabled bool
+	// AdminAPIKey grants unscoped access, including the admin and organization management endpoints
+	AdminAPIKey string
+}
+
+// TracingConfig controls OpenTelemetry tracing; the OTLP endpoint and headers come from the standard OTEL_EXPORTER_OTLP_* variables
+type TracingConfig struct {
+	// Exporter is "none" (tracing disabled), "otlp" or "stdout"
+	Exporter    string
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded; traces continued from a parent follow its decision
+	SampleRatio float64
+}
+
+// HealthConfig controls the dependency checks behind /readyz
+type HealthConfig struct {
+	// CheckTimeout bounds each dependency check
+	CheckTimeout time.Duration
+	// CheckLLM adds the LLM provider to the readiness checks
+	CheckLLM bool
+}
+
+// Verification modes of the judge
+const (
+	// JudgeModeFlag marks results the judge finds inconsistent as needing review
+	JudgeModeFlag = "flag"
+	// JudgeModeCorrect applies the judge's corrected scores and feedback, flagging only what it could not correct
+	JudgeModeCorrect = "correct"
+)
+
+// JudgeConfig controls the optional verification pass in which a second model reviews each evaluation
+type JudgeConfig struct {
+	Enabled bool
+	// Model is the judge's model on the active provider; empty uses the evaluation model
+	Model string
+	Mode  string
+}
+
+// Feedback verbosities
+const (
+	// FeedbackVerbosityDetailed writes feedback as a narrative explaining the scores
+	FeedbackVerbosityDetailed = "detailed"
+	// FeedbackVerbosityBullets writes feedback as a short bullet list
+	FeedbackVerbosityBullets = "bullets"
+)
+
+// Feedback tones
+const (
+	// FeedbackToneInternal writes candid notes for the hiring team
+	FeedbackToneInternal = "internal"
+	// FeedbackToneCandidate writes constructive feedback that can be shared with the candidate
+	FeedbackToneCandidate = "candidate"
+)
+
+// Feedback letter tones
+const (
+	// LetterToneWarm writes feedback letters in a warm, personal voice
+	LetterToneWarm = "warm"
+	// LetterToneFormal writes feedback letters in a formal, professional register
+	LetterToneFormal = "formal"
+)
+
+// FeedbackConfig holds the default style of the feedback and summary of evaluations, and the defaults of
+// the letters drafted from them; requests can override both
+type FeedbackConfig struct {
+	Verbosity string
+	Tone      string
+
+	LetterTone string
+	// LetterCompany and LetterSender name the company and the person signing letters; either may be empty
+	LetterCompany string
+	LetterSender  string
+}
+
+// HiringConfig holds the default recommendation thresholds: the overall scores, as fractions of the overall
+// scale's maximum, from which candidates are recommended as a strong hire or a hire. Job descriptions can
+// have their own.
+type HiringConfig struct {
+	StrongHireThreshold float64
+	HireThreshold       float64
+}
+
+// EmbeddingsConfig controls how embeddings are requested and cached
+type EmbeddingsConfig struct {
+	// Provider is empty to embed with the LLM provider, or onnx for a local model
+	Provider string
+	// ONNXModelPath is the directory of an ONNX sentence-transformer model, with model.onnx and vocab.txt
+	ONNXModelPath string
+	// ONNXRuntimeLibrary is the onnxruntime shared library to load; empty uses the system default
+	ONNXRuntimeLibrary string
+	// ONNXMaxSequenceLength is the most tokens of a text the local model sees; the rest is ignored
+	ONNXMaxSequenceLength int
+
+	CacheEnabled bool
+	CacheTTL     time.Duration
+	// Most texts sent in one embeddings request
+	BatchSize int
+	// Most embeddings requests in flight at once for a single call
+	Concurrency int
+}
+
+// RetentionConfig controls how long job documents are kept
+type RetentionConfig struct {
+	// Days after which finished jobs lose their documents and uploaded files, and soft-deleted jobs
+	// are purged; 0 keeps everything
+	Days int
+	// Interval is how often the retention policy is applied
+	Interval time.Duration
+}
+
+// CalibrationConfig controls the scheduled re-evaluation of the golden set
+type CalibrationConfig struct {
+	// Interval is how often the golden set is re-evaluated; 0 disables scheduled runs
+	Interval time.Duration
+	// Tolerance is the largest score change allowed for golden jobs without expected ranges
+	Tolerance float64
+	// WebhookURL receives a JSON alert when a run finds drift; drift is always logged
+	WebhookURL string
+}
+
+// PrivacyConfig controls how candidate personal data is handled during evaluation
+type PrivacyConfig struct {
+	// RedactPII replaces names, contact details, addresses and photo references with placeholders
+	// before documents are sent to the LLM provider
+	RedactPII bool
+}
+
+// ModerationConfig controls the content check documents pass before they are evaluated
+type ModerationConfig struct {
+	// Provider is none, local (built-in rules) or openai (the OpenAI moderation endpoint plus the local rules)
+	Provider string
+	// BlockedTerms are rejected by the local rules in addition to the built-in ones, matched as whole words
+	BlockedTerms []string
+}
+
+// GitHubConfig controls the analysis of a candidate's GitHub repository for project evaluation
+type GitHubConfig struct {
+	Enabled bool
+	// Token raises the API rate limit from 60 to 5000 requests an hour and allows private repositories
+	Token   string
+	APIURL  string
+	Timeout time.Duration
+	// MaxRepos caps the repositories analyzed when a username rather than a repository is given
+	MaxRepos int
+}
+
+// PlagiarismConfig controls the comparison of project reports with those submitted before
+type PlagiarismConfig struct {
+	Enabled bool
+	// Threshold is the cosine similarity from which two project reports are reported as similar
+	Threshold float64
+	// MaxMatches caps the similar projects listed on a result
+	MaxMatches int
+}
+
+// AuditConfig controls the llm_calls audit log
+type AuditConfig struct {
+	Enabled bool
+	// MaxContent caps the stored prompt and response, in bytes; 0 stores only the prompt hash
+	MaxContent int
+}
+
+// PricingConfig holds the model prices used to estimate the cost of evaluations
+type PricingConfig struct {
+	// Models maps a model name to its price; dated versions such as gpt-4-0613 use the longest matching name
+	Models map[string]ModelPrice
+}
+
+// ModelPrice is a model's USD price per 1K tokens
+type ModelPrice struct {
+	Prompt     float64
+	Completion float64
+}
+
+// defaultModelPrices are list prices at the time of writing; override or extend them with LLM_PRICING
+var defaultModelPrices = map[string]ModelPrice{
+	"gpt-4":                  {Prompt: 0.03, Completion: 0.06},
+	"gpt-4-32k":              {Prompt: 0.06, Completion: 0.12},
+	"gpt-4-turbo":            {Prompt: 0.01, Completion: 0.03},
+	"gpt-4o":                 {Prompt: 0.0025, Completion: 0.01},
+	"gpt-4o-mini":            {Prompt: 0.00015, Completion: 0.0006},
+	"gpt-3.5-turbo":          {Prompt: 0.0005, Completion: 0.0015},
+	"text-embedding-ada-002": {Prompt: 0.0001},
+	"text-embedding-3-small": {Prompt: 0.00002},
+	"text-embedding-3-large": {Prompt: 0.00013},
 }
 
 func Load() (*Config, error) {
@@ -66,7 +437,140 @@ func Load() (*Config, error) {
 
 	timeout, _ := strconv.Atoi(getEnv("JOB_TIMEOUT", "300"))
 	maxRetries, _ := strconv.Atoi(getEnv("MAX_RETRIES", "3"))
+	bufferTTL, _ := strconv.Atoi(getEnv("DEGRADED_BUFFER_TTL", "900"))
+	duplicateWindow, _ := strconv.Atoi(getEnv("DUPLICATE_WINDOW", "86400"))
+	resultCacheTTL, _ := strconv.Atoi(getEnv("RESULT_CACHE_TTL", "2592000"))
+	workerConcurrency, _ := strconv.Atoi(getEnv("WORKER_CONCURRENCY", "4"))
+	reaperInterval, _ := strconv.Atoi(getEnv("REAPER_INTERVAL", "60"))
+	dispatchInterval, _ := strconv.Atoi(getEnv("QUEUE_DISPATCH_INTERVAL", "10"))
+	sweepInterval, _ := strconv.Atoi(getEnv("QUEUE_SWEEP_INTERVAL", "300"))
+	schedulerInterval, _ := strconv.Atoi(getEnv("QUEUE_SCHEDULER_INTERVAL", "5"))
+	claimTimeout, _ := strconv.Atoi(getEnv("QUEUE_CLAIM_TIMEOUT", "600"))
+	// Defaults to JOB_TIMEOUT, long enough for every in-flight evaluation to finish
+	shutdownGrace, _ := strconv.Atoi(getEnv("SHUTDOWN_GRACE_PERIOD", strconv.Itoa(timeout)))
 	maxFileSize, _ := strconv.ParseInt(getEnv("MAX_FILE_SIZE", "10485760"), 10, 64)
+	maxArchiveSize, _ := strconv.ParseInt(getEnv("MAX_ARCHIVE_SIZE", "104857600"), 10, 64)
+	uploadOrphanTTL, _ := strconv.Atoi(getEnv("UPLOAD_ORPHAN_TTL", "86400"))
+	uploadCleanupInterval, _ := strconv.Atoi(getEnv("UPLOAD_CLEANUP_INTERVAL", "3600"))
+	objectStorageRegion := getEnv("OBJECT_STORAGE_REGION", "us-east-1")
+	objectStoragePathStyle, _ := strconv.ParseBool(getEnv("OBJECT_STORAGE_PATH_STYLE", "false"))
+	presignExpiry, _ := strconv.Atoi(getEnv("OBJECT_STORAGE_PRESIGN_EXPIRY", "900"))
+	objectStorageTimeout, _ := strconv.Atoi(getEnv("OBJECT_STORAGE_TIMEOUT", "60"))
+	ocrEnabled, _ := strconv.ParseBool(getEnv("OCR_ENABLED", "false"))
+	ocrMinTextLength, _ := strconv.Atoi(getEnv("OCR_MIN_TEXT_LENGTH", "100"))
+	ocrDPI, _ := strconv.Atoi(getEnv("OCR_DPI", "300"))
+	ocrMaxPages, _ := strconv.Atoi(getEnv("OCR_MAX_PAGES", "10"))
+	ocrTimeout, _ := strconv.Atoi(getEnv("OCR_TIMEOUT", "120"))
+	clamAVEnabled, _ := strconv.ParseBool(getEnv("CLAMAV_ENABLED", "false"))
+	clamAVTimeout, _ := strconv.Atoi(getEnv("CLAMAV_TIMEOUT", "60"))
+	translationEnabled, _ := strconv.ParseBool(getEnv("TRANSLATION_ENABLED", "false"))
+	openAIRequestsPerMinute, _ := strconv.Atoi(getEnv("OPENAI_REQUESTS_PER_MINUTE", "0"))
+	openAITokensPerMinute, _ := strconv.Atoi(getEnv("OPENAI_TOKENS_PER_MINUTE", "0"))
+	openRouterRequestsPerMinute, _ := strconv.Atoi(getEnv("OPENROUTER_REQUESTS_PER_MINUTE", "0"))
+	openRouterTokensPerMinute, _ := strconv.Atoi(getEnv("OPENROUTER_TOKENS_PER_MINUTE", "0"))
+	geminiRequestsPerMinute, _ := strconv.Atoi(getEnv("GEMINI_REQUESTS_PER_MINUTE", "0"))
+	geminiTokensPerMinute, _ := strconv.Atoi(getEnv("GEMINI_TOKENS_PER_MINUTE", "0"))
+
+	chaosEnabled, _ := strconv.ParseBool(getEnv("CHAOS_ENABLED", "false"))
+	chaosLLMTimeoutRate, _ := strconv.ParseFloat(getEnv("CHAOS_LLM_TIMEOUT_RATE", "0"), 64)
+	chaosRedisErrorRate, _ := strconv.ParseFloat(getEnv("CHAOS_REDIS_ERROR_RATE", "0"), 64)
+	chaosMongoLatencyRate, _ := strconv.ParseFloat(getEnv("CHAOS_MONGO_LATENCY_RATE", "0"), 64)
+	chaosMongoLatency, _ := strconv.Atoi(getEnv("CHAOS_MONGO_LATENCY_MS", "500"))
+	multiTenant, _ := strconv.ParseBool(getEnv("MULTI_TENANT", "false"))
+	tracingSampleRatio, _ := strconv.ParseFloat(getEnv("TRACING_SAMPLE_RATIO", "1"), 64)
+	readinessTimeout, _ := strconv.Atoi(getEnv("READINESS_TIMEOUT_MS", "2000"))
+	readinessCheckLLM, _ := strconv.ParseBool(getEnv("READINESS_CHECK_LLM", "false"))
+	modelPrices, err := parseModelPrices(getEnv("LLM_PRICING", ""))
+	if err != nil {
+		return nil, err
+	}
+	provider := getEnv("LLM_PROVIDER", "auto")
+	switch provider {
+	case "auto", "openai", "openrouter", "gemini", "mock":
+	default:
+		return nil, fmt.Errorf("invalid LLM_PROVIDER %q, must be auto, openai, openrouter, gemini or mock", provider)
+	}
+	contextWindow, _ := strconv.Atoi(getEnv("LLM_CONTEXT_WINDOW", "0"))
+	judgeEnabled, _ := strconv.ParseBool(getEnv("JUDGE_ENABLED", "false"))
+	auditEnabled, _ := strconv.ParseBool(getEnv("LLM_AUDIT_ENABLED", "true"))
+	embeddingProvider := getEnv("EMBEDDING_PROVIDER", "")
+	switch embeddingProvider {
+	case "", "onnx":
+	default:
+		return nil, fmt.Errorf("invalid EMBEDDING_PROVIDER %q, must be empty or onnx", embeddingProvider)
+	}
+	onnxMaxSequenceLength, _ := strconv.Atoi(getEnv("ONNX_MAX_SEQUENCE_LENGTH", "256"))
+	embeddingCacheEnabled, _ := strconv.ParseBool(getEnv("EMBEDDING_CACHE_ENABLED", "true"))
+	embeddingCacheTTL, _ := strconv.Atoi(getEnv("EMBEDDING_CACHE_TTL", "2592000"))
+	embeddingBatchSize, _ := strconv.Atoi(getEnv("EMBEDDING_BATCH_SIZE", "100"))
+	embeddingConcurrency, _ := strconv.Atoi(getEnv("EMBEDDING_CONCURRENCY", "4"))
+	auditMaxContent, _ := strconv.Atoi(getEnv("LLM_AUDIT_MAX_CONTENT", "4000"))
+	retentionDays, _ := strconv.Atoi(getEnv("RETENTION_DAYS", "0"))
+	githubEnabled, _ := strconv.ParseBool(getEnv("GITHUB_ANALYSIS_ENABLED", "true"))
+	githubTimeout, _ := strconv.Atoi(getEnv("GITHUB_TIMEOUT", "15"))
+	githubMaxRepos, _ := strconv.Atoi(getEnv("GITHUB_MAX_REPOS", "3"))
+	plagiarismEnabled, _ := strconv.ParseBool(getEnv("PLAGIARISM_CHECK_ENABLED", "true"))
+	plagiarismThreshold, _ := strconv.ParseFloat(getEnv("PLAGIARISM_THRESHOLD", "0.95"), 64)
+	plagiarismMaxMatches, _ := strconv.Atoi(getEnv("PLAGIARISM_MAX_MATCHES", "5"))
+	retentionInterval, _ := strconv.Atoi(getEnv("RETENTION_INTERVAL", "3600"))
+	calibrationInterval, _ := strconv.Atoi(getEnv("CALIBRATION_INTERVAL", "0"))
+	calibrationTolerance, _ := strconv.ParseFloat(getEnv("CALIBRATION_TOLERANCE", "0.5"), 64)
+	redactPII, _ := strconv.ParseBool(getEnv("PII_REDACTION_ENABLED", "false"))
+	chunkSize, _ := strconv.Atoi(getEnv("RAG_CHUNK_SIZE", "300"))
+	chunkOverlap, _ := strconv.Atoi(getEnv("RAG_CHUNK_OVERLAP", "50"))
+	ragTopK, _ := strconv.Atoi(getEnv("RAG_TOP_K", "4"))
+	maxContextTokens, _ := strconv.Atoi(getEnv("RAG_MAX_CONTEXT_TOKENS", "1500"))
+	similarityThreshold, _ := strconv.ParseFloat(getEnv("RAG_SIMILARITY_THRESHOLD", "0"), 64)
+	hnswM, _ := strconv.Atoi(getEnv("VECTOR_DB_HNSW_M", "16"))
+	hnswEfConstruction, _ := strconv.Atoi(getEnv("VECTOR_DB_HNSW_EF_CONSTRUCTION", "100"))
+	hnswEfSearch, _ := strconv.Atoi(getEnv("VECTOR_DB_HNSW_EF_SEARCH", "64"))
+	if chunkSize <= 0 || chunkOverlap < 0 || chunkOverlap >= chunkSize {
+		return nil, fmt.Errorf("invalid RAG_CHUNK_SIZE %d and RAG_CHUNK_OVERLAP %d, the overlap must be smaller than the chunk size", chunkSize, chunkOverlap)
+	}
+	if ragTopK <= 0 || maxContextTokens <= 0 {
+		return nil, fmt.Errorf("RAG_TOP_K and RAG_MAX_CONTEXT_TOKENS must be positive")
+	}
+	if similarityThreshold < 0 || similarityThreshold > 1 {
+		return nil, fmt.Errorf("RAG_SIMILARITY_THRESHOLD must be between 0 and 1")
+	}
+	moderationProvider := getEnv("MODERATION_PROVIDER", "none")
+	switch moderationProvider {
+	case "none", "local":
+	case "openai":
+		if getEnv("OPENAI_API_KEY", "") == "" {
+			return nil, fmt.Errorf("MODERATION_PROVIDER=openai requires OPENAI_API_KEY")
+		}
+	default:
+		return nil, fmt.Errorf("invalid MODERATION_PROVIDER %q, must be none, local or openai", moderationProvider)
+	}
+	judgeMode := getEnv("JUDGE_MODE", JudgeModeFlag)
+	if judgeMode != JudgeModeFlag && judgeMode != JudgeModeCorrect {
+		return nil, fmt.Errorf("invalid JUDGE_MODE %q, must be %s or %s", judgeMode, JudgeModeFlag, JudgeModeCorrect)
+	}
+	feedbackVerbosity := getEnv("FEEDBACK_VERBOSITY", FeedbackVerbosityDetailed)
+	if feedbackVerbosity != FeedbackVerbosityDetailed && feedbackVerbosity != FeedbackVerbosityBullets {
+		return nil, fmt.Errorf("invalid FEEDBACK_VERBOSITY %q, must be %s or %s", feedbackVerbosity, FeedbackVerbosityDetailed, FeedbackVerbosityBullets)
+	}
+	feedbackTone := getEnv("FEEDBACK_TONE", FeedbackToneInternal)
+	if feedbackTone != FeedbackToneInternal && feedbackTone != FeedbackToneCandidate {
+		return nil, fmt.Errorf("invalid FEEDBACK_TONE %q, must be %s or %s", feedbackTone, FeedbackToneInternal, FeedbackToneCandidate)
+	}
+	strongHireThreshold, _ := strconv.ParseFloat(getEnv("RECOMMENDATION_STRONG_HIRE_THRESHOLD", "0.8"), 64)
+	hireThreshold, _ := strconv.ParseFloat(getEnv("RECOMMENDATION_HIRE_THRESHOLD", "0.7"), 64)
+	if hireThreshold <= 0 || hireThreshold > strongHireThreshold || strongHireThreshold > 1 {
+		return nil, fmt.Errorf("RECOMMENDATION_HIRE_THRESHOLD and RECOMMENDATION_STRONG_HIRE_THRESHOLD must satisfy 0 < hire <= strong hire <= 1")
+	}
+	letterTone := getEnv("FEEDBACK_LETTER_TONE", LetterToneWarm)
+	if letterTone != LetterToneWarm && letterTone != LetterToneFormal {
+		return nil, fmt.Errorf("invalid FEEDBACK_LETTER_TONE %q, must be %s or %s", letterTone, LetterToneWarm, LetterToneFormal)
+	}
+	if calibrationTolerance < 0 {
+		return nil, fmt.Errorf("CALIBRATION_TOLERANCE must not be negative")
+	}
+	// A job still within its timeout would otherwise be reclaimed by a second worker and evaluated twice
+	if claimTimeout <= timeout {
+		return nil, fmt.Errorf("QUEUE_CLAIM_TIMEOUT (%ds) must be greater than JOB_TIMEOUT (%ds)", claimTimeout, timeout)
+	}
 
 	return &Config{
 		Server: ServerConfig{
@@ -77,30 +581,201 @@ func Load() (*Config, error) {
 			URI:      getEnv("MONGODB_URI", "mongodb://localhost:27017"),
 			Database: getEnv("MONGODB_DATABASE", "ai_cv_summarize"),
 		},
+		Storage: StorageConfig{
+			Backend: getEnv("STORAGE_BACKEND", "mongodb"),
+			Path:    getEnv("STORAGE_PATH", "./data/store.json"),
+		},
+		Postgres: PostgresConfig{
+			URL: getEnv("POSTGRES_URL", "postgres://localhost:5432/ai_cv_summarize?sslmode=disable"),
+		},
 		Redis: RedisConfig{
 			URL: getEnv("REDIS_URL", "redis://localhost:6379"),
 		},
+		LLM: LLMConfig{
+			Provider:      provider,
+			ContextWindow: contextWindow,
+		},
 		OpenAI: OpenAIConfig{
-			APIKey:  getEnv("OPENAI_API_KEY", ""),
-			BaseURL: getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
-			Model:   getEnv("OPENAI_MODEL", "gpt-4"),
+			APIKey:         getEnv("OPENAI_API_KEY", ""),
+			BaseURL:        getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+			Model:          getEnv("OPENAI_MODEL", "gpt-4"),
+			EmbeddingModel: getEnv("OPENAI_EMBEDDING_MODEL", "text-embedding-ada-002"),
+
+			RequestsPerMinute: openAIRequestsPerMinute,
+			TokensPerMinute:   openAITokensPerMinute,
 		},
 		OpenRouter: OpenRouterConfig{
-			APIKey:  getEnv("OPENROUTER_API_KEY", ""),
-			BaseURL: getEnv("OPENROUTER_BASE_URL", "https://openrouter.ai/api/v1"),
-			Model:   getEnv("OPENROUTER_MODEL", "openai/gpt-4"),
+			APIKey:         getEnv("OPENROUTER_API_KEY", ""),
+			BaseURL:        getEnv("OPENROUTER_BASE_URL", "https://openrouter.ai/api/v1"),
+			Model:          getEnv("OPENROUTER_MODEL", "openai/gpt-4"),
+			EmbeddingModel: getEnv("OPENROUTER_EMBEDDING_MODEL", "text-embedding-ada-002"),
+
+			RequestsPerMinute: openRouterRequestsPerMinute,
+			TokensPerMinute:   openRouterTokensPerMinute,
+		},
+		Gemini: GeminiConfig{
+			APIKey:         getEnv("GEMINI_API_KEY", ""),
+			BaseURL:        getEnv("GEMINI_BASE_URL", "https://generativelanguage.googleapis.com/v1beta"),
+			Model:          getEnv("GEMINI_MODEL", "gemini-2.5-flash"),
+			EmbeddingModel: getEnv("GEMINI_EMBEDDING_MODEL", "text-embedding-004"),
+
+			RequestsPerMinute: geminiRequestsPerMinute,
+			TokensPerMinute:   geminiTokensPerMinute,
 		},
 		VectorDB: VectorDBConfig{
+			Backend:    getEnv("VECTOR_DB_BACKEND", "scan"),
 			URL:        getEnv("VECTOR_DB_URL", "http://localhost:8000"),
+			APIKey:     getEnv("VECTOR_DB_API_KEY", ""),
 			Collection: getEnv("VECTOR_DB_COLLECTION", "job_descriptions"),
+
+			ChunkSize:           chunkSize,
+			ChunkOverlap:        chunkOverlap,
+			TopK:                ragTopK,
+			SimilarityThreshold: similarityThreshold,
+			MaxContextTokens:    maxContextTokens,
+
+			HNSWM:              hnswM,
+			HNSWEfConstruction: hnswEfConstruction,
+			HNSWEfSearch:       hnswEfSearch,
 		},
 		Upload: UploadConfig{
-			MaxFileSize: maxFileSize,
-			UploadDir:   getEnv("UPLOAD_DIR", "./uploads"),
+			MaxFileSize:     maxFileSize,
+			MaxArchiveSize:  maxArchiveSize,
+			UploadDir:       getEnv("UPLOAD_DIR", "./uploads"),
+			OrphanTTL:       time.Duration(uploadOrphanTTL) * time.Second,
+			CleanupInterval: time.Duration(uploadCleanupInterval) * time.Second,
+		},
+		Objects: ObjectStorageConfig{
+			Bucket:          getEnv("OBJECT_STORAGE_BUCKET", ""),
+			Prefix:          strings.Trim(getEnv("OBJECT_STORAGE_PREFIX", "uploads"), "/"),
+			Endpoint:        strings.TrimRight(getEnv("OBJECT_STORAGE_ENDPOINT", "https://s3."+objectStorageRegion+".amazonaws.com"), "/"),
+			Region:          objectStorageRegion,
+			AccessKeyID:     getEnv("OBJECT_STORAGE_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnv("OBJECT_STORAGE_SECRET_ACCESS_KEY", ""),
+			PathStyle:       objectStoragePathStyle,
+			PresignExpiry:   time.Duration(presignExpiry) * time.Second,
+			Timeout:         time.Duration(objectStorageTimeout) * time.Second,
+		},
+		OCR: OCRConfig{
+			Enabled:       ocrEnabled,
+			MinTextLength: ocrMinTextLength,
+			Languages:     getEnv("OCR_LANGUAGES", "eng"),
+			TesseractPath: getEnv("OCR_TESSERACT_PATH", "tesseract"),
+			PDFToPPMPath:  getEnv("OCR_PDFTOPPM_PATH", "pdftoppm"),
+			DPI:           ocrDPI,
+			MaxPages:      ocrMaxPages,
+			Timeout:       time.Duration(ocrTimeout) * time.Second,
+		},
+		Antivirus: AntivirusConfig{
+			Enabled:       clamAVEnabled,
+			Address:       getEnv("CLAMAV_ADDRESS", "localhost:3310"),
+			Timeout:       time.Duration(clamAVTimeout) * time.Second,
+			QuarantineDir: getEnv("QUARANTINE_DIR", "./quarantine"),
 		},
 		JobQueue: JobQueueConfig{
 			Timeout:    time.Duration(timeout) * time.Second,
 			MaxRetries: maxRetries,
+			Backend:    getEnv("QUEUE_BACKEND", "auto"),
+			BufferTTL:  time.Duration(bufferTTL) * time.Second,
+
+			Concurrency:     workerConcurrency,
+			ClaimTimeout:    time.Duration(claimTimeout) * time.Second,
+			ReaperInterval:  time.Duration(reaperInterval) * time.Second,
+			DuplicateWindow: time.Duration(duplicateWindow) * time.Second,
+			ResultCacheTTL:  time.Duration(resultCacheTTL) * time.Second,
+
+			DispatchInterval:  time.Duration(dispatchInterval) * time.Second,
+			SchedulerInterval: time.Duration(schedulerInterval) * time.Second,
+			SweepInterval:     time.Duration(sweepInterval) * time.Second,
+
+			ShutdownGrace: max(time.Duration(shutdownGrace)*time.Second, minShutdownGrace),
+		},
+		Language: LanguageConfig{
+			Supported:          splitList(getEnv("SUPPORTED_LANGUAGES", "en,id")),
+			TranslationEnabled: translationEnabled,
+		},
+		Chaos: ChaosConfig{
+			Enabled:          chaosEnabled,
+			LLMTimeoutRate:   chaosLLMTimeoutRate,
+			RedisErrorRate:   chaosRedisErrorRate,
+			MongoLatencyRate: chaosMongoLatencyRate,
+			MongoLatency:     time.Duration(chaosMongoLatency) * time.Millisecond,
+		},
+		Tenancy: TenancyConfig{
+			Enabled:     multiTenant,
+			AdminAPIKey: getEnv("ADMIN_API_KEY", ""),
+		},
+		Tracing: TracingConfig{
+			Exporter:    getEnv("TRACING_EXPORTER", "none"),
+			ServiceName: getEnv("OTEL_SERVICE_NAME", "ai-cv-summarize"),
+			SampleRatio: tracingSampleRatio,
+		},
+		Pricing: PricingConfig{
+			Models: modelPrices,
+		},
+		Health: HealthConfig{
+			CheckTimeout: time.Duration(readinessTimeout) * time.Millisecond,
+			CheckLLM:     readinessCheckLLM,
+		},
+		Judge: JudgeConfig{
+			Enabled: judgeEnabled,
+			Model:   getEnv("JUDGE_MODEL", ""),
+			Mode:    judgeMode,
+		},
+		Feedback: FeedbackConfig{
+			Verbosity: feedbackVerbosity,
+			Tone:      feedbackTone,
+
+			LetterTone:    letterTone,
+			LetterCompany: getEnv("FEEDBACK_LETTER_COMPANY", ""),
+			LetterSender:  getEnv("FEEDBACK_LETTER_SENDER", ""),
+		},
+		Hiring: HiringConfig{
+			StrongHireThreshold: strongHireThreshold,
+			HireThreshold:       hireThreshold,
+		},
+		Audit: AuditConfig{
+			Enabled:    auditEnabled,
+			MaxContent: auditMaxContent,
+		},
+		Embeddings: EmbeddingsConfig{
+			Provider:              embeddingProvider,
+			ONNXModelPath:         getEnv("ONNX_MODEL_PATH", ""),
+			ONNXRuntimeLibrary:    getEnv("ONNX_RUNTIME_LIBRARY", ""),
+			ONNXMaxSequenceLength: onnxMaxSequenceLength,
+
+			CacheEnabled: embeddingCacheEnabled,
+			CacheTTL:     time.Duration(embeddingCacheTTL) * time.Second,
+			BatchSize:    embeddingBatchSize,
+			Concurrency:  embeddingConcurrency,
+		},
+		Retention: RetentionConfig{
+			Days:     retentionDays,
+			Interval: time.Duration(retentionInterval) * time.Second,
+		},
+		Calibration: CalibrationConfig{
+			Interval:   time.Duration(calibrationInterval) * time.Second,
+			Tolerance:  calibrationTolerance,
+			WebhookURL: getEnv("CALIBRATION_WEBHOOK_URL", ""),
+		},
+		Privacy: PrivacyConfig{
+			RedactPII: redactPII,
+		},
+		Moderation: ModerationConfig{
+			Provider:     moderationProvider,
+			BlockedTerms: splitList(getEnv("MODERATION_BLOCKED_TERMS", "")),
+		},
+		GitHub: GitHubConfig{
+			Enabled:  githubEnabled,
+			Token:    getEnv("GITHUB_TOKEN", ""),
+			APIURL:   strings.TrimRight(getEnv("GITHUB_API_URL", "https://api.github.com"), "/"),
+			Timeout:  time.Duration(githubTimeout) * time.Second,
+			MaxRepos: githubMaxRepos,
+		},
+		Plagiarism: PlagiarismConfig{
+			Enabled:    plagiarismEnabled,
+			Threshold:  plagiarismThreshold,
+			MaxMatches: plagiarismMaxMatches,
 		},
 	}, nil
 }
@@ -111,3 +786,45 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// splitList parses a comma-separated env value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseModelPrices reads "model=prompt:completion" entries, USD per 1K tokens, over the default prices.
+// The completion price may be omitted for embedding models.
+func parseModelPrices(value string) (map[string]ModelPrice, error) {
+	prices := make(map[string]ModelPrice, len(defaultModelPrices))
+	for model, price := range defaultModelPrices {
+		prices[model] = price
+	}
+
+	for _, entry := range splitList(value) {
+		model, rates, found := strings.Cut(entry, "=")
+		if !found || model == "" {
+			return nil, fmt.Errorf("invalid LLM_PRICING entry %q, expected model=prompt:completion", entry)
+		}
+
+		promptRate, completionRate, _ := strings.Cut(rates, ":")
+		var price ModelPrice
+		var err error
+		if price.Prompt, err = strconv.ParseFloat(promptRate, 64); err != nil {
+			return nil, fmt.Errorf("invalid LLM_PRICING prompt price for %s: %w", model, err)
+		}
+		if completionRate != "" {
+			if price.Completion, err = strconv.ParseFloat(completionRate, 64); err != nil {
+				return nil, fmt.Errorf("invalid LLM_PRICING completion price for %s: %w", model, err)
+			}
+		}
+		prices[strings.TrimSpace(model)] = price
+	}
+
+	return prices, nil
+}
@@ -0,0 +1,15 @@
+// Package docs embeds the OpenAPI specification of the HTTP API and the Swagger UI page that renders it.
+// Keep openapi.yaml in step with the handlers and models it describes.
+package docs
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI 3 document for the /api/v1 endpoints
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// SwaggerUI is an HTML page loading Swagger UI from a CDN and pointing it at /openapi.yaml
+//
+//go:embed swagger.html
+var SwaggerUI []byte
@@ -0,0 +1,39 @@
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+// Supported export formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Writer streams rows of a spreadsheet to an underlying writer. Cells are strings, numbers or nil for empty cells.
+// Close must be called to finish the document.
+type Writer interface {
+	WriteRow(cells []interface{}) error
+	Close() error
+}
+
+// NewWriter returns a writer for the given format
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/rag"
+	"ai-cv-summarize/internal/repositories"
+	"ai-cv-summarize/internal/services"
+	"ai-cv-summarize/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AdminHandler struct {
+	repository        repositories.Repository
+	dbInitService     *services.DatabaseInitService
+	goldenService     *services.GoldenService
+	calibration       *services.CalibrationService
+	evaluationService *services.EvaluationService
+	retentionService  *services.RetentionService
+	fairnessService   *services.FairnessService
+	rebuilder         *rag.IndexRebuilder
+}
+
+func NewAdminHandler(
+	repository repositories.Repository,
+	dbInitService *services.DatabaseInitService,
+	goldenService *services.GoldenService,
+	calibration *services.CalibrationService,
+	evaluationService *services.EvaluationService,
+	retentionService *services.RetentionService,
+	fairnessService *services.FairnessService,
+	rebuilder *rag.IndexRebuilder,
+) *AdminHandler {
+	return &AdminHandler{
+		repository:        repository,
+		dbInitService:     dbInitService,
+		goldenService:     goldenService,
+		calibration:       calibration,
+		evaluationService: evaluationService,
+		retentionService:  retentionService,
+		fairnessService:   fairnessService,
+		rebuilder:         rebuilder,
+	}
+}
+
+// BulkDeleteJobs deletes or archives all jobs matching the given filters within one organization, or all
+// of them when the request says so explicitly
+func (h *AdminHandler) BulkDeleteJobs(c *gin.Context) {
+	var req models.BulkDeleteJobsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	var v validation
+	if req.OrgID != "" {
+		v.check(primitive.IsValidObjectID(req.OrgID), "org_id", "must be an organization ID")
+		v.check(!req.AllOrganizations, "all_organizations", "cannot be combined with org_id")
+	} else {
+		v.check(req.AllOrganizations, "org_id", "is required unless all_organizations is true")
+	}
+	if v.respond(c) {
+		return
+	}
+
+	if req.Action == "" {
+		req.Action = "delete"
+	}
+	if req.Action != "delete" && req.Action != "archive" {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid action, must be delete or archive")
+		return
+	}
+
+	// Refuse to operate on the whole collection without any filter
+	if req.Status == "" && req.OlderThanDays <= 0 {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "At least one filter (status, older_than_days) is required")
+		return
+	}
+
+	filter := repositories.JobBulkFilter{Status: req.Status}
+	if req.OlderThanDays > 0 {
+		filter.OlderThan = time.Now().AddDate(0, 0, -req.OlderThanDays)
+	}
+
+	ctx := c.Request.Context()
+	if req.OrgID != "" {
+		ctx = tenant.WithOrgID(ctx, req.OrgID)
+	}
+
+	response, err := h.retentionService.RemoveJobs(ctx, filter, req.Action == "archive", req.DryRun)
+	switch {
+	case errors.Is(err, services.ErrJobsInProgress):
+		respondWithError(c, http.StatusConflict, models.ErrorCodeConflict, err.Error()+"; narrow the filters or retry once they finish")
+	case err != nil:
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to "+req.Action+" jobs: "+err.Error())
+	default:
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// PurgeJobs permanently removes soft-deleted jobs and their uploaded files
+func (h *AdminHandler) PurgeJobs(c *gin.Context) {
+	var req models.PurgeJobsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.DeletedBeforeDays < 0 {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "deleted_before_days must not be negative")
+		return
+	}
+
+	response, err := h.retentionService.PurgeDeletedJobs(c.Request.Context(), time.Now().AddDate(0, 0, -req.DeletedBeforeDays), req.DryRun)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// LoadSampleData loads sample job descriptions, rubrics and fixtures so an environment is demo-ready
+func (h *AdminHandler) LoadSampleData(c *gin.Context) {
+	summary, err := h.dbInitService.LoadSampleData(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to load sample data: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sample data loaded successfully",
+		"summary": summary,
+	})
+}
+
+// AddGoldenJob registers a completed job's result as a golden reference
+func (h *AdminHandler) AddGoldenJob(c *gin.Context) {
+	var req models.CreateGoldenJobRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	golden, err := h.goldenService.AddGoldenJob(c.Request.Context(), req)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, golden)
+}
+
+// ListGoldenJobs lists the golden reference set
+func (h *AdminHandler) ListGoldenJobs(c *gin.Context) {
+	goldens, err := h.repository.GetAllGoldenJobs(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve golden jobs")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"golden_jobs": goldens,
+		"total":       len(goldens),
+	})
+}
+
+// DeleteGoldenJob removes a job from the golden reference set
+func (h *AdminHandler) DeleteGoldenJob(c *gin.Context) {
+	if err := h.repository.DeleteGoldenJob(c.Request.Context(), c.Param("id")); err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Golden job not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Golden job deleted"})
+}
+
+// CompareGoldenJobs re-runs the golden set with the current prompts/model and reports score deltas.
+// With replay=true the jobs are re-run against their recorded LLM responses instead of the provider.
+func (h *AdminHandler) CompareGoldenJobs(c *gin.Context) {
+	tolerance := 0.5
+	if value := c.Query("tolerance"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid tolerance")
+			return
+		}
+		tolerance = parsed
+	}
+
+	replay, _ := strconv.ParseBool(c.Query("replay"))
+
+	report, err := h.goldenService.Compare(c.Request.Context(), tolerance, replay)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to compare golden jobs: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RunCalibration re-evaluates the golden set now, like a scheduled calibration run, alerting on drift
+func (h *AdminHandler) RunCalibration(c *gin.Context) {
+	run, err := h.calibration.Calibrate(c.Request.Context(), false)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to calibrate golden set: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// ListCalibrationRuns returns the latest calibration runs, newest first
+func (h *AdminHandler) ListCalibrationRuns(c *gin.Context) {
+	limit := 20
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > 100 {
+			respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid limit, must be between 1 and 100")
+			return
+		}
+		limit = parsed
+	}
+
+	runs, err := h.repository.GetCalibrationRuns(c.Request.Context(), limit)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to get calibration runs")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"calibration_runs": runs, "total": len(runs)})
+}
+
+// RebuildVectorIndex starts (or resumes) a background rebuild of the job description vectors
+func (h *AdminHandler) RebuildVectorIndex(c *gin.Context) {
+	batchSize := rag.DefaultRebuildBatchSize
+	if value := c.Query("batch_size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid batch_size")
+			return
+		}
+		batchSize = parsed
+	}
+
+	rebuild, err := h.rebuilder.Start(c.Request.Context(), batchSize, c.Query("restart") == "true")
+	if errors.Is(err, rag.ErrRebuildInProgress) {
+		respondWithError(c, http.StatusConflict, models.ErrorCodeConflict, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to start vector index rebuild: "+err.Error())
+		return
+	}
+
+	// The rebuild outlives this request; respond with a snapshot before it starts mutating
+	snapshot := *rebuild
+	go func() {
+		if err := h.rebuilder.Run(context.Background(), rebuild); err != nil {
+			log.Printf("Vector index rebuild %s failed: %v", rebuild.ID.Hex(), err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, snapshot)
+}
+
+// GetVectorIndexRebuild reports the progress of the latest vector index rebuild
+func (h *AdminHandler) GetVectorIndexRebuild(c *gin.Context) {
+	rebuild, err := h.repository.GetLatestIndexRebuild(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "No vector index rebuild found")
+		return
+	}
+
+	c.JSON(http.StatusOK, rebuild)
+}
+
+// StartFairnessReport starts a background report comparing the score distributions of evaluation cohorts
+func (h *AdminHandler) StartFairnessReport(c *gin.Context) {
+	var req models.FairnessReportRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+	if req.Days < 0 || req.Threshold < 0 {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "days and threshold must not be negative")
+		return
+	}
+
+	report, err := h.fairnessService.Start(c.Request.Context(), req)
+	if errors.Is(err, services.ErrFairnessReportInProgress) {
+		respondWithError(c, http.StatusConflict, models.ErrorCodeConflict, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to start fairness report: "+err.Error())
+		return
+	}
+
+	// The report outlives this request; respond with a snapshot before it is filled in
+	snapshot := *report
+	go func() {
+		if err := h.fairnessService.Run(context.Background(), report); err != nil {
+			log.Printf("Fairness report %s failed: %v", report.ID.Hex(), err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, snapshot)
+}
+
+// ListFairnessReports returns every fairness report, newest first
+func (h *AdminHandler) ListFairnessReports(c *gin.Context) {
+	reports, err := h.repository.GetFairnessReports(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to get fairness reports")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports, "total": len(reports)})
+}
+
+// GetFairnessReport returns one fairness report
+func (h *AdminHandler) GetFairnessReport(c *gin.Context) {
+	report, err := h.repository.GetFairnessReport(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Fairness report not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ReplayJob re-runs a job's evaluation against its recorded LLM responses and compares the outcome
+// with the stored result. Nothing is saved and the provider is not called.
+func (h *AdminHandler) ReplayJob(c *gin.Context) {
+	job, err := h.repository.GetJobByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job not found")
+		return
+	}
+
+	result, changedSteps, err := h.evaluationService.ReplayContent(c.Request.Context(), job)
+	if errors.Is(err, services.ErrNoRecordedCalls) {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job has no recorded LLM calls")
+		return
+	}
+	if err != nil {
+		respondWithError(c, http.StatusUnprocessableEntity, models.ErrorCodeUnprocessable, err.Error())
+		return
+	}
+
+	response := models.ReplayResponse{
+		JobID:        job.ID.Hex(),
+		Result:       result,
+		ChangedSteps: changedSteps,
+	}
+	if job.Result != nil {
+		replayed := *job
+		replayed.Result = result
+		response.Diff = services.DiffEvaluations(job, &replayed)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// maxLLMCalls caps the calls returned by one audit log request
+const maxLLMCalls = 1000
+
+// ListLLMCalls returns audited LLM calls, newest first.
+// Optional query parameters: job_id, step and limit (default 100).
+func (h *AdminHandler) ListLLMCalls(c *gin.Context) {
+	filter := repositories.LLMCallFilter{
+		JobID: c.Query("job_id"),
+		Step:  c.Query("step"),
+		Limit: 100,
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxLLMCalls {
+			respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, fmt.Sprintf("limit must be between 1 and %d", maxLLMCalls))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	calls, err := h.repository.GetLLMCalls(c.Request.Context(), filter)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to get LLM calls")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"calls": calls})
+}
+
+// GetUsage reports LLM token usage and estimated cost per day, organization and model.
+// Optional query parameters: from and to (inclusive YYYY-MM-DD dates) and org_id.
+func (h *AdminHandler) GetUsage(c *gin.Context) {
+	filter := repositories.UsageFilter{
+		From:  c.Query("from"),
+		To:    c.Query("to"),
+		OrgID: c.Query("org_id"),
+	}
+	for _, date := range []string{filter.From, filter.To} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid date, expected YYYY-MM-DD")
+			return
+		}
+	}
+
+	totals, err := h.repository.GetUsageTotals(c.Request.Context(), filter)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to get usage totals")
+		return
+	}
+
+	response := models.UsageResponse{
+		From:   filter.From,
+		To:     filter.To,
+		Totals: make([]models.UsageTotal, 0, len(totals)),
+	}
+	for _, total := range totals {
+		response.Total.Add(total.TokenUsage)
+		response.Totals = append(response.Totals, *total)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
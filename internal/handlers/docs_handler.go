@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ai-cv-summarize/internal/docs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DocsHandler struct{}
+
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+// OpenAPI serves the OpenAPI specification of the API
+func (h *DocsHandler) OpenAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/yaml", docs.OpenAPI)
+}
+
+// SwaggerUI serves the interactive API documentation
+func (h *DocsHandler) SwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docs.SwaggerUI)
+}
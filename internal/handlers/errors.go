@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ai-cv-summarize/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondWithError writes the error envelope of a failed request
+func respondWithError(c *gin.Context, status int, code models.ErrorCode, message string) {
+	respondWithErrorDetails(c, status, code, message, nil)
+}
+
+// respondWithErrorDetails writes the error envelope of a failed request with data specific to its code
+func respondWithErrorDetails(c *gin.Context, status int, code models.ErrorCode, message string, details interface{}) {
+	c.JSON(status, errorResponse(code, message, details))
+}
+
+// abortWithError writes the error envelope and stops the remaining handlers, for middleware
+func abortWithError(c *gin.Context, status int, code models.ErrorCode, message string) {
+	c.AbortWithStatusJSON(status, errorResponse(code, message, nil))
+}
+
+func errorResponse(code models.ErrorCode, message string, details interface{}) models.ErrorResponse {
+	return models.ErrorResponse{Error: models.APIError{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		Retryable: code.Retryable(),
+	}}
+}
+
+// RouteNotFound answers requests for unknown routes with the error envelope
+func RouteNotFound(c *gin.Context) {
+	respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Route "+c.Request.Method+" "+c.Request.URL.Path+" not found")
+}
@@ -1,106 +1,1248 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"ai-cv-summarize/internal/export"
 	"ai-cv-summarize/internal/models"
 	"ai-cv-summarize/internal/repositories"
 	"ai-cv-summarize/internal/services"
+	"ai-cv-summarize/internal/telemetry"
+	"ai-cv-summarize/internal/tenant"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// jobSortFields maps the accepted sort_by values to their document fields
+var jobSortFields = map[string]string{
+	"created_at":    "created_at",
+	"completed_at":  "completed_at",
+	"overall_score": "result.overall_score",
+}
+
+// jobScoreFilters maps the score names accepted by min_<score> and max_<score> to their document fields
+var jobScoreFilters = []struct{ name, field string }{
+	{"cv_match_rate", "result.cv_match_rate"},
+	{"project_score", "result.project_score"},
+	{"overall_score", "result.overall_score"},
+}
+
+// maxBatchGetIDs caps the number of job IDs accepted by BatchGetResults
+const maxBatchGetIDs = 100
+
+// jobExportColumns are the header row of job exports; jobExportRow must return the cells in the same order
+var jobExportColumns = []interface{}{
+	"id", "status", "candidate_id", "candidate_name", "job_description_id", "batch_id", "cv_file", "project_file",
+	"cv_match_rate", "project_score", "overall_score", "retry_count",
+	"created_at", "started_at", "completed_at", "error",
+}
+
+// maxBatchCandidates caps the number of candidates accepted by StartBatchEvaluation
+const maxBatchCandidates = 100
+
+// maxJobPageSize caps the limit accepted by ListJobs
+const maxJobPageSize = 100
+
+// minTextDocumentLength and maxTextDocumentLength bound the characters of each document sent to
+// StartTextEvaluation, ignoring surrounding whitespace
+const (
+	minTextDocumentLength = 50
+	maxTextDocumentLength = 100000
+)
+
+// maxScheduleDelay caps how far ahead run_at may schedule an evaluation
+const maxScheduleDelay = 30 * 24 * time.Hour
+
+// maxIdempotencyKeyLength caps the Idempotency-Key header accepted by the evaluation endpoints
+const maxIdempotencyKeyLength = 255
+
+// batchItemRejected is the status reported for batch candidates that never got an evaluation job
+const batchItemRejected = "rejected"
+
 type EvaluationHandler struct {
-	repository        *repositories.MongoDBRepository
-	evaluationService *services.EvaluationService
-	jobQueue          *services.JobQueue
-	fileService       *services.FileService
+	repository               repositories.Repository
+	evaluationService        *services.EvaluationService
+	sandboxEvaluationService *services.EvaluationService
+	jobQueue                 *services.JobQueue
+	jobBuffer                *services.JobBuffer
+	fileService              *services.FileService
+	duplicateWindow          time.Duration
+	resultCacheTTL           time.Duration
+}
+
+func NewEvaluationHandler(
+	repository repositories.Repository,
+	evaluationService *services.EvaluationService,
+	sandboxEvaluationService *services.EvaluationService,
+	jobQueue *services.JobQueue,
+	jobBuffer *services.JobBuffer,
+	fileService *services.FileService,
+	duplicateWindow time.Duration,
+	resultCacheTTL time.Duration,
+) *EvaluationHandler {
+	return &EvaluationHandler{
+		repository:               repository,
+		evaluationService:        evaluationService,
+		sandboxEvaluationService: sandboxEvaluationService,
+		jobQueue:                 jobQueue,
+		jobBuffer:                jobBuffer,
+		fileService:              fileService,
+		duplicateWindow:          duplicateWindow,
+		resultCacheTTL:           resultCacheTTL,
+	}
+}
+
+// StartEvaluation starts the evaluation process
+func (h *EvaluationHandler) StartEvaluation(c *gin.Context) {
+	var req models.EvaluateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	var v validation
+	v.fileID("cv_file", req.CVFile)
+	v.fileID("project_file", req.ProjectFile)
+	if v.respond(c) {
+		return
+	}
+	if h.respondIfReplayed(c) {
+		return
+	}
+
+	if h.respondIfUnknownJobDescription(c, req.JobDescriptionID) || respondIfInvalidGitHub(c, req.GitHub) ||
+		respondIfInvalidRunAt(c, req.RunAt, req.Sandbox) {
+		return
+	}
+
+	// Read content from files
+	cvUpload, cvContent, err := h.readUpload(c.Request.Context(), req.CVFile)
+	if err != nil {
+		respondWithReadError(c, "CV", err)
+		return
+	}
+
+	projectUpload, projectContent, err := h.readUpload(c.Request.Context(), req.ProjectFile)
+	if err != nil {
+		respondWithReadError(c, "project", err)
+		return
+	}
+
+	job := &models.EvaluationJob{
+		CVFile:           cvUpload.Filename,
+		ProjectFile:      projectUpload.Filename,
+		CVContent:        cvContent,
+		ProjectContent:   projectContent,
+		CandidateID:      req.CandidateID,
+		CandidateName:    req.CandidateName,
+		JobDescriptionID: req.JobDescriptionID,
+		GitHub:           strings.TrimSpace(req.GitHub),
+		ScoringOptions:   req.ScoringOptions,
+		Sandbox:          req.Sandbox,
+		RunAt:            scheduledRunAt(req.RunAt),
+	}
+	if h.respondIfInvalidScoring(c, job) || h.respondIfUnsupportedLanguage(c, job) || h.respondIfDisallowedContent(c, job) {
+		return
+	}
+	if !req.Force && (h.respondIfCached(c, job) || h.respondIfDuplicate(c, job)) {
+		return
+	}
+
+	h.createAndEnqueueJob(c, job)
+}
+
+// StartInlineEvaluation starts the evaluation process from base64-encoded documents
+func (h *EvaluationHandler) StartInlineEvaluation(c *gin.Context) {
+	var req models.EvaluateInlineRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if h.respondIfReplayed(c) {
+		return
+	}
+
+	if h.respondIfUnknownJobDescription(c, req.JobDescriptionID) || respondIfInvalidGitHub(c, req.GitHub) {
+		return
+	}
+
+	// Decode and save CV document
+	cvUpload, err := h.fileService.SaveBase64File(c.Request.Context(), req.CVDocument)
+	if err != nil {
+		respondWithSaveError(c, http.StatusBadRequest, "Failed to decode CV document", err)
+		return
+	}
+
+	// Decode and save project document
+	projectUpload, err := h.fileService.SaveBase64File(c.Request.Context(), req.ProjectDocument)
+	if err != nil {
+		h.fileService.CleanupFile(c.Request.Context(), cvUpload)
+		respondWithSaveError(c, http.StatusBadRequest, "Failed to decode project document", err)
+		return
+	}
+
+	h.evaluateSavedFiles(c, cvUpload, projectUpload, &models.EvaluationJob{
+		CandidateID:      req.CandidateID,
+		CandidateName:    req.CandidateName,
+		JobDescriptionID: req.JobDescriptionID,
+		GitHub:           strings.TrimSpace(req.GitHub),
+		ScoringOptions:   req.ScoringOptions,
+		Sandbox:          req.Sandbox,
+	}, req.Force)
+}
+
+// StartUploadEvaluation saves a CV and project report sent as multipart files and starts their evaluation in
+// one call, instead of uploading them first. The other fields of /evaluate are sent as form fields, with
+// weights and retrieval_options as JSON objects.
+func (h *EvaluationHandler) StartUploadEvaluation(c *gin.Context) {
+	if h.respondIfReplayed(c) {
+		return
+	}
+
+	form, ok := parseUploadForm(c, h.fileService)
+	if !ok {
+		return
+	}
+
+	cvFiles := form.File["cv_file"]
+	if len(cvFiles) == 0 {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "CV file is required")
+		return
+	}
+	projectFiles := form.File["project_file"]
+	if len(projectFiles) == 0 {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "Project file is required")
+		return
+	}
+
+	job := &models.EvaluationJob{
+		CandidateID:      c.PostForm("candidate_id"),
+		CandidateName:    c.PostForm("candidate_name"),
+		JobDescriptionID: c.PostForm("job_description_id"),
+		GitHub:           strings.TrimSpace(c.PostForm("github")),
+		ScoringOptions: models.ScoringOptions{
+			CVRubricID:        c.PostForm("cv_rubric_id"),
+			ProjectRubricID:   c.PostForm("project_rubric_id"),
+			FeedbackVerbosity: c.PostForm("feedback_verbosity"),
+			FeedbackTone:      c.PostForm("feedback_tone"),
+		},
+	}
+	job.Sandbox, _ = strconv.ParseBool(c.PostForm("sandbox"))
+	job.Blind, _ = strconv.ParseBool(c.PostForm("blind"))
+	force, _ := strconv.ParseBool(c.PostForm("force"))
+	if weights := c.PostForm("weights"); weights != "" {
+		job.Weights = &models.ScoringWeights{}
+		if err := json.Unmarshal([]byte(weights), job.Weights); err != nil {
+			respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid weights: "+err.Error())
+			return
+		}
+	}
+	if retrieval := c.PostForm("retrieval_options"); retrieval != "" {
+		job.RetrievalOptions = &models.RetrievalOptions{}
+		if err := json.Unmarshal([]byte(retrieval), job.RetrievalOptions); err != nil {
+			respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid retrieval options: "+err.Error())
+			return
+		}
+	}
+
+	if h.respondIfUnknownJobDescription(c, job.JobDescriptionID) || respondIfInvalidGitHub(c, job.GitHub) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	cvUpload, err := h.fileService.SaveFile(ctx, cvFiles[0])
+	if err != nil {
+		respondWithSaveError(c, saveFailureStatus(err), "Failed to save CV file", err)
+		return
+	}
+	projectUpload, err := h.fileService.SaveFile(ctx, projectFiles[0])
+	if err != nil {
+		h.fileService.CleanupFile(ctx, cvUpload)
+		respondWithSaveError(c, saveFailureStatus(err), "Failed to save project file", err)
+		return
+	}
+
+	h.evaluateSavedFiles(c, cvUpload, projectUpload, job, force)
+}
+
+// StartTextEvaluation starts the evaluation process from the plain text of the CV and project report, for
+// integrations that have already extracted it. No files are stored for the job.
+func (h *EvaluationHandler) StartTextEvaluation(c *gin.Context) {
+	var req models.EvaluateTextRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if h.respondIfReplayed(c) {
+		return
+	}
+
+	cvText, projectText := strings.TrimSpace(req.CVText), strings.TrimSpace(req.ProjectText)
+	for _, field := range []struct{ name, text string }{{"cv_text", cvText}, {"project_text", projectText}} {
+		if n := utf8.RuneCountInString(field.text); n < minTextDocumentLength || n > maxTextDocumentLength {
+			respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, fmt.Sprintf("%s must be between %d and %d characters, got %d", field.name, minTextDocumentLength, maxTextDocumentLength, n))
+			return
+		}
+	}
+
+	if h.respondIfUnknownJobDescription(c, req.JobDescriptionID) || respondIfInvalidGitHub(c, req.GitHub) {
+		return
+	}
+
+	job := &models.EvaluationJob{
+		CVContent:        cvText,
+		ProjectContent:   projectText,
+		CandidateID:      req.CandidateID,
+		CandidateName:    req.CandidateName,
+		JobDescriptionID: req.JobDescriptionID,
+		GitHub:           strings.TrimSpace(req.GitHub),
+		ScoringOptions:   req.ScoringOptions,
+		Sandbox:          req.Sandbox,
+	}
+	if h.respondIfInvalidScoring(c, job) || h.respondIfUnsupportedLanguage(c, job) || h.respondIfDisallowedContent(c, job) {
+		return
+	}
+	if !req.Force && (h.respondIfCached(c, job) || h.respondIfDuplicate(c, job)) {
+		return
+	}
+
+	h.createAndEnqueueJob(c, job)
+}
+
+// evaluateSavedFiles extracts the text of the CV and project files saved for a request and starts the
+// evaluation of job with them. The files are removed when the job is rejected or served from an earlier one.
+func (h *EvaluationHandler) evaluateSavedFiles(c *gin.Context, cvUpload, projectUpload *models.Upload, job *models.EvaluationJob, force bool) {
+	ctx := c.Request.Context()
+	cleanup := func() {
+		h.fileService.CleanupFile(ctx, cvUpload)
+		h.fileService.CleanupFile(ctx, projectUpload)
+	}
+
+	// Read content through the normal extraction path
+	cvContent, err := h.readFileContent(cvUpload.Filename)
+	if err != nil {
+		cleanup()
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "Failed to read CV file: "+err.Error())
+		return
+	}
+
+	projectContent, err := h.readFileContent(projectUpload.Filename)
+	if err != nil {
+		cleanup()
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "Failed to read project file: "+err.Error())
+		return
+	}
+
+	job.CVFile = cvUpload.Filename
+	job.ProjectFile = projectUpload.Filename
+	job.CVContent = cvContent
+	job.ProjectContent = projectContent
+	if h.respondIfInvalidScoring(c, job) || h.respondIfUnsupportedLanguage(c, job) || h.respondIfDisallowedContent(c, job) || (!force && (h.respondIfCached(c, job) || h.respondIfDuplicate(c, job))) {
+		// Rejected, cached and duplicate submissions never reference the saved documents
+		cleanup()
+		return
+	}
+
+	h.createAndEnqueueJob(c, job)
+}
+
+// ReevaluateJob reruns a completed or failed job with the same documents, e.g. after a rubric or model
+// change. The job is queued again under the same ID and its current result becomes an earlier version, so
+// the candidate keeps a single job in listings and rankings while every result stays available.
+func (h *EvaluationHandler) ReevaluateJob(c *gin.Context) {
+	ctx := c.Request.Context()
+	job, err := h.repository.GetJobByID(ctx, c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job not found")
+		return
+	}
+
+	if job.Status != models.StatusCompleted && job.Status != models.StatusFailed {
+		respondWithError(c, http.StatusConflict, models.ErrorCodeConflict, "Only completed or failed jobs can be re-evaluated, job is "+string(job.Status))
+		return
+	}
+	if job.ContentErasedAt != nil {
+		respondWithError(c, http.StatusGone, models.ErrorCodeContentErased, "The job's documents were erased by the retention policy")
+		return
+	}
+
+	// The rubrics may have changed since the job was hashed
+	job.ContentHash = ""
+	h.hashJob(ctx, job)
+
+	err = h.repository.ReopenJob(ctx, job.ID.Hex(), job.ContentHash)
+	if errors.Is(err, repositories.ErrNotFound) {
+		// Another request re-evaluated or deleted the job since it was read
+		respondWithError(c, http.StatusConflict, models.ErrorCodeConflict, "The job is already being re-evaluated")
+		return
+	}
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to re-evaluate job")
+		return
+	}
+
+	if job.Sandbox {
+		if err := h.evaluateSandboxJob(ctx, job.ID.Hex()); err != nil {
+			respondWithError(c, http.StatusInternalServerError, models.ErrorCodeLLMFailure, "Sandbox evaluation failed: "+err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, models.EvaluateResponse{
+			ID:     job.ID.Hex(),
+			Status: string(models.StatusCompleted),
+		})
+		return
+	}
+
+	// The job was reopened with its enqueue pending, so the outbox dispatcher retries a failed push
+	if err := h.jobQueue.AddJob(job); err != nil {
+		log.Printf("Error queueing job %s, left to the outbox dispatcher: %v", job.ID.Hex(), err)
+	}
+
+	c.JSON(http.StatusOK, models.EvaluateResponse{
+		ID:     job.ID.Hex(),
+		Status: string(models.StatusQueued),
+	})
+}
+
+// ParseResume extracts the structured content of a CV: a stored job's CV, in which case the parsed fields
+// are saved on the job, or an uploaded or inline document, which is only parsed
+func (h *EvaluationHandler) ParseResume(c *gin.Context) {
+	var req models.ParseRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	sources := 0
+	for _, set := range []bool{req.JobID != "", req.CVFile != "", req.CVDocument != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Exactly one of job_id, cv_file or cv_document is required")
+		return
+	}
+
+	var job *models.EvaluationJob
+	switch {
+	case req.JobID != "":
+		stored, err := h.repository.GetJobByID(c.Request.Context(), req.JobID)
+		if err != nil {
+			respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job not found")
+			return
+		}
+		if stored.ContentErasedAt != nil {
+			respondWithError(c, http.StatusGone, models.ErrorCodeContentErased, "The job's documents were erased by the retention policy")
+			return
+		}
+		job = stored
+	case req.CVFile != "":
+		var v validation
+		v.fileID("cv_file", req.CVFile)
+		if v.respond(c) {
+			return
+		}
+		cvUpload, cvContent, err := h.readUpload(c.Request.Context(), req.CVFile)
+		if err != nil {
+			respondWithReadError(c, "CV", err)
+			return
+		}
+		job = &models.EvaluationJob{CVFile: cvUpload.Filename, CVContent: cvContent, Sandbox: req.Sandbox}
+	default:
+		cvUpload, err := h.fileService.SaveBase64File(c.Request.Context(), *req.CVDocument)
+		if err != nil {
+			respondWithSaveError(c, http.StatusBadRequest, "Failed to decode CV document", err)
+			return
+		}
+		// The document is only parsed, so it is not kept as an upload
+		cvContent, err := h.readFileContent(cvUpload.Filename)
+		h.fileService.CleanupFile(c.Request.Context(), cvUpload)
+		if err != nil {
+			respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "Failed to read CV file: "+err.Error())
+			return
+		}
+		job = &models.EvaluationJob{CVContent: cvContent, Sandbox: req.Sandbox}
+	}
+
+	parsed, err := h.evaluationServiceFor(job).ParseResume(c.Request.Context(), job)
+	if err != nil {
+		var modErr *services.ModerationError
+		if errors.As(err, &modErr) {
+			respondWithModerationError(c, modErr)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeLLMFailure, "Failed to parse CV: "+err.Error())
+		return
+	}
+
+	response := models.ParseResponse{Resume: parsed}
+	if req.JobID != "" {
+		if err := h.repository.UpdateJobParsedCV(c.Request.Context(), req.JobID, parsed); err != nil {
+			respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to save parsed CV")
+			return
+		}
+		response.JobID = req.JobID
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GenerateFeedbackLetter drafts an email to the candidate of a completed job from its feedback, as text and
+// HTML, for a recruiter to review and send. Nothing is sent or stored.
+func (h *EvaluationHandler) GenerateFeedbackLetter(c *gin.Context) {
+	var req models.FeedbackLetterRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+	if err := services.ValidateFeedbackLetterRequest(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	job, err := h.repository.GetJobByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job not found")
+		return
+	}
+	if job.Status != models.StatusCompleted || job.Result == nil {
+		respondWithError(c, http.StatusConflict, models.ErrorCodeConflict, "Only completed jobs have feedback to send, job is "+string(job.Status))
+		return
+	}
+
+	letter, err := h.evaluationServiceFor(job).GenerateFeedbackLetter(c.Request.Context(), job, req)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeLLMFailure, "Failed to generate feedback letter: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, letter)
+}
+
+// respondIfUnknownJobDescription rejects evaluations pinned to a job description that does not exist.
+// It reports whether a response was written.
+func (h *EvaluationHandler) respondIfUnknownJobDescription(c *gin.Context, jobDescriptionID string) bool {
+	if jobDescriptionID == "" {
+		return false
+	}
+
+	// Look it up as the worker will: jobs created with the admin key belong to no organization and only
+	// see the global job descriptions
+	ctx := c.Request.Context()
+	_, err := h.repository.GetJobDescription(tenant.WithOrgID(ctx, tenant.OrgID(ctx)), jobDescriptionID)
+	if errors.Is(err, repositories.ErrNotFound) || errors.Is(err, primitive.ErrInvalidHex) {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job description not found")
+		return true
+	}
+
+	return false
+}
+
+// respondIfInvalidGitHub rejects GitHub references that do not name an account or repository.
+// It reports whether a response was written.
+func respondIfInvalidGitHub(c *gin.Context, ref string) bool {
+	if strings.TrimSpace(ref) == "" {
+		return false
+	}
+	if _, _, err := services.ParseGitHubRef(ref); err != nil {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, err.Error())
+		return true
+	}
+	return false
+}
+
+// respondIfInvalidRunAt rejects a run_at further ahead than maxScheduleDelay, or set on a sandbox
+// evaluation, which runs inline. It reports whether a response was written.
+func respondIfInvalidRunAt(c *gin.Context, runAt *time.Time, sandbox bool) bool {
+	if runAt == nil {
+		return false
+	}
+	if sandbox {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "run_at is not supported for sandbox evaluations")
+		return true
+	}
+	if time.Until(*runAt) > maxScheduleDelay {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, fmt.Sprintf("run_at must be within %d days", int(maxScheduleDelay.Hours()/24)))
+		return true
+	}
+	return false
+}
+
+// scheduledRunAt returns the run time of a job scheduled for runAt, or nil when it is due already and
+// should be queued now
+func scheduledRunAt(runAt *time.Time) *time.Time {
+	if runAt == nil || !runAt.After(time.Now()) {
+		return nil
+	}
+	utc := runAt.UTC()
+	return &utc
+}
+
+// respondIfInvalidScoring rejects jobs whose rubric or weight overrides cannot be applied.
+// It reports whether a response was written.
+func (h *EvaluationHandler) respondIfInvalidScoring(c *gin.Context, job *models.EvaluationJob) bool {
+	if err := h.evaluationService.CheckScoring(c.Request.Context(), job); err != nil {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, err.Error())
+		return true
+	}
+	return false
+}
+
+// respondIfUnsupportedLanguage rejects jobs whose documents are in a language the pipeline cannot evaluate.
+// It reports whether a response was written.
+func (h *EvaluationHandler) respondIfUnsupportedLanguage(c *gin.Context, job *models.EvaluationJob) bool {
+	var langErr *services.LanguageError
+	if err := h.evaluationService.CheckLanguages(job); !errors.As(err, &langErr) {
+		return false
+	}
+
+	respondWithErrorDetails(c, http.StatusUnprocessableEntity, models.ErrorCodeLanguageUnsupported,
+		langErr.Error(), gin.H{"language": langErr.Language})
+	return true
+}
+
+// evaluationServiceFor returns the service that evaluates job, so sandbox documents never reach a provider
+func (h *EvaluationHandler) evaluationServiceFor(job *models.EvaluationJob) *services.EvaluationService {
+	if job.Sandbox {
+		return h.sandboxEvaluationService
+	}
+	return h.evaluationService
+}
+
+// respondIfDisallowedContent rejects jobs whose documents fail the content moderation check.
+// It reports whether a response was written.
+func (h *EvaluationHandler) respondIfDisallowedContent(c *gin.Context, job *models.EvaluationJob) bool {
+	var modErr *services.ModerationError
+	if err := h.evaluationServiceFor(job).CheckContent(c.Request.Context(), job); !errors.As(err, &modErr) {
+		return false
+	}
+
+	respondWithModerationError(c, modErr)
+	return true
+}
+
+// respondWithModerationError reports a document rejected by the content moderation check
+func respondWithModerationError(c *gin.Context, modErr *services.ModerationError) {
+	respondWithErrorDetails(c, http.StatusUnprocessableEntity, models.ErrorCodeContentRejected,
+		modErr.Error(), gin.H{"categories": modErr.Categories})
+}
+
+// respondIfReplayed writes the job created earlier with the request's Idempotency-Key instead of starting a
+// new one, so a retried request is evaluated and billed once. It also rejects malformed keys. It reports
+// whether a response was written.
+func (h *EvaluationHandler) respondIfReplayed(c *gin.Context) bool {
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" {
+		return false
+	}
+	if !validIdempotencyKey(key) {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, fmt.Sprintf("Idempotency-Key must be 1 to %d printable ASCII characters", maxIdempotencyKeyLength))
+		return true
+	}
+
+	prior, err := h.repository.FindJobByIdempotencyKey(c.Request.Context(), key)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to look up idempotency key")
+		return true
+	}
+
+	c.JSON(http.StatusOK, models.EvaluateResponse{
+		ID:       prior.ID.Hex(),
+		Status:   string(prior.Status),
+		Replayed: true,
+	})
+	return true
+}
+
+// validIdempotencyKey reports whether an Idempotency-Key header is short printable ASCII
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// respondIfCached writes the result of a completed job with the same content hash instead of starting a new
+// job. It reports whether a response was written.
+func (h *EvaluationHandler) respondIfCached(c *gin.Context, job *models.EvaluationJob) bool {
+	prior := h.findCachedResult(c.Request.Context(), job)
+	if prior == nil {
+		return false
+	}
+
+	c.JSON(http.StatusOK, models.EvaluateResponse{
+		ID:     prior.ID.Hex(),
+		Status: string(prior.Status),
+		Cached: true,
+		Result: prior.Result,
+	})
+	return true
+}
+
+// findCachedResult returns the newest completed job within the result cache TTL that evaluated the same
+// content, or nil when there is none
+func (h *EvaluationHandler) findCachedResult(ctx context.Context, job *models.EvaluationJob) *models.EvaluationJob {
+	if h.resultCacheTTL <= 0 {
+		return nil
+	}
+
+	h.hashJob(ctx, job)
+	if job.ContentHash == "" {
+		return nil
+	}
+
+	prior, err := h.repository.FindCompletedJobByContentHash(ctx, job.ContentHash, time.Now().Add(-h.resultCacheTTL))
+	if err != nil || prior.Result == nil {
+		// Lookup failures must not block new submissions
+		return nil
+	}
+
+	return prior
+}
+
+// hashJob sets the content hashes of a new job so later submissions of the same content can find it
+func (h *EvaluationHandler) hashJob(ctx context.Context, job *models.EvaluationJob) {
+	if job.ProjectHash == "" {
+		job.ProjectHash = services.HashContent(job.ProjectContent)
+	}
+	if job.ContentHash != "" {
+		return
+	}
+
+	hash, err := h.evaluationService.ContentHash(ctx, job)
+	if err != nil {
+		log.Printf("Warning: failed to hash job content: %v", err)
+		return
+	}
+	job.ContentHash = hash
+}
+
+// respondIfDuplicate writes the prior job instead of starting a new one when the same CV was
+// submitted within the duplicate window. It reports whether a response was written.
+func (h *EvaluationHandler) respondIfDuplicate(c *gin.Context, job *models.EvaluationJob) bool {
+	prior := h.findDuplicate(c.Request.Context(), job)
+	if prior == nil {
+		return false
+	}
+
+	response := models.EvaluateResponse{
+		ID:        prior.ID.Hex(),
+		Status:    string(prior.Status),
+		Duplicate: true,
+	}
+	if prior.Status == models.StatusCompleted {
+		response.Result = prior.Result
+		response.Warning = "This CV was already evaluated; returning the prior result. Set force=true to re-evaluate."
+	} else {
+		response.Warning = "This CV is already being evaluated; returning the prior job. Set force=true to re-evaluate."
+	}
+
+	c.JSON(http.StatusOK, response)
+	return true
+}
+
+// findDuplicate returns the recent job that already covers the same CV, or nil when there is none
+func (h *EvaluationHandler) findDuplicate(ctx context.Context, job *models.EvaluationJob) *models.EvaluationJob {
+	if h.duplicateWindow <= 0 {
+		return nil
+	}
+
+	job.CVHash = services.HashContent(job.CVContent)
+	prior, err := h.repository.FindRecentJobByCVHash(ctx, job.CVHash, job.JobDescriptionID, job.Sandbox, time.Now().Add(-h.duplicateWindow))
+	if err != nil {
+		// Lookup failures must not block new submissions
+		return nil
+	}
+	// A regular result would defeat a blind request, and the other way round
+	if prior.Blind != job.Blind {
+		return nil
+	}
+
+	return prior
+}
+
+// createAndEnqueueJob persists a new evaluation job, queues it and writes the response.
+// Sandbox jobs are evaluated inline with the mock LLM instead of being queued.
+func (h *EvaluationHandler) createAndEnqueueJob(c *gin.Context, job *models.EvaluationJob) {
+	h.hashJob(c.Request.Context(), job)
+	initJob(c.Request.Context(), job)
+	job.IdempotencyKey = c.GetHeader("Idempotency-Key")
+
+	// Save job to database
+	jobID, err := h.repository.CreateJob(c.Request.Context(), job)
+	if errors.Is(err, repositories.ErrDuplicateIdempotencyKey) {
+		// A concurrent request with the same key created the job first
+		if !h.respondIfReplayed(c) {
+			respondWithError(c, http.StatusConflict, models.ErrorCodeConflict, "A request with the same Idempotency-Key is in progress")
+		}
+		return
+	}
+	if err != nil {
+		// Keep accepting work during short database outages when a buffer is available
+		if h.jobBuffer != nil && !job.Sandbox {
+			if bufferErr := h.jobBuffer.Buffer(c.Request.Context(), job, err); bufferErr == nil {
+				c.JSON(http.StatusAccepted, models.EvaluateResponse{
+					ID:       job.ID.Hex(),
+					Status:   string(job.Status),
+					Degraded: true,
+				})
+				return
+			}
+		}
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to create evaluation job")
+		return
+	}
+	job.ID = jobID.(primitive.ObjectID)
+	fmt.Println("Job created: ", job.ID.Hex())
+
+	if job.Sandbox {
+		// Mock evaluations are instant, so run them without the queue
+		if err := h.evaluateSandboxJob(c.Request.Context(), job.ID.Hex()); err != nil {
+			respondWithError(c, http.StatusInternalServerError, models.ErrorCodeLLMFailure, "Sandbox evaluation failed: "+err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, models.EvaluateResponse{
+			ID:     job.ID.Hex(),
+			Status: string(models.StatusCompleted),
+		})
+		return
+	}
+
+	// Add job to queue; the job was saved with its enqueue pending, so the outbox dispatcher retries a failed push
+	if err := h.jobQueue.AddJob(job); err != nil {
+		log.Printf("Error queueing job %s, left to the outbox dispatcher: %v", job.ID.Hex(), err)
+	}
+
+	// Return response
+	response := models.EvaluateResponse{
+		ID:     job.ID.Hex(),
+		Status: string(job.Status),
+		RunAt:  job.RunAt,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// evaluateSandboxJob claims a sandbox job and evaluates it inline, failing the job when the evaluation fails
+func (h *EvaluationHandler) evaluateSandboxJob(ctx context.Context, jobID string) error {
+	claimToken, err := h.repository.ClaimJob(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	if err := h.sandboxEvaluationService.EvaluateCandidate(ctx, jobID, claimToken); err != nil {
+		h.repository.UpdateJobError(ctx, jobID, claimToken, models.ErrorTypeEvaluation, err.Error())
+		return err
+	}
+	return nil
+}
+
+// StartBatchEvaluation evaluates an applicant pool against the same job, creating a batch that tracks one
+// evaluation job per candidate. Candidates that cannot be evaluated are recorded on the batch with an error
+// instead of failing the whole request.
+func (h *EvaluationHandler) StartBatchEvaluation(c *gin.Context) {
+	var req models.BatchEvaluateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if len(req.Candidates) == 0 {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "At least one candidate is required")
+		return
+	}
+
+	if len(req.Candidates) > maxBatchCandidates {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, fmt.Sprintf("At most %d candidates are allowed", maxBatchCandidates))
+		return
+	}
+
+	var v validation
+	for i, candidate := range req.Candidates {
+		v.fileID(fmt.Sprintf("candidates[%d].cv_file", i), candidate.CVFile)
+		v.fileID(fmt.Sprintf("candidates[%d].project_file", i), candidate.ProjectFile)
+	}
+	if v.respond(c) {
+		return
+	}
+
+	if h.respondIfUnknownJobDescription(c, req.JobDescriptionID) || respondIfInvalidRunAt(c, req.RunAt, req.Sandbox) {
+		return
+	}
+
+	// Scoring options are shared by all candidates, so check them once
+	if h.respondIfInvalidScoring(c, &models.EvaluationJob{ScoringOptions: req.ScoringOptions}) {
+		return
+	}
+
+	// The batch ID is assigned up front so every child job can link back to it
+	batch := &models.BatchJob{
+		ID:               primitive.NewObjectID(),
+		JobDescriptionID: req.JobDescriptionID,
+		ScoringOptions:   req.ScoringOptions,
+		Sandbox:          req.Sandbox,
+		RunAt:            scheduledRunAt(req.RunAt),
+		Items:            make([]models.BatchItem, 0, len(req.Candidates)),
+		CreatedAt:        time.Now(),
+	}
+	for _, candidate := range req.Candidates {
+		batch.Items = append(batch.Items, h.startBatchItem(c.Request.Context(), batch, candidate, req.Force))
+	}
+
+	if err := h.repository.CreateBatchJob(c.Request.Context(), batch); err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to create batch job")
+		return
+	}
+
+	h.respondWithBatch(c, batch)
+}
+
+// StartArchiveBatchEvaluation imports a ZIP archive of an applicant pool, as exported by job boards, and
+// evaluates every CV in it as one batch. Each CV is paired with its project report in the archive by name;
+// an optional project_file is used for CVs without one.
+func (h *EvaluationHandler) StartArchiveBatchEvaluation(c *gin.Context) {
+	ctx := c.Request.Context()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.fileService.MaxArchiveSize()+h.fileService.MaxRequestSize(1))
+
+	archive, err := c.FormFile("archive")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(c, http.StatusRequestEntityTooLarge, models.ErrorCodeBadUpload, "Archive exceeds the maximum allowed size")
+			return
+		}
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "ZIP archive is required")
+		return
+	}
+	if archive.Size > h.fileService.MaxArchiveSize() {
+		respondWithError(c, http.StatusRequestEntityTooLarge, models.ErrorCodeBadUpload, "Archive exceeds the maximum allowed size")
+		return
+	}
+
+	jobDescriptionID := c.PostForm("job_description_id")
+	sandbox, _ := strconv.ParseBool(c.PostForm("sandbox"))
+	force, _ := strconv.ParseBool(c.PostForm("force"))
+	if h.respondIfUnknownJobDescription(c, jobDescriptionID) {
+		return
+	}
+
+	file, err := archive.Open()
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to read archive")
+		return
+	}
+	defer file.Close()
+
+	pairs, skipped, err := h.fileService.ReadArchive(file, archive.Size)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, err.Error())
+		return
+	}
+	if len(pairs) == 0 {
+		respondWithErrorDetails(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "No CVs found in the archive", gin.H{"skipped": skipped})
+		return
+	}
+	if len(pairs) > maxBatchCandidates {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, fmt.Sprintf("At most %d candidates are allowed", maxBatchCandidates))
+		return
+	}
+
+	sharedProject := ""
+	if projectFile, err := c.FormFile("project_file"); err == nil {
+		upload, err := h.fileService.SaveFile(ctx, projectFile)
+		if err != nil {
+			respondWithSaveError(c, saveFailureStatus(err), "Failed to save project file", err)
+			return
+		}
+		sharedProject = upload.ID.Hex()
+	}
+
+	batch := &models.BatchJob{
+		ID:               primitive.NewObjectID(),
+		JobDescriptionID: jobDescriptionID,
+		Sandbox:          sandbox,
+		Items:            make([]models.BatchItem, 0, len(pairs)),
+		Skipped:          skipped,
+		CreatedAt:        time.Now(),
+	}
+	for _, pair := range pairs {
+		candidate, err := h.saveArchivePair(ctx, pair, sharedProject)
+		if err != nil {
+			batch.Items = append(batch.Items, models.BatchItem{Source: pair.CV.Name, Error: err.Error()})
+			continue
+		}
+		item := h.startBatchItem(ctx, batch, candidate, force)
+		item.Source = pair.CV.Name
+		batch.Items = append(batch.Items, item)
+	}
+
+	if err := h.repository.CreateBatchJob(ctx, batch); err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to create batch job")
+		return
+	}
+
+	h.respondWithBatch(c, batch)
 }
 
-func NewEvaluationHandler(
-	repository *repositories.MongoDBRepository,
-	evaluationService *services.EvaluationService,
-	jobQueue *services.JobQueue,
-	fileService *services.FileService,
-) *EvaluationHandler {
-	return &EvaluationHandler{
-		repository:        repository,
-		evaluationService: evaluationService,
-		jobQueue:          jobQueue,
-		fileService:       fileService,
+// saveArchivePair saves a CV from an archive and its project report, falling back to the shared report.
+// The candidate refers to the files by upload ID, like those of a batch request.
+func (h *EvaluationHandler) saveArchivePair(ctx context.Context, pair services.ArchivePair, sharedProject string) (models.BatchCandidate, error) {
+	if pair.Project == nil && sharedProject == "" {
+		return models.BatchCandidate{}, errors.New("no matching project report in the archive")
 	}
+
+	cvUpload, err := h.fileService.SaveArchiveFile(ctx, pair.CV)
+	if err != nil {
+		return models.BatchCandidate{}, fmt.Errorf("failed to save CV file: %w", err)
+	}
+	candidate := models.BatchCandidate{CVFile: cvUpload.ID.Hex(), ProjectFile: sharedProject}
+	if pair.Project != nil {
+		projectUpload, err := h.fileService.SaveArchiveFile(ctx, pair.Project)
+		if err != nil {
+			h.fileService.CleanupFile(ctx, cvUpload)
+			return models.BatchCandidate{}, fmt.Errorf("failed to save project file %s: %w", pair.Project.Name, err)
+		}
+		candidate.ProjectFile = projectUpload.ID.Hex()
+	}
+	return candidate, nil
 }
 
-// StartEvaluation starts the evaluation process
-func (h *EvaluationHandler) StartEvaluation(c *gin.Context) {
-	var (
-		req   models.EvaluateRequest
-		jobID interface{}
-		err   error
-	)
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
-		return
+// startBatchItem creates and queues the evaluation job of one batch candidate.
+// Sandbox jobs are evaluated inline, as for single evaluations.
+func (h *EvaluationHandler) startBatchItem(ctx context.Context, batch *models.BatchJob, candidate models.BatchCandidate, force bool) models.BatchItem {
+	item := models.BatchItem{
+		CandidateID: candidate.CandidateID,
+		CVFile:      candidate.CVFile,
+		ProjectFile: candidate.ProjectFile,
 	}
 
-	// Read content from files
-	cvContent, err := h.readFileContent(req.CVFile)
+	cvUpload, cvContent, err := h.readUpload(ctx, candidate.CVFile)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read CV file: " + err.Error()})
-		return
+		item.Error = "Failed to read CV file: " + err.Error()
+		return item
 	}
 
-	projectContent, err := h.readFileContent(req.ProjectFile)
+	projectUpload, projectContent, err := h.readUpload(ctx, candidate.ProjectFile)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read project file: " + err.Error()})
-		return
+		item.Error = "Failed to read project file: " + err.Error()
+		return item
 	}
+	// Like jobs, items keep the stored file names the uploads resolved to
+	item.CVFile, item.ProjectFile = cvUpload.Filename, projectUpload.Filename
 
-	// Create new evaluation job
 	job := &models.EvaluationJob{
-		Status:         models.StatusQueued,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
-		CVFile:         req.CVFile,
-		ProjectFile:    req.ProjectFile,
-		CVContent:      cvContent,
-		ProjectContent: projectContent,
-		RetryCount:     0,
+		CVFile:           cvUpload.Filename,
+		ProjectFile:      projectUpload.Filename,
+		CVContent:        cvContent,
+		ProjectContent:   projectContent,
+		CandidateID:      candidate.CandidateID,
+		CandidateName:    candidate.CandidateName,
+		JobDescriptionID: batch.JobDescriptionID,
+		BatchID:          batch.ID.Hex(),
+		ScoringOptions:   batch.ScoringOptions,
+		Sandbox:          batch.Sandbox,
+		RunAt:            batch.RunAt,
+	}
+	if err := h.evaluationService.CheckLanguages(job); err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	if err := h.evaluationServiceFor(job).CheckContent(ctx, job); err != nil {
+		item.Error = err.Error()
+		return item
 	}
 
-	// Save job to database
-	if jobID, err = h.repository.CreateJob(c.Request.Context(), job); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create evaluation job"})
-		return
+	if !force {
+		if prior := h.findCachedResult(ctx, job); prior != nil {
+			item.JobID = prior.ID.Hex()
+			item.Cached = true
+			return item
+		}
+		if prior := h.findDuplicate(ctx, job); prior != nil {
+			item.JobID = prior.ID.Hex()
+			item.Duplicate = true
+			return item
+		}
+	}
+
+	h.hashJob(ctx, job)
+	initJob(ctx, job)
+	jobID, err := h.repository.CreateJob(ctx, job)
+	if err != nil {
+		item.Error = "Failed to create evaluation job"
+		return item
 	}
 	job.ID = jobID.(primitive.ObjectID)
-	fmt.Println("Job created: ", job.ID.Hex())
+	item.JobID = job.ID.Hex()
+
+	if job.Sandbox {
+		h.evaluateSandboxJob(ctx, item.JobID)
+		return item
+	}
+
+	if err := h.jobQueue.AddJob(job); err != nil {
+		log.Printf("Error queueing job %s, left to the outbox dispatcher: %v", item.JobID, err)
+	}
+
+	return item
+}
 
-	// Add job to queue
-	if err := h.jobQueue.AddJob(job.ID.Hex()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add job to queue"})
+// GetBatch retrieves the aggregate progress and per-candidate results of a batch evaluation
+func (h *EvaluationHandler) GetBatch(c *gin.Context) {
+	batch, err := h.repository.GetBatchJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Batch not found")
 		return
 	}
 
-	// Return response
-	response := models.EvaluateResponse{
-		ID:     job.ID.Hex(),
-		Status: string(job.Status),
+	h.respondWithBatch(c, batch)
+}
+
+// respondWithBatch writes the current state of a batch from its child jobs
+func (h *EvaluationHandler) respondWithBatch(c *gin.Context, batch *models.BatchJob) {
+	jobIDs := make([]string, 0, len(batch.Items))
+	for _, item := range batch.Items {
+		if item.JobID != "" {
+			jobIDs = append(jobIDs, item.JobID)
+		}
 	}
 
-	c.JSON(http.StatusOK, response)
+	jobs, err := h.repository.GetJobsByIDs(c.Request.Context(), jobIDs)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve batch jobs")
+		return
+	}
+
+	jobsByID := make(map[string]*models.EvaluationJob, len(jobs))
+	for _, job := range jobs {
+		jobsByID[job.ID.Hex()] = job
+	}
+
+	c.JSON(http.StatusOK, batchResponse(batch, jobsByID))
+}
+
+// batchResponse aggregates the child jobs of a batch. Failed and rejected candidates count as finished
+// so they do not hold back the batch's progress.
+func batchResponse(batch *models.BatchJob, jobsByID map[string]*models.EvaluationJob) models.BatchResponse {
+	response := models.BatchResponse{
+		ID:               batch.ID.Hex(),
+		Status:           string(models.StatusCompleted),
+		JobDescriptionID: batch.JobDescriptionID,
+		CreatedAt:        batch.CreatedAt,
+		Total:            len(batch.Items),
+		Counts:           map[string]int{},
+		Candidates:       make([]models.BatchCandidateStatus, 0, len(batch.Items)),
+		Skipped:          batch.Skipped,
+	}
+
+	progress := 0
+	for _, item := range batch.Items {
+		candidate := models.BatchCandidateStatus{
+			JobID:       item.JobID,
+			CandidateID: item.CandidateID,
+			CVFile:      item.CVFile,
+			ProjectFile: item.ProjectFile,
+			Source:      item.Source,
+			Status:      batchItemRejected,
+			Progress:    100,
+			Duplicate:   item.Duplicate,
+			Cached:      item.Cached,
+			Error:       item.Error,
+		}
+
+		if job, ok := jobsByID[item.JobID]; ok {
+			candidate.Status = string(job.Status)
+			candidate.Progress = jobProgress(job)
+			candidate.Result = job.Result
+			if job.ErrorMessage != "" {
+				candidate.Error = job.ErrorMessage
+			}
+		} else if item.JobID != "" {
+			candidate.Error = "Job not found"
+		}
+
+		switch candidate.Status {
+		case string(models.StatusQueued), string(models.StatusProcessing):
+			response.Status = string(models.StatusProcessing)
+			progress += candidate.Progress
+		default:
+			progress += 100
+		}
+		response.Counts[candidate.Status]++
+		response.Candidates = append(response.Candidates, candidate)
+	}
+
+	if len(batch.Items) > 0 {
+		response.Progress = progress / len(batch.Items)
+	}
+
+	return response
+}
+
+// initJob sets the initial state of a new job, owned by the caller's organization and traced with its request,
+// and flags documents that look like prompt-injection attempts
+func initJob(ctx context.Context, job *models.EvaluationJob) {
+	if job.CVHash == "" {
+		job.CVHash = services.HashContent(job.CVContent)
+	}
+	job.InjectionSignals = services.DetectInjection(job.CVContent, job.ProjectContent)
+	job.InjectionRisk = len(job.InjectionSignals) > 0
+	job.OrgID = tenant.OrgID(ctx)
+	job.TraceParent = telemetry.TraceParent(ctx)
+	job.TraceID = telemetry.TraceID(ctx)
+	job.Status = models.StatusQueued
+	// Sandbox jobs are evaluated inline; the others are saved with their enqueue pending (see JobQueue.AddJob)
+	job.EnqueuePending = !job.Sandbox
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = time.Now()
+	job.RetryCount = 0
+}
+
+// readUpload resolves a file ID returned by /upload to the organization's upload and reads its content
+func (h *EvaluationHandler) readUpload(ctx context.Context, fileID string) (*models.Upload, string, error) {
+	upload, err := h.fileService.ResolveUpload(ctx, fileID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	content, err := h.readFileContent(upload.Filename)
+	if err != nil {
+		return nil, "", err
+	}
+	return upload, content, nil
+}
+
+// respondWithReadError answers a request whose CV or project file could not be read
+func respondWithReadError(c *gin.Context, document string, err error) {
+	if errors.Is(err, services.ErrUnknownFile) {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Unknown "+document+" file, upload it first")
+		return
+	}
+	respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "Failed to read "+document+" file: "+err.Error())
 }
 
-// readFileContent reads content from a file
+// readFileContent reads content from a stored upload by the name it was stored under
 func (h *EvaluationHandler) readFileContent(filename string) (string, error) {
-	// Construct file path (assuming files are in uploads directory)
-	filePath := filepath.Join("uploads", filename)
+	// Stored names come from the upload records; refuse anything that could resolve outside the upload directory regardless
+	if err := services.ValidateUploadName(filename); err != nil {
+		return "", err
+	}
+	filePath := h.fileService.UploadPath(filename)
 
 	// Extract text content from file
 	content, err := h.fileService.ExtractTextFromFile(filePath)
@@ -116,54 +1258,202 @@ func (h *EvaluationHandler) readFileContent(filename string) (string, error) {
 	return content, nil
 }
 
-// GetResult retrieves the evaluation result
+// getJob loads a job from the repository, falling back to jobs buffered during a database outage
+func (h *EvaluationHandler) getJob(c *gin.Context, jobID string) (*models.EvaluationJob, error) {
+	job, err := h.repository.GetJobByID(c.Request.Context(), jobID)
+	if err != nil && h.jobBuffer != nil {
+		if buffered, bufferErr := h.jobBuffer.Get(c.Request.Context(), jobID); bufferErr == nil {
+			return buffered, nil
+		}
+	}
+	return job, err
+}
+
+// DeleteJob soft-deletes a job: it disappears from every endpoint and is removed for good by the
+// admin purge or the retention policy
+func (h *EvaluationHandler) DeleteJob(c *gin.Context) {
+	jobID := c.Param("id")
+	if err := h.repository.SoftDeleteJob(c.Request.Context(), jobID); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job not found")
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to delete job")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job deleted", "id": jobID})
+}
+
+// GetResult retrieves the evaluation result. With rank=true, completed results also report where the
+// overall score sits among all completed evaluations for the same job description.
 func (h *EvaluationHandler) GetResult(c *gin.Context) {
 	jobID := c.Param("id")
 	if jobID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Job ID is required"})
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Job ID is required")
 		return
 	}
 
 	// Get job from database
-	job, err := h.repository.GetJobByID(c.Request.Context(), jobID)
+	job, err := h.getJob(c, jobID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job not found")
 		return
 	}
 
 	// Prepare response
+	response := resultResponse(job)
+
+	// Earlier results are kept as versions: version=N returns one of them in place of the current result,
+	// history=true lists them all
+	versions := resultVersions(job)
+	if raw := c.Query("version"); raw != "" {
+		version, err := strconv.Atoi(raw)
+		if err != nil || version < 1 {
+			respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "version must be a positive integer")
+			return
+		}
+		if version > len(versions) {
+			respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, fmt.Sprintf("Job has no result version %d", version))
+			return
+		}
+		response.Result = versions[version-1]
+		response.Scale = response.Result.Scale
+	}
+	if c.Query("history") == "true" {
+		response.Versions = versions
+	}
+
+	if c.Query("rank") == "true" && job.Status == models.StatusCompleted && response.Result != nil {
+		counts, err := h.repository.GetScoreCounts(c.Request.Context(), repositories.ScoreCohort{
+			JobDescriptionID: job.JobDescriptionID,
+			Sandbox:          job.Sandbox,
+		}, response.Result.OverallScore)
+		if err != nil {
+			respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to rank result")
+			return
+		}
+		response.Rank = scoreRank(counts)
+	}
+
+	// Shows prompt engineers which documents and chunks each step's context was built from
+	if c.Query("debug") == "true" {
+		response.Retrieval = job.Retrieval
+	}
+
+	// A failed job is the outcome being read, not an error of this request
+	c.JSON(http.StatusOK, response)
+}
+
+// resultResponse describes a job's outcome
+func resultResponse(job *models.EvaluationJob) models.ResultResponse {
 	response := models.ResultResponse{
 		ID:     job.ID.Hex(),
 		Status: string(job.Status),
 		Result: job.Result,
 		Error:  job.ErrorMessage,
+		Usage:  job.Usage,
+
+		ErrorType: job.ErrorType,
+
+		InjectionRisk:    job.InjectionRisk,
+		InjectionSignals: job.InjectionSignals,
 	}
+	if job.Result != nil {
+		response.Scale = job.Result.Scale
+	}
+	return response
+}
 
-	// Return appropriate status code based on job status
-	switch job.Status {
-	case models.StatusQueued, models.StatusProcessing:
-		c.JSON(http.StatusOK, response)
-	case models.StatusCompleted:
-		c.JSON(http.StatusOK, response)
-	case models.StatusFailed:
-		c.JSON(http.StatusInternalServerError, response)
-	default:
-		c.JSON(http.StatusOK, response)
+// resultVersions returns every result a job has had, oldest first, numbering those stored before versioning
+// by their position
+func resultVersions(job *models.EvaluationJob) []*models.EvaluationResult {
+	versions := job.Results()
+	for i, result := range versions {
+		if result.Version == 0 {
+			result.Version = i + 1
+		}
+	}
+	return versions
+}
+
+// scoreRank converts cohort counts into a rank and a percentile rank, counting ties as half below
+func scoreRank(counts *repositories.ScoreCounts) *models.ScoreRank {
+	rank := &models.ScoreRank{
+		Rank:       counts.Total - counts.Below - counts.Equal + 1,
+		CohortSize: counts.Total,
+	}
+	if counts.Total > 0 {
+		percentile := (float64(counts.Below) + float64(counts.Equal)/2) / float64(counts.Total) * 100
+		rank.Percentile = math.Round(percentile*10) / 10
+	}
+	return rank
+}
+
+// BatchGetResults retrieves the status and result of several jobs in one call
+func (h *EvaluationHandler) BatchGetResults(c *gin.Context) {
+	// The route is registered as a param so only the batchGet custom method is accepted
+	if c.Param("method") != ":batchGet" {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Unknown method")
+		return
 	}
+
+	var req models.BatchGetResultsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "At least one job ID is required")
+		return
+	}
+
+	if len(req.IDs) > maxBatchGetIDs {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, fmt.Sprintf("At most %d job IDs are allowed", maxBatchGetIDs))
+		return
+	}
+
+	jobs, err := h.repository.GetJobsByIDs(c.Request.Context(), req.IDs)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve jobs")
+		return
+	}
+
+	jobsByID := make(map[string]*models.EvaluationJob, len(jobs))
+	for _, job := range jobs {
+		jobsByID[job.ID.Hex()] = job
+	}
+
+	// Keep the order of the requested IDs
+	response := models.BatchGetResultsResponse{
+		Results:  []models.ResultResponse{},
+		NotFound: []string{},
+	}
+	for _, id := range req.IDs {
+		job, ok := jobsByID[id]
+		if !ok {
+			response.NotFound = append(response.NotFound, id)
+			continue
+		}
+
+		response.Results = append(response.Results, resultResponse(job))
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // GetJobStatus retrieves the current status of a job
 func (h *EvaluationHandler) GetJobStatus(c *gin.Context) {
 	jobID := c.Param("id")
 	if jobID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Job ID is required"})
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Job ID is required")
 		return
 	}
 
 	// Get job from database
-	job, err := h.repository.GetJobByID(c.Request.Context(), jobID)
+	job, err := h.getJob(c, jobID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job not found")
 		return
 	}
 
@@ -175,6 +1465,27 @@ func (h *EvaluationHandler) GetJobStatus(c *gin.Context) {
 		"updated_at": job.UpdatedAt,
 	}
 
+	if job.JobDescriptionID != "" {
+		response["job_description_id"] = job.JobDescriptionID
+	}
+
+	if job.PreviousJobID != "" {
+		response["previous_job_id"] = job.PreviousJobID
+	}
+
+	if job.TraceID != "" {
+		response["trace_id"] = job.TraceID
+	}
+
+	if job.RunAt != nil {
+		response["run_at"] = job.RunAt
+	}
+
+	if len(job.Steps) > 0 {
+		response["steps"] = job.Steps
+	}
+	response["progress"] = jobProgress(job)
+
 	if job.StartedAt != nil {
 		response["started_at"] = job.StartedAt
 	}
@@ -185,6 +1496,7 @@ func (h *EvaluationHandler) GetJobStatus(c *gin.Context) {
 
 	if job.ErrorMessage != "" {
 		response["error"] = job.ErrorMessage
+		response["error_type"] = job.ErrorType
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -192,31 +1504,54 @@ func (h *EvaluationHandler) GetJobStatus(c *gin.Context) {
 
 // ListJobs retrieves all jobs (for admin purposes)
 func (h *EvaluationHandler) ListJobs(c *gin.Context) {
+	var v validation
+	opts := parseJobFilters(c, &v)
+
 	// Get query parameters
-	status := c.Query("status")
-	limit := c.DefaultQuery("limit", "10")
-	offset := c.DefaultQuery("offset", "0")
+	sortBy := c.DefaultQuery("sort_by", "created_at")
+	order := c.DefaultQuery("order", "desc")
+	after := c.Query("after")
 
-	// Parse limit and offset
-	limitInt := 10
-	offsetInt := 0
+	// Parse sort options
+	sortField, ok := jobSortFields[sortBy]
+	v.check(ok, "sort_by", "must be one of created_at, completed_at, overall_score")
 
-	if limit != "" {
-		if parsed, err := strconv.Atoi(limit); err == nil {
-			limitInt = parsed
-		}
+	sortOrder := -1
+	if order == "asc" {
+		sortOrder = 1
 	}
+	v.oneOf("order", order, "asc", "desc")
 
-	if offset != "" {
-		if parsed, err := strconv.Atoi(offset); err == nil {
-			offsetInt = parsed
-		}
+	// Parse limit and offset
+	limitInt, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	v.check(err == nil && limitInt >= 1 && limitInt <= maxJobPageSize, "limit", "must be an integer from 1 to %d", maxJobPageSize)
+	offsetInt, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	v.check(err == nil && offsetInt >= 0, "offset", "must be a non-negative integer")
+	v.check(after == "" || offsetInt <= 0, "after", "cannot be combined with offset")
+
+	if v.respond(c) {
+		return
 	}
 
 	// Get jobs from database
-	jobs, err := h.repository.GetJobsWithFilters(c.Request.Context(), status, limitInt, offsetInt)
+	opts.Limit = limitInt
+	opts.Offset = offsetInt
+	opts.SortBy = sortField
+	opts.SortOrder = sortOrder
+	opts.After = after
+	jobs, err := h.repository.GetJobsWithFilters(c.Request.Context(), opts)
+	if errors.Is(err, repositories.ErrInvalidCursor) {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid after, must be the ID of a listed job")
+		return
+	}
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve jobs")
+		return
+	}
+
+	total, err := h.repository.CountJobs(c.Request.Context(), opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve jobs"})
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to count jobs")
 		return
 	}
 
@@ -230,6 +1565,18 @@ func (h *EvaluationHandler) ListJobs(c *gin.Context) {
 			"updated_at": job.UpdatedAt,
 		}
 
+		if job.CandidateID != "" {
+			jobResponse["candidate_id"] = job.CandidateID
+		}
+
+		if job.CandidateName != "" {
+			jobResponse["candidate_name"] = job.CandidateName
+		}
+
+		if job.JobDescriptionID != "" {
+			jobResponse["job_description_id"] = job.JobDescriptionID
+		}
+
 		if job.StartedAt != nil {
 			jobResponse["started_at"] = job.StartedAt
 		}
@@ -244,15 +1591,231 @@ func (h *EvaluationHandler) ListJobs(c *gin.Context) {
 
 		if job.ErrorMessage != "" {
 			jobResponse["error"] = job.ErrorMessage
+			jobResponse["error_type"] = job.ErrorType
 		}
 
 		response = append(response, jobResponse)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"jobs":   response,
-		"total":  len(response),
-		"limit":  limitInt,
-		"offset": offsetInt,
-	})
+	body := gin.H{
+		"jobs":    response,
+		"total":   total,
+		"limit":   limitInt,
+		"offset":  offsetInt,
+		"sort_by": sortBy,
+		"order":   order,
+	}
+	// A full page may be followed by another; pass next_cursor as after to fetch it
+	if len(jobs) == limitInt {
+		body["next_cursor"] = jobs[len(jobs)-1].ID.Hex()
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// parseJobFilters reads the filters shared by ListJobs and ExportJobs, recording invalid values in v
+func parseJobFilters(c *gin.Context, v *validation) repositories.JobListOptions {
+	opts := repositories.JobListOptions{
+		Status:        c.Query("status"),
+		CandidateID:   c.Query("candidate_id"),
+		CandidateName: strings.TrimSpace(c.Query("candidate_name")),
+		Search:        strings.TrimSpace(c.Query("q")),
+	}
+	v.oneOf("status", opts.Status, string(models.StatusQueued), string(models.StatusProcessing),
+		string(models.StatusCompleted), string(models.StatusFailed))
+
+	// from and to are inclusive dates
+	if from := c.Query("from"); from != "" {
+		date, err := time.Parse("2006-01-02", from)
+		v.check(err == nil, "from", "must be a date in YYYY-MM-DD format")
+		opts.CreatedFrom = date
+	}
+	if to := c.Query("to"); to != "" {
+		date, err := time.Parse("2006-01-02", to)
+		v.check(err == nil, "to", "must be a date in YYYY-MM-DD format")
+		opts.CreatedBefore = date.AddDate(0, 0, 1)
+	}
+
+	for _, score := range jobScoreFilters {
+		minScore, err := floatQuery(c, "min_"+score.name)
+		v.check(err == nil, "min_"+score.name, "must be a number")
+		maxScore, err := floatQuery(c, "max_"+score.name)
+		v.check(err == nil, "max_"+score.name, "must be a number")
+		if minScore != nil || maxScore != nil {
+			opts.ScoreRanges = append(opts.ScoreRanges, repositories.ScoreRange{Field: score.field, Min: minScore, Max: maxScore})
+		}
+	}
+
+	return opts
+}
+
+// floatQuery parses an optional numeric query parameter; it returns nil when the parameter is absent
+func floatQuery(c *gin.Context, name string) (*float64, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// ExportJobs streams the jobs matching the ListJobs filters as a CSV or XLSX spreadsheet, newest first.
+// Jobs are written as they are read so exports of any size use constant memory.
+func (h *EvaluationHandler) ExportJobs(c *gin.Context) {
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid format, must be csv or xlsx")
+		return
+	}
+
+	var v validation
+	opts := parseJobFilters(c, &v)
+	if v.respond(c) {
+		return
+	}
+	opts.SortBy = "created_at"
+	opts.SortOrder = -1
+
+	filename := fmt.Sprintf("jobs-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	writer, err := export.NewWriter(format, c.Writer)
+	if err == nil {
+		err = writer.WriteRow(jobExportColumns)
+	}
+	if err == nil {
+		err = h.repository.StreamJobs(c.Request.Context(), opts, func(job *models.EvaluationJob) error {
+			return writer.WriteRow(jobExportRow(job))
+		})
+	}
+	if err != nil {
+		// The status was already sent, so the export is left truncated; XLSX files will not open
+		log.Printf("Job export failed: %v", err)
+		return
+	}
+
+	if err := writer.Close(); err != nil {
+		log.Printf("Job export failed: %v", err)
+	}
+}
+
+// jobExportRow returns the export cells of a job in jobExportColumns order
+func jobExportRow(job *models.EvaluationJob) []interface{} {
+	row := []interface{}{
+		job.ID.Hex(), string(job.Status), job.CandidateID, job.CandidateName, job.JobDescriptionID, job.BatchID, job.CVFile, job.ProjectFile,
+		nil, nil, nil, job.RetryCount,
+		exportTime(&job.CreatedAt), exportTime(job.StartedAt), exportTime(job.CompletedAt), job.ErrorMessage,
+	}
+	if job.Result != nil {
+		row[8], row[9], row[10] = job.Result.CVMatchRate, job.Result.ProjectScore, job.Result.OverallScore
+	}
+	return row
+}
+
+// exportTime formats a timestamp as RFC 3339 in UTC, or an empty cell when unset
+func exportTime(t *time.Time) interface{} {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// DiffEvaluations compares two completed evaluations of a candidate. Without from/to it compares
+// the candidate's two most recent completed evaluations.
+func (h *EvaluationHandler) DiffEvaluations(c *gin.Context) {
+	candidateID := c.Param("id")
+	fromID := c.Query("from")
+	toID := c.Query("to")
+
+	if (fromID == "") != (toID == "") {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "from and to must be provided together")
+		return
+	}
+
+	var fromJob, toJob *models.EvaluationJob
+	if fromID == "" {
+		jobs, err := h.repository.GetJobsWithFilters(c.Request.Context(), repositories.JobListOptions{
+			Status:      string(models.StatusCompleted),
+			CandidateID: candidateID,
+			Limit:       2,
+			SortBy:      "created_at",
+			SortOrder:   -1,
+		})
+		if err != nil {
+			respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve evaluations")
+			return
+		}
+		if len(jobs) < 2 {
+			respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Candidate needs at least two completed evaluations")
+			return
+		}
+		fromJob, toJob = jobs[1], jobs[0]
+	} else {
+		var ok bool
+		if fromJob, ok = h.getCandidateEvaluation(c, candidateID, fromID); !ok {
+			return
+		}
+		if toJob, ok = h.getCandidateEvaluation(c, candidateID, toID); !ok {
+			return
+		}
+	}
+
+	diff := services.DiffEvaluations(fromJob, toJob)
+
+	if c.DefaultQuery("narrative", "true") != "false" {
+		evaluationService := h.evaluationService
+		if fromJob.Sandbox || toJob.Sandbox {
+			evaluationService = h.sandboxEvaluationService
+		}
+
+		// The score and feedback diff is still useful when the narrative cannot be generated
+		narrative, err := evaluationService.DescribeImprovement(c.Request.Context(), diff)
+		if err != nil {
+			diff.NarrativeError = err.Error()
+		} else {
+			diff.Narrative = narrative
+		}
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// getCandidateEvaluation loads a completed job belonging to the candidate, writing an error response when it cannot
+func (h *EvaluationHandler) getCandidateEvaluation(c *gin.Context, candidateID, jobID string) (*models.EvaluationJob, bool) {
+	job, err := h.repository.GetJobByID(c.Request.Context(), jobID)
+	if err != nil || job.CandidateID != candidateID {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Evaluation "+jobID+" not found for candidate")
+		return nil, false
+	}
+
+	if job.Status != models.StatusCompleted || job.Result == nil {
+		respondWithError(c, http.StatusConflict, models.ErrorCodeConflict, "Evaluation "+jobID+" is not completed")
+		return nil, false
+	}
+
+	return job, true
+}
+
+// jobProgress reports the percentage of pipeline steps a job has completed
+func jobProgress(job *models.EvaluationJob) int {
+	if job.Status == models.StatusCompleted {
+		return 100
+	}
+	if len(job.Steps) == 0 {
+		return 0
+	}
+
+	completed := 0
+	for _, step := range job.Steps {
+		if step.Status == models.StepCompleted {
+			completed++
+		}
+	}
+
+	return completed * 100 / len(job.Steps)
 }
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+	"ai-cv-summarize/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExperimentHandler struct {
+	repository        repositories.Repository
+	experimentService *services.ExperimentService
+}
+
+func NewExperimentHandler(repository repositories.Repository, experimentService *services.ExperimentService) *ExperimentHandler {
+	return &ExperimentHandler{
+		repository:        repository,
+		experimentService: experimentService,
+	}
+}
+
+// StartExperiment starts routing evaluations to the two variants of a new experiment
+func (h *ExperimentHandler) StartExperiment(c *gin.Context) {
+	var req models.ExperimentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	experiment, err := h.experimentService.Start(c.Request.Context(), req)
+	switch {
+	case errors.Is(err, services.ErrInvalidExperiment):
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, err.Error())
+		return
+	case errors.Is(err, services.ErrExperimentRunning):
+		respondWithError(c, http.StatusConflict, models.ErrorCodeConflict, err.Error()+"; stop it first")
+		return
+	case err != nil:
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to start experiment: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, experiment)
+}
+
+// ListExperiments returns every experiment, newest first
+func (h *ExperimentHandler) ListExperiments(c *gin.Context) {
+	experiments, err := h.repository.GetExperiments(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to get experiments")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"experiments": experiments, "total": len(experiments)})
+}
+
+// GetExperiment returns one experiment
+func (h *ExperimentHandler) GetExperiment(c *gin.Context) {
+	experiment, err := h.repository.GetExperiment(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Experiment not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, experiment)
+}
+
+// StopExperiment stops routing evaluations to an experiment
+func (h *ExperimentHandler) StopExperiment(c *gin.Context) {
+	experiment, err := h.experimentService.Stop(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Experiment not found")
+		return
+	}
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to stop experiment: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, experiment)
+}
+
+// CompareExperiment compares the score distributions of an experiment's variants and how often they
+// disagree on the same CV
+func (h *ExperimentHandler) CompareExperiment(c *gin.Context) {
+	experiment, err := h.repository.GetExperiment(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Experiment not found")
+		return
+	}
+
+	comparison, err := h.experimentService.Compare(c.Request.Context(), experiment)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to compare experiment: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, comparison)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/llm"
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+	"ai-cv-summarize/internal/services"
+	"ai-cv-summarize/internal/version"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type HealthHandler struct {
+	repository  repositories.Repository
+	redisClient redis.UniversalClient
+	llmClient   llm.LLMClient
+	jobBuffer   *services.JobBuffer
+	config      *config.HealthConfig
+	llmProvider string
+	llmModel    string
+}
+
+func NewHealthHandler(
+	repository repositories.Repository,
+	redisClient redis.UniversalClient,
+	llmClient llm.LLMClient,
+	jobBuffer *services.JobBuffer,
+	config *config.HealthConfig,
+	llmProvider, llmModel string,
+) *HealthHandler {
+	return &HealthHandler{
+		repository:  repository,
+		redisClient: redisClient,
+		llmClient:   llmClient,
+		jobBuffer:   jobBuffer,
+		config:      config,
+		llmProvider: llmProvider,
+		llmModel:    llmModel,
+	}
+}
+
+// dependencyCheck is the outcome of pinging one dependency
+type dependencyCheck struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Health reports service health along with build information
+func (h *HealthHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"version": h.versionInfo(),
+	})
+}
+
+// Live is the liveness probe. It checks no dependencies, so an outage elsewhere never gets the process restarted.
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready is the readiness probe. It pings MongoDB, Redis when the queue uses it, and the LLM provider when
+// READINESS_CHECK_LLM is set, each bounded by the check timeout:
+//   - A failed database keeps the service ready as "degraded" while evaluate requests can be buffered in Redis.
+//   - A failed LLM provider only degrades the service; every instance shares it, so withdrawing traffic would not help.
+//   - Any other failure reports "not_ready" with 503.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	checks := map[string]func(context.Context) error{
+		"database": h.repository.Ping,
+	}
+	if h.redisClient != nil {
+		checks["redis"] = func(ctx context.Context) error {
+			return h.redisClient.Ping(ctx).Err()
+		}
+	}
+	if h.config.CheckLLM {
+		checks["llm"] = h.llmClient.Ping
+	}
+
+	results := h.runChecks(c.Request.Context(), checks)
+	response := gin.H{"status": "ready", "checks": results}
+
+	databaseUp := results["database"].Status == "up"
+	redisUp := h.redisClient == nil || results["redis"].Status == "up"
+	status := "ready"
+	if h.config.CheckLLM && results["llm"].Status != "up" {
+		status = "degraded"
+	}
+
+	// Evaluate requests can be buffered during a database outage as long as Redis is up
+	buffering := h.jobBuffer != nil && redisUp
+	if buffering {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), h.config.CheckTimeout)
+		defer cancel()
+
+		degraded, buffered, lastError := h.jobBuffer.Status(ctx)
+		response["buffered_jobs"] = buffered
+		if degraded || !databaseUp {
+			status = "degraded"
+			if lastError != "" {
+				response["degraded_reason"] = lastError
+			}
+		}
+	}
+
+	if !redisUp || (!databaseUp && !buffering) {
+		response["status"] = "not_ready"
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+
+	response["status"] = status
+	c.JSON(http.StatusOK, response)
+}
+
+// runChecks runs the dependency checks concurrently, each with its own timeout
+func (h *HealthHandler) runChecks(ctx context.Context, checks map[string]func(context.Context) error) map[string]dependencyCheck {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]dependencyCheck, len(checks))
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, h.config.CheckTimeout)
+			defer cancel()
+
+			startedAt := time.Now()
+			err := check(checkCtx)
+			result := dependencyCheck{Status: "up", LatencyMS: time.Since(startedAt).Milliseconds()}
+			if err != nil {
+				result.Status = "down"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// Version reports the build and configuration that produced this server
+func (h *HealthHandler) Version(c *gin.Context) {
+	c.JSON(http.StatusOK, h.versionInfo())
+}
+
+func (h *HealthHandler) versionInfo() gin.H {
+	return gin.H{
+		"version":        version.Version,
+		"commit":         version.Commit,
+		"build_time":     version.BuildTime,
+		"go_version":     runtime.Version(),
+		"llm_provider":   h.llmProvider,
+		"llm_model":      h.llmModel,
+		"schema_version": models.SchemaVersion,
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/rag"
+	"ai-cv-summarize/internal/repositories"
+	"ai-cv-summarize/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobDescriptionHandler struct {
+	repository  repositories.Repository
+	vectorStore *rag.VectorStore
+}
+
+func NewJobDescriptionHandler(repository repositories.Repository, vectorStore *rag.VectorStore) *JobDescriptionHandler {
+	return &JobDescriptionHandler{
+		repository:  repository,
+		vectorStore: vectorStore,
+	}
+}
+
+// CreateJobDescription stores a new job description and indexes its embedding
+func (h *JobDescriptionHandler) CreateJobDescription(c *gin.Context) {
+	var req models.JobDescriptionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	jobDesc, err := h.vectorStore.AddJobDescription(c.Request.Context(), req.Title, req.Description, req.Requirements)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeLLMFailure, "Failed to create job description: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, withoutEmbedding(jobDesc))
+}
+
+// ListJobDescriptions lists all job descriptions used for retrieval
+func (h *JobDescriptionHandler) ListJobDescriptions(c *gin.Context) {
+	jobDescs, err := h.repository.GetAllJobDescriptions(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve job descriptions")
+		return
+	}
+
+	for i, jobDesc := range jobDescs {
+		jobDescs[i] = withoutEmbedding(jobDesc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job_descriptions": jobDescs,
+		"total":            len(jobDescs),
+	})
+}
+
+// GetJobDescription returns a single job description
+func (h *JobDescriptionHandler) GetJobDescription(c *gin.Context) {
+	jobDesc, err := h.repository.GetJobDescription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job description not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, withoutEmbedding(jobDesc))
+}
+
+// UpdateJobDescription replaces a job description and regenerates its embedding
+func (h *JobDescriptionHandler) UpdateJobDescription(c *gin.Context) {
+	var req models.JobDescriptionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	id := c.Param("id")
+	if !h.ownJobDescription(c, id) {
+		return
+	}
+
+	jobDesc, err := h.vectorStore.UpdateJobDescription(c.Request.Context(), id, req.Title, req.Description, req.Requirements)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeLLMFailure, "Failed to update job description: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, withoutEmbedding(jobDesc))
+}
+
+// DeleteJobDescription removes a job description and its embedding
+func (h *JobDescriptionHandler) DeleteJobDescription(c *gin.Context) {
+	id := c.Param("id")
+	if !h.ownJobDescription(c, id) {
+		return
+	}
+
+	if err := h.vectorStore.DeleteJobDescription(c.Request.Context(), id); err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to delete job description: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job description deleted"})
+}
+
+// SetRecommendationThresholds calibrates the overall scores from which candidates evaluated against a job
+// description are recommended as a strong hire or a hire
+func (h *JobDescriptionHandler) SetRecommendationThresholds(c *gin.Context) {
+	var thresholds models.RecommendationThresholds
+	if !bindJSON(c, &thresholds) {
+		return
+	}
+	if err := services.ValidateRecommendationThresholds(thresholds); err != nil {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	h.updateRecommendationThresholds(c, &thresholds)
+}
+
+// DeleteRecommendationThresholds makes a job description use the configured recommendation thresholds again
+func (h *JobDescriptionHandler) DeleteRecommendationThresholds(c *gin.Context) {
+	h.updateRecommendationThresholds(c, nil)
+}
+
+// updateRecommendationThresholds stores a job description's thresholds and responds with the job description
+func (h *JobDescriptionHandler) updateRecommendationThresholds(c *gin.Context, thresholds *models.RecommendationThresholds) {
+	id := c.Param("id")
+	if !h.ownJobDescription(c, id) {
+		return
+	}
+
+	if err := h.repository.UpdateJobDescriptionThresholds(c.Request.Context(), id, thresholds); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job description not found")
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to update recommendation thresholds: "+err.Error())
+		return
+	}
+
+	jobDesc, err := h.repository.GetJobDescription(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve job description")
+		return
+	}
+
+	c.JSON(http.StatusOK, withoutEmbedding(jobDesc))
+}
+
+// ownJobDescription checks that a job description exists and that the request may change it, writing an error
+// response otherwise. Organizations can use the global job descriptions but not change them.
+func (h *JobDescriptionHandler) ownJobDescription(c *gin.Context, id string) bool {
+	jobDesc, err := h.repository.GetJobDescription(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job description not found")
+		return false
+	}
+	if !ownedByTenant(c, jobDesc.OrgID) {
+		respondWithError(c, http.StatusForbidden, models.ErrorCodeForbidden, "Global job descriptions are read-only")
+		return false
+	}
+
+	return true
+}
+
+// withoutEmbedding drops the raw vector, which is large and only meaningful to the vector store
+func withoutEmbedding(jobDesc *models.JobDescription) *models.JobDescription {
+	stripped := *jobDesc
+	stripped.Embedding = nil
+	return &stripped
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+	"ai-cv-summarize/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OrganizationHandler struct {
+	repository repositories.Repository
+	config     *config.TenancyConfig
+}
+
+func NewOrganizationHandler(repository repositories.Repository, config *config.TenancyConfig) *OrganizationHandler {
+	return &OrganizationHandler{
+		repository: repository,
+		config:     config,
+	}
+}
+
+// Authenticate resolves the request's API key when multi-tenancy is enabled. Organization keys scope the
+// request to that organization's data; the admin key leaves it unscoped.
+func (h *OrganizationHandler) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.config.Enabled {
+			c.Next()
+			return
+		}
+
+		key := requestAPIKey(c)
+		if key == "" {
+			abortWithError(c, http.StatusUnauthorized, models.ErrorCodeUnauthorized, "API key required")
+			return
+		}
+
+		if h.config.AdminAPIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(h.config.AdminAPIKey)) == 1 {
+			c.Next()
+			return
+		}
+
+		org, err := h.repository.GetOrganizationByAPIKeyHash(c.Request.Context(), hashAPIKey(key))
+		if errors.Is(err, repositories.ErrNotFound) {
+			abortWithError(c, http.StatusUnauthorized, models.ErrorCodeUnauthorized, "Invalid API key")
+			return
+		}
+		if err != nil {
+			abortWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to authenticate request")
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithOrgID(c.Request.Context(), org.ID.Hex()))
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects organization-scoped requests; it must run after Authenticate. Without tenancy
+// Authenticate lets every request through, so the admin key is checked here, and admin requests are refused
+// while no admin key is configured.
+func (h *OrganizationHandler) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.config.Enabled {
+			if h.config.AdminAPIKey == "" {
+				abortWithError(c, http.StatusForbidden, models.ErrorCodeForbidden, "Admin endpoints are disabled until ADMIN_API_KEY is set")
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(requestAPIKey(c)), []byte(h.config.AdminAPIKey)) != 1 {
+				abortWithError(c, http.StatusUnauthorized, models.ErrorCodeUnauthorized, "Admin API key required")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if tenant.OrgID(c.Request.Context()) != "" {
+			abortWithError(c, http.StatusForbidden, models.ErrorCodeForbidden, "Admin API key required")
+			return
+		}
+		c.Next()
+	}
+}
+
+// CreateOrganization creates an organization and returns its API key, which is not shown again
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req models.OrganizationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to generate API key")
+		return
+	}
+
+	now := time.Now()
+	org := &models.Organization{
+		ID:                   primitive.NewObjectID(),
+		Name:                 req.Name,
+		APIKeyHash:           hashAPIKey(key),
+		OrganizationDefaults: req.OrganizationDefaults,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+	if err := h.validateDefaults(c.Request.Context(), org.ID.Hex(), org.OrganizationDefaults); err != nil {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	if err := h.repository.CreateOrganization(c.Request.Context(), org); err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to create organization")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"organization": withoutAPIKeyHash(org),
+		"api_key":      key,
+	})
+}
+
+// ListOrganizations lists all organizations
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	orgs, err := h.repository.GetAllOrganizations(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve organizations")
+		return
+	}
+
+	for i, org := range orgs {
+		orgs[i] = withoutAPIKeyHash(org)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"organizations": orgs,
+		"total":         len(orgs),
+	})
+}
+
+// UpdateOrganization renames an organization and replaces its defaults
+func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
+	var req models.OrganizationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	org, err := h.repository.GetOrganization(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Organization not found")
+		return
+	}
+	org.Name = req.Name
+
+	h.saveDefaults(c, org, req.OrganizationDefaults)
+}
+
+// GetCurrentOrganization returns the organization the API key belongs to
+func (h *OrganizationHandler) GetCurrentOrganization(c *gin.Context) {
+	org, ok := h.currentOrganization(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, withoutAPIKeyHash(org))
+}
+
+// UpdateCurrentDefaults lets an organization choose its own default rubrics and job description
+func (h *OrganizationHandler) UpdateCurrentDefaults(c *gin.Context) {
+	var defaults models.OrganizationDefaults
+	if !bindJSON(c, &defaults) {
+		return
+	}
+
+	org, ok := h.currentOrganization(c)
+	if !ok {
+		return
+	}
+
+	h.saveDefaults(c, org, defaults)
+}
+
+// currentOrganization loads the request's organization, writing an error response when there is none
+func (h *OrganizationHandler) currentOrganization(c *gin.Context) (*models.Organization, bool) {
+	orgID := tenant.OrgID(c.Request.Context())
+	if orgID == "" {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Request is not scoped to an organization")
+		return nil, false
+	}
+
+	org, err := h.repository.GetOrganization(c.Request.Context(), orgID)
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Organization not found")
+		return nil, false
+	}
+
+	return org, true
+}
+
+// ownedByTenant reports whether the request may change a shareable document belonging to orgID: the admin key
+// may change any, an organization only its own
+func ownedByTenant(c *gin.Context, orgID string) bool {
+	scope := tenant.OrgID(c.Request.Context())
+	return scope == "" || scope == orgID
+}
+
+// saveDefaults validates and stores an organization's defaults and writes the updated organization
+func (h *OrganizationHandler) saveDefaults(c *gin.Context, org *models.Organization, defaults models.OrganizationDefaults) {
+	if err := h.validateDefaults(c.Request.Context(), org.ID.Hex(), defaults); err != nil {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	org.OrganizationDefaults = defaults
+	org.UpdatedAt = time.Now()
+	if err := h.repository.UpdateOrganization(c.Request.Context(), org); err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to update organization")
+		return
+	}
+
+	c.JSON(http.StatusOK, withoutAPIKeyHash(org))
+}
+
+// validateDefaults checks that every default refers to a rubric or job description the organization can see
+func (h *OrganizationHandler) validateDefaults(ctx context.Context, orgID string, defaults models.OrganizationDefaults) error {
+	ctx = tenant.WithOrgID(ctx, orgID)
+
+	for field, id := range map[string]string{
+		"default_cv_rubric_id":      defaults.DefaultCVRubricID,
+		"default_project_rubric_id": defaults.DefaultProjectRubricID,
+	} {
+		if id == "" {
+			continue
+		}
+		if _, err := h.repository.GetScoringRubric(ctx, id); err != nil {
+			return fmt.Errorf("%s: rubric %s not found", field, id)
+		}
+	}
+
+	if id := defaults.DefaultJobDescriptionID; id != "" {
+		if _, err := h.repository.GetJobDescription(ctx, id); err != nil {
+			return fmt.Errorf("default_job_description_id: job description %s not found", id)
+		}
+	}
+
+	return nil
+}
+
+// withoutAPIKeyHash drops the key hash, which is only needed to authenticate requests
+func withoutAPIKeyHash(org *models.Organization) *models.Organization {
+	stripped := *org
+	stripped.APIKeyHash = ""
+	return &stripped
+}
+
+// requestAPIKey reads the API key from the X-API-Key header or an "Authorization: Bearer" header
+func requestAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+func generateAPIKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "org_" + hex.EncodeToString(b), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+	"ai-cv-summarize/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrivacyHandler serves right-to-erasure requests
+type PrivacyHandler struct {
+	repository     repositories.Repository
+	erasureService *services.ErasureService
+}
+
+func NewPrivacyHandler(repository repositories.Repository, erasureService *services.ErasureService) *PrivacyHandler {
+	return &PrivacyHandler{
+		repository:     repository,
+		erasureService: erasureService,
+	}
+}
+
+// ForgetCandidate irreversibly erases the personal data of every job of a candidate, keeping their scores
+func (h *PrivacyHandler) ForgetCandidate(c *gin.Context) {
+	h.forget(c, "Candidate", func(reason string) (*models.ErasureRecord, error) {
+		return h.erasureService.ForgetCandidate(c.Request.Context(), c.Param("id"), reason)
+	})
+}
+
+// ForgetJob irreversibly erases the personal data of one job, keeping its scores
+func (h *PrivacyHandler) ForgetJob(c *gin.Context) {
+	h.forget(c, "Job", func(reason string) (*models.ErasureRecord, error) {
+		return h.erasureService.ForgetJob(c.Request.Context(), c.Param("id"), reason)
+	})
+}
+
+// forget runs an erasure with the optional request body and writes its audit record as the response
+func (h *PrivacyHandler) forget(c *gin.Context, subject string, erase func(reason string) (*models.ErasureRecord, error)) {
+	var req models.ForgetRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	record, err := erase(req.Reason)
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, subject+" not found")
+	case errors.Is(err, services.ErrJobsInProgress):
+		respondWithError(c, http.StatusConflict, models.ErrorCodeConflict, err.Error()+"; retry once they finish")
+	case err != nil:
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to erase data: "+err.Error())
+	default:
+		c.JSON(http.StatusOK, record)
+	}
+}
+
+// ListErasures returns the audit records of past erasures, newest first
+func (h *PrivacyHandler) ListErasures(c *gin.Context) {
+	records, err := h.repository.GetErasureRecords(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to get erasure records")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"erasures": records, "total": len(records)})
+}
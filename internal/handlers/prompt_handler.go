@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+	"ai-cv-summarize/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromptHandler struct {
+	repository               repositories.Repository
+	promptService            *services.PromptService
+	evaluationService        *services.EvaluationService
+	sandboxEvaluationService *services.EvaluationService
+}
+
+func NewPromptHandler(
+	repository repositories.Repository,
+	promptService *services.PromptService,
+	evaluationService *services.EvaluationService,
+	sandboxEvaluationService *services.EvaluationService,
+) *PromptHandler {
+	return &PromptHandler{
+		repository:               repository,
+		promptService:            promptService,
+		evaluationService:        evaluationService,
+		sandboxEvaluationService: sandboxEvaluationService,
+	}
+}
+
+// ListPrompts returns the active template for every evaluation step
+func (h *PromptHandler) ListPrompts(c *gin.Context) {
+	templates, err := h.promptService.ListTemplates(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to get prompt templates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prompts": templates})
+}
+
+// GetPrompt returns the active template for one step
+func (h *PromptHandler) GetPrompt(c *gin.Context) {
+	tmpl, err := h.promptService.GetTemplate(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.respondError(c, err, "Failed to get prompt template")
+		return
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// UpdatePrompt saves a new version of a step's template and makes it active
+func (h *PromptHandler) UpdatePrompt(c *gin.Context) {
+	var req models.UpdatePromptTemplateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tmpl, err := h.promptService.SaveTemplate(c.Request.Context(), c.Param("name"), req.Description, req.Template, req.ModelParams)
+	if err != nil {
+		h.respondError(c, err, "Failed to save prompt template")
+		return
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// ListPromptVersions returns every saved version of a step's template, newest first
+func (h *PromptHandler) ListPromptVersions(c *gin.Context) {
+	name := c.Param("name")
+	versions, err := h.promptService.ListVersions(c.Request.Context(), name)
+	if err != nil {
+		h.respondError(c, err, "Failed to get prompt template versions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"name": name, "versions": versions})
+}
+
+// GetPromptVersion returns one saved version of a step's template
+func (h *PromptHandler) GetPromptVersion(c *gin.Context) {
+	version, ok := h.versionParam(c)
+	if !ok {
+		return
+	}
+
+	tmpl, err := h.promptService.GetVersion(c.Request.Context(), c.Param("name"), version)
+	if err != nil {
+		h.respondError(c, err, "Failed to get prompt template version")
+		return
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// ActivatePromptVersion makes a saved version the active template, e.g. to roll back a change
+func (h *PromptHandler) ActivatePromptVersion(c *gin.Context) {
+	version, ok := h.versionParam(c)
+	if !ok {
+		return
+	}
+
+	tmpl, err := h.promptService.ActivateVersion(c.Request.Context(), c.Param("name"), version)
+	if err != nil {
+		h.respondError(c, err, "Failed to activate prompt template version")
+		return
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// versionParam parses the :version path parameter, responding with 400 when it is not a positive number
+func (h *PromptHandler) versionParam(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid prompt template version")
+		return 0, false
+	}
+	return version, true
+}
+
+// DeletePrompt removes the stored template so the built-in default applies again
+func (h *PromptHandler) DeletePrompt(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.promptService.ResetTemplate(c.Request.Context(), name); err != nil {
+		h.respondError(c, err, "Failed to delete prompt template")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"name": name, "reset": true})
+}
+
+// PreviewPrompt renders a template against a stored job and optionally runs it through the LLM
+func (h *PromptHandler) PreviewPrompt(c *gin.Context) {
+	var req models.PromptPreviewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	name := c.Param("name")
+	if _, err := h.promptService.GetTemplate(c.Request.Context(), name); err != nil {
+		h.respondError(c, err, "Failed to get prompt template")
+		return
+	}
+
+	job, err := h.repository.GetJobByID(c.Request.Context(), req.JobID)
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Job not found")
+		return
+	}
+
+	// Sandbox jobs are previewed with the mock LLM so they never reach a provider
+	evaluationService := h.evaluationService
+	if job.Sandbox {
+		evaluationService = h.sandboxEvaluationService
+	}
+
+	preview, err := evaluationService.PreviewPrompt(c.Request.Context(), job, name, req.Template, req.Execute)
+	if err != nil {
+		respondWithError(c, http.StatusUnprocessableEntity, models.ErrorCodeUnprocessable, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// respondError maps prompt service errors to HTTP responses
+func (h *PromptHandler) respondError(c *gin.Context, err error, message string) {
+	if errors.Is(err, services.ErrUnknownPrompt) {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Unknown prompt template")
+		return
+	}
+	if errors.Is(err, services.ErrUnknownPromptVersion) {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Prompt template version not found")
+		return
+	}
+	if errors.Is(err, services.ErrInvalidPrompt) {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, message)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/rag"
+	"ai-cv-summarize/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReferenceDocumentHandler struct {
+	repository  repositories.Repository
+	vectorStore *rag.VectorStore
+}
+
+func NewReferenceDocumentHandler(repository repositories.Repository, vectorStore *rag.VectorStore) *ReferenceDocumentHandler {
+	return &ReferenceDocumentHandler{
+		repository:  repository,
+		vectorStore: vectorStore,
+	}
+}
+
+// CreateReferenceDocument stores a scoring guideline, company values document or case-study brief and indexes its chunks
+func (h *ReferenceDocumentHandler) CreateReferenceDocument(c *gin.Context) {
+	var req models.ReferenceDocumentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if !slices.Contains(models.ReferenceDocumentTypes, req.Type) {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "type must be one of "+strings.Join(models.ReferenceDocumentTypes, ", "))
+		return
+	}
+
+	doc, err := h.vectorStore.AddReferenceDocument(c.Request.Context(), req.Type, req.Title, req.Content)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to create document: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, doc)
+}
+
+// ListReferenceDocuments lists the reference documents, optionally of one type
+func (h *ReferenceDocumentHandler) ListReferenceDocuments(c *gin.Context) {
+	docType := c.Query("type")
+	if docType != "" && !slices.Contains(models.ReferenceDocumentTypes, docType) {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "type must be one of "+strings.Join(models.ReferenceDocumentTypes, ", "))
+		return
+	}
+
+	docs, err := h.repository.GetReferenceDocuments(c.Request.Context(), docType)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve documents")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents": docs,
+		"total":     len(docs),
+	})
+}
+
+// GetReferenceDocument returns a single reference document
+func (h *ReferenceDocumentHandler) GetReferenceDocument(c *gin.Context) {
+	doc, err := h.repository.GetReferenceDocument(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Document not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+// DeleteReferenceDocument removes a reference document and its chunks
+func (h *ReferenceDocumentHandler) DeleteReferenceDocument(c *gin.Context) {
+	id := c.Param("id")
+	doc, err := h.repository.GetReferenceDocument(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Document not found")
+		return
+	}
+	if !ownedByTenant(c, doc.OrgID) {
+		respondWithError(c, http.StatusForbidden, models.ErrorCodeForbidden, "Global documents are read-only")
+		return
+	}
+
+	if err := h.vectorStore.DeleteReferenceDocument(c.Request.Context(), id); err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to delete document: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Document deleted"})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RubricHandler struct {
+	repository repositories.Repository
+}
+
+func NewRubricHandler(repository repositories.Repository) *RubricHandler {
+	return &RubricHandler{repository: repository}
+}
+
+// CreateRubric stores a scoring rubric. Rubrics created with an organization key belong to that organization;
+// those created without one are global.
+func (h *RubricHandler) CreateRubric(c *gin.Context) {
+	var req models.RubricRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	var totalWeight float64
+	for _, criterion := range req.Criteria {
+		if criterion.Name == "" || criterion.Weight < 0 {
+			respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Every criterion needs a name and a non-negative weight")
+			return
+		}
+		totalWeight += criterion.Weight
+	}
+	if totalWeight <= 0 {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Criteria weights must add up to more than zero")
+		return
+	}
+
+	if req.Scale != nil && (req.Scale.MaxScore < 0 || req.Scale.DisplayMax < 0) {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Scale max_score and display_max must not be negative")
+		return
+	}
+
+	rubric := &models.ScoringRubric{
+		Name:        req.Name,
+		Description: req.Description,
+		Criteria:    req.Criteria,
+		Scale:       req.Scale,
+		CreatedAt:   time.Now(),
+	}
+	if err := h.repository.CreateScoringRubric(c.Request.Context(), rubric); err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to create rubric")
+		return
+	}
+
+	c.JSON(http.StatusCreated, rubric)
+}
+
+// ListRubrics lists the rubrics visible to the caller: its organization's own plus the global ones
+func (h *RubricHandler) ListRubrics(c *gin.Context) {
+	rubrics, err := h.repository.GetAllScoringRubrics(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve rubrics")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rubrics": rubrics,
+		"total":   len(rubrics),
+	})
+}
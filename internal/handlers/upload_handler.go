@@ -1,90 +1,105 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
 	"net/http"
-	"path/filepath"
+	"strconv"
+	"strings"
 
 	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
 	"ai-cv-summarize/internal/services"
+	"ai-cv-summarize/internal/tenant"
 
 	"github.com/gin-gonic/gin"
 )
 
+// uploadPreviewLength is the number of characters of extracted text GetUpload returns
+const uploadPreviewLength = 500
+
+// maxUploadPageSize caps the limit accepted by ListUploads
+const maxUploadPageSize = 100
+
 type UploadHandler struct {
-	fileService *services.FileService
+	repository    repositories.Repository
+	fileService   *services.FileService
+	objectStorage *services.ObjectStorage
 }
 
-func NewUploadHandler(fileService *services.FileService) *UploadHandler {
+func NewUploadHandler(repository repositories.Repository, fileService *services.FileService, objectStorage *services.ObjectStorage) *UploadHandler {
 	return &UploadHandler{
-		fileService: fileService,
+		repository:    repository,
+		fileService:   fileService,
+		objectStorage: objectStorage,
 	}
 }
 
 // UploadFiles handles file upload for CV and project report
 func (h *UploadHandler) UploadFiles(c *gin.Context) {
-	// Parse multipart form
-	form, err := c.MultipartForm()
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse multipart form"})
+	form, ok := parseUploadForm(c, h.fileService)
+	if !ok {
 		return
 	}
 
 	// Get CV file
 	cvFiles := form.File["cv_file"]
 	if len(cvFiles) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "CV file is required"})
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "CV file is required")
 		return
 	}
 
 	// Get project file
 	projectFiles := form.File["project_file"]
 	if len(projectFiles) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project file is required"})
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "Project file is required")
 		return
 	}
 
 	// Save CV file
 	cvFile := cvFiles[0]
-	cvFilePath, err := h.fileService.SaveFile(cvFile)
+	cvUpload, err := h.fileService.SaveFile(c.Request.Context(), cvFile)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save CV file: " + err.Error()})
+		respondWithSaveError(c, saveFailureStatus(err), "Failed to save CV file", err)
 		return
 	}
 
 	// Save project file
 	projectFile := projectFiles[0]
-	projectFilePath, err := h.fileService.SaveFile(projectFile)
+	projectUpload, err := h.fileService.SaveFile(c.Request.Context(), projectFile)
 	if err != nil {
 		// Cleanup CV file if project file save fails
-		h.fileService.CleanupFile(cvFilePath)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save project file: " + err.Error()})
+		h.fileService.CleanupFile(c.Request.Context(), cvUpload)
+		respondWithSaveError(c, saveFailureStatus(err), "Failed to save project file", err)
 		return
 	}
 
 	// Extract text content from files (for validation)
-	_, err = h.fileService.ExtractTextFromFile(cvFilePath)
+	_, err = h.fileService.ExtractTextFromFile(h.fileService.UploadPath(cvUpload.Filename))
 	if err != nil {
 		// Cleanup files if text extraction fails
-		h.fileService.CleanupFile(cvFilePath)
-		h.fileService.CleanupFile(projectFilePath)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to extract CV content: " + err.Error()})
+		h.fileService.CleanupFile(c.Request.Context(), cvUpload)
+		h.fileService.CleanupFile(c.Request.Context(), projectUpload)
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeExtractionFailed, "Failed to extract CV content: "+err.Error())
 		return
 	}
 
-	_, err = h.fileService.ExtractTextFromFile(projectFilePath)
+	_, err = h.fileService.ExtractTextFromFile(h.fileService.UploadPath(projectUpload.Filename))
 	if err != nil {
 		// Cleanup files if text extraction fails
-		h.fileService.CleanupFile(cvFilePath)
-		h.fileService.CleanupFile(projectFilePath)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to extract project content: " + err.Error()})
+		h.fileService.CleanupFile(c.Request.Context(), cvUpload)
+		h.fileService.CleanupFile(c.Request.Context(), projectUpload)
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeExtractionFailed, "Failed to extract project content: "+err.Error())
 		return
 	}
 
-	// Return success response with actual saved filenames
+	// Return the upload IDs /evaluate takes
 	response := models.UploadResponse{
 		Message:     "Files uploaded successfully",
-		CVFile:      filepath.Base(cvFilePath),      // Return the actual saved filename
-		ProjectFile: filepath.Base(projectFilePath), // Return the actual saved filename
+		CVFile:      cvUpload.ID.Hex(),
+		ProjectFile: projectUpload.ID.Hex(),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -92,72 +107,309 @@ func (h *UploadHandler) UploadFiles(c *gin.Context) {
 
 // UploadFilesWithContent handles file upload and returns content
 func (h *UploadHandler) UploadFilesWithContent(c *gin.Context) {
-	// Parse multipart form
-	form, err := c.MultipartForm()
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse multipart form"})
+	form, ok := parseUploadForm(c, h.fileService)
+	if !ok {
 		return
 	}
 
 	// Get CV file
 	cvFiles := form.File["cv_file"]
 	if len(cvFiles) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "CV file is required"})
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "CV file is required")
 		return
 	}
 
 	// Get project file
 	projectFiles := form.File["project_file"]
 	if len(projectFiles) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project file is required"})
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "Project file is required")
 		return
 	}
 
 	// Save CV file
 	cvFile := cvFiles[0]
-	cvFilePath, err := h.fileService.SaveFile(cvFile)
+	cvUpload, err := h.fileService.SaveFile(c.Request.Context(), cvFile)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save CV file: " + err.Error()})
+		respondWithSaveError(c, saveFailureStatus(err), "Failed to save CV file", err)
 		return
 	}
 
 	// Save project file
 	projectFile := projectFiles[0]
-	projectFilePath, err := h.fileService.SaveFile(projectFile)
+	projectUpload, err := h.fileService.SaveFile(c.Request.Context(), projectFile)
 	if err != nil {
 		// Cleanup CV file if project file save fails
-		h.fileService.CleanupFile(cvFilePath)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save project file: " + err.Error()})
+		h.fileService.CleanupFile(c.Request.Context(), cvUpload)
+		respondWithSaveError(c, saveFailureStatus(err), "Failed to save project file", err)
 		return
 	}
 
 	// Extract text content from files
-	cvContent, err := h.fileService.ExtractTextFromFile(cvFilePath)
+	cvContent, err := h.fileService.ExtractTextFromFile(h.fileService.UploadPath(cvUpload.Filename))
 	if err != nil {
 		// Cleanup files if text extraction fails
-		h.fileService.CleanupFile(cvFilePath)
-		h.fileService.CleanupFile(projectFilePath)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to extract CV content: " + err.Error()})
+		h.fileService.CleanupFile(c.Request.Context(), cvUpload)
+		h.fileService.CleanupFile(c.Request.Context(), projectUpload)
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeExtractionFailed, "Failed to extract CV content: "+err.Error())
 		return
 	}
 
-	projectContent, err := h.fileService.ExtractTextFromFile(projectFilePath)
+	projectContent, err := h.fileService.ExtractTextFromFile(h.fileService.UploadPath(projectUpload.Filename))
 	if err != nil {
 		// Cleanup files if text extraction fails
-		h.fileService.CleanupFile(cvFilePath)
-		h.fileService.CleanupFile(projectFilePath)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to extract project content: " + err.Error()})
+		h.fileService.CleanupFile(c.Request.Context(), cvUpload)
+		h.fileService.CleanupFile(c.Request.Context(), projectUpload)
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeExtractionFailed, "Failed to extract project content: "+err.Error())
 		return
 	}
 
 	// Return success response with content
 	response := gin.H{
 		"message":         "Files uploaded and processed successfully",
-		"cv_file":         filepath.Base(cvFilePath),
-		"project_file":    filepath.Base(projectFilePath),
+		"cv_file":         cvUpload.ID.Hex(),
+		"project_file":    projectUpload.ID.Hex(),
 		"cv_content":      cvContent,
 		"project_content": projectContent,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+// PresignUpload issues a URL for uploading one file directly to object storage, keeping large payloads off
+// the API server. The file is registered for evaluation with ConfirmUpload once uploaded.
+func (h *UploadHandler) PresignUpload(c *gin.Context) {
+	if !h.objectStorage.Enabled() {
+		respondWithError(c, http.StatusNotImplemented, models.ErrorCodeNotConfigured, "Direct uploads are not configured")
+		return
+	}
+
+	var req models.PresignUploadRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.MimeType == "" {
+		req.MimeType = services.MimeTypeFor(req.Filename)
+	}
+	if err := h.fileService.ValidateUpload(req.Filename, req.MimeType, req.Size); err != nil {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, err.Error())
+		return
+	}
+
+	upload, err := h.objectStorage.PresignUpload(req.Filename, req.MimeType, req.Size)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to presign upload: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PresignUploadResponse{
+		UploadID:  upload.UploadID,
+		URL:       upload.URL,
+		Method:    http.MethodPut,
+		Headers:   upload.Headers,
+		ExpiresAt: upload.ExpiresAt,
+	})
+}
+
+// ConfirmUpload checks the size, type and text of a file uploaded to a presigned URL and copies it into the
+// upload directory, so it can be evaluated like a file sent to /upload. Once found, the object is removed
+// from the bucket whether or not it is accepted.
+func (h *UploadHandler) ConfirmUpload(c *gin.Context) {
+	if !h.objectStorage.Enabled() {
+		respondWithError(c, http.StatusNotImplemented, models.ErrorCodeNotConfigured, "Direct uploads are not configured")
+		return
+	}
+
+	var req models.ConfirmUploadRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	object, err := h.objectStorage.StatUpload(ctx, req.UploadID)
+	switch {
+	case errors.Is(err, services.ErrInvalidUploadID):
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid upload ID")
+		return
+	case errors.Is(err, services.ErrUploadNotFound):
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Upload not found")
+		return
+	case err != nil:
+		respondWithError(c, http.StatusBadGateway, models.ErrorCodeStorageUnavailable, "Failed to read upload: "+err.Error())
+		return
+	}
+	defer h.deleteUpload(c, req.UploadID)
+
+	if err := h.fileService.ValidateUpload(object.Filename, object.ContentType, object.Size); err != nil {
+		respondWithError(c, http.StatusUnprocessableEntity, models.ErrorCodeBadUpload, err.Error())
+		return
+	}
+
+	body, err := h.objectStorage.OpenUpload(ctx, req.UploadID)
+	if err != nil {
+		respondWithError(c, http.StatusBadGateway, models.ErrorCodeStorageUnavailable, "Failed to read upload: "+err.Error())
+		return
+	}
+	upload, err := h.fileService.SaveReader(ctx, object.Filename, body)
+	body.Close()
+	if err != nil {
+		status := http.StatusInternalServerError
+		if saveFailureStatus(err) != status {
+			// The content was checked against the type only now
+			status = http.StatusUnprocessableEntity
+		}
+		respondWithSaveError(c, status, "Failed to save file", err)
+		return
+	}
+
+	content, err := h.fileService.ExtractTextFromFile(h.fileService.UploadPath(upload.Filename))
+	if err == nil && strings.TrimSpace(content) == "" {
+		err = errors.New("file contains no text")
+	}
+	if err != nil {
+		h.fileService.CleanupFile(ctx, upload)
+		respondWithError(c, http.StatusUnprocessableEntity, models.ErrorCodeExtractionFailed, "Failed to extract content: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ConfirmUploadResponse{
+		Message:  "File uploaded successfully",
+		File:     upload.ID.Hex(),
+		Size:     object.Size,
+		MimeType: object.ContentType,
+	})
+}
+
+// ListUploads lists the organization's uploaded files, newest first
+func (h *UploadHandler) ListUploads(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > maxUploadPageSize {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, fmt.Sprintf("Invalid limit, must be between 1 and %d", maxUploadPageSize))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid offset, must be zero or more")
+		return
+	}
+
+	uploads, total, err := h.repository.ListUploads(c.Request.Context(), limit, offset)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve uploads")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"uploads": uploads,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// GetUpload returns an uploaded file's metadata and the beginning of its extracted text
+func (h *UploadHandler) GetUpload(c *gin.Context) {
+	upload, err := h.repository.GetUpload(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Upload not found")
+		return
+	}
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve upload")
+		return
+	}
+
+	response := models.UploadDetailResponse{Upload: upload}
+	text, err := h.fileService.ExtractTextFromFile(h.fileService.UploadPath(upload.Filename))
+	if err != nil {
+		response.PreviewError = err.Error()
+	} else {
+		runes := []rune(strings.TrimSpace(text))
+		response.TextLength = len(runes)
+		if len(runes) > uploadPreviewLength {
+			runes = runes[:uploadPreviewLength]
+		}
+		response.TextPreview = string(runes)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteUpload removes the organization's record of an uploaded file, and the file once no other
+// organization has uploaded it. Jobs keep the text extracted from it.
+func (h *UploadHandler) DeleteUpload(c *gin.Context) {
+	ctx := c.Request.Context()
+	upload, err := h.repository.GetUpload(ctx, c.Param("id"))
+	if errors.Is(err, repositories.ErrNotFound) {
+		respondWithError(c, http.StatusNotFound, models.ErrorCodeNotFound, "Upload not found")
+		return
+	}
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to retrieve upload")
+		return
+	}
+
+	// Only the upload's organization gives up its record, also when an unscoped admin deletes it
+	if upload.OrgID != "" {
+		ctx = tenant.WithOrgID(ctx, upload.OrgID)
+	}
+	if err := h.fileService.RemoveUpload(ctx, upload.Filename); err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrorCodeInternal, "Failed to delete upload")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Upload deleted", "id": upload.ID.Hex()})
+}
+
+// parseUploadForm reads a multipart request with a CV and a project file, refusing it while it is read once
+// it is larger than two files can be
+func parseUploadForm(c *gin.Context, fileService *services.FileService) (*multipart.Form, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, fileService.MaxRequestSize(2))
+	form, err := c.MultipartForm()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(c, http.StatusRequestEntityTooLarge, models.ErrorCodeBadUpload, services.ErrFileTooLarge.Error())
+			return nil, false
+		}
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeBadUpload, "Failed to parse multipart form")
+		return nil, false
+	}
+	return form, true
+}
+
+// respondWithSaveError reports a file that could not be saved with status, except that an infected file
+// is reported with its own code and the signature it matched
+func respondWithSaveError(c *gin.Context, status int, message string, err error) {
+	var infected *services.InfectedFileError
+	if errors.As(err, &infected) {
+		respondWithErrorDetails(c, http.StatusUnprocessableEntity, models.ErrorCodeFileInfected,
+			infected.Error(), gin.H{"signature": infected.Signature})
+		return
+	}
+
+	code := models.ErrorCodeBadUpload
+	if status >= http.StatusInternalServerError {
+		code = models.ErrorCodeInternal
+	}
+	respondWithError(c, status, code, message+": "+err.Error())
+}
+
+// saveFailureStatus maps an error saving a file to a status: rejected files are the client's fault
+func saveFailureStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrEmptyFile):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrUnsupportedFileType):
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusInternalServerError
+}
+
+// deleteUpload removes a confirmed object from the bucket; a leftover object is harmless, so failures are only logged
+func (h *UploadHandler) deleteUpload(c *gin.Context, uploadID string) {
+	if err := h.objectStorage.DeleteUpload(c.Request.Context(), uploadID); err != nil {
+		log.Printf("Warning: failed to delete upload %s from object storage: %v", uploadID, err)
+	}
+}
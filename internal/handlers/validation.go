@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"ai-cv-summarize/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	// Report binding errors by the JSON names clients send rather than Go field names
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// validation collects the field errors of a request, so a client learns about all of them at once
+type validation struct {
+	fields []models.FieldError
+}
+
+// add records that field is invalid
+func (v *validation) add(field, format string, args ...interface{}) {
+	v.fields = append(v.fields, models.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// check records that field is invalid unless ok
+func (v *validation) check(ok bool, field, format string, args ...interface{}) {
+	if !ok {
+		v.add(field, format, args...)
+	}
+}
+
+// fileID checks a reference to an uploaded file, which must be an upload ID; the file service resolves it
+func (v *validation) fileID(field, id string) {
+	v.check(primitive.IsValidObjectID(id), field, "must be a file ID returned by /upload")
+}
+
+// oneOf checks an optional enum value
+func (v *validation) oneOf(field, value string, allowed ...string) {
+	if value == "" {
+		return
+	}
+	for _, option := range allowed {
+		if value == option {
+			return
+		}
+	}
+	v.add(field, "must be one of %s", strings.Join(allowed, ", "))
+}
+
+// respond writes the collected field errors; it reports whether there were any
+func (v *validation) respond(c *gin.Context) bool {
+	if len(v.fields) == 0 {
+		return false
+	}
+	respondWithValidationErrors(c, v.fields)
+	return true
+}
+
+// respondWithValidationErrors reports invalid fields, listing them in the message and in details
+func respondWithValidationErrors(c *gin.Context, fields []models.FieldError) {
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field.Field+" "+field.Message)
+	}
+	respondWithErrorDetails(c, http.StatusBadRequest, models.ErrorCodeValidationFailed,
+		"Invalid request: "+strings.Join(messages, "; "), gin.H{"fields": fields})
+}
+
+// bindJSON decodes and validates a JSON body, answering with field errors when it is invalid.
+// It reports whether the request can proceed.
+func bindJSON(c *gin.Context, req interface{}) bool {
+	return bindError(c, c.ShouldBindJSON(req))
+}
+
+// bindOptionalJSON is bindJSON for endpoints whose body may be empty
+func bindOptionalJSON(c *gin.Context, req interface{}) bool {
+	err := c.ShouldBindJSON(req)
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+	return bindError(c, err)
+}
+
+// bindError answers a failed binding; it reports whether there was none
+func bindError(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrors):
+		fields := make([]models.FieldError, 0, len(validationErrors))
+		for _, fieldError := range validationErrors {
+			fields = append(fields, models.FieldError{Field: fieldPath(fieldError), Message: ruleMessage(fieldError)})
+		}
+		respondWithValidationErrors(c, fields)
+	case errors.As(err, &typeError) && typeError.Field != "":
+		respondWithValidationErrors(c, []models.FieldError{{Field: typeError.Field, Message: "must be " + jsonType(typeError.Type)}})
+	default:
+		respondWithError(c, http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid request format")
+	}
+	return false
+}
+
+// fieldPath is the JSON path of a failed field without the request struct's name, e.g. "candidates[0].cv_file"
+func fieldPath(fieldError validator.FieldError) string {
+	namespace := fieldError.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// ruleMessage describes the binding rule a field failed
+func ruleMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if fieldError.Kind() == reflect.Slice || fieldError.Kind() == reflect.Map {
+			return "needs at least " + fieldError.Param() + " items"
+		}
+		return "must be at least " + fieldError.Param()
+	case "max":
+		if fieldError.Kind() == reflect.Slice || fieldError.Kind() == reflect.Map {
+			return "allows at most " + fieldError.Param() + " items"
+		}
+		return "must be at most " + fieldError.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldError.Param(), " ", ", ")
+	}
+	return "is invalid (" + fieldError.Tag() + ")"
+}
+
+// jsonType names the JSON type of a Go type for decoding errors
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
@@ -0,0 +1,151 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// prepareEmbeddingInput trims text and cuts it to maxTokens, failing for text an embedding model would reject
+func prepareEmbeddingInput(text string, maxTokens int) (string, error) {
+	if text == "" {
+		return "", fmt.Errorf("input text cannot be empty")
+	}
+
+	// Embedding models reject longer input, so keep its start
+	text, _ = TruncateTokens(text, maxTokens)
+
+	text = strings.TrimSpace(text)
+	if text == "" || len(text) < 3 {
+		return "", fmt.Errorf("input text is invalid")
+	}
+
+	if strings.Contains(text, "\x00") {
+		return "", fmt.Errorf("input text contains null bytes")
+	}
+	return text, nil
+}
+
+// createEmbeddings embeds texts in one request to an OpenAI-compatible embeddings endpoint
+func createEmbeddings(ctx context.Context, client *openai.Client, model openai.EmbeddingModel, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	inputs := make([]string, len(texts))
+	for i, text := range texts {
+		input, err := prepareEmbeddingInput(text, EmbeddingMaxTokens)
+		if err != nil {
+			return nil, fmt.Errorf("text %d: %w", i, err)
+		}
+		inputs[i] = input
+	}
+
+	req := openai.EmbeddingRequest{
+		Input: inputs,
+		Model: model,
+	}
+
+	resp, err := client.CreateEmbeddings(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+	recordUsage(ctx, req.Model.String(), resp.Usage)
+
+	// Each embedding carries the index of its input, which is not necessarily its position
+	embeddings := make([][]float64, len(inputs))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(inputs) {
+			return nil, fmt.Errorf("embedding returned for unknown input %d", data.Index)
+		}
+		embedding := make([]float64, len(data.Embedding))
+		for i, v := range data.Embedding {
+			embedding[i] = float64(v)
+		}
+		embeddings[data.Index] = embedding
+	}
+	for i, embedding := range embeddings {
+		if len(embedding) == 0 {
+			return nil, fmt.Errorf("no embedding returned for text %d", i)
+		}
+	}
+
+	return embeddings, nil
+}
+
+// singleEmbedding embeds one text through a batch embedding call
+func singleEmbedding(ctx context.Context, text string, generate func(ctx context.Context, texts []string) ([][]float64, error)) ([]float64, error) {
+	embeddings, err := generate(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// WrapEmbeddingBatches splits GenerateEmbeddings calls into requests of at most batchSize texts, sending up
+// to concurrency of them at once. Other calls pass through to the wrapped client.
+func WrapEmbeddingBatches(next LLMClient, batchSize, concurrency int) LLMClient {
+	return &batchingClient{LLMClient: next, batchSize: max(batchSize, 1), concurrency: max(concurrency, 1)}
+}
+
+// batchingClient wraps an LLM client with batched embedding requests
+type batchingClient struct {
+	LLMClient
+	batchSize   int
+	concurrency int
+}
+
+func (c *batchingClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if len(texts) <= c.batchSize {
+		return c.LLMClient.GenerateEmbeddings(ctx, texts)
+	}
+
+	embeddings := make([][]float64, len(texts))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(c.concurrency)
+	for start := 0; start < len(texts); start += c.batchSize {
+		start, end := start, min(start+c.batchSize, len(texts))
+		group.Go(func() error {
+			batch, err := c.LLMClient.GenerateEmbeddings(groupCtx, texts[start:end])
+			if err != nil {
+				return fmt.Errorf("texts %d-%d: %w", start, end-1, err)
+			}
+			copy(embeddings[start:end], batch)
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+// WithEmbedder serves embeddings from embedder and everything else from the wrapped client
+func WithEmbedder(next LLMClient, embedder Embedder) LLMClient {
+	return &embedderClient{LLMClient: next, embedder: embedder}
+}
+
+// embedderClient wraps an LLM client with another source of embeddings
+type embedderClient struct {
+	LLMClient
+	embedder Embedder
+}
+
+func (c *embedderClient) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	return c.embedder.GenerateEmbedding(ctx, text)
+}
+
+func (c *embedderClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	return c.embedder.GenerateEmbeddings(ctx, texts)
+}
+
+func (c *embedderClient) EmbeddingModel() string {
+	return c.embedder.EmbeddingModel()
+}
@@ -0,0 +1,311 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"ai-cv-summarize/internal/config"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// geminiEmbeddingMaxTokens is the most input tokens text-embedding-004 accepts
+const geminiEmbeddingMaxTokens = 2048
+
+// geminiModels maps OpenAI model names, e.g. from JUDGE_MODEL, to the Gemini models of about the same tier
+var geminiModels = map[string]string{
+	"gpt-4":         "gemini-2.5-pro",
+	"gpt-4-turbo":   "gemini-2.5-pro",
+	"gpt-4o":        "gemini-2.5-pro",
+	"gpt-4.1":       "gemini-2.5-pro",
+	"gpt-4o-mini":   "gemini-2.5-flash",
+	"gpt-4.1-mini":  "gemini-2.5-flash",
+	"gpt-3.5-turbo": "gemini-2.5-flash",
+}
+
+// resolveGeminiModel returns the Gemini model for a configured name, which may be an OpenAI model or an
+// OpenRouter name such as "google/gemini-2.5-pro"
+func resolveGeminiModel(name string) string {
+	name = strings.TrimPrefix(name, "google/")
+	if model, ok := geminiModels[name]; ok {
+		return model
+	}
+	return name
+}
+
+// GeminiError is a failed call to the Gemini API: an error response, or a request that got none, in
+// which case StatusCode is 0 and Err is the transport error
+type GeminiError struct {
+	StatusCode int
+	Status     string
+	Message    string
+	Err        error
+}
+
+func (e *GeminiError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("Gemini request failed: %v", e.Err)
+	}
+	return fmt.Sprintf("Gemini error, status code: %d, status: %s, message: %s", e.StatusCode, e.Status, e.Message)
+}
+
+func (e *GeminiError) Unwrap() error {
+	return e.Err
+}
+
+// GeminiClient calls Google's Gemini API (generateContent and batchEmbedContents) over REST
+type GeminiClient struct {
+	httpClient *http.Client
+	config     *config.GeminiConfig
+	model      string
+}
+
+func NewGeminiClient(cfg *config.GeminiConfig) *GeminiClient {
+	return &GeminiClient{
+		httpClient: newHTTPClient(),
+		config:     cfg,
+		model:      resolveGeminiModel(cfg.Model),
+	}
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature        float32         `json:"temperature"`
+	MaxOutputTokens    int             `json:"maxOutputTokens"`
+	ResponseMimeType   string          `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema json.RawMessage `json:"responseJsonSchema,omitempty"`
+}
+
+type geminiGenerateRequest struct {
+	Contents         []geminiContent        `json:"contents"`
+	GenerationConfig geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiGenerateResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+type geminiEmbedRequest struct {
+	Model   string        `json:"model"`
+	Content geminiContent `json:"content"`
+}
+
+type geminiBatchEmbedRequest struct {
+	Requests []geminiEmbedRequest `json:"requests"`
+}
+
+type geminiBatchEmbedResponse struct {
+	Embeddings []struct {
+		Values []float64 `json:"values"`
+	} `json:"embeddings"`
+}
+
+// EmbeddingModel returns the model used for GenerateEmbedding
+func (c *GeminiClient) EmbeddingModel() string {
+	return c.config.EmbeddingModel
+}
+
+func (c *GeminiClient) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	return singleEmbedding(ctx, text, c.GenerateEmbeddings)
+}
+
+// GenerateEmbeddings embeds texts in one batchEmbedContents request
+func (c *GeminiClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	req := geminiBatchEmbedRequest{Requests: make([]geminiEmbedRequest, len(texts))}
+	for i, text := range texts {
+		input, err := prepareEmbeddingInput(text, geminiEmbeddingMaxTokens)
+		if err != nil {
+			return nil, fmt.Errorf("text %d: %w", i, err)
+		}
+		req.Requests[i] = geminiEmbedRequest{
+			Model:   "models/" + c.config.EmbeddingModel,
+			Content: geminiContent{Parts: []geminiPart{{Text: input}}},
+		}
+	}
+
+	var resp geminiBatchEmbedResponse
+	if err := c.call(ctx, http.MethodPost, "models/"+c.config.EmbeddingModel+":batchEmbedContents", req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("%d embeddings returned for %d texts", len(resp.Embeddings), len(texts))
+	}
+	embeddings := make([][]float64, len(texts))
+	for i, embedding := range resp.Embeddings {
+		if len(embedding.Values) == 0 {
+			return nil, fmt.Errorf("no embedding returned for text %d", i)
+		}
+		embeddings[i] = embedding.Values
+	}
+	return embeddings, nil
+}
+
+// Ping lists the provider's models, which is free, to check connectivity and the API key
+func (c *GeminiClient) Ping(ctx context.Context) error {
+	if err := c.call(ctx, http.MethodGet, "models?pageSize=1", nil, nil); err != nil {
+		return fmt.Errorf("failed to reach Gemini: %w", err)
+	}
+	return nil
+}
+
+func (c *GeminiClient) GenerateCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
+	text, err := c.generate(ctx, prompt, geminiGenerationConfig{Temperature: temperature})
+	if err != nil {
+		return "", fmt.Errorf("failed to create completion: %w", err)
+	}
+	return text, nil
+}
+
+// GenerateStructuredCompletion asks for a JSON response, which Gemini enforces with its response MIME type
+func (c *GeminiClient) GenerateStructuredCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
+	text, err := c.generate(ctx, prompt, geminiGenerationConfig{
+		Temperature:      temperature,
+		ResponseMimeType: "application/json",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create structured completion: %w", err)
+	}
+	return text, nil
+}
+
+// GenerateSchemaCompletion constrains the response to the schema with Gemini's structured output
+func (c *GeminiClient) GenerateSchemaCompletion(ctx context.Context, prompt string, schema *Schema, temperature float32) (string, error) {
+	text, err := c.generate(ctx, prompt, geminiGenerationConfig{
+		Temperature:        temperature,
+		ResponseMimeType:   "application/json",
+		ResponseJSONSchema: schema.Definition,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create schema completion: %w", err)
+	}
+	return text, nil
+}
+
+func (c *GeminiClient) GenerateCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateCompletion(ctx, prompt, temperature)
+	})
+}
+
+func (c *GeminiClient) GenerateStructuredCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateStructuredCompletion(ctx, prompt, temperature)
+	})
+}
+
+func (c *GeminiClient) GenerateSchemaCompletionWithRetry(ctx context.Context, prompt string, schema *Schema, temperature float32, maxRetries int) (string, error) {
+	return Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateSchemaCompletion(ctx, prompt, schema, temperature)
+	})
+}
+
+// generate runs generateContent on a single user message and returns the text of the first candidate
+func (c *GeminiClient) generate(ctx context.Context, prompt string, generationConfig geminiGenerationConfig) (string, error) {
+	generationConfig.MaxOutputTokens = CompletionMaxTokens
+	req := geminiGenerateRequest{
+		Contents:         []geminiContent{{Role: "user", Parts: []geminiPart{{Text: prompt}}}},
+		GenerationConfig: generationConfig,
+	}
+
+	var resp geminiGenerateResponse
+	if err := c.call(ctx, http.MethodPost, "models/"+c.model+":generateContent", req, &resp); err != nil {
+		return "", err
+	}
+	recordUsage(ctx, c.model, openai.Usage{
+		PromptTokens:     resp.UsageMetadata.PromptTokenCount,
+		CompletionTokens: resp.UsageMetadata.CandidatesTokenCount,
+	})
+
+	if resp.PromptFeedback.BlockReason != "" {
+		return "", fmt.Errorf("prompt blocked by Gemini: %s", resp.PromptFeedback.BlockReason)
+	}
+	if len(resp.Candidates) == 0 {
+		return "", fmt.Errorf("no completion candidates returned")
+	}
+
+	var text strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("empty completion returned (finish reason %s)", resp.Candidates[0].FinishReason)
+	}
+	return text.String(), nil
+}
+
+// call sends a request to the Gemini API and decodes its JSON response into out, when out is not nil
+func (c *GeminiClient) call(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.config.BaseURL, "/")+"/"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-goog-api-key", c.config.APIKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &GeminiError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := &GeminiError{StatusCode: resp.StatusCode}
+		var errResp struct {
+			Error struct {
+				Message string `json:"message"`
+				Status  string `json:"status"`
+			} `json:"error"`
+		}
+		if data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024)); err == nil && json.Unmarshal(data, &errResp) == nil {
+			apiErr.Status, apiErr.Message = errResp.Error.Status, errResp.Error.Message
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Gemini response: %w", err)
+	}
+	return nil
+}
@@ -5,34 +5,95 @@ import (
 	"context"
 )
 
+// CompletionMaxTokens caps the tokens generated by a completion
+const CompletionMaxTokens = 2000
+
 // LLMClient defines the interface for LLM operations
 type LLMClient interface {
 	GenerateEmbedding(ctx context.Context, text string) ([]float64, error)
+	// GenerateEmbeddings embeds texts in one request where the provider supports it, returning vectors in input order
+	GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error)
+	// EmbeddingModel identifies the model behind GenerateEmbedding; vectors from different models are not comparable
+	EmbeddingModel() string
 	GenerateCompletion(ctx context.Context, prompt string, temperature float32) (string, error)
 	GenerateStructuredCompletion(ctx context.Context, prompt string, temperature float32) (string, error)
 	GenerateCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error)
 	GenerateStructuredCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error)
+	// GenerateSchemaCompletion returns a JSON object constrained by schema where the provider supports it
+	GenerateSchemaCompletion(ctx context.Context, prompt string, schema *Schema, temperature float32) (string, error)
+	GenerateSchemaCompletionWithRetry(ctx context.Context, prompt string, schema *Schema, temperature float32, maxRetries int) (string, error)
+	// Ping checks that the provider is reachable and accepts the credentials without spending tokens
+	Ping(ctx context.Context) error
+}
+
+// Embedder generates embeddings without a completion model, such as a local model
+type Embedder interface {
+	GenerateEmbedding(ctx context.Context, text string) ([]float64, error)
+	GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error)
+	EmbeddingModel() string
 }
 
 // LLMFactory creates LLM clients based on configuration
-type LLMFactory struct{}
+type LLMFactory struct {
+	provider string
+}
 
-func NewLLMFactory() *LLMFactory {
-	return &LLMFactory{}
+// NewLLMFactory returns a factory for the configured provider; ProviderAuto (or empty) picks one by API key
+func NewLLMFactory(provider string) *LLMFactory {
+	return &LLMFactory{provider: provider}
 }
 
+// Provider names reported by the factory
+const (
+	ProviderAuto       = "auto"
+	ProviderOpenAI     = "openai"
+	ProviderOpenRouter = "openrouter"
+	ProviderGemini     = "gemini"
+	ProviderMock       = "mock"
+)
+
 // CreateClient creates an LLM client based on the provided configuration
-func (f *LLMFactory) CreateClient(openAIConfig *config.OpenAIConfig, openRouterConfig *config.OpenRouterConfig) LLMClient {
+func (f *LLMFactory) CreateClient(openAIConfig *config.OpenAIConfig, openRouterConfig *config.OpenRouterConfig, geminiConfig *config.GeminiConfig) LLMClient {
+	switch provider, _ := f.ActiveProvider(openAIConfig, openRouterConfig, geminiConfig); provider {
+	case ProviderOpenRouter:
+		return NewOpenRouterClient(openRouterConfig)
+	case ProviderGemini:
+		return NewGeminiClient(geminiConfig)
+	case ProviderMock:
+		return NewMockClient()
+	default:
+		return NewOpenAIClient(openAIConfig)
+	}
+}
+
+// ActiveProvider returns the provider and model CreateClient selects for the given configuration
+func (f *LLMFactory) ActiveProvider(openAIConfig *config.OpenAIConfig, openRouterConfig *config.OpenRouterConfig, geminiConfig *config.GeminiConfig) (string, string) {
+	switch f.provider {
+	case ProviderOpenAI:
+		return ProviderOpenAI, openAIConfig.Model
+	case ProviderOpenRouter:
+		return ProviderOpenRouter, openRouterConfig.Model
+	case ProviderGemini:
+		return ProviderGemini, resolveGeminiModel(geminiConfig.Model)
+	case ProviderMock:
+		return ProviderMock, mockModel
+	}
+
 	// Prioritize OpenAI if API key is available
 	if openAIConfig.APIKey != "" {
-		return NewOpenAIClient(openAIConfig)
+		return ProviderOpenAI, openAIConfig.Model
 	}
 
 	// Fallback to OpenRouter if OpenAI is not available
 	if openRouterConfig.APIKey != "" {
-		return NewOpenRouterClient(openRouterConfig)
+		return ProviderOpenRouter, openRouterConfig.Model
+	}
+
+	// Then Gemini
+	if geminiConfig.APIKey != "" {
+		return ProviderGemini, resolveGeminiModel(geminiConfig.Model)
 	}
 
-	// If neither is available, return OpenAI client with empty config (will fail gracefully)
-	return NewOpenAIClient(openAIConfig)
+	// If neither is available, use OpenAI client with empty config (will fail gracefully)
+	return ProviderOpenAI, openAIConfig.Model
 }
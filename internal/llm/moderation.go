@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Moderate sends text to the OpenAI moderation endpoint and returns the categories it was flagged for,
+// such as "violence" or "sexual/minors", or nil when it was not flagged
+func (c *OpenAIClient) Moderate(ctx context.Context, text string) ([]string, error) {
+	resp, err := c.client.Moderations(ctx, openai.ModerationRequest{Input: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to moderate content: %w", err)
+	}
+
+	var categories []string
+	for _, result := range resp.Results {
+		if !result.Flagged {
+			continue
+		}
+		flags := []struct {
+			name    string
+			flagged bool
+		}{
+			{"hate", result.Categories.Hate},
+			{"hate/threatening", result.Categories.HateThreatening},
+			{"self-harm", result.Categories.SelfHarm},
+			{"sexual", result.Categories.Sexual},
+			{"sexual/minors", result.Categories.SexualMinors},
+			{"violence", result.Categories.Violence},
+			{"violence/graphic", result.Categories.ViolenceGraphic},
+		}
+		for _, flag := range flags {
+			if flag.flagged {
+				categories = append(categories, flag.name)
+			}
+		}
+		// Flagged without a known category, e.g. one added to the endpoint after this client
+		if len(categories) == 0 {
+			categories = append(categories, "flagged")
+		}
+	}
+
+	return categories, nil
+}
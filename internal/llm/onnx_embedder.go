@@ -0,0 +1,223 @@
+//go:build onnx
+
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"ai-cv-summarize/internal/config"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+var (
+	onnxRuntimeOnce sync.Once
+	onnxRuntimeErr  error
+)
+
+// ONNXEmbedder runs a sentence-transformer model exported to ONNX in process, so embeddings need no
+// provider. Token vectors are mean-pooled over the attention mask unless the model already pools them.
+type ONNXEmbedder struct {
+	session   *ort.DynamicAdvancedSession
+	inputs    []string
+	pooled    bool
+	tokenizer *wordPieceTokenizer
+	maxLength int
+	model     string
+}
+
+// NewONNXEmbedder loads the onnxruntime library and the model in cfg.ONNXModelPath
+func NewONNXEmbedder(cfg *config.EmbeddingsConfig) (Embedder, error) {
+	if cfg.ONNXModelPath == "" {
+		return nil, fmt.Errorf("ONNX_MODEL_PATH is not set")
+	}
+	modelFile, err := findONNXModel(cfg.ONNXModelPath)
+	if err != nil {
+		return nil, err
+	}
+	tokenizer, err := loadWordPieceTokenizer(filepath.Join(cfg.ONNXModelPath, "vocab.txt"), onnxLowercase(cfg.ONNXModelPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer: %w", err)
+	}
+
+	onnxRuntimeOnce.Do(func() {
+		if cfg.ONNXRuntimeLibrary != "" {
+			ort.SetSharedLibraryPath(cfg.ONNXRuntimeLibrary)
+		}
+		onnxRuntimeErr = ort.InitializeEnvironment()
+	})
+	if onnxRuntimeErr != nil {
+		return nil, fmt.Errorf("failed to load onnxruntime: %w", onnxRuntimeErr)
+	}
+
+	inputInfo, outputInfo, err := ort.GetInputOutputInfo(modelFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model %s: %w", modelFile, err)
+	}
+	e := &ONNXEmbedder{
+		tokenizer: tokenizer,
+		maxLength: max(cfg.ONNXMaxSequenceLength, 2),
+		model:     "onnx/" + filepath.Base(filepath.Clean(cfg.ONNXModelPath)),
+	}
+	for _, input := range inputInfo {
+		switch input.Name {
+		case "input_ids", "attention_mask", "token_type_ids":
+			e.inputs = append(e.inputs, input.Name)
+		default:
+			return nil, fmt.Errorf("model %s has unsupported input %q", modelFile, input.Name)
+		}
+	}
+
+	// Prefer the pooled sentence vector of models that export one, else the per-token vectors
+	output := outputInfo[0]
+	for _, info := range outputInfo {
+		if info.Name == "sentence_embedding" {
+			output = info
+		}
+	}
+	e.pooled = len(output.Dimensions) == 2
+
+	e.session, err = ort.NewDynamicAdvancedSession(modelFile, e.inputs, []string{output.Name}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model %s: %w", modelFile, err)
+	}
+	return e, nil
+}
+
+// findONNXModel returns the model file of a model directory, at its root or in the onnx/ folder Hugging Face exports use
+func findONNXModel(dir string) (string, error) {
+	for _, name := range []string{"model.onnx", filepath.Join("onnx", "model.onnx")} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no model.onnx in %s", dir)
+}
+
+// onnxLowercase reads do_lower_case from the model's tokenizer_config.json; most sentence-transformers are uncased
+func onnxLowercase(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, "tokenizer_config.json"))
+	if err != nil {
+		return true
+	}
+	var tokenizerConfig struct {
+		DoLowerCase *bool `json:"do_lower_case"`
+	}
+	if json.Unmarshal(data, &tokenizerConfig) != nil || tokenizerConfig.DoLowerCase == nil {
+		return true
+	}
+	return *tokenizerConfig.DoLowerCase
+}
+
+// EmbeddingModel returns "onnx/" and the name of the model directory
+func (e *ONNXEmbedder) EmbeddingModel() string {
+	return e.model
+}
+
+func (e *ONNXEmbedder) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	return singleEmbedding(ctx, text, e.GenerateEmbeddings)
+}
+
+// GenerateEmbeddings runs the model once on all texts, padded to the longest of them
+func (e *ONNXEmbedder) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	encoded := make([][]int64, len(texts))
+	length := 0
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			return nil, fmt.Errorf("text %d: input text cannot be empty", i)
+		}
+		encoded[i] = e.tokenizer.encode(text, e.maxLength)
+		length = max(length, len(encoded[i]))
+	}
+
+	size := len(texts) * length
+	values := map[string][]int64{
+		"input_ids":      make([]int64, size),
+		"attention_mask": make([]int64, size),
+		"token_type_ids": make([]int64, size),
+	}
+	for i, ids := range encoded {
+		copy(values["input_ids"][i*length:], ids)
+		for j := range ids {
+			values["attention_mask"][i*length+j] = 1
+		}
+	}
+
+	shape := ort.NewShape(int64(len(texts)), int64(length))
+	inputs := make([]ort.Value, len(e.inputs))
+	for i, name := range e.inputs {
+		tensor, err := ort.NewTensor(shape, values[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s tensor: %w", name, err)
+		}
+		defer tensor.Destroy()
+		inputs[i] = tensor
+	}
+
+	outputs := []ort.Value{nil}
+	if err := e.session.Run(inputs, outputs); err != nil {
+		return nil, fmt.Errorf("failed to run embedding model: %w", err)
+	}
+	defer outputs[0].Destroy()
+	output, ok := outputs[0].(*ort.Tensor[float32])
+	if !ok {
+		return nil, fmt.Errorf("embedding model returned %T, expected float32 tensor", outputs[0])
+	}
+
+	data := output.GetData()
+	outputShape := output.GetShape()
+	dimensions := int(outputShape[len(outputShape)-1])
+	embeddings := make([][]float64, len(texts))
+	for i := range texts {
+		embedding := make([]float64, dimensions)
+		if e.pooled {
+			for d := range embedding {
+				embedding[d] = float64(data[i*dimensions+d])
+			}
+		} else {
+			tokens := len(encoded[i])
+			for t := 0; t < tokens; t++ {
+				offset := (i*length + t) * dimensions
+				for d := range embedding {
+					embedding[d] += float64(data[offset+d])
+				}
+			}
+			for d := range embedding {
+				embedding[d] /= float64(tokens)
+			}
+		}
+		embeddings[i] = normalize(embedding)
+	}
+	return embeddings, nil
+}
+
+// normalize scales a vector to unit length, as sentence-transformers do
+func normalize(vector []float64) []float64 {
+	var sum float64
+	for _, v := range vector {
+		sum += v * v
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := math.Sqrt(sum)
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
@@ -0,0 +1,14 @@
+//go:build !onnx
+
+package llm
+
+import (
+	"fmt"
+
+	"ai-cv-summarize/internal/config"
+)
+
+// NewONNXEmbedder fails in builds without the onnx tag, which leave out the cgo onnxruntime bindings
+func NewONNXEmbedder(cfg *config.EmbeddingsConfig) (Embedder, error) {
+	return nil, fmt.Errorf("local ONNX embeddings require a server built with `-tags onnx`")
+}
@@ -3,8 +3,6 @@ package llm
 import (
 	"context"
 	"fmt"
-	"strings"
-	"time"
 
 	"ai-cv-summarize/internal/config"
 
@@ -19,6 +17,7 @@ type OpenRouterClient struct {
 func NewOpenRouterClient(cfg *config.OpenRouterConfig) *OpenRouterClient {
 	clientConfig := openai.DefaultConfig(cfg.APIKey)
 	clientConfig.BaseURL = cfg.BaseURL
+	clientConfig.HTTPClient = newHTTPClient()
 
 	client := openai.NewClientWithConfig(clientConfig)
 
@@ -28,41 +27,25 @@ func NewOpenRouterClient(cfg *config.OpenRouterConfig) *OpenRouterClient {
 	}
 }
 
-func (c *OpenRouterClient) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
-	if text == "" {
-		return nil, fmt.Errorf("input text cannot be empty")
-	}
-
-	// Truncate if exceeds token limit
-	if len(text) > 8000 {
-		text = text[:8000]
-	}
-
-	text = strings.TrimSpace(text)
-	if text == "" || len(text) < 3 {
-		return nil, fmt.Errorf("input text is invalid")
-	}
-
-	req := openai.EmbeddingRequest{
-		Input: []string{text},
-		Model: openai.AdaEmbeddingV2,
-	}
+// EmbeddingModel returns the model used for GenerateEmbedding
+func (c *OpenRouterClient) EmbeddingModel() string {
+	return resolveEmbeddingModel(c.config.EmbeddingModel).String()
+}
 
-	resp, err := c.client.CreateEmbeddings(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create embeddings: %w", err)
-	}
+func (c *OpenRouterClient) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	return singleEmbedding(ctx, text, c.GenerateEmbeddings)
+}
 
-	if len(resp.Data) == 0 {
-		return nil, fmt.Errorf("no embeddings returned")
-	}
+func (c *OpenRouterClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	return createEmbeddings(ctx, c.client, resolveEmbeddingModel(c.config.EmbeddingModel), texts)
+}
 
-	embedding := make([]float64, len(resp.Data[0].Embedding))
-	for i, v := range resp.Data[0].Embedding {
-		embedding[i] = float64(v)
+// Ping lists the provider's models, which is free, to check connectivity and the API key
+func (c *OpenRouterClient) Ping(ctx context.Context) error {
+	if _, err := c.client.ListModels(ctx); err != nil {
+		return fmt.Errorf("failed to reach OpenRouter: %w", err)
 	}
-
-	return embedding, nil
+	return nil
 }
 
 func (c *OpenRouterClient) GenerateCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
@@ -75,13 +58,14 @@ func (c *OpenRouterClient) GenerateCompletion(ctx context.Context, prompt string
 			},
 		},
 		Temperature: temperature,
-		MaxTokens:   2000,
+		MaxTokens:   CompletionMaxTokens,
 	}
 
 	resp, err := c.client.CreateChatCompletion(ctx, req)
 	if err != nil {
 		return "", fmt.Errorf("failed to create completion: %w", err)
 	}
+	recordUsage(ctx, req.Model, resp.Usage)
 
 	if len(resp.Choices) == 0 {
 		return "", fmt.Errorf("no completion choices returned")
@@ -104,13 +88,14 @@ IMPORTANT: Respond with ONLY valid JSON. Do not include any additional text, exp
 			},
 		},
 		Temperature: temperature,
-		MaxTokens:   2000,
+		MaxTokens:   CompletionMaxTokens,
 	}
 
 	resp, err := c.client.CreateChatCompletion(ctx, req)
 	if err != nil {
 		return "", fmt.Errorf("failed to create structured completion: %w", err)
 	}
+	recordUsage(ctx, req.Model, resp.Usage)
 
 	if len(resp.Choices) == 0 {
 		return "", fmt.Errorf("no completion choices returned")
@@ -119,42 +104,25 @@ IMPORTANT: Respond with ONLY valid JSON. Do not include any additional text, exp
 	return resp.Choices[0].Message.Content, nil
 }
 
-func (c *OpenRouterClient) GenerateCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
-	var lastErr error
-
-	for i := 0; i < maxRetries; i++ {
-		if i > 0 {
-			backoffDuration := time.Duration(i*i) * time.Second
-			time.Sleep(backoffDuration)
-		}
-
-		result, err := c.GenerateCompletion(ctx, prompt, temperature)
-		if err == nil {
-			return result, nil
-		}
-
-		lastErr = err
-	}
+// GenerateSchemaCompletion uses function calling so the model's arguments follow the schema
+func (c *OpenRouterClient) GenerateSchemaCompletion(ctx context.Context, prompt string, schema *Schema, temperature float32) (string, error) {
+	return schemaCompletion(ctx, c.client, c.config.Model, prompt, schema, temperature)
+}
 
-	return "", fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
+func (c *OpenRouterClient) GenerateCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateCompletion(ctx, prompt, temperature)
+	})
 }
 
 func (c *OpenRouterClient) GenerateStructuredCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
-	var lastErr error
-
-	for i := 0; i < maxRetries; i++ {
-		if i > 0 {
-			backoffDuration := time.Duration(i*i) * time.Second
-			time.Sleep(backoffDuration)
-		}
-
-		result, err := c.GenerateStructuredCompletion(ctx, prompt, temperature)
-		if err == nil {
-			return result, nil
-		}
-
-		lastErr = err
-	}
+	return Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateStructuredCompletion(ctx, prompt, temperature)
+	})
+}
 
-	return "", fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
+func (c *OpenRouterClient) GenerateSchemaCompletionWithRetry(ctx context.Context, prompt string, schema *Schema, temperature float32, maxRetries int) (string, error) {
+	return Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateSchemaCompletion(ctx, prompt, schema, temperature)
+	})
 }
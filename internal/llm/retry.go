@@ -0,0 +1,219 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Backoff between retries doubles from retryBaseDelay up to retryMaxDelay, with jitter so clients that
+// failed together do not retry together
+const (
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+// ErrorClass groups LLM call errors by their cause, which decides whether they are retried
+type ErrorClass string
+
+const (
+	ErrorClassRateLimit      ErrorClass = "rate_limit"
+	ErrorClassQuotaExceeded  ErrorClass = "quota_exceeded"
+	ErrorClassTimeout        ErrorClass = "timeout"
+	ErrorClassServer         ErrorClass = "server_error"
+	ErrorClassConnection     ErrorClass = "connection"
+	ErrorClassInvalidRequest ErrorClass = "invalid_request"
+	ErrorClassCanceled       ErrorClass = "canceled"
+	// ErrorClassOther is any other failure, such as an unusable response, and is retried
+	ErrorClassOther ErrorClass = "other"
+)
+
+// Retryable reports whether a call that failed with this class of error may succeed when repeated
+func (c ErrorClass) Retryable() bool {
+	switch c {
+	case ErrorClassInvalidRequest, ErrorClassQuotaExceeded, ErrorClassCanceled:
+		return false
+	}
+	return true
+}
+
+// ClassifyError tells rate limits, timeouts, server errors and invalid requests apart by the provider's
+// HTTP status or the transport error
+func ClassifyError(err error) ErrorClass {
+	var apiErr *openai.APIError
+	var requestErr *openai.RequestError
+	var geminiErr *GeminiError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.As(err, &apiErr):
+		// An exhausted quota is also reported as 429, but waiting does not help
+		if apiErr.Type == "insufficient_quota" || apiErr.Code == "insufficient_quota" {
+			return ErrorClassQuotaExceeded
+		}
+		return classifyStatus(apiErr.HTTPStatusCode)
+	case errors.As(err, &requestErr):
+		return classifyStatus(requestErr.HTTPStatusCode)
+	case errors.As(err, &geminiErr) && geminiErr.StatusCode != 0:
+		return classifyStatus(geminiErr.StatusCode)
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassConnection
+	}
+	return ErrorClassOther
+}
+
+// classifyStatus classifies an error response by its HTTP status code
+func classifyStatus(status int) ErrorClass {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrorClassRateLimit
+	case status == http.StatusRequestTimeout:
+		return ErrorClassTimeout
+	case status >= 500:
+		return ErrorClassServer
+	case status >= 400:
+		return ErrorClassInvalidRequest
+	}
+	return ErrorClassOther
+}
+
+// Retry calls fn up to maxRetries times while it fails with a retryable error, waiting between attempts
+// with jittered exponential backoff, or as long as the provider's Retry-After asks when that is longer.
+// It gives up early once ctx is done, e.g. when the job deadline passes, or when the wait would outlast
+// the deadline, and then returns an error wrapping the context's error.
+func Retry(ctx context.Context, maxRetries int, fn func(ctx context.Context) (string, error)) (string, error) {
+	ctx, hint := withRetryHint(ctx)
+	var lastErr error
+
+	for i := 0; i < maxRetries; i++ {
+		if i > 0 {
+			wait := max(backoff(i), hint.get())
+			if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+				return "", fmt.Errorf("gave up after %d attempts, the next one is due after the deadline: %w (last error: %v)", i, context.DeadlineExceeded, lastErr)
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return "", fmt.Errorf("gave up after %d attempts: %w (last error: %v)", i, ctx.Err(), lastErr)
+			}
+		}
+
+		hint.set(0)
+		result, err := fn(ctx)
+		if err == nil {
+			return result, nil
+		}
+
+		lastErr = err
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("gave up after %d attempts: %w (last error: %v)", i+1, ctx.Err(), lastErr)
+		}
+		if class := ClassifyError(err); !class.Retryable() {
+			return "", fmt.Errorf("%s error, not retried: %w", class, err)
+		}
+	}
+
+	return "", fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
+}
+
+// backoff returns the wait before retry n (from 1): the delay doubles each time up to retryMaxDelay, and
+// a random half of it is jitter
+func backoff(n int) time.Duration {
+	delay := retryMaxDelay
+	if n < 16 {
+		delay = min(retryBaseDelay<<(n-1), retryMaxDelay)
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// IsProviderError reports whether err came from the LLM provider's API: an error response or a failed request
+func IsProviderError(err error) bool {
+	var apiErr *openai.APIError
+	var requestErr *openai.RequestError
+	var geminiErr *GeminiError
+	return errors.As(err, &apiErr) || errors.As(err, &requestErr) || errors.As(err, &geminiErr)
+}
+
+// retryHint carries the wait a provider asked for in its last error response from the HTTP transport
+// back to Retry
+type retryHint struct {
+	mu    sync.Mutex
+	after time.Duration
+}
+
+type retryHintKey struct{}
+
+func withRetryHint(ctx context.Context) (context.Context, *retryHint) {
+	hint := &retryHint{}
+	return context.WithValue(ctx, retryHintKey{}, hint), hint
+}
+
+func (h *retryHint) get() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.after
+}
+
+func (h *retryHint) set(after time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.after = after
+}
+
+// retryAfterTransport records the Retry-After of rate limited and unavailable responses in the request's
+// retry hint, since the provider's error type does not keep response headers
+type retryAfterTransport struct {
+	next http.RoundTripper
+}
+
+// newHTTPClient returns the HTTP client LLM providers are called with
+func newHTTPClient() *http.Client {
+	return &http.Client{Transport: &retryAfterTransport{next: http.DefaultTransport}}
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return resp, err
+	}
+
+	if hint, ok := req.Context().Value(retryHintKey{}).(*retryHint); ok {
+		if after, ok := parseRetryAfter(resp.Header, time.Now()); ok {
+			hint.set(after)
+		}
+	}
+	return resp, nil
+}
+
+// parseRetryAfter reads the wait a response asks for: OpenAI's retry-after-ms, or the standard
+// Retry-After in seconds or as an HTTP date
+func parseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// jsonRepairAttempts is how many times GenerateJSON re-prompts the model after an unparseable response
+const jsonRepairAttempts = 2
+
+// Schema describes the JSON object a schema completion must return
+type Schema struct {
+	// Name identifies the schema to the provider, e.g. "cv_evaluation"
+	Name        string
+	Description string
+	// Definition is a JSON Schema document for the object
+	Definition json.RawMessage
+}
+
+// schemaCompletion asks the model to call a single forced function whose parameters are the schema,
+// so the provider validates the shape of the returned arguments
+func schemaCompletion(ctx context.Context, client *openai.Client, model, prompt string, schema *Schema, temperature float32) (string, error) {
+	req := openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+		Temperature: temperature,
+		MaxTokens:   CompletionMaxTokens,
+		Tools: []openai.Tool{
+			{
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionDefinition{
+					Name:        schema.Name,
+					Description: schema.Description,
+					Parameters:  schema.Definition,
+				},
+			},
+		},
+		ToolChoice: openai.ToolChoice{
+			Type:     openai.ToolTypeFunction,
+			Function: openai.ToolFunction{Name: schema.Name},
+		},
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create schema completion: %w", err)
+	}
+	recordUsage(ctx, req.Model, resp.Usage)
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no completion choices returned")
+	}
+
+	message := resp.Choices[0].Message
+	if len(message.ToolCalls) > 0 {
+		return message.ToolCalls[0].Function.Arguments, nil
+	}
+
+	// Some models ignore the forced tool call and answer in the message body
+	return message.Content, nil
+}
+
+// GenerateJSON runs a schema completion and decodes the response into out. When the response does not
+// parse, the model is shown its output and the error and asked to fix it, up to jsonRepairAttempts times.
+func GenerateJSON(ctx context.Context, client LLMClient, prompt string, schema *Schema, temperature float32, maxRetries int, out interface{}) (string, error) {
+	response, err := client.GenerateSchemaCompletionWithRetry(ctx, prompt, schema, temperature, maxRetries)
+	if err != nil {
+		return "", err
+	}
+
+	response = extractJSON(response)
+	parseErr := json.Unmarshal([]byte(response), out)
+	for attempt := 0; parseErr != nil && attempt < jsonRepairAttempts; attempt++ {
+		response, err = client.GenerateSchemaCompletionWithRetry(ctx, repairPrompt(prompt, response, parseErr), schema, 0, maxRetries)
+		if err != nil {
+			return "", fmt.Errorf("failed to repair JSON response: %w", err)
+		}
+
+		response = extractJSON(response)
+		parseErr = json.Unmarshal([]byte(response), out)
+	}
+	if parseErr != nil {
+		return "", fmt.Errorf("invalid JSON response after %d repair attempts: %w", jsonRepairAttempts, parseErr)
+	}
+
+	return response, nil
+}
+
+func repairPrompt(prompt, response string, parseErr error) string {
+	return fmt.Sprintf(`%s
+
+Your previous response could not be parsed as JSON (%v):
+%s
+
+Return the corrected response as a single valid JSON object matching the requested format, with no other text.`, prompt, parseErr, response)
+}
+
+// extractJSON strips Markdown code fences and any text around the outermost JSON object
+func extractJSON(response string) string {
+	response = strings.TrimSpace(response)
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return response
+	}
+	return response[start : end+1]
+}
@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// EmbeddingMaxTokens is the most input tokens the embedding models accept
+const EmbeddingMaxTokens = 8191
+
+// defaultContextWindow is assumed for models missing from contextWindows, the smallest of the chat models
+const defaultContextWindow = 8192
+
+// contextWindows are the context windows in tokens of chat models by name prefix, longest prefixes first.
+// OpenRouter names such as "openai/gpt-4" are looked up without their vendor.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-1106", 128000},
+	{"gpt-4-0125", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+	{"gemini", 1048576},
+	{"llama-3", 128000},
+	{"mistral", 32768},
+}
+
+var (
+	encodingOnce sync.Once
+	encoding     *tiktoken.Tiktoken
+)
+
+// tokenizer returns the cl100k_base encoding of GPT-4 and the embedding models, loaded once from the copy
+// built into the binary. Newer models use o200k_base, which encodes the same text in fewer tokens, so
+// counts stay on the safe side. It returns nil if the encoding fails to load.
+func tokenizer() *tiktoken.Tiktoken {
+	encodingOnce.Do(func() {
+		tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+		var err error
+		if encoding, err = tiktoken.GetEncoding("cl100k_base"); err != nil {
+			log.Printf("Warning: failed to load the tokenizer, estimating tokens at four characters each: %v", err)
+		}
+	})
+	return encoding
+}
+
+// CountTokens returns the number of tokens in text
+func CountTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	if enc := tokenizer(); enc != nil {
+		return len(enc.EncodeOrdinary(text))
+	}
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// TruncateTokens shortens text to at most maxTokens tokens, cutting at the last whitespace before the limit
+// so no word is split, and reports whether it was shortened
+func TruncateTokens(text string, maxTokens int) (string, bool) {
+	maxTokens = max(maxTokens, 0)
+
+	var cut string
+	if enc := tokenizer(); enc != nil {
+		tokens := enc.EncodeOrdinary(text)
+		if len(tokens) <= maxTokens {
+			return text, false
+		}
+		// The decoded prefix can end inside a UTF-8 sequence that continues in the next token
+		cut = strings.ToValidUTF8(enc.Decode(tokens[:maxTokens]), "")
+	} else {
+		runes := []rune(text)
+		if len(runes) <= maxTokens*4 {
+			return text, false
+		}
+		cut = string(runes[:maxTokens*4])
+	}
+
+	if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRightFunc(cut, unicode.IsSpace), true
+}
+
+// ContextWindow returns the context window in tokens of a chat model
+func ContextWindow(model string) int {
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	model = strings.ToLower(model)
+
+	for _, window := range contextWindows {
+		if strings.HasPrefix(model, window.prefix) {
+			return window.tokens
+		}
+	}
+	return defaultContextWindow
+}
@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Usage counts the tokens consumed by LLM calls to one model
+type Usage struct {
+	Model            string
+	Calls            int
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// UsageRecorder totals the token usage of LLM calls made with a context from WithUsageRecorder
+type UsageRecorder struct {
+	parent *UsageRecorder
+
+	mu      sync.Mutex
+	byModel map[string]*Usage
+}
+
+type usageRecorderKey struct{}
+
+// WithUsageRecorder returns a context recording LLM usage into a new recorder.
+// Usage also counts toward the recorder already in ctx, so a step recorder adds up into its job's recorder.
+func WithUsageRecorder(ctx context.Context) (context.Context, *UsageRecorder) {
+	parent, _ := ctx.Value(usageRecorderKey{}).(*UsageRecorder)
+	recorder := &UsageRecorder{parent: parent, byModel: make(map[string]*Usage)}
+	return context.WithValue(ctx, usageRecorderKey{}, recorder), recorder
+}
+
+// Usage returns the recorded usage per model, sorted by model
+func (r *UsageRecorder) Usage() []Usage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage := make([]Usage, 0, len(r.byModel))
+	for _, u := range r.byModel {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Model < usage[j].Model })
+	return usage
+}
+
+func (r *UsageRecorder) add(model string, promptTokens, completionTokens int) {
+	for ; r != nil; r = r.parent {
+		r.mu.Lock()
+		u, ok := r.byModel[model]
+		if !ok {
+			u = &Usage{Model: model}
+			r.byModel[model] = u
+		}
+		u.Calls++
+		u.PromptTokens += promptTokens
+		u.CompletionTokens += completionTokens
+		r.mu.Unlock()
+	}
+}
+
+// recordUsage adds the token counts reported by a provider response to the recorder in ctx, if any
+func recordUsage(ctx context.Context, model string, usage openai.Usage) {
+	recorder, _ := ctx.Value(usageRecorderKey{}).(*UsageRecorder)
+	recorder.add(model, usage.PromptTokens, usage.CompletionTokens)
+}
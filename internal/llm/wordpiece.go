@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// wordPieceMaxWordRunes is the longest word split into subwords; longer ones become the unknown token
+const wordPieceMaxWordRunes = 100
+
+// wordPieceTokenizer turns text into the token IDs of a BERT-style model, as sentence-transformer models
+// expect: basic tokenization on whitespace and punctuation, then greedy longest-match subwords from vocab.txt
+type wordPieceTokenizer struct {
+	vocab     map[string]int64
+	lowercase bool
+	unknown   int64
+	cls       int64
+	sep       int64
+}
+
+// loadWordPieceTokenizer reads a vocab.txt with one token per line, the line number being its ID
+func loadWordPieceTokenizer(path string, lowercase bool) (*wordPieceTokenizer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	vocab := make(map[string]int64)
+	scanner := bufio.NewScanner(file)
+	for id := int64(0); scanner.Scan(); id++ {
+		vocab[strings.TrimRight(scanner.Text(), "\r")] = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	t := &wordPieceTokenizer{vocab: vocab, lowercase: lowercase}
+	for token, id := range map[string]*int64{"[UNK]": &t.unknown, "[CLS]": &t.cls, "[SEP]": &t.sep} {
+		value, ok := vocab[token]
+		if !ok {
+			return nil, fmt.Errorf("vocabulary %s has no %s token", path, token)
+		}
+		*id = value
+	}
+	return t, nil
+}
+
+// encode returns the IDs of text wrapped in [CLS] and [SEP], cut to at most maxLength IDs
+func (t *wordPieceTokenizer) encode(text string, maxLength int) []int64 {
+	ids := []int64{t.cls}
+	for _, word := range t.words(text) {
+		if len(ids) >= maxLength-1 {
+			break
+		}
+		ids = append(ids, t.subwords(word)...)
+	}
+	if len(ids) > maxLength-1 {
+		ids = ids[:maxLength-1]
+	}
+	return append(ids, t.sep)
+}
+
+// words splits text on whitespace and around punctuation and CJK characters, lowercasing and removing
+// accents for uncased models
+func (t *wordPieceTokenizer) words(text string) []string {
+	if t.lowercase {
+		text = strings.ToLower(text)
+		text = norm.NFD.String(text)
+	}
+
+	var words []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case r == 0 || r == unicode.ReplacementChar || (unicode.IsControl(r) && !unicode.IsSpace(r)):
+		case t.lowercase && unicode.Is(unicode.Mn, r):
+		case unicode.IsSpace(r):
+			flush()
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.Is(unicode.Han, r):
+			flush()
+			words = append(words, string(r))
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return words
+}
+
+// subwords splits a word into the longest vocabulary entries from its start, continuations prefixed with ##
+func (t *wordPieceTokenizer) subwords(word string) []int64 {
+	runes := []rune(word)
+	if len(runes) > wordPieceMaxWordRunes {
+		return []int64{t.unknown}
+	}
+
+	var ids []int64
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		found := false
+		for ; end > start; end-- {
+			piece := string(runes[start:end])
+			if start > 0 {
+				piece = "##" + piece
+			}
+			if id, ok := t.vocab[piece]; ok {
+				ids = append(ids, id)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return []int64{t.unknown}
+		}
+		start = end
+	}
+	return ids
+}
@@ -6,6 +6,9 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// SchemaVersion is the version of the stored document shapes, bumped on breaking changes
+const SchemaVersion = 1
+
 // JobStatus represents the status of an evaluation job
 type JobStatus string
 
@@ -16,6 +19,184 @@ const (
 	StatusFailed     JobStatus = "failed"
 )
 
+// ErrorType classifies why a job failed
+type ErrorType string
+
+const (
+	// ErrorTypeTimeout is an evaluation that ran past JOB_TIMEOUT
+	ErrorTypeTimeout ErrorType = "timeout"
+	// ErrorTypeProvider is an error from the LLM provider, such as an error response or a failed request
+	ErrorTypeProvider ErrorType = "provider"
+	// ErrorTypeEvaluation is any other evaluation failure, e.g. an unreadable document or LLM response
+	ErrorTypeEvaluation ErrorType = "evaluation"
+	// ErrorTypeInternal is a crash, or a job its workers kept abandoning until it ran out of retries
+	ErrorTypeInternal ErrorType = "internal"
+)
+
+// Evaluation pipeline steps, in order
+const (
+	StepAnalyzeCV       = "analyze_cv"
+	StepEvaluateCV      = "evaluate_cv"
+	StepEvaluateProject = "evaluate_project"
+	StepVerify          = "verify"
+	StepSummary         = "summary"
+)
+
+// PipelineSteps lists the steps tracked on every evaluation job; StepVerify runs before StepSummary when the judge is enabled
+var PipelineSteps = []string{StepAnalyzeCV, StepEvaluateCV, StepEvaluateProject, StepSummary}
+
+// Step states
+const (
+	StepPending   = "pending"
+	StepRunning   = "running"
+	StepCompleted = "completed"
+	StepFailed    = "failed"
+)
+
+// JobStep tracks the progress of one pipeline step
+type JobStep struct {
+	Name        string     `bson:"name" json:"name"`
+	Status      string     `bson:"status" json:"status"`
+	StartedAt   *time.Time `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	Error       string     `bson:"error,omitempty" json:"error,omitempty"`
+	// Usage counts the LLM tokens the step consumed
+	Usage *TokenUsage `bson:"usage,omitempty" json:"usage,omitempty"`
+}
+
+// Retrieval modes of a step's job description context
+const (
+	// RetrievalChunks uses the job description chunks most similar to the CV and project report
+	RetrievalChunks = "chunks"
+	// RetrievalDocuments uses the most similar whole job descriptions, until any are chunked
+	RetrievalDocuments = "documents"
+	// RetrievalPinned uses the job's or organization's job description
+	RetrievalPinned = "pinned"
+)
+
+// StepRetrieval is the context retrieved for one evaluation step
+type StepRetrieval struct {
+	Step string `bson:"step" json:"step"`
+	Mode string `bson:"mode" json:"mode"`
+	// Documents are the retrieved documents and chunks, best first, whether or not they fit in the prompt
+	Documents []RetrievedDocument `bson:"documents" json:"documents"`
+	// ContextTokens is the size of the context added to the step's prompt
+	ContextTokens int `bson:"context_tokens" json:"context_tokens"`
+}
+
+// RetrievedDocument is a document, or one of its chunks, retrieved as evaluation context
+type RetrievedDocument struct {
+	DocumentID   string `bson:"document_id" json:"document_id"`
+	DocumentType string `bson:"document_type" json:"document_type"`
+	// ChunkID is empty when the whole document was retrieved
+	ChunkID string `bson:"chunk_id,omitempty" json:"chunk_id,omitempty"`
+	Title   string `bson:"title" json:"title"`
+	// Score is the cosine similarity to the CV or project report; 0 for pinned job descriptions
+	Score  float64 `bson:"score" json:"score"`
+	Tokens int     `bson:"tokens" json:"tokens"`
+	// Included reports whether it fit in the token budget and was added to the prompt
+	Included bool `bson:"included" json:"included"`
+}
+
+// TokenUsage counts LLM tokens and their estimated cost
+type TokenUsage struct {
+	Calls            int     `bson:"calls" json:"calls"`
+	PromptTokens     int     `bson:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int     `bson:"completion_tokens" json:"completion_tokens"`
+	TotalTokens      int     `bson:"total_tokens" json:"total_tokens"`
+	EstimatedCostUSD float64 `bson:"estimated_cost_usd" json:"estimated_cost_usd"`
+}
+
+// Add accumulates other into u
+func (u *TokenUsage) Add(other TokenUsage) {
+	u.Calls += other.Calls
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.EstimatedCostUSD += other.EstimatedCostUSD
+}
+
+// ModelUsage is the token usage of one model
+type ModelUsage struct {
+	Model      string `bson:"model" json:"model"`
+	TokenUsage `bson:",inline"`
+}
+
+// JobUsage is the LLM usage of a job's latest evaluation attempt, in total and per model
+type JobUsage struct {
+	TokenUsage `bson:",inline"`
+	Models     []ModelUsage `bson:"models" json:"models"`
+}
+
+// UsageTotal aggregates the LLM usage of evaluations per day, organization and model
+type UsageTotal struct {
+	// Date is the UTC day, formatted as YYYY-MM-DD
+	Date  string `bson:"date" json:"date"`
+	OrgID string `bson:"org_id" json:"org_id,omitempty"`
+	Model string `bson:"model" json:"model"`
+	// Evaluations counts the evaluation attempts that used the model
+	Evaluations int `bson:"evaluations" json:"evaluations"`
+	TokenUsage  `bson:",inline"`
+}
+
+// DocumentChunk is a passage of a job description or reference document embedded on its own so retrieval
+// can return the relevant parts of long documents. Chunks are replaced whenever their document is re-embedded.
+type DocumentChunk struct {
+	ID         string `bson:"_id" json:"id"`
+	DocumentID string `bson:"document_id" json:"document_id"`
+	// DocumentType is the type of the chunk's document; empty for job descriptions chunked before types existed
+	DocumentType   string    `bson:"document_type,omitempty" json:"document_type,omitempty"`
+	Title          string    `bson:"title" json:"title"`
+	Index          int       `bson:"index" json:"index"`
+	Text           string    `bson:"text" json:"text"`
+	Tokens         int       `bson:"tokens" json:"tokens"`
+	Embedding      []float64 `bson:"embedding" json:"embedding,omitempty"`
+	EmbeddingModel string    `bson:"embedding_model,omitempty" json:"embedding_model,omitempty"`
+	EmbeddingNorm  float64   `bson:"embedding_norm,omitempty" json:"embedding_norm,omitempty"`
+	OrgID          string    `bson:"org_id,omitempty" json:"org_id,omitempty"`
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+}
+
+// CachedEmbedding is an embedding stored for reuse, keyed by the SHA-256 of its model and normalized text
+type CachedEmbedding struct {
+	Key       string    `bson:"_id" json:"key"`
+	Model     string    `bson:"model" json:"model"`
+	Embedding []float64 `bson:"embedding" json:"embedding"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
+
+// LLMCall is the audit record of one LLM request and its response. Prompt and Response hold the
+// content truncated to LLM_AUDIT_MAX_CONTENT bytes; PromptHash identifies the full prompt.
+type LLMCall struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrgID             string             `bson:"org_id,omitempty" json:"org_id,omitempty"`
+	JobID             string             `bson:"job_id,omitempty" json:"job_id,omitempty"`
+	Step              string             `bson:"step,omitempty" json:"step,omitempty"`
+	Operation         string             `bson:"operation" json:"operation"`
+	Model             string             `bson:"model" json:"model"`
+	Temperature       *float32           `bson:"temperature,omitempty" json:"temperature,omitempty"`
+	Schema            string             `bson:"schema,omitempty" json:"schema,omitempty"`
+	PromptHash        string             `bson:"prompt_hash" json:"prompt_hash"`
+	Prompt            string             `bson:"prompt,omitempty" json:"prompt,omitempty"`
+	PromptTruncated   bool               `bson:"prompt_truncated,omitempty" json:"prompt_truncated,omitempty"`
+	Response          string             `bson:"response,omitempty" json:"response,omitempty"`
+	ResponseTruncated bool               `bson:"response_truncated,omitempty" json:"response_truncated,omitempty"`
+	PromptTokens      int                `bson:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens  int                `bson:"completion_tokens" json:"completion_tokens"`
+	LatencyMs         int64              `bson:"latency_ms" json:"latency_ms"`
+	Error             string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// UsageResponse is the admin usage report
+type UsageResponse struct {
+	From   string       `json:"from,omitempty"`
+	To     string       `json:"to,omitempty"`
+	Total  TokenUsage   `json:"total"`
+	Totals []UsageTotal `json:"totals"`
+}
+
 // EvaluationJob represents a job in the evaluation queue
 type EvaluationJob struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -25,16 +206,212 @@ type EvaluationJob struct {
 	StartedAt   *time.Time         `bson:"started_at,omitempty" json:"started_at,omitempty"`
 	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
 
+	// DeletedAt is set when the job is soft-deleted; deleted jobs are hidden from every query until purged
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	// ContentErasedAt is set once the job's documents and uploaded files were erased, by the retention
+	// policy or an erasure request
+	ContentErasedAt *time.Time `bson:"content_erased_at,omitempty" json:"content_erased_at,omitempty"`
+	// AnonymizedAt is set once an erasure request removed everything identifying the candidate
+	AnonymizedAt *time.Time `bson:"anonymized_at,omitempty" json:"anonymized_at,omitempty"`
+
 	// Input files
 	CVFile         string `bson:"cv_file" json:"cv_file"`
 	ProjectFile    string `bson:"project_file" json:"project_file"`
 	CVContent      string `bson:"cv_content" json:"cv_content"`
 	ProjectContent string `bson:"project_content" json:"project_content"`
+	CVHash         string `bson:"cv_hash,omitempty" json:"cv_hash,omitempty"`
+	ProjectHash    string `bson:"project_hash,omitempty" json:"project_hash,omitempty"`
+
+	// ProjectEmbedding is the vector of the project report, compared with later submissions to find copies
+	ProjectEmbedding      []float64 `bson:"project_embedding,omitempty" json:"project_embedding,omitempty"`
+	ProjectEmbeddingModel string    `bson:"project_embedding_model,omitempty" json:"project_embedding_model,omitempty"`
+
+	// ContentHash covers the documents, job description and rubrics, see EvaluationService.ContentHash
+	ContentHash string `bson:"content_hash,omitempty" json:"content_hash,omitempty"`
+
+	// OrgID is the organization that owns the job; empty for single-tenant deployments
+	OrgID string `bson:"org_id,omitempty" json:"org_id,omitempty"`
+
+	// CandidateID groups repeat evaluations of the same person, e.g. re-applications
+	CandidateID string `bson:"candidate_id,omitempty" json:"candidate_id,omitempty"`
+	// CandidateName is the candidate's display name, used to find their jobs
+	CandidateName string `bson:"candidate_name,omitempty" json:"candidate_name,omitempty"`
+
+	// JobDescriptionID pins the evaluation to one job description instead of retrieved context
+	JobDescriptionID string `bson:"job_description_id,omitempty" json:"job_description_id,omitempty"`
+
+	// GitHub is the candidate's GitHub username, "owner/repo" or repository URL, analyzed for the project evaluation
+	GitHub string `bson:"github,omitempty" json:"github,omitempty"`
+
+	// PreviousJobID links a job created by a re-evaluation to the job (and result) it reran. Re-evaluations
+	// now rerun the job itself, so only jobs re-evaluated before that have it.
+	PreviousJobID string `bson:"previous_job_id,omitempty" json:"previous_job_id,omitempty"`
+
+	// BatchID links the job to the batch evaluation that created it
+	BatchID string `bson:"batch_id,omitempty" json:"batch_id,omitempty"`
+
+	// ScoringOptions overrides the rubrics and weights the job is scored with
+	ScoringOptions `bson:",inline"`
+
+	// TraceParent is the W3C trace context of the request that created the job, so the worker
+	// continues the same trace; TraceID identifies that trace in the tracing backend
+	TraceParent string `bson:"trace_parent,omitempty" json:"trace_parent,omitempty"`
+	TraceID     string `bson:"trace_id,omitempty" json:"trace_id,omitempty"`
+
+	// IdempotencyKey is the Idempotency-Key header of the request that created the job; a retry with the
+	// same key gets this job instead of a new one
+	IdempotencyKey string `bson:"idempotency_key,omitempty" json:"idempotency_key,omitempty"`
+
+	// ClaimToken identifies the worker attempt that claimed the job; result and error writes from any
+	// other attempt, e.g. one whose job was requeued meanwhile, are ignored
+	ClaimToken string `bson:"claim_token,omitempty" json:"claim_token,omitempty"`
+
+	// EnqueuePending is the job's outbox entry: it is stored with the job and cleared once the job ID is on
+	// the queue, so a job whose enqueue failed is pushed again by the outbox dispatcher
+	EnqueuePending bool `bson:"enqueue_pending,omitempty" json:"enqueue_pending,omitempty"`
+
+	// RunAt delays the evaluation: the job waits in the scheduled set until then instead of the queue
+	RunAt *time.Time `bson:"run_at,omitempty" json:"run_at,omitempty"`
+
+	// Steps tracks pipeline progress while the job is processed
+	Steps []JobStep `bson:"steps,omitempty" json:"steps,omitempty"`
+
+	// Usage is the LLM token usage and estimated cost of the latest evaluation attempt
+	Usage *JobUsage `bson:"usage,omitempty" json:"usage,omitempty"`
+
+	// Retrieval records the context retrieved for each step of the latest evaluation attempt
+	Retrieval []StepRetrieval `bson:"retrieval,omitempty" json:"retrieval,omitempty"`
 
 	// Results
 	Result       *EvaluationResult `bson:"result,omitempty" json:"result,omitempty"`
 	ErrorMessage string            `bson:"error_message,omitempty" json:"error_message,omitempty"`
+	ErrorType    ErrorType         `bson:"error_type,omitempty" json:"error_type,omitempty"`
 	RetryCount   int               `bson:"retry_count" json:"retry_count"`
+
+	// ResultHistory keeps the results the job had before its current one, oldest first. A re-evaluation
+	// moves the job's result here before the job runs again.
+	ResultHistory []*EvaluationResult `bson:"result_history,omitempty" json:"result_history,omitempty"`
+
+	// Sandbox jobs are evaluated with the mock LLM and never reach a provider
+	Sandbox bool `bson:"sandbox,omitempty" json:"sandbox,omitempty"`
+
+	// InjectionRisk is set when the documents contain instruction-like content aimed at the evaluator;
+	// InjectionSignals lists what was found, e.g. "cv:ignore_instructions"
+	InjectionRisk    bool     `bson:"injection_risk,omitempty" json:"injection_risk,omitempty"`
+	InjectionSignals []string `bson:"injection_signals,omitempty" json:"injection_signals,omitempty"`
+
+	// ParsedCV is the structured content of the CV, set by the parse endpoint
+	ParsedCV *ParsedResume `bson:"parsed_cv,omitempty" json:"parsed_cv,omitempty"`
+}
+
+// ParsedResume is the normalized, structured content of a CV. Dates are "YYYY-MM", or "YYYY" when the
+// CV only gives the year.
+type ParsedResume struct {
+	Contact    ResumeContact      `bson:"contact" json:"contact"`
+	Experience []ResumeExperience `bson:"experience" json:"experience"`
+	Education  []ResumeEducation  `bson:"education" json:"education"`
+	Skills     []string           `bson:"skills" json:"skills"`
+	// ExperienceMonths totals the employment periods, counting overlapping periods once
+	ExperienceMonths int       `bson:"experience_months" json:"experience_months"`
+	ParsedAt         time.Time `bson:"parsed_at" json:"parsed_at"`
+}
+
+// ResumeContact holds a candidate's contact details as found in the CV
+type ResumeContact struct {
+	Name     string   `bson:"name,omitempty" json:"name,omitempty"`
+	Email    string   `bson:"email,omitempty" json:"email,omitempty"`
+	Phone    string   `bson:"phone,omitempty" json:"phone,omitempty"`
+	Location string   `bson:"location,omitempty" json:"location,omitempty"`
+	Links    []string `bson:"links,omitempty" json:"links,omitempty"`
+}
+
+// ResumeExperience is one position in a candidate's employment history
+type ResumeExperience struct {
+	Title       string `bson:"title" json:"title"`
+	Company     string `bson:"company" json:"company"`
+	Location    string `bson:"location,omitempty" json:"location,omitempty"`
+	StartDate   string `bson:"start_date,omitempty" json:"start_date,omitempty"`
+	EndDate     string `bson:"end_date,omitempty" json:"end_date,omitempty"`
+	Current     bool   `bson:"current,omitempty" json:"current,omitempty"`
+	Description string `bson:"description,omitempty" json:"description,omitempty"`
+}
+
+// ResumeEducation is one degree or course of study
+type ResumeEducation struct {
+	Institution string `bson:"institution" json:"institution"`
+	Degree      string `bson:"degree,omitempty" json:"degree,omitempty"`
+	Field       string `bson:"field,omitempty" json:"field,omitempty"`
+	StartDate   string `bson:"start_date,omitempty" json:"start_date,omitempty"`
+	EndDate     string `bson:"end_date,omitempty" json:"end_date,omitempty"`
+}
+
+// Anonymize removes the candidate's documents, identifiers and feedback from the job, keeping only
+// its scores
+func (j *EvaluationJob) Anonymize(now time.Time) {
+	j.CVFile = ""
+	j.ProjectFile = ""
+	j.CVContent = ""
+	j.ProjectContent = ""
+	j.CVHash = ""
+	j.ProjectHash = ""
+	j.ProjectEmbedding = nil
+	j.ProjectEmbeddingModel = ""
+	j.ContentHash = ""
+	j.CandidateID = ""
+	j.CandidateName = ""
+	j.ParsedCV = nil
+	j.GitHub = ""
+	// Errors may quote file names or document text
+	j.ErrorMessage = ""
+	for i := range j.Steps {
+		j.Steps[i].Error = ""
+	}
+
+	for _, result := range j.Results() {
+		result.EraseContent()
+		result.CVFeedback = ""
+		result.ProjectFeedback = ""
+		result.OverallSummary = ""
+		result.GitHub = nil
+		result.GitHubError = ""
+		if result.Review != nil {
+			result.Review.Issues = nil
+			result.Review.Error = ""
+		}
+	}
+
+	if j.ContentErasedAt == nil {
+		j.ContentErasedAt = &now
+	}
+	j.AnonymizedAt = &now
+	j.UpdatedAt = now
+}
+
+// Results returns every result the job has had, oldest first: its result history followed by its current result
+func (j *EvaluationJob) Results() []*EvaluationResult {
+	results := make([]*EvaluationResult, 0, len(j.ResultHistory)+1)
+	results = append(results, j.ResultHistory...)
+	if j.Result != nil {
+		results = append(results, j.Result)
+	}
+	return results
+}
+
+// ErasureRecord is the audit record of an erasure request. It holds no personal data; the candidate ID
+// is kept as a SHA-256 hash so repeated requests for the same candidate can be matched.
+type ErasureRecord struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrgID           string             `bson:"org_id,omitempty" json:"org_id,omitempty"`
+	CandidateIDHash string             `bson:"candidate_id_hash,omitempty" json:"candidate_id_hash,omitempty"`
+	JobIDs          []string           `bson:"job_ids" json:"job_ids"`
+	// ArchivedJobs counts the erased jobs that had been archived
+	ArchivedJobs      int       `bson:"archived_jobs" json:"archived_jobs"`
+	FilesRemoved      int       `bson:"files_removed" json:"files_removed"`
+	EmbeddingsRemoved int64     `bson:"embeddings_removed" json:"embeddings_removed"`
+	LLMCallsRemoved   int64     `bson:"llm_calls_removed" json:"llm_calls_removed"`
+	GoldenJobsRemoved int       `bson:"golden_jobs_removed" json:"golden_jobs_removed"`
+	Reason            string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	CreatedAt         time.Time `bson:"created_at" json:"created_at"`
 }
 
 // EvaluationResult represents the final evaluation result
@@ -44,10 +421,188 @@ type EvaluationResult struct {
 	ProjectScore    float64 `bson:"project_score" json:"project_score"`
 	ProjectFeedback string  `bson:"project_feedback" json:"project_feedback"`
 	OverallSummary  string  `bson:"overall_summary" json:"overall_summary"`
+	OverallScore    float64 `bson:"overall_score" json:"overall_score"`
 
 	// Detailed scores
 	CVScores      CVScores      `bson:"cv_scores" json:"cv_scores"`
 	ProjectScores ProjectScores `bson:"project_scores" json:"project_scores"`
+
+	// Scores keyed by rubric criterion, including criteria without a field above
+	CVCriteria      map[string]float64 `bson:"cv_criteria,omitempty" json:"cv_criteria,omitempty"`
+	ProjectCriteria map[string]float64 `bson:"project_criteria,omitempty" json:"project_criteria,omitempty"`
+
+	// Review is the judge's verdict when verification is enabled. NeedsReview is set when the judge found
+	// inconsistencies it did not correct, or could not verify the result at all.
+	Review      *EvaluationReview `bson:"review,omitempty" json:"review,omitempty"`
+	NeedsReview bool              `bson:"needs_review,omitempty" json:"needs_review,omitempty"`
+
+	// Scale records the rubric scales the scores were computed on; Display holds the same scores on the display scales
+	Scale   *ResultScale   `bson:"scale,omitempty" json:"scale,omitempty"`
+	Display *DisplayScores `bson:"display,omitempty" json:"display,omitempty"`
+
+	// Redactions maps the placeholders sent to the LLM provider in place of personal data, such as
+	// [EMAIL_1], to the values they replaced. It is only set when PII redaction is enabled.
+	Redactions map[string]string `bson:"redactions,omitempty" json:"redactions,omitempty"`
+
+	// Blind is set for results of blind evaluations, which were scored on the anonymized documents below
+	Blind               bool   `bson:"blind,omitempty" json:"blind,omitempty"`
+	BlindCVContent      string `bson:"blind_cv_content,omitempty" json:"blind_cv_content,omitempty"`
+	BlindProjectContent string `bson:"blind_project_content,omitempty" json:"blind_project_content,omitempty"`
+
+	// PromptVersions holds the active template version of each step prompt when the job was evaluated
+	PromptVersions map[string]int `bson:"prompt_versions,omitempty" json:"prompt_versions,omitempty"`
+
+	// GitHub is the repository data the project evaluation was grounded on, when the job named a GitHub
+	// account or repository. GitHubError is set instead when it could not be fetched.
+	GitHub      *GitHubAnalysis `bson:"github,omitempty" json:"github,omitempty"`
+	GitHubError string          `bson:"github_error,omitempty" json:"github_error,omitempty"`
+
+	// SimilarProjects lists earlier project reports of other candidates at least as similar as the
+	// plagiarism threshold, most similar first; PlagiarismSuspected is set when there are any
+	SimilarProjects     []SimilarProject `bson:"similar_projects,omitempty" json:"similar_projects,omitempty"`
+	PlagiarismSuspected bool             `bson:"plagiarism_suspected,omitempty" json:"plagiarism_suspected,omitempty"`
+
+	// Recommendation is the hiring decision the overall score maps to
+	Recommendation *HiringRecommendation `bson:"recommendation,omitempty" json:"recommendation,omitempty"`
+
+	// Version numbers the results of a job from 1, counting those in its result history. JobID is the job
+	// that produced the result, EvaluatedAt when, and Models, CVRubric and ProjectRubric with PromptVersions
+	// what it was produced with. Results stored before versioning have none of these.
+	Version       int            `bson:"version,omitempty" json:"version,omitempty"`
+	JobID         string         `bson:"job_id,omitempty" json:"job_id,omitempty"`
+	EvaluatedAt   *time.Time     `bson:"evaluated_at,omitempty" json:"evaluated_at,omitempty"`
+	Models        []string       `bson:"models,omitempty" json:"models,omitempty"`
+	CVRubric      *RubricVersion `bson:"cv_rubric,omitempty" json:"cv_rubric,omitempty"`
+	ProjectRubric *RubricVersion `bson:"project_rubric,omitempty" json:"project_rubric,omitempty"`
+
+	// Experiment is the experiment variant the result was evaluated with, if any
+	Experiment *ExperimentAssignment `bson:"experiment,omitempty" json:"experiment,omitempty"`
+}
+
+// EraseContent drops what a result keeps of the documents it was evaluated on: the redaction mapping and
+// the anonymized documents of blind evaluations
+func (r *EvaluationResult) EraseContent() {
+	r.Redactions = nil
+	r.BlindCVContent = ""
+	r.BlindProjectContent = ""
+}
+
+// RubricVersion identifies the rubric a result was scored with. Rubrics are edited in place, so Hash
+// fingerprints the criteria, weights and scale it had at the time.
+type RubricVersion struct {
+	ID   string `bson:"id,omitempty" json:"id,omitempty"`
+	Name string `bson:"name" json:"name"`
+	Hash string `bson:"hash" json:"hash"`
+}
+
+// Hiring recommendations
+const (
+	RecommendationStrongHire = "strong_hire"
+	RecommendationHire       = "hire"
+	RecommendationNoHire     = "no_hire"
+)
+
+// Sources of recommendation thresholds
+const (
+	// ThresholdsJobDescription are the thresholds calibrated for the job's job description
+	ThresholdsJobDescription = "job_description"
+	// ThresholdsDefault are the configured thresholds, for jobs whose job description has none
+	ThresholdsDefault = "default"
+)
+
+// RecommendationThresholds are the overall scores, as fractions of the overall scale's maximum, from which a
+// candidate is recommended as a strong hire or a hire; lower scores are no hire
+type RecommendationThresholds struct {
+	StrongHire float64 `bson:"strong_hire" json:"strong_hire"`
+	Hire       float64 `bson:"hire" json:"hire"`
+}
+
+// HiringRecommendation is the decision an overall score maps to and the thresholds it was compared with
+type HiringRecommendation struct {
+	Decision string `bson:"decision" json:"decision"`
+	// Score is the overall score as a fraction of the overall scale's maximum
+	Score      float64                  `bson:"score" json:"score"`
+	Thresholds RecommendationThresholds `bson:"thresholds" json:"thresholds"`
+	Source     string                   `bson:"source" json:"source"`
+}
+
+// SimilarProject is an earlier job whose project report closely matches the evaluated one
+type SimilarProject struct {
+	JobID      string  `bson:"job_id" json:"job_id"`
+	Similarity float64 `bson:"similarity" json:"similarity"`
+	// Identical is set when the two reports have exactly the same text
+	Identical bool `bson:"identical,omitempty" json:"identical,omitempty"`
+}
+
+// GitHubAnalysis is what the GitHub API reports about a candidate's account or project repository
+type GitHubAnalysis struct {
+	// Profile is set when a username rather than a single repository was given
+	Profile      *GitHubProfile     `bson:"profile,omitempty" json:"profile,omitempty"`
+	Repositories []GitHubRepository `bson:"repositories" json:"repositories"`
+	FetchedAt    time.Time          `bson:"fetched_at" json:"fetched_at"`
+}
+
+// GitHubProfile summarizes a GitHub account
+type GitHubProfile struct {
+	Login       string    `bson:"login" json:"login"`
+	Name        string    `bson:"name,omitempty" json:"name,omitempty"`
+	Bio         string    `bson:"bio,omitempty" json:"bio,omitempty"`
+	PublicRepos int       `bson:"public_repos" json:"public_repos"`
+	Followers   int       `bson:"followers" json:"followers"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
+
+// GitHubRepository summarizes a repository: its metadata, language mix, README and recent commit activity
+type GitHubRepository struct {
+	FullName    string   `bson:"full_name" json:"full_name"`
+	URL         string   `bson:"url" json:"url"`
+	Description string   `bson:"description,omitempty" json:"description,omitempty"`
+	Topics      []string `bson:"topics,omitempty" json:"topics,omitempty"`
+	License     string   `bson:"license,omitempty" json:"license,omitempty"`
+	Stars       int      `bson:"stars" json:"stars"`
+	Forks       int      `bson:"forks" json:"forks"`
+	OpenIssues  int      `bson:"open_issues" json:"open_issues"`
+	Fork        bool     `bson:"fork,omitempty" json:"fork,omitempty"`
+	Archived    bool     `bson:"archived,omitempty" json:"archived,omitempty"`
+	// Languages maps each language to its share of the code in percent
+	Languages map[string]float64 `bson:"languages,omitempty" json:"languages,omitempty"`
+	// RootFiles are the names at the top of the default branch, which show tests, CI and build setup
+	RootFiles []string `bson:"root_files,omitempty" json:"root_files,omitempty"`
+	// Readme is the start of the README, truncated
+	Readme    string    `bson:"readme,omitempty" json:"readme,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	PushedAt  time.Time `bson:"pushed_at" json:"pushed_at"`
+	// RecentCommits counts the default branch's commits of the last 90 days, up to 100; Contributors counts
+	// their distinct authors
+	RecentCommits int        `bson:"recent_commits" json:"recent_commits"`
+	Contributors  int        `bson:"contributors" json:"contributors"`
+	LastCommitAt  *time.Time `bson:"last_commit_at,omitempty" json:"last_commit_at,omitempty"`
+}
+
+// EvaluationReview is the judge's verdict on an evaluation
+type EvaluationReview struct {
+	Consistent bool     `bson:"consistent" json:"consistent"`
+	Issues     []string `bson:"issues,omitempty" json:"issues,omitempty"`
+	// Corrected is set when the judge's corrected scores or feedback replaced the original ones
+	Corrected bool `bson:"corrected,omitempty" json:"corrected,omitempty"`
+	// Error is set when verification failed
+	Error string `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// ResultScale holds the scales of a result's CV, project and overall scores. The overall score uses the CV scale.
+type ResultScale struct {
+	CV      RubricScale `bson:"cv" json:"cv"`
+	Project RubricScale `bson:"project" json:"project"`
+	Overall RubricScale `bson:"overall" json:"overall"`
+}
+
+// DisplayScores are a result's scores converted to the display scales, e.g. percentages
+type DisplayScores struct {
+	CVScore         float64            `bson:"cv_score" json:"cv_score"`
+	ProjectScore    float64            `bson:"project_score" json:"project_score"`
+	OverallScore    float64            `bson:"overall_score" json:"overall_score"`
+	CVCriteria      map[string]float64 `bson:"cv_criteria,omitempty" json:"cv_criteria,omitempty"`
+	ProjectCriteria map[string]float64 `bson:"project_criteria,omitempty" json:"project_criteria,omitempty"`
 }
 
 // CVScores represents detailed CV evaluation scores
@@ -73,8 +628,282 @@ type JobDescription struct {
 	Title        string             `bson:"title" json:"title"`
 	Description  string             `bson:"description" json:"description"`
 	Requirements string             `bson:"requirements" json:"requirements"`
-	Embedding    []float64          `bson:"embedding" json:"embedding"`
+	Embedding    []float64          `bson:"embedding" json:"embedding,omitempty"`
 	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+
+	// OrgID is the organization that owns the job description; empty for single-tenant deployments
+	OrgID string `bson:"org_id,omitempty" json:"org_id,omitempty"`
+
+	// Embedding provenance; vectors are only comparable with queries from the same model
+	EmbeddingModel      string `bson:"embedding_model,omitempty" json:"embedding_model,omitempty"`
+	EmbeddingDimensions int    `bson:"embedding_dimensions,omitempty" json:"embedding_dimensions,omitempty"`
+	// EmbeddingNorm is the Euclidean length of Embedding, kept so searches need not recompute it; 0 when unknown
+	EmbeddingNorm float64 `bson:"embedding_norm,omitempty" json:"embedding_norm,omitempty"`
+
+	// RecommendationThresholds are calibrated for this job description; nil uses the configured defaults
+	RecommendationThresholds *RecommendationThresholds `bson:"recommendation_thresholds,omitempty" json:"recommendation_thresholds,omitempty"`
+}
+
+// Document types retrieved as evaluation context
+const (
+	DocumentTypeJobDescription   = "job_description"
+	DocumentTypeScoringGuideline = "scoring_guideline"
+	DocumentTypeCompanyValues    = "company_values"
+	DocumentTypeCaseStudy        = "case_study"
+)
+
+// ReferenceDocumentTypes are the types of documents ingested through the RAG documents endpoint
+var ReferenceDocumentTypes = []string{DocumentTypeScoringGuideline, DocumentTypeCompanyValues, DocumentTypeCaseStudy}
+
+// ReferenceDocument is a document other than a job description, such as scoring guidelines, company values or
+// a case-study brief, that is chunked and retrieved as context for the evaluation steps that use its type
+type ReferenceDocument struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type      string             `bson:"type" json:"type"`
+	Title     string             `bson:"title" json:"title"`
+	Content   string             `bson:"content" json:"content"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+
+	// OrgID is the organization that owns the document; empty for single-tenant deployments
+	OrgID string `bson:"org_id,omitempty" json:"org_id,omitempty"`
+}
+
+// Index rebuild states
+const (
+	IndexRebuildRunning   = "running"
+	IndexRebuildCompleted = "completed"
+	IndexRebuildFailed    = "failed"
+)
+
+// IndexRebuild tracks a batched rebuild of the job description vectors so it can be resumed after interruption
+type IndexRebuild struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Status         string             `bson:"status" json:"status"`
+	Model          string             `bson:"model" json:"model"`
+	BatchSize      int                `bson:"batch_size" json:"batch_size"`
+	Wiped          bool               `bson:"wiped" json:"wiped"`
+	Total          int                `bson:"total" json:"total"`
+	Processed      int                `bson:"processed" json:"processed"`
+	Failed         int                `bson:"failed" json:"failed"`
+	Progress       float64            `bson:"progress" json:"progress"`
+	LastDocumentID string             `bson:"last_document_id,omitempty" json:"last_document_id,omitempty"`
+	Error          string             `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt      time.Time          `bson:"started_at" json:"started_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+	CompletedAt    *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// Fairness report states
+const (
+	FairnessReportRunning   = "running"
+	FairnessReportCompleted = "completed"
+	FairnessReportFailed    = "failed"
+)
+
+// FairnessReport compares the score distributions of evaluation cohorts, such as blind and regular
+// evaluations or evaluations run with different models or prompt versions, and flags systematic drift.
+// Scores are percentages of their scale so results scored on different rubrics can be compared.
+type FairnessReport struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Status string             `bson:"status" json:"status"`
+	// Since limits the report to evaluations created after it
+	Since *time.Time `bson:"since,omitempty" json:"since,omitempty"`
+	// Threshold is the score difference, in percentage points, reported as drift
+	Threshold    float64             `bson:"threshold" json:"threshold"`
+	JobsAnalyzed int                 `bson:"jobs_analyzed" json:"jobs_analyzed"`
+	Dimensions   []FairnessDimension `bson:"dimensions,omitempty" json:"dimensions,omitempty"`
+	// Drifts describes each cohort flagged as drifting from its baseline
+	Drifts      []string   `bson:"drifts,omitempty" json:"drifts,omitempty"`
+	Error       string     `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt   time.Time  `bson:"started_at" json:"started_at"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// FairnessDimension compares the cohorts of one dimension, e.g. "blind", with its baseline cohort
+type FairnessDimension struct {
+	Name     string           `bson:"name" json:"name"`
+	Baseline string           `bson:"baseline" json:"baseline"`
+	Cohorts  []FairnessCohort `bson:"cohorts" json:"cohorts"`
+}
+
+// FairnessCohort summarizes the overall scores of the evaluations sharing one value of a dimension
+type FairnessCohort struct {
+	Value  string  `bson:"value" json:"value"`
+	Jobs   int     `bson:"jobs" json:"jobs"`
+	Mean   float64 `bson:"mean" json:"mean"`
+	Median float64 `bson:"median" json:"median"`
+	StdDev float64 `bson:"std_dev" json:"std_dev"`
+	// MeanDelta is the cohort's mean minus the baseline's
+	MeanDelta float64 `bson:"mean_delta" json:"mean_delta"`
+	// Pairs counts the CVs evaluated against the same job description in this cohort and the baseline;
+	// PairedDelta is their mean score difference, which does not depend on who applied in each cohort
+	Pairs       int     `bson:"pairs" json:"pairs"`
+	PairedDelta float64 `bson:"paired_delta" json:"paired_delta"`
+	// Drift is set when the cohort's scores differ from the baseline's by at least the threshold, and by
+	// more than chance would explain
+	Drift bool `bson:"drift" json:"drift"`
+}
+
+// FairnessReportRequest starts a fairness report
+type FairnessReportRequest struct {
+	// Days limits the report to evaluations created in the last days; 0 covers every evaluation
+	Days int `json:"days"`
+	// Threshold is the score difference, in percentage points, reported as drift; 0 uses the default
+	Threshold float64 `json:"threshold"`
+}
+
+// Experiment states
+const (
+	ExperimentRunning = "running"
+	ExperimentStopped = "stopped"
+)
+
+// Experiment splits live evaluations between two variants of the evaluation prompts and model, so a change
+// can be validated before it is rolled out. At most one experiment runs at a time.
+type Experiment struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Name        string              `bson:"name" json:"name"`
+	Description string              `bson:"description,omitempty" json:"description,omitempty"`
+	Status      string              `bson:"status" json:"status"`
+	Variants    []ExperimentVariant `bson:"variants" json:"variants"`
+	CreatedAt   time.Time           `bson:"created_at" json:"created_at"`
+	StoppedAt   *time.Time          `bson:"stopped_at,omitempty" json:"stopped_at,omitempty"`
+}
+
+// ExperimentVariant is one arm of an experiment: the percentage of evaluations routed to it and what they
+// are evaluated with. An empty model uses the evaluation model; steps without a prompt version render their
+// active template, and version 0 is the built-in default.
+type ExperimentVariant struct {
+	Name           string         `bson:"name" json:"name"`
+	Percent        int            `bson:"percent" json:"percent"`
+	Model          string         `bson:"model,omitempty" json:"model,omitempty"`
+	PromptVersions map[string]int `bson:"prompt_versions,omitempty" json:"prompt_versions,omitempty"`
+}
+
+// ExperimentAssignment tags a result with the experiment variant it was evaluated with
+type ExperimentAssignment struct {
+	ExperimentID string `bson:"experiment_id" json:"experiment_id"`
+	Variant      string `bson:"variant" json:"variant"`
+}
+
+// ExperimentRequest defines a new experiment
+type ExperimentRequest struct {
+	Name        string              `json:"name" binding:"required"`
+	Description string              `json:"description"`
+	Variants    []ExperimentVariant `json:"variants" binding:"required"`
+}
+
+// ExperimentComparison compares the results of an experiment's variants. Scores are percentages of their
+// scale, like in fairness reports.
+type ExperimentComparison struct {
+	ExperimentID string                   `json:"experiment_id"`
+	Variants     []ExperimentVariantStats `json:"variants"`
+	// Pairs counts the CVs evaluated with both variants against the same job description.
+	// DisagreementRate is the share of them whose hiring recommendations differ, and MeanScoreDelta the
+	// mean score of the second variant minus the first's.
+	Pairs            int     `json:"pairs"`
+	DisagreementRate float64 `json:"disagreement_rate"`
+	MeanScoreDelta   float64 `json:"mean_score_delta"`
+}
+
+// ExperimentVariantStats summarizes the overall scores of the evaluations routed to one variant
+type ExperimentVariantStats struct {
+	Variant     string  `json:"variant"`
+	Evaluations int     `json:"evaluations"`
+	Mean        float64 `json:"mean"`
+	Median      float64 `json:"median"`
+	StdDev      float64 `json:"std_dev"`
+	Min         float64 `json:"min"`
+	Max         float64 `json:"max"`
+	// Distribution counts the scores in each tenth of the scale, from 0-10 to 90-100
+	Distribution []int `json:"distribution"`
+	// Recommendations counts the hiring recommendations by decision
+	Recommendations map[string]int `json:"recommendations,omitempty"`
+}
+
+// BatchJob groups the evaluation jobs of an applicant pool submitted in one request
+type BatchJob struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrgID            string             `bson:"org_id,omitempty" json:"org_id,omitempty"`
+	JobDescriptionID string             `bson:"job_description_id,omitempty" json:"job_description_id,omitempty"`
+	Sandbox          bool               `bson:"sandbox,omitempty" json:"sandbox,omitempty"`
+	RunAt            *time.Time         `bson:"run_at,omitempty" json:"run_at,omitempty"`
+	ScoringOptions   `bson:",inline"`
+	Items            []BatchItem `bson:"items" json:"items"`
+	// Skipped lists the files of an imported ZIP archive that did not become a candidate
+	Skipped   []SkippedFile `bson:"skipped,omitempty" json:"skipped,omitempty"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+}
+
+// SkippedFile is a file of an imported ZIP archive that was left out of a batch, with the reason
+type SkippedFile struct {
+	Name   string `bson:"name" json:"name"`
+	Reason string `bson:"reason" json:"reason"`
+}
+
+// BatchItem is one candidate of a batch. JobID is empty when the candidate was rejected, with Error saying why.
+type BatchItem struct {
+	JobID       string `bson:"job_id,omitempty" json:"job_id,omitempty"`
+	CandidateID string `bson:"candidate_id,omitempty" json:"candidate_id,omitempty"`
+	CVFile      string `bson:"cv_file" json:"cv_file"`
+	ProjectFile string `bson:"project_file" json:"project_file"`
+	// Source is the path of the CV in the ZIP archive the candidate was imported from
+	Source    string `bson:"source,omitempty" json:"source,omitempty"`
+	Duplicate bool   `bson:"duplicate,omitempty" json:"duplicate,omitempty"`
+	Cached    bool   `bson:"cached,omitempty" json:"cached,omitempty"`
+	Error     string `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// GoldenJob is a curated job whose recorded result serves as the expected output for prompt/model changes
+type GoldenJob struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	JobID  string             `bson:"job_id" json:"job_id"`
+	Label  string             `bson:"label" json:"label"`
+	Result EvaluationResult   `bson:"result" json:"result"`
+	// ExpectedRanges bounds scores, keyed like the comparison deltas, e.g. "overall_score"; scores without
+	// a range are only held to the tolerance
+	ExpectedRanges map[string]ScoreRange `bson:"expected_ranges,omitempty" json:"expected_ranges,omitempty"`
+	// Source is the job as it was when it joined the golden set; comparisons re-run it, since the retention
+	// policy later erases or purges the job itself
+	Source    *EvaluationJob `bson:"source,omitempty" json:"source,omitempty"`
+	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
+}
+
+// ScoreRange is the inclusive range a golden job's score is expected to stay in
+type ScoreRange struct {
+	Min float64 `bson:"min" json:"min"`
+	Max float64 `bson:"max" json:"max"`
+}
+
+// PromptTemplate is the text/template source used to build the prompt for one evaluation step.
+// The stored document is the active version; every saved version is kept as a PromptTemplateVersion.
+type PromptTemplate struct {
+	Name        string             `bson:"_id" json:"name"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Template    string             `bson:"template" json:"template"`
+	ModelParams *PromptModelParams `bson:"model_params,omitempty" json:"model_params,omitempty"`
+	// Version is the active version number; built-in defaults are version 0
+	Version   int       `bson:"version" json:"version"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at,omitempty"`
+	IsDefault bool      `bson:"-" json:"is_default"`
+}
+
+// PromptModelParams overrides the LLM parameters a step is run with
+type PromptModelParams struct {
+	Temperature *float32 `bson:"temperature,omitempty" json:"temperature,omitempty"`
+}
+
+// PromptTemplateVersion is one saved version of a step's template
+type PromptTemplateVersion struct {
+	ID          string             `bson:"_id" json:"-"`
+	Name        string             `bson:"name" json:"name"`
+	Version     int                `bson:"version" json:"version"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Template    string             `bson:"template" json:"template"`
+	ModelParams *PromptModelParams `bson:"model_params,omitempty" json:"model_params,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
 }
 
 // ScoringRubric represents the scoring rubric for project evaluation
@@ -83,11 +912,61 @@ type ScoringRubric struct {
 	Name        string             `bson:"name" json:"name"`
 	Description string             `bson:"description" json:"description"`
 	Criteria    []RubricCriteria   `bson:"criteria" json:"criteria"`
+	Scale       *RubricScale       `bson:"scale,omitempty" json:"scale,omitempty"`
 	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+
+	// OrgID is the organization that owns the rubric; global rubrics have none and are shared by every organization
+	OrgID string `bson:"org_id,omitempty" json:"org_id,omitempty"`
+}
+
+// RubricScale sets the scales a rubric's scores are reported on. Step scores run from 0 to MaxScore (5 when unset)
+// and are also converted to 0-DisplayMax, e.g. 100 for percentages (MaxScore when unset).
+type RubricScale struct {
+	MaxScore   float64 `bson:"max_score" json:"max_score"`
+	DisplayMax float64 `bson:"display_max" json:"display_max"`
+}
+
+// ScoringOptions overrides how an evaluation is scored. Rubric IDs replace the stored default rubrics;
+// weights override the weights of individual criteria by criterion key; retrieval options override how much
+// context the prompts are given; the feedback verbosity (detailed or bullets) and tone (internal or candidate)
+// override the configured style of the feedback and summary.
+type ScoringOptions struct {
+	CVRubricID        string            `bson:"cv_rubric_id,omitempty" json:"cv_rubric_id,omitempty"`
+	ProjectRubricID   string            `bson:"project_rubric_id,omitempty" json:"project_rubric_id,omitempty"`
+	Weights           *ScoringWeights   `bson:"weights,omitempty" json:"weights,omitempty"`
+	RetrievalOptions  *RetrievalOptions `bson:"retrieval_options,omitempty" json:"retrieval_options,omitempty"`
+	FeedbackVerbosity string            `bson:"feedback_verbosity,omitempty" json:"feedback_verbosity,omitempty"`
+	FeedbackTone      string            `bson:"feedback_tone,omitempty" json:"feedback_tone,omitempty"`
+	// Blind evaluates anonymized documents, without the candidate's name, contact details, gender hints,
+	// age, photo or university names
+	Blind bool `bson:"blind,omitempty" json:"blind,omitempty"`
+}
+
+// RetrievalOptions holds per-request overrides of the RAG_TOP_K, RAG_SIMILARITY_THRESHOLD and
+// RAG_MAX_CONTEXT_TOKENS settings; unset fields keep the configured values
+type RetrievalOptions struct {
+	TopK                int      `bson:"top_k,omitempty" json:"top_k,omitempty"`
+	SimilarityThreshold *float64 `bson:"similarity_threshold,omitempty" json:"similarity_threshold,omitempty"`
+	MaxContextTokens    int      `bson:"max_context_tokens,omitempty" json:"max_context_tokens,omitempty"`
+}
+
+// ScoringWeights holds per-request weight overrides
+type ScoringWeights struct {
+	CV      map[string]float64 `bson:"cv,omitempty" json:"cv,omitempty"`
+	Project map[string]float64 `bson:"project,omitempty" json:"project,omitempty"`
+	Overall *OverallWeights    `bson:"overall,omitempty" json:"overall,omitempty"`
+}
+
+// OverallWeights weighs the CV and project scores in the overall score; they need not sum to 1
+type OverallWeights struct {
+	CV      float64 `bson:"cv" json:"cv"`
+	Project float64 `bson:"project" json:"project"`
 }
 
 // RubricCriteria represents individual criteria in the scoring rubric
 type RubricCriteria struct {
+	// Key names the score field the LLM returns for this criterion ("<key>_score"); derived from Name when empty
+	Key         string  `bson:"key,omitempty" json:"key,omitempty"`
 	Name        string  `bson:"name" json:"name"`
 	Description string  `bson:"description" json:"description"`
 	Weight      float64 `bson:"weight" json:"weight"`
@@ -100,23 +979,185 @@ type UploadRequest struct {
 	ProjectFile string `json:"project_file" binding:"required"`
 }
 
-// UploadResponse represents the response after file upload
+// Upload is the metadata of an uploaded file. Files are stored once, named after the SHA-256 digest of
+// their content, and an organization has one record per distinct file however often it uploads it.
+type Upload struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// OrgID is the organization that uploaded the file; empty for single-tenant deployments
+	OrgID string `bson:"org_id,omitempty" json:"org_id,omitempty"`
+	// Filename is the stored file name, the digest and the original extension; clients refer to the file by ID
+	Filename     string    `bson:"filename" json:"filename"`
+	OriginalName string    `bson:"original_name" json:"original_name"`
+	Hash         string    `bson:"hash" json:"hash"`
+	Size         int64     `bson:"size" json:"size"`
+	MimeType     string    `bson:"mime_type" json:"mime_type"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+	// Created reports whether saving the file created this record rather than finding the organization's
+	// existing one; it is not stored
+	Created bool `bson:"-" json:"-"`
+}
+
+// UploadDetailResponse is an uploaded file's metadata with the beginning of its extracted text
+type UploadDetailResponse struct {
+	*Upload
+	TextPreview string `json:"text_preview"`
+	// TextLength is the length of the whole extracted text in characters
+	TextLength   int    `json:"text_length"`
+	PreviewError string `json:"preview_error,omitempty"`
+}
+
+// UploadResponse represents the response after file upload, with the upload IDs of the files
 type UploadResponse struct {
 	Message     string `json:"message"`
 	CVFile      string `json:"cv_file"`
 	ProjectFile string `json:"project_file"`
 }
 
+// PresignUploadRequest asks for a URL to upload one file directly to object storage
+type PresignUploadRequest struct {
+	Filename string `json:"filename" binding:"required"`
+	// MimeType defaults to the type of the file extension
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size" binding:"required"`
+}
+
+// PresignUploadResponse is a presigned URL the client PUTs the file to, sending the listed headers
+type PresignUploadResponse struct {
+	UploadID  string            `json:"upload_id"`
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// ConfirmUploadRequest registers a file uploaded to a presigned URL
+type ConfirmUploadRequest struct {
+	UploadID string `json:"upload_id" binding:"required"`
+}
+
+// ConfirmUploadResponse identifies the registered file, whose ID is passed to /evaluate like an uploaded one
+type ConfirmUploadResponse struct {
+	Message  string `json:"message"`
+	File     string `json:"file"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type"`
+}
+
 // EvaluateRequest represents the request to start evaluation
 type EvaluateRequest struct {
-	CVFile      string `json:"cv_file" binding:"required"`
-	ProjectFile string `json:"project_file" binding:"required"`
+	CVFile           string `json:"cv_file" binding:"required"`
+	ProjectFile      string `json:"project_file" binding:"required"`
+	CandidateID      string `json:"candidate_id"`
+	CandidateName    string `json:"candidate_name"`
+	JobDescriptionID string `json:"job_description_id"`
+	// GitHub is a username, "owner/repo" or repository URL whose code grounds the project evaluation
+	GitHub  string `json:"github"`
+	Sandbox bool   `json:"sandbox"`
+	Force   bool   `json:"force"`
+	// RunAt schedules the evaluation for later, e.g. off-peak hours; a time in the past runs it now
+	RunAt *time.Time `json:"run_at"`
+	ScoringOptions
+}
+
+// InlineDocument represents a document delivered inline as base64 content
+type InlineDocument struct {
+	Filename string `json:"filename" binding:"required"`
+	MimeType string `json:"mime_type"`
+	Content  string `json:"content" binding:"required"`
+}
+
+// EvaluateInlineRequest represents the request to start evaluation from base64-encoded documents
+type EvaluateInlineRequest struct {
+	CVDocument       InlineDocument `json:"cv_document" binding:"required"`
+	ProjectDocument  InlineDocument `json:"project_document" binding:"required"`
+	CandidateID      string         `json:"candidate_id"`
+	CandidateName    string         `json:"candidate_name"`
+	JobDescriptionID string         `json:"job_description_id"`
+	GitHub           string         `json:"github"`
+	Sandbox          bool           `json:"sandbox"`
+	Force            bool           `json:"force"`
+	ScoringOptions
+}
+
+// EvaluateTextRequest represents the request to start evaluation from plain text, e.g. documents an ATS
+// has already parsed
+type EvaluateTextRequest struct {
+	CVText           string `json:"cv_text" binding:"required"`
+	ProjectText      string `json:"project_text" binding:"required"`
+	CandidateID      string `json:"candidate_id"`
+	CandidateName    string `json:"candidate_name"`
+	JobDescriptionID string `json:"job_description_id"`
+	GitHub           string `json:"github"`
+	Sandbox          bool   `json:"sandbox"`
+	Force            bool   `json:"force"`
+	ScoringOptions
+}
+
+// ParseRequest selects the CV to parse: a stored job's CV, whose parsed fields are then saved on the job,
+// an uploaded file, or a base64-encoded document
+type ParseRequest struct {
+	JobID      string          `json:"job_id"`
+	CVFile     string          `json:"cv_file"`
+	CVDocument *InlineDocument `json:"cv_document"`
+	Sandbox    bool            `json:"sandbox"`
+}
+
+// ParseResponse represents the response of the parse endpoint
+type ParseResponse struct {
+	JobID  string        `json:"job_id,omitempty"`
+	Resume *ParsedResume `json:"resume"`
+}
+
+// Feedback letter types
+const (
+	// FeedbackLetterRejection tells the candidate they will not move forward, with feedback to help them grow
+	FeedbackLetterRejection = "rejection"
+	// FeedbackLetterFeedback shares the evaluation's feedback without a hiring decision
+	FeedbackLetterFeedback = "feedback"
+)
+
+// FeedbackLetterRequest represents the optional body of the feedback letter endpoint. Empty fields use the
+// configured defaults, and the position defaults to the title of the job's job description.
+type FeedbackLetterRequest struct {
+	Type        string `json:"type"`
+	Tone        string `json:"tone"`
+	CompanyName string `json:"company_name"`
+	Position    string `json:"position"`
+	SenderName  string `json:"sender_name"`
+}
+
+// FeedbackLetter is an email to a candidate drafted from their evaluation, for a recruiter to review and send
+type FeedbackLetter struct {
+	JobID         string    `json:"job_id"`
+	Type          string    `json:"type"`
+	Tone          string    `json:"tone"`
+	Subject       string    `json:"subject"`
+	Text          string    `json:"text"`
+	HTML          string    `json:"html"`
+	PromptVersion int       `json:"prompt_version"`
+	GeneratedAt   time.Time `json:"generated_at"`
 }
 
 // EvaluateResponse represents the response after starting evaluation
 type EvaluateResponse struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Degraded bool   `json:"degraded,omitempty"`
+
+	// RunAt is when a scheduled job will be queued for evaluation
+	RunAt *time.Time `json:"run_at,omitempty"`
+
+	// Set when the request reused an Idempotency-Key; ID and Status then refer to the job created with it
+	Replayed bool `json:"replayed,omitempty"`
+
+	// Set when a recent job already covers the same CV; ID and Status then refer to that job
+	Duplicate bool `json:"duplicate,omitempty"`
+
+	// Set when a completed job already evaluated the same documents, job description and rubrics;
+	// ID refers to that job and Result is its result
+	Cached  bool              `json:"cached,omitempty"`
+	Warning string            `json:"warning,omitempty"`
+	Result  *EvaluationResult `json:"result,omitempty"`
 }
 
 // ResultResponse represents the response for getting evaluation result
@@ -125,4 +1166,342 @@ type ResultResponse struct {
 	Status string            `json:"status"`
 	Result *EvaluationResult `json:"result,omitempty"`
 	Error  string            `json:"error,omitempty"`
+	// ErrorType classifies the failure of a failed job
+	ErrorType ErrorType    `json:"error_type,omitempty"`
+	Usage     *JobUsage    `json:"usage,omitempty"`
+	Rank      *ScoreRank   `json:"rank,omitempty"`
+	Scale     *ResultScale `json:"scale,omitempty"`
+	// Retrieval is the context retrieved for each step, returned with debug=true
+	Retrieval []StepRetrieval `json:"retrieval,omitempty"`
+	// Versions is every result the job has had, oldest first, returned with history=true
+	Versions []*EvaluationResult `json:"versions,omitempty"`
+
+	// InjectionRisk and InjectionSignals repeat the job's prompt-injection flag
+	InjectionRisk    bool     `json:"injection_risk,omitempty"`
+	InjectionSignals []string `json:"injection_signals,omitempty"`
+}
+
+// ScoreRank places an overall score within the completed evaluations for the same job description.
+// Rank 1 is the best score; tied candidates share a rank.
+type ScoreRank struct {
+	Percentile float64 `json:"percentile"`
+	Rank       int     `json:"rank"`
+	CohortSize int     `json:"cohort_size"`
+}
+
+// BatchGetResultsRequest represents the request for retrieving several results at once
+type BatchGetResultsRequest struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+// BatchGetResultsResponse represents the response for a bulk result retrieval
+type BatchGetResultsResponse struct {
+	Results  []ResultResponse `json:"results"`
+	NotFound []string         `json:"not_found"`
+}
+
+// BatchCandidate is one candidate's documents in a batch evaluation request
+type BatchCandidate struct {
+	CVFile        string `json:"cv_file" binding:"required"`
+	ProjectFile   string `json:"project_file" binding:"required"`
+	CandidateID   string `json:"candidate_id"`
+	CandidateName string `json:"candidate_name"`
+}
+
+// BatchEvaluateRequest represents the request to evaluate several candidates against the same job
+type BatchEvaluateRequest struct {
+	Candidates       []BatchCandidate `json:"candidates" binding:"required,dive"`
+	JobDescriptionID string           `json:"job_description_id"`
+	Sandbox          bool             `json:"sandbox"`
+	Force            bool             `json:"force"`
+	// RunAt schedules every evaluation of the batch for later
+	RunAt *time.Time `json:"run_at"`
+	ScoringOptions
+}
+
+// BatchCandidateStatus represents the state of one candidate of a batch evaluation
+type BatchCandidateStatus struct {
+	JobID       string            `json:"job_id,omitempty"`
+	CandidateID string            `json:"candidate_id,omitempty"`
+	CVFile      string            `json:"cv_file"`
+	ProjectFile string            `json:"project_file"`
+	Source      string            `json:"source,omitempty"`
+	Status      string            `json:"status"`
+	Progress    int               `json:"progress"`
+	Duplicate   bool              `json:"duplicate,omitempty"`
+	Cached      bool              `json:"cached,omitempty"`
+	Result      *EvaluationResult `json:"result,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// BatchResponse represents the aggregate progress and per-candidate results of a batch evaluation
+type BatchResponse struct {
+	ID               string                 `json:"id"`
+	Status           string                 `json:"status"`
+	JobDescriptionID string                 `json:"job_description_id,omitempty"`
+	CreatedAt        time.Time              `json:"created_at"`
+	Total            int                    `json:"total"`
+	Counts           map[string]int         `json:"counts"`
+	Progress         int                    `json:"progress"`
+	Candidates       []BatchCandidateStatus `json:"candidates"`
+	Skipped          []SkippedFile          `json:"skipped,omitempty"`
+}
+
+// BulkDeleteJobsRequest represents the admin request to delete or archive jobs matching filters
+type BulkDeleteJobsRequest struct {
+	// OrgID scopes the request to one organization; AllOrganizations must be set instead to match every
+	// organization's jobs
+	OrgID            string `json:"org_id"`
+	AllOrganizations bool   `json:"all_organizations"`
+	Status           string `json:"status"`
+	OlderThanDays    int    `json:"older_than_days"`
+	Action           string `json:"action"`
+	DryRun           bool   `json:"dry_run"`
+}
+
+// BulkDeleteJobsResponse represents the outcome of an admin bulk delete
+type BulkDeleteJobsResponse struct {
+	Action   string   `json:"action"`
+	DryRun   bool     `json:"dry_run"`
+	Matched  int      `json:"matched"`
+	Affected int64    `json:"affected"`
+	JobIDs   []string `json:"job_ids"`
+}
+
+// PurgeJobsRequest represents the admin request to permanently remove soft-deleted jobs
+type PurgeJobsRequest struct {
+	// DeletedBeforeDays only purges jobs deleted at least this many days ago; 0 purges every deleted job
+	DeletedBeforeDays int  `json:"deleted_before_days"`
+	DryRun            bool `json:"dry_run"`
+}
+
+// PurgeJobsResponse represents the outcome of a purge
+type PurgeJobsResponse struct {
+	DryRun       bool     `json:"dry_run"`
+	Matched      int      `json:"matched"`
+	Purged       int64    `json:"purged"`
+	FilesRemoved int      `json:"files_removed"`
+	JobIDs       []string `json:"job_ids"`
+}
+
+// ForgetRequest is the optional body of an erasure request
+type ForgetRequest struct {
+	// Reason is recorded in the audit record, e.g. a ticket reference; it must not contain personal data
+	Reason string `json:"reason"`
+}
+
+// CreateGoldenJobRequest represents the request to register a job in the golden set
+type CreateGoldenJobRequest struct {
+	JobID          string                `json:"job_id" binding:"required"`
+	Label          string                `json:"label"`
+	ExpectedRanges map[string]ScoreRange `json:"expected_ranges"`
+}
+
+// GoldenComparison reports the score deltas of one golden job re-run with the current prompts/model
+type GoldenComparison struct {
+	GoldenID        string             `bson:"golden_id" json:"golden_id"`
+	JobID           string             `bson:"job_id" json:"job_id"`
+	Label           string             `bson:"label" json:"label"`
+	Deltas          map[string]float64 `bson:"deltas,omitempty" json:"deltas,omitempty"`
+	MaxAbsDelta     float64            `bson:"max_abs_delta" json:"max_abs_delta"`
+	WithinTolerance bool               `bson:"within_tolerance" json:"within_tolerance"`
+	// OutOfRange lists the scores outside the golden job's expected ranges
+	OutOfRange []OutOfRangeScore `bson:"out_of_range,omitempty" json:"out_of_range,omitempty"`
+	// Passed is set when the scores stay in their expected ranges, or within tolerance for a golden job
+	// without ranges
+	Passed bool `bson:"passed" json:"passed"`
+	// ChangedSteps lists, for replayed comparisons, the steps whose prompts no longer match the recording
+	ChangedSteps []string `bson:"changed_steps,omitempty" json:"changed_steps,omitempty"`
+	Error        string   `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// OutOfRangeScore is a re-run score outside its expected range
+type OutOfRangeScore struct {
+	Score    string     `bson:"score" json:"score"`
+	Value    float64    `bson:"value" json:"value"`
+	Expected ScoreRange `bson:"expected" json:"expected"`
+}
+
+// GoldenComparisonReport summarizes a golden-set comparison run. Calibration runs are stored with an ID.
+type GoldenComparisonReport struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	// Scheduled is set on calibration runs started by the CALIBRATION_INTERVAL schedule
+	Scheduled bool `bson:"scheduled,omitempty" json:"scheduled,omitempty"`
+	// Replay is set when jobs were re-run against their recorded LLM responses instead of the provider
+	Replay       bool               `bson:"replay,omitempty" json:"replay,omitempty"`
+	Tolerance    float64            `bson:"tolerance" json:"tolerance"`
+	Total        int                `bson:"total" json:"total"`
+	Passed       int                `bson:"passed" json:"passed"`
+	Failed       int                `bson:"failed" json:"failed"`
+	Errors       int                `bson:"errors" json:"errors"`
+	MeanAbsDelta float64            `bson:"mean_abs_delta" json:"mean_abs_delta"`
+	Comparisons  []GoldenComparison `bson:"comparisons" json:"comparisons"`
+	// Drifts describes each failed or erroring golden job in plain words
+	Drifts []string `bson:"drifts,omitempty" json:"drifts,omitempty"`
+	// Alerted is set when the drifts were sent to CALIBRATION_WEBHOOK_URL
+	Alerted     bool       `bson:"alerted,omitempty" json:"alerted,omitempty"`
+	StartedAt   time.Time  `bson:"started_at" json:"started_at"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// ReplayResponse is the outcome of re-running a job's evaluation against its recorded LLM responses
+type ReplayResponse struct {
+	JobID  string            `json:"job_id"`
+	Result *EvaluationResult `json:"result"`
+	// Diff compares the stored result with the replayed one; it is absent when the job has no stored result
+	Diff *EvaluationDiff `json:"diff,omitempty"`
+	// ChangedSteps lists the steps whose prompts no longer match the recording
+	ChangedSteps []string `json:"changed_steps"`
+}
+
+// ScoreChange is the movement of one score between two evaluations
+type ScoreChange struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Delta float64 `json:"delta"`
+}
+
+// FeedbackChange lists the sentences that appeared in or disappeared from one feedback field
+type FeedbackChange struct {
+	Field   string   `json:"field"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// EvaluationDiff compares two completed evaluations of the same candidate
+type EvaluationDiff struct {
+	CandidateID     string                 `json:"candidate_id"`
+	FromJobID       string                 `json:"from_job_id"`
+	ToJobID         string                 `json:"to_job_id"`
+	FromCreatedAt   time.Time              `json:"from_created_at"`
+	ToCreatedAt     time.Time              `json:"to_created_at"`
+	Scores          map[string]ScoreChange `json:"scores"`
+	FeedbackChanges []FeedbackChange       `json:"feedback_changes"`
+	Narrative       string                 `json:"narrative,omitempty"`
+	NarrativeError  string                 `json:"narrative_error,omitempty"`
+}
+
+// JobDescriptionRequest creates or replaces a job description
+type JobDescriptionRequest struct {
+	Title        string `json:"title" binding:"required"`
+	Description  string `json:"description" binding:"required"`
+	Requirements string `json:"requirements"`
+}
+
+// ReferenceDocumentRequest ingests a reference document of one of ReferenceDocumentTypes
+type ReferenceDocumentRequest struct {
+	Type    string `json:"type" binding:"required"`
+	Title   string `json:"title" binding:"required"`
+	Content string `json:"content" binding:"required"`
+}
+
+// UpdatePromptTemplateRequest saves a new version of a step's template and makes it active
+type UpdatePromptTemplateRequest struct {
+	Template    string             `json:"template" binding:"required"`
+	Description string             `json:"description"`
+	ModelParams *PromptModelParams `json:"model_params,omitempty"`
+}
+
+// PromptPreviewRequest renders a prompt against a stored job, optionally running it through the LLM
+type PromptPreviewRequest struct {
+	JobID    string `json:"job_id" binding:"required"`
+	Template string `json:"template,omitempty"`
+	Execute  bool   `json:"execute"`
+}
+
+// PromptPreviewResponse holds the rendered prompt and, when executed, the raw LLM output
+type PromptPreviewResponse struct {
+	Name           string `json:"name"`
+	RenderedPrompt string `json:"rendered_prompt"`
+	Output         string `json:"output,omitempty"`
+}
+
+// Organization is a tenant: a hiring team whose jobs, job descriptions and rubrics are isolated from other tenants
+type Organization struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name string             `bson:"name" json:"name"`
+
+	// APIKeyHash is the SHA-256 of the organization's API key; the key itself is only returned at creation.
+	// Handlers strip it from responses.
+	APIKeyHash string `bson:"api_key_hash" json:"api_key_hash,omitempty"`
+
+	OrganizationDefaults `bson:",inline"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// OrganizationDefaults are used for an organization's evaluations when a request does not choose its own
+type OrganizationDefaults struct {
+	DefaultCVRubricID       string `bson:"default_cv_rubric_id,omitempty" json:"default_cv_rubric_id,omitempty"`
+	DefaultProjectRubricID  string `bson:"default_project_rubric_id,omitempty" json:"default_project_rubric_id,omitempty"`
+	DefaultJobDescriptionID string `bson:"default_job_description_id,omitempty" json:"default_job_description_id,omitempty"`
+}
+
+// OrganizationRequest creates or updates an organization
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+	OrganizationDefaults
+}
+
+// RubricRequest creates a scoring rubric
+type RubricRequest struct {
+	Name        string           `json:"name" binding:"required"`
+	Description string           `json:"description"`
+	Criteria    []RubricCriteria `json:"criteria" binding:"required,min=1"`
+	Scale       *RubricScale     `json:"scale"`
+}
+
+// ErrorCode identifies the kind of failure in an API error response, so clients can branch on it instead of
+// the message
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest      ErrorCode = "INVALID_REQUEST"
+	ErrorCodeValidationFailed    ErrorCode = "VALIDATION_FAILED"
+	ErrorCodeUnauthorized        ErrorCode = "UNAUTHORIZED"
+	ErrorCodeForbidden           ErrorCode = "FORBIDDEN"
+	ErrorCodeNotFound            ErrorCode = "NOT_FOUND"
+	ErrorCodeConflict            ErrorCode = "CONFLICT"
+	ErrorCodeContentErased       ErrorCode = "CONTENT_ERASED"
+	ErrorCodeBadUpload           ErrorCode = "BAD_UPLOAD"
+	ErrorCodeExtractionFailed    ErrorCode = "EXTRACTION_FAILED"
+	ErrorCodeUnprocessable       ErrorCode = "UNPROCESSABLE"
+	ErrorCodeLanguageUnsupported ErrorCode = "LANGUAGE_UNSUPPORTED"
+	ErrorCodeContentRejected     ErrorCode = "CONTENT_REJECTED"
+	ErrorCodeFileInfected        ErrorCode = "FILE_INFECTED"
+	ErrorCodeLLMFailure          ErrorCode = "LLM_FAILURE"
+	ErrorCodeStorageUnavailable  ErrorCode = "STORAGE_UNAVAILABLE"
+	ErrorCodeNotConfigured       ErrorCode = "NOT_CONFIGURED"
+	ErrorCodeInternal            ErrorCode = "INTERNAL_ERROR"
+)
+
+// Retryable reports whether a request that failed with the code may succeed when sent again unchanged
+func (c ErrorCode) Retryable() bool {
+	switch c {
+	case ErrorCodeLLMFailure, ErrorCodeStorageUnavailable, ErrorCodeInternal:
+		return true
+	}
+	return false
+}
+
+// APIError describes a failed request; every error response has one under "error"
+type APIError struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	// Details carries data specific to the code, e.g. the detected language of LANGUAGE_UNSUPPORTED
+	Details   interface{} `json:"details,omitempty"`
+	Retryable bool        `json:"retryable"`
+}
+
+// FieldError describes why one field of a request is invalid. Field is the JSON name or query parameter,
+// with the index of array elements, e.g. "candidates[2].cv_file".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Error APIError `json:"error"`
 }
@@ -0,0 +1,46 @@
+package rag
+
+import (
+	"strings"
+
+	"ai-cv-summarize/internal/llm"
+)
+
+// wordTokens is the token count of one word, which is never less than one
+func wordTokens(word string) int {
+	if tokens := llm.CountTokens(word); tokens > 0 {
+		return tokens
+	}
+	return 1
+}
+
+// ChunkText splits text on word boundaries into chunks of at most size tokens, each starting
+// with about overlap tokens from the end of the previous chunk so passages cut at a boundary stay whole
+// in one of them. A single word longer than size becomes its own chunk.
+func ChunkText(text string, size, overlap int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var chunks []string
+	for start := 0; ; {
+		end, tokens := start, 0
+		for end < len(words) && (end == start || tokens+wordTokens(words[end]) <= size) {
+			tokens += wordTokens(words[end])
+			end++
+		}
+		chunks = append(chunks, strings.Join(words[start:end], " "))
+		if end == len(words) {
+			return chunks
+		}
+
+		// Step back over the overlap, always moving at least one word past the previous start
+		next, repeated := end, 0
+		for next > start+1 && repeated+wordTokens(words[next-1]) <= overlap {
+			next--
+			repeated += wordTokens(words[next])
+		}
+		start = next
+	}
+}
@@ -0,0 +1,273 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"ai-cv-summarize/internal/llm"
+	"ai-cv-summarize/internal/models"
+
+	"gonum.org/v1/gonum/floats"
+)
+
+// chunkHit is a stored chunk scored against the retrieval queries
+type chunkHit struct {
+	chunk *models.DocumentChunk
+	score float64
+}
+
+// indexChunks splits a job description into chunks, embeds them together and replaces its stored chunks
+func (vs *VectorStore) indexChunks(ctx context.Context, jobDesc *models.JobDescription) error {
+	return vs.indexDocumentChunks(ctx, jobDesc.ID.Hex(), models.DocumentTypeJobDescription, jobDesc.Title, jobDescriptionText(jobDesc), jobDesc.OrgID)
+}
+
+// indexDocumentChunks splits a document into chunks, embeds them together and replaces its stored chunks
+func (vs *VectorStore) indexDocumentChunks(ctx context.Context, id, docType, title, text, orgID string) error {
+	model := vs.llmClient.EmbeddingModel()
+	texts := ChunkText(text, vs.config.ChunkSize, vs.config.ChunkOverlap)
+
+	embeddings, err := vs.llmClient.GenerateEmbeddings(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed chunks: %w", err)
+	}
+
+	chunks := make([]*models.DocumentChunk, 0, len(texts))
+	for i, text := range texts {
+		chunks = append(chunks, &models.DocumentChunk{
+			ID:             fmt.Sprintf("%s:%d", id, i),
+			DocumentID:     id,
+			DocumentType:   docType,
+			Title:          title,
+			Index:          i,
+			Text:           text,
+			Tokens:         llm.CountTokens(text),
+			Embedding:      embeddings[i],
+			EmbeddingModel: model,
+			EmbeddingNorm:  floats.Norm(embeddings[i], 2),
+			OrgID:          orgID,
+			CreatedAt:      time.Now(),
+		})
+	}
+
+	return vs.repository.ReplaceDocumentChunks(ctx, id, chunks)
+}
+
+// chunksCurrent reports whether a document has chunks and all of them were embedded by the current model
+func (vs *VectorStore) chunksCurrent(ctx context.Context, id string) (bool, error) {
+	chunks, err := vs.repository.GetDocumentChunks(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	model := vs.llmClient.EmbeddingModel()
+	for _, chunk := range chunks {
+		if len(chunk.Embedding) == 0 || chunk.EmbeddingModel != model {
+			return false, nil
+		}
+	}
+	return len(chunks) > 0, nil
+}
+
+// embedQueries chunks each query document like stored documents and embeds the chunks of all of them together,
+// returning the vectors of each query's chunks
+func (vs *VectorStore) embedQueries(ctx context.Context, queries ...string) ([][][]float64, error) {
+	var texts []string
+	counts := make([]int, len(queries))
+	for i, query := range queries {
+		queryChunks := ChunkText(query, vs.config.ChunkSize, vs.config.ChunkOverlap)
+		texts = append(texts, queryChunks...)
+		counts[i] = len(queryChunks)
+	}
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	embeddings, err := vs.llmClient.GenerateEmbeddings(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embeddings: %w", err)
+	}
+
+	queryVectors := make([][][]float64, 0, len(queries))
+	for _, count := range counts {
+		queryVectors = append(queryVectors, embeddings[:count])
+		embeddings = embeddings[count:]
+	}
+	return queryVectors, nil
+}
+
+// rankChunks scores chunks against each query and keeps the top K best per query that reach the similarity
+// threshold, best first. A chunk scores its highest similarity to any chunk of the query, so long CVs are
+// matched in full rather than through one truncated embedding.
+func (vs *VectorStore) rankChunks(ctx context.Context, chunks []*models.DocumentChunk, queryVectors [][][]float64, params retrievalParams) ([]chunkHit, error) {
+	dimensions := 0
+	for _, vectors := range queryVectors {
+		if len(vectors) > 0 {
+			dimensions = len(vectors[0])
+		}
+	}
+	if dimensions == 0 || len(chunks) == 0 {
+		return nil, nil
+	}
+
+	chunkVectors, chunkNorms, err := vs.chunkEmbeddings(ctx, chunks, dimensions)
+	if err != nil {
+		return nil, err
+	}
+
+	best := make(map[string]chunkHit)
+	for _, vectors := range queryVectors {
+		if len(vectors) == 0 {
+			continue
+		}
+		norms := make([]float64, len(vectors))
+		for i, vector := range vectors {
+			norms[i] = floats.Norm(vector, 2)
+		}
+
+		hits := make([]chunkHit, 0, len(chunks))
+		for i, chunk := range chunks {
+			var score float64
+			for j, vector := range vectors {
+				score = math.Max(score, cosine(vector, chunkVectors[i], norms[j], chunkNorms[i]))
+			}
+			if score >= params.threshold {
+				hits = append(hits, chunkHit{chunk: chunk, score: score})
+			}
+		}
+		sortHits(hits)
+
+		if len(hits) > params.topK {
+			hits = hits[:params.topK]
+		}
+		for _, hit := range hits {
+			if previous, ok := best[hit.chunk.ID]; !ok || hit.score > previous.score {
+				best[hit.chunk.ID] = hit
+			}
+		}
+	}
+
+	ranked := make([]chunkHit, 0, len(best))
+	for _, hit := range best {
+		ranked = append(ranked, hit)
+	}
+	sortHits(ranked)
+
+	return ranked, nil
+}
+
+// chunkEmbeddings returns the vectors, and their norms, to compare chunks with queries of the given dimensions.
+// Chunks without a vector score zero; chunks from another model fail the search unless the store embeds them on the fly.
+func (vs *VectorStore) chunkEmbeddings(ctx context.Context, chunks []*models.DocumentChunk, dimensions int) ([][]float64, []float64, error) {
+	model := vs.llmClient.EmbeddingModel()
+
+	vectors := make([][]float64, len(chunks))
+	norms := make([]float64, len(chunks))
+	var mismatched []int
+	var texts []string
+	for i, chunk := range chunks {
+		vectors[i] = chunk.Embedding
+		norms[i] = storedNorm(chunk.Embedding, chunk.EmbeddingNorm)
+		if len(chunk.Embedding) == 0 || (len(chunk.Embedding) == dimensions && chunk.EmbeddingModel == model) {
+			continue
+		}
+		if !vs.embedMismatched {
+			return nil, nil, fmt.Errorf("%w: chunk %s has %d-dimension %q vectors but queries use %d-dimension %q; run `server migrate-embeddings`",
+				ErrEmbeddingMismatch, chunk.ID, len(chunk.Embedding), chunk.EmbeddingModel, dimensions, model)
+		}
+		mismatched = append(mismatched, i)
+		texts = append(texts, chunk.Text)
+	}
+	if len(mismatched) == 0 {
+		return vectors, norms, nil
+	}
+
+	embeddings, err := vs.llmClient.GenerateEmbeddings(ctx, texts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to embed %d chunks: %w", len(texts), err)
+	}
+	for j, i := range mismatched {
+		vectors[i] = embeddings[j]
+		norms[i] = floats.Norm(embeddings[j], 2)
+	}
+
+	return vectors, norms, nil
+}
+
+// formatStepContext builds a step's evaluation context: the job context's text, when set, then the excerpts of
+// the step's document types, grouped by type. Excerpts are picked best first, across types, while they fit in
+// the token budget left by the job context; one that does not fit is skipped so smaller, lower-ranked ones can
+// still be used. Every ranked chunk is recorded in the retrieval, included or not.
+func (vs *VectorStore) formatStepContext(job jobContext, hits map[string][]chunkHit, types []string, params retrievalParams) StepContext {
+	budget := params.maxContextTokens - llm.CountTokens(job.text)
+
+	var candidates []chunkHit
+	for _, docType := range types {
+		candidates = append(candidates, hits[docType]...)
+	}
+	sortHits(candidates)
+
+	retrieval := models.StepRetrieval{Mode: job.mode, Documents: append([]models.RetrievedDocument{}, job.documents...)}
+	selected := make(map[string][]chunkHit)
+	for _, hit := range candidates {
+		tokens := llm.CountTokens(formatExcerpt(hit.chunk))
+		included := tokens <= budget
+		if included {
+			budget -= tokens
+			docType := chunkType(hit.chunk)
+			selected[docType] = append(selected[docType], hit)
+		}
+		retrieval.Documents = append(retrieval.Documents, models.RetrievedDocument{
+			DocumentID:   hit.chunk.DocumentID,
+			DocumentType: chunkType(hit.chunk),
+			ChunkID:      hit.chunk.ID,
+			Title:        hit.chunk.Title,
+			Score:        hit.score,
+			Tokens:       tokens,
+			Included:     included,
+		})
+	}
+
+	var context strings.Builder
+	context.WriteString(job.text)
+	for _, docType := range types {
+		if len(selected[docType]) == 0 {
+			continue
+		}
+		context.WriteString(fmt.Sprintf("Relevant %s Excerpts:\n\n", documentTypeHeadings[docType]))
+		for _, hit := range selected[docType] {
+			context.WriteString(formatExcerpt(hit.chunk))
+		}
+	}
+
+	retrieval.ContextTokens = llm.CountTokens(context.String())
+	return StepContext{Text: context.String(), Retrieval: retrieval}
+}
+
+// formatExcerpt formats a chunk for the evaluation context
+func formatExcerpt(chunk *models.DocumentChunk) string {
+	return fmt.Sprintf("From %s (part %d):\n%s\n\n", chunk.Title, chunk.Index+1, chunk.Text)
+}
+
+// chunkType returns the type of a chunk's document; chunks stored before types existed are from job descriptions
+func chunkType(chunk *models.DocumentChunk) string {
+	if chunk.DocumentType == "" {
+		return models.DocumentTypeJobDescription
+	}
+	return chunk.DocumentType
+}
+
+// sortHits orders hits by descending score, then by document position so ties are stable
+func sortHits(hits []chunkHit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		if hits[i].chunk.DocumentID != hits[j].chunk.DocumentID {
+			return hits[i].chunk.DocumentID < hits[j].chunk.DocumentID
+		}
+		return hits[i].chunk.Index < hits[j].chunk.Index
+	})
+}
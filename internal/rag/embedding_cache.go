@@ -0,0 +1,132 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"ai-cv-summarize/internal/llm"
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+)
+
+// WrapEmbeddingCache serves repeated embedding requests from the embedding_cache collection. Entries are
+// keyed by the embedding model and the SHA-256 of the normalized text, and expire after ttl.
+// Other calls pass through to the wrapped client.
+func WrapEmbeddingCache(next llm.LLMClient, repository repositories.Repository, ttl time.Duration) llm.LLMClient {
+	return &cachedEmbeddingClient{LLMClient: next, repository: repository, ttl: ttl}
+}
+
+// cachedEmbeddingClient wraps an LLM client with an embedding cache
+type cachedEmbeddingClient struct {
+	llm.LLMClient
+	repository repositories.Repository
+	ttl        time.Duration
+}
+
+func (c *cachedEmbeddingClient) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	model := c.EmbeddingModel()
+	key := embeddingCacheKey(model, text)
+
+	if embedding, ok := c.lookup(ctx, key); ok {
+		return embedding, nil
+	}
+
+	embedding, err := c.LLMClient.GenerateEmbedding(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	c.store(ctx, key, model, embedding)
+
+	return embedding, nil
+}
+
+// GenerateEmbeddings serves the cached texts and embeds the rest in one call
+func (c *cachedEmbeddingClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	model := c.EmbeddingModel()
+	embeddings := make([][]float64, len(texts))
+	keys := make([]string, len(texts))
+
+	var missing []int
+	var missingTexts []string
+	for i, text := range texts {
+		keys[i] = embeddingCacheKey(model, text)
+		if embedding, ok := c.lookup(ctx, keys[i]); ok {
+			embeddings[i] = embedding
+			continue
+		}
+		missing = append(missing, i)
+		missingTexts = append(missingTexts, text)
+	}
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+
+	generated, err := c.LLMClient.GenerateEmbeddings(ctx, missingTexts)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missing {
+		embeddings[i] = generated[j]
+		c.store(ctx, keys[i], model, generated[j])
+	}
+
+	return embeddings, nil
+}
+
+// lookup returns the cached embedding under key. The cache only saves cost, so lookup and store failures
+// fall through to the provider.
+func (c *cachedEmbeddingClient) lookup(ctx context.Context, key string) ([]float64, bool) {
+	cached, err := c.repository.GetCachedEmbedding(ctx, key)
+	if err == nil {
+		return cached.Embedding, true
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		log.Printf("Warning: failed to read embedding cache: %v", err)
+	}
+	return nil, false
+}
+
+// store caches an embedding under key until the TTL
+func (c *cachedEmbeddingClient) store(ctx context.Context, key, model string, embedding []float64) {
+	now := time.Now()
+	entry := &models.CachedEmbedding{
+		Key:       key,
+		Model:     model,
+		Embedding: embedding,
+		CreatedAt: now,
+		ExpiresAt: now.Add(c.ttl),
+	}
+	if err := c.repository.SaveCachedEmbedding(ctx, entry); err != nil {
+		log.Printf("Warning: failed to write embedding cache: %v", err)
+	}
+}
+
+// DocumentEmbeddingKeys returns the embedding cache keys a CV or project report may be stored under
+// after being used as a retrieval query: the whole text and each of its chunks
+func (vs *VectorStore) DocumentEmbeddingKeys(texts ...string) []string {
+	model := vs.llmClient.EmbeddingModel()
+
+	var keys []string
+	for _, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		keys = append(keys, embeddingCacheKey(model, text))
+		for _, chunk := range ChunkText(text, vs.config.ChunkSize, vs.config.ChunkOverlap) {
+			keys = append(keys, embeddingCacheKey(model, chunk))
+		}
+	}
+	return keys
+}
+
+// embeddingCacheKey hashes the model and the text with runs of whitespace collapsed, so reformatted
+// copies of a document share an entry
+func embeddingCacheKey(model, text string) string {
+	sum := sha256.Sum256([]byte(model + "\n" + strings.Join(strings.Fields(text), " ")))
+	return hex.EncodeToString(sum[:])
+}
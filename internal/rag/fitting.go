@@ -0,0 +1,120 @@
+package rag
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"ai-cv-summarize/internal/llm"
+)
+
+// fitPassageTokens is the largest size of the passages FitText keeps or drops; small budgets use smaller
+// ones so more than a couple fit
+const fitPassageTokens = 200
+
+// fitGapMarker replaces the passages FitText drops
+const fitGapMarker = "[...]"
+
+// FitText shortens text to at most budget tokens by keeping its passages that share the most words with
+// query, in their original order with a marker where passages were dropped. The first passage, usually a
+// CV's name and summary, is always kept. It reports whether text was shortened.
+func FitText(text, query string, budget int) (string, bool) {
+	if llm.CountTokens(text) <= budget {
+		return text, false
+	}
+
+	parts := Passages(text, max(min(fitPassageTokens, budget/8), 1))
+	tokens := make([]int, len(parts))
+	for i, part := range parts {
+		tokens[i] = llm.CountTokens(part)
+	}
+	gapTokens := llm.CountTokens("\n" + fitGapMarker + "\n")
+	if len(parts) < 2 || tokens[0]+gapTokens > budget {
+		return llm.TruncateTokens(text, budget)
+	}
+
+	// Rank the other passages by the distinct query words they contain, earlier passages first on ties
+	terms := queryTerms(query)
+	scores := make([]int, len(parts))
+	for i, part := range parts {
+		for term := range queryTerms(part) {
+			if terms[term] {
+				scores[i]++
+			}
+		}
+	}
+	order := make([]int, 0, len(parts)-1)
+	for i := 1; i < len(parts); i++ {
+		order = append(order, i)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	// Every passage may need a gap marker after it, so reserving one each keeps the result within budget
+	kept := make([]bool, len(parts))
+	kept[0] = true
+	used := tokens[0] + gapTokens
+	for _, i := range order {
+		if used+tokens[i]+gapTokens <= budget {
+			kept[i] = true
+			used += tokens[i] + gapTokens
+		}
+	}
+
+	var fitted strings.Builder
+	for i, part := range parts {
+		switch {
+		case kept[i]:
+			if fitted.Len() > 0 {
+				fitted.WriteString("\n")
+			}
+			fitted.WriteString(part)
+		case kept[i-1]:
+			fitted.WriteString("\n" + fitGapMarker)
+		}
+	}
+	return fitted.String(), true
+}
+
+// Passages splits text into runs of whole lines of at most size tokens. Lines longer than that are split
+// on word boundaries.
+func Passages(text string, size int) []string {
+	var parts, current []string
+	currentTokens := 0
+	flush := func() {
+		if len(current) > 0 {
+			parts = append(parts, strings.Join(current, "\n"))
+			current, currentTokens = nil, 0
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		tokens := llm.CountTokens(line)
+		if tokens > size {
+			flush()
+			parts = append(parts, ChunkText(line, size, 0)...)
+			continue
+		}
+		if currentTokens+tokens > size {
+			flush()
+		}
+		current = append(current, line)
+		currentTokens += tokens
+	}
+	flush()
+
+	return parts
+}
+
+// queryTerms returns the distinct lowercase words of text, leaving out words under four letters, which are
+// mostly function words
+func queryTerms(text string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) >= 4 {
+			terms[word] = true
+		}
+	}
+	return terms
+}
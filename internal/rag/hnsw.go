@@ -0,0 +1,198 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/tenant"
+)
+
+// HNSWVectorDB keeps job description vectors in an in-process HNSW graph, built from the repository by
+// Build and updated by Upsert and Delete, for fast approximate search over large catalogues. Until the graph
+// is built, and for searches it cannot answer such as queries from another embedding model, it falls back
+// to the scan.
+type HNSWVectorDB struct {
+	scan   *ScanVectorDB
+	config *config.VectorDBConfig
+
+	mu    sync.RWMutex
+	index *hnswIndex
+	ready bool
+	// pending records the writes made while Build runs, to replay on the graph it builds
+	pending  []hnswWrite
+	building bool
+}
+
+// hnswIndex is the graph and its live node counts per embedding model and per organization
+type hnswIndex struct {
+	graph  *hnswGraph
+	models map[string]int
+	orgs   map[string]int
+}
+
+// hnswWrite is an Upsert (jobDesc set), Delete (id set) or Reset
+type hnswWrite struct {
+	jobDesc *models.JobDescription
+	id      string
+}
+
+func NewHNSWVectorDB(scan *ScanVectorDB, cfg *config.VectorDBConfig) *HNSWVectorDB {
+	db := &HNSWVectorDB{scan: scan, config: cfg}
+	db.index = db.newIndex()
+	return db
+}
+
+func (db *HNSWVectorDB) Name() string {
+	return "hnsw"
+}
+
+// Build indexes every job description with a vector, across all organizations. Searches keep using the
+// scan while it runs, and writes made meanwhile are applied to the new graph before it is used.
+func (db *HNSWVectorDB) Build(ctx context.Context) error {
+	db.mu.Lock()
+	db.building, db.pending = true, nil
+	db.mu.Unlock()
+
+	index := db.newIndex()
+	jobDescs, err := db.scan.repository.GetJobDescriptionVectors(ctx)
+	if err == nil {
+		for _, job := range jobDescs {
+			index.add(job)
+		}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.building = false
+	if err != nil {
+		return fmt.Errorf("failed to get job descriptions: %w", err)
+	}
+	for _, write := range db.pending {
+		switch {
+		case write.jobDesc != nil:
+			index.remove(write.jobDesc.ID.Hex())
+			index.add(write.jobDesc)
+		case write.id != "":
+			index.remove(write.id)
+		default:
+			index = db.newIndex()
+		}
+	}
+	db.index, db.pending, db.ready = index, nil, true
+	return nil
+}
+
+func (db *HNSWVectorDB) Upsert(ctx context.Context, jobDesc *models.JobDescription) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.index.remove(jobDesc.ID.Hex())
+	db.index.add(jobDesc)
+	db.record(hnswWrite{jobDesc: jobDesc})
+	return nil
+}
+
+func (db *HNSWVectorDB) Delete(ctx context.Context, id string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.index.remove(id)
+	db.record(hnswWrite{id: id})
+	return nil
+}
+
+func (db *HNSWVectorDB) Reset(ctx context.Context) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.index = db.newIndex()
+	db.record(hnswWrite{})
+	return nil
+}
+
+// record keeps a write for Build to replay; callers hold the write lock
+func (db *HNSWVectorDB) record(write hnswWrite) {
+	if db.building {
+		db.pending = append(db.pending, write)
+	}
+}
+
+// Search walks the graph when every indexed vector comes from the query's model and has its dimensions,
+// and scans otherwise, so mismatches are reported (or embedded on the fly) exactly as the scan does. A
+// tenant's search that finds fewer hits than the tenant can see, because the graph walk strayed
+// through other tenants' vectors, is also answered by the scan.
+func (db *HNSWVectorDB) Search(ctx context.Context, query []float64, model string, limit int) ([]SearchHit, error) {
+	db.mu.RLock()
+	hits, ok := db.search(ctx, query, model, limit)
+	db.mu.RUnlock()
+	if ok {
+		return hits, nil
+	}
+	return db.scan.Search(ctx, query, model, limit)
+}
+
+func (db *HNSWVectorDB) search(ctx context.Context, query []float64, model string, limit int) ([]SearchHit, bool) {
+	if !db.ready {
+		return nil, false
+	}
+	for indexed, count := range db.index.models {
+		if count > 0 && indexed != "" && indexed != model {
+			return nil, false
+		}
+	}
+	graph := db.index.graph
+	if graph.size() > 0 && len(graph.nodes[graph.entry].vector) != len(query) {
+		return nil, false
+	}
+
+	available := graph.size()
+	var filter func(*hnswNode) bool
+	if orgID, scoped := tenant.Scope(ctx); scoped {
+		// The organization's own job descriptions plus the global ones
+		available = db.index.orgs[""]
+		if orgID != "" {
+			available += db.index.orgs[orgID]
+		}
+		filter = func(node *hnswNode) bool { return node.orgID == orgID || node.orgID == "" }
+	}
+
+	items := graph.search(query, limit, db.config.HNSWEfSearch, filter)
+	if len(items) < min(limit, available) {
+		return nil, false
+	}
+
+	hits := make([]SearchHit, len(items))
+	for i, item := range items {
+		hits[i] = SearchHit{ID: graph.nodes[item.node].id, Score: item.similarity}
+	}
+	return hits, true
+}
+
+func (db *HNSWVectorDB) newIndex() *hnswIndex {
+	return &hnswIndex{
+		graph:  newHNSWGraph(db.config.HNSWM, db.config.HNSWEfConstruction),
+		models: make(map[string]int),
+		orgs:   make(map[string]int),
+	}
+}
+
+// add indexes a job description's vector, if it has one
+func (index *hnswIndex) add(jobDesc *models.JobDescription) {
+	if len(jobDesc.Embedding) == 0 {
+		return
+	}
+	index.graph.add(jobDesc.ID.Hex(), jobDesc.OrgID, jobDesc.EmbeddingModel, jobDesc.Embedding)
+	index.models[jobDesc.EmbeddingModel]++
+	index.orgs[jobDesc.OrgID]++
+}
+
+// remove drops a job description from the graph
+func (index *hnswIndex) remove(id string) {
+	node, ok := index.graph.byID[id]
+	if !ok {
+		return
+	}
+	index.models[index.graph.nodes[node].model]--
+	index.orgs[index.graph.nodes[node].orgID]--
+	index.graph.remove(id)
+}
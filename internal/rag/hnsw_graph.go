@@ -0,0 +1,290 @@
+package rag
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/floats"
+)
+
+// hnswGraph is a hierarchical navigable small world graph over unit vectors, answering approximate
+// nearest-neighbour queries in roughly logarithmic time. Removed nodes stay in the graph as waypoints
+// until they outnumber the live ones, when the graph is rebuilt without them.
+type hnswGraph struct {
+	m              int
+	efConstruction int
+	levelFactor    float64
+	random         *rand.Rand
+
+	nodes    []*hnswNode
+	byID     map[string]int32
+	entry    int32
+	maxLevel int
+	removed  int
+}
+
+// hnswNode is an indexed vector and its neighbours on each level it appears on
+type hnswNode struct {
+	id        string
+	orgID     string
+	model     string
+	vector    []float64
+	neighbors [][]int32
+	removed   bool
+}
+
+// hnswItem is a node and its similarity to the vector being searched for
+type hnswItem struct {
+	node       int32
+	similarity float64
+}
+
+func newHNSWGraph(m, efConstruction int) *hnswGraph {
+	m = max(m, 2)
+	return &hnswGraph{
+		m:              m,
+		efConstruction: max(efConstruction, m),
+		levelFactor:    1 / math.Log(float64(m)),
+		random:         rand.New(rand.NewSource(1)),
+		byID:           make(map[string]int32),
+		entry:          -1,
+	}
+}
+
+// size returns the number of live nodes
+func (g *hnswGraph) size() int {
+	return len(g.byID)
+}
+
+// add indexes a vector under id, replacing the vector indexed under it before
+func (g *hnswGraph) add(id, orgID, model string, vector []float64) {
+	g.remove(id)
+
+	unit := make([]float64, len(vector))
+	if norm := floats.Norm(vector, 2); norm > 0 {
+		floats.ScaleTo(unit, 1/norm, vector)
+	}
+
+	level := int(-math.Log(1-g.random.Float64()) * g.levelFactor)
+	node := &hnswNode{id: id, orgID: orgID, model: model, vector: unit, neighbors: make([][]int32, level+1)}
+	index := int32(len(g.nodes))
+	g.nodes = append(g.nodes, node)
+	g.byID[id] = index
+
+	if g.entry < 0 {
+		g.entry, g.maxLevel = index, level
+		return
+	}
+
+	// Descend greedily to the node's top level, then link it on every level below
+	entry := []hnswItem{{node: g.entry, similarity: g.similarity(unit, g.entry)}}
+	for l := g.maxLevel; l > level; l-- {
+		entry = g.searchLevel(unit, entry, 1, l, nil)
+	}
+	live := func(n *hnswNode) bool { return !n.removed }
+	for l := min(level, g.maxLevel); l >= 0; l-- {
+		candidates := g.searchLevel(unit, entry, g.efConstruction, l, live)
+		if len(candidates) == 0 {
+			candidates = entry
+		}
+		node.neighbors[l] = g.selectNeighbors(candidates, g.maxNeighbors(l))
+		for _, neighbor := range node.neighbors[l] {
+			g.link(neighbor, index, l)
+		}
+		entry = candidates
+	}
+
+	if level > g.maxLevel {
+		g.entry, g.maxLevel = index, level
+	}
+}
+
+// remove drops id from search results, rebuilding the graph once removed nodes outnumber live ones
+func (g *hnswGraph) remove(id string) {
+	index, ok := g.byID[id]
+	if !ok {
+		return
+	}
+	g.nodes[index].removed = true
+	delete(g.byID, id)
+	g.removed++
+
+	if g.removed > len(g.byID) {
+		nodes := g.nodes
+		g.nodes, g.byID, g.entry, g.maxLevel, g.removed = nil, make(map[string]int32), -1, 0, 0
+		for _, node := range nodes {
+			if !node.removed {
+				g.add(node.id, node.orgID, node.model, node.vector)
+			}
+		}
+	}
+}
+
+// search returns up to limit live nodes accepted by filter, most similar to query first, exploring ef candidates
+func (g *hnswGraph) search(query []float64, limit, ef int, filter func(*hnswNode) bool) []hnswItem {
+	if g.entry < 0 || limit <= 0 {
+		return nil
+	}
+
+	unit := make([]float64, len(query))
+	if norm := floats.Norm(query, 2); norm > 0 {
+		floats.ScaleTo(unit, 1/norm, query)
+	}
+
+	entry := []hnswItem{{node: g.entry, similarity: g.similarity(unit, g.entry)}}
+	for l := g.maxLevel; l > 0; l-- {
+		entry = g.searchLevel(unit, entry, 1, l, nil)
+	}
+	results := g.searchLevel(unit, entry, max(ef, limit), 0, func(n *hnswNode) bool {
+		return !n.removed && (filter == nil || filter(n))
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// searchLevel runs a beam search of width ef on one level from the entry nodes. Every node is traversed,
+// but only those accepted by filter (all when nil) are returned, most similar first.
+func (g *hnswGraph) searchLevel(query []float64, entry []hnswItem, ef, level int, filter func(*hnswNode) bool) []hnswItem {
+	visited := make(map[int32]bool, ef*g.m)
+	candidates := &hnswQueue{nearestFirst: true}
+	results := &hnswQueue{}
+	for _, item := range entry {
+		visited[item.node] = true
+		heap.Push(candidates, item)
+		if filter == nil || filter(g.nodes[item.node]) {
+			heap.Push(results, item)
+		}
+	}
+
+	for candidates.Len() > 0 {
+		current := heap.Pop(candidates).(hnswItem)
+		if results.Len() >= ef && current.similarity < results.items[0].similarity {
+			break
+		}
+		if level >= len(g.nodes[current.node].neighbors) {
+			continue
+		}
+		for _, neighbor := range g.nodes[current.node].neighbors[level] {
+			if visited[neighbor] {
+				continue
+			}
+			visited[neighbor] = true
+
+			item := hnswItem{node: neighbor, similarity: g.similarity(query, neighbor)}
+			if results.Len() < ef || item.similarity > results.items[0].similarity {
+				heap.Push(candidates, item)
+				if filter == nil || filter(g.nodes[neighbor]) {
+					heap.Push(results, item)
+					if results.Len() > ef {
+						heap.Pop(results)
+					}
+				}
+			}
+		}
+	}
+
+	// The results heap holds the least similar on top, so pop into the slice back to front
+	sorted := make([]hnswItem, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(results).(hnswItem)
+	}
+	return sorted
+}
+
+// link adds an edge from one node to another, keeping only the closest neighbours when it has too many
+func (g *hnswGraph) link(from, to int32, level int) {
+	node := g.nodes[from]
+	node.neighbors[level] = append(node.neighbors[level], to)
+	if len(node.neighbors[level]) <= g.maxNeighbors(level) {
+		return
+	}
+
+	items := make([]hnswItem, len(node.neighbors[level]))
+	for i, neighbor := range node.neighbors[level] {
+		items[i] = hnswItem{node: neighbor, similarity: floats.Dot(node.vector, g.nodes[neighbor].vector)}
+	}
+	sortItems(items)
+	node.neighbors[level] = g.selectNeighbors(items, g.maxNeighbors(level))
+}
+
+// selectNeighbors picks up to n of the items, sorted most similar first, to link to. An item is preferred
+// when it is more similar to the new node than to any item already picked, which spreads the links over
+// different directions and keeps separate clusters connected; the remaining places go to the closest others.
+func (g *hnswGraph) selectNeighbors(items []hnswItem, n int) []int32 {
+	selected := make([]int32, 0, min(n, len(items)))
+	var skipped []int32
+	for _, item := range items {
+		if len(selected) == n {
+			break
+		}
+		diverse := true
+		for _, other := range selected {
+			if floats.Dot(g.nodes[item.node].vector, g.nodes[other].vector) > item.similarity {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, item.node)
+		} else {
+			skipped = append(skipped, item.node)
+		}
+	}
+	for _, node := range skipped {
+		if len(selected) == n {
+			break
+		}
+		selected = append(selected, node)
+	}
+	return selected
+}
+
+// maxNeighbors is M on the upper levels and 2M on the bottom one, which holds every node
+func (g *hnswGraph) maxNeighbors(level int) int {
+	if level == 0 {
+		return 2 * g.m
+	}
+	return g.m
+}
+
+func (g *hnswGraph) similarity(query []float64, node int32) float64 {
+	vector := g.nodes[node].vector
+	if len(vector) != len(query) {
+		return -1
+	}
+	return floats.Dot(query, vector)
+}
+
+// sortItems orders items most similar first
+func sortItems(items []hnswItem) {
+	sort.Slice(items, func(i, j int) bool { return items[i].similarity > items[j].similarity })
+}
+
+// hnswQueue is a heap of items with the most similar on top when nearestFirst is set, else the least similar
+type hnswQueue struct {
+	items        []hnswItem
+	nearestFirst bool
+}
+
+func (q *hnswQueue) Len() int { return len(q.items) }
+func (q *hnswQueue) Less(i, j int) bool {
+	if q.nearestFirst {
+		return q.items[i].similarity > q.items[j].similarity
+	}
+	return q.items[i].similarity < q.items[j].similarity
+}
+func (q *hnswQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+func (q *hnswQueue) Push(x interface{}) {
+	q.items = append(q.items, x.(hnswItem))
+}
+
+func (q *hnswQueue) Pop() interface{} {
+	item := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return item
+}
@@ -0,0 +1,88 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"ai-cv-summarize/internal/models"
+)
+
+// EmbeddingMigrationSummary reports the outcome of MigrateEmbeddings
+type EmbeddingMigrationSummary struct {
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+	Checked    int    `json:"checked"`
+	Reembedded int    `json:"reembedded"`
+	Rechunked  int    `json:"rechunked"`
+	Failed     int    `json:"failed"`
+}
+
+// MigrateEmbeddings re-embeds every job description whose vector is missing or was produced by a
+// different model than the current client, and re-chunks job descriptions and reference documents whose chunks are missing or stale. Failures are logged and counted so one bad document
+// does not block the rest; rerunning the migration retries them.
+func (vs *VectorStore) MigrateEmbeddings(ctx context.Context) (*EmbeddingMigrationSummary, error) {
+	jobDescs, err := vs.repository.GetAllJobDescriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job descriptions: %w", err)
+	}
+
+	summary := &EmbeddingMigrationSummary{Model: vs.llmClient.EmbeddingModel()}
+	var stale []*models.JobDescription
+	for _, job := range jobDescs {
+		summary.Checked++
+
+		if len(job.Embedding) > 0 && job.EmbeddingModel == summary.Model &&
+			(summary.Dimensions == 0 || len(job.Embedding) == summary.Dimensions) {
+			summary.Dimensions = len(job.Embedding)
+			vs.migrateChunks(ctx, job, summary)
+			continue
+		}
+		stale = append(stale, job)
+	}
+
+	embeddings, errs := vs.embedJobDescriptions(ctx, stale)
+	for i, job := range stale {
+		if errs[i] != nil {
+			log.Printf("Failed to re-embed job description %s: %v", job.ID.Hex(), errs[i])
+			summary.Failed++
+			continue
+		}
+
+		if err := vs.storeEmbedding(ctx, job, embeddings[i]); err != nil {
+			log.Printf("Failed to save embedding for job description %s: %v", job.ID.Hex(), err)
+			summary.Failed++
+			continue
+		}
+
+		summary.Dimensions = len(embeddings[i])
+		summary.Reembedded++
+	}
+
+	rechunked, failed, err := vs.migrateReferenceChunks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary.Rechunked += rechunked
+	summary.Failed += failed
+
+	return summary, nil
+}
+
+// migrateChunks re-chunks a job description whose vector is current but whose chunks are not
+func (vs *VectorStore) migrateChunks(ctx context.Context, job *models.JobDescription, summary *EmbeddingMigrationSummary) {
+	current, err := vs.chunksCurrent(ctx, job.ID.Hex())
+	if err == nil && current {
+		return
+	}
+	if err == nil {
+		err = vs.indexChunks(ctx, job)
+	}
+	if err != nil {
+		log.Printf("Failed to re-chunk job description %s: %v", job.ID.Hex(), err)
+		summary.Failed++
+		return
+	}
+
+	summary.Rechunked++
+}
@@ -0,0 +1,142 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pgvectorSchema creates the vector table. The column has no fixed size so the embedding model can
+// change; a rebuild replaces every row.
+var pgvectorSchema = []string{
+	`CREATE EXTENSION IF NOT EXISTS vector`,
+	`CREATE TABLE IF NOT EXISTS job_description_vectors (
+		id TEXT PRIMARY KEY,
+		org_id TEXT NOT NULL DEFAULT '',
+		embedding_model TEXT NOT NULL DEFAULT '',
+		embedding vector NOT NULL
+	)`,
+}
+
+// PgVectorDB indexes job description vectors in PostgreSQL with the pgvector extension
+type PgVectorDB struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgVectorDB connects to the database at url and creates the vector table if needed
+func NewPgVectorDB(ctx context.Context, url string) (*PgVectorDB, error) {
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres pool: %w", err)
+	}
+
+	for _, statement := range pgvectorSchema {
+		if _, err := pool.Exec(ctx, statement); err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to create pgvector schema: %w", err)
+		}
+	}
+
+	return &PgVectorDB{pool: pool}, nil
+}
+
+func (db *PgVectorDB) Name() string {
+	return "pgvector"
+}
+
+// Ping checks that PostgreSQL is reachable
+func (db *PgVectorDB) Ping(ctx context.Context) error {
+	return db.pool.Ping(ctx)
+}
+
+// Close releases the connection pool
+func (db *PgVectorDB) Close() {
+	db.pool.Close()
+}
+
+func (db *PgVectorDB) Upsert(ctx context.Context, jobDesc *models.JobDescription) error {
+	if len(jobDesc.Embedding) == 0 {
+		return db.Delete(ctx, jobDesc.ID.Hex())
+	}
+
+	_, err := db.pool.Exec(ctx, `INSERT INTO job_description_vectors (id, org_id, embedding_model, embedding)
+		VALUES ($1, $2, $3, $4::vector)
+		ON CONFLICT (id) DO UPDATE SET org_id = EXCLUDED.org_id, embedding_model = EXCLUDED.embedding_model, embedding = EXCLUDED.embedding`,
+		jobDesc.ID.Hex(), jobDesc.OrgID, jobDesc.EmbeddingModel, vectorLiteral(jobDesc.Embedding))
+	return err
+}
+
+func (db *PgVectorDB) Delete(ctx context.Context, id string) error {
+	_, err := db.pool.Exec(ctx, "DELETE FROM job_description_vectors WHERE id = $1", id)
+	return err
+}
+
+func (db *PgVectorDB) Search(ctx context.Context, query []float64, model string, limit int) ([]SearchHit, error) {
+	// Both queries take the organization as $3
+	filter, orgArgs := "TRUE", []interface{}{}
+	if orgID, scoped := tenant.Scope(ctx); scoped {
+		// Only search the organization's own job descriptions and the global ones
+		filter = "org_id IN ($3, '')"
+		orgArgs = append(orgArgs, orgID)
+	}
+
+	// Distances between vectors of different sizes are an error in pgvector, so check first
+	var id, indexedModel string
+	var dimensions int
+	err := db.pool.QueryRow(ctx, `SELECT id, embedding_model, vector_dims(embedding) FROM job_description_vectors
+		WHERE `+filter+` AND (vector_dims(embedding) <> $1 OR embedding_model NOT IN ('', $2))
+		LIMIT 1`, append([]interface{}{len(query), model}, orgArgs...)...).Scan(&id, &indexedModel, &dimensions)
+	if err == nil {
+		if dimensions != len(query) {
+			return nil, fmt.Errorf("%w: job description %s is indexed with %d-dimension vectors but queries use %d-dimension %q; run `server rebuild-index`",
+				ErrEmbeddingMismatch, id, dimensions, len(query), model)
+		}
+		return nil, fmt.Errorf("%w: job description %s is indexed with %q vectors but queries use %q; run `server rebuild-index`",
+			ErrEmbeddingMismatch, id, indexedModel, model)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	// <=> is cosine distance
+	rows, err := db.pool.Query(ctx, `SELECT id, 1 - (embedding <=> $1::vector) FROM job_description_vectors
+		WHERE `+filter+` ORDER BY embedding <=> $1::vector LIMIT $2`, append([]interface{}{vectorLiteral(query), limit}, orgArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []SearchHit{}
+	for rows.Next() {
+		var hit SearchHit
+		if err := rows.Scan(&hit.ID, &hit.Score); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, rows.Err()
+}
+
+// Reset deletes every indexed vector
+func (db *PgVectorDB) Reset(ctx context.Context) error {
+	_, err := db.pool.Exec(ctx, "DELETE FROM job_description_vectors")
+	return err
+}
+
+// vectorLiteral formats a vector as pgvector's text input, e.g. [0.1,0.2]
+func vectorLiteral(vector []float64) string {
+	values := make([]string, len(vector))
+	for i, v := range vector {
+		values[i] = strconv.FormatFloat(v, 'g', -1, 32)
+	}
+	return "[" + strings.Join(values, ",") + "]"
+}
@@ -0,0 +1,283 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/tenant"
+)
+
+// QdrantVectorDB indexes job description vectors in a Qdrant collection through its REST API
+type QdrantVectorDB struct {
+	baseURL    string
+	apiKey     string
+	collection string
+	httpClient *http.Client
+
+	mu         sync.Mutex
+	dimensions int // vector size of the collection, 0 until known
+}
+
+func NewQdrantVectorDB(cfg *config.VectorDBConfig) *QdrantVectorDB {
+	return &QdrantVectorDB{
+		baseURL:    strings.TrimRight(cfg.URL, "/"),
+		apiKey:     cfg.APIKey,
+		collection: cfg.Collection,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (db *QdrantVectorDB) Name() string {
+	return "qdrant"
+}
+
+// Ping checks that Qdrant is reachable
+func (db *QdrantVectorDB) Ping(ctx context.Context) error {
+	return db.do(ctx, http.MethodGet, "/collections", nil, nil)
+}
+
+func (db *QdrantVectorDB) Upsert(ctx context.Context, jobDesc *models.JobDescription) error {
+	if len(jobDesc.Embedding) == 0 {
+		return db.Delete(ctx, jobDesc.ID.Hex())
+	}
+
+	if err := db.ensureCollection(ctx, len(jobDesc.Embedding)); err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"points": []map[string]interface{}{
+			{
+				"id":     qdrantPointID(jobDesc.ID.Hex()),
+				"vector": jobDesc.Embedding,
+				"payload": map[string]interface{}{
+					"job_description_id": jobDesc.ID.Hex(),
+					"embedding_model":    jobDesc.EmbeddingModel,
+					"org_id":             jobDesc.OrgID,
+				},
+			},
+		},
+	}
+
+	return db.do(ctx, http.MethodPut, db.collectionPath("/points?wait=true"), body, nil)
+}
+
+func (db *QdrantVectorDB) Delete(ctx context.Context, id string) error {
+	body := map[string]interface{}{
+		"points": []string{qdrantPointID(id)},
+	}
+
+	err := db.do(ctx, http.MethodPost, db.collectionPath("/points/delete?wait=true"), body, nil)
+	if isQdrantNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (db *QdrantVectorDB) Search(ctx context.Context, query []float64, model string, limit int) ([]SearchHit, error) {
+	dimensions, err := db.collectionDimensions(ctx)
+	if isQdrantNotFound(err) {
+		// Nothing has been indexed yet
+		return []SearchHit{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dimensions != len(query) {
+		return nil, fmt.Errorf("%w: qdrant collection %q holds %d-dimension vectors but queries use %d-dimension %q; run `server rebuild-index`",
+			ErrEmbeddingMismatch, db.collection, dimensions, len(query), model)
+	}
+
+	body := map[string]interface{}{
+		"vector":       query,
+		"limit":        limit,
+		"with_payload": true,
+	}
+	if orgID, scoped := tenant.Scope(ctx); scoped {
+		// Only search the organization's own job descriptions and the global ones
+		body["filter"] = map[string]interface{}{
+			"must": []map[string]interface{}{
+				{"key": "org_id", "match": map[string]interface{}{"any": []string{orgID, ""}}},
+			},
+		}
+	}
+
+	var response struct {
+		Result []struct {
+			Score   float64 `json:"score"`
+			Payload struct {
+				JobDescriptionID string `json:"job_description_id"`
+				EmbeddingModel   string `json:"embedding_model"`
+			} `json:"payload"`
+		} `json:"result"`
+	}
+	if err := db.do(ctx, http.MethodPost, db.collectionPath("/points/search"), body, &response); err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, 0, len(response.Result))
+	for _, point := range response.Result {
+		if point.Payload.EmbeddingModel != "" && point.Payload.EmbeddingModel != model {
+			return nil, fmt.Errorf("%w: job description %s is indexed with %q vectors but queries use %q; run `server rebuild-index`",
+				ErrEmbeddingMismatch, point.Payload.JobDescriptionID, point.Payload.EmbeddingModel, model)
+		}
+		hits = append(hits, SearchHit{
+			ID:    point.Payload.JobDescriptionID,
+			Score: point.Score,
+		})
+	}
+
+	return hits, nil
+}
+
+// Reset drops the collection; it is recreated with the right vector size on the next upsert
+func (db *QdrantVectorDB) Reset(ctx context.Context) error {
+	db.mu.Lock()
+	db.dimensions = 0
+	db.mu.Unlock()
+
+	err := db.do(ctx, http.MethodDelete, db.collectionPath(""), nil, nil)
+	if isQdrantNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// ensureCollection creates the collection for vectors of the given size if it does not exist yet
+func (db *QdrantVectorDB) ensureCollection(ctx context.Context, size int) error {
+	dimensions, err := db.collectionDimensions(ctx)
+	if err == nil {
+		if dimensions != size {
+			return fmt.Errorf("%w: qdrant collection %q holds %d-dimension vectors, cannot index %d-dimension vectors; run `server rebuild-index`",
+				ErrEmbeddingMismatch, db.collection, dimensions, size)
+		}
+		return nil
+	}
+	if !isQdrantNotFound(err) {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"vectors": map[string]interface{}{
+			"size":     size,
+			"distance": "Cosine",
+		},
+	}
+	if err := db.do(ctx, http.MethodPut, db.collectionPath(""), body, nil); err != nil {
+		return fmt.Errorf("failed to create qdrant collection: %w", err)
+	}
+
+	db.mu.Lock()
+	db.dimensions = size
+	db.mu.Unlock()
+
+	return nil
+}
+
+// collectionDimensions returns the vector size of the collection, caching it after the first lookup
+func (db *QdrantVectorDB) collectionDimensions(ctx context.Context) (int, error) {
+	db.mu.Lock()
+	dimensions := db.dimensions
+	db.mu.Unlock()
+	if dimensions > 0 {
+		return dimensions, nil
+	}
+
+	var response struct {
+		Result struct {
+			Config struct {
+				Params struct {
+					Vectors struct {
+						Size int `json:"size"`
+					} `json:"vectors"`
+				} `json:"params"`
+			} `json:"config"`
+		} `json:"result"`
+	}
+	if err := db.do(ctx, http.MethodGet, db.collectionPath(""), nil, &response); err != nil {
+		return 0, err
+	}
+
+	dimensions = response.Result.Config.Params.Vectors.Size
+	db.mu.Lock()
+	db.dimensions = dimensions
+	db.mu.Unlock()
+
+	return dimensions, nil
+}
+
+func (db *QdrantVectorDB) collectionPath(suffix string) string {
+	return "/collections/" + db.collection + suffix
+}
+
+// qdrantError carries the HTTP status of a failed Qdrant request
+type qdrantError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *qdrantError) Error() string {
+	return fmt.Sprintf("qdrant returned status %d: %s", e.StatusCode, e.Body)
+}
+
+func isQdrantNotFound(err error) bool {
+	qerr, ok := err.(*qdrantError)
+	return ok && qerr.StatusCode == http.StatusNotFound
+}
+
+// do sends a JSON request to Qdrant and decodes the response into out when it is non-nil
+func (db *QdrantVectorDB) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode qdrant request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, db.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if db.apiKey != "" {
+		req.Header.Set("api-key", db.apiKey)
+	}
+
+	resp, err := db.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("qdrant request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &qdrantError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(message))}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode qdrant response: %w", err)
+	}
+
+	return nil
+}
+
+// qdrantPointID converts a 24-hex-digit ObjectID into the UUID form Qdrant requires for string IDs
+func qdrantPointID(id string) string {
+	hex := fmt.Sprintf("%-32s", id)
+	hex = strings.ReplaceAll(hex, " ", "0")
+	return hex[0:8] + "-" + hex[8:12] + "-" + hex[12:16] + "-" + hex[16:20] + "-" + hex[20:32]
+}
@@ -0,0 +1,193 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+)
+
+// DefaultRebuildBatchSize is the number of documents embedded between checkpoints
+const DefaultRebuildBatchSize = 20
+
+// ErrRebuildInProgress is returned when a rebuild is already running in this process
+var ErrRebuildInProgress = errors.New("vector index rebuild already in progress")
+
+// IndexRebuilder wipes and re-embeds every job description in batches, checkpointing progress
+// after each batch so an interrupted rebuild resumes where it stopped
+type IndexRebuilder struct {
+	vectorStore *VectorStore
+	repository  repositories.Repository
+
+	mu      sync.Mutex
+	running bool
+}
+
+func NewIndexRebuilder(vectorStore *VectorStore, repository repositories.Repository) *IndexRebuilder {
+	return &IndexRebuilder{
+		vectorStore: vectorStore,
+		repository:  repository,
+	}
+}
+
+// Start prepares a rebuild, resuming the latest unfinished one unless restart is set.
+// The returned rebuild must be passed to Run.
+func (ir *IndexRebuilder) Start(ctx context.Context, batchSize int, restart bool) (*models.IndexRebuild, error) {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	if ir.running {
+		return nil, ErrRebuildInProgress
+	}
+
+	if !restart {
+		latest, err := ir.repository.GetLatestIndexRebuild(ctx)
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			return nil, fmt.Errorf("failed to get latest rebuild: %w", err)
+		}
+		// A running record with no live worker was interrupted by a restart or crash
+		if latest != nil && latest.Status != models.IndexRebuildCompleted && latest.Model == ir.vectorStore.llmClient.EmbeddingModel() {
+			latest.Status = models.IndexRebuildRunning
+			latest.Error = ""
+			ir.running = true
+			return latest, nil
+		}
+	}
+
+	if batchSize <= 0 {
+		batchSize = DefaultRebuildBatchSize
+	}
+
+	rebuild := &models.IndexRebuild{
+		Status:    models.IndexRebuildRunning,
+		Model:     ir.vectorStore.llmClient.EmbeddingModel(),
+		BatchSize: batchSize,
+		StartedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := ir.repository.SaveIndexRebuild(ctx, rebuild); err != nil {
+		return nil, fmt.Errorf("failed to save rebuild: %w", err)
+	}
+
+	ir.running = true
+	return rebuild, nil
+}
+
+// Run executes a rebuild returned by Start until every document is processed or ctx is cancelled
+func (ir *IndexRebuilder) Run(ctx context.Context, rebuild *models.IndexRebuild) error {
+	defer func() {
+		ir.mu.Lock()
+		ir.running = false
+		ir.mu.Unlock()
+	}()
+
+	err := ir.run(ctx, rebuild)
+	if err != nil {
+		rebuild.Status = models.IndexRebuildFailed
+		rebuild.Error = err.Error()
+	} else {
+		now := time.Now()
+		rebuild.Status = models.IndexRebuildCompleted
+		rebuild.CompletedAt = &now
+	}
+
+	// Record the outcome even if the request that started the rebuild has gone away
+	if saveErr := ir.save(context.Background(), rebuild); saveErr != nil && err == nil {
+		err = saveErr
+	}
+
+	return err
+}
+
+func (ir *IndexRebuilder) run(ctx context.Context, rebuild *models.IndexRebuild) error {
+	jobDescs, err := ir.repository.GetAllJobDescriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get job descriptions: %w", err)
+	}
+
+	// Object IDs sort by creation, which gives a stable order to resume from
+	sort.Slice(jobDescs, func(i, j int) bool {
+		return jobDescs[i].ID.Hex() < jobDescs[j].ID.Hex()
+	})
+	rebuild.Total = len(jobDescs)
+
+	if !rebuild.Wiped {
+		if err := ir.vectorStore.vectorDB.Reset(ctx); err != nil {
+			return fmt.Errorf("failed to reset %s index: %w", ir.vectorStore.vectorDB.Name(), err)
+		}
+		for _, job := range jobDescs {
+			if err := ir.repository.UpdateJobDescriptionEmbedding(ctx, job.ID.Hex(), nil, ""); err != nil {
+				return fmt.Errorf("failed to wipe embedding for job description %s: %w", job.ID.Hex(), err)
+			}
+		}
+		rebuild.Wiped = true
+		if err := ir.save(ctx, rebuild); err != nil {
+			return err
+		}
+	}
+
+	var pending []*models.JobDescription
+	for _, job := range jobDescs {
+		if job.ID.Hex() > rebuild.LastDocumentID {
+			pending = append(pending, job)
+		}
+	}
+
+	// Each batch is embedded in one call and checkpointed once stored
+	for start := 0; start < len(pending); start += rebuild.BatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batch := pending[start:min(start+rebuild.BatchSize, len(pending))]
+		embeddings, errs := ir.vectorStore.embedJobDescriptions(ctx, batch)
+		for i, job := range batch {
+			err := errs[i]
+			if err == nil {
+				err = ir.vectorStore.storeEmbedding(ctx, job, embeddings[i])
+			}
+			if err != nil {
+				log.Printf("Failed to rebuild embedding for job description %s: %v", job.ID.Hex(), err)
+				rebuild.Failed++
+			}
+
+			rebuild.Processed++
+			rebuild.LastDocumentID = job.ID.Hex()
+		}
+
+		if err := ir.save(ctx, rebuild); err != nil {
+			return err
+		}
+		log.Printf("Vector index rebuild progress: %d/%d (%d failed)", rebuild.Processed, rebuild.Total, rebuild.Failed)
+	}
+
+	// Reference documents are only chunked, so they just need chunks from the current model
+	_, failed, err := ir.vectorStore.migrateReferenceChunks(ctx)
+	if err != nil {
+		return err
+	}
+	rebuild.Failed += failed
+
+	return nil
+}
+
+// save checkpoints the rebuild with an up-to-date progress percentage
+func (ir *IndexRebuilder) save(ctx context.Context, rebuild *models.IndexRebuild) error {
+	rebuild.UpdatedAt = time.Now()
+	rebuild.Progress = 100
+	if rebuild.Total > 0 {
+		rebuild.Progress = math.Round(float64(rebuild.Processed)/float64(rebuild.Total)*10000) / 100
+	}
+
+	if err := ir.repository.SaveIndexRebuild(ctx, rebuild); err != nil {
+		return fmt.Errorf("failed to save rebuild progress: %w", err)
+	}
+	return nil
+}
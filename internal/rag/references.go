@@ -0,0 +1,106 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"ai-cv-summarize/internal/models"
+)
+
+// StepDocumentTypes are the document types each evaluation step retrieves context from: job descriptions
+// and company values ground the CV steps, and job descriptions, scoring guidelines and case-study briefs
+// ground the project evaluation
+var StepDocumentTypes = map[string][]string{
+	models.StepAnalyzeCV:       {models.DocumentTypeJobDescription, models.DocumentTypeCompanyValues},
+	models.StepEvaluateCV:      {models.DocumentTypeJobDescription, models.DocumentTypeCompanyValues},
+	models.StepEvaluateProject: {models.DocumentTypeJobDescription, models.DocumentTypeScoringGuideline, models.DocumentTypeCaseStudy},
+}
+
+// documentTypeHeadings name each document type in the evaluation context
+var documentTypeHeadings = map[string]string{
+	models.DocumentTypeJobDescription:   "Job Description",
+	models.DocumentTypeScoringGuideline: "Scoring Guideline",
+	models.DocumentTypeCompanyValues:    "Company Values",
+	models.DocumentTypeCaseStudy:        "Case Study",
+}
+
+// AddReferenceDocument stores a reference document and indexes its chunks for retrieval
+func (vs *VectorStore) AddReferenceDocument(ctx context.Context, docType, title, content string) (*models.ReferenceDocument, error) {
+	if !slices.Contains(models.ReferenceDocumentTypes, docType) {
+		return nil, fmt.Errorf("unsupported document type %q", docType)
+	}
+
+	doc := &models.ReferenceDocument{
+		Type:      docType,
+		Title:     title,
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+	if err := vs.repository.CreateReferenceDocument(ctx, doc); err != nil {
+		return nil, err
+	}
+
+	if err := vs.indexReferenceChunks(ctx, doc); err != nil {
+		return nil, fmt.Errorf("failed to index reference document chunks: %w", err)
+	}
+
+	return doc, nil
+}
+
+// DeleteReferenceDocument removes a reference document and its chunks
+func (vs *VectorStore) DeleteReferenceDocument(ctx context.Context, id string) error {
+	if err := vs.repository.DeleteReferenceDocument(ctx, id); err != nil {
+		return err
+	}
+
+	if err := vs.repository.DeleteDocumentChunks(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete reference document chunks: %w", err)
+	}
+
+	return nil
+}
+
+// indexReferenceChunks replaces the stored chunks of a reference document
+func (vs *VectorStore) indexReferenceChunks(ctx context.Context, doc *models.ReferenceDocument) error {
+	return vs.indexDocumentChunks(ctx, doc.ID.Hex(), doc.Type, doc.Title, doc.Title+"\n"+doc.Content, doc.OrgID)
+}
+
+// migrateReferenceChunks re-chunks the reference documents whose chunks are missing or from another
+// embedding model, and returns how many it re-chunked and how many failed
+func (vs *VectorStore) migrateReferenceChunks(ctx context.Context) (rechunked, failed int, err error) {
+	docs, err := vs.repository.GetReferenceDocuments(ctx, "")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get reference documents: %w", err)
+	}
+
+	for _, doc := range docs {
+		current, err := vs.chunksCurrent(ctx, doc.ID.Hex())
+		if err == nil && current {
+			continue
+		}
+		if err == nil {
+			err = vs.indexReferenceChunks(ctx, doc)
+		}
+		if err != nil {
+			log.Printf("Failed to re-chunk reference document %s: %v", doc.ID.Hex(), err)
+			failed++
+			continue
+		}
+		rechunked++
+	}
+
+	return rechunked, failed, nil
+}
+
+// hasChunkType reports whether any chunk belongs to a document of the given type
+func hasChunkType(chunks []*models.DocumentChunk, docType string) bool {
+	for _, chunk := range chunks {
+		if chunkType(chunk) == docType {
+			return true
+		}
+	}
+	return false
+}
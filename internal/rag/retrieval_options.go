@@ -0,0 +1,60 @@
+package rag
+
+import (
+	"fmt"
+	"math"
+
+	"ai-cv-summarize/internal/models"
+)
+
+// retrievalParams are the settings of one retrieval: the configured ones with a request's overrides applied
+type retrievalParams struct {
+	topK             int
+	threshold        float64
+	maxContextTokens int
+}
+
+// retrievalParams applies a request's retrieval options, if any, to the configured settings
+func (vs *VectorStore) retrievalParams(opts *models.RetrievalOptions) retrievalParams {
+	params := retrievalParams{
+		topK:             vs.config.TopK,
+		threshold:        vs.config.SimilarityThreshold,
+		maxContextTokens: vs.config.MaxContextTokens,
+	}
+	if opts == nil {
+		return params
+	}
+	if opts.TopK > 0 {
+		params.topK = opts.TopK
+	}
+	if opts.SimilarityThreshold != nil {
+		params.threshold = *opts.SimilarityThreshold
+	}
+	if opts.MaxContextTokens > 0 {
+		params.maxContextTokens = opts.MaxContextTokens
+	}
+	return params
+}
+
+// unthresholded returns the params without a similarity threshold, for documents used however dissimilar they are
+func (params retrievalParams) unthresholded() retrievalParams {
+	params.threshold = math.Inf(-1)
+	return params
+}
+
+// ValidateRetrievalOptions fails when a request's retrieval options are out of range
+func ValidateRetrievalOptions(opts *models.RetrievalOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if opts.TopK < 0 {
+		return fmt.Errorf("top_k must not be negative")
+	}
+	if opts.MaxContextTokens < 0 {
+		return fmt.Errorf("max_context_tokens must not be negative")
+	}
+	if threshold := opts.SimilarityThreshold; threshold != nil && (*threshold < 0 || *threshold > 1) {
+		return fmt.Errorf("similarity_threshold must be between 0 and 1")
+	}
+	return nil
+}
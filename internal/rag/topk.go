@@ -0,0 +1,59 @@
+package rag
+
+import "container/heap"
+
+// topHits keeps the best limit search hits added to it, in O(log limit) per hit
+type topHits struct {
+	limit int
+	hits  hitHeap
+}
+
+func newTopHits(limit int) *topHits {
+	return &topHits{limit: limit, hits: make(hitHeap, 0, max(limit, 0))}
+}
+
+func (t *topHits) add(hit SearchHit) {
+	switch {
+	case t.limit <= 0:
+	case len(t.hits) < t.limit:
+		heap.Push(&t.hits, hit)
+	case worseHit(t.hits[0], hit):
+		t.hits[0] = hit
+		heap.Fix(&t.hits, 0)
+	}
+}
+
+// sorted empties the heap into a slice ordered best first
+func (t *topHits) sorted() []SearchHit {
+	hits := make([]SearchHit, len(t.hits))
+	for i := len(hits) - 1; i >= 0; i-- {
+		hits[i] = heap.Pop(&t.hits).(SearchHit)
+	}
+	return hits
+}
+
+// worseHit orders hits by ascending score, and by descending ID on ties so the earlier document wins
+func worseHit(a, b SearchHit) bool {
+	if a.Score != b.Score {
+		return a.Score < b.Score
+	}
+	return a.ID > b.ID
+}
+
+// hitHeap is a min-heap with the worst kept hit on top
+type hitHeap []SearchHit
+
+func (h hitHeap) Len() int           { return len(h) }
+func (h hitHeap) Less(i, j int) bool { return worseHit(h[i], h[j]) }
+func (h hitHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *hitHeap) Push(x interface{}) {
+	*h = append(*h, x.(SearchHit))
+}
+
+func (h *hitHeap) Pop() interface{} {
+	old := *h
+	hit := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return hit
+}
@@ -0,0 +1,156 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"ai-cv-summarize/internal/llm"
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+
+	"gonum.org/v1/gonum/floats"
+)
+
+// SearchHit is a job description matched by a vector search
+type SearchHit struct {
+	ID    string
+	Score float64
+}
+
+// VectorDB stores job description vectors and answers nearest-neighbour queries.
+// Job description documents remain the source of truth; a VectorDB only indexes their vectors.
+type VectorDB interface {
+	// Name identifies the backend in logs
+	Name() string
+	// Upsert indexes the vector currently stored on the job description
+	Upsert(ctx context.Context, jobDesc *models.JobDescription) error
+	// Delete removes a job description from the index
+	Delete(ctx context.Context, id string) error
+	// Search returns up to limit hits ordered by descending similarity, failing with
+	// ErrEmbeddingMismatch when indexed vectors are not comparable with the query
+	Search(ctx context.Context, query []float64, model string, limit int) ([]SearchHit, error)
+	// Reset drops every indexed vector
+	Reset(ctx context.Context) error
+}
+
+// ScanVectorDB brute-forces cosine similarity over the vectors stored on the job description
+// documents. It needs no extra infrastructure and is the fallback when no vector database is configured.
+type ScanVectorDB struct {
+	repository repositories.Repository
+	llmClient  llm.LLMClient
+
+	// embedMismatched embeds incompatible documents in memory instead of failing the search
+	embedMismatched bool
+}
+
+func NewScanVectorDB(repository repositories.Repository, llmClient llm.LLMClient) *ScanVectorDB {
+	return &ScanVectorDB{
+		repository: repository,
+		llmClient:  llmClient,
+	}
+}
+
+func (db *ScanVectorDB) Name() string {
+	return "scan"
+}
+
+// Upsert is a no-op: the vector already lives on the job description document
+func (db *ScanVectorDB) Upsert(ctx context.Context, jobDesc *models.JobDescription) error {
+	return nil
+}
+
+// Delete is a no-op: deleting the job description removes its vector
+func (db *ScanVectorDB) Delete(ctx context.Context, id string) error {
+	return nil
+}
+
+// Reset is a no-op: vectors are wiped by clearing them on the job description documents
+func (db *ScanVectorDB) Reset(ctx context.Context) error {
+	return nil
+}
+
+// Search scores only the vectors, leaving job description text unloaded, and keeps the best limit hits in a heap
+func (db *ScanVectorDB) Search(ctx context.Context, query []float64, model string, limit int) ([]SearchHit, error) {
+	jobDescs, err := db.repository.GetJobDescriptionVectors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job descriptions: %w", err)
+	}
+
+	queryNorm := floats.Norm(query, 2)
+	top := newTopHits(limit)
+	var mismatched []string
+	for _, job := range jobDescs {
+		if !compatibleEmbedding(job, model, len(query)) {
+			if !db.embedMismatched {
+				return nil, fmt.Errorf("%w: job description %s has %d-dimension %q vectors but queries use %d-dimension %q; run `server migrate-embeddings`",
+					ErrEmbeddingMismatch, job.ID.Hex(), len(job.Embedding), job.EmbeddingModel, len(query), model)
+			}
+			mismatched = append(mismatched, job.ID.Hex())
+			continue
+		}
+
+		top.add(SearchHit{
+			ID:    job.ID.Hex(),
+			Score: cosine(query, job.Embedding, queryNorm, storedNorm(job.Embedding, job.EmbeddingNorm)),
+		})
+	}
+	if len(mismatched) == 0 {
+		return top.sorted(), nil
+	}
+
+	// Incompatible documents are embedded on the fly, which needs their text
+	texts := make([]string, len(mismatched))
+	for i, id := range mismatched {
+		job, err := db.repository.GetJobDescription(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get job description %s: %w", id, err)
+		}
+		texts[i] = jobDescriptionText(job)
+	}
+	embeddings, err := db.llmClient.GenerateEmbeddings(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed %d job descriptions: %w", len(texts), err)
+	}
+	for i, id := range mismatched {
+		top.add(SearchHit{ID: id, Score: CosineSimilarity(query, embeddings[i])})
+	}
+
+	return top.sorted(), nil
+}
+
+// compatibleEmbedding reports whether a stored vector can be compared with a query from model.
+// Documents without a vector yet score zero rather than failing, and documents stored before
+// the model was recorded are checked by dimension only.
+func compatibleEmbedding(job *models.JobDescription, model string, dimensions int) bool {
+	if len(job.Embedding) == 0 {
+		return true
+	}
+	if len(job.Embedding) != dimensions {
+		return false
+	}
+	return job.EmbeddingModel == "" || job.EmbeddingModel == model
+}
+
+// CosineSimilarity returns the cosine of the angle between two vectors, or 0 when they cannot be compared
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0.0
+	}
+	return cosine(a, b, floats.Norm(a, 2), floats.Norm(b, 2))
+}
+
+// cosine is CosineSimilarity with the norms of both vectors already known
+func cosine(a, b []float64, normA, normB float64) float64 {
+	if len(a) != len(b) || normA == 0 || normB == 0 {
+		return 0.0
+	}
+	return floats.Dot(a, b) / (normA * normB)
+}
+
+// storedNorm returns the norm saved with an embedding, computing it for vectors stored before norms were
+func storedNorm(embedding []float64, norm float64) float64 {
+	if norm > 0 || len(embedding) == 0 {
+		return norm
+	}
+	return floats.Norm(embedding, 2)
+}
@@ -2,49 +2,209 @@ package rag
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math"
+	"slices"
 	"strings"
+	"time"
 
 	"ai-cv-summarize/internal/config"
 	"ai-cv-summarize/internal/llm"
 	"ai-cv-summarize/internal/models"
 	"ai-cv-summarize/internal/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gonum.org/v1/gonum/floats"
 )
 
+// ErrEmbeddingMismatch is returned when stored vectors come from a different embedding model than the query
+var ErrEmbeddingMismatch = errors.New("embedding model mismatch")
+
 type VectorStore struct {
 	llmClient  llm.LLMClient
-	repository *repositories.MongoDBRepository
+	repository repositories.Repository
+	vectorDB   VectorDB
 	config     *config.VectorDBConfig
+
+	// embedMismatched embeds chunks from other models on the fly instead of failing retrieval
+	embedMismatched bool
 }
 
-func NewVectorStore(llmClient llm.LLMClient, repository *repositories.MongoDBRepository, config *config.VectorDBConfig) *VectorStore {
+func NewVectorStore(llmClient llm.LLMClient, repository repositories.Repository, vectorDB VectorDB, config *config.VectorDBConfig) *VectorStore {
 	return &VectorStore{
 		llmClient:  llmClient,
 		repository: repository,
+		vectorDB:   vectorDB,
 		config:     config,
 	}
 }
 
-func (vs *VectorStore) AddJobDescription(ctx context.Context, title, description, requirements string) error {
-	fullText := fmt.Sprintf("Title: %s\nDescription: %s\nRequirements: %s", title, description, requirements)
-
-	embedding, err := vs.llmClient.GenerateEmbedding(ctx, fullText)
-	if err != nil {
-		return fmt.Errorf("failed to generate embedding: %w", err)
-	}
+// NewEphemeralVectorStore returns a scan-backed store that embeds documents from other models on the fly
+// rather than rejecting them. Only use it with cheap local clients such as the mock LLM.
+func NewEphemeralVectorStore(llmClient llm.LLMClient, repository repositories.Repository, config *config.VectorDBConfig) *VectorStore {
+	scan := NewScanVectorDB(repository, llmClient)
+	scan.embedMismatched = true
+	vs := NewVectorStore(llmClient, repository, scan, config)
+	vs.embedMismatched = true
+	return vs
+}
 
+func (vs *VectorStore) AddJobDescription(ctx context.Context, title, description, requirements string) (*models.JobDescription, error) {
 	jobDesc := &models.JobDescription{
+		ID:           primitive.NewObjectID(),
 		Title:        title,
 		Description:  description,
 		Requirements: requirements,
-		Embedding:    embedding,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := vs.embedJobDescription(ctx, jobDesc); err != nil {
+		return nil, err
+	}
+
+	if err := vs.repository.CreateJobDescription(ctx, jobDesc); err != nil {
+		return nil, err
+	}
+
+	if err := vs.vectorDB.Upsert(ctx, jobDesc); err != nil {
+		return nil, fmt.Errorf("failed to index job description: %w", err)
+	}
+
+	if err := vs.indexChunks(ctx, jobDesc); err != nil {
+		return nil, fmt.Errorf("failed to index job description chunks: %w", err)
 	}
 
-	return vs.repository.CreateJobDescription(ctx, jobDesc)
+	return jobDesc, nil
+}
+
+// UpdateJobDescription replaces a job description's content and regenerates its embedding
+func (vs *VectorStore) UpdateJobDescription(ctx context.Context, id, title, description, requirements string) (*models.JobDescription, error) {
+	jobDesc, err := vs.repository.GetJobDescription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	jobDesc.Title = title
+	jobDesc.Description = description
+	jobDesc.Requirements = requirements
+	jobDesc.UpdatedAt = time.Now()
+
+	if err := vs.embedJobDescription(ctx, jobDesc); err != nil {
+		return nil, err
+	}
+
+	if err := vs.repository.UpdateJobDescription(ctx, jobDesc); err != nil {
+		return nil, err
+	}
+
+	if err := vs.vectorDB.Upsert(ctx, jobDesc); err != nil {
+		return nil, fmt.Errorf("failed to index job description: %w", err)
+	}
+
+	if err := vs.indexChunks(ctx, jobDesc); err != nil {
+		return nil, fmt.Errorf("failed to index job description chunks: %w", err)
+	}
+
+	return jobDesc, nil
+}
+
+// DeleteJobDescription removes a job description, its indexed vector and its chunks
+func (vs *VectorStore) DeleteJobDescription(ctx context.Context, id string) error {
+	if err := vs.repository.DeleteJobDescription(ctx, id); err != nil {
+		return err
+	}
+
+	if err := vs.vectorDB.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to remove job description from index: %w", err)
+	}
+
+	if err := vs.repository.DeleteDocumentChunks(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete job description chunks: %w", err)
+	}
+
+	return nil
+}
+
+// embedJobDescription generates the vector for a job description's current content
+func (vs *VectorStore) embedJobDescription(ctx context.Context, jobDesc *models.JobDescription) error {
+	embedding, err := vs.llmClient.GenerateEmbedding(ctx, jobDescriptionText(jobDesc))
+	if err != nil {
+		return fmt.Errorf("failed to generate embedding: %w", err)
+	}
+	jobDesc.Embedding = embedding
+	jobDesc.EmbeddingModel = vs.llmClient.EmbeddingModel()
+	jobDesc.EmbeddingDimensions = len(embedding)
+	jobDesc.EmbeddingNorm = floats.Norm(embedding, 2)
+
+	return nil
+}
+
+// storeEmbedding records a new vector on the job description and indexes it along with its chunks
+func (vs *VectorStore) storeEmbedding(ctx context.Context, jobDesc *models.JobDescription, embedding []float64) error {
+	model := vs.llmClient.EmbeddingModel()
+	if len(embedding) == 0 {
+		model = ""
+	}
+
+	if err := vs.repository.UpdateJobDescriptionEmbedding(ctx, jobDesc.ID.Hex(), embedding, model); err != nil {
+		return err
+	}
+
+	jobDesc.Embedding = embedding
+	jobDesc.EmbeddingModel = model
+	jobDesc.EmbeddingDimensions = len(embedding)
+	jobDesc.EmbeddingNorm = floats.Norm(embedding, 2)
+
+	if err := vs.vectorDB.Upsert(ctx, jobDesc); err != nil {
+		return err
+	}
+	if len(embedding) == 0 {
+		return nil
+	}
+	return vs.indexChunks(ctx, jobDesc)
+}
+
+// embedJobDescriptions embeds job descriptions in one batch call. When the batch fails, each one is retried
+// on its own so a single bad document only fails itself; errs holds the error of each document, if any.
+func (vs *VectorStore) embedJobDescriptions(ctx context.Context, jobDescs []*models.JobDescription) (embeddings [][]float64, errs []error) {
+	texts := make([]string, len(jobDescs))
+	for i, job := range jobDescs {
+		texts[i] = jobDescriptionText(job)
+	}
+
+	errs = make([]error, len(jobDescs))
+	embeddings, err := vs.llmClient.GenerateEmbeddings(ctx, texts)
+	if err == nil {
+		return embeddings, errs
+	}
+
+	embeddings = make([][]float64, len(jobDescs))
+	for i, text := range texts {
+		embeddings[i], errs[i] = vs.llmClient.GenerateEmbedding(ctx, text)
+	}
+	return embeddings, errs
 }
 
 func (vs *VectorStore) SearchSimilarJobDescriptions(ctx context.Context, query string, limit int) ([]*models.JobDescription, error) {
+	hits, err := vs.searchJobDescriptions(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.JobDescription, len(hits))
+	for i, hit := range hits {
+		results[i] = hit.jobDesc
+	}
+	return results, nil
+}
+
+// jobHit is a job description scored against a query
+type jobHit struct {
+	jobDesc *models.JobDescription
+	score   float64
+}
+
+// searchJobDescriptions returns the job descriptions most similar to a query, best first
+func (vs *VectorStore) searchJobDescriptions(ctx context.Context, query string, limit int) ([]jobHit, error) {
 	if query == "" {
 		return nil, fmt.Errorf("query cannot be empty")
 	}
@@ -59,94 +219,225 @@ func (vs *VectorStore) SearchSimilarJobDescriptions(ctx context.Context, query s
 		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
 	}
 
-	jobDescs, err := vs.repository.GetAllJobDescriptions(ctx)
+	hits, err := vs.vectorDB.Search(ctx, queryEmbedding, vs.llmClient.EmbeddingModel(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []jobHit
+	for _, hit := range hits {
+		jobDesc, err := vs.repository.GetJobDescription(ctx, hit.ID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			// The index can briefly lag behind deletions
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get job description %s: %w", hit.ID, err)
+		}
+		results = append(results, jobHit{jobDesc: jobDesc, score: hit.Score})
+	}
+
+	return results, nil
+}
+
+// StepContext is the evaluation context of one step and what was retrieved to build it
+type StepContext struct {
+	Text      string
+	Retrieval models.StepRetrieval
+}
+
+// jobContext is the part of the evaluation context taken from job descriptions other than their ranked chunks:
+// whole retrieved documents or a pinned one
+type jobContext struct {
+	text      string
+	mode      string
+	documents []models.RetrievedDocument
+}
+
+// GetRelevantContext builds the evaluation context of each step (see StepDocumentTypes) from the chunks of
+// the step's document types most similar to the CV and project report. Until job descriptions are chunked,
+// the most similar whole job descriptions stand in for their excerpts. Options, when set, override the
+// configured top K, similarity threshold and token budget; a step none of whose documents reach the
+// threshold gets no context.
+func (vs *VectorStore) GetRelevantContext(ctx context.Context, cvContent, projectContent string, opts *models.RetrievalOptions) (map[string]StepContext, error) {
+	params := vs.retrievalParams(opts)
+	chunks, err := vs.repository.GetAllDocumentChunks(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get job descriptions: %w", err)
+		return nil, fmt.Errorf("failed to get document chunks: %w", err)
 	}
 
-	type scoredJob struct {
-		job   *models.JobDescription
-		score float64
+	job := jobContext{mode: models.RetrievalChunks}
+	if !hasChunkType(chunks, models.DocumentTypeJobDescription) {
+		if job, err = vs.documentContext(ctx, cvContent, projectContent, params); err != nil {
+			return nil, err
+		}
 	}
 
-	var scoredJobs []scoredJob
-	for _, job := range jobDescs {
-		similarity := vs.cosineSimilarity(queryEmbedding, job.Embedding)
-		scoredJobs = append(scoredJobs, scoredJob{
-			job:   job,
-			score: similarity,
-		})
+	return vs.stepContexts(ctx, job, chunks, cvContent, projectContent, params)
+}
+
+// stepContexts ranks the chunks of each document type against the CV and project report, then builds the
+// context of each step from the job context and the excerpts of the step's types. The chunks of a pinned job
+// description are ranked without the similarity threshold.
+func (vs *VectorStore) stepContexts(ctx context.Context, job jobContext, chunks []*models.DocumentChunk, cvContent, projectContent string, params retrievalParams) (map[string]StepContext, error) {
+	byType := make(map[string][]*models.DocumentChunk)
+	for _, chunk := range chunks {
+		byType[chunkType(chunk)] = append(byType[chunkType(chunk)], chunk)
 	}
 
-	// Sort by similarity
-	for i := 0; i < len(scoredJobs); i++ {
-		for j := i + 1; j < len(scoredJobs); j++ {
-			if scoredJobs[i].score < scoredJobs[j].score {
-				scoredJobs[i], scoredJobs[j] = scoredJobs[j], scoredJobs[i]
+	// Types are ranked apart so a large catalogue of one type cannot crowd the others out of the top K
+	hits := make(map[string][]chunkHit)
+	if len(chunks) > 0 {
+		queryVectors, err := vs.embedQueries(ctx, cvContent, projectContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search context: %w", err)
+		}
+		for docType, typeChunks := range byType {
+			typeParams := params
+			if job.mode == models.RetrievalPinned && docType == models.DocumentTypeJobDescription {
+				typeParams = params.unthresholded()
+			}
+			if hits[docType], err = vs.rankChunks(ctx, typeChunks, queryVectors, typeParams); err != nil {
+				return nil, fmt.Errorf("failed to search context: %w", err)
 			}
 		}
 	}
 
-	if limit > len(scoredJobs) {
-		limit = len(scoredJobs)
+	contexts := make(map[string]StepContext, len(StepDocumentTypes))
+	for step, types := range StepDocumentTypes {
+		stepJob := jobContext{mode: job.mode}
+		if slices.Contains(types, models.DocumentTypeJobDescription) {
+			stepJob = job
+		}
+		context := vs.formatStepContext(stepJob, hits, types, params)
+		context.Retrieval.Step = step
+		contexts[step] = context
 	}
+	return contexts, nil
+}
 
-	var results []*models.JobDescription
-	for i := 0; i < limit; i++ {
-		results = append(results, scoredJobs[i].job)
+// documentContext builds the job context from the top K whole job descriptions most similar to the CV and
+// project report that reach the similarity threshold
+func (vs *VectorStore) documentContext(ctx context.Context, cvContent, projectContent string, params retrievalParams) (jobContext, error) {
+	cvResults, err := vs.searchJobDescriptions(ctx, cvContent, params.topK)
+	if err != nil {
+		return jobContext{}, fmt.Errorf("failed to search CV context: %w", err)
 	}
 
-	return results, nil
+	projectResults, err := vs.searchJobDescriptions(ctx, projectContent, params.topK)
+	if err != nil {
+		return jobContext{}, fmt.Errorf("failed to search project context: %w", err)
+	}
+
+	// Best matches first, so the budget drops the weakest
+	seen := make(map[string]bool)
+	var results []jobHit
+	for _, result := range append(cvResults, projectResults...) {
+		if result.score >= params.threshold && !seen[result.jobDesc.ID.Hex()] {
+			seen[result.jobDesc.ID.Hex()] = true
+			results = append(results, result)
+		}
+	}
+
+	jobs := make([]*models.JobDescription, len(results))
+	for i, result := range results {
+		jobs[i] = result.jobDesc
+	}
+	text, included := fitContext(jobs, params.maxContextTokens)
+
+	documents := make([]models.RetrievedDocument, len(results))
+	for i, result := range results {
+		documents[i] = wholeDocument(result.jobDesc, result.score, i < included)
+	}
+	return jobContext{text: text, mode: models.RetrievalDocuments, documents: documents}, nil
 }
 
-func (vs *VectorStore) GetRelevantContext(ctx context.Context, cvContent, projectContent string) (string, error) {
-	cvResults, err := vs.SearchSimilarJobDescriptions(ctx, cvContent, 2)
+// fitContext builds the evaluation context from as many of the job descriptions, in order, as fit in the
+// token budget, and returns how many it used. When even the first does not fit, it is cut at the budget.
+func fitContext(jobs []*models.JobDescription, maxTokens int) (string, int) {
+	if len(jobs) == 0 {
+		return "", 0
+	}
+	n := len(jobs)
+	for n > 1 && llm.CountTokens(formatContext(jobs[:n])) > maxTokens {
+		n--
+	}
+	context, _ := llm.TruncateTokens(formatContext(jobs[:n]), maxTokens)
+	return context, n
+}
+
+// GetJobDescriptionContext builds the evaluation context of each step from one specific job description and
+// the reference documents relevant to the CV and project report. Descriptions over the context token budget
+// are reduced to their chunks most similar to the CV and project report. Options apply as in
+// GetRelevantContext, except that the pinned description is used however dissimilar it is.
+func (vs *VectorStore) GetJobDescriptionContext(ctx context.Context, id, cvContent, projectContent string, opts *models.RetrievalOptions) (map[string]StepContext, error) {
+	params := vs.retrievalParams(opts)
+
+	jobDesc, err := vs.repository.GetJobDescription(ctx, id)
 	if err != nil {
-		return "", fmt.Errorf("failed to search CV context: %w", err)
+		return nil, fmt.Errorf("failed to get job description %s: %w", id, err)
 	}
 
-	projectResults, err := vs.SearchSimilarJobDescriptions(ctx, projectContent, 2)
+	chunks, err := vs.repository.GetAllDocumentChunks(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to search project context: %w", err)
+		return nil, fmt.Errorf("failed to get document chunks: %w", err)
+	}
+	var references []*models.DocumentChunk
+	for _, chunk := range chunks {
+		if chunkType(chunk) != models.DocumentTypeJobDescription {
+			references = append(references, chunk)
+		}
 	}
 
-	contextMap := make(map[string]*models.JobDescription)
+	whole := formatContext([]*models.JobDescription{jobDesc})
+	if llm.CountTokens(whole) <= params.maxContextTokens {
+		job := jobContext{text: whole, mode: models.RetrievalPinned, documents: []models.RetrievedDocument{wholeDocument(jobDesc, 0, true)}}
+		return vs.stepContexts(ctx, job, references, cvContent, projectContent, params)
+	}
 
-	for _, result := range cvResults {
-		contextMap[result.ID.Hex()] = result
+	jobChunks, err := vs.repository.GetDocumentChunks(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chunks of job description %s: %w", id, err)
+	}
+	if len(jobChunks) == 0 {
+		text, _ := fitContext([]*models.JobDescription{jobDesc}, params.maxContextTokens)
+		job := jobContext{text: text, mode: models.RetrievalPinned, documents: []models.RetrievedDocument{wholeDocument(jobDesc, 0, true)}}
+		return vs.stepContexts(ctx, job, references, cvContent, projectContent, params)
 	}
 
-	for _, result := range projectResults {
-		contextMap[result.ID.Hex()] = result
+	contexts, err := vs.stepContexts(ctx, jobContext{mode: models.RetrievalPinned}, append(jobChunks, references...), cvContent, projectContent, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search job description %s: %w", id, err)
 	}
+	return contexts, nil
+}
 
+// wholeDocument describes a job description retrieved whole
+func wholeDocument(jobDesc *models.JobDescription, score float64, included bool) models.RetrievedDocument {
+	return models.RetrievedDocument{
+		DocumentID:   jobDesc.ID.Hex(),
+		DocumentType: models.DocumentTypeJobDescription,
+		Title:        jobDesc.Title,
+		Score:        score,
+		Tokens:       llm.CountTokens(formatContext([]*models.JobDescription{jobDesc})),
+		Included:     included,
+	}
+}
+
+func formatContext(jobs []*models.JobDescription) string {
 	var context strings.Builder
 	context.WriteString("Relevant Job Descriptions:\n\n")
 
-	for _, job := range contextMap {
+	for _, job := range jobs {
 		context.WriteString(fmt.Sprintf("Title: %s\n", job.Title))
 		context.WriteString(fmt.Sprintf("Description: %s\n", job.Description))
 		context.WriteString(fmt.Sprintf("Requirements: %s\n\n", job.Requirements))
 	}
 
-	return context.String(), nil
+	return context.String()
 }
 
-func (vs *VectorStore) cosineSimilarity(a, b []float64) float64 {
-	if len(a) != len(b) {
-		return 0.0
-	}
-
-	var dotProduct, normA, normB float64
-	for i := 0; i < len(a); i++ {
-		dotProduct += a[i] * b[i]
-		normA += a[i] * a[i]
-		normB += b[i] * b[i]
-	}
-
-	if normA == 0 || normB == 0 {
-		return 0.0
-	}
-
-	return dotProduct / (math.Sqrt(normA) * math.Sqrt(normB))
+// jobDescriptionText is the text embedded for a job description
+func jobDescriptionText(job *models.JobDescription) string {
+	return fmt.Sprintf("Title: %s\nDescription: %s\nRequirements: %s", job.Title, job.Description, job.Requirements)
 }
@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript takes cost from the request and token buckets of a limiter atomically: both or neither. It
+// returns 0 when the call may go ahead, or the milliseconds until both buckets hold enough.
+// Buckets are hashes of their level and the time of its last update, refilled continuously.
+var takeScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local wait = 0
+local levels = {}
+for i = 1, 2 do
+	local capacity = tonumber(ARGV[2 * i])
+	local cost = tonumber(ARGV[2 * i + 1])
+	if capacity > 0 then
+		local rate = capacity / 60000
+		local state = redis.call('HMGET', KEYS[i], 'level', 'updated')
+		local level = tonumber(state[1]) or capacity
+		local updated = tonumber(state[2]) or now
+		level = math.min(capacity, level + math.max(0, now - updated) * rate)
+		levels[i] = level - cost
+		if level < cost then
+			wait = math.max(wait, math.ceil((cost - level) / rate))
+		end
+	end
+end
+if wait > 0 then
+	return wait
+end
+for i = 1, 2 do
+	if levels[i] then
+		redis.call('HSET', KEYS[i], 'level', levels[i], 'updated', now)
+		redis.call('PEXPIRE', KEYS[i], 120000)
+	end
+end
+return 0
+`)
+
+// Limiter keeps LLM calls to one provider within a number of requests and tokens per minute with a pair
+// of token buckets. With Redis the buckets are shared by every worker of every instance; without it, or
+// while Redis fails, each instance limits its own calls.
+type Limiter struct {
+	redisClient       redis.UniversalClient
+	keys              []string
+	requestsPerMinute int
+	tokensPerMinute   int
+
+	mu    sync.Mutex
+	local [2]bucket
+}
+
+// bucket is a token bucket refilled continuously at its capacity per minute
+type bucket struct {
+	level   float64
+	updated time.Time
+}
+
+// NewLimiter returns a limiter for a provider's calls; a zero rate leaves that dimension unlimited and
+// redisClient may be nil
+func NewLimiter(redisClient redis.UniversalClient, provider string, requestsPerMinute, tokensPerMinute int) *Limiter {
+	// The hash tag keeps both buckets in one slot, so the script can use them on Redis Cluster
+	prefix := "{llm_rate_limit:" + provider + "}:"
+	return &Limiter{
+		redisClient:       redisClient,
+		keys:              []string{prefix + "requests", prefix + "tokens"},
+		requestsPerMinute: requestsPerMinute,
+		tokensPerMinute:   tokensPerMinute,
+	}
+}
+
+// Enabled reports whether any rate is limited
+func (l *Limiter) Enabled() bool {
+	return l.requestsPerMinute > 0 || l.tokensPerMinute > 0
+}
+
+// Shared reports whether the buckets are shared through Redis
+func (l *Limiter) Shared() bool {
+	return l.redisClient != nil
+}
+
+// Wait blocks until a call estimated to use tokens fits within the limits and takes it from the buckets.
+// A call larger than the tokens per minute waits for a full bucket. It fails with an error wrapping
+// context.DeadlineExceeded without waiting when the call could not start before ctx's deadline.
+func (l *Limiter) Wait(ctx context.Context, tokens int) error {
+	if l.tokensPerMinute > 0 {
+		tokens = min(tokens, l.tokensPerMinute)
+	}
+
+	for {
+		wait := l.take(ctx, tokens)
+		if wait <= 0 {
+			return nil
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("LLM rate limit leaves no time before the deadline: %w", context.DeadlineExceeded)
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// take takes a call from the buckets and returns zero, or how long until it fits
+func (l *Limiter) take(ctx context.Context, tokens int) time.Duration {
+	if l.redisClient != nil {
+		wait, err := takeScript.Run(ctx, l.redisClient, l.keys,
+			time.Now().UnixMilli(), l.requestsPerMinute, 1, l.tokensPerMinute, tokens).Int64()
+		if err == nil {
+			return time.Duration(wait) * time.Millisecond
+		}
+		log.Printf("Warning: shared LLM rate limit unavailable, limiting this instance only: %v", err)
+	}
+
+	return l.takeLocal(time.Now(), tokens)
+}
+
+// takeLocal is take on this instance's own buckets
+func (l *Limiter) takeLocal(now time.Time, tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	capacities := [2]int{l.requestsPerMinute, l.tokensPerMinute}
+	costs := [2]float64{1, float64(tokens)}
+
+	var levels [2]float64
+	var wait time.Duration
+	for i, capacity := range capacities {
+		if capacity <= 0 {
+			continue
+		}
+		b := &l.local[i]
+		rate := float64(capacity) / float64(time.Minute)
+		if b.updated.IsZero() {
+			b.level, b.updated = float64(capacity), now
+		}
+		levels[i] = math.Min(float64(capacity), b.level+float64(now.Sub(b.updated))*rate)
+		if levels[i] < costs[i] {
+			wait = max(wait, time.Duration(math.Ceil((costs[i]-levels[i])/rate)))
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	for i, capacity := range capacities {
+		if capacity > 0 {
+			l.local[i] = bucket{level: levels[i] - costs[i], updated: now}
+		}
+	}
+	return 0
+}
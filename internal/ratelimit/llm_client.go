@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+
+	"ai-cv-summarize/internal/llm"
+)
+
+// WrapLLMClient makes every call through the client wait for the limiter first. Like the provider, it
+// counts a completion as its prompt plus the most tokens it may generate.
+func (l *Limiter) WrapLLMClient(client llm.LLMClient) llm.LLMClient {
+	return &llmClient{next: client, limiter: l}
+}
+
+// llmClient wraps an LLM client with rate limiting
+type llmClient struct {
+	next    llm.LLMClient
+	limiter *Limiter
+}
+
+func (c *llmClient) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	if err := c.limiter.Wait(ctx, llm.CountTokens(text)); err != nil {
+		return nil, err
+	}
+	return c.next.GenerateEmbedding(ctx, text)
+}
+
+// GenerateEmbeddings is one request for the whole batch, with the tokens of every text
+func (c *llmClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	tokens := 0
+	for _, text := range texts {
+		tokens += llm.CountTokens(text)
+	}
+	if err := c.limiter.Wait(ctx, tokens); err != nil {
+		return nil, err
+	}
+	return c.next.GenerateEmbeddings(ctx, texts)
+}
+
+func (c *llmClient) EmbeddingModel() string {
+	return c.next.EmbeddingModel()
+}
+
+// Ping lists models, which does not count toward the limits
+func (c *llmClient) Ping(ctx context.Context) error {
+	return c.next.Ping(ctx)
+}
+
+func (c *llmClient) GenerateCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
+	if err := c.waitCompletion(ctx, prompt); err != nil {
+		return "", err
+	}
+	return c.next.GenerateCompletion(ctx, prompt, temperature)
+}
+
+func (c *llmClient) GenerateStructuredCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
+	if err := c.waitCompletion(ctx, prompt); err != nil {
+		return "", err
+	}
+	return c.next.GenerateStructuredCompletion(ctx, prompt, temperature)
+}
+
+func (c *llmClient) GenerateSchemaCompletion(ctx context.Context, prompt string, schema *llm.Schema, temperature float32) (string, error) {
+	if err := c.waitCompletion(ctx, prompt); err != nil {
+		return "", err
+	}
+	return c.next.GenerateSchemaCompletion(ctx, prompt, schema, temperature)
+}
+
+// The retry variants retry through the wrapper so every attempt is limited
+func (c *llmClient) GenerateCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return llm.Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateCompletion(ctx, prompt, temperature)
+	})
+}
+
+func (c *llmClient) GenerateStructuredCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return llm.Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateStructuredCompletion(ctx, prompt, temperature)
+	})
+}
+
+func (c *llmClient) GenerateSchemaCompletionWithRetry(ctx context.Context, prompt string, schema *llm.Schema, temperature float32, maxRetries int) (string, error) {
+	return llm.Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateSchemaCompletion(ctx, prompt, schema, temperature)
+	})
+}
+
+// waitCompletion waits until a completion of prompt fits within the limits
+func (c *llmClient) waitCompletion(ctx context.Context, prompt string) error {
+	return c.limiter.Wait(ctx, llm.CountTokens(prompt)+llm.CompletionMaxTokens)
+}
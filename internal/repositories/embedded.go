@@ -0,0 +1,2475 @@
+package repositories
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-cv-summarize/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EmbeddedRepository keeps all documents in memory and persists them to local files, so the service
+// can run without MongoDB. Each write appends the documents it changed to a journal next to the JSON
+// snapshot and only then applies them in memory, so a failed write changes neither; the snapshot is
+// rewritten when the journal is compacted. The LLM call log and the embedding cache, which grow with
+// every LLM call, are appended to their own logs instead. Without a file it is a purely in-memory
+// store. It is meant for local development, tests and demos and does not support multiple server
+// instances.
+type EmbeddedRepository struct {
+	mu   sync.RWMutex
+	path string
+	data embeddedData
+	// logLines counts the lines of each collection log, for compacting logs mostly made of replaced
+	// and deleted documents
+	logLines map[string]int
+	// journalLines counts the writes journaled since the last snapshot
+	journalLines int
+}
+
+// Names of the append-only collection logs, stored next to the snapshot as <path>.<name>.jsonl
+const (
+	llmCallLog        = "llm_calls"
+	embeddingCacheLog = "embedding_cache"
+)
+
+// journalName names the journal of snapshot writes, stored next to the snapshot as <path>.journal.jsonl
+const journalName = "journal"
+
+// minCompactLogLines is the size below which a collection log or the journal is never compacted
+const minCompactLogLines = 1000
+
+// logEntry is one line of a collection log; an entry without a document deletes the key
+type logEntry[T any] struct {
+	Key string `json:"key"`
+	Doc *T     `json:"doc,omitempty"`
+}
+
+// change is one document write staged by a store write: a replacement, or a deletion when it has no document
+type change struct {
+	Collection string          `json:"collection"`
+	Key        string          `json:"key"`
+	Doc        json.RawMessage `json:"doc,omitempty"`
+
+	doc   interface{}
+	apply func()
+}
+
+// journalRecord is one line of the journal, holding every change of one write
+type journalRecord struct {
+	Changes []change `json:"changes"`
+}
+
+// put stages doc as the document stored under key in the named collection
+func put[T any](docs map[string]*T, collection, key string, doc *T) change {
+	return change{Collection: collection, Key: key, doc: doc, apply: func() { docs[key] = doc }}
+}
+
+// remove stages the deletion of the document stored under key in the named collection
+func remove[T any](docs map[string]*T, collection, key string) change {
+	return change{Collection: collection, Key: key, apply: func() { delete(docs, key) }}
+}
+
+// embeddedData is the on-disk layout of the embedded store
+type embeddedData struct {
+	Jobs            map[string]*models.EvaluationJob          `json:"jobs"`
+	ArchivedJobs    map[string]*models.EvaluationJob          `json:"archived_jobs"`
+	BatchJobs       map[string]*models.BatchJob               `json:"batch_jobs"`
+	JobDescriptions map[string]*models.JobDescription         `json:"job_descriptions"`
+	ScoringRubrics  map[string]*models.ScoringRubric          `json:"scoring_rubrics"`
+	GoldenJobs      map[string]*models.GoldenJob              `json:"golden_jobs"`
+	PromptTemplates map[string]*models.PromptTemplate         `json:"prompt_templates"`
+	PromptVersions  map[string]*models.PromptTemplateVersion  `json:"prompt_template_versions"`
+	IndexRebuilds   map[string]*models.IndexRebuild           `json:"index_rebuilds"`
+	Organizations   map[string]*models.Organization           `json:"organizations"`
+	UsageTotals     map[string]*models.UsageTotal             `json:"usage_totals"`
+	LLMCalls        map[string]*models.LLMCall                `json:"llm_calls,omitempty"`
+	EmbeddingCache  map[string]*models.CachedEmbedding        `json:"embedding_cache,omitempty"`
+	DocumentChunks  map[string]*models.DocumentChunk          `json:"document_chunks"`
+	ReferenceDocs   map[string]*models.ReferenceDocument      `json:"reference_documents"`
+	ErasureRecords  map[string]*models.ErasureRecord          `json:"erasure_records"`
+	FairnessReports map[string]*models.FairnessReport         `json:"fairness_reports"`
+	Experiments     map[string]*models.Experiment             `json:"experiments"`
+	CalibrationRuns map[string]*models.GoldenComparisonReport `json:"calibration_runs"`
+	Uploads         map[string]*models.Upload                 `json:"uploads"`
+}
+
+// NewMemoryRepository returns an empty store that is never written to disk, for tests and
+// ephemeral demos
+func NewMemoryRepository() *EmbeddedRepository {
+	r := &EmbeddedRepository{logLines: map[string]int{}}
+	r.data.ensureCollections()
+	return r
+}
+
+// NewEmbeddedRepository opens (or creates) the store at path
+func NewEmbeddedRepository(path string) (*EmbeddedRepository, error) {
+	r := NewMemoryRepository()
+	r.path = path
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read embedded store: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, &r.data); err != nil {
+			return nil, fmt.Errorf("failed to parse embedded store: %w", err)
+		}
+		r.data.ensureCollections()
+	}
+	if err := r.replayJournal(); err != nil {
+		return nil, err
+	}
+
+	// Stores written by older versions keep these collections in the snapshot; compacting moves them
+	// to the logs, and the next snapshot leaves them out
+	if err := loadLog(r.logPath(llmCallLog), r.data.LLMCalls); err != nil {
+		return nil, err
+	}
+	if err := compactLog(r, llmCallLog, r.data.LLMCalls); err != nil {
+		return nil, err
+	}
+	if err := loadLog(r.logPath(embeddingCacheLog), r.data.EmbeddingCache); err != nil {
+		return nil, err
+	}
+	if err := compactLog(r, embeddingCacheLog, r.data.EmbeddingCache); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// ensureCollections initializes collections missing from stores written by older versions
+func (d *embeddedData) ensureCollections() {
+	if d.Jobs == nil {
+		d.Jobs = map[string]*models.EvaluationJob{}
+	}
+	if d.ArchivedJobs == nil {
+		d.ArchivedJobs = map[string]*models.EvaluationJob{}
+	}
+	if d.BatchJobs == nil {
+		d.BatchJobs = map[string]*models.BatchJob{}
+	}
+	if d.JobDescriptions == nil {
+		d.JobDescriptions = map[string]*models.JobDescription{}
+	}
+	if d.ScoringRubrics == nil {
+		d.ScoringRubrics = map[string]*models.ScoringRubric{}
+	}
+	if d.GoldenJobs == nil {
+		d.GoldenJobs = map[string]*models.GoldenJob{}
+	}
+	if d.PromptTemplates == nil {
+		d.PromptTemplates = map[string]*models.PromptTemplate{}
+	}
+	if d.PromptVersions == nil {
+		d.PromptVersions = map[string]*models.PromptTemplateVersion{}
+	}
+	if d.IndexRebuilds == nil {
+		d.IndexRebuilds = map[string]*models.IndexRebuild{}
+	}
+	if d.Organizations == nil {
+		d.Organizations = map[string]*models.Organization{}
+	}
+	if d.UsageTotals == nil {
+		d.UsageTotals = map[string]*models.UsageTotal{}
+	}
+	if d.LLMCalls == nil {
+		d.LLMCalls = map[string]*models.LLMCall{}
+	}
+	if d.EmbeddingCache == nil {
+		d.EmbeddingCache = map[string]*models.CachedEmbedding{}
+	}
+	if d.DocumentChunks == nil {
+		d.DocumentChunks = map[string]*models.DocumentChunk{}
+	}
+	if d.ReferenceDocs == nil {
+		d.ReferenceDocs = map[string]*models.ReferenceDocument{}
+	}
+	if d.ErasureRecords == nil {
+		d.ErasureRecords = map[string]*models.ErasureRecord{}
+	}
+	if d.FairnessReports == nil {
+		d.FairnessReports = map[string]*models.FairnessReport{}
+	}
+	if d.Experiments == nil {
+		d.Experiments = map[string]*models.Experiment{}
+	}
+	if d.CalibrationRuns == nil {
+		d.CalibrationRuns = map[string]*models.GoldenComparisonReport{}
+	}
+	if d.Uploads == nil {
+		d.Uploads = map[string]*models.Upload{}
+	}
+}
+
+// replayers returns, for each snapshot collection, a function replaying a journaled change into it
+func (d *embeddedData) replayers() map[string]func(key string, doc json.RawMessage) error {
+	return map[string]func(string, json.RawMessage) error{
+		"jobs":                     replayInto(d.Jobs),
+		"archived_jobs":            replayInto(d.ArchivedJobs),
+		"batch_jobs":               replayInto(d.BatchJobs),
+		"job_descriptions":         replayInto(d.JobDescriptions),
+		"scoring_rubrics":          replayInto(d.ScoringRubrics),
+		"golden_jobs":              replayInto(d.GoldenJobs),
+		"prompt_templates":         replayInto(d.PromptTemplates),
+		"prompt_template_versions": replayInto(d.PromptVersions),
+		"index_rebuilds":           replayInto(d.IndexRebuilds),
+		"organizations":            replayInto(d.Organizations),
+		"usage_totals":             replayInto(d.UsageTotals),
+		"document_chunks":          replayInto(d.DocumentChunks),
+		"reference_documents":      replayInto(d.ReferenceDocs),
+		"erasure_records":          replayInto(d.ErasureRecords),
+		"fairness_reports":         replayInto(d.FairnessReports),
+		"experiments":              replayInto(d.Experiments),
+		"calibration_runs":         replayInto(d.CalibrationRuns),
+		"uploads":                  replayInto(d.Uploads),
+	}
+}
+
+// replayInto returns a function applying a journaled change to docs
+func replayInto[T any](docs map[string]*T) func(key string, doc json.RawMessage) error {
+	return func(key string, doc json.RawMessage) error {
+		if doc == nil {
+			delete(docs, key)
+			return nil
+		}
+		var decoded T
+		if err := json.Unmarshal(doc, &decoded); err != nil {
+			return err
+		}
+		docs[key] = &decoded
+		return nil
+	}
+}
+
+// commit journals the changes of one write as a single line, if the store has a file, and then applies
+// them, so a write that fails to reach the disk leaves the store as it was. The snapshot is rewritten once
+// the journal outgrows the store. Callers must hold the write lock.
+func (r *EmbeddedRepository) commit(changes ...change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	if r.path != "" {
+		for i := range changes {
+			if changes[i].doc == nil {
+				continue
+			}
+			doc, err := json.Marshal(changes[i].doc)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s document: %w", changes[i].Collection, err)
+			}
+			changes[i].Doc = doc
+		}
+		line, err := json.Marshal(journalRecord{Changes: changes})
+		if err != nil {
+			return fmt.Errorf("failed to encode embedded store journal: %w", err)
+		}
+		if err := appendFile(r.logPath(journalName), append(line, '\n')); err != nil {
+			return err
+		}
+		r.journalLines++
+	}
+
+	for _, c := range changes {
+		c.apply()
+	}
+
+	if r.path != "" && r.journalLines > minCompactLogLines && r.journalLines > r.data.size() {
+		// The write is already durable in the journal; a failed compaction is retried on the next write
+		if err := r.snapshot(); err != nil {
+			log.Printf("Warning: failed to compact embedded store journal: %v", err)
+		}
+	}
+	return nil
+}
+
+// size returns the number of documents in the snapshot collections
+func (d *embeddedData) size() int {
+	return len(d.Jobs) + len(d.ArchivedJobs) + len(d.BatchJobs) + len(d.JobDescriptions) + len(d.ScoringRubrics) +
+		len(d.GoldenJobs) + len(d.PromptTemplates) + len(d.PromptVersions) + len(d.IndexRebuilds) +
+		len(d.Organizations) + len(d.UsageTotals) + len(d.DocumentChunks) + len(d.ReferenceDocs) +
+		len(d.ErasureRecords) + len(d.FairnessReports) + len(d.Experiments) + len(d.CalibrationRuns) + len(d.Uploads)
+}
+
+// snapshot writes the current state to disk atomically and empties the journal; callers must hold the
+// write lock. The collections with their own logs are left out. A crash before the journal is emptied
+// replays it over the new snapshot, which ends in the same state.
+func (r *EmbeddedRepository) snapshot() error {
+	snapshot := r.data
+	snapshot.LLMCalls = nil
+	snapshot.EmbeddingCache = nil
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode embedded store: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+
+	tmpPath := r.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, r.path); err != nil {
+		return err
+	}
+
+	if err := os.Remove(r.logPath(journalName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	r.journalLines = 0
+	return nil
+}
+
+// replayJournal applies the writes journaled since the snapshot. A crash while appending can leave
+// the last line incomplete; that write never completed and is ignored.
+func (r *EmbeddedRepository) replayJournal() error {
+	path := r.logPath(journalName)
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read embedded store journal: %w", err)
+	}
+	defer file.Close()
+
+	replayers := r.data.replayers()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	var incomplete error
+	for scanner.Scan() {
+		if incomplete != nil {
+			return incomplete
+		}
+		var record journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			incomplete = fmt.Errorf("failed to parse embedded store journal %s: %w", path, err)
+			continue
+		}
+		for _, c := range record.Changes {
+			replay, ok := replayers[c.Collection]
+			if !ok {
+				return fmt.Errorf("embedded store journal %s has unknown collection %q", path, c.Collection)
+			}
+			if err := replay(c.Key, c.Doc); err != nil {
+				return fmt.Errorf("failed to replay embedded store journal %s: %w", path, err)
+			}
+		}
+		r.journalLines++
+	}
+	return scanner.Err()
+}
+
+// appendFile appends content to a file, truncating it back when the write fails so a partial line is
+// never followed by later ones
+func appendFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Truncate(info.Size())
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// logPath returns the file of a collection log
+func (r *EmbeddedRepository) logPath(name string) string {
+	return r.path + "." + name + ".jsonl"
+}
+
+// appendLog appends entries to a collection log, if the store has a file, and then applies them to
+// collection, so a failed write changes neither. The log is compacted once most of its lines are
+// outdated. Callers must hold the write lock.
+func appendLog[T any](r *EmbeddedRepository, name string, collection map[string]*T, entries ...logEntry[T]) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if r.path != "" {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return fmt.Errorf("failed to encode %s entry: %w", name, err)
+			}
+		}
+		if err := appendFile(r.logPath(name), buf.Bytes()); err != nil {
+			return err
+		}
+		r.logLines[name] += len(entries)
+	}
+
+	for _, entry := range entries {
+		if entry.Doc == nil {
+			delete(collection, entry.Key)
+		} else {
+			collection[entry.Key] = entry.Doc
+		}
+	}
+
+	if lines := r.logLines[name]; r.path != "" && lines > minCompactLogLines && lines > 2*len(collection) {
+		// The entries are already durable in the log; a failed compaction is retried on the next write
+		if err := compactLog(r, name, collection); err != nil {
+			log.Printf("Warning: failed to compact embedded store %s log: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// compactLog atomically rewrites a collection log with one line per document, if the store has a file
+func compactLog[T any](r *EmbeddedRepository, name string, collection map[string]*T) error {
+	if r.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for key, doc := range collection {
+		if err := encoder.Encode(logEntry[T]{Key: key, Doc: doc}); err != nil {
+			return fmt.Errorf("failed to encode %s entry: %w", name, err)
+		}
+	}
+
+	tmpPath := r.logPath(name) + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, r.logPath(name)); err != nil {
+		return err
+	}
+	r.logLines[name] = len(collection)
+	return nil
+}
+
+// loadLog replays a collection log into collection. A crash while appending can leave the last line
+// incomplete; it is ignored.
+func loadLog[T any](path string, collection map[string]*T) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read embedded store log: %w", err)
+	}
+	defer file.Close()
+
+	// Cached embeddings make for long lines
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	var incomplete error
+	for scanner.Scan() {
+		if incomplete != nil {
+			return incomplete
+		}
+		var entry logEntry[T]
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			incomplete = fmt.Errorf("failed to parse embedded store log %s: %w", path, err)
+			continue
+		}
+		if entry.Doc == nil {
+			delete(collection, entry.Key)
+		} else {
+			collection[entry.Key] = entry.Doc
+		}
+	}
+	return scanner.Err()
+}
+
+// clone deep-copies a document so callers cannot mutate the stored state. Documents that do not
+// survive a JSON round trip, such as NaN scores, are refused rather than stored or returned half-copied.
+func clone[T any](v *T) (*T, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy document: %w", err)
+	}
+
+	var c T
+	if err := json.Unmarshal(content, &c); err != nil {
+		return nil, fmt.Errorf("failed to copy document: %w", err)
+	}
+
+	return &c, nil
+}
+
+// updateJob applies fn to a copy of a stored job and commits the copy
+func (r *EmbeddedRepository) updateJob(ctx context.Context, id string, fn func(job *models.EvaluationJob)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.data.Jobs[id]
+	if !ok || !inTenant(ctx, job.OrgID) {
+		return ErrNotFound
+	}
+
+	return r.commitJob(job, fn)
+}
+
+// commitJob applies fn to a copy of a live job and commits the copy; callers must hold the write lock
+func (r *EmbeddedRepository) commitJob(job *models.EvaluationJob, fn func(job *models.EvaluationJob)) error {
+	staged, err := clone(job)
+	if err != nil {
+		return err
+	}
+
+	fn(staged)
+	return r.commit(put(r.data.Jobs, "jobs", staged.ID.Hex(), staged))
+}
+
+// liveJob reports whether a job belongs to the context's organization and is not soft-deleted
+func liveJob(ctx context.Context, job *models.EvaluationJob) bool {
+	return job.DeletedAt == nil && inTenant(ctx, job.OrgID)
+}
+
+func (r *EmbeddedRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Job Repository Methods
+func (r *EmbeddedRepository) CreateJob(ctx context.Context, job *models.EvaluationJob) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &job.OrgID)
+	if job.IdempotencyKey != "" {
+		for _, existing := range r.data.Jobs {
+			if existing.OrgID == job.OrgID && existing.IdempotencyKey == job.IdempotencyKey {
+				return nil, ErrDuplicateIdempotencyKey
+			}
+		}
+	}
+	copied, err := clone(job)
+	if err != nil {
+		return nil, err
+	}
+
+	return job.ID, r.commit(put(r.data.Jobs, "jobs", job.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) FindJobByIdempotencyKey(ctx context.Context, key string) (*models.EvaluationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, job := range r.data.Jobs {
+		if job.IdempotencyKey == key && inTenant(ctx, job.OrgID) {
+			return clone(job)
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *EmbeddedRepository) GetJobByID(ctx context.Context, id string) (*models.EvaluationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.data.Jobs[id]
+	if !ok || !liveJob(ctx, job) {
+		return nil, ErrNotFound
+	}
+
+	return clone(job)
+}
+
+func (r *EmbeddedRepository) GetJobsByIDs(ctx context.Context, ids []string) ([]*models.EvaluationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobs := []*models.EvaluationJob{}
+	for _, id := range ids {
+		if job, ok := r.data.Jobs[id]; ok && liveJob(ctx, job) {
+			copied, err := clone(job)
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, copied)
+		}
+	}
+
+	return jobs, nil
+}
+
+func (r *EmbeddedRepository) UpdateJobStatus(ctx context.Context, id string, status models.JobStatus) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) {
+		now := time.Now()
+		job.Status = status
+		job.UpdatedAt = now
+
+		if status == models.StatusProcessing {
+			job.StartedAt = &now
+		} else if status == models.StatusCompleted || status == models.StatusFailed {
+			job.CompletedAt = &now
+		}
+	})
+}
+
+func (r *EmbeddedRepository) UpdateJobResult(ctx context.Context, id, claimToken string, result *models.EvaluationResult) error {
+	stored, err := clone(result)
+	if err != nil {
+		return err
+	}
+	return r.updateClaimedJob(ctx, id, claimToken, func(job *models.EvaluationJob) {
+		now := time.Now()
+		if job.Result != nil {
+			job.ResultHistory = append(job.ResultHistory, job.Result)
+		}
+		job.Result = stored
+		job.Status = models.StatusCompleted
+		job.UpdatedAt = now
+		job.CompletedAt = &now
+	})
+}
+
+func (r *EmbeddedRepository) UpdateJobError(ctx context.Context, id, claimToken string, errorType models.ErrorType, errorMessage string) error {
+	return r.updateClaimedJob(ctx, id, claimToken, func(job *models.EvaluationJob) {
+		now := time.Now()
+		job.ErrorMessage = errorMessage
+		job.ErrorType = errorType
+		job.Status = models.StatusFailed
+		job.UpdatedAt = now
+		job.CompletedAt = &now
+	})
+}
+
+// updateClaimedJob applies fn to a job still processing under the given claim token, or returns ErrNotFound
+func (r *EmbeddedRepository) updateClaimedJob(ctx context.Context, id, claimToken string, fn func(job *models.EvaluationJob)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.data.Jobs[id]
+	if !ok || !inTenant(ctx, job.OrgID) || !ownsClaim(job, claimToken) {
+		return ErrNotFound
+	}
+
+	return r.commitJob(job, fn)
+}
+
+// UpdateJobSteps replaces a job's pipeline steps
+func (r *EmbeddedRepository) UpdateJobSteps(ctx context.Context, id string, steps []models.JobStep) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) {
+		job.Steps = append([]models.JobStep(nil), steps...)
+		job.UpdatedAt = time.Now()
+	})
+}
+
+// UpdateJobStep replaces one pipeline step, matched by name, on a job
+func (r *EmbeddedRepository) UpdateJobStep(ctx context.Context, id string, step models.JobStep) error {
+	found := false
+	err := r.updateJob(ctx, id, func(job *models.EvaluationJob) {
+		for i := range job.Steps {
+			if job.Steps[i].Name == step.Name {
+				job.Steps[i] = step
+				job.UpdatedAt = time.Now()
+				found = true
+			}
+		}
+	})
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+// UpdateJobUsage records the LLM usage of a job's latest evaluation attempt
+func (r *EmbeddedRepository) UpdateJobUsage(ctx context.Context, id string, usage *models.JobUsage) error {
+	stored, err := clone(usage)
+	if err != nil {
+		return err
+	}
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) {
+		job.Usage = stored
+		job.UpdatedAt = time.Now()
+	})
+}
+
+// UpdateJobRetrieval stores the context retrieved for each step of a job's evaluation
+func (r *EmbeddedRepository) UpdateJobRetrieval(ctx context.Context, id string, retrieval []models.StepRetrieval) error {
+	stored, err := clone(&retrieval)
+	if err != nil {
+		return err
+	}
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) {
+		job.Retrieval = *stored
+		job.UpdatedAt = time.Now()
+	})
+}
+
+// UpdateJobParsedCV stores the structured content of a job's CV
+func (r *EmbeddedRepository) UpdateJobParsedCV(ctx context.Context, id string, parsed *models.ParsedResume) error {
+	stored, err := clone(parsed)
+	if err != nil {
+		return err
+	}
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) {
+		job.ParsedCV = stored
+		job.UpdatedAt = time.Now()
+	})
+}
+
+// UpdateJobProjectEmbedding stores the embedding of a job's project report
+func (r *EmbeddedRepository) UpdateJobProjectEmbedding(ctx context.Context, id string, embedding []float64, model string) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) {
+		job.ProjectEmbedding = append([]float64(nil), embedding...)
+		job.ProjectEmbeddingModel = model
+		job.UpdatedAt = time.Now()
+	})
+}
+
+func (r *EmbeddedRepository) IncrementRetryCount(ctx context.Context, id string) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) {
+		job.RetryCount++
+		job.UpdatedAt = time.Now()
+	})
+}
+
+func (r *EmbeddedRepository) GetPendingJobs(ctx context.Context) ([]*models.EvaluationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobs []*models.EvaluationJob
+	for _, job := range r.data.Jobs {
+		if (job.Status == models.StatusQueued || job.Status == models.StatusProcessing) && liveJob(ctx, job) {
+			copied, err := clone(job)
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, copied)
+		}
+	}
+
+	return jobs, nil
+}
+
+// GetStuckJobs returns processing jobs that started before the given time
+func (r *EmbeddedRepository) GetStuckJobs(ctx context.Context, startedBefore time.Time) ([]*models.EvaluationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobs []*models.EvaluationJob
+	for _, job := range r.data.Jobs {
+		if job.Status == models.StatusProcessing && job.StartedAt != nil && job.StartedAt.Before(startedBefore) && liveJob(ctx, job) {
+			copied, err := clone(job)
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, copied)
+		}
+	}
+
+	return jobs, nil
+}
+
+// RequeueStuckJob moves a processing job back to queued and counts the attempt. It returns ErrNotFound
+// when the job is no longer processing.
+func (r *EmbeddedRepository) RequeueStuckJob(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.data.Jobs[id]
+	if !ok || job.Status != models.StatusProcessing || !inTenant(ctx, job.OrgID) {
+		return ErrNotFound
+	}
+
+	return r.commitJob(job, func(job *models.EvaluationJob) {
+		job.Status = models.StatusQueued
+		job.ClaimToken = ""
+		job.StartedAt = nil
+		job.Steps = nil
+		job.RetryCount++
+		job.UpdatedAt = time.Now()
+	})
+}
+
+// ReopenJob queues a completed or failed live job again for a re-evaluation with the given content hash,
+// moving its result to the history. It returns ErrNotFound when the job is not completed or failed.
+func (r *EmbeddedRepository) ReopenJob(ctx context.Context, id, contentHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.data.Jobs[id]
+	if !ok || !liveJob(ctx, job) || (job.Status != models.StatusCompleted && job.Status != models.StatusFailed) {
+		return ErrNotFound
+	}
+
+	return r.commitJob(job, func(job *models.EvaluationJob) {
+		reopenJob(job, contentHash)
+	})
+}
+
+// ClaimJob moves a queued job to processing. It returns ErrNotFound when the job is not queued, e.g.
+// because another worker claimed it first.
+func (r *EmbeddedRepository) ClaimJob(ctx context.Context, id string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.data.Jobs[id]
+	if !ok || job.Status != models.StatusQueued || !inTenant(ctx, job.OrgID) {
+		return "", ErrNotFound
+	}
+
+	claimToken := newClaimToken()
+	err := r.commitJob(job, func(job *models.EvaluationJob) {
+		now := time.Now()
+		job.Status = models.StatusProcessing
+		job.ClaimToken = claimToken
+		job.StartedAt = &now
+		job.UpdatedAt = now
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return claimToken, nil
+}
+
+// GetUndispatchedJobs returns the live jobs, last updated before the given time, whose enqueue is still pending
+func (r *EmbeddedRepository) GetUndispatchedJobs(ctx context.Context, updatedBefore time.Time) ([]*models.EvaluationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobs []*models.EvaluationJob
+	for _, job := range r.data.Jobs {
+		if job.EnqueuePending && job.UpdatedAt.Before(updatedBefore) && liveJob(ctx, job) {
+			copied, err := clone(job)
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, copied)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].UpdatedAt.Before(jobs[j].UpdatedAt) })
+
+	return jobs, nil
+}
+
+// MarkJobEnqueued clears a job's pending enqueue once its ID is on the queue
+func (r *EmbeddedRepository) MarkJobEnqueued(ctx context.Context, id string) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) {
+		job.EnqueuePending = false
+	})
+}
+
+// FindRecentJobByCVHash returns the newest non-failed job created since the given time for the same CV
+// and job description
+func (r *EmbeddedRepository) FindRecentJobByCVHash(ctx context.Context, cvHash, jobDescriptionID string, sandbox bool, since time.Time) (*models.EvaluationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *models.EvaluationJob
+	for _, job := range r.data.Jobs {
+		if job.CVHash != cvHash || job.JobDescriptionID != jobDescriptionID || job.Sandbox != sandbox ||
+			job.Status == models.StatusFailed || job.CreatedAt.Before(since) || !liveJob(ctx, job) {
+			continue
+		}
+		if latest == nil || job.CreatedAt.After(latest.CreatedAt) {
+			latest = job
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+
+	return clone(latest)
+}
+
+func (r *EmbeddedRepository) FindProjectEmbeddings(ctx context.Context, model string, sandbox bool) ([]*ProjectEmbedding, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var embeddings []*ProjectEmbedding
+	for _, job := range r.data.Jobs {
+		if job.ProjectEmbeddingModel != model || len(job.ProjectEmbedding) == 0 || job.Sandbox != sandbox || !liveJob(ctx, job) {
+			continue
+		}
+		embeddings = append(embeddings, &ProjectEmbedding{
+			JobID:       job.ID.Hex(),
+			CandidateID: job.CandidateID,
+			CVHash:      job.CVHash,
+			ProjectHash: job.ProjectHash,
+			Embedding:   append([]float64(nil), job.ProjectEmbedding...),
+		})
+	}
+
+	return embeddings, nil
+}
+
+// FindCompletedJobByContentHash returns the newest job completed since the given time with the same content hash
+func (r *EmbeddedRepository) FindCompletedJobByContentHash(ctx context.Context, contentHash string, since time.Time) (*models.EvaluationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *models.EvaluationJob
+	for _, job := range r.data.Jobs {
+		if job.ContentHash != contentHash || job.Status != models.StatusCompleted || job.CompletedAt == nil ||
+			job.CompletedAt.Before(since) || !liveJob(ctx, job) {
+			continue
+		}
+		if latest == nil || job.CompletedAt.After(*latest.CompletedAt) {
+			latest = job
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+
+	return clone(latest)
+}
+
+func (r *EmbeddedRepository) GetJobsWithFilters(ctx context.Context, opts JobListOptions) ([]*models.EvaluationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobs := r.filterJobs(ctx, opts)
+	if opts.After != "" {
+		// The cursor job may no longer match the filters, e.g. after its status changed
+		after, ok := r.data.Jobs[opts.After]
+		if !ok || !liveJob(ctx, after) {
+			return nil, ErrInvalidCursor
+		}
+
+		i := sort.Search(len(jobs), func(i int) bool {
+			return jobBefore(after, jobs[i], opts)
+		})
+		jobs = jobs[i:]
+	}
+
+	return paginate(jobs, opts.Offset, opts.Limit)
+}
+
+func (r *EmbeddedRepository) CountJobs(ctx context.Context, opts JobListOptions) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return int64(len(r.filterJobs(ctx, opts))), nil
+}
+
+// StreamJobs calls fn with each matching job in order. The lock is only held while a job is
+// copied, so slow consumers do not block writers; jobs deleted meanwhile are skipped.
+func (r *EmbeddedRepository) StreamJobs(ctx context.Context, opts JobListOptions, fn func(*models.EvaluationJob) error) error {
+	r.mu.RLock()
+	var ids []string
+	for _, job := range r.filterJobs(ctx, opts) {
+		ids = append(ids, job.ID.Hex())
+	}
+	r.mu.RUnlock()
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		r.mu.RLock()
+		job, ok := r.data.Jobs[id]
+		ok = ok && job.DeletedAt == nil
+		var err error
+		if ok {
+			job, err = clone(job)
+		}
+		r.mu.RUnlock()
+
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := fn(job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *EmbeddedRepository) GetScoreCounts(ctx context.Context, cohort ScoreCohort, score float64) (*ScoreCounts, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := &ScoreCounts{}
+	for _, job := range r.data.Jobs {
+		if !liveJob(ctx, job) || job.Status != models.StatusCompleted || job.Result == nil {
+			continue
+		}
+		if job.JobDescriptionID != cohort.JobDescriptionID || job.Sandbox != cohort.Sandbox {
+			continue
+		}
+
+		counts.Total++
+		switch {
+		case job.Result.OverallScore < score:
+			counts.Below++
+		case job.Result.OverallScore == score:
+			counts.Equal++
+		}
+	}
+
+	return counts, nil
+}
+
+// filterJobs returns the jobs matching opts in sort order, without paging; callers must hold the lock
+func (r *EmbeddedRepository) filterJobs(ctx context.Context, opts JobListOptions) []*models.EvaluationJob {
+	var jobs []*models.EvaluationJob
+	for _, job := range r.data.Jobs {
+		if !liveJob(ctx, job) {
+			continue
+		}
+		if opts.Status != "" && string(job.Status) != opts.Status {
+			continue
+		}
+		if opts.CandidateID != "" && job.CandidateID != opts.CandidateID {
+			continue
+		}
+		if !jobMatchesSearch(job, opts) {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobBefore(jobs[i], jobs[j], opts)
+	})
+
+	return jobs
+}
+
+// jobMatchesSearch applies the date, score, name and feedback filters of opts to a job
+func jobMatchesSearch(job *models.EvaluationJob, opts JobListOptions) bool {
+	if !opts.CreatedFrom.IsZero() && job.CreatedAt.Before(opts.CreatedFrom) {
+		return false
+	}
+	if !opts.CreatedBefore.IsZero() && !job.CreatedAt.Before(opts.CreatedBefore) {
+		return false
+	}
+
+	for _, r := range opts.ScoreRanges {
+		if job.Result == nil {
+			return false
+		}
+		score := resultScore(job.Result, r.Field)
+		if r.Min != nil && score < *r.Min || r.Max != nil && score > *r.Max {
+			return false
+		}
+	}
+
+	if opts.CandidateName != "" && !strings.Contains(strings.ToLower(job.CandidateName), strings.ToLower(opts.CandidateName)) {
+		return false
+	}
+
+	if terms := searchTerms(opts.Search); len(terms) > 0 {
+		if job.Result == nil {
+			return false
+		}
+		text := strings.ToLower(job.Result.CVFeedback + " " + job.Result.ProjectFeedback + " " + job.Result.OverallSummary)
+		for _, term := range terms {
+			if strings.Contains(text, term) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// jobBefore reports whether job a is listed before job b. Ties are ordered by ID like the other
+// backends, so cursors are stable.
+func jobBefore(a, b *models.EvaluationJob, opts JobListOptions) bool {
+	keyA, keyB := jobSortKey(a, opts.sortField()), jobSortKey(b, opts.sortField())
+	if opts.ascending() {
+		return keyA < keyB || keyA == keyB && a.ID.Hex() < b.ID.Hex()
+	}
+	return keyA > keyB || keyA == keyB && a.ID.Hex() > b.ID.Hex()
+}
+
+// jobSortKey returns the numeric value used to order jobs by the given field
+func jobSortKey(job *models.EvaluationJob, field string) float64 {
+	switch field {
+	case "completed_at":
+		if job.CompletedAt == nil {
+			return 0
+		}
+		return float64(job.CompletedAt.UnixNano())
+	case "result.overall_score":
+		if job.Result == nil {
+			return 0
+		}
+		return job.Result.OverallScore
+	default:
+		return float64(job.CreatedAt.UnixNano())
+	}
+}
+
+// paginate applies offset/limit and clones the selected jobs
+func paginate(jobs []*models.EvaluationJob, offset, limit int) ([]*models.EvaluationJob, error) {
+	if offset > len(jobs) {
+		offset = len(jobs)
+	}
+	jobs = jobs[offset:]
+
+	if limit > 0 && limit < len(jobs) {
+		jobs = jobs[:limit]
+	}
+
+	page := make([]*models.EvaluationJob, 0, len(jobs))
+	for _, job := range jobs {
+		copied, err := clone(job)
+		if err != nil {
+			return nil, err
+		}
+		page = append(page, copied)
+	}
+
+	return page, nil
+}
+
+func (f JobBulkFilter) matches(job *models.EvaluationJob) bool {
+	if f.Status != "" && string(job.Status) != f.Status {
+		return false
+	}
+	if !f.OlderThan.IsZero() && !job.CreatedAt.Before(f.OlderThan) {
+		return false
+	}
+	if !f.NewerThan.IsZero() && job.CreatedAt.Before(f.NewerThan) {
+		return false
+	}
+	if f.CandidateID != "" && job.CandidateID != f.CandidateID {
+		return false
+	}
+	if f.IDs != nil && !slices.Contains(f.IDs, job.ID.Hex()) {
+		return false
+	}
+	if !f.DeletedBefore.IsZero() && (job.DeletedAt == nil || !job.DeletedAt.Before(f.DeletedBefore)) {
+		return false
+	}
+	if f.WithContent {
+		if f.Status == "" && job.Status != models.StatusCompleted && job.Status != models.StatusFailed {
+			return false
+		}
+		if job.ContentErasedAt != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// SoftDeleteJob marks a job deleted; it is hidden from every query until purged
+func (r *EmbeddedRepository) SoftDeleteJob(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.data.Jobs[id]
+	if !ok || !liveJob(ctx, job) {
+		return ErrNotFound
+	}
+
+	return r.commitJob(job, func(job *models.EvaluationJob) {
+		now := time.Now()
+		job.DeletedAt = &now
+		job.UpdatedAt = now
+	})
+}
+
+// jobStore returns the collection holding live or archived jobs and its name
+func (r *EmbeddedRepository) jobStore(archived bool) (map[string]*models.EvaluationJob, string) {
+	if archived {
+		return r.data.ArchivedJobs, "archived_jobs"
+	}
+	return r.data.Jobs, "jobs"
+}
+
+func (r *EmbeddedRepository) FindJobs(ctx context.Context, filter JobBulkFilter) ([]*models.EvaluationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, _ := r.jobStore(filter.Archived)
+	jobs := []*models.EvaluationJob{}
+	for _, job := range stored {
+		if filter.matches(job) && inTenant(ctx, job.OrgID) {
+			copied, err := clone(job)
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, copied)
+		}
+	}
+
+	return jobs, nil
+}
+
+func (r *EmbeddedRepository) EraseJobContent(ctx context.Context, ids []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var changes []change
+	for _, id := range ids {
+		jobs, collection, job, ok := r.findJob(id)
+		if !ok || !inTenant(ctx, job.OrgID) {
+			continue
+		}
+		staged, err := clone(job)
+		if err != nil {
+			return 0, err
+		}
+		staged.CVContent = ""
+		staged.ProjectContent = ""
+		staged.ParsedCV = nil
+		staged.ProjectEmbedding = nil
+		staged.ProjectEmbeddingModel = ""
+		for _, result := range staged.Results() {
+			result.EraseContent()
+		}
+		staged.ContentErasedAt = &now
+		staged.UpdatedAt = now
+		changes = append(changes, put(jobs, collection, id, staged))
+	}
+
+	return int64(len(changes)), r.commit(changes...)
+}
+
+func (r *EmbeddedRepository) AnonymizeJob(ctx context.Context, id string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs, collection, job, ok := r.findJob(id)
+	if !ok || !inTenant(ctx, job.OrgID) {
+		return ErrNotFound
+	}
+	staged, err := clone(job)
+	if err != nil {
+		return err
+	}
+	staged.Anonymize(now)
+
+	return r.commit(put(jobs, collection, id, staged))
+}
+
+// findJob looks a job up among the live jobs, then the archived ones, and returns its collection and name
+func (r *EmbeddedRepository) findJob(id string) (map[string]*models.EvaluationJob, string, *models.EvaluationJob, bool) {
+	for _, archived := range []bool{false, true} {
+		jobs, collection := r.jobStore(archived)
+		if job, ok := jobs[id]; ok {
+			return jobs, collection, job, true
+		}
+	}
+	return nil, "", nil, false
+}
+
+func (r *EmbeddedRepository) FindJobIDs(ctx context.Context, filter JobBulkFilter) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := []string{}
+	for id, job := range r.data.Jobs {
+		if filter.matches(job) && inTenant(ctx, job.OrgID) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (r *EmbeddedRepository) DeleteJobs(ctx context.Context, filter JobBulkFilter) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs, collection := r.jobStore(filter.Archived)
+	var changes []change
+	for id, job := range jobs {
+		if filter.matches(job) && inTenant(ctx, job.OrgID) {
+			changes = append(changes, remove(jobs, collection, id))
+		}
+	}
+
+	return int64(len(changes)), r.commit(changes...)
+}
+
+func (r *EmbeddedRepository) ArchiveJobs(ctx context.Context, filter JobBulkFilter) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var archived int64
+	var changes []change
+	for id, job := range r.data.Jobs {
+		if filter.matches(job) && inTenant(ctx, job.OrgID) {
+			changes = append(changes, put(r.data.ArchivedJobs, "archived_jobs", id, job), remove(r.data.Jobs, "jobs", id))
+			archived++
+		}
+	}
+
+	return archived, r.commit(changes...)
+}
+
+// Batch Job Repository Methods
+func (r *EmbeddedRepository) CreateBatchJob(ctx context.Context, batch *models.BatchJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if batch.ID.IsZero() {
+		batch.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &batch.OrgID)
+	copied, err := clone(batch)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.BatchJobs, "batch_jobs", batch.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) GetBatchJob(ctx context.Context, id string) (*models.BatchJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	batch, ok := r.data.BatchJobs[id]
+	if !ok || !inTenant(ctx, batch.OrgID) {
+		return nil, ErrNotFound
+	}
+
+	return clone(batch)
+}
+
+func (r *EmbeddedRepository) AnonymizeBatchItems(ctx context.Context, batchID string, jobIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.data.BatchJobs[batchID]
+	if !ok || !inTenant(ctx, stored.OrgID) {
+		return ErrNotFound
+	}
+	batch, err := clone(stored)
+	if err != nil {
+		return err
+	}
+	for i := range batch.Items {
+		if item := &batch.Items[i]; slices.Contains(jobIDs, item.JobID) {
+			item.CandidateID = ""
+			item.CVFile = ""
+			item.ProjectFile = ""
+			item.Source = ""
+		}
+	}
+
+	return r.commit(put(r.data.BatchJobs, "batch_jobs", batchID, batch))
+}
+
+// Job Description Repository Methods
+func (r *EmbeddedRepository) CreateJobDescription(ctx context.Context, jobDesc *models.JobDescription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if jobDesc.ID.IsZero() {
+		jobDesc.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &jobDesc.OrgID)
+	copied, err := clone(jobDesc)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.JobDescriptions, "job_descriptions", jobDesc.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) GetJobDescription(ctx context.Context, id string) (*models.JobDescription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobDesc, ok := r.data.JobDescriptions[id]
+	if !ok || !inShared(ctx, jobDesc.OrgID) {
+		return nil, ErrNotFound
+	}
+
+	return clone(jobDesc)
+}
+
+func (r *EmbeddedRepository) GetAllJobDescriptions(ctx context.Context) ([]*models.JobDescription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobDescs []*models.JobDescription
+	for _, jobDesc := range r.data.JobDescriptions {
+		if inShared(ctx, jobDesc.OrgID) {
+			copied, err := clone(jobDesc)
+			if err != nil {
+				return nil, err
+			}
+			jobDescs = append(jobDescs, copied)
+		}
+	}
+
+	return jobDescs, nil
+}
+
+func (r *EmbeddedRepository) GetJobDescriptionVectors(ctx context.Context) ([]*models.JobDescription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobDescs []*models.JobDescription
+	for _, jobDesc := range r.data.JobDescriptions {
+		if inShared(ctx, jobDesc.OrgID) {
+			jobDescs = append(jobDescs, &models.JobDescription{
+				ID:                  jobDesc.ID,
+				Embedding:           append([]float64(nil), jobDesc.Embedding...),
+				OrgID:               jobDesc.OrgID,
+				EmbeddingModel:      jobDesc.EmbeddingModel,
+				EmbeddingDimensions: jobDesc.EmbeddingDimensions,
+				EmbeddingNorm:       jobDesc.EmbeddingNorm,
+			})
+		}
+	}
+
+	return jobDescs, nil
+}
+
+// UpdateJobDescription replaces a job description's content and vector
+func (r *EmbeddedRepository) UpdateJobDescription(ctx context.Context, jobDesc *models.JobDescription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.data.JobDescriptions[jobDesc.ID.Hex()]; !ok || !inTenant(ctx, stored.OrgID) {
+		return ErrNotFound
+	}
+	copied, err := clone(jobDesc)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.JobDescriptions, "job_descriptions", jobDesc.ID.Hex(), copied))
+}
+
+// UpdateJobDescriptionEmbedding replaces a job description's vector and records the model that produced it
+func (r *EmbeddedRepository) UpdateJobDescriptionEmbedding(ctx context.Context, id string, embedding []float64, model string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.data.JobDescriptions[id]
+	if !ok || !inTenant(ctx, stored.OrgID) {
+		return ErrNotFound
+	}
+	jobDesc, err := clone(stored)
+	if err != nil {
+		return err
+	}
+	jobDesc.Embedding = append([]float64(nil), embedding...)
+	jobDesc.EmbeddingModel = model
+	jobDesc.EmbeddingDimensions = len(embedding)
+	jobDesc.EmbeddingNorm = embeddingNorm(embedding)
+
+	return r.commit(put(r.data.JobDescriptions, "job_descriptions", id, jobDesc))
+}
+
+func (r *EmbeddedRepository) UpdateJobDescriptionThresholds(ctx context.Context, id string, thresholds *models.RecommendationThresholds) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.data.JobDescriptions[id]
+	if !ok || !inTenant(ctx, stored.OrgID) {
+		return ErrNotFound
+	}
+	jobDesc, err := clone(stored)
+	if err != nil {
+		return err
+	}
+	jobDesc.RecommendationThresholds = nil
+	if thresholds != nil {
+		copied, err := clone(thresholds)
+		if err != nil {
+			return err
+		}
+		jobDesc.RecommendationThresholds = copied
+	}
+
+	return r.commit(put(r.data.JobDescriptions, "job_descriptions", id, jobDesc))
+}
+
+func (r *EmbeddedRepository) DeleteJobDescription(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if jobDesc, ok := r.data.JobDescriptions[id]; !ok || !inTenant(ctx, jobDesc.OrgID) {
+		return ErrNotFound
+	}
+
+	return r.commit(remove(r.data.JobDescriptions, "job_descriptions", id))
+}
+
+// Reference Document Repository Methods
+func (r *EmbeddedRepository) CreateReferenceDocument(ctx context.Context, doc *models.ReferenceDocument) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if doc.ID.IsZero() {
+		doc.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &doc.OrgID)
+	copied, err := clone(doc)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.ReferenceDocs, "reference_documents", doc.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) GetReferenceDocument(ctx context.Context, id string) (*models.ReferenceDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	doc, ok := r.data.ReferenceDocs[id]
+	if !ok || !inShared(ctx, doc.OrgID) {
+		return nil, ErrNotFound
+	}
+
+	return clone(doc)
+}
+
+func (r *EmbeddedRepository) GetReferenceDocuments(ctx context.Context, docType string) ([]*models.ReferenceDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var docs []*models.ReferenceDocument
+	for _, doc := range r.data.ReferenceDocs {
+		if inShared(ctx, doc.OrgID) && (docType == "" || doc.Type == docType) {
+			copied, err := clone(doc)
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, copied)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].ID.Hex() < docs[j].ID.Hex()
+	})
+
+	return docs, nil
+}
+
+func (r *EmbeddedRepository) DeleteReferenceDocument(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if doc, ok := r.data.ReferenceDocs[id]; !ok || !inTenant(ctx, doc.OrgID) {
+		return ErrNotFound
+	}
+
+	return r.commit(remove(r.data.ReferenceDocs, "reference_documents", id))
+}
+
+// Document Chunk Repository Methods
+func (r *EmbeddedRepository) ReplaceDocumentChunks(ctx context.Context, documentID string, chunks []*models.DocumentChunk) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changes := r.documentChunkRemovals(ctx, documentID)
+	for _, chunk := range chunks {
+		copied, err := clone(chunk)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, put(r.data.DocumentChunks, "document_chunks", chunk.ID, copied))
+	}
+
+	return r.commit(changes...)
+}
+
+func (r *EmbeddedRepository) GetDocumentChunks(ctx context.Context, documentID string) ([]*models.DocumentChunk, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var chunks []*models.DocumentChunk
+	for _, chunk := range r.data.DocumentChunks {
+		if chunk.DocumentID == documentID && inShared(ctx, chunk.OrgID) {
+			copied, err := clone(chunk)
+			if err != nil {
+				return nil, err
+			}
+			chunks = append(chunks, copied)
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].Index < chunks[j].Index
+	})
+
+	return chunks, nil
+}
+
+func (r *EmbeddedRepository) GetAllDocumentChunks(ctx context.Context) ([]*models.DocumentChunk, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var chunks []*models.DocumentChunk
+	for _, chunk := range r.data.DocumentChunks {
+		if inShared(ctx, chunk.OrgID) {
+			copied, err := clone(chunk)
+			if err != nil {
+				return nil, err
+			}
+			chunks = append(chunks, copied)
+		}
+	}
+
+	return chunks, nil
+}
+
+func (r *EmbeddedRepository) DeleteDocumentChunks(ctx context.Context, documentID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.commit(r.documentChunkRemovals(ctx, documentID)...)
+}
+
+// documentChunkRemovals stages the removal of a document's chunks; callers must hold the write lock
+func (r *EmbeddedRepository) documentChunkRemovals(ctx context.Context, documentID string) []change {
+	var changes []change
+	for id, chunk := range r.data.DocumentChunks {
+		if chunk.DocumentID == documentID && inTenant(ctx, chunk.OrgID) {
+			changes = append(changes, remove(r.data.DocumentChunks, "document_chunks", id))
+		}
+	}
+	return changes
+}
+
+// Index Rebuild Repository Methods
+func (r *EmbeddedRepository) SaveIndexRebuild(ctx context.Context, rebuild *models.IndexRebuild) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rebuild.ID.IsZero() {
+		rebuild.ID = primitive.NewObjectID()
+	}
+	copied, err := clone(rebuild)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.IndexRebuilds, "index_rebuilds", rebuild.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) GetLatestIndexRebuild(ctx context.Context) (*models.IndexRebuild, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *models.IndexRebuild
+	for _, rebuild := range r.data.IndexRebuilds {
+		if latest == nil || rebuild.StartedAt.After(latest.StartedAt) {
+			latest = rebuild
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+
+	return clone(latest)
+}
+
+func (r *EmbeddedRepository) SaveFairnessReport(ctx context.Context, report *models.FairnessReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if report.ID.IsZero() {
+		report.ID = primitive.NewObjectID()
+	}
+	copied, err := clone(report)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.FairnessReports, "fairness_reports", report.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) GetFairnessReport(ctx context.Context, id string) (*models.FairnessReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	report, ok := r.data.FairnessReports[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return clone(report)
+}
+
+func (r *EmbeddedRepository) GetFairnessReports(ctx context.Context) ([]*models.FairnessReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reports := make([]*models.FairnessReport, 0, len(r.data.FairnessReports))
+	for _, report := range r.data.FairnessReports {
+		copied, err := clone(report)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, copied)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].StartedAt.After(reports[j].StartedAt)
+	})
+
+	return reports, nil
+}
+
+func (r *EmbeddedRepository) SaveExperiment(ctx context.Context, experiment *models.Experiment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if experiment.ID.IsZero() {
+		experiment.ID = primitive.NewObjectID()
+	}
+	copied, err := clone(experiment)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.Experiments, "experiments", experiment.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) GetExperiment(ctx context.Context, id string) (*models.Experiment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	experiment, ok := r.data.Experiments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return clone(experiment)
+}
+
+func (r *EmbeddedRepository) GetExperiments(ctx context.Context) ([]*models.Experiment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	experiments := make([]*models.Experiment, 0, len(r.data.Experiments))
+	for _, experiment := range r.data.Experiments {
+		copied, err := clone(experiment)
+		if err != nil {
+			return nil, err
+		}
+		experiments = append(experiments, copied)
+	}
+
+	sort.Slice(experiments, func(i, j int) bool {
+		return experiments[i].CreatedAt.After(experiments[j].CreatedAt)
+	})
+
+	return experiments, nil
+}
+
+// Golden Job Repository Methods
+func (r *EmbeddedRepository) CreateGoldenJob(ctx context.Context, golden *models.GoldenJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if golden.ID.IsZero() {
+		golden.ID = primitive.NewObjectID()
+	}
+	copied, err := clone(golden)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.GoldenJobs, "golden_jobs", golden.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) GetAllGoldenJobs(ctx context.Context) ([]*models.GoldenJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var goldens []*models.GoldenJob
+	for _, golden := range r.data.GoldenJobs {
+		copied, err := clone(golden)
+		if err != nil {
+			return nil, err
+		}
+		goldens = append(goldens, copied)
+	}
+
+	return goldens, nil
+}
+
+func (r *EmbeddedRepository) DeleteGoldenJob(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.data.GoldenJobs[id]; !ok {
+		return ErrNotFound
+	}
+
+	return r.commit(remove(r.data.GoldenJobs, "golden_jobs", id))
+}
+
+func (r *EmbeddedRepository) SaveCalibrationRun(ctx context.Context, run *models.GoldenComparisonReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+	copied, err := clone(run)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.CalibrationRuns, "calibration_runs", run.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) GetCalibrationRuns(ctx context.Context, limit int) ([]*models.GoldenComparisonReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	runs := make([]*models.GoldenComparisonReport, 0, len(r.data.CalibrationRuns))
+	for _, run := range r.data.CalibrationRuns {
+		copied, err := clone(run)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, copied)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+
+	return runs, nil
+}
+
+// Prompt Template Repository Methods
+func (r *EmbeddedRepository) GetPromptTemplate(ctx context.Context, name string) (*models.PromptTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tmpl, ok := r.data.PromptTemplates[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return clone(tmpl)
+}
+
+func (r *EmbeddedRepository) GetAllPromptTemplates(ctx context.Context) ([]*models.PromptTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var templates []*models.PromptTemplate
+	for _, tmpl := range r.data.PromptTemplates {
+		copied, err := clone(tmpl)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, copied)
+	}
+
+	return templates, nil
+}
+
+func (r *EmbeddedRepository) UpsertPromptTemplate(ctx context.Context, tmpl *models.PromptTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied, err := clone(tmpl)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.PromptTemplates, "prompt_templates", tmpl.Name, copied))
+}
+
+func (r *EmbeddedRepository) DeletePromptTemplate(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.data.PromptTemplates[name]; !ok {
+		return ErrNotFound
+	}
+
+	return r.commit(remove(r.data.PromptTemplates, "prompt_templates", name))
+}
+
+func (r *EmbeddedRepository) CreatePromptTemplateVersion(ctx context.Context, version *models.PromptTemplateVersion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	version.ID = promptVersionID(version.Name, version.Version)
+	if _, ok := r.data.PromptVersions[version.ID]; ok {
+		return fmt.Errorf("prompt template %s already exists", version.ID)
+	}
+	copied, err := clone(version)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.PromptVersions, "prompt_template_versions", version.ID, copied))
+}
+
+func (r *EmbeddedRepository) GetPromptTemplateVersion(ctx context.Context, name string, version int) (*models.PromptTemplateVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tmpl, ok := r.data.PromptVersions[promptVersionID(name, version)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return clone(tmpl)
+}
+
+func (r *EmbeddedRepository) GetPromptTemplateVersions(ctx context.Context, name string) ([]*models.PromptTemplateVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var versions []*models.PromptTemplateVersion
+	for _, tmpl := range r.data.PromptVersions {
+		if tmpl.Name == name {
+			copied, err := clone(tmpl)
+			if err != nil {
+				return nil, err
+			}
+			versions = append(versions, copied)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version > versions[j].Version
+	})
+
+	return versions, nil
+}
+
+// Scoring Rubric Repository Methods
+func (r *EmbeddedRepository) CreateScoringRubric(ctx context.Context, rubric *models.ScoringRubric) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rubric.ID.IsZero() {
+		rubric.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &rubric.OrgID)
+	copied, err := clone(rubric)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.ScoringRubrics, "scoring_rubrics", rubric.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) GetScoringRubric(ctx context.Context, id string) (*models.ScoringRubric, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rubric, ok := r.data.ScoringRubrics[id]
+	if !ok || !inShared(ctx, rubric.OrgID) {
+		return nil, ErrNotFound
+	}
+
+	return clone(rubric)
+}
+
+func (r *EmbeddedRepository) GetDefaultScoringRubric(ctx context.Context) (*models.ScoringRubric, error) {
+	return r.GetScoringRubricByName(ctx, "default")
+}
+
+// GetScoringRubricByName prefers the organization's own rubric over a global one with the same name
+func (r *EmbeddedRepository) GetScoringRubricByName(ctx context.Context, name string) (*models.ScoringRubric, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *models.ScoringRubric
+	for _, rubric := range r.data.ScoringRubrics {
+		if rubric.Name != name || !inShared(ctx, rubric.OrgID) {
+			continue
+		}
+		if found == nil || rubric.OrgID > found.OrgID {
+			found = rubric
+		}
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+
+	return clone(found)
+}
+
+// GetAllScoringRubrics lists the organization's rubrics and the global ones
+func (r *EmbeddedRepository) GetAllScoringRubrics(ctx context.Context) ([]*models.ScoringRubric, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rubrics []*models.ScoringRubric
+	for _, rubric := range r.data.ScoringRubrics {
+		if inShared(ctx, rubric.OrgID) {
+			copied, err := clone(rubric)
+			if err != nil {
+				return nil, err
+			}
+			rubrics = append(rubrics, copied)
+		}
+	}
+
+	return rubrics, nil
+}
+
+// Organization Repository Methods
+func (r *EmbeddedRepository) CreateOrganization(ctx context.Context, org *models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if org.ID.IsZero() {
+		org.ID = primitive.NewObjectID()
+	}
+	copied, err := clone(org)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.Organizations, "organizations", org.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) GetOrganization(ctx context.Context, id string) (*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	org, ok := r.data.Organizations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return clone(org)
+}
+
+func (r *EmbeddedRepository) GetOrganizationByAPIKeyHash(ctx context.Context, hash string) (*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, org := range r.data.Organizations {
+		if org.APIKeyHash == hash {
+			return clone(org)
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *EmbeddedRepository) GetAllOrganizations(ctx context.Context) ([]*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var orgs []*models.Organization
+	for _, org := range r.data.Organizations {
+		copied, err := clone(org)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, copied)
+	}
+
+	return orgs, nil
+}
+
+func (r *EmbeddedRepository) UpdateOrganization(ctx context.Context, org *models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.data.Organizations[org.ID.Hex()]; !ok {
+		return ErrNotFound
+	}
+	copied, err := clone(org)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.Organizations, "organizations", org.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) GetCachedEmbedding(ctx context.Context, key string) (*models.CachedEmbedding, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.data.EmbeddingCache[key]
+	if !ok || !entry.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+
+	return clone(entry)
+}
+
+// SaveCachedEmbedding stores an entry and drops expired ones, which the embedded store has no TTL index for
+func (r *EmbeddedRepository) SaveCachedEmbedding(ctx context.Context, entry *models.CachedEmbedding) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var entries []logEntry[models.CachedEmbedding]
+	for key, cached := range r.data.EmbeddingCache {
+		if !cached.ExpiresAt.After(now) {
+			entries = append(entries, logEntry[models.CachedEmbedding]{Key: key})
+		}
+	}
+	copied, err := clone(entry)
+	if err != nil {
+		return err
+	}
+	entries = append(entries, logEntry[models.CachedEmbedding]{Key: entry.Key, Doc: copied})
+
+	return appendLog(r, embeddingCacheLog, r.data.EmbeddingCache, entries...)
+}
+
+func (r *EmbeddedRepository) DeleteCachedEmbeddings(ctx context.Context, keys []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entries []logEntry[models.CachedEmbedding]
+	for _, key := range keys {
+		if _, ok := r.data.EmbeddingCache[key]; ok {
+			entries = append(entries, logEntry[models.CachedEmbedding]{Key: key})
+		}
+	}
+
+	return int64(len(entries)), appendLog(r, embeddingCacheLog, r.data.EmbeddingCache, entries...)
+}
+
+func (r *EmbeddedRepository) CreateLLMCall(ctx context.Context, call *models.LLMCall) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if call.ID.IsZero() {
+		call.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &call.OrgID)
+	copied, err := clone(call)
+	if err != nil {
+		return err
+	}
+
+	return appendLog(r, llmCallLog, r.data.LLMCalls, logEntry[models.LLMCall]{Key: call.ID.Hex(), Doc: copied})
+}
+
+func (r *EmbeddedRepository) GetLLMCalls(ctx context.Context, filter LLMCallFilter) ([]*models.LLMCall, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	calls := []*models.LLMCall{}
+	for _, call := range r.data.LLMCalls {
+		if !inTenant(ctx, call.OrgID) {
+			continue
+		}
+		if filter.JobID != "" && call.JobID != filter.JobID {
+			continue
+		}
+		if filter.Step != "" && call.Step != filter.Step {
+			continue
+		}
+		copied, err := clone(call)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, copied)
+	}
+
+	sort.Slice(calls, func(i, j int) bool {
+		return calls[i].CreatedAt.After(calls[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(calls) > filter.Limit {
+		calls = calls[:filter.Limit]
+	}
+
+	return calls, nil
+}
+
+func (r *EmbeddedRepository) DeleteLLMCalls(ctx context.Context, jobIDs []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entries []logEntry[models.LLMCall]
+	for id, call := range r.data.LLMCalls {
+		if inTenant(ctx, call.OrgID) && slices.Contains(jobIDs, call.JobID) {
+			entries = append(entries, logEntry[models.LLMCall]{Key: id})
+		}
+	}
+
+	return int64(len(entries)), appendLog(r, llmCallLog, r.data.LLMCalls, entries...)
+}
+
+func (r *EmbeddedRepository) RecordUpload(ctx context.Context, upload *models.Upload) (*models.Upload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stampOrgID(ctx, &upload.OrgID)
+	for _, stored := range r.data.Uploads {
+		if stored.OrgID == upload.OrgID && stored.Filename == upload.Filename {
+			return clone(stored)
+		}
+	}
+
+	if upload.ID.IsZero() {
+		upload.ID = primitive.NewObjectID()
+	}
+	stored, err := clone(upload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.commit(put(r.data.Uploads, "uploads", upload.ID.Hex(), stored)); err != nil {
+		return nil, err
+	}
+	return clone(upload)
+}
+
+func (r *EmbeddedRepository) DeleteUploads(ctx context.Context, filenames []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := map[string]bool{}
+	for _, name := range filenames {
+		names[name] = true
+	}
+
+	var changes []change
+	for id, upload := range r.data.Uploads {
+		if names[upload.Filename] && inTenant(ctx, upload.OrgID) {
+			changes = append(changes, remove(r.data.Uploads, "uploads", id))
+		}
+	}
+
+	return int64(len(changes)), r.commit(changes...)
+}
+
+func (r *EmbeddedRepository) CountUploads(ctx context.Context, filename string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, upload := range r.data.Uploads {
+		if upload.Filename == filename {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *EmbeddedRepository) FindUploadsBefore(ctx context.Context, before time.Time) ([]*models.Upload, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	uploads := []*models.Upload{}
+	for _, upload := range r.data.Uploads {
+		if inTenant(ctx, upload.OrgID) && upload.CreatedAt.Before(before) {
+			copied, err := clone(upload)
+			if err != nil {
+				return nil, err
+			}
+			uploads = append(uploads, copied)
+		}
+	}
+	return uploads, nil
+}
+
+func (r *EmbeddedRepository) FindReferencedFiles(ctx context.Context, filenames []string, excludeJobIDs []string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := map[string]bool{}
+	for _, name := range filenames {
+		wanted[name] = true
+	}
+	excluded := map[string]bool{}
+	for _, id := range excludeJobIDs {
+		excluded[id] = true
+	}
+	referenced := map[string]bool{}
+	for _, jobs := range []map[string]*models.EvaluationJob{r.data.Jobs, r.data.ArchivedJobs} {
+		for id, job := range jobs {
+			if excluded[id] || !inTenant(ctx, job.OrgID) {
+				continue
+			}
+			for _, name := range []string{job.CVFile, job.ProjectFile} {
+				if wanted[name] {
+					referenced[name] = true
+				}
+			}
+		}
+	}
+
+	files := make([]string, 0, len(referenced))
+	for name := range referenced {
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+func (r *EmbeddedRepository) ListUploads(ctx context.Context, limit, offset int) ([]*models.Upload, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	uploads := []*models.Upload{}
+	for _, upload := range r.data.Uploads {
+		if inTenant(ctx, upload.OrgID) {
+			uploads = append(uploads, upload)
+		}
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		if !uploads[i].CreatedAt.Equal(uploads[j].CreatedAt) {
+			return uploads[i].CreatedAt.After(uploads[j].CreatedAt)
+		}
+		return uploads[i].ID.Hex() > uploads[j].ID.Hex()
+	})
+
+	total := int64(len(uploads))
+	uploads = uploads[min(offset, len(uploads)):]
+	if limit > 0 && len(uploads) > limit {
+		uploads = uploads[:limit]
+	}
+	page := make([]*models.Upload, 0, len(uploads))
+	for _, upload := range uploads {
+		copied, err := clone(upload)
+		if err != nil {
+			return nil, 0, err
+		}
+		page = append(page, copied)
+	}
+	return page, total, nil
+}
+
+func (r *EmbeddedRepository) GetUpload(ctx context.Context, id string) (*models.Upload, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	upload, ok := r.data.Uploads[id]
+	if !ok || !inTenant(ctx, upload.OrgID) {
+		return nil, ErrNotFound
+	}
+	return clone(upload)
+}
+
+func (r *EmbeddedRepository) CreateErasureRecord(ctx context.Context, record *models.ErasureRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record.ID.IsZero() {
+		record.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &record.OrgID)
+	copied, err := clone(record)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(put(r.data.ErasureRecords, "erasure_records", record.ID.Hex(), copied))
+}
+
+func (r *EmbeddedRepository) GetErasureRecords(ctx context.Context) ([]*models.ErasureRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	records := []*models.ErasureRecord{}
+	for _, record := range r.data.ErasureRecords {
+		if inTenant(ctx, record.OrgID) {
+			copied, err := clone(record)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, copied)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+
+	return records, nil
+}
+
+// IncrementUsageTotals adds one evaluation attempt's usage to the day's totals of each model
+func (r *EmbeddedRepository) IncrementUsageTotals(ctx context.Context, date, orgID string, usage []models.ModelUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	staged := map[string]*models.UsageTotal{}
+	var changes []change
+	for _, u := range usage {
+		key := date + "|" + orgID + "|" + u.Model
+		total, ok := staged[key]
+		if !ok {
+			total = &models.UsageTotal{Date: date, OrgID: orgID, Model: u.Model}
+			if stored, ok := r.data.UsageTotals[key]; ok {
+				copied, err := clone(stored)
+				if err != nil {
+					return err
+				}
+				total = copied
+			}
+			staged[key] = total
+			changes = append(changes, put(r.data.UsageTotals, "usage_totals", key, total))
+		}
+		total.Evaluations++
+		total.Add(u.TokenUsage)
+	}
+
+	return r.commit(changes...)
+}
+
+// GetUsageTotals returns the usage totals matching filter, ordered by date, organization and model
+func (r *EmbeddedRepository) GetUsageTotals(ctx context.Context, filter UsageFilter) ([]*models.UsageTotal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	totals := []*models.UsageTotal{}
+	for _, total := range r.data.UsageTotals {
+		if filter.From != "" && total.Date < filter.From {
+			continue
+		}
+		if filter.To != "" && total.Date > filter.To {
+			continue
+		}
+		if filter.OrgID != "" && total.OrgID != filter.OrgID {
+			continue
+		}
+		copied, err := clone(total)
+		if err != nil {
+			return nil, err
+		}
+		totals = append(totals, copied)
+	}
+
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Date != totals[j].Date {
+			return totals[i].Date < totals[j].Date
+		}
+		if totals[i].OrgID != totals[j].OrgID {
+			return totals[i].OrgID < totals[j].OrgID
+		}
+		return totals[i].Model < totals[j].Model
+	})
+
+	return totals, nil
+}
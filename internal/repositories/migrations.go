@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration records a versioned schema change. Each backend applies its migrations in version
+// order on startup and records them in a migrations collection, so every change runs once.
+type Migration struct {
+	Version     int       `bson:"_id" json:"version"`
+	Description string    `bson:"description" json:"description"`
+	AppliedAt   time.Time `bson:"applied_at" json:"applied_at"`
+}
+
+// mongoMigration is a MongoDB schema change; up must be safe to run again if recording it fails
+type mongoMigration struct {
+	version     int
+	description string
+	up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// Append new migrations with the next version; never edit or reorder applied ones
+var mongoMigrations = []mongoMigration{
+	{1, "index evaluation jobs by status, organization, candidate and content hashes", createIndexes("evaluation_jobs",
+		mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "started_at", Value: 1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "candidate_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "cv_hash", Value: 1}, {Key: "created_at", Value: -1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "content_hash", Value: 1}, {Key: "completed_at", Value: -1}}},
+	)},
+	{2, "index job descriptions by title and chunks by document", func(ctx context.Context, db *mongo.Database) error {
+		if err := createIndexes("job_descriptions",
+			mongo.IndexModel{Keys: bson.D{{Key: "title", Value: 1}}},
+		)(ctx, db); err != nil {
+			return err
+		}
+		return createIndexes("document_chunks",
+			mongo.IndexModel{Keys: bson.D{{Key: "document_id", Value: 1}, {Key: "index", Value: 1}}},
+		)(ctx, db)
+	}},
+	{3, "index audited LLM calls by job and rubrics by name", func(ctx context.Context, db *mongo.Database) error {
+		if err := createIndexes("llm_calls",
+			mongo.IndexModel{Keys: bson.D{{Key: "job_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		)(ctx, db); err != nil {
+			return err
+		}
+		return createIndexes("scoring_rubrics",
+			mongo.IndexModel{Keys: bson.D{{Key: "name", Value: 1}, {Key: "org_id", Value: -1}}},
+		)(ctx, db)
+	}},
+	{4, "unique organization API keys and daily usage totals", func(ctx context.Context, db *mongo.Database) error {
+		if err := createIndexes("organizations",
+			mongo.IndexModel{
+				Keys: bson.D{{Key: "api_key_hash", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"api_key_hash": bson.M{"$gt": ""}}),
+			},
+		)(ctx, db); err != nil {
+			return err
+		}
+		// Concurrent upserts from IncrementUsageTotals could otherwise create duplicate rows
+		return createIndexes("usage_totals",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "date", Value: 1}, {Key: "org_id", Value: 1}, {Key: "model", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		)(ctx, db)
+	}},
+	{5, "expire cached embeddings", createIndexes("embedding_cache",
+		mongo.IndexModel{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	)},
+	{6, "index job feedback for text search", createIndexes("evaluation_jobs",
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "result.cv_feedback", Value: "text"},
+				{Key: "result.project_feedback", Value: "text"},
+				{Key: "result.overall_summary", Value: "text"},
+			},
+			Options: options.Index().SetName("feedback_text"),
+		},
+	)},
+	{7, "index erasure records by organization", createIndexes("erasure_records",
+		mongo.IndexModel{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	)},
+	{8, "one upload record per organization and file", createIndexes("uploads",
+		mongo.IndexModel{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "filename", Value: 1}}, Options: options.Index().SetUnique(true)},
+		mongo.IndexModel{Keys: bson.D{{Key: "filename", Value: 1}}},
+	)},
+	{9, "list uploads by organization", createIndexes("uploads",
+		mongo.IndexModel{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	)},
+	{10, "find jobs by uploaded file", createIndexes("evaluation_jobs",
+		mongo.IndexModel{Keys: bson.D{{Key: "cv_file", Value: 1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "project_file", Value: 1}}},
+	)},
+	{11, "find archived jobs by uploaded file", createIndexes("evaluation_jobs_archive",
+		mongo.IndexModel{Keys: bson.D{{Key: "cv_file", Value: 1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "project_file", Value: 1}}},
+	)},
+	{12, "unique job idempotency keys", createIndexes("evaluation_jobs",
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "idempotency_key", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"idempotency_key": bson.M{"$exists": true}}),
+		},
+	)},
+	{13, "find jobs with a pending enqueue", createIndexes("evaluation_jobs",
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "updated_at", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"enqueue_pending": true}),
+		},
+	)},
+	{14, "index reference documents by organization and type", createIndexes("reference_documents",
+		mongo.IndexModel{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "type", Value: 1}}},
+	)},
+}
+
+// createIndexes returns a migration step that creates indexes on a collection; existing identical
+// indexes are left alone
+func createIndexes(collection string, indexes ...mongo.IndexModel) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes); err != nil {
+			return fmt.Errorf("failed to create %s indexes: %w", collection, err)
+		}
+		return nil
+	}
+}
+
+// Migrate applies pending migrations in version order and returns the ones it applied
+func (r *MongoDBRepository) Migrate(ctx context.Context) ([]Migration, error) {
+	collection := r.db.Collection("migrations")
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var recorded []Migration
+	if err := cursor.All(ctx, &recorded); err != nil {
+		return nil, err
+	}
+	done := map[int]bool{}
+	for _, m := range recorded {
+		done[m.Version] = true
+	}
+
+	applied := []Migration{}
+	for _, m := range mongoMigrations {
+		if done[m.version] {
+			continue
+		}
+		if err := m.up(ctx, r.db); err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+
+		migration := Migration{Version: m.version, Description: m.description, AppliedAt: time.Now()}
+		// Another instance starting at the same time may have recorded it first
+		if _, err := collection.InsertOne(ctx, migration); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return applied, fmt.Errorf("failed to record migration %d: %w", m.version, err)
+		}
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
+
+// Migrate is a no-op; the embedded store has no indexes and fills in missing collections when opened
+func (r *EmbeddedRepository) Migrate(ctx context.Context) ([]Migration, error) {
+	return []Migration{}, nil
+}
@@ -2,7 +2,10 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"ai-cv-summarize/internal/models"
@@ -21,12 +24,32 @@ func NewMongoDBRepository(db *mongo.Database) *MongoDBRepository {
 	return &MongoDBRepository{db: db}
 }
 
+func (r *MongoDBRepository) Ping(ctx context.Context) error {
+	return r.db.Client().Ping(ctx, nil)
+}
+
 // Job Repository Methods
 func (r *MongoDBRepository) CreateJob(ctx context.Context, job *models.EvaluationJob) (interface{}, error) {
 	collection := r.db.Collection("evaluation_jobs")
+	stampOrgID(ctx, &job.OrgID)
 	id, err := collection.InsertOne(ctx, job)
+	if err != nil {
+		if job.IdempotencyKey != "" && mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateIdempotencyKey
+		}
+		return nil, err
+	}
 	fmt.Println("Job created: ", id.InsertedID)
-	return id.InsertedID, err
+	return id.InsertedID, nil
+}
+
+func (r *MongoDBRepository) FindJobByIdempotencyKey(ctx context.Context, key string) (*models.EvaluationJob, error) {
+	var job models.EvaluationJob
+	err := r.db.Collection("evaluation_jobs").FindOne(ctx, tenantFilter(ctx, bson.M{"idempotency_key": key})).Decode(&job)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
 }
 
 func (r *MongoDBRepository) GetJobByID(ctx context.Context, id string) (*models.EvaluationJob, error) {
@@ -37,7 +60,7 @@ func (r *MongoDBRepository) GetJobByID(ctx context.Context, id string) (*models.
 	}
 
 	var job models.EvaluationJob
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&job)
+	err = collection.FindOne(ctx, liveJobFilter(ctx, bson.M{"_id": objectID})).Decode(&job)
 	if err != nil {
 		return nil, err
 	}
@@ -45,6 +68,36 @@ func (r *MongoDBRepository) GetJobByID(ctx context.Context, id string) (*models.
 	return &job, nil
 }
 
+func (r *MongoDBRepository) GetJobsByIDs(ctx context.Context, ids []string) ([]*models.EvaluationJob, error) {
+	collection := r.db.Collection("evaluation_jobs")
+
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			continue
+		}
+		objectIDs = append(objectIDs, objectID)
+	}
+
+	if len(objectIDs) == 0 {
+		return []*models.EvaluationJob{}, nil
+	}
+
+	cursor, err := collection.Find(ctx, liveJobFilter(ctx, bson.M{"_id": bson.M{"$in": objectIDs}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []*models.EvaluationJob
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
 func (r *MongoDBRepository) UpdateJobStatus(ctx context.Context, id string, status models.JobStatus) error {
 	collection := r.db.Collection("evaluation_jobs")
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -67,11 +120,139 @@ func (r *MongoDBRepository) UpdateJobStatus(ctx context.Context, id string, stat
 		update["$set"].(bson.M)["completed_at"] = now
 	}
 
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	_, err = collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}), update)
+	return err
+}
+
+// claimFilter selects a live, unerased job still processing under the given claim token
+func claimFilter(ctx context.Context, id primitive.ObjectID, claimToken string) bson.M {
+	return liveJobFilter(ctx, bson.M{
+		"_id":               id,
+		"status":            models.StatusProcessing,
+		"claim_token":       claimToken,
+		"content_erased_at": nil,
+	})
+}
+
+func (r *MongoDBRepository) UpdateJobResult(ctx context.Context, id, claimToken string, result *models.EvaluationResult) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	// The result being replaced moves to the history in the same update, so a concurrent write cannot lose it
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"result_history": bson.M{"$concatArrays": bson.A{
+			bson.M{"$ifNull": bson.A{"$result_history", bson.A{}}},
+			bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{bson.M{"$type": "$result"}, "object"}}, bson.A{"$result"}, bson.A{}}},
+		}},
+		"result":       bson.M{"$literal": result},
+		"status":       models.StatusCompleted,
+		"updated_at":   time.Now(),
+		"completed_at": time.Now(),
+	}}}}
+
+	return r.updateClaimedJob(ctx, objectID, claimToken, update)
+}
+
+func (r *MongoDBRepository) UpdateJobError(ctx context.Context, id, claimToken string, errorType models.ErrorType, errorMessage string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"error_message": errorMessage,
+			"error_type":    errorType,
+			"status":        models.StatusFailed,
+			"updated_at":    time.Now(),
+			"completed_at":  time.Now(),
+		},
+	}
+
+	return r.updateClaimedJob(ctx, objectID, claimToken, update)
+}
+
+// updateClaimedJob applies an update to a job still processing under the given claim token, or returns ErrNotFound
+func (r *MongoDBRepository) updateClaimedJob(ctx context.Context, id primitive.ObjectID, claimToken string, update interface{}) error {
+	result, err := r.db.Collection("evaluation_jobs").UpdateOne(ctx, claimFilter(ctx, id, claimToken), update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateJobSteps replaces a job's pipeline steps
+func (r *MongoDBRepository) UpdateJobSteps(ctx context.Context, id string, steps []models.JobStep) error {
+	collection := r.db.Collection("evaluation_jobs")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"steps":      steps,
+			"updated_at": time.Now(),
+		},
+	}
+
+	_, err = collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}), update)
+	return err
+}
+
+// UpdateJobStep replaces one pipeline step, matched by name, on a job
+func (r *MongoDBRepository) UpdateJobStep(ctx context.Context, id string, step models.JobStep) error {
+	collection := r.db.Collection("evaluation_jobs")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"steps.$":    step,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID, "steps.name": step.Name}), update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateJobUsage records the LLM usage of a job's latest evaluation attempt
+func (r *MongoDBRepository) UpdateJobUsage(ctx context.Context, id string, usage *models.JobUsage) error {
+	collection := r.db.Collection("evaluation_jobs")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"usage":      usage,
+			"updated_at": time.Now(),
+		},
+	}
+
+	_, err = collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}), update)
 	return err
 }
 
-func (r *MongoDBRepository) UpdateJobResult(ctx context.Context, id string, result *models.EvaluationResult) error {
+// UpdateJobRetrieval stores the context retrieved for each step of a job's evaluation
+func (r *MongoDBRepository) UpdateJobRetrieval(ctx context.Context, id string, retrieval []models.StepRetrieval) error {
 	collection := r.db.Collection("evaluation_jobs")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -80,18 +261,17 @@ func (r *MongoDBRepository) UpdateJobResult(ctx context.Context, id string, resu
 
 	update := bson.M{
 		"$set": bson.M{
-			"result":       result,
-			"status":       models.StatusCompleted,
-			"updated_at":   time.Now(),
-			"completed_at": time.Now(),
+			"retrieval":  retrieval,
+			"updated_at": time.Now(),
 		},
 	}
 
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	_, err = collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}), update)
 	return err
 }
 
-func (r *MongoDBRepository) UpdateJobError(ctx context.Context, id string, errorMessage string) error {
+// UpdateJobParsedCV stores the structured content of a job's CV
+func (r *MongoDBRepository) UpdateJobParsedCV(ctx context.Context, id string, parsed *models.ParsedResume) error {
 	collection := r.db.Collection("evaluation_jobs")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -100,14 +280,32 @@ func (r *MongoDBRepository) UpdateJobError(ctx context.Context, id string, error
 
 	update := bson.M{
 		"$set": bson.M{
-			"error_message": errorMessage,
-			"status":        models.StatusFailed,
-			"updated_at":    time.Now(),
-			"completed_at":  time.Now(),
+			"parsed_cv":  parsed,
+			"updated_at": time.Now(),
+		},
+	}
+
+	_, err = collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}), update)
+	return err
+}
+
+// UpdateJobProjectEmbedding stores the embedding of a job's project report
+func (r *MongoDBRepository) UpdateJobProjectEmbedding(ctx context.Context, id string, embedding []float64, model string) error {
+	collection := r.db.Collection("evaluation_jobs")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"project_embedding":       embedding,
+			"project_embedding_model": model,
+			"updated_at":              time.Now(),
 		},
 	}
 
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	_, err = collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}), update)
 	return err
 }
 
@@ -123,16 +321,16 @@ func (r *MongoDBRepository) IncrementRetryCount(ctx context.Context, id string)
 		"$set": bson.M{"updated_at": time.Now()},
 	}
 
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	_, err = collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}), update)
 	return err
 }
 
 func (r *MongoDBRepository) GetPendingJobs(ctx context.Context) ([]*models.EvaluationJob, error) {
 	collection := r.db.Collection("evaluation_jobs")
 
-	cursor, err := collection.Find(ctx, bson.M{
+	cursor, err := collection.Find(ctx, liveJobFilter(ctx, bson.M{
 		"status": bson.M{"$in": []models.JobStatus{models.StatusQueued, models.StatusProcessing}},
-	})
+	}))
 	if err != nil {
 		return nil, err
 	}
@@ -146,20 +344,14 @@ func (r *MongoDBRepository) GetPendingJobs(ctx context.Context) ([]*models.Evalu
 	return jobs, nil
 }
 
-func (r *MongoDBRepository) GetJobsWithFilters(ctx context.Context, status string, limit, offset int) ([]*models.EvaluationJob, error) {
+// GetStuckJobs returns processing jobs that started before the given time
+func (r *MongoDBRepository) GetStuckJobs(ctx context.Context, startedBefore time.Time) ([]*models.EvaluationJob, error) {
 	collection := r.db.Collection("evaluation_jobs")
 
-	filter := bson.M{}
-	if status != "" {
-		filter["status"] = status
-	}
-
-	opts := options.Find().
-		SetLimit(int64(limit)).
-		SetSkip(int64(offset)).
-		SetSort(bson.D{{"created_at", -1}})
-
-	cursor, err := collection.Find(ctx, filter, opts)
+	cursor, err := collection.Find(ctx, liveJobFilter(ctx, bson.M{
+		"status":     models.StatusProcessing,
+		"started_at": bson.M{"$lt": startedBefore},
+	}))
 	if err != nil {
 		return nil, err
 	}
@@ -173,77 +365,1636 @@ func (r *MongoDBRepository) GetJobsWithFilters(ctx context.Context, status strin
 	return jobs, nil
 }
 
-// Job Description Repository Methods
-func (r *MongoDBRepository) CreateJobDescription(ctx context.Context, jobDesc *models.JobDescription) error {
-	collection := r.db.Collection("job_descriptions")
-	_, err := collection.InsertOne(ctx, jobDesc)
-	return err
+// RequeueStuckJob moves a processing job back to queued and counts the attempt. It returns ErrNotFound
+// when the job is no longer processing, e.g. because its worker finished in the meantime.
+func (r *MongoDBRepository) RequeueStuckJob(ctx context.Context, id string) error {
+	collection := r.db.Collection("evaluation_jobs")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"status": models.StatusQueued, "updated_at": time.Now()},
+		"$unset": bson.M{"started_at": "", "steps": "", "claim_token": ""},
+		"$inc":   bson.M{"retry_count": 1},
+	}
+
+	result, err := collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID, "status": models.StatusProcessing}), update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
 }
 
-func (r *MongoDBRepository) GetJobDescription(ctx context.Context, id string) (*models.JobDescription, error) {
-	collection := r.db.Collection("job_descriptions")
+// ReopenJob queues a completed or failed live job again for a re-evaluation with the given content hash,
+// moving its result to the history. It returns ErrNotFound when the job is not completed or failed.
+func (r *MongoDBRepository) ReopenJob(ctx context.Context, id, contentHash string) error {
+	collection := r.db.Collection("evaluation_jobs")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var jobDesc models.JobDescription
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&jobDesc)
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"result_history": bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$result_history", bson.A{}}},
+				bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{bson.M{"$type": "$result"}, "object"}}, bson.A{"$result"}, bson.A{}}},
+			}},
+			"content_hash":    contentHash,
+			"status":          models.StatusQueued,
+			"enqueue_pending": bson.M{"$ne": bson.A{"$sandbox", true}},
+			"retry_count":     0,
+			"updated_at":      time.Now(),
+		}}},
+		{{Key: "$unset", Value: bson.A{"result", "error_message", "error_type", "steps", "claim_token", "started_at", "completed_at"}}},
+	}
+
+	filter := bson.M{"_id": objectID, "status": bson.M{"$in": bson.A{models.StatusCompleted, models.StatusFailed}}}
+	result, err := collection.UpdateOne(ctx, liveJobFilter(ctx, filter), update)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
 	}
 
-	return &jobDesc, nil
+	return nil
 }
 
-func (r *MongoDBRepository) GetAllJobDescriptions(ctx context.Context) ([]*models.JobDescription, error) {
-	collection := r.db.Collection("job_descriptions")
+// ClaimJob moves a queued job to processing. It returns ErrNotFound when the job is not queued, e.g.
+// because another worker claimed it first.
+func (r *MongoDBRepository) ClaimJob(ctx context.Context, id string) (string, error) {
+	collection := r.db.Collection("evaluation_jobs")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err
+	}
 
-	cursor, err := collection.Find(ctx, bson.M{})
+	now := time.Now()
+	claimToken := newClaimToken()
+	update := bson.M{"$set": bson.M{"status": models.StatusProcessing, "claim_token": claimToken, "started_at": now, "updated_at": now}}
+
+	result, err := collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID, "status": models.StatusQueued}), update)
+	if err != nil {
+		return "", err
+	}
+	if result.MatchedCount == 0 {
+		return "", ErrNotFound
+	}
+
+	return claimToken, nil
+}
+
+// GetUndispatchedJobs returns the live jobs, last updated before the given time, whose enqueue is still pending
+func (r *MongoDBRepository) GetUndispatchedJobs(ctx context.Context, updatedBefore time.Time) ([]*models.EvaluationJob, error) {
+	collection := r.db.Collection("evaluation_jobs")
+
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: 1}})
+	cursor, err := collection.Find(ctx, liveJobFilter(ctx, bson.M{
+		"enqueue_pending": true,
+		"updated_at":      bson.M{"$lt": updatedBefore},
+	}), opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var jobDescs []*models.JobDescription
-	if err = cursor.All(ctx, &jobDescs); err != nil {
+	var jobs []*models.EvaluationJob
+	if err = cursor.All(ctx, &jobs); err != nil {
 		return nil, err
 	}
 
-	return jobDescs, nil
+	return jobs, nil
 }
 
-// Scoring Rubric Repository Methods
-func (r *MongoDBRepository) CreateScoringRubric(ctx context.Context, rubric *models.ScoringRubric) error {
-	collection := r.db.Collection("scoring_rubrics")
-	_, err := collection.InsertOne(ctx, rubric)
+// MarkJobEnqueued clears a job's pending enqueue once its ID is on the queue
+func (r *MongoDBRepository) MarkJobEnqueued(ctx context.Context, id string) error {
+	collection := r.db.Collection("evaluation_jobs")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}), bson.M{"$unset": bson.M{"enqueue_pending": ""}})
 	return err
 }
 
-func (r *MongoDBRepository) GetScoringRubric(ctx context.Context, id string) (*models.ScoringRubric, error) {
-	collection := r.db.Collection("scoring_rubrics")
-	objectID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
+// FindRecentJobByCVHash returns the newest non-failed job created since the given time for the same CV
+// and job description
+func (r *MongoDBRepository) FindRecentJobByCVHash(ctx context.Context, cvHash, jobDescriptionID string, sandbox bool, since time.Time) (*models.EvaluationJob, error) {
+	collection := r.db.Collection("evaluation_jobs")
+
+	filter := bson.M{
+		"cv_hash":    cvHash,
+		"status":     bson.M{"$ne": models.StatusFailed},
+		"created_at": bson.M{"$gte": since},
+	}
+	if jobDescriptionID != "" {
+		filter["job_description_id"] = jobDescriptionID
+	} else {
+		filter["job_description_id"] = bson.M{"$in": bson.A{nil, ""}}
+	}
+	if sandbox {
+		filter["sandbox"] = true
+	} else {
+		filter["sandbox"] = bson.M{"$ne": true}
+	}
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var job models.EvaluationJob
+	if err := collection.FindOne(ctx, liveJobFilter(ctx, filter), opts).Decode(&job); err != nil {
 		return nil, err
 	}
 
-	var rubric models.ScoringRubric
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&rubric)
+	return &job, nil
+}
+
+func (r *MongoDBRepository) FindProjectEmbeddings(ctx context.Context, model string, sandbox bool) ([]*ProjectEmbedding, error) {
+	collection := r.db.Collection("evaluation_jobs")
+
+	filter := bson.M{"project_embedding_model": model}
+	if sandbox {
+		filter["sandbox"] = true
+	} else {
+		filter["sandbox"] = bson.M{"$ne": true}
+	}
+	opts := options.Find().SetProjection(bson.M{
+		"_id": 1, "candidate_id": 1, "cv_hash": 1, "project_hash": 1, "project_embedding": 1,
+	})
+
+	cursor, err := collection.Find(ctx, liveJobFilter(ctx, filter), opts)
 	if err != nil {
 		return nil, err
 	}
+	defer cursor.Close(ctx)
+
+	var embeddings []*ProjectEmbedding
+	for cursor.Next(ctx) {
+		var job models.EvaluationJob
+		if err := cursor.Decode(&job); err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, &ProjectEmbedding{
+			JobID:       job.ID.Hex(),
+			CandidateID: job.CandidateID,
+			CVHash:      job.CVHash,
+			ProjectHash: job.ProjectHash,
+			Embedding:   job.ProjectEmbedding,
+		})
+	}
+
+	return embeddings, cursor.Err()
+}
+
+// FindCompletedJobByContentHash returns the newest job completed since the given time with the same content hash
+func (r *MongoDBRepository) FindCompletedJobByContentHash(ctx context.Context, contentHash string, since time.Time) (*models.EvaluationJob, error) {
+	collection := r.db.Collection("evaluation_jobs")
+
+	filter := bson.M{
+		"content_hash": contentHash,
+		"status":       models.StatusCompleted,
+		"completed_at": bson.M{"$gte": since},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "completed_at", Value: -1}})
+
+	var job models.EvaluationJob
+	if err := collection.FindOne(ctx, liveJobFilter(ctx, filter), opts).Decode(&job); err != nil {
+		return nil, err
+	}
 
-	return &rubric, nil
+	return &job, nil
 }
 
-func (r *MongoDBRepository) GetDefaultScoringRubric(ctx context.Context) (*models.ScoringRubric, error) {
-	collection := r.db.Collection("scoring_rubrics")
+func (r *MongoDBRepository) GetJobsWithFilters(ctx context.Context, opts JobListOptions) ([]*models.EvaluationJob, error) {
+	collection := r.db.Collection("evaluation_jobs")
 
-	var rubric models.ScoringRubric
-	err := collection.FindOne(ctx, bson.M{"name": "default"}).Decode(&rubric)
+	filter, findOpts := opts.toFind()
+	if opts.After != "" {
+		after, err := r.GetJobByID(ctx, opts.After)
+		if errors.Is(err, ErrNotFound) || errors.Is(err, primitive.ErrInvalidHex) {
+			return nil, ErrInvalidCursor
+		}
+		if err != nil {
+			return nil, err
+		}
+		filter["$or"] = opts.afterBSON(after)
+	}
+
+	cursor, err := collection.Find(ctx, liveJobFilter(ctx, filter), findOpts)
 	if err != nil {
 		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	return &rubric, nil
+	var jobs []*models.EvaluationJob
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (r *MongoDBRepository) CountJobs(ctx context.Context, opts JobListOptions) (int64, error) {
+	collection := r.db.Collection("evaluation_jobs")
+
+	filter, _ := opts.toFind()
+	return collection.CountDocuments(ctx, liveJobFilter(ctx, filter))
+}
+
+// StreamJobs calls fn with each matching job in order, decoding one document at a time.
+// Document contents are not loaded since exports only need job metadata and results.
+func (r *MongoDBRepository) StreamJobs(ctx context.Context, opts JobListOptions, fn func(*models.EvaluationJob) error) error {
+	collection := r.db.Collection("evaluation_jobs")
+
+	filter, findOpts := opts.toFind()
+	findOpts.SetProjection(bson.M{"cv_content": 0, "project_content": 0, "steps": 0, "result_history": 0})
+
+	cursor, err := collection.Find(ctx, liveJobFilter(ctx, filter), findOpts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var job models.EvaluationJob
+		if err := cursor.Decode(&job); err != nil {
+			return err
+		}
+		if err := fn(&job); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// GetScoreCounts counts the cohort's completed evaluations scoring below and equal to score in a single aggregation
+func (r *MongoDBRepository) GetScoreCounts(ctx context.Context, cohort ScoreCohort, score float64) (*ScoreCounts, error) {
+	collection := r.db.Collection("evaluation_jobs")
+
+	match := bson.M{
+		"status":             models.StatusCompleted,
+		"result":             bson.M{"$ne": nil},
+		"job_description_id": cohort.JobDescriptionID,
+		"sandbox":            cohort.Sandbox,
+	}
+	// Both fields are omitted when empty
+	if cohort.JobDescriptionID == "" {
+		match["job_description_id"] = bson.M{"$in": bson.A{nil, ""}}
+	}
+	if !cohort.Sandbox {
+		match["sandbox"] = bson.M{"$ne": true}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: liveJobFilter(ctx, match)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": 1},
+			"below": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$lt": bson.A{"$result.overall_score", score}}, 1, 0}}},
+			"equal": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$result.overall_score", score}}, 1, 0}}},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := &ScoreCounts{}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(counts); err != nil {
+			return nil, err
+		}
+	}
+
+	return counts, cursor.Err()
+}
+
+// toFind builds the query and find options of a job listing
+func (opts JobListOptions) toFind() (bson.M, *options.FindOptions) {
+	filter := bson.M{}
+	if opts.Status != "" {
+		filter["status"] = opts.Status
+	}
+	if opts.CandidateID != "" {
+		filter["candidate_id"] = opts.CandidateID
+	}
+
+	created := bson.M{}
+	if !opts.CreatedFrom.IsZero() {
+		created["$gte"] = opts.CreatedFrom
+	}
+	if !opts.CreatedBefore.IsZero() {
+		created["$lt"] = opts.CreatedBefore
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+
+	for _, r := range opts.ScoreRanges {
+		bounds := bson.M{"$ne": nil}
+		if r.Min != nil {
+			bounds["$gte"] = *r.Min
+		}
+		if r.Max != nil {
+			bounds["$lte"] = *r.Max
+		}
+		filter[r.Field] = bounds
+	}
+
+	if opts.CandidateName != "" {
+		filter["candidate_name"] = bson.M{"$regex": regexp.QuoteMeta(opts.CandidateName), "$options": "i"}
+	}
+	if terms := searchTerms(opts.Search); len(terms) > 0 {
+		// Served by the feedback text index, which matches any of the words
+		filter["$text"] = bson.M{"$search": strings.Join(terms, " ")}
+	}
+
+	sortOrder := -1
+	if opts.ascending() {
+		sortOrder = 1
+	}
+
+	findOpts := options.Find().
+		SetLimit(int64(opts.Limit)).
+		SetSkip(int64(opts.Offset)).
+		SetSort(bson.D{{Key: opts.sortField(), Value: sortOrder}, {Key: "_id", Value: sortOrder}})
+
+	return filter, findOpts
+}
+
+// afterBSON selects the jobs listed after the given job. Jobs without a sort value are the lowest,
+// so they come last in descending order and first in ascending order.
+func (opts JobListOptions) afterBSON(after *models.EvaluationJob) bson.A {
+	field := opts.sortField()
+	cmp := "$lt"
+	if opts.ascending() {
+		cmp = "$gt"
+	}
+
+	value := jobSortValue(after, field)
+	if value == nil {
+		following := bson.A{bson.M{field: nil, "_id": bson.M{cmp: after.ID}}}
+		if opts.ascending() {
+			following = append(following, bson.M{field: bson.M{"$ne": nil}})
+		}
+		return following
+	}
+
+	following := bson.A{
+		bson.M{field: bson.M{cmp: value}},
+		bson.M{field: value, "_id": bson.M{cmp: after.ID}},
+	}
+	if !opts.ascending() {
+		following = append(following, bson.M{field: nil})
+	}
+	return following
+}
+
+func (f JobBulkFilter) toBSON() bson.M {
+	filter := bson.M{}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	created := bson.M{}
+	if !f.OlderThan.IsZero() {
+		created["$lt"] = f.OlderThan
+	}
+	if !f.NewerThan.IsZero() {
+		created["$gte"] = f.NewerThan
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+	if f.CandidateID != "" {
+		filter["candidate_id"] = f.CandidateID
+	}
+	if f.IDs != nil {
+		objectIDs := make([]primitive.ObjectID, 0, len(f.IDs))
+		for _, id := range f.IDs {
+			if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+				objectIDs = append(objectIDs, objectID)
+			}
+		}
+		filter["_id"] = bson.M{"$in": objectIDs}
+	}
+	if !f.DeletedBefore.IsZero() {
+		filter["deleted_at"] = bson.M{"$ne": nil, "$lt": f.DeletedBefore}
+	}
+	if f.WithContent {
+		if f.Status == "" {
+			filter["status"] = bson.M{"$in": bson.A{models.StatusCompleted, models.StatusFailed}}
+		}
+		filter["content_erased_at"] = nil
+	}
+	return filter
+}
+
+// SoftDeleteJob marks a job deleted; it is hidden from every query until purged
+func (r *MongoDBRepository) SoftDeleteJob(ctx context.Context, id string) error {
+	collection := r.db.Collection("evaluation_jobs")
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}}
+	result, err := collection.UpdateOne(ctx, liveJobFilter(ctx, bson.M{"_id": objectID}), update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// jobCollection returns the collection holding live or archived jobs
+func (r *MongoDBRepository) jobCollection(archived bool) *mongo.Collection {
+	if archived {
+		return r.db.Collection("evaluation_jobs_archive")
+	}
+	return r.db.Collection("evaluation_jobs")
+}
+
+func (r *MongoDBRepository) FindJobs(ctx context.Context, filter JobBulkFilter) ([]*models.EvaluationJob, error) {
+	collection := r.jobCollection(filter.Archived)
+
+	cursor, err := collection.Find(ctx, tenantFilter(ctx, filter.toBSON()))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	jobs := []*models.EvaluationJob{}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *MongoDBRepository) EraseJobContent(ctx context.Context, ids []string) (int64, error) {
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+	if len(objectIDs) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{"cv_content": "", "project_content": "", "content_erased_at": now, "updated_at": now},
+		"$unset": bson.M{
+			"result.redactions": "", "result.blind_cv_content": "", "result.blind_project_content": "", "parsed_cv": "",
+			"project_embedding": "", "project_embedding_model": "",
+		},
+	}
+	var erased int64
+	for _, archived := range []bool{false, true} {
+		collection := r.jobCollection(archived)
+		result, err := collection.UpdateMany(ctx, tenantFilter(ctx, bson.M{"_id": bson.M{"$in": objectIDs}}), update)
+		if err != nil {
+			return erased, err
+		}
+		erased += result.ModifiedCount
+
+		// Earlier results keep the same data; $[] needs the array to exist, so only jobs with a history are updated
+		_, err = collection.UpdateMany(ctx, tenantFilter(ctx, bson.M{"_id": bson.M{"$in": objectIDs}, "result_history.0": bson.M{"$exists": true}}), bson.M{
+			"$unset": bson.M{
+				"result_history.$[].redactions": "", "result_history.$[].blind_cv_content": "", "result_history.$[].blind_project_content": "",
+			},
+		})
+		if err != nil {
+			return erased, err
+		}
+	}
+	return erased, nil
+}
+
+func (r *MongoDBRepository) AnonymizeJob(ctx context.Context, id string, now time.Time) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	for _, archived := range []bool{false, true} {
+		collection := r.jobCollection(archived)
+
+		// archived_at is not part of the model and must survive the replace
+		var doc struct {
+			models.EvaluationJob `bson:",inline"`
+			ArchivedAt           *time.Time `bson:"archived_at,omitempty"`
+		}
+		err := collection.FindOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID})).Decode(&doc)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		doc.Anonymize(now)
+
+		_, err = collection.ReplaceOne(ctx, bson.M{"_id": objectID}, &doc)
+		return err
+	}
+	return ErrNotFound
+}
+
+func (r *MongoDBRepository) FindJobIDs(ctx context.Context, filter JobBulkFilter) ([]string, error) {
+	collection := r.db.Collection("evaluation_jobs")
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := collection.Find(ctx, tenantFilter(ctx, filter.toBSON()), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	ids := []string{}
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids = append(ids, doc.ID.Hex())
+	}
+
+	return ids, cursor.Err()
+}
+
+func (r *MongoDBRepository) DeleteJobs(ctx context.Context, filter JobBulkFilter) (int64, error) {
+	collection := r.jobCollection(filter.Archived)
+	result, err := collection.DeleteMany(ctx, tenantFilter(ctx, filter.toBSON()))
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// ArchiveJobs copies matching jobs into the archive collection and removes them from evaluation_jobs
+func (r *MongoDBRepository) ArchiveJobs(ctx context.Context, filter JobBulkFilter) (int64, error) {
+	collection := r.db.Collection("evaluation_jobs")
+	archive := r.db.Collection("evaluation_jobs_archive")
+
+	cursor, err := collection.Find(ctx, tenantFilter(ctx, filter.toBSON()))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var archived int64
+	for cursor.Next(ctx) {
+		var job bson.M
+		if err := cursor.Decode(&job); err != nil {
+			return archived, err
+		}
+		job["archived_at"] = time.Now()
+
+		if _, err := archive.ReplaceOne(ctx, bson.M{"_id": job["_id"]}, job, options.Replace().SetUpsert(true)); err != nil {
+			return archived, err
+		}
+		if _, err := collection.DeleteOne(ctx, bson.M{"_id": job["_id"]}); err != nil {
+			return archived, err
+		}
+		archived++
+	}
+
+	return archived, cursor.Err()
+}
+
+// Batch Job Repository Methods
+func (r *MongoDBRepository) CreateBatchJob(ctx context.Context, batch *models.BatchJob) error {
+	collection := r.db.Collection("batch_jobs")
+	if batch.ID.IsZero() {
+		batch.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &batch.OrgID)
+	_, err := collection.InsertOne(ctx, batch)
+	return err
+}
+
+func (r *MongoDBRepository) GetBatchJob(ctx context.Context, id string) (*models.BatchJob, error) {
+	collection := r.db.Collection("batch_jobs")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch models.BatchJob
+	err = collection.FindOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID})).Decode(&batch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &batch, nil
+}
+
+func (r *MongoDBRepository) AnonymizeBatchItems(ctx context.Context, batchID string, jobIDs []string) error {
+	collection := r.db.Collection("batch_jobs")
+	objectID, err := primitive.ObjectIDFromHex(batchID)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"items.$[item].cv_file": "", "items.$[item].project_file": ""},
+		"$unset": bson.M{"items.$[item].candidate_id": "", "items.$[item].source": ""},
+	}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"item.job_id": bson.M{"$in": jobIDs}}},
+	})
+	_, err = collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}), update, opts)
+	return err
+}
+
+// Job Description Repository Methods
+func (r *MongoDBRepository) CreateJobDescription(ctx context.Context, jobDesc *models.JobDescription) error {
+	collection := r.db.Collection("job_descriptions")
+	stampOrgID(ctx, &jobDesc.OrgID)
+	_, err := collection.InsertOne(ctx, jobDesc)
+	return err
+}
+
+func (r *MongoDBRepository) GetJobDescription(ctx context.Context, id string) (*models.JobDescription, error) {
+	collection := r.db.Collection("job_descriptions")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobDesc models.JobDescription
+	err = collection.FindOne(ctx, sharedFilter(ctx, bson.M{"_id": objectID})).Decode(&jobDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jobDesc, nil
+}
+
+func (r *MongoDBRepository) GetAllJobDescriptions(ctx context.Context) ([]*models.JobDescription, error) {
+	collection := r.db.Collection("job_descriptions")
+
+	cursor, err := collection.Find(ctx, sharedFilter(ctx, bson.M{}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobDescs []*models.JobDescription
+	if err = cursor.All(ctx, &jobDescs); err != nil {
+		return nil, err
+	}
+
+	return jobDescs, nil
+}
+
+func (r *MongoDBRepository) GetJobDescriptionVectors(ctx context.Context) ([]*models.JobDescription, error) {
+	collection := r.db.Collection("job_descriptions")
+	opts := options.Find().SetProjection(bson.M{"title": 0, "description": 0, "requirements": 0})
+
+	cursor, err := collection.Find(ctx, sharedFilter(ctx, bson.M{}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobDescs []*models.JobDescription
+	if err = cursor.All(ctx, &jobDescs); err != nil {
+		return nil, err
+	}
+
+	return jobDescs, nil
+}
+
+// UpdateJobDescription replaces a job description's content and vector
+func (r *MongoDBRepository) UpdateJobDescription(ctx context.Context, jobDesc *models.JobDescription) error {
+	collection := r.db.Collection("job_descriptions")
+
+	result, err := collection.ReplaceOne(ctx, tenantFilter(ctx, bson.M{"_id": jobDesc.ID}), jobDesc)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateJobDescriptionEmbedding replaces a job description's vector and records the model that produced it
+func (r *MongoDBRepository) UpdateJobDescriptionEmbedding(ctx context.Context, id string, embedding []float64, model string) error {
+	collection := r.db.Collection("job_descriptions")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}), bson.M{
+		"$set": bson.M{
+			"embedding":            embedding,
+			"embedding_model":      model,
+			"embedding_dimensions": len(embedding),
+			"embedding_norm":       embeddingNorm(embedding),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MongoDBRepository) UpdateJobDescriptionThresholds(ctx context.Context, id string, thresholds *models.RecommendationThresholds) error {
+	collection := r.db.Collection("job_descriptions")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"recommendation_thresholds": thresholds}}
+	if thresholds == nil {
+		update = bson.M{"$unset": bson.M{"recommendation_thresholds": ""}}
+	}
+	result, err := collection.UpdateOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}), update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MongoDBRepository) DeleteJobDescription(ctx context.Context, id string) error {
+	collection := r.db.Collection("job_descriptions")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := collection.DeleteOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Reference Document Repository Methods
+func (r *MongoDBRepository) CreateReferenceDocument(ctx context.Context, doc *models.ReferenceDocument) error {
+	collection := r.db.Collection("reference_documents")
+	if doc.ID.IsZero() {
+		doc.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &doc.OrgID)
+	_, err := collection.InsertOne(ctx, doc)
+	return err
+}
+
+func (r *MongoDBRepository) GetReferenceDocument(ctx context.Context, id string) (*models.ReferenceDocument, error) {
+	collection := r.db.Collection("reference_documents")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc models.ReferenceDocument
+	err = collection.FindOne(ctx, sharedFilter(ctx, bson.M{"_id": objectID})).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+func (r *MongoDBRepository) GetReferenceDocuments(ctx context.Context, docType string) ([]*models.ReferenceDocument, error) {
+	collection := r.db.Collection("reference_documents")
+
+	filter := bson.M{}
+	if docType != "" {
+		filter["type"] = docType
+	}
+	cursor, err := collection.Find(ctx, sharedFilter(ctx, filter), options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []*models.ReferenceDocument
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+func (r *MongoDBRepository) DeleteReferenceDocument(ctx context.Context, id string) error {
+	collection := r.db.Collection("reference_documents")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := collection.DeleteOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID}))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Document Chunk Repository Methods
+func (r *MongoDBRepository) ReplaceDocumentChunks(ctx context.Context, documentID string, chunks []*models.DocumentChunk) error {
+	if err := r.DeleteDocumentChunks(ctx, documentID); err != nil {
+		return err
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(chunks))
+	for i, chunk := range chunks {
+		documents[i] = chunk
+	}
+	_, err := r.db.Collection("document_chunks").InsertMany(ctx, documents)
+	return err
+}
+
+func (r *MongoDBRepository) GetDocumentChunks(ctx context.Context, documentID string) ([]*models.DocumentChunk, error) {
+	return r.findDocumentChunks(ctx, bson.M{"document_id": documentID})
+}
+
+func (r *MongoDBRepository) GetAllDocumentChunks(ctx context.Context) ([]*models.DocumentChunk, error) {
+	return r.findDocumentChunks(ctx, bson.M{})
+}
+
+func (r *MongoDBRepository) findDocumentChunks(ctx context.Context, filter bson.M) ([]*models.DocumentChunk, error) {
+	collection := r.db.Collection("document_chunks")
+
+	opts := options.Find().SetSort(bson.D{{Key: "document_id", Value: 1}, {Key: "index", Value: 1}})
+	cursor, err := collection.Find(ctx, sharedFilter(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var chunks []*models.DocumentChunk
+	if err = cursor.All(ctx, &chunks); err != nil {
+		return nil, err
+	}
+
+	return chunks, nil
+}
+
+func (r *MongoDBRepository) DeleteDocumentChunks(ctx context.Context, documentID string) error {
+	collection := r.db.Collection("document_chunks")
+
+	_, err := collection.DeleteMany(ctx, tenantFilter(ctx, bson.M{"document_id": documentID}))
+	return err
+}
+
+// Index Rebuild Repository Methods
+func (r *MongoDBRepository) SaveIndexRebuild(ctx context.Context, rebuild *models.IndexRebuild) error {
+	collection := r.db.Collection("index_rebuilds")
+
+	if rebuild.ID.IsZero() {
+		rebuild.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": rebuild.ID}, rebuild, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoDBRepository) GetLatestIndexRebuild(ctx context.Context) (*models.IndexRebuild, error) {
+	collection := r.db.Collection("index_rebuilds")
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "started_at", Value: -1}})
+
+	var rebuild models.IndexRebuild
+	if err := collection.FindOne(ctx, bson.M{}, opts).Decode(&rebuild); err != nil {
+		return nil, err
+	}
+
+	return &rebuild, nil
+}
+
+func (r *MongoDBRepository) SaveFairnessReport(ctx context.Context, report *models.FairnessReport) error {
+	collection := r.db.Collection("fairness_reports")
+
+	if report.ID.IsZero() {
+		report.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": report.ID}, report, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoDBRepository) GetFairnessReport(ctx context.Context, id string) (*models.FairnessReport, error) {
+	collection := r.db.Collection("fairness_reports")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var report models.FairnessReport
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&report); err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (r *MongoDBRepository) GetFairnessReports(ctx context.Context) ([]*models.FairnessReport, error) {
+	collection := r.db.Collection("fairness_reports")
+
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reports := []*models.FairnessReport{}
+	if err = cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func (r *MongoDBRepository) SaveExperiment(ctx context.Context, experiment *models.Experiment) error {
+	collection := r.db.Collection("experiments")
+
+	if experiment.ID.IsZero() {
+		experiment.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": experiment.ID}, experiment, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoDBRepository) GetExperiment(ctx context.Context, id string) (*models.Experiment, error) {
+	collection := r.db.Collection("experiments")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var experiment models.Experiment
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&experiment); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &experiment, nil
+}
+
+func (r *MongoDBRepository) GetExperiments(ctx context.Context) ([]*models.Experiment, error) {
+	collection := r.db.Collection("experiments")
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	experiments := []*models.Experiment{}
+	if err = cursor.All(ctx, &experiments); err != nil {
+		return nil, err
+	}
+
+	return experiments, nil
+}
+
+// Golden Job Repository Methods
+func (r *MongoDBRepository) CreateGoldenJob(ctx context.Context, golden *models.GoldenJob) error {
+	collection := r.db.Collection("golden_jobs")
+	result, err := collection.InsertOne(ctx, golden)
+	if err != nil {
+		return err
+	}
+	golden.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoDBRepository) GetAllGoldenJobs(ctx context.Context) ([]*models.GoldenJob, error) {
+	collection := r.db.Collection("golden_jobs")
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var goldens []*models.GoldenJob
+	if err = cursor.All(ctx, &goldens); err != nil {
+		return nil, err
+	}
+
+	return goldens, nil
+}
+
+func (r *MongoDBRepository) DeleteGoldenJob(ctx context.Context, id string) error {
+	collection := r.db.Collection("golden_jobs")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MongoDBRepository) SaveCalibrationRun(ctx context.Context, run *models.GoldenComparisonReport) error {
+	collection := r.db.Collection("calibration_runs")
+
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": run.ID}, run, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoDBRepository) GetCalibrationRuns(ctx context.Context, limit int) ([]*models.GoldenComparisonReport, error) {
+	collection := r.db.Collection("calibration_runs")
+
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	runs := []*models.GoldenComparisonReport{}
+	if err = cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// Prompt Template Repository Methods
+func (r *MongoDBRepository) GetPromptTemplate(ctx context.Context, name string) (*models.PromptTemplate, error) {
+	collection := r.db.Collection("prompt_templates")
+
+	var tmpl models.PromptTemplate
+	if err := collection.FindOne(ctx, bson.M{"_id": name}).Decode(&tmpl); err != nil {
+		return nil, err
+	}
+
+	return &tmpl, nil
+}
+
+func (r *MongoDBRepository) GetAllPromptTemplates(ctx context.Context) ([]*models.PromptTemplate, error) {
+	collection := r.db.Collection("prompt_templates")
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var templates []*models.PromptTemplate
+	if err = cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (r *MongoDBRepository) UpsertPromptTemplate(ctx context.Context, tmpl *models.PromptTemplate) error {
+	collection := r.db.Collection("prompt_templates")
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": tmpl.Name}, tmpl, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoDBRepository) DeletePromptTemplate(ctx context.Context, name string) error {
+	collection := r.db.Collection("prompt_templates")
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MongoDBRepository) CreatePromptTemplateVersion(ctx context.Context, version *models.PromptTemplateVersion) error {
+	collection := r.db.Collection("prompt_template_versions")
+	version.ID = promptVersionID(version.Name, version.Version)
+
+	_, err := collection.InsertOne(ctx, version)
+	return err
+}
+
+func (r *MongoDBRepository) GetPromptTemplateVersion(ctx context.Context, name string, version int) (*models.PromptTemplateVersion, error) {
+	collection := r.db.Collection("prompt_template_versions")
+
+	var tmpl models.PromptTemplateVersion
+	if err := collection.FindOne(ctx, bson.M{"_id": promptVersionID(name, version)}).Decode(&tmpl); err != nil {
+		return nil, err
+	}
+
+	return &tmpl, nil
+}
+
+func (r *MongoDBRepository) GetPromptTemplateVersions(ctx context.Context, name string) ([]*models.PromptTemplateVersion, error) {
+	collection := r.db.Collection("prompt_template_versions")
+
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.M{"name": name}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var versions []*models.PromptTemplateVersion
+	if err = cursor.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// Scoring Rubric Repository Methods
+func (r *MongoDBRepository) CreateScoringRubric(ctx context.Context, rubric *models.ScoringRubric) error {
+	collection := r.db.Collection("scoring_rubrics")
+	stampOrgID(ctx, &rubric.OrgID)
+	result, err := collection.InsertOne(ctx, rubric)
+	if err != nil {
+		return err
+	}
+	rubric.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoDBRepository) GetScoringRubric(ctx context.Context, id string) (*models.ScoringRubric, error) {
+	collection := r.db.Collection("scoring_rubrics")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var rubric models.ScoringRubric
+	err = collection.FindOne(ctx, sharedFilter(ctx, bson.M{"_id": objectID})).Decode(&rubric)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rubric, nil
+}
+
+func (r *MongoDBRepository) GetDefaultScoringRubric(ctx context.Context) (*models.ScoringRubric, error) {
+	return r.GetScoringRubricByName(ctx, "default")
+}
+
+// GetScoringRubricByName prefers the organization's own rubric over a global one with the same name
+func (r *MongoDBRepository) GetScoringRubricByName(ctx context.Context, name string) (*models.ScoringRubric, error) {
+	collection := r.db.Collection("scoring_rubrics")
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "org_id", Value: -1}})
+
+	var rubric models.ScoringRubric
+	err := collection.FindOne(ctx, sharedFilter(ctx, bson.M{"name": name}), opts).Decode(&rubric)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rubric, nil
+}
+
+// GetAllScoringRubrics lists the organization's rubrics and the global ones
+func (r *MongoDBRepository) GetAllScoringRubrics(ctx context.Context) ([]*models.ScoringRubric, error) {
+	collection := r.db.Collection("scoring_rubrics")
+
+	cursor, err := collection.Find(ctx, sharedFilter(ctx, bson.M{}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rubrics []*models.ScoringRubric
+	if err = cursor.All(ctx, &rubrics); err != nil {
+		return nil, err
+	}
+
+	return rubrics, nil
+}
+
+// Organization Repository Methods
+func (r *MongoDBRepository) CreateOrganization(ctx context.Context, org *models.Organization) error {
+	collection := r.db.Collection("organizations")
+	result, err := collection.InsertOne(ctx, org)
+	if err != nil {
+		return err
+	}
+	org.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoDBRepository) GetOrganization(ctx context.Context, id string) (*models.Organization, error) {
+	collection := r.db.Collection("organizations")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var org models.Organization
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&org); err != nil {
+		return nil, err
+	}
+
+	return &org, nil
+}
+
+func (r *MongoDBRepository) GetOrganizationByAPIKeyHash(ctx context.Context, hash string) (*models.Organization, error) {
+	collection := r.db.Collection("organizations")
+
+	var org models.Organization
+	if err := collection.FindOne(ctx, bson.M{"api_key_hash": hash}).Decode(&org); err != nil {
+		return nil, err
+	}
+
+	return &org, nil
+}
+
+func (r *MongoDBRepository) GetAllOrganizations(ctx context.Context) ([]*models.Organization, error) {
+	collection := r.db.Collection("organizations")
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orgs []*models.Organization
+	if err = cursor.All(ctx, &orgs); err != nil {
+		return nil, err
+	}
+
+	return orgs, nil
+}
+
+func (r *MongoDBRepository) UpdateOrganization(ctx context.Context, org *models.Organization) error {
+	collection := r.db.Collection("organizations")
+
+	result, err := collection.ReplaceOne(ctx, bson.M{"_id": org.ID}, org)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Usage Totals Repository Methods
+
+func (r *MongoDBRepository) GetCachedEmbedding(ctx context.Context, key string) (*models.CachedEmbedding, error) {
+	collection := r.db.Collection("embedding_cache")
+
+	var entry models.CachedEmbedding
+	filter := bson.M{"_id": key, "expires_at": bson.M{"$gt": time.Now()}}
+	if err := collection.FindOne(ctx, filter).Decode(&entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (r *MongoDBRepository) SaveCachedEmbedding(ctx context.Context, entry *models.CachedEmbedding) error {
+	collection := r.db.Collection("embedding_cache")
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": entry.Key}, entry, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoDBRepository) DeleteCachedEmbeddings(ctx context.Context, keys []string) (int64, error) {
+	collection := r.db.Collection("embedding_cache")
+
+	result, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": keys}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *MongoDBRepository) CreateLLMCall(ctx context.Context, call *models.LLMCall) error {
+	collection := r.db.Collection("llm_calls")
+	stampOrgID(ctx, &call.OrgID)
+	result, err := collection.InsertOne(ctx, call)
+	if err != nil {
+		return err
+	}
+	call.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoDBRepository) GetLLMCalls(ctx context.Context, filter LLMCallFilter) ([]*models.LLMCall, error) {
+	collection := r.db.Collection("llm_calls")
+
+	query := bson.M{}
+	if filter.JobID != "" {
+		query["job_id"] = filter.JobID
+	}
+	if filter.Step != "" {
+		query["step"] = filter.Step
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+	cursor, err := collection.Find(ctx, tenantFilter(ctx, query), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	calls := []*models.LLMCall{}
+	if err = cursor.All(ctx, &calls); err != nil {
+		return nil, err
+	}
+
+	return calls, nil
+}
+
+func (r *MongoDBRepository) DeleteLLMCalls(ctx context.Context, jobIDs []string) (int64, error) {
+	collection := r.db.Collection("llm_calls")
+
+	result, err := collection.DeleteMany(ctx, tenantFilter(ctx, bson.M{"job_id": bson.M{"$in": jobIDs}}))
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *MongoDBRepository) RecordUpload(ctx context.Context, upload *models.Upload) (*models.Upload, error) {
+	collection := r.db.Collection("uploads")
+	stampOrgID(ctx, &upload.OrgID)
+
+	// The unique index on org_id and filename makes concurrent uploads of the same file share one record
+	filter := bson.M{"org_id": upload.OrgID, "filename": upload.Filename}
+	update := bson.M{"$setOnInsert": upload}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var stored models.Upload
+	if err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (r *MongoDBRepository) DeleteUploads(ctx context.Context, filenames []string) (int64, error) {
+	collection := r.db.Collection("uploads")
+
+	result, err := collection.DeleteMany(ctx, tenantFilter(ctx, bson.M{"filename": bson.M{"$in": filenames}}))
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *MongoDBRepository) CountUploads(ctx context.Context, filename string) (int64, error) {
+	return r.db.Collection("uploads").CountDocuments(ctx, bson.M{"filename": filename})
+}
+
+func (r *MongoDBRepository) FindUploadsBefore(ctx context.Context, before time.Time) ([]*models.Upload, error) {
+	cursor, err := r.db.Collection("uploads").Find(ctx, tenantFilter(ctx, bson.M{"created_at": bson.M{"$lt": before}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	uploads := []*models.Upload{}
+	if err = cursor.All(ctx, &uploads); err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
+func (r *MongoDBRepository) FindReferencedFiles(ctx context.Context, filenames []string, excludeJobIDs []string) ([]string, error) {
+	filter := tenantFilter(ctx, bson.M{"$or": []bson.M{
+		{"cv_file": bson.M{"$in": filenames}},
+		{"project_file": bson.M{"$in": filenames}},
+	}})
+	if len(excludeJobIDs) > 0 {
+		excluded := make([]primitive.ObjectID, 0, len(excludeJobIDs))
+		for _, id := range excludeJobIDs {
+			if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+				excluded = append(excluded, objectID)
+			}
+		}
+		filter["_id"] = bson.M{"$nin": excluded}
+	}
+	opts := options.Find().SetProjection(bson.M{"cv_file": 1, "project_file": 1})
+
+	wanted := map[string]bool{}
+	for _, name := range filenames {
+		wanted[name] = true
+	}
+	referenced := map[string]bool{}
+	for _, name := range []string{"evaluation_jobs", "evaluation_jobs_archive"} {
+		cursor, err := r.db.Collection(name).Find(ctx, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		for cursor.Next(ctx) {
+			var job struct {
+				CVFile      string `bson:"cv_file"`
+				ProjectFile string `bson:"project_file"`
+			}
+			if err := cursor.Decode(&job); err != nil {
+				cursor.Close(ctx)
+				return nil, err
+			}
+			for _, name := range []string{job.CVFile, job.ProjectFile} {
+				if wanted[name] {
+					referenced[name] = true
+				}
+			}
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	files := make([]string, 0, len(referenced))
+	for name := range referenced {
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+func (r *MongoDBRepository) ListUploads(ctx context.Context, limit, offset int) ([]*models.Upload, int64, error) {
+	collection := r.db.Collection("uploads")
+	filter := tenantFilter(ctx, bson.M{})
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetSkip(int64(offset))
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	uploads := []*models.Upload{}
+	if err = cursor.All(ctx, &uploads); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return uploads, total, nil
+}
+
+func (r *MongoDBRepository) GetUpload(ctx context.Context, id string) (*models.Upload, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var upload models.Upload
+	if err := r.db.Collection("uploads").FindOne(ctx, tenantFilter(ctx, bson.M{"_id": objectID})).Decode(&upload); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (r *MongoDBRepository) CreateErasureRecord(ctx context.Context, record *models.ErasureRecord) error {
+	collection := r.db.Collection("erasure_records")
+	stampOrgID(ctx, &record.OrgID)
+	result, err := collection.InsertOne(ctx, record)
+	if err != nil {
+		return err
+	}
+	record.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoDBRepository) GetErasureRecords(ctx context.Context) ([]*models.ErasureRecord, error) {
+	collection := r.db.Collection("erasure_records")
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := collection.Find(ctx, tenantFilter(ctx, bson.M{}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	records := []*models.ErasureRecord{}
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// IncrementUsageTotals adds one evaluation attempt's usage to the day's totals of each model
+func (r *MongoDBRepository) IncrementUsageTotals(ctx context.Context, date, orgID string, usage []models.ModelUsage) error {
+	collection := r.db.Collection("usage_totals")
+
+	for _, u := range usage {
+		filter := bson.M{"date": date, "org_id": orgID, "model": u.Model}
+		update := bson.M{
+			"$inc": bson.M{
+				"evaluations":        1,
+				"calls":              u.Calls,
+				"prompt_tokens":      u.PromptTokens,
+				"completion_tokens":  u.CompletionTokens,
+				"total_tokens":       u.TotalTokens,
+				"estimated_cost_usd": u.EstimatedCostUSD,
+			},
+		}
+		if _, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetUsageTotals returns the usage totals matching filter, ordered by date, organization and model
+func (r *MongoDBRepository) GetUsageTotals(ctx context.Context, filter UsageFilter) ([]*models.UsageTotal, error) {
+	collection := r.db.Collection("usage_totals")
+
+	query := bson.M{}
+	dateRange := bson.M{}
+	if filter.From != "" {
+		dateRange["$gte"] = filter.From
+	}
+	if filter.To != "" {
+		dateRange["$lte"] = filter.To
+	}
+	if len(dateRange) > 0 {
+		query["date"] = dateRange
+	}
+	if filter.OrgID != "" {
+		query["org_id"] = filter.OrgID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "org_id", Value: 1}, {Key: "model", Value: 1}})
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	totals := []*models.UsageTotal{}
+	if err = cursor.All(ctx, &totals); err != nil {
+		return nil, err
+	}
+
+	return totals, nil
 }
@@ -0,0 +1,1830 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// postgresMigration is a PostgreSQL schema change, applied in a transaction with its record
+type postgresMigration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+// postgresMigrations create and evolve the schema. Every table keeps the full document as JSONB, plus
+// the columns it is filtered and sorted by; writers keep both in sync. Append new migrations with the
+// next version; never edit or reorder applied ones.
+var postgresMigrations = []postgresMigration{
+	{1, "create tables", []string{
+		`CREATE TABLE IF NOT EXISTS evaluation_jobs (
+			id TEXT PRIMARY KEY,
+			org_id TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			candidate_id TEXT NOT NULL DEFAULT '',
+			job_description_id TEXT NOT NULL DEFAULT '',
+			cv_hash TEXT NOT NULL DEFAULT '',
+			content_hash TEXT NOT NULL DEFAULT '',
+			sandbox BOOLEAN NOT NULL DEFAULT FALSE,
+			overall_score DOUBLE PRECISION,
+			created_at TIMESTAMPTZ NOT NULL,
+			started_at TIMESTAMPTZ,
+			completed_at TIMESTAMPTZ,
+			doc JSONB NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS evaluation_jobs_status_created_at ON evaluation_jobs (status, created_at)`,
+		`CREATE INDEX IF NOT EXISTS evaluation_jobs_org_id_created_at ON evaluation_jobs (org_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS evaluation_jobs_candidate_id ON evaluation_jobs (candidate_id)`,
+		`CREATE INDEX IF NOT EXISTS evaluation_jobs_cv_hash ON evaluation_jobs (cv_hash)`,
+		`CREATE INDEX IF NOT EXISTS evaluation_jobs_content_hash ON evaluation_jobs (content_hash)`,
+		`CREATE TABLE IF NOT EXISTS archived_jobs (LIKE evaluation_jobs INCLUDING ALL)`,
+		`CREATE TABLE IF NOT EXISTS batch_jobs (
+			id TEXT PRIMARY KEY,
+			org_id TEXT NOT NULL DEFAULT '',
+			doc JSONB NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS job_descriptions (
+			id TEXT PRIMARY KEY,
+			org_id TEXT NOT NULL DEFAULT '',
+			doc JSONB NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS document_chunks (
+			id TEXT PRIMARY KEY,
+			org_id TEXT NOT NULL DEFAULT '',
+			document_id TEXT NOT NULL,
+			index INTEGER NOT NULL,
+			doc JSONB NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS document_chunks_document_id ON document_chunks (document_id, index)`,
+		`CREATE TABLE IF NOT EXISTS index_rebuilds (
+			id TEXT PRIMARY KEY,
+			started_at TIMESTAMPTZ NOT NULL,
+			doc JSONB NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS golden_jobs (
+			id TEXT PRIMARY KEY,
+			doc JSONB NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS prompt_templates (
+			name TEXT PRIMARY KEY,
+			doc JSONB NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS prompt_template_versions (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			doc JSONB NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS prompt_template_versions_name ON prompt_template_versions (name, version)`,
+		`CREATE TABLE IF NOT EXISTS scoring_rubrics (
+			id TEXT PRIMARY KEY,
+			org_id TEXT NOT NULL DEFAULT '',
+			name TEXT NOT NULL,
+			doc JSONB NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS organizations (
+			id TEXT PRIMARY KEY,
+			api_key_hash TEXT NOT NULL DEFAULT '',
+			doc JSONB NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS usage_totals (
+			date TEXT NOT NULL,
+			org_id TEXT NOT NULL,
+			model TEXT NOT NULL,
+			doc JSONB NOT NULL,
+			PRIMARY KEY (date, org_id, model)
+		)`,
+		`CREATE TABLE IF NOT EXISTS embedding_cache (
+			key TEXT PRIMARY KEY,
+			expires_at TIMESTAMPTZ NOT NULL,
+			doc JSONB NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS embedding_cache_expires_at ON embedding_cache (expires_at)`,
+		`CREATE TABLE IF NOT EXISTS llm_calls (
+			id TEXT PRIMARY KEY,
+			org_id TEXT NOT NULL DEFAULT '',
+			job_id TEXT NOT NULL DEFAULT '',
+			step TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL,
+			doc JSONB NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS llm_calls_job_id ON llm_calls (job_id, created_at)`,
+	}},
+	{2, "index job descriptions by title and make organization API keys unique", []string{
+		`CREATE INDEX IF NOT EXISTS job_descriptions_title ON job_descriptions ((doc->>'title'))`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS organizations_api_key_hash ON organizations (api_key_hash) WHERE api_key_hash <> ''`,
+	}},
+	{3, "index job feedback for text search", []string{
+		`CREATE INDEX IF NOT EXISTS evaluation_jobs_feedback ON evaluation_jobs USING GIN (` + jobFeedbackVector + `)`,
+	}},
+	{4, "soft-delete jobs", []string{
+		`ALTER TABLE evaluation_jobs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`,
+		`ALTER TABLE archived_jobs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`,
+	}},
+	{5, "record erasure requests", []string{
+		`CREATE TABLE IF NOT EXISTS erasure_records (
+			id TEXT PRIMARY KEY,
+			org_id TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL,
+			doc JSONB NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS erasure_records_org_id_created_at ON erasure_records (org_id, created_at)`,
+	}},
+	{6, "store fairness reports", []string{
+		`CREATE TABLE IF NOT EXISTS fairness_reports (
+			id TEXT PRIMARY KEY,
+			started_at TIMESTAMPTZ NOT NULL,
+			doc JSONB NOT NULL
+		)`,
+	}},
+	{7, "store upload metadata", []string{
+		`CREATE TABLE IF NOT EXISTS uploads (
+			id TEXT PRIMARY KEY,
+			org_id TEXT NOT NULL DEFAULT '',
+			filename TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			doc JSONB NOT NULL
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS uploads_org_id_filename ON uploads (org_id, filename)`,
+		`CREATE INDEX IF NOT EXISTS uploads_filename ON uploads (filename)`,
+	}},
+	{8, "list uploads by organization", []string{
+		`CREATE INDEX IF NOT EXISTS uploads_org_id_created_at ON uploads (org_id, created_at DESC)`,
+	}},
+	{9, "find jobs by uploaded file", []string{
+		`CREATE INDEX IF NOT EXISTS evaluation_jobs_cv_file ON evaluation_jobs ((doc->>'cv_file'))`,
+		`CREATE INDEX IF NOT EXISTS evaluation_jobs_project_file ON evaluation_jobs ((doc->>'project_file'))`,
+		`CREATE INDEX IF NOT EXISTS archived_jobs_cv_file ON archived_jobs ((doc->>'cv_file'))`,
+		`CREATE INDEX IF NOT EXISTS archived_jobs_project_file ON archived_jobs ((doc->>'project_file'))`,
+	}},
+	{10, "unique job idempotency keys", []string{
+		`CREATE UNIQUE INDEX IF NOT EXISTS evaluation_jobs_idempotency_key ON evaluation_jobs (org_id, (doc->>'idempotency_key'))
+			WHERE doc ? 'idempotency_key'`,
+	}},
+	{11, "find jobs with a pending enqueue", []string{
+		`CREATE INDEX IF NOT EXISTS evaluation_jobs_enqueue_pending ON evaluation_jobs (created_at)
+			WHERE doc->>'enqueue_pending' = 'true'`,
+	}},
+	{12, "store reference documents", []string{
+		`CREATE TABLE IF NOT EXISTS reference_documents (
+			id TEXT PRIMARY KEY,
+			org_id TEXT NOT NULL DEFAULT '',
+			type TEXT NOT NULL,
+			doc JSONB NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS reference_documents_org_id_type ON reference_documents (org_id, type)`,
+	}},
+	{13, "store experiments", []string{
+		`CREATE TABLE IF NOT EXISTS experiments (
+			id TEXT PRIMARY KEY,
+			created_at TIMESTAMPTZ NOT NULL,
+			doc JSONB NOT NULL
+		)`,
+	}},
+	{14, "store calibration runs", []string{
+		`CREATE TABLE IF NOT EXISTS calibration_runs (
+			id TEXT PRIMARY KEY,
+			started_at TIMESTAMPTZ NOT NULL,
+			doc JSONB NOT NULL
+		)`,
+	}},
+}
+
+// jobFeedbackVector is the text search vector of a job's feedback and summary; queries must use the
+// same expression to be served by its index
+const jobFeedbackVector = `to_tsvector('english', coalesce(doc->'result'->>'cv_feedback', '') || ' ' ||
+	coalesce(doc->'result'->>'project_feedback', '') || ' ' || coalesce(doc->'result'->>'overall_summary', ''))`
+
+// jobScoreColumns maps ScoreRange fields to job column expressions
+var jobScoreColumns = map[string]string{
+	"result.cv_match_rate": "(doc->'result'->>'cv_match_rate')::double precision",
+	"result.project_score": "(doc->'result'->>'project_score')::double precision",
+	"result.overall_score": "overall_score",
+}
+
+// jobColumns are the columns written for every job; saveJob passes its values in the same order
+const jobColumns = "id, org_id, status, candidate_id, job_description_id, cv_hash, content_hash, sandbox, overall_score, created_at, started_at, completed_at, deleted_at, doc"
+
+// jobSortColumns maps the sort fields of JobListOptions to job columns
+var jobSortColumns = map[string]string{
+	"created_at":           "created_at",
+	"completed_at":         "completed_at",
+	"result.overall_score": "overall_score",
+}
+
+// PostgresRepository stores documents in PostgreSQL for deployments that do not run MongoDB
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// querier runs statements on the pool or within a transaction
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// NewPostgresRepository connects to the database at url; Migrate creates the tables
+func NewPostgresRepository(ctx context.Context, url string) (*PostgresRepository, error) {
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres pool: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return &PostgresRepository{pool: pool}, nil
+}
+
+// Migrate applies pending migrations in version order and returns the ones it applied
+func (r *PostgresRepository) Migrate(ctx context.Context) ([]Migration, error) {
+	if _, err := r.pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS migrations (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	applied := []Migration{}
+	for _, m := range postgresMigrations {
+		migration := Migration{Version: m.version, Description: m.description, AppliedAt: time.Now()}
+		ok, err := r.applyMigration(ctx, m, migration)
+		if err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+		if ok {
+			applied = append(applied, migration)
+		}
+	}
+
+	return applied, nil
+}
+
+// applyMigration runs a migration unless it is already recorded. The record is inserted first, so an
+// instance starting at the same time waits for the other's transaction and then skips it.
+func (r *PostgresRepository) applyMigration(ctx context.Context, m postgresMigration, migration Migration) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, "INSERT INTO migrations (version, description, applied_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+		migration.Version, migration.Description, migration.AppliedAt)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	for _, statement := range m.statements {
+		if _, err := tx.Exec(ctx, statement); err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// Close releases the connection pool
+func (r *PostgresRepository) Close() {
+	r.pool.Close()
+}
+
+// pgWhere builds a WHERE clause with numbered placeholders
+type pgWhere struct {
+	conds []string
+	args  []interface{}
+}
+
+// add appends a condition whose "?" placeholders take args in order
+func (w *pgWhere) add(cond string, args ...interface{}) *pgWhere {
+	for _, arg := range args {
+		w.args = append(w.args, arg)
+		cond = strings.Replace(cond, "?", "$"+strconv.Itoa(len(w.args)), 1)
+	}
+	w.conds = append(w.conds, cond)
+	return w
+}
+
+// arg adds a value used outside the conditions, e.g. in LIMIT, and returns its placeholder
+func (w *pgWhere) arg(value interface{}) string {
+	w.args = append(w.args, value)
+	return "$" + strconv.Itoa(len(w.args))
+}
+
+func (w *pgWhere) String() string {
+	if len(w.conds) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(w.conds, " AND ")
+}
+
+// tenantWhere scopes a query to the context's organization, see tenantFilter
+func tenantWhere(ctx context.Context) *pgWhere {
+	w := &pgWhere{}
+	if orgID := tenant.OrgID(ctx); orgID != "" {
+		w.add("org_id = ?", orgID)
+	}
+	return w
+}
+
+// liveJobWhere scopes a job query to the context's organization and hides soft-deleted jobs
+func liveJobWhere(ctx context.Context) *pgWhere {
+	return tenantWhere(ctx).add("deleted_at IS NULL")
+}
+
+// sharedWhere scopes a query on shareable documents, see sharedFilter
+func sharedWhere(ctx context.Context) *pgWhere {
+	w := &pgWhere{}
+	if orgID, scoped := tenant.Scope(ctx); scoped {
+		w.add("org_id IN (?, '')", orgID)
+	}
+	return w
+}
+
+// getDoc decodes the single doc column returned by query, reporting ErrNotFound when there is no row
+func getDoc[T any](ctx context.Context, q querier, query string, args ...interface{}) (*T, error) {
+	var content []byte
+	if err := q.QueryRow(ctx, query, args...).Scan(&content); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var doc T
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+	return &doc, nil
+}
+
+// findDocs decodes the doc column of every row returned by query
+func findDocs[T any](ctx context.Context, q querier, query string, args ...interface{}) ([]*T, error) {
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*T
+	for rows.Next() {
+		var content []byte
+		if err := rows.Scan(&content); err != nil {
+			return nil, err
+		}
+
+		var doc T
+		if err := json.Unmarshal(content, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decode document: %w", err)
+		}
+		docs = append(docs, &doc)
+	}
+
+	return docs, rows.Err()
+}
+
+// encodeDoc encodes a document for a JSONB column
+func encodeDoc(doc interface{}) ([]byte, error) {
+	content, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	return content, nil
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	return r.pool.Ping(ctx)
+}
+
+// saveJob inserts or replaces a job document in table along with its query columns
+func saveJob(ctx context.Context, q querier, table string, job *models.EvaluationJob) error {
+	doc, err := encodeDoc(job)
+	if err != nil {
+		return err
+	}
+
+	var overallScore *float64
+	if job.Result != nil {
+		overallScore = &job.Result.OverallScore
+	}
+
+	_, err = q.Exec(ctx, `INSERT INTO `+table+` (`+jobColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (id) DO UPDATE SET
+			org_id = EXCLUDED.org_id, status = EXCLUDED.status, candidate_id = EXCLUDED.candidate_id,
+			job_description_id = EXCLUDED.job_description_id, cv_hash = EXCLUDED.cv_hash,
+			content_hash = EXCLUDED.content_hash, sandbox = EXCLUDED.sandbox, overall_score = EXCLUDED.overall_score,
+			created_at = EXCLUDED.created_at, started_at = EXCLUDED.started_at, completed_at = EXCLUDED.completed_at,
+			deleted_at = EXCLUDED.deleted_at, doc = EXCLUDED.doc`,
+		job.ID.Hex(), job.OrgID, string(job.Status), job.CandidateID, job.JobDescriptionID, job.CVHash,
+		job.ContentHash, job.Sandbox, overallScore, job.CreatedAt, job.StartedAt, job.CompletedAt, job.DeletedAt, doc)
+	return err
+}
+
+// updateJob applies fn to a stored job within a transaction and saves the result; an error from fn aborts the update
+func (r *PostgresRepository) updateJob(ctx context.Context, id string, fn func(job *models.EvaluationJob) error) error {
+	return r.updateJobIn(ctx, "evaluation_jobs", id, fn)
+}
+
+// updateJobIn is updateJob for a job in the given table, e.g. archived_jobs
+func (r *PostgresRepository) updateJobIn(ctx context.Context, table, id string, fn func(job *models.EvaluationJob) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	w := tenantWhere(ctx).add("id = ?", id)
+	job, err := getDoc[models.EvaluationJob](ctx, tx, "SELECT doc FROM "+table+" WHERE "+w.String()+" FOR UPDATE", w.args...)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(job); err != nil {
+		return err
+	}
+	if err := saveJob(ctx, tx, table, job); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Job Repository Methods
+func (r *PostgresRepository) CreateJob(ctx context.Context, job *models.EvaluationJob) (interface{}, error) {
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &job.OrgID)
+
+	if err := saveJob(ctx, r.pool, "evaluation_jobs", job); err != nil {
+		if job.IdempotencyKey != "" && isUniqueViolation(err) {
+			return nil, ErrDuplicateIdempotencyKey
+		}
+		return nil, err
+	}
+	return job.ID, nil
+}
+
+func (r *PostgresRepository) FindJobByIdempotencyKey(ctx context.Context, key string) (*models.EvaluationJob, error) {
+	w := tenantWhere(ctx).add("doc->>'idempotency_key' = ?", key)
+	return getDoc[models.EvaluationJob](ctx, r.pool, "SELECT doc FROM evaluation_jobs WHERE "+w.String(), w.args...)
+}
+
+func (r *PostgresRepository) GetJobByID(ctx context.Context, id string) (*models.EvaluationJob, error) {
+	w := liveJobWhere(ctx).add("id = ?", id)
+	return getDoc[models.EvaluationJob](ctx, r.pool, "SELECT doc FROM evaluation_jobs WHERE "+w.String(), w.args...)
+}
+
+func (r *PostgresRepository) GetJobsByIDs(ctx context.Context, ids []string) ([]*models.EvaluationJob, error) {
+	w := liveJobWhere(ctx).add("id = ANY(?)", ids)
+	jobs, err := findDocs[models.EvaluationJob](ctx, r.pool, "SELECT doc FROM evaluation_jobs WHERE "+w.String(), w.args...)
+	if jobs == nil && err == nil {
+		jobs = []*models.EvaluationJob{}
+	}
+	return jobs, err
+}
+
+func (r *PostgresRepository) UpdateJobStatus(ctx context.Context, id string, status models.JobStatus) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		now := time.Now()
+		job.Status = status
+		job.UpdatedAt = now
+
+		if status == models.StatusProcessing {
+			job.StartedAt = &now
+		} else if status == models.StatusCompleted || status == models.StatusFailed {
+			job.CompletedAt = &now
+		}
+		return nil
+	})
+}
+
+func (r *PostgresRepository) UpdateJobResult(ctx context.Context, id, claimToken string, result *models.EvaluationResult) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		if !ownsClaim(job, claimToken) {
+			return ErrNotFound
+		}
+
+		now := time.Now()
+		if job.Result != nil {
+			job.ResultHistory = append(job.ResultHistory, job.Result)
+		}
+		job.Result = result
+		job.Status = models.StatusCompleted
+		job.UpdatedAt = now
+		job.CompletedAt = &now
+		return nil
+	})
+}
+
+func (r *PostgresRepository) UpdateJobError(ctx context.Context, id, claimToken string, errorType models.ErrorType, errorMessage string) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		if !ownsClaim(job, claimToken) {
+			return ErrNotFound
+		}
+
+		now := time.Now()
+		job.ErrorMessage = errorMessage
+		job.ErrorType = errorType
+		job.Status = models.StatusFailed
+		job.UpdatedAt = now
+		job.CompletedAt = &now
+		return nil
+	})
+}
+
+// UpdateJobSteps replaces a job's pipeline steps
+func (r *PostgresRepository) UpdateJobSteps(ctx context.Context, id string, steps []models.JobStep) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		job.Steps = steps
+		job.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// UpdateJobStep replaces one pipeline step, matched by name, on a job
+func (r *PostgresRepository) UpdateJobStep(ctx context.Context, id string, step models.JobStep) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		for i := range job.Steps {
+			if job.Steps[i].Name == step.Name {
+				job.Steps[i] = step
+				job.UpdatedAt = time.Now()
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+}
+
+// UpdateJobUsage records the LLM usage of a job's latest evaluation attempt
+func (r *PostgresRepository) UpdateJobUsage(ctx context.Context, id string, usage *models.JobUsage) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		job.Usage = usage
+		job.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// UpdateJobRetrieval stores the context retrieved for each step of a job's evaluation
+func (r *PostgresRepository) UpdateJobRetrieval(ctx context.Context, id string, retrieval []models.StepRetrieval) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		job.Retrieval = retrieval
+		job.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// UpdateJobParsedCV stores the structured content of a job's CV
+func (r *PostgresRepository) UpdateJobParsedCV(ctx context.Context, id string, parsed *models.ParsedResume) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		job.ParsedCV = parsed
+		job.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// UpdateJobProjectEmbedding stores the embedding of a job's project report
+func (r *PostgresRepository) UpdateJobProjectEmbedding(ctx context.Context, id string, embedding []float64, model string) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		job.ProjectEmbedding = embedding
+		job.ProjectEmbeddingModel = model
+		job.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+func (r *PostgresRepository) IncrementRetryCount(ctx context.Context, id string) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		job.RetryCount++
+		job.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+func (r *PostgresRepository) GetPendingJobs(ctx context.Context) ([]*models.EvaluationJob, error) {
+	w := liveJobWhere(ctx).add("status IN (?, ?)", string(models.StatusQueued), string(models.StatusProcessing))
+	return findDocs[models.EvaluationJob](ctx, r.pool, "SELECT doc FROM evaluation_jobs WHERE "+w.String(), w.args...)
+}
+
+// GetStuckJobs returns processing jobs that started before the given time
+func (r *PostgresRepository) GetStuckJobs(ctx context.Context, startedBefore time.Time) ([]*models.EvaluationJob, error) {
+	w := liveJobWhere(ctx).
+		add("status = ?", string(models.StatusProcessing)).
+		add("started_at < ?", startedBefore)
+	return findDocs[models.EvaluationJob](ctx, r.pool, "SELECT doc FROM evaluation_jobs WHERE "+w.String(), w.args...)
+}
+
+// RequeueStuckJob moves a processing job back to queued and counts the attempt. It returns ErrNotFound
+// when the job is no longer processing.
+func (r *PostgresRepository) RequeueStuckJob(ctx context.Context, id string) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		if job.Status != models.StatusProcessing {
+			return ErrNotFound
+		}
+
+		job.Status = models.StatusQueued
+		job.ClaimToken = ""
+		job.StartedAt = nil
+		job.Steps = nil
+		job.RetryCount++
+		job.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// ReopenJob queues a completed or failed live job again for a re-evaluation with the given content hash,
+// moving its result to the history. It returns ErrNotFound when the job is not completed or failed.
+func (r *PostgresRepository) ReopenJob(ctx context.Context, id, contentHash string) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		if job.DeletedAt != nil || (job.Status != models.StatusCompleted && job.Status != models.StatusFailed) {
+			return ErrNotFound
+		}
+
+		reopenJob(job, contentHash)
+		return nil
+	})
+}
+
+// ClaimJob moves a queued job to processing. It returns ErrNotFound when the job is not queued, e.g.
+// because another worker claimed it first.
+func (r *PostgresRepository) ClaimJob(ctx context.Context, id string) (string, error) {
+	// updateJob locks the row, so of two workers claiming the job only the first sees it queued
+	claimToken := newClaimToken()
+	err := r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		if job.Status != models.StatusQueued {
+			return ErrNotFound
+		}
+
+		now := time.Now()
+		job.Status = models.StatusProcessing
+		job.ClaimToken = claimToken
+		job.StartedAt = &now
+		job.UpdatedAt = now
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return claimToken, nil
+}
+
+// GetUndispatchedJobs returns the live jobs, last updated before the given time, whose enqueue is still pending
+func (r *PostgresRepository) GetUndispatchedJobs(ctx context.Context, updatedBefore time.Time) ([]*models.EvaluationJob, error) {
+	w := liveJobWhere(ctx).
+		add("doc->>'enqueue_pending' = 'true'").
+		add("(doc->>'updated_at')::timestamptz < ?", updatedBefore)
+	return findDocs[models.EvaluationJob](ctx, r.pool, "SELECT doc FROM evaluation_jobs WHERE "+w.String()+" ORDER BY created_at", w.args...)
+}
+
+// MarkJobEnqueued clears a job's pending enqueue once its ID is on the queue
+func (r *PostgresRepository) MarkJobEnqueued(ctx context.Context, id string) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		job.EnqueuePending = false
+		return nil
+	})
+}
+
+// FindRecentJobByCVHash returns the newest non-failed job created since the given time for the same CV
+// and job description
+func (r *PostgresRepository) FindRecentJobByCVHash(ctx context.Context, cvHash, jobDescriptionID string, sandbox bool, since time.Time) (*models.EvaluationJob, error) {
+	w := liveJobWhere(ctx).
+		add("cv_hash = ?", cvHash).
+		add("job_description_id = ?", jobDescriptionID).
+		add("sandbox = ?", sandbox).
+		add("status <> ?", string(models.StatusFailed)).
+		add("created_at >= ?", since)
+	return getDoc[models.EvaluationJob](ctx, r.pool,
+		"SELECT doc FROM evaluation_jobs WHERE "+w.String()+" ORDER BY created_at DESC LIMIT 1", w.args...)
+}
+
+func (r *PostgresRepository) FindProjectEmbeddings(ctx context.Context, model string, sandbox bool) ([]*ProjectEmbedding, error) {
+	w := liveJobWhere(ctx).
+		add("sandbox = ?", sandbox).
+		add("doc->>'project_embedding_model' = ?", model)
+	rows, err := r.pool.Query(ctx, `SELECT id, candidate_id, cv_hash, COALESCE(doc->>'project_hash', ''), doc->'project_embedding'
+		FROM evaluation_jobs WHERE `+w.String(), w.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByPos[ProjectEmbedding])
+}
+
+// FindCompletedJobByContentHash returns the newest job completed since the given time with the same content hash
+func (r *PostgresRepository) FindCompletedJobByContentHash(ctx context.Context, contentHash string, since time.Time) (*models.EvaluationJob, error) {
+	w := liveJobWhere(ctx).
+		add("content_hash = ?", contentHash).
+		add("status = ?", string(models.StatusCompleted)).
+		add("completed_at >= ?", since)
+	return getDoc[models.EvaluationJob](ctx, r.pool,
+		"SELECT doc FROM evaluation_jobs WHERE "+w.String()+" ORDER BY completed_at DESC LIMIT 1", w.args...)
+}
+
+func (r *PostgresRepository) GetJobsWithFilters(ctx context.Context, opts JobListOptions) ([]*models.EvaluationJob, error) {
+	w := jobListWhere(ctx, opts)
+	if opts.After != "" {
+		if err := r.whereAfter(ctx, w, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	query := "SELECT doc FROM evaluation_jobs WHERE " + w.String() + jobListOrder(opts)
+	if opts.Limit > 0 {
+		query += " LIMIT " + w.arg(opts.Limit)
+	}
+	if opts.Offset > 0 {
+		query += " OFFSET " + w.arg(opts.Offset)
+	}
+
+	return findDocs[models.EvaluationJob](ctx, r.pool, query, w.args...)
+}
+
+func (r *PostgresRepository) CountJobs(ctx context.Context, opts JobListOptions) (int64, error) {
+	w := jobListWhere(ctx, opts)
+
+	var count int64
+	err := r.pool.QueryRow(ctx, "SELECT count(*) FROM evaluation_jobs WHERE "+w.String(), w.args...).Scan(&count)
+	return count, err
+}
+
+// whereAfter restricts w to the jobs listed after opts.After. The cursor's sort value is read in SQL
+// rather than from its document, which has more precise timestamps than the column.
+func (r *PostgresRepository) whereAfter(ctx context.Context, w *pgWhere, opts JobListOptions) error {
+	column := jobSortColumn(opts)
+
+	var missing bool
+	cursor := liveJobWhere(ctx).add("id = ?", opts.After)
+	err := r.pool.QueryRow(ctx, "SELECT "+column+" IS NULL FROM evaluation_jobs WHERE "+cursor.String(), cursor.args...).Scan(&missing)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrInvalidCursor
+	}
+	if err != nil {
+		return err
+	}
+
+	// Jobs without a sort value come last in descending order and first in ascending order
+	value := "(SELECT " + column + " FROM evaluation_jobs WHERE id = ?)"
+	switch {
+	case missing && opts.ascending():
+		w.add("(("+column+" IS NULL AND id > ?) OR "+column+" IS NOT NULL)", opts.After)
+	case missing:
+		w.add(column+" IS NULL AND id < ?", opts.After)
+	case opts.ascending():
+		w.add("("+column+", id) > ("+value+", ?)", opts.After, opts.After)
+	default:
+		w.add("(("+column+", id) < ("+value+", ?) OR "+column+" IS NULL)", opts.After, opts.After)
+	}
+	return nil
+}
+
+// StreamJobs calls fn with each matching job in order, reading them from a cursor
+func (r *PostgresRepository) StreamJobs(ctx context.Context, opts JobListOptions, fn func(*models.EvaluationJob) error) error {
+	w := jobListWhere(ctx, opts)
+	rows, err := r.pool.Query(ctx, "SELECT doc FROM evaluation_jobs WHERE "+w.String()+jobListOrder(opts), w.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var content []byte
+		if err := rows.Scan(&content); err != nil {
+			return err
+		}
+
+		var job models.EvaluationJob
+		if err := json.Unmarshal(content, &job); err != nil {
+			return fmt.Errorf("failed to decode job: %w", err)
+		}
+		if err := fn(&job); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// jobListWhere selects the jobs matching the filters of opts
+func jobListWhere(ctx context.Context, opts JobListOptions) *pgWhere {
+	w := liveJobWhere(ctx)
+	if opts.Status != "" {
+		w.add("status = ?", opts.Status)
+	}
+	if opts.CandidateID != "" {
+		w.add("candidate_id = ?", opts.CandidateID)
+	}
+	if !opts.CreatedFrom.IsZero() {
+		w.add("created_at >= ?", opts.CreatedFrom)
+	}
+	if !opts.CreatedBefore.IsZero() {
+		w.add("created_at < ?", opts.CreatedBefore)
+	}
+
+	for _, r := range opts.ScoreRanges {
+		column, ok := jobScoreColumns[r.Field]
+		if !ok {
+			continue
+		}
+		w.add(column + " IS NOT NULL")
+		if r.Min != nil {
+			w.add(column+" >= ?", *r.Min)
+		}
+		if r.Max != nil {
+			w.add(column+" <= ?", *r.Max)
+		}
+	}
+
+	if opts.CandidateName != "" {
+		name := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(opts.CandidateName)
+		w.add("doc->>'candidate_name' ILIKE ?", "%"+name+"%")
+	}
+	if terms := searchTerms(opts.Search); len(terms) > 0 {
+		// Any of the words, like MongoDB's text search
+		w.add(jobFeedbackVector+" @@ to_tsquery('english', ?)", strings.Join(terms, " | "))
+	}
+	return w
+}
+
+// jobSortColumn returns the column jobs are ordered by
+func jobSortColumn(opts JobListOptions) string {
+	if column, ok := jobSortColumns[opts.sortField()]; ok {
+		return column
+	}
+	return "created_at"
+}
+
+// jobListOrder orders jobs like the other backends: jobs missing the sort value are the lowest, and
+// ties are broken by ID
+func jobListOrder(opts JobListOptions) string {
+	if opts.ascending() {
+		return " ORDER BY " + jobSortColumn(opts) + " ASC NULLS FIRST, id ASC"
+	}
+	return " ORDER BY " + jobSortColumn(opts) + " DESC NULLS LAST, id DESC"
+}
+
+func (r *PostgresRepository) GetScoreCounts(ctx context.Context, cohort ScoreCohort, score float64) (*ScoreCounts, error) {
+	w := liveJobWhere(ctx).
+		add("status = ?", string(models.StatusCompleted)).
+		add("overall_score IS NOT NULL").
+		add("job_description_id = ?", cohort.JobDescriptionID).
+		add("sandbox = ?", cohort.Sandbox)
+	scoreArg := w.arg(score)
+
+	counts := &ScoreCounts{}
+	err := r.pool.QueryRow(ctx, `SELECT count(*),
+			count(*) FILTER (WHERE overall_score < `+scoreArg+`),
+			count(*) FILTER (WHERE overall_score = `+scoreArg+`)
+		FROM evaluation_jobs WHERE `+w.String(), w.args...).Scan(&counts.Total, &counts.Below, &counts.Equal)
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// table returns the table holding the jobs the filter selects
+func (f JobBulkFilter) table() string {
+	if f.Archived {
+		return "archived_jobs"
+	}
+	return "evaluation_jobs"
+}
+
+// where selects the jobs matching the filter
+func (f JobBulkFilter) where(ctx context.Context) *pgWhere {
+	w := tenantWhere(ctx)
+	if f.Status != "" {
+		w.add("status = ?", f.Status)
+	}
+	if !f.OlderThan.IsZero() {
+		w.add("created_at < ?", f.OlderThan)
+	}
+	if !f.NewerThan.IsZero() {
+		w.add("created_at >= ?", f.NewerThan)
+	}
+	if f.CandidateID != "" {
+		w.add("candidate_id = ?", f.CandidateID)
+	}
+	if f.IDs != nil {
+		w.add("id = ANY(?)", f.IDs)
+	}
+	if !f.DeletedBefore.IsZero() {
+		w.add("deleted_at < ?", f.DeletedBefore)
+	}
+	if f.WithContent {
+		if f.Status == "" {
+			w.add("status IN (?, ?)", string(models.StatusCompleted), string(models.StatusFailed))
+		}
+		w.add("doc->>'content_erased_at' IS NULL")
+	}
+	return w
+}
+
+func (r *PostgresRepository) AnonymizeJob(ctx context.Context, id string, now time.Time) error {
+	anonymize := func(job *models.EvaluationJob) error {
+		job.Anonymize(now)
+		return nil
+	}
+	err := r.updateJob(ctx, id, anonymize)
+	if errors.Is(err, ErrNotFound) {
+		return r.updateJobIn(ctx, "archived_jobs", id, anonymize)
+	}
+	return err
+}
+
+// SoftDeleteJob marks a job deleted; it is hidden from every query until purged
+func (r *PostgresRepository) SoftDeleteJob(ctx context.Context, id string) error {
+	return r.updateJob(ctx, id, func(job *models.EvaluationJob) error {
+		if job.DeletedAt != nil {
+			return ErrNotFound
+		}
+
+		now := time.Now()
+		job.DeletedAt = &now
+		job.UpdatedAt = now
+		return nil
+	})
+}
+
+func (r *PostgresRepository) FindJobs(ctx context.Context, filter JobBulkFilter) ([]*models.EvaluationJob, error) {
+	w := filter.where(ctx)
+	jobs, err := findDocs[models.EvaluationJob](ctx, r.pool, "SELECT doc FROM "+filter.table()+" WHERE "+w.String(), w.args...)
+	if jobs == nil && err == nil {
+		jobs = []*models.EvaluationJob{}
+	}
+	return jobs, err
+}
+
+func (r *PostgresRepository) EraseJobContent(ctx context.Context, ids []string) (int64, error) {
+	var erased int64
+	for _, table := range []string{"evaluation_jobs", "archived_jobs"} {
+		w := tenantWhere(ctx).add("id = ANY(?)", ids)
+		now := w.arg(time.Now().Format(time.RFC3339Nano))
+
+		// The redaction mapping, blind documents, parsed CV and project embedding are taken from the documents, so they go with them
+		tag, err := r.pool.Exec(ctx, `UPDATE `+table+` SET doc = (doc || jsonb_build_object(
+				'cv_content', '', 'project_content', '', 'content_erased_at', `+now+`::text, 'updated_at', `+now+`::text))
+				#- '{result,redactions}' #- '{result,blind_cv_content}' #- '{result,blind_project_content}' #- '{parsed_cv}'
+				#- '{project_embedding}' #- '{project_embedding_model}'
+			WHERE `+w.String(), w.args...)
+		if err != nil {
+			return erased, err
+		}
+		erased += tag.RowsAffected()
+
+		// Earlier results keep the same data
+		w = tenantWhere(ctx).add("id = ANY(?)", ids).add("jsonb_array_length(doc->'result_history') > 0")
+		if _, err := r.pool.Exec(ctx, `UPDATE `+table+` SET doc = jsonb_set(doc, '{result_history}', (
+				SELECT jsonb_agg(result - 'redactions' - 'blind_cv_content' - 'blind_project_content' ORDER BY i)
+				FROM jsonb_array_elements(doc->'result_history') WITH ORDINALITY AS h(result, i)))
+			WHERE `+w.String(), w.args...); err != nil {
+			return erased, err
+		}
+	}
+	return erased, nil
+}
+
+func (r *PostgresRepository) FindJobIDs(ctx context.Context, filter JobBulkFilter) ([]string, error) {
+	w := filter.where(ctx)
+	rows, err := r.pool.Query(ctx, "SELECT id FROM evaluation_jobs WHERE "+w.String(), w.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if ids == nil && err == nil {
+		ids = []string{}
+	}
+	return ids, err
+}
+
+func (r *PostgresRepository) DeleteJobs(ctx context.Context, filter JobBulkFilter) (int64, error) {
+	w := filter.where(ctx)
+	tag, err := r.pool.Exec(ctx, "DELETE FROM "+filter.table()+" WHERE "+w.String(), w.args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *PostgresRepository) ArchiveJobs(ctx context.Context, filter JobBulkFilter) (int64, error) {
+	w := filter.where(ctx)
+	tag, err := r.pool.Exec(ctx, `WITH moved AS (DELETE FROM evaluation_jobs WHERE `+w.String()+` RETURNING `+jobColumns+`)
+		INSERT INTO archived_jobs (`+jobColumns+`) SELECT `+jobColumns+` FROM moved`, w.args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Batch Job Repository Methods
+func (r *PostgresRepository) CreateBatchJob(ctx context.Context, batch *models.BatchJob) error {
+	if batch.ID.IsZero() {
+		batch.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &batch.OrgID)
+
+	doc, err := encodeDoc(batch)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, "INSERT INTO batch_jobs (id, org_id, doc) VALUES ($1, $2, $3)", batch.ID.Hex(), batch.OrgID, doc)
+	return err
+}
+
+func (r *PostgresRepository) GetBatchJob(ctx context.Context, id string) (*models.BatchJob, error) {
+	w := tenantWhere(ctx).add("id = ?", id)
+	return getDoc[models.BatchJob](ctx, r.pool, "SELECT doc FROM batch_jobs WHERE "+w.String(), w.args...)
+}
+
+func (r *PostgresRepository) AnonymizeBatchItems(ctx context.Context, batchID string, jobIDs []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	w := tenantWhere(ctx).add("id = ?", batchID)
+	batch, err := getDoc[models.BatchJob](ctx, tx, "SELECT doc FROM batch_jobs WHERE "+w.String()+" FOR UPDATE", w.args...)
+	if err != nil {
+		return err
+	}
+	for i := range batch.Items {
+		if item := &batch.Items[i]; slices.Contains(jobIDs, item.JobID) {
+			item.CandidateID = ""
+			item.CVFile = ""
+			item.ProjectFile = ""
+			item.Source = ""
+		}
+	}
+
+	doc, err := encodeDoc(batch)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE batch_jobs SET doc = $1 WHERE id = $2", doc, batchID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Job Description Repository Methods
+func (r *PostgresRepository) CreateJobDescription(ctx context.Context, jobDesc *models.JobDescription) error {
+	if jobDesc.ID.IsZero() {
+		jobDesc.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &jobDesc.OrgID)
+
+	doc, err := encodeDoc(jobDesc)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, "INSERT INTO job_descriptions (id, org_id, doc) VALUES ($1, $2, $3)", jobDesc.ID.Hex(), jobDesc.OrgID, doc)
+	return err
+}
+
+func (r *PostgresRepository) GetJobDescription(ctx context.Context, id string) (*models.JobDescription, error) {
+	w := sharedWhere(ctx).add("id = ?", id)
+	return getDoc[models.JobDescription](ctx, r.pool, "SELECT doc FROM job_descriptions WHERE "+w.String(), w.args...)
+}
+
+func (r *PostgresRepository) GetAllJobDescriptions(ctx context.Context) ([]*models.JobDescription, error) {
+	w := sharedWhere(ctx)
+	return findDocs[models.JobDescription](ctx, r.pool, "SELECT doc FROM job_descriptions WHERE "+w.String()+" ORDER BY id", w.args...)
+}
+
+func (r *PostgresRepository) GetJobDescriptionVectors(ctx context.Context) ([]*models.JobDescription, error) {
+	w := sharedWhere(ctx)
+	return findDocs[models.JobDescription](ctx, r.pool, "SELECT doc - 'title' - 'description' - 'requirements' FROM job_descriptions WHERE "+w.String()+" ORDER BY id", w.args...)
+}
+
+// UpdateJobDescription replaces a job description's content and vector
+func (r *PostgresRepository) UpdateJobDescription(ctx context.Context, jobDesc *models.JobDescription) error {
+	doc, err := encodeDoc(jobDesc)
+	if err != nil {
+		return err
+	}
+
+	w := tenantWhere(ctx).add("id = ?", jobDesc.ID.Hex())
+	tag, err := r.pool.Exec(ctx, "UPDATE job_descriptions SET doc = "+w.arg(doc)+" WHERE "+w.String(), w.args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateJobDescriptionEmbedding replaces a job description's vector and records the model that produced it
+func (r *PostgresRepository) UpdateJobDescriptionEmbedding(ctx context.Context, id string, embedding []float64, model string) error {
+	fields, err := encodeDoc(map[string]interface{}{
+		"embedding":            embedding,
+		"embedding_model":      model,
+		"embedding_dimensions": len(embedding),
+		"embedding_norm":       embeddingNorm(embedding),
+	})
+	if err != nil {
+		return err
+	}
+
+	w := tenantWhere(ctx).add("id = ?", id)
+	tag, err := r.pool.Exec(ctx, "UPDATE job_descriptions SET doc = doc || "+w.arg(fields)+"::jsonb WHERE "+w.String(), w.args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) UpdateJobDescriptionThresholds(ctx context.Context, id string, thresholds *models.RecommendationThresholds) error {
+	update := map[string]interface{}{}
+	if thresholds != nil {
+		update["recommendation_thresholds"] = thresholds
+	}
+	fields, err := encodeDoc(update)
+	if err != nil {
+		return err
+	}
+
+	w := tenantWhere(ctx).add("id = ?", id)
+	tag, err := r.pool.Exec(ctx, "UPDATE job_descriptions SET doc = (doc - 'recommendation_thresholds') || "+w.arg(fields)+"::jsonb WHERE "+w.String(), w.args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) DeleteJobDescription(ctx context.Context, id string) error {
+	w := tenantWhere(ctx).add("id = ?", id)
+	tag, err := r.pool.Exec(ctx, "DELETE FROM job_descriptions WHERE "+w.String(), w.args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Reference Document Repository Methods
+func (r *PostgresRepository) CreateReferenceDocument(ctx context.Context, doc *models.ReferenceDocument) error {
+	if doc.ID.IsZero() {
+		doc.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &doc.OrgID)
+
+	encoded, err := encodeDoc(doc)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, "INSERT INTO reference_documents (id, org_id, type, doc) VALUES ($1, $2, $3, $4)", doc.ID.Hex(), doc.OrgID, doc.Type, encoded)
+	return err
+}
+
+func (r *PostgresRepository) GetReferenceDocument(ctx context.Context, id string) (*models.ReferenceDocument, error) {
+	w := sharedWhere(ctx).add("id = ?", id)
+	return getDoc[models.ReferenceDocument](ctx, r.pool, "SELECT doc FROM reference_documents WHERE "+w.String(), w.args...)
+}
+
+func (r *PostgresRepository) GetReferenceDocuments(ctx context.Context, docType string) ([]*models.ReferenceDocument, error) {
+	w := sharedWhere(ctx)
+	if docType != "" {
+		w.add("type = ?", docType)
+	}
+	return findDocs[models.ReferenceDocument](ctx, r.pool, "SELECT doc FROM reference_documents WHERE "+w.String()+" ORDER BY id", w.args...)
+}
+
+func (r *PostgresRepository) DeleteReferenceDocument(ctx context.Context, id string) error {
+	w := tenantWhere(ctx).add("id = ?", id)
+	tag, err := r.pool.Exec(ctx, "DELETE FROM reference_documents WHERE "+w.String(), w.args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Document Chunk Repository Methods
+func (r *PostgresRepository) ReplaceDocumentChunks(ctx context.Context, documentID string, chunks []*models.DocumentChunk) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	w := tenantWhere(ctx).add("document_id = ?", documentID)
+	if _, err := tx.Exec(ctx, "DELETE FROM document_chunks WHERE "+w.String(), w.args...); err != nil {
+		return err
+	}
+
+	for _, chunk := range chunks {
+		doc, err := encodeDoc(chunk)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, "INSERT INTO document_chunks (id, org_id, document_id, index, doc) VALUES ($1, $2, $3, $4, $5)",
+			chunk.ID, chunk.OrgID, chunk.DocumentID, chunk.Index, doc); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PostgresRepository) GetDocumentChunks(ctx context.Context, documentID string) ([]*models.DocumentChunk, error) {
+	w := sharedWhere(ctx).add("document_id = ?", documentID)
+	return findDocs[models.DocumentChunk](ctx, r.pool, "SELECT doc FROM document_chunks WHERE "+w.String()+" ORDER BY index", w.args...)
+}
+
+func (r *PostgresRepository) GetAllDocumentChunks(ctx context.Context) ([]*models.DocumentChunk, error) {
+	w := sharedWhere(ctx)
+	return findDocs[models.DocumentChunk](ctx, r.pool, "SELECT doc FROM document_chunks WHERE "+w.String()+" ORDER BY document_id, index", w.args...)
+}
+
+func (r *PostgresRepository) DeleteDocumentChunks(ctx context.Context, documentID string) error {
+	w := tenantWhere(ctx).add("document_id = ?", documentID)
+	_, err := r.pool.Exec(ctx, "DELETE FROM document_chunks WHERE "+w.String(), w.args...)
+	return err
+}
+
+// Index Rebuild Repository Methods
+func (r *PostgresRepository) SaveIndexRebuild(ctx context.Context, rebuild *models.IndexRebuild) error {
+	if rebuild.ID.IsZero() {
+		rebuild.ID = primitive.NewObjectID()
+	}
+
+	doc, err := encodeDoc(rebuild)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `INSERT INTO index_rebuilds (id, started_at, doc) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET started_at = EXCLUDED.started_at, doc = EXCLUDED.doc`,
+		rebuild.ID.Hex(), rebuild.StartedAt, doc)
+	return err
+}
+
+func (r *PostgresRepository) GetLatestIndexRebuild(ctx context.Context) (*models.IndexRebuild, error) {
+	return getDoc[models.IndexRebuild](ctx, r.pool, "SELECT doc FROM index_rebuilds ORDER BY started_at DESC LIMIT 1")
+}
+
+func (r *PostgresRepository) SaveFairnessReport(ctx context.Context, report *models.FairnessReport) error {
+	if report.ID.IsZero() {
+		report.ID = primitive.NewObjectID()
+	}
+
+	doc, err := encodeDoc(report)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `INSERT INTO fairness_reports (id, started_at, doc) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET started_at = EXCLUDED.started_at, doc = EXCLUDED.doc`,
+		report.ID.Hex(), report.StartedAt, doc)
+	return err
+}
+
+func (r *PostgresRepository) GetFairnessReport(ctx context.Context, id string) (*models.FairnessReport, error) {
+	return getDoc[models.FairnessReport](ctx, r.pool, "SELECT doc FROM fairness_reports WHERE id = $1", id)
+}
+
+func (r *PostgresRepository) GetFairnessReports(ctx context.Context) ([]*models.FairnessReport, error) {
+	reports, err := findDocs[models.FairnessReport](ctx, r.pool, "SELECT doc FROM fairness_reports ORDER BY started_at DESC")
+	if reports == nil && err == nil {
+		reports = []*models.FairnessReport{}
+	}
+	return reports, err
+}
+
+func (r *PostgresRepository) SaveExperiment(ctx context.Context, experiment *models.Experiment) error {
+	if experiment.ID.IsZero() {
+		experiment.ID = primitive.NewObjectID()
+	}
+
+	doc, err := encodeDoc(experiment)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `INSERT INTO experiments (id, created_at, doc) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET doc = EXCLUDED.doc`,
+		experiment.ID.Hex(), experiment.CreatedAt, doc)
+	return err
+}
+
+func (r *PostgresRepository) GetExperiment(ctx context.Context, id string) (*models.Experiment, error) {
+	return getDoc[models.Experiment](ctx, r.pool, "SELECT doc FROM experiments WHERE id = $1", id)
+}
+
+func (r *PostgresRepository) GetExperiments(ctx context.Context) ([]*models.Experiment, error) {
+	experiments, err := findDocs[models.Experiment](ctx, r.pool, "SELECT doc FROM experiments ORDER BY created_at DESC")
+	if experiments == nil && err == nil {
+		experiments = []*models.Experiment{}
+	}
+	return experiments, err
+}
+
+// Golden Job Repository Methods
+func (r *PostgresRepository) CreateGoldenJob(ctx context.Context, golden *models.GoldenJob) error {
+	if golden.ID.IsZero() {
+		golden.ID = primitive.NewObjectID()
+	}
+
+	doc, err := encodeDoc(golden)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, "INSERT INTO golden_jobs (id, doc) VALUES ($1, $2)", golden.ID.Hex(), doc)
+	return err
+}
+
+func (r *PostgresRepository) GetAllGoldenJobs(ctx context.Context) ([]*models.GoldenJob, error) {
+	return findDocs[models.GoldenJob](ctx, r.pool, "SELECT doc FROM golden_jobs ORDER BY id")
+}
+
+func (r *PostgresRepository) DeleteGoldenJob(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM golden_jobs WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) SaveCalibrationRun(ctx context.Context, run *models.GoldenComparisonReport) error {
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+
+	doc, err := encodeDoc(run)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `INSERT INTO calibration_runs (id, started_at, doc) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET started_at = EXCLUDED.started_at, doc = EXCLUDED.doc`,
+		run.ID.Hex(), run.StartedAt, doc)
+	return err
+}
+
+func (r *PostgresRepository) GetCalibrationRuns(ctx context.Context, limit int) ([]*models.GoldenComparisonReport, error) {
+	runs, err := findDocs[models.GoldenComparisonReport](ctx, r.pool,
+		"SELECT doc FROM calibration_runs ORDER BY started_at DESC LIMIT $1", limit)
+	if runs == nil && err == nil {
+		runs = []*models.GoldenComparisonReport{}
+	}
+	return runs, err
+}
+
+// Prompt Template Repository Methods
+func (r *PostgresRepository) GetPromptTemplate(ctx context.Context, name string) (*models.PromptTemplate, error) {
+	return getDoc[models.PromptTemplate](ctx, r.pool, "SELECT doc FROM prompt_templates WHERE name = $1", name)
+}
+
+func (r *PostgresRepository) GetAllPromptTemplates(ctx context.Context) ([]*models.PromptTemplate, error) {
+	return findDocs[models.PromptTemplate](ctx, r.pool, "SELECT doc FROM prompt_templates ORDER BY name")
+}
+
+func (r *PostgresRepository) UpsertPromptTemplate(ctx context.Context, tmpl *models.PromptTemplate) error {
+	doc, err := encodeDoc(tmpl)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `INSERT INTO prompt_templates (name, doc) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET doc = EXCLUDED.doc`, tmpl.Name, doc)
+	return err
+}
+
+func (r *PostgresRepository) DeletePromptTemplate(ctx context.Context, name string) error {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM prompt_templates WHERE name = $1", name)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) CreatePromptTemplateVersion(ctx context.Context, version *models.PromptTemplateVersion) error {
+	version.ID = promptVersionID(version.Name, version.Version)
+
+	doc, err := encodeDoc(version)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, "INSERT INTO prompt_template_versions (id, name, version, doc) VALUES ($1, $2, $3, $4)",
+		version.ID, version.Name, version.Version, doc)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("prompt template %s already exists", version.ID)
+	}
+	return err
+}
+
+func (r *PostgresRepository) GetPromptTemplateVersion(ctx context.Context, name string, version int) (*models.PromptTemplateVersion, error) {
+	tmpl, err := getDoc[models.PromptTemplateVersion](ctx, r.pool, "SELECT doc FROM prompt_template_versions WHERE id = $1", promptVersionID(name, version))
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl.ID = promptVersionID(tmpl.Name, tmpl.Version)
+	return tmpl, nil
+}
+
+func (r *PostgresRepository) GetPromptTemplateVersions(ctx context.Context, name string) ([]*models.PromptTemplateVersion, error) {
+	versions, err := findDocs[models.PromptTemplateVersion](ctx, r.pool, "SELECT doc FROM prompt_template_versions WHERE name = $1 ORDER BY version DESC", name)
+	for _, tmpl := range versions {
+		tmpl.ID = promptVersionID(tmpl.Name, tmpl.Version)
+	}
+	return versions, err
+}
+
+// Scoring Rubric Repository Methods
+func (r *PostgresRepository) CreateScoringRubric(ctx context.Context, rubric *models.ScoringRubric) error {
+	if rubric.ID.IsZero() {
+		rubric.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &rubric.OrgID)
+
+	doc, err := encodeDoc(rubric)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, "INSERT INTO scoring_rubrics (id, org_id, name, doc) VALUES ($1, $2, $3, $4)",
+		rubric.ID.Hex(), rubric.OrgID, rubric.Name, doc)
+	return err
+}
+
+func (r *PostgresRepository) GetScoringRubric(ctx context.Context, id string) (*models.ScoringRubric, error) {
+	w := sharedWhere(ctx).add("id = ?", id)
+	return getDoc[models.ScoringRubric](ctx, r.pool, "SELECT doc FROM scoring_rubrics WHERE "+w.String(), w.args...)
+}
+
+func (r *PostgresRepository) GetDefaultScoringRubric(ctx context.Context) (*models.ScoringRubric, error) {
+	return r.GetScoringRubricByName(ctx, "default")
+}
+
+// GetScoringRubricByName prefers the organization's own rubric over a global one with the same name
+func (r *PostgresRepository) GetScoringRubricByName(ctx context.Context, name string) (*models.ScoringRubric, error) {
+	w := sharedWhere(ctx).add("name = ?", name)
+	return getDoc[models.ScoringRubric](ctx, r.pool, "SELECT doc FROM scoring_rubrics WHERE "+w.String()+" ORDER BY org_id DESC LIMIT 1", w.args...)
+}
+
+// GetAllScoringRubrics lists the organization's rubrics and the global ones
+func (r *PostgresRepository) GetAllScoringRubrics(ctx context.Context) ([]*models.ScoringRubric, error) {
+	w := sharedWhere(ctx)
+	return findDocs[models.ScoringRubric](ctx, r.pool, "SELECT doc FROM scoring_rubrics WHERE "+w.String()+" ORDER BY id", w.args...)
+}
+
+// Organization Repository Methods
+func (r *PostgresRepository) CreateOrganization(ctx context.Context, org *models.Organization) error {
+	if org.ID.IsZero() {
+		org.ID = primitive.NewObjectID()
+	}
+
+	doc, err := encodeDoc(org)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, "INSERT INTO organizations (id, api_key_hash, doc) VALUES ($1, $2, $3)", org.ID.Hex(), org.APIKeyHash, doc)
+	return err
+}
+
+func (r *PostgresRepository) GetOrganization(ctx context.Context, id string) (*models.Organization, error) {
+	return getDoc[models.Organization](ctx, r.pool, "SELECT doc FROM organizations WHERE id = $1", id)
+}
+
+func (r *PostgresRepository) GetOrganizationByAPIKeyHash(ctx context.Context, hash string) (*models.Organization, error) {
+	return getDoc[models.Organization](ctx, r.pool, "SELECT doc FROM organizations WHERE api_key_hash = $1", hash)
+}
+
+func (r *PostgresRepository) GetAllOrganizations(ctx context.Context) ([]*models.Organization, error) {
+	return findDocs[models.Organization](ctx, r.pool, "SELECT doc FROM organizations ORDER BY id")
+}
+
+func (r *PostgresRepository) UpdateOrganization(ctx context.Context, org *models.Organization) error {
+	doc, err := encodeDoc(org)
+	if err != nil {
+		return err
+	}
+
+	tag, err := r.pool.Exec(ctx, "UPDATE organizations SET api_key_hash = $2, doc = $3 WHERE id = $1", org.ID.Hex(), org.APIKeyHash, doc)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) GetCachedEmbedding(ctx context.Context, key string) (*models.CachedEmbedding, error) {
+	return getDoc[models.CachedEmbedding](ctx, r.pool, "SELECT doc FROM embedding_cache WHERE key = $1 AND expires_at > now()", key)
+}
+
+// SaveCachedEmbedding stores an entry and drops expired ones, which PostgreSQL has no TTL index for
+func (r *PostgresRepository) SaveCachedEmbedding(ctx context.Context, entry *models.CachedEmbedding) error {
+	if _, err := r.pool.Exec(ctx, "DELETE FROM embedding_cache WHERE expires_at <= now()"); err != nil {
+		return err
+	}
+
+	doc, err := encodeDoc(entry)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `INSERT INTO embedding_cache (key, expires_at, doc) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET expires_at = EXCLUDED.expires_at, doc = EXCLUDED.doc`,
+		entry.Key, entry.ExpiresAt, doc)
+	return err
+}
+
+func (r *PostgresRepository) DeleteCachedEmbeddings(ctx context.Context, keys []string) (int64, error) {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM embedding_cache WHERE key = ANY($1)", keys)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *PostgresRepository) CreateLLMCall(ctx context.Context, call *models.LLMCall) error {
+	if call.ID.IsZero() {
+		call.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &call.OrgID)
+
+	doc, err := encodeDoc(call)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, "INSERT INTO llm_calls (id, org_id, job_id, step, created_at, doc) VALUES ($1, $2, $3, $4, $5, $6)",
+		call.ID.Hex(), call.OrgID, call.JobID, call.Step, call.CreatedAt, doc)
+	return err
+}
+
+func (r *PostgresRepository) GetLLMCalls(ctx context.Context, filter LLMCallFilter) ([]*models.LLMCall, error) {
+	w := tenantWhere(ctx)
+	if filter.JobID != "" {
+		w.add("job_id = ?", filter.JobID)
+	}
+	if filter.Step != "" {
+		w.add("step = ?", filter.Step)
+	}
+
+	query := "SELECT doc FROM llm_calls WHERE " + w.String() + " ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT " + w.arg(filter.Limit)
+	}
+
+	calls, err := findDocs[models.LLMCall](ctx, r.pool, query, w.args...)
+	if calls == nil && err == nil {
+		calls = []*models.LLMCall{}
+	}
+	return calls, err
+}
+
+func (r *PostgresRepository) DeleteLLMCalls(ctx context.Context, jobIDs []string) (int64, error) {
+	w := tenantWhere(ctx).add("job_id = ANY(?)", jobIDs)
+	tag, err := r.pool.Exec(ctx, "DELETE FROM llm_calls WHERE "+w.String(), w.args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *PostgresRepository) RecordUpload(ctx context.Context, upload *models.Upload) (*models.Upload, error) {
+	if upload.ID.IsZero() {
+		upload.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &upload.OrgID)
+
+	doc, err := encodeDoc(upload)
+	if err != nil {
+		return nil, err
+	}
+	// The unique index on org_id and filename makes concurrent uploads of the same file share one record
+	if _, err := r.pool.Exec(ctx, `INSERT INTO uploads (id, org_id, filename, created_at, doc) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (org_id, filename) DO NOTHING`, upload.ID.Hex(), upload.OrgID, upload.Filename, upload.CreatedAt, doc); err != nil {
+		return nil, err
+	}
+	return getDoc[models.Upload](ctx, r.pool, "SELECT doc FROM uploads WHERE org_id = $1 AND filename = $2",
+		upload.OrgID, upload.Filename)
+}
+
+func (r *PostgresRepository) DeleteUploads(ctx context.Context, filenames []string) (int64, error) {
+	w := tenantWhere(ctx).add("filename = ANY(?)", filenames)
+	tag, err := r.pool.Exec(ctx, "DELETE FROM uploads WHERE "+w.String(), w.args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *PostgresRepository) CountUploads(ctx context.Context, filename string) (int64, error) {
+	var count int64
+	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM uploads WHERE filename = $1", filename).Scan(&count)
+	return count, err
+}
+
+func (r *PostgresRepository) FindUploadsBefore(ctx context.Context, before time.Time) ([]*models.Upload, error) {
+	w := tenantWhere(ctx).add("created_at < ?", before)
+	uploads, err := findDocs[models.Upload](ctx, r.pool, "SELECT doc FROM uploads WHERE "+w.String(), w.args...)
+	if uploads == nil && err == nil {
+		uploads = []*models.Upload{}
+	}
+	return uploads, err
+}
+
+func (r *PostgresRepository) FindReferencedFiles(ctx context.Context, filenames []string, excludeJobIDs []string) ([]string, error) {
+	wanted := map[string]bool{}
+	for _, name := range filenames {
+		wanted[name] = true
+	}
+	referenced := map[string]bool{}
+	for _, table := range []string{"evaluation_jobs", "archived_jobs"} {
+		w := tenantWhere(ctx).add("(doc->>'cv_file' = ANY(?) OR doc->>'project_file' = ANY(?))", filenames, filenames)
+		if len(excludeJobIDs) > 0 {
+			w.add("id <> ALL(?)", excludeJobIDs)
+		}
+		rows, err := r.pool.Query(ctx, "SELECT doc->>'cv_file', doc->>'project_file' FROM "+table+" WHERE "+w.String(), w.args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var cvFile, projectFile *string
+			if err := rows.Scan(&cvFile, &projectFile); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			for _, name := range []*string{cvFile, projectFile} {
+				if name != nil && wanted[*name] {
+					referenced[*name] = true
+				}
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	files := make([]string, 0, len(referenced))
+	for name := range referenced {
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+func (r *PostgresRepository) ListUploads(ctx context.Context, limit, offset int) ([]*models.Upload, int64, error) {
+	w := tenantWhere(ctx)
+	var total int64
+	if err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM uploads WHERE "+w.String(), w.args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT doc FROM uploads WHERE " + w.String() + " ORDER BY created_at DESC, id DESC"
+	if limit > 0 {
+		query += " LIMIT " + w.arg(limit)
+	}
+	if offset > 0 {
+		query += " OFFSET " + w.arg(offset)
+	}
+	uploads, err := findDocs[models.Upload](ctx, r.pool, query, w.args...)
+	if uploads == nil && err == nil {
+		uploads = []*models.Upload{}
+	}
+	return uploads, total, err
+}
+
+func (r *PostgresRepository) GetUpload(ctx context.Context, id string) (*models.Upload, error) {
+	w := tenantWhere(ctx).add("id = ?", id)
+	return getDoc[models.Upload](ctx, r.pool, "SELECT doc FROM uploads WHERE "+w.String(), w.args...)
+}
+
+func (r *PostgresRepository) CreateErasureRecord(ctx context.Context, record *models.ErasureRecord) error {
+	if record.ID.IsZero() {
+		record.ID = primitive.NewObjectID()
+	}
+	stampOrgID(ctx, &record.OrgID)
+
+	doc, err := encodeDoc(record)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, "INSERT INTO erasure_records (id, org_id, created_at, doc) VALUES ($1, $2, $3, $4)",
+		record.ID.Hex(), record.OrgID, record.CreatedAt, doc)
+	return err
+}
+
+func (r *PostgresRepository) GetErasureRecords(ctx context.Context) ([]*models.ErasureRecord, error) {
+	w := tenantWhere(ctx)
+	records, err := findDocs[models.ErasureRecord](ctx, r.pool,
+		"SELECT doc FROM erasure_records WHERE "+w.String()+" ORDER BY created_at DESC", w.args...)
+	if records == nil && err == nil {
+		records = []*models.ErasureRecord{}
+	}
+	return records, err
+}
+
+// IncrementUsageTotals adds one evaluation attempt's usage to the day's totals of each model
+func (r *PostgresRepository) IncrementUsageTotals(ctx context.Context, date, orgID string, usage []models.ModelUsage) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, u := range usage {
+		empty, err := encodeDoc(&models.UsageTotal{Date: date, OrgID: orgID, Model: u.Model})
+		if err != nil {
+			return err
+		}
+		// Create the row first so concurrent increments lock the same one
+		if _, err := tx.Exec(ctx, `INSERT INTO usage_totals (date, org_id, model, doc) VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING`, date, orgID, u.Model, empty); err != nil {
+			return err
+		}
+
+		total, err := getDoc[models.UsageTotal](ctx, tx, `SELECT doc FROM usage_totals
+			WHERE date = $1 AND org_id = $2 AND model = $3 FOR UPDATE`, date, orgID, u.Model)
+		if err != nil {
+			return err
+		}
+		total.Evaluations++
+		total.Add(u.TokenUsage)
+
+		doc, err := encodeDoc(total)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, "UPDATE usage_totals SET doc = $4 WHERE date = $1 AND org_id = $2 AND model = $3",
+			date, orgID, u.Model, doc); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetUsageTotals returns the usage totals matching filter, ordered by date, organization and model
+func (r *PostgresRepository) GetUsageTotals(ctx context.Context, filter UsageFilter) ([]*models.UsageTotal, error) {
+	w := &pgWhere{}
+	if filter.From != "" {
+		w.add("date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		w.add("date <= ?", filter.To)
+	}
+	if filter.OrgID != "" {
+		w.add("org_id = ?", filter.OrgID)
+	}
+
+	totals, err := findDocs[models.UsageTotal](ctx, r.pool, "SELECT doc FROM usage_totals WHERE "+w.String()+" ORDER BY date, org_id, model", w.args...)
+	if totals == nil && err == nil {
+		totals = []*models.UsageTotal{}
+	}
+	return totals, err
+}
@@ -0,0 +1,386 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"ai-cv-summarize/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gonum.org/v1/gonum/floats"
+)
+
+// ErrNotFound is returned by every backend when a document does not exist
+var ErrNotFound = mongo.ErrNoDocuments
+
+// ErrDuplicateIdempotencyKey is returned by CreateJob when the organization already has a job created with
+// the same idempotency key
+var ErrDuplicateIdempotencyKey = errors.New("idempotency key already used")
+
+// ErrInvalidCursor is returned when a listing's After cursor does not name a job the caller can see
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Repository is the persistence layer used by handlers and services.
+// Job, batch, job description, reference document and rubric queries are scoped to the organization carried by the context (see package tenant).
+type Repository interface {
+	// Ping checks that the backing store is reachable
+	Ping(ctx context.Context) error
+	// Migrate applies pending schema migrations, such as indexes, and returns the ones it applied
+	Migrate(ctx context.Context) ([]Migration, error)
+
+	// Evaluation jobs
+	CreateJob(ctx context.Context, job *models.EvaluationJob) (interface{}, error)
+	// FindJobByIdempotencyKey returns the job created with an idempotency key, even when it was deleted since
+	FindJobByIdempotencyKey(ctx context.Context, key string) (*models.EvaluationJob, error)
+	GetJobByID(ctx context.Context, id string) (*models.EvaluationJob, error)
+	GetJobsByIDs(ctx context.Context, ids []string) ([]*models.EvaluationJob, error)
+	UpdateJobStatus(ctx context.Context, id string, status models.JobStatus) error
+	// UpdateJobResult completes a job with its result. Like UpdateJobError, it only writes a live job still
+	// processing under the given claim token and returns ErrNotFound otherwise, so a stale worker cannot
+	// overwrite a job that was requeued, deleted or erased while it ran.
+	UpdateJobResult(ctx context.Context, id, claimToken string, result *models.EvaluationResult) error
+	// UpdateJobError fails a job claimed with claimToken with the reason and its classification
+	UpdateJobError(ctx context.Context, id, claimToken string, errorType models.ErrorType, errorMessage string) error
+	UpdateJobSteps(ctx context.Context, id string, steps []models.JobStep) error
+	UpdateJobStep(ctx context.Context, id string, step models.JobStep) error
+	UpdateJobUsage(ctx context.Context, id string, usage *models.JobUsage) error
+	UpdateJobRetrieval(ctx context.Context, id string, retrieval []models.StepRetrieval) error
+	UpdateJobParsedCV(ctx context.Context, id string, parsed *models.ParsedResume) error
+	UpdateJobProjectEmbedding(ctx context.Context, id string, embedding []float64, model string) error
+	// FindProjectEmbeddings returns the project report embeddings made with model of the live jobs in the given mode
+	FindProjectEmbeddings(ctx context.Context, model string, sandbox bool) ([]*ProjectEmbedding, error)
+	IncrementRetryCount(ctx context.Context, id string) error
+	GetPendingJobs(ctx context.Context) ([]*models.EvaluationJob, error)
+	GetStuckJobs(ctx context.Context, startedBefore time.Time) ([]*models.EvaluationJob, error)
+	RequeueStuckJob(ctx context.Context, id string) error
+	// ReopenJob queues a completed or failed live job again for a re-evaluation with the given content hash,
+	// moving its result to the history. It returns ErrNotFound when the job is not completed or failed.
+	ReopenJob(ctx context.Context, id, contentHash string) error
+	// ClaimJob moves a queued job to processing and returns the claim token the worker writes its outcome
+	// with. It returns ErrNotFound when the job is not queued, e.g. because another worker claimed it first.
+	ClaimJob(ctx context.Context, id string) (string, error)
+	// GetUndispatchedJobs returns the live jobs, last updated before the given time, whose enqueue is still pending
+	GetUndispatchedJobs(ctx context.Context, updatedBefore time.Time) ([]*models.EvaluationJob, error)
+	// MarkJobEnqueued clears a job's pending enqueue once its ID is on the queue
+	MarkJobEnqueued(ctx context.Context, id string) error
+	FindRecentJobByCVHash(ctx context.Context, cvHash, jobDescriptionID string, sandbox bool, since time.Time) (*models.EvaluationJob, error)
+	FindCompletedJobByContentHash(ctx context.Context, contentHash string, since time.Time) (*models.EvaluationJob, error)
+	// GetJobsWithFilters returns one page of jobs; it fails with ErrInvalidCursor when opts.After is not a visible job
+	GetJobsWithFilters(ctx context.Context, opts JobListOptions) ([]*models.EvaluationJob, error)
+	// CountJobs counts the jobs matching the filters of opts, ignoring paging
+	CountJobs(ctx context.Context, opts JobListOptions) (int64, error)
+	StreamJobs(ctx context.Context, opts JobListOptions, fn func(*models.EvaluationJob) error) error
+	GetScoreCounts(ctx context.Context, cohort ScoreCohort, score float64) (*ScoreCounts, error)
+	// SoftDeleteJob hides a job from every query until it is purged; it returns ErrNotFound for missing or deleted jobs
+	SoftDeleteJob(ctx context.Context, id string) error
+	FindJobs(ctx context.Context, filter JobBulkFilter) ([]*models.EvaluationJob, error)
+	// EraseJobContent removes the document contents, redaction mapping, blind documents, parsed CV and project embedding of the given live or archived jobs and records when it happened
+	EraseJobContent(ctx context.Context, ids []string) (int64, error)
+	// AnonymizeJob applies EvaluationJob.Anonymize to a stored job, including soft-deleted and archived ones
+	AnonymizeJob(ctx context.Context, id string, now time.Time) error
+	FindJobIDs(ctx context.Context, filter JobBulkFilter) ([]string, error)
+	DeleteJobs(ctx context.Context, filter JobBulkFilter) (int64, error)
+	ArchiveJobs(ctx context.Context, filter JobBulkFilter) (int64, error)
+
+	// Batch evaluations
+	CreateBatchJob(ctx context.Context, batch *models.BatchJob) error
+	GetBatchJob(ctx context.Context, id string) (*models.BatchJob, error)
+	// AnonymizeBatchItems clears the candidate ID and file names of the batch items of the given jobs
+	AnonymizeBatchItems(ctx context.Context, batchID string, jobIDs []string) error
+
+	// Job descriptions
+	CreateJobDescription(ctx context.Context, jobDesc *models.JobDescription) error
+	GetJobDescription(ctx context.Context, id string) (*models.JobDescription, error)
+	GetAllJobDescriptions(ctx context.Context) ([]*models.JobDescription, error)
+	// GetJobDescriptionVectors returns every job description without its title, description and requirements,
+	// for vector searches that only need the embedding
+	GetJobDescriptionVectors(ctx context.Context) ([]*models.JobDescription, error)
+	UpdateJobDescription(ctx context.Context, jobDesc *models.JobDescription) error
+	UpdateJobDescriptionEmbedding(ctx context.Context, id string, embedding []float64, model string) error
+	// UpdateJobDescriptionThresholds sets a job description's recommendation thresholds; nil removes them
+	UpdateJobDescriptionThresholds(ctx context.Context, id string, thresholds *models.RecommendationThresholds) error
+	DeleteJobDescription(ctx context.Context, id string) error
+
+	// Reference documents retrieved as context alongside job descriptions
+	CreateReferenceDocument(ctx context.Context, doc *models.ReferenceDocument) error
+	GetReferenceDocument(ctx context.Context, id string) (*models.ReferenceDocument, error)
+	// GetReferenceDocuments returns the reference documents of a type, or of every type when docType is empty
+	GetReferenceDocuments(ctx context.Context, docType string) ([]*models.ReferenceDocument, error)
+	DeleteReferenceDocument(ctx context.Context, id string) error
+
+	// Document chunks; chunks keep the organization of their document
+	ReplaceDocumentChunks(ctx context.Context, documentID string, chunks []*models.DocumentChunk) error
+	// GetDocumentChunks returns a document's chunks in document order
+	GetDocumentChunks(ctx context.Context, documentID string) ([]*models.DocumentChunk, error)
+	GetAllDocumentChunks(ctx context.Context) ([]*models.DocumentChunk, error)
+	DeleteDocumentChunks(ctx context.Context, documentID string) error
+
+	// Vector index rebuilds
+	SaveIndexRebuild(ctx context.Context, rebuild *models.IndexRebuild) error
+	GetLatestIndexRebuild(ctx context.Context) (*models.IndexRebuild, error)
+
+	// Fairness reports
+	SaveFairnessReport(ctx context.Context, report *models.FairnessReport) error
+	GetFairnessReport(ctx context.Context, id string) (*models.FairnessReport, error)
+	// GetFairnessReports returns every report, newest first
+	GetFairnessReports(ctx context.Context) ([]*models.FairnessReport, error)
+
+	// Experiments
+	SaveExperiment(ctx context.Context, experiment *models.Experiment) error
+	GetExperiment(ctx context.Context, id string) (*models.Experiment, error)
+	// GetExperiments returns every experiment, newest first
+	GetExperiments(ctx context.Context) ([]*models.Experiment, error)
+
+	// Golden jobs
+	CreateGoldenJob(ctx context.Context, golden *models.GoldenJob) error
+	GetAllGoldenJobs(ctx context.Context) ([]*models.GoldenJob, error)
+	DeleteGoldenJob(ctx context.Context, id string) error
+	SaveCalibrationRun(ctx context.Context, run *models.GoldenComparisonReport) error
+	// GetCalibrationRuns returns the latest calibration runs, newest first
+	GetCalibrationRuns(ctx context.Context, limit int) ([]*models.GoldenComparisonReport, error)
+
+	// Prompt templates
+	GetPromptTemplate(ctx context.Context, name string) (*models.PromptTemplate, error)
+	GetAllPromptTemplates(ctx context.Context) ([]*models.PromptTemplate, error)
+	UpsertPromptTemplate(ctx context.Context, tmpl *models.PromptTemplate) error
+	DeletePromptTemplate(ctx context.Context, name string) error
+	CreatePromptTemplateVersion(ctx context.Context, version *models.PromptTemplateVersion) error
+	GetPromptTemplateVersion(ctx context.Context, name string, version int) (*models.PromptTemplateVersion, error)
+	// GetPromptTemplateVersions returns a step's saved versions, newest first
+	GetPromptTemplateVersions(ctx context.Context, name string) ([]*models.PromptTemplateVersion, error)
+
+	// Scoring rubrics
+	CreateScoringRubric(ctx context.Context, rubric *models.ScoringRubric) error
+	GetScoringRubric(ctx context.Context, id string) (*models.ScoringRubric, error)
+	GetDefaultScoringRubric(ctx context.Context) (*models.ScoringRubric, error)
+	GetScoringRubricByName(ctx context.Context, name string) (*models.ScoringRubric, error)
+	GetAllScoringRubrics(ctx context.Context) ([]*models.ScoringRubric, error)
+
+	// LLM usage totals, aggregated per day, organization and model
+	IncrementUsageTotals(ctx context.Context, date, orgID string, usage []models.ModelUsage) error
+	GetUsageTotals(ctx context.Context, filter UsageFilter) ([]*models.UsageTotal, error)
+
+	// Embedding cache; expired entries are reported as ErrNotFound
+	GetCachedEmbedding(ctx context.Context, key string) (*models.CachedEmbedding, error)
+	SaveCachedEmbedding(ctx context.Context, entry *models.CachedEmbedding) error
+	DeleteCachedEmbeddings(ctx context.Context, keys []string) (int64, error)
+
+	// LLM call audit log
+	CreateLLMCall(ctx context.Context, call *models.LLMCall) error
+	// GetLLMCalls returns the calls matching filter, newest first
+	GetLLMCalls(ctx context.Context, filter LLMCallFilter) ([]*models.LLMCall, error)
+	// DeleteLLMCalls removes the recorded calls of the given jobs
+	DeleteLLMCalls(ctx context.Context, jobIDs []string) (int64, error)
+
+	// Uploaded file metadata
+	// RecordUpload stores the metadata of an upload unless the organization already has a record of the same
+	// file, and returns the stored record
+	RecordUpload(ctx context.Context, upload *models.Upload) (*models.Upload, error)
+	// DeleteUploads removes the organization's records of the given files
+	DeleteUploads(ctx context.Context, filenames []string) (int64, error)
+	// CountUploads counts the records of a file across every organization
+	CountUploads(ctx context.Context, filename string) (int64, error)
+	// FindUploadsBefore returns the records of files uploaded before the given time
+	FindUploadsBefore(ctx context.Context, before time.Time) ([]*models.Upload, error)
+	// FindReferencedFiles returns which of the given files are used by a job, including soft-deleted and
+	// archived jobs, other than the excluded ones
+	FindReferencedFiles(ctx context.Context, filenames []string, excludeJobIDs []string) ([]string, error)
+	// ListUploads returns a page of the organization's uploads, newest first, and how many there are in total
+	ListUploads(ctx context.Context, limit, offset int) ([]*models.Upload, int64, error)
+	GetUpload(ctx context.Context, id string) (*models.Upload, error)
+
+	// Erasure audit records
+	CreateErasureRecord(ctx context.Context, record *models.ErasureRecord) error
+	// GetErasureRecords returns the organization's erasure records, newest first
+	GetErasureRecords(ctx context.Context) ([]*models.ErasureRecord, error)
+
+	// Organizations
+	CreateOrganization(ctx context.Context, org *models.Organization) error
+	GetOrganization(ctx context.Context, id string) (*models.Organization, error)
+	GetOrganizationByAPIKeyHash(ctx context.Context, hash string) (*models.Organization, error)
+	GetAllOrganizations(ctx context.Context) ([]*models.Organization, error)
+	UpdateOrganization(ctx context.Context, org *models.Organization) error
+}
+
+// JobListOptions holds the filtering, paging and sorting options for job listings
+type JobListOptions struct {
+	Status      string
+	CandidateID string
+	Limit       int
+	Offset      int
+	SortBy      string
+	SortOrder   int
+	// After is the ID of the last job of the previous page; the page continues from it in sort order
+	After string
+
+	// CreatedFrom and CreatedBefore bound the creation time; zero values leave the range open
+	CreatedFrom   time.Time
+	CreatedBefore time.Time
+	// ScoreRanges keep the jobs whose result falls within every range
+	ScoreRanges []ScoreRange
+	// CandidateName keeps the jobs whose candidate name contains it, ignoring case
+	CandidateName string
+	// Search keeps the jobs whose feedback or summary contains any of its words
+	Search string
+}
+
+// ScoreRange bounds one result score, e.g. result.cv_match_rate; nil bounds are open
+type ScoreRange struct {
+	Field string
+	Min   *float64
+	Max   *float64
+}
+
+// embeddingNorm returns the Euclidean length stored with an embedding
+func embeddingNorm(embedding []float64) float64 {
+	if len(embedding) == 0 {
+		return 0
+	}
+	return floats.Norm(embedding, 2)
+}
+
+// searchTerms splits a search into lowercase words, dropping punctuation
+func searchTerms(search string) []string {
+	return strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// resultScore returns the value of a ScoreRange field on a result
+func resultScore(result *models.EvaluationResult, field string) float64 {
+	switch field {
+	case "result.cv_match_rate":
+		return result.CVMatchRate
+	case "result.project_score":
+		return result.ProjectScore
+	default:
+		return result.OverallScore
+	}
+}
+
+// sortField returns the field jobs are ordered by
+func (opts JobListOptions) sortField() string {
+	if opts.SortBy == "" {
+		return "created_at"
+	}
+	return opts.SortBy
+}
+
+// ascending reports whether jobs are listed in ascending order; the default is descending
+func (opts JobListOptions) ascending() bool {
+	return opts.SortOrder > 0
+}
+
+// jobSortValue returns a job's value of a sort field, or nil when the job has none. Jobs without a
+// value sort below every other job, as in MongoDB.
+func jobSortValue(job *models.EvaluationJob, field string) interface{} {
+	switch field {
+	case "completed_at":
+		if job.CompletedAt == nil {
+			return nil
+		}
+		return *job.CompletedAt
+	case "result.overall_score":
+		if job.Result == nil {
+			return nil
+		}
+		return job.Result.OverallScore
+	default:
+		return job.CreatedAt
+	}
+}
+
+// newClaimToken returns a token unique to one claim of a job
+func newClaimToken() string {
+	return primitive.NewObjectID().Hex()
+}
+
+// ownsClaim reports whether a job is live, not erased and still processing under the given claim
+func ownsClaim(job *models.EvaluationJob, claimToken string) bool {
+	return job.DeletedAt == nil && job.ContentErasedAt == nil &&
+		job.Status == models.StatusProcessing && job.ClaimToken == claimToken
+}
+
+// reopenJob resets a finished job to queued for ReopenJob, keeping its result as the latest earlier version
+func reopenJob(job *models.EvaluationJob, contentHash string) {
+	if job.Result != nil {
+		job.ResultHistory = append(job.ResultHistory, job.Result)
+	}
+	job.Result = nil
+	job.ErrorMessage = ""
+	job.ErrorType = ""
+	job.Steps = nil
+	job.StartedAt = nil
+	job.CompletedAt = nil
+	job.RetryCount = 0
+	job.ContentHash = contentHash
+	job.ClaimToken = ""
+	job.Status = models.StatusQueued
+	job.EnqueuePending = !job.Sandbox
+	job.UpdatedAt = time.Now()
+}
+
+// JobBulkFilter selects the jobs affected by admin bulk operations, the retention policy and erasure
+// requests. Unlike job listings it includes soft-deleted jobs.
+type JobBulkFilter struct {
+	Status      string
+	OlderThan   time.Time
+	CandidateID string
+	// NewerThan selects the jobs created at or after it
+	NewerThan time.Time
+	// IDs restricts the filter to the given jobs when not nil
+	IDs []string
+	// DeletedBefore selects the jobs soft-deleted before it
+	DeletedBefore time.Time
+	// WithContent selects finished jobs whose documents have not been erased
+	WithContent bool
+	// Archived selects archived jobs instead of live ones; FindJobs and DeleteJobs support it
+	Archived bool
+}
+
+// ScoreCohort selects the completed evaluations a score is ranked against.
+// An empty JobDescriptionID selects evaluations that were not pinned to a job description.
+type ScoreCohort struct {
+	JobDescriptionID string
+	Sandbox          bool
+}
+
+// ScoreCounts counts the overall scores of a cohort relative to one score
+type ScoreCounts struct {
+	Total int `bson:"total"`
+	Below int `bson:"below"`
+	Equal int `bson:"equal"`
+}
+
+// ProjectEmbedding is the project report embedding of a job, with what is needed to tell whose report it is
+type ProjectEmbedding struct {
+	JobID       string
+	CandidateID string
+	CVHash      string
+	ProjectHash string
+	Embedding   []float64
+}
+
+// UsageFilter selects usage totals; dates are inclusive YYYY-MM-DD strings and empty fields match everything
+type UsageFilter struct {
+	From  string
+	To    string
+	OrgID string
+}
+
+// LLMCallFilter selects audited LLM calls; empty fields match everything and Limit caps the result
+type LLMCallFilter struct {
+	JobID string
+	Step  string
+	Limit int
+}
+
+// promptVersionID is the document ID of a saved prompt template version, e.g. "evaluate_cv@3"
+func promptVersionID(name string, version int) string {
+	return name + "@" + strconv.Itoa(version)
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+
+	"ai-cv-summarize/internal/tenant"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// tenantFilter restricts a filter on tenant-owned documents (jobs, and writes to shareable documents) to the
+// context's organization
+func tenantFilter(ctx context.Context, filter bson.M) bson.M {
+	if orgID := tenant.OrgID(ctx); orgID != "" {
+		filter["org_id"] = orgID
+	}
+	return filter
+}
+
+// liveJobFilter restricts a job filter to the context's organization and hides soft-deleted jobs
+func liveJobFilter(ctx context.Context, filter bson.M) bson.M {
+	filter["deleted_at"] = nil
+	return tenantFilter(ctx, filter)
+}
+
+// sharedFilter restricts a read of shareable documents (rubrics, job descriptions, reference documents and
+// their chunks) to the context's organization plus the global documents every organization can use
+func sharedFilter(ctx context.Context, filter bson.M) bson.M {
+	if orgID, scoped := tenant.Scope(ctx); scoped {
+		filter["org_id"] = bson.M{"$in": bson.A{orgID, nil, ""}}
+	}
+	return filter
+}
+
+// inTenant reports whether a tenant-owned document belonging to orgID is visible from ctx
+func inTenant(ctx context.Context, orgID string) bool {
+	scope := tenant.OrgID(ctx)
+	return scope == "" || scope == orgID
+}
+
+// inShared reports whether a shareable document belonging to orgID is visible from ctx
+func inShared(ctx context.Context, orgID string) bool {
+	scope, scoped := tenant.Scope(ctx)
+	return orgID == "" || !scoped || scope == orgID
+}
+
+// stampOrgID assigns new documents to the context's organization unless they already name one
+func stampOrgID(ctx context.Context, orgID *string) {
+	if *orgID == "" {
+		*orgID = tenant.OrgID(ctx)
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ai-cv-summarize/internal/config"
+)
+
+// ErrFileInfected is wrapped by every InfectedFileError so callers can match it with errors.Is
+var ErrFileInfected = errors.New("FILE_INFECTED")
+
+// InfectedFileError reports an upload the virus scanner found malware in
+type InfectedFileError struct {
+	Filename  string
+	Signature string
+}
+
+func (e *InfectedFileError) Error() string {
+	return fmt.Sprintf("%s is infected (%s)", e.Filename, e.Signature)
+}
+
+func (e *InfectedFileError) Unwrap() error {
+	return ErrFileInfected
+}
+
+// clamdChunkSize is the size of the chunks a file is streamed to clamd in
+const clamdChunkSize = 64 * 1024
+
+// VirusScanner scans files with a ClamAV daemon over its INSTREAM command, so clamd needs no access to
+// the upload directory
+type VirusScanner struct {
+	config *config.AntivirusConfig
+}
+
+// NewVirusScanner returns a virus scanner; it does nothing unless cfg.Enabled is set
+func NewVirusScanner(cfg *config.AntivirusConfig) *VirusScanner {
+	return &VirusScanner{config: cfg}
+}
+
+// Enabled reports whether uploads are scanned
+func (v *VirusScanner) Enabled() bool {
+	return v != nil && v.config.Enabled
+}
+
+// Ping checks that clamd is reachable
+func (v *VirusScanner) Ping(ctx context.Context) error {
+	reply, err := v.command(ctx, "PING", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("unexpected clamd reply %q", reply)
+	}
+	return nil
+}
+
+// ScanFile streams a file to clamd and returns the name of the malware signature it matched, or "" when
+// the file is clean
+func (v *VirusScanner) ScanFile(ctx context.Context, filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	reply, err := v.command(ctx, "INSTREAM", file)
+	if err != nil {
+		return "", err
+	}
+
+	// Replies are "stream: OK", "stream: <signature> FOUND" or "<reason> ERROR"
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return "", nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return strings.TrimSuffix(reply, " FOUND"), nil
+	default:
+		return "", fmt.Errorf("clamd scan failed: %s", reply)
+	}
+}
+
+// command sends a null-terminated command to clamd, followed by the contents of body in length-prefixed
+// chunks when it is set, and returns the reply
+func (v *VirusScanner) command(ctx context.Context, name string, body io.Reader) (string, error) {
+	if v.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.config.Timeout)
+		defer cancel()
+	}
+
+	network, address := "tcp", v.config.Address
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		network, address = "unix", path
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte("z" + name + "\x00")); err != nil {
+		return "", fmt.Errorf("failed to send clamd command: %w", err)
+	}
+	if body != nil {
+		if err := writeChunks(conn, body); err != nil {
+			return "", fmt.Errorf("failed to stream file to clamd: %w", err)
+		}
+	}
+
+	reply, err := io.ReadAll(io.LimitReader(conn, 4096))
+	if err != nil {
+		return "", fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return string(bytes.TrimRight(reply, "\x00\n")), nil
+}
+
+// writeChunks streams r as chunks prefixed with their length in network byte order, ending with an empty chunk
+func writeChunks(w io.Writer, r io.Reader) error {
+	buf := make([]byte, 4+clamdChunkSize)
+	for {
+		n, err := r.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, werr := w.Write(buf[:4+n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := w.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
+// quarantine moves an infected file out of reach of text extraction, named by its digest without an
+// extension so nothing opens it by type
+func (v *VirusScanner) quarantine(filePath, hash string) (string, error) {
+	if err := os.MkdirAll(v.config.QuarantineDir, 0700); err != nil {
+		return "", err
+	}
+	target := filepath.Join(v.config.QuarantineDir, hash+"-"+time.Now().UTC().Format("20060102T150405Z"))
+	if err := os.Chmod(filePath, 0600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(filePath, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
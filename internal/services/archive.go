@@ -0,0 +1,208 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"ai-cv-summarize/internal/models"
+)
+
+// ErrInvalidArchive is returned for uploads that are not readable ZIP archives
+var ErrInvalidArchive = errors.New("invalid ZIP archive")
+
+// archiveProjectWords mark a file as a project report when they end its name or name its folder; the CV
+// words are dropped from CV names, so "jane_cv.pdf" pairs with "jane_project.pdf"
+var (
+	archiveProjectWords = []string{"project", "projects", "report"}
+	archiveCVWords      = []string{"cv", "cvs", "resume", "resumes"}
+)
+
+// ArchivePair is a CV in a ZIP archive with the project report of the same candidate, if there is one
+type ArchivePair struct {
+	CV      *zip.File
+	Project *zip.File
+}
+
+// ReadArchive lists the documents of a ZIP archive of applicant pools and pairs each CV with its project
+// report by name: a report is named like the CV with a project or report suffix ("jane_doe_cv.pdf" and
+// "jane_doe_project.docx"), or has the CV's name in a project folder ("cv/jane_doe.pdf" and
+// "projects/jane_doe.pdf"), or sits next to a CV named cv in the candidate's own folder ("jane_doe/cv.pdf"
+// and "jane_doe/project.pdf"). Nothing is extracted to disk by entry name, and entries with absolute or
+// parent-relative paths are skipped as unsafe along with unsupported and unpaired files.
+func (s *FileService) ReadArchive(r io.ReaderAt, size int64) ([]ArchivePair, []models.SkippedFile, error) {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	var skipped []models.SkippedFile
+	skip := func(name, reason string) {
+		skipped = append(skipped, models.SkippedFile{Name: name, Reason: reason})
+	}
+
+	type candidate struct {
+		cvs, projects []*zip.File
+	}
+	candidates := map[string]*candidate{}
+	for _, file := range reader.File {
+		name := strings.ReplaceAll(file.Name, `\`, "/")
+		if file.FileInfo().IsDir() || isArchiveJunk(name) {
+			continue
+		}
+		if !isSafeArchivePath(name) {
+			skip(file.Name, "unsafe path")
+			continue
+		}
+		if MimeTypeFor(name) == "" {
+			skip(file.Name, "unsupported file type")
+			continue
+		}
+
+		key, project := archiveCandidateKey(name)
+		if key == "" {
+			skip(file.Name, "file name does not identify a candidate")
+			continue
+		}
+		if candidates[key] == nil {
+			candidates[key] = &candidate{}
+		}
+		if project {
+			candidates[key].projects = append(candidates[key].projects, file)
+		} else {
+			candidates[key].cvs = append(candidates[key].cvs, file)
+		}
+	}
+
+	pairs := []ArchivePair{}
+	for _, c := range candidates {
+		if len(c.cvs) == 0 {
+			for _, file := range c.projects {
+				skip(file.Name, "no matching CV")
+			}
+			continue
+		}
+		if len(c.cvs) > 1 || len(c.projects) > 1 {
+			for _, file := range append(c.cvs, c.projects...) {
+				skip(file.Name, "more than one CV or project report for the same candidate")
+			}
+			continue
+		}
+		pair := ArchivePair{CV: c.cvs[0]}
+		if len(c.projects) == 1 {
+			pair.Project = c.projects[0]
+		}
+		pairs = append(pairs, pair)
+	}
+
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].CV.Name < pairs[j].CV.Name })
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].Name < skipped[j].Name })
+	return pairs, skipped, nil
+}
+
+// SaveArchiveFile saves a file of a ZIP archive like a regular upload, under its base name. The size is
+// enforced on the decompressed data, whatever the archive's header claims.
+func (s *FileService) SaveArchiveFile(ctx context.Context, file *zip.File) (*models.Upload, error) {
+	if file.UncompressedSize64 > uint64(s.maxFileSize) {
+		return nil, ErrFileTooLarge
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer rc.Close()
+
+	return s.SaveReader(ctx, path.Base(strings.ReplaceAll(file.Name, `\`, "/")), rc)
+}
+
+// MaxArchiveSize is the largest ZIP archive accepted
+func (s *FileService) MaxArchiveSize() int64 {
+	return s.maxArchiveSize
+}
+
+// isSafeArchivePath reports whether an archive entry name stays inside the archive: relative, without
+// parent references or drive letters
+func isSafeArchivePath(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, ":") {
+		return false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// isArchiveJunk reports whether an archive entry is operating system metadata such as macOS resource forks
+func isArchiveJunk(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if part == "__MACOSX" || (strings.HasPrefix(part, ".") && part != "." && part != "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveCandidateKey derives the name shared by a candidate's CV and project report from an archive
+// path, and whether the path is a project report
+func archiveCandidateKey(name string) (string, bool) {
+	dir, file := path.Split(name)
+	stem := strings.ToLower(strings.TrimSuffix(file, path.Ext(file)))
+
+	project := false
+	var parts []string
+	for _, folder := range strings.Split(strings.Trim(dir, "/"), "/") {
+		folder = strings.ToLower(folder)
+		switch {
+		case folder == "" || folder == ".":
+		case slices.Contains(archiveProjectWords, folder):
+			project = true
+		case slices.Contains(archiveCVWords, folder):
+		default:
+			parts = append(parts, folder)
+		}
+	}
+
+	for {
+		trimmed, ok := trimArchiveWord(stem, archiveProjectWords)
+		if !ok {
+			break
+		}
+		stem, project = trimmed, true
+	}
+	for {
+		trimmed, ok := trimArchiveWord(stem, archiveCVWords)
+		if !ok {
+			break
+		}
+		stem = trimmed
+	}
+
+	if stem != "" {
+		parts = append(parts, stem)
+	}
+	return strings.Join(parts, "/"), project
+}
+
+// trimArchiveWord removes one of words from the end of a file name stem, with the separator before it
+func trimArchiveWord(stem string, words []string) (string, bool) {
+	for _, word := range words {
+		if stem == word {
+			return "", true
+		}
+		for _, sep := range []string{"_", "-", " ", "."} {
+			if strings.HasSuffix(stem, sep+word) {
+				return strings.TrimSuffix(stem, sep+word), true
+			}
+		}
+	}
+	return stem, false
+}
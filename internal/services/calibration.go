@@ -0,0 +1,175 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+	"ai-cv-summarize/internal/telemetry"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// calibrationLeaseKey is held in Redis by the instance that ran the latest scheduled calibration
+const calibrationLeaseKey = "calibration:lease"
+
+// CalibrationAlert is the body posted to CALIBRATION_WEBHOOK_URL when a calibration run finds drift
+type CalibrationAlert struct {
+	Event     string    `json:"event"`
+	RunID     string    `json:"run_id"`
+	Scheduled bool      `json:"scheduled"`
+	Total     int       `json:"total"`
+	Failed    int       `json:"failed"`
+	Errors    int       `json:"errors"`
+	Drifts    []string  `json:"drifts"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// CalibrationService re-evaluates the golden set on a schedule and alerts when model or prompt drift
+// pushes scores out of their expected ranges
+type CalibrationService struct {
+	repository    repositories.Repository
+	goldenService *GoldenService
+	config        *config.CalibrationConfig
+	httpClient    *http.Client
+	// redisClient coordinates scheduled runs between instances; nil when running a single instance
+	redisClient redis.UniversalClient
+}
+
+func NewCalibrationService(repository repositories.Repository, goldenService *GoldenService, redisClient redis.UniversalClient, cfg *config.CalibrationConfig) *CalibrationService {
+	return &CalibrationService{
+		repository:    repository,
+		goldenService: goldenService,
+		redisClient:   redisClient,
+		config:        cfg,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Run calibrates every interval until ctx is cancelled. The first run waits a full interval, so restarts
+// do not re-evaluate the golden set. With Redis, only the instance that takes the lease runs, so the golden
+// set is re-evaluated once per interval however many instances are deployed.
+func (cs *CalibrationService) Run(ctx context.Context) {
+	if cs.config.Interval <= 0 {
+		log.Println("Scheduled golden-set calibration disabled")
+		return
+	}
+
+	for {
+		select {
+		case <-time.After(cs.config.Interval):
+		case <-ctx.Done():
+			return
+		}
+
+		acquired, err := cs.acquireLease(ctx)
+		if err != nil {
+			log.Printf("Error acquiring calibration lease: %v", err)
+			continue
+		}
+		if !acquired {
+			continue
+		}
+
+		if _, err := cs.Calibrate(ctx, true); err != nil {
+			log.Printf("Error calibrating golden set: %v", err)
+		}
+	}
+}
+
+// acquireLease reports whether this instance runs the scheduled calibration. The lease is kept for a full
+// interval rather than released after the run, so another instance whose timer fires shortly after does not
+// run again.
+func (cs *CalibrationService) acquireLease(ctx context.Context) (bool, error) {
+	if cs.redisClient == nil {
+		return true, nil
+	}
+
+	holder, _ := os.Hostname()
+	return cs.redisClient.SetNX(ctx, calibrationLeaseKey, holder, cs.config.Interval).Result()
+}
+
+// Calibrate re-evaluates the golden set with the current prompts/model, alerts on drift and stores the run
+func (cs *CalibrationService) Calibrate(ctx context.Context, scheduled bool) (run *models.GoldenComparisonReport, err error) {
+	ctx, span := telemetry.Start(ctx, "golden.calibration", trace.WithAttributes(attribute.Bool("calibration.scheduled", scheduled)))
+	defer func() { telemetry.End(span, err) }()
+
+	run, err = cs.goldenService.Compare(ctx, cs.config.Tolerance, false)
+	if err != nil {
+		return nil, err
+	}
+	run.Scheduled = scheduled
+	run.ID = primitive.NewObjectID()
+
+	span.SetAttributes(
+		attribute.Int("calibration.total", run.Total),
+		attribute.Int("calibration.failed", run.Failed),
+		attribute.Int("calibration.errors", run.Errors),
+		attribute.Float64("calibration.mean_abs_delta", run.MeanAbsDelta),
+	)
+
+	if len(run.Drifts) > 0 {
+		span.AddEvent("golden.drift")
+		for _, drift := range run.Drifts {
+			log.Printf("Golden-set drift: %s", drift)
+		}
+		if cs.config.WebhookURL != "" {
+			if err := cs.sendAlert(ctx, run); err != nil {
+				log.Printf("Error sending calibration alert for run %s: %v", run.ID.Hex(), err)
+			} else {
+				run.Alerted = true
+			}
+		}
+	}
+
+	if err := cs.repository.SaveCalibrationRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to save calibration run: %w", err)
+	}
+
+	return run, nil
+}
+
+// sendAlert posts the drifts of a run to the webhook
+func (cs *CalibrationService) sendAlert(ctx context.Context, run *models.GoldenComparisonReport) error {
+	body, err := json.Marshal(CalibrationAlert{
+		Event:     "golden.drift",
+		RunID:     run.ID.Hex(),
+		Scheduled: run.Scheduled,
+		Total:     run.Total,
+		Failed:    run.Failed,
+		Errors:    run.Errors,
+		Drifts:    run.Drifts,
+		StartedAt: run.StartedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cs.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := cs.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"ai-cv-summarize/internal/llm"
+	"ai-cv-summarize/internal/rag"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// condenseMaxRounds bounds the merge rounds of condenseDocument
+const condenseMaxRounds = 3
+
+// condenseParallelism is how many parts of a document are sent to the provider at once
+const condenseParallelism = 4
+
+// condensePromptReserve is left in the context window for the extraction and merge templates
+const condensePromptReserve = 500
+
+// condenseDocument reduces a document too long for its prompt to the facts the evaluation needs instead of
+// dropping most of it: the document is split into parts that fit the context window, the facts of every
+// part are extracted, and the facts of consecutive parts are merged, round after round, until they fit in
+// budget tokens. Facts still over budget after the last round are cut to their passages most relevant to query.
+func (es *EvaluationService) condenseDocument(ctx context.Context, documentType, document, query string, budget int) (string, error) {
+	partTokens := es.config.LLM.ContextWindow - llm.CompletionMaxTokens - condensePromptReserve
+	if partTokens <= 0 {
+		fitted, _ := rag.FitText(document, query, budget)
+		return fitted, nil
+	}
+
+	parts := rag.Passages(document, partTokens)
+	facts, err := es.runFactPrompts(ctx, PromptExtractFacts, documentType, parts)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract facts from %s: %w", documentType, err)
+	}
+
+	for round := 0; round < condenseMaxRounds && llm.CountTokens(strings.Join(facts, "\n")) > budget; round++ {
+		groups := groupFacts(facts, partTokens)
+		if len(groups) == len(facts) {
+			// Every part's facts already fill a prompt of their own, so merging cannot shrink them
+			break
+		}
+		if facts, err = es.runFactPrompts(ctx, PromptMergeFacts, documentType, groups); err != nil {
+			return "", fmt.Errorf("failed to merge facts from %s: %w", documentType, err)
+		}
+	}
+
+	condensed := strings.Join(facts, "\n")
+	log.Printf("Condensed %s of %d tokens in %d parts to %d tokens of facts",
+		documentType, llm.CountTokens(document), len(parts), llm.CountTokens(condensed))
+	fitted, _ := rag.FitText(condensed, query, budget)
+	return fitted, nil
+}
+
+// runFactPrompts runs the extraction or merge prompt on every input, a few at a time, and returns the
+// responses in input order
+func (es *EvaluationService) runFactPrompts(ctx context.Context, name, documentType string, inputs []string) ([]string, error) {
+	results := make([]string, len(inputs))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(condenseParallelism)
+	for i, input := range inputs {
+		i, input := i, input
+		group.Go(func() error {
+			prompt, err := es.promptService.Render(groupCtx, name, PromptData{
+				Document:     QuoteDocument(input),
+				DocumentType: documentType,
+			})
+			if err != nil {
+				return err
+			}
+			response, err := es.llmClient.GenerateCompletionWithRetry(groupCtx, prompt.Text, prompt.Temperature(0), es.config.JobQueue.MaxRetries)
+			if err != nil {
+				return fmt.Errorf("part %d of %d: %w", i+1, len(inputs), err)
+			}
+			results[i] = strings.TrimSpace(response)
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// groupFacts joins the facts of consecutive parts into groups of at most size tokens to merge together
+func groupFacts(facts []string, size int) []string {
+	var groups, current []string
+	currentTokens := 0
+	for _, fact := range facts {
+		tokens := llm.CountTokens(fact)
+		if len(current) > 0 && currentTokens+tokens > size {
+			groups = append(groups, strings.Join(current, "\n"))
+			current, currentTokens = nil, 0
+		}
+		current = append(current, fact)
+		currentTokens += tokens
+	}
+	if len(current) > 0 {
+		groups = append(groups, strings.Join(current, "\n"))
+	}
+	return groups
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"ai-cv-summarize/internal/models"
+)
+
+// ContentHash identifies everything an evaluation result depends on: the CV and project content, the job
+// description, the resolved rubrics with their weights, the retrieval options, the feedback style, the GitHub
+// reference and whether the job is a sandbox or blind run. Jobs with the same hash produce the same result, so a completed one can be
+// returned instead of evaluating again.
+// Jobs without a job description hash the same regardless of the stored job descriptions they retrieve from.
+func (es *EvaluationService) ContentHash(ctx context.Context, job *models.EvaluationJob) (string, error) {
+	cvRubric, projectRubric, err := es.jobRubrics(ctx, job)
+	if err != nil {
+		return "", err
+	}
+
+	jobDescription, err := es.jobDescriptionHash(ctx, job)
+	if err != nil {
+		return "", err
+	}
+
+	var overall *models.OverallWeights
+	if job.Weights != nil {
+		overall = job.Weights.Overall
+	}
+
+	// Only the parts of a rubric that affect scoring are hashed, so built-in rubrics hash the same every time
+	rubrics, err := json.Marshal([]interface{}{
+		cvRubric.Criteria, cvRubric.Scale,
+		projectRubric.Criteria, projectRubric.Scale,
+		overall,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode rubrics: %w", err)
+	}
+
+	parts := []string{
+		HashContent(job.CVContent),
+		HashContent(job.ProjectContent),
+		jobDescription,
+		string(rubrics),
+		strconv.FormatBool(job.Sandbox),
+	}
+	// Appended only when set so hashes of existing jobs stay valid
+	if job.Blind {
+		parts = append(parts, "blind")
+	}
+	if job.GitHub != "" {
+		parts = append(parts, "github:"+job.GitHub)
+	}
+	if job.RetrievalOptions != nil {
+		retrieval, err := json.Marshal(job.RetrievalOptions)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode retrieval options: %w", err)
+		}
+		parts = append(parts, "retrieval:"+string(retrieval))
+	}
+	if feedback := es.feedbackStyle(job); !feedback.isDefault() {
+		parts = append(parts, "feedback:"+feedback.verbosity+"/"+feedback.tone)
+	}
+
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// jobDescriptionHash hashes the job description a job is evaluated against, or returns an empty string when
+// its context is retrieved from all job descriptions
+func (es *EvaluationService) jobDescriptionHash(ctx context.Context, job *models.EvaluationJob) (string, error) {
+	id := job.JobDescriptionID
+	if id == "" {
+		if org := es.organization(ctx); org != nil {
+			id = org.DefaultJobDescriptionID
+		}
+	}
+	if id == "" {
+		return "", nil
+	}
+
+	jobDescription, err := es.repository.GetJobDescription(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to load job description %s: %w", id, err)
+	}
+	content := jobDescription.Title + "\n" + jobDescription.Description + "\n" + jobDescription.Requirements
+	// Calibrated thresholds change the recommendation, and are only hashed when set like the parts above
+	if t := jobDescription.RecommendationThresholds; t != nil {
+		content += fmt.Sprintf("\nthresholds:%g/%g", t.StrongHire, t.Hire)
+	}
+	return HashContent(content), nil
+}
@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"time"
 
 	"ai-cv-summarize/internal/models"
@@ -10,12 +13,14 @@ import (
 )
 
 type DatabaseInitService struct {
-	repository *repositories.MongoDBRepository
+	repository repositories.Repository
+	uploadDir  string
 }
 
-func NewDatabaseInitService(repository *repositories.MongoDBRepository) *DatabaseInitService {
+func NewDatabaseInitService(repository repositories.Repository, uploadDir string) *DatabaseInitService {
 	return &DatabaseInitService{
 		repository: repository,
+		uploadDir:  uploadDir,
 	}
 }
 
@@ -85,54 +90,24 @@ You should have experience with backend languages and frameworks (Node.js, Djang
 	return nil
 }
 
-// initializeDefaultScoringRubric creates a default scoring rubric
+// initializeDefaultScoringRubric creates the default CV and project scoring rubrics
 func (dis *DatabaseInitService) initializeDefaultScoringRubric(ctx context.Context) error {
-	// Check if scoring rubrics already exist
-	existing, err := dis.repository.GetDefaultScoringRubric(ctx)
-	if err == nil && existing != nil {
-		log.Println("Scoring rubric already exists, skipping initialization")
-		return nil
-	}
+	for _, rubric := range []*models.ScoringRubric{defaultCVRubric(), defaultProjectRubric()} {
+		// Check if the rubric already exists
+		existing, err := dis.repository.GetScoringRubricByName(ctx, rubric.Name)
+		if err == nil && existing != nil {
+			log.Printf("Scoring rubric %s already exists, skipping initialization", rubric.Name)
+			continue
+		}
 
-	// Create default scoring rubric
-	rubric := &models.ScoringRubric{
-		Name:        "default",
-		Description: "Default scoring rubric for candidate evaluation",
-		Criteria: []models.RubricCriteria{
-			{
-				Name:        "Technical Skills Match",
-				Description: "Alignment with job requirements (backend, databases, APIs, cloud, AI/LLM)",
-				Weight:      0.4,
-				MaxScore:    5.0,
-			},
-			{
-				Name:        "Experience Level",
-				Description: "Years of experience and project complexity",
-				Weight:      0.25,
-				MaxScore:    5.0,
-			},
-			{
-				Name:        "Relevant Achievements",
-				Description: "Impact of past work (scaling, performance, adoption)",
-				Weight:      0.2,
-				MaxScore:    5.0,
-			},
-			{
-				Name:        "Cultural/Collaboration Fit",
-				Description: "Communication, learning mindset, teamwork/leadership",
-				Weight:      0.15,
-				MaxScore:    5.0,
-			},
-		},
-		CreatedAt: time.Now(),
-	}
+		// Save to database
+		if err := dis.repository.CreateScoringRubric(ctx, rubric); err != nil {
+			return err
+		}
 
-	// Save to database
-	if err := dis.repository.CreateScoringRubric(ctx, rubric); err != nil {
-		return err
+		log.Printf("Scoring rubric %s created", rubric.Name)
 	}
 
-	log.Println("Default scoring rubric created")
 	return nil
 }
 
@@ -168,3 +143,127 @@ func (dis *DatabaseInitService) CreateSampleJobDescriptions(ctx context.Context)
 	log.Println("Sample job descriptions created")
 	return nil
 }
+
+// CreateSampleScoringRubrics creates sample rubrics, skipping any that already exist
+func (dis *DatabaseInitService) CreateSampleScoringRubrics(ctx context.Context) ([]string, error) {
+	sampleRubrics := []*models.ScoringRubric{
+		{
+			Name:        "junior-backend",
+			Description: "Sample rubric favouring potential over experience for junior roles",
+			Criteria: []models.RubricCriteria{
+				{Key: "technical_skills", Name: "Technical Skills Match", Description: "Fundamentals in backend, databases and APIs", Weight: 0.45, MaxScore: 5.0},
+				{Key: "experience_level", Name: "Experience Level", Description: "Internships, personal and academic projects", Weight: 0.1, MaxScore: 5.0},
+				{Key: "achievements", Name: "Relevant Achievements", Description: "Hackathons, open source, coursework highlights", Weight: 0.2, MaxScore: 5.0},
+				{Key: "cultural_fit", Name: "Cultural/Collaboration Fit", Description: "Learning mindset and teamwork", Weight: 0.25, MaxScore: 5.0},
+			},
+			CreatedAt: time.Now(),
+		},
+	}
+
+	var created []string
+	for _, rubric := range sampleRubrics {
+		if existing, err := dis.repository.GetScoringRubricByName(ctx, rubric.Name); err == nil && existing != nil {
+			continue
+		}
+		if err := dis.repository.CreateScoringRubric(ctx, rubric); err != nil {
+			return created, err
+		}
+		created = append(created, rubric.Name)
+	}
+
+	log.Printf("Sample scoring rubrics created: %d", len(created))
+	return created, nil
+}
+
+// CreateSampleFixtures writes a sample CV and project report into the upload directory
+func (dis *DatabaseInitService) CreateSampleFixtures() ([]string, error) {
+	fixtures := map[string]string{
+		"sample_cv.txt":             sampleCV,
+		"sample_project_report.txt": sampleProjectReport,
+	}
+
+	if err := os.MkdirAll(dis.uploadDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	var files []string
+	for name, content := range fixtures {
+		if err := os.WriteFile(filepath.Join(dis.uploadDir, name), []byte(content), 0644); err != nil {
+			return files, fmt.Errorf("failed to write fixture %s: %w", name, err)
+		}
+		files = append(files, name)
+	}
+
+	log.Println("Sample fixtures created")
+	return files, nil
+}
+
+// SampleDataSummary describes what LoadSampleData created
+type SampleDataSummary struct {
+	JobDescriptions int      `json:"job_descriptions"`
+	ScoringRubrics  []string `json:"scoring_rubrics"`
+	Fixtures        []string `json:"fixtures"`
+}
+
+// LoadSampleData loads sample job descriptions, rubrics and fixture files for demos
+func (dis *DatabaseInitService) LoadSampleData(ctx context.Context) (*SampleDataSummary, error) {
+	if err := dis.CreateSampleJobDescriptions(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create sample job descriptions: %w", err)
+	}
+
+	rubrics, err := dis.CreateSampleScoringRubrics(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sample scoring rubrics: %w", err)
+	}
+
+	fixtures, err := dis.CreateSampleFixtures()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SampleDataSummary{
+		JobDescriptions: 3,
+		ScoringRubrics:  rubrics,
+		Fixtures:        fixtures,
+	}, nil
+}
+
+const sampleCV = `Jane Doe
+Backend Engineer
+
+Summary
+Backend engineer with 4 years of experience building APIs and data pipelines in Go and Python.
+
+Experience
+Backend Engineer, Acme Logistics (2021 - present)
+- Built an event-driven order processing service in Go, Kafka and PostgreSQL, reducing latency by 40%.
+- Introduced Redis caching for the pricing API, cutting p95 response time from 800ms to 120ms.
+- Prototyped an LLM-based support ticket classifier using OpenAI embeddings.
+
+Software Engineer, Bright Apps (2019 - 2021)
+- Developed REST APIs in Python/Django serving 200k monthly users.
+- Migrated deployments to Docker and AWS ECS.
+
+Skills
+Go, Python, MongoDB, PostgreSQL, Redis, Docker, AWS, REST, gRPC, OpenAI API
+
+Education
+B.Sc. Computer Science, State University (2019)
+`
+
+const sampleProjectReport = `Project Report: AI CV Evaluator
+
+Overview
+A Go service that accepts CV and project report uploads, queues evaluation jobs in Redis and scores them with an LLM pipeline backed by retrieval over job descriptions.
+
+Design
+- Gin HTTP API with upload, evaluate, result and job status endpoints.
+- MongoDB stores jobs, job descriptions with embeddings, and scoring rubrics.
+- The pipeline extracts structured CV data, evaluates it against the rubric, scores the project report and writes a summary.
+
+Resilience
+LLM calls are retried with backoff; failed jobs record their error message and retry count.
+
+Trade-offs
+Similarity search is a brute-force scan, which is fine for tens of job descriptions but would need a vector database at scale.
+`
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// rtfSkippedDestinations are RTF groups that hold formatting tables, metadata or binary data rather than text
+var rtfSkippedDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true, "pict": true, "object": true,
+	"themedata": true, "colorschememapping": true, "datastore": true, "latentstyles": true, "listtable": true,
+	"listoverridetable": true, "rsidtbl": true, "generator": true, "xmlnstbl": true, "filetbl": true,
+	"revtbl": true, "fldinst": true, "datafield": true, "nonshppict": true, "bkmkstart": true, "bkmkend": true,
+	"mmathPr": true, "pgdsctbl": true, "operator": true, "userprops": true,
+}
+
+// rtfSymbols maps RTF control words to the text they stand for
+var rtfSymbols = map[string]string{
+	"par": "\n", "line": "\n", "row": "\n", "sect": "\n", "page": "\n", "tab": "\t", "cell": "\t",
+	"emdash": "—", "endash": "–", "bullet": "•", "lquote": "‘", "rquote": "’", "ldblquote": "“", "rdblquote": "”",
+	"emspace": " ", "enspace": " ", "qmspace": " ",
+}
+
+// extractRTFText returns the text of an RTF document, dropping formatting, embedded objects and pictures
+func extractRTFText(data []byte) (string, error) {
+	if !strings.HasPrefix(string(data), "{\\rtf") {
+		return "", errors.New("not an RTF document")
+	}
+
+	type groupState struct {
+		skip bool
+		// ucSkip is how many fallback characters follow a \u escape
+		ucSkip int
+	}
+	state := groupState{ucSkip: 1}
+	var stack []groupState
+	var text strings.Builder
+	// pending counts the fallback characters of the last \u escape still to drop
+	pending := 0
+
+	write := func(value string) {
+		if state.skip {
+			return
+		}
+		if pending > 0 {
+			pending--
+			return
+		}
+		text.WriteString(value)
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '{':
+			stack = append(stack, state)
+			pending = 0
+			continue
+		case '}':
+			if len(stack) > 0 {
+				state, stack = stack[len(stack)-1], stack[:len(stack)-1]
+			}
+			pending = 0
+			continue
+		case '\r', '\n':
+			continue
+		case '\\':
+		default:
+			write(string(charmap.Windows1252.DecodeByte(c)))
+			continue
+		}
+
+		if i+1 >= len(data) {
+			break
+		}
+		i++
+		c = data[i]
+		switch {
+		case c == '\\' || c == '{' || c == '}':
+			write(string(c))
+		case c == '~':
+			write(" ")
+		case c == '_':
+			write("-")
+		case c == '*':
+			state.skip = true
+		case c == '\'':
+			if i+2 < len(data) {
+				if value, err := strconv.ParseUint(string(data[i+1:i+3]), 16, 8); err == nil {
+					write(string(charmap.Windows1252.DecodeByte(byte(value))))
+				}
+				i += 2
+			}
+		case c == '\r' || c == '\n':
+			write("\n")
+		case isASCIILetter(c):
+			start := i
+			for i < len(data) && isASCIILetter(data[i]) {
+				i++
+			}
+			word := string(data[start:i])
+			paramStart := i
+			if i < len(data) && data[i] == '-' {
+				i++
+			}
+			for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+				i++
+			}
+			param, hasParam := 0, i > paramStart
+			if hasParam {
+				param, _ = strconv.Atoi(string(data[paramStart:i]))
+			}
+			// A single space delimits the control word and is not part of the text
+			if i >= len(data) || data[i] != ' ' {
+				i--
+			}
+
+			switch {
+			case rtfSkippedDestinations[word]:
+				state.skip = true
+			case word == "uc" && hasParam:
+				state.ucSkip = param
+			case word == "u" && hasParam:
+				if param < 0 {
+					param += 0x10000
+				}
+				if !state.skip {
+					pending = 0
+					text.WriteRune(rune(param))
+					pending = state.ucSkip
+				}
+			case word == "bin" && hasParam:
+				i += param
+			default:
+				if symbol, ok := rtfSymbols[word]; ok {
+					write(symbol)
+				}
+			}
+		}
+	}
+
+	return tidyExtractedText(text.String()), nil
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// htmlSkippedElements hold no visible document text
+var htmlSkippedElements = map[string]bool{
+	"title": true, "script": true, "style": true, "noscript": true, "template": true, "svg": true, "iframe": true,
+}
+
+// htmlBlockElements start a new line in the extracted text
+var htmlBlockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "ul": true, "ol": true, "tr": true, "table": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "section": true, "article": true,
+	"header": true, "footer": true, "blockquote": true, "pre": true, "hr": true, "dt": true, "dd": true,
+	"address": true, "main": true, "aside": true, "nav": true,
+}
+
+// extractHTMLText returns the visible text of an HTML document, one line per block element
+func extractHTMLText(r io.Reader) (string, error) {
+	tokenizer := html.NewTokenizer(r)
+	var text strings.Builder
+	skipDepth, preDepth := 0, 0
+	// space is set when whitespace separated the last text from whatever comes next
+	space := false
+
+	atLineStart := func() bool {
+		current := text.String()
+		return current == "" || strings.HasSuffix(current, "\n") || strings.HasSuffix(current, "\t")
+	}
+	newline := func() {
+		if !atLineStart() {
+			text.WriteString("\n")
+		}
+		space = false
+	}
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return "", err
+			}
+			return tidyExtractedText(text.String()), nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			switch {
+			case tag == "body":
+				// Recover from head elements that were never closed
+				skipDepth = 0
+			case htmlSkippedElements[tag] && tokenType == html.StartTagToken:
+				skipDepth++
+			case tag == "pre":
+				preDepth++
+			}
+			if htmlBlockElements[tag] {
+				newline()
+			}
+			switch tag {
+			case "li":
+				text.WriteString("- ")
+			case "td", "th":
+				text.WriteString("\t")
+				space = false
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if htmlSkippedElements[tag] && skipDepth > 0 {
+				skipDepth--
+			}
+			if tag == "pre" && preDepth > 0 {
+				preDepth--
+			}
+			if htmlBlockElements[tag] {
+				newline()
+			}
+		case html.TextToken:
+			if skipDepth > 0 {
+				continue
+			}
+			content := html.UnescapeString(string(tokenizer.Text()))
+			if preDepth > 0 {
+				text.WriteString(content)
+				continue
+			}
+
+			words := strings.Fields(content)
+			if len(words) == 0 {
+				space = space || content != ""
+				continue
+			}
+			if (space || unicode.IsSpace([]rune(content)[0])) && !atLineStart() {
+				text.WriteString(" ")
+			}
+			text.WriteString(strings.Join(words, " "))
+			space = unicode.IsSpace([]rune(content)[len([]rune(content))-1])
+		}
+	}
+}
+
+// odtTextNamespace is the OpenDocument namespace of paragraphs, headings and their inline elements
+const odtTextNamespace = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+
+// extractODTText returns the text of an OpenDocument content.xml, one line per paragraph or heading
+func extractODTText(r io.Reader) (string, error) {
+	decoder := xml.NewDecoder(r)
+	var text strings.Builder
+	// Only character data inside paragraphs and headings is text; the rest is styles and declarations
+	depth := 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return tidyExtractedText(text.String()), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if element.Name.Space != odtTextNamespace {
+				continue
+			}
+			switch element.Name.Local {
+			case "p", "h":
+				depth++
+			case "tab":
+				text.WriteString("\t")
+			case "line-break":
+				text.WriteString("\n")
+			case "s":
+				count := 1
+				for _, attr := range element.Attr {
+					if attr.Name.Local == "c" {
+						if n, err := strconv.Atoi(attr.Value); err == nil && n > 0 {
+							count = n
+						}
+					}
+				}
+				text.WriteString(strings.Repeat(" ", count))
+			case "list-item":
+				text.WriteString("- ")
+			}
+		case xml.EndElement:
+			if element.Name.Space == odtTextNamespace && (element.Name.Local == "p" || element.Name.Local == "h") {
+				depth--
+				text.WriteString("\n")
+			}
+		case xml.CharData:
+			if depth > 0 {
+				text.Write(element)
+			}
+		}
+	}
+}
+
+// tidyExtractedText trims trailing spaces from lines and collapses runs of blank lines left by markup
+func tidyExtractedText(text string) string {
+	lines := strings.Split(text, "\n")
+	tidy := make([]string, 0, len(lines))
+	blank := true
+	for _, line := range lines {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" {
+			if !blank {
+				tidy = append(tidy, "")
+			}
+			blank = true
+			continue
+		}
+		tidy = append(tidy, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(tidy, "\n"))
+}
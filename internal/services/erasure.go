@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/rag"
+	"ai-cv-summarize/internal/repositories"
+)
+
+// ErrJobsInProgress is returned when an erasure covers jobs that are still queued or processing
+var ErrJobsInProgress = errors.New("jobs are still being evaluated")
+
+// ErasureService handles right-to-erasure requests. It irreversibly removes a candidate's documents,
+// uploaded files, identifiers, feedback, cached embeddings and LLM call records, keeping the jobs'
+// scores so aggregate statistics stay intact, and writes an audit record of each erasure.
+type ErasureService struct {
+	repository  repositories.Repository
+	fileService *FileService
+	vectorStore *rag.VectorStore
+}
+
+func NewErasureService(repository repositories.Repository, fileService *FileService, vectorStore *rag.VectorStore) *ErasureService {
+	return &ErasureService{
+		repository:  repository,
+		fileService: fileService,
+		vectorStore: vectorStore,
+	}
+}
+
+// ForgetCandidate erases every job of a candidate; it returns ErrNotFound when the candidate has no jobs
+func (s *ErasureService) ForgetCandidate(ctx context.Context, candidateID, reason string) (*models.ErasureRecord, error) {
+	sum := sha256.Sum256([]byte(candidateID))
+	record := &models.ErasureRecord{CandidateIDHash: hex.EncodeToString(sum[:]), Reason: reason}
+	return s.forget(ctx, repositories.JobBulkFilter{CandidateID: candidateID}, record)
+}
+
+// ForgetJob erases a single job, including soft-deleted ones
+func (s *ErasureService) ForgetJob(ctx context.Context, jobID, reason string) (*models.ErasureRecord, error) {
+	return s.forget(ctx, repositories.JobBulkFilter{IDs: []string{jobID}}, &models.ErasureRecord{Reason: reason})
+}
+
+func (s *ErasureService) forget(ctx context.Context, filter repositories.JobBulkFilter, record *models.ErasureRecord) (*models.ErasureRecord, error) {
+	jobs, err := s.repository.FindJobs(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find jobs: %w", err)
+	}
+
+	// A worker would write feedback back onto an erased job
+	for _, job := range jobs {
+		if job.Status == models.StatusQueued || job.Status == models.StatusProcessing {
+			return nil, fmt.Errorf("%w: job %s is %s", ErrJobsInProgress, job.ID.Hex(), job.Status)
+		}
+	}
+
+	// Archived copies hold the same documents and feedback
+	filter.Archived = true
+	archived, err := s.repository.FindJobs(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find archived jobs: %w", err)
+	}
+	record.ArchivedJobs = len(archived)
+	jobs = append(jobs, archived...)
+	if len(jobs) == 0 {
+		return nil, repositories.ErrNotFound
+	}
+
+	now := time.Now()
+	record.JobIDs = make([]string, 0, len(jobs))
+	var embeddingKeys []string
+	batches := map[string][]string{}
+	for _, job := range jobs {
+		jobID := job.ID.Hex()
+		record.JobIDs = append(record.JobIDs, jobID)
+		embeddingKeys = append(embeddingKeys, s.vectorStore.DocumentEmbeddingKeys(job.CVContent, job.ProjectContent)...)
+		if job.BatchID != "" {
+			batches[job.BatchID] = append(batches[job.BatchID], jobID)
+		}
+	}
+
+	record.FilesRemoved = s.fileService.RemoveJobUploads(ctx, jobs)
+	for _, jobID := range record.JobIDs {
+		if err := s.repository.AnonymizeJob(ctx, jobID, now); err != nil {
+			return nil, fmt.Errorf("failed to anonymize job %s: %w", jobID, err)
+		}
+	}
+	for batchID, jobIDs := range batches {
+		if err := s.repository.AnonymizeBatchItems(ctx, batchID, jobIDs); err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			return nil, fmt.Errorf("failed to anonymize batch %s: %w", batchID, err)
+		}
+	}
+
+	if len(embeddingKeys) > 0 {
+		if record.EmbeddingsRemoved, err = s.repository.DeleteCachedEmbeddings(ctx, embeddingKeys); err != nil {
+			return nil, fmt.Errorf("failed to delete cached embeddings: %w", err)
+		}
+	}
+	if record.LLMCallsRemoved, err = s.repository.DeleteLLMCalls(ctx, record.JobIDs); err != nil {
+		return nil, fmt.Errorf("failed to delete LLM calls: %w", err)
+	}
+	if record.GoldenJobsRemoved, err = s.removeGoldenJobs(ctx, record.JobIDs); err != nil {
+		return nil, err
+	}
+
+	record.CreatedAt = now
+	if err := s.repository.CreateErasureRecord(ctx, record); err != nil {
+		// The erasure itself is done; only its audit trail is missing
+		log.Printf("Error recording erasure of jobs %v: %v", record.JobIDs, err)
+		return record, fmt.Errorf("jobs were erased but the audit record could not be saved: %w", err)
+	}
+
+	return record, nil
+}
+
+// removeGoldenJobs deletes golden set entries made from the jobs, since they copy the job's documents and feedback
+func (s *ErasureService) removeGoldenJobs(ctx context.Context, jobIDs []string) (int, error) {
+	goldens, err := s.repository.GetAllGoldenJobs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get golden jobs: %w", err)
+	}
+
+	erased := map[string]bool{}
+	for _, jobID := range jobIDs {
+		erased[jobID] = true
+	}
+
+	removed := 0
+	for _, golden := range goldens {
+		if !erased[golden.JobID] {
+			continue
+		}
+		if err := s.repository.DeleteGoldenJob(ctx, golden.ID.Hex()); err != nil {
+			return removed, fmt.Errorf("failed to delete golden job %s: %w", golden.ID.Hex(), err)
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"math"
+	"strings"
+
+	"ai-cv-summarize/internal/models"
+)
+
+// DiffEvaluations compares the results of two completed jobs. Both jobs must have a result.
+func DiffEvaluations(from, to *models.EvaluationJob) *models.EvaluationDiff {
+	fromScores := resultScores(from.Result)
+	toScores := resultScores(to.Result)
+
+	scores := make(map[string]models.ScoreChange, len(fromScores))
+	for name, value := range fromScores {
+		scores[name] = models.ScoreChange{
+			From:  value,
+			To:    toScores[name],
+			Delta: math.Round((toScores[name]-value)*100) / 100,
+		}
+	}
+
+	diff := &models.EvaluationDiff{
+		CandidateID:     to.CandidateID,
+		FromJobID:       from.ID.Hex(),
+		ToJobID:         to.ID.Hex(),
+		FromCreatedAt:   from.CreatedAt,
+		ToCreatedAt:     to.CreatedAt,
+		Scores:          scores,
+		FeedbackChanges: []models.FeedbackChange{},
+	}
+
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"cv_feedback", from.Result.CVFeedback, to.Result.CVFeedback},
+		{"project_feedback", from.Result.ProjectFeedback, to.Result.ProjectFeedback},
+		{"overall_summary", from.Result.OverallSummary, to.Result.OverallSummary},
+	}
+	for _, field := range fields {
+		added, removed := sentenceChanges(field.from, field.to)
+		if len(added) == 0 && len(removed) == 0 {
+			continue
+		}
+		diff.FeedbackChanges = append(diff.FeedbackChanges, models.FeedbackChange{
+			Field:   field.name,
+			Added:   added,
+			Removed: removed,
+		})
+	}
+
+	return diff
+}
+
+// sentenceChanges returns the sentences only present in to (added) and only present in from (removed)
+func sentenceChanges(from, to string) (added, removed []string) {
+	fromSentences := splitSentences(from)
+	toSentences := splitSentences(to)
+
+	fromSet := make(map[string]bool, len(fromSentences))
+	for _, sentence := range fromSentences {
+		fromSet[sentence] = true
+	}
+	toSet := make(map[string]bool, len(toSentences))
+	for _, sentence := range toSentences {
+		toSet[sentence] = true
+	}
+
+	for _, sentence := range toSentences {
+		if !fromSet[sentence] {
+			added = append(added, sentence)
+		}
+	}
+	for _, sentence := range fromSentences {
+		if !toSet[sentence] {
+			removed = append(removed, sentence)
+		}
+	}
+
+	return added, removed
+}
+
+// splitSentences breaks feedback text into trimmed sentences on terminal punctuation and line breaks
+func splitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+
+	flush := func() {
+		if sentence := strings.TrimSpace(current.String()); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+		if (r == '.' || r == '!' || r == '?') && (i+1 == len(runes) || runes[i+1] == ' ' || runes[i+1] == '\n') {
+			flush()
+		}
+	}
+	flush()
+
+	return sentences
+}
@@ -2,137 +2,540 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"math"
 	"strings"
+	"time"
 
+	"ai-cv-summarize/internal/audit"
 	"ai-cv-summarize/internal/config"
 	"ai-cv-summarize/internal/llm"
 	"ai-cv-summarize/internal/models"
 	"ai-cv-summarize/internal/rag"
 	"ai-cv-summarize/internal/repositories"
+	"ai-cv-summarize/internal/telemetry"
+	"ai-cv-summarize/internal/tenant"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 )
 
 type EvaluationService struct {
-	llmClient   llm.LLMClient
-	repository  *repositories.MongoDBRepository
-	vectorStore *rag.VectorStore
-	config      *config.Config
+	llmClient      llm.LLMClient
+	judgeClient    llm.LLMClient
+	repository     repositories.Repository
+	vectorStore    *rag.VectorStore
+	scoringService *ScoringService
+	promptService  *PromptService
+	languages      *LanguageService
+	moderation     *ModerationService
+	github         *GitHubService
+	experiments    *ExperimentService
+	config         *config.Config
+
+	// replaying makes a replay reuse the GitHub data and similar projects recorded on the result instead
+	// of fetching and searching them again
+	replaying bool
 }
 
 func NewEvaluationService(
 	llmClient llm.LLMClient,
-	repository *repositories.MongoDBRepository,
+	judgeClient llm.LLMClient,
+	repository repositories.Repository,
 	vectorStore *rag.VectorStore,
+	scoringService *ScoringService,
+	promptService *PromptService,
+	languages *LanguageService,
+	moderation *ModerationService,
+	github *GitHubService,
+	experiments *ExperimentService,
 	config *config.Config,
 ) *EvaluationService {
 	return &EvaluationService{
-		llmClient:   llmClient,
-		repository:  repository,
-		vectorStore: vectorStore,
-		config:      config,
+		llmClient:      llmClient,
+		judgeClient:    judgeClient,
+		repository:     repository,
+		vectorStore:    vectorStore,
+		scoringService: scoringService,
+		promptService:  promptService,
+		languages:      languages,
+		moderation:     moderation,
+		github:         github,
+		experiments:    experiments,
+		config:         config,
 	}
 }
 
-// EvaluateCandidate runs the complete evaluation pipeline
-func (es *EvaluationService) EvaluateCandidate(ctx context.Context, jobID string) error {
+// EvaluateCandidate runs the complete evaluation pipeline on a job claimed with claimToken (see
+// Repository.ClaimJob). The result is only saved while the claim still holds.
+func (es *EvaluationService) EvaluateCandidate(ctx context.Context, jobID, claimToken string) error {
 	// Get job from database
 	job, err := es.repository.GetJobByID(ctx, jobID)
 	if err != nil {
 		return fmt.Errorf("failed to get job: %w", err)
 	}
 
-	// Update status to processing
-	if err := es.repository.UpdateJobStatus(ctx, jobID, models.StatusProcessing); err != nil {
-		return fmt.Errorf("failed to update job status: %w", err)
+	// Workers run unscoped; evaluate with the owning organization's data, rubrics and defaults. Jobs
+	// created with the admin key belong to no organization and only retrieve the global documents.
+	ctx = tenant.WithOrgID(ctx, job.OrgID)
+	ctx = audit.WithJob(ctx, jobID)
+
+	// Reset step progress, including steps left over from a previous attempt
+	pipeline := es.pipelineSteps()
+	steps := make([]models.JobStep, 0, len(pipeline))
+	for _, name := range pipeline {
+		steps = append(steps, models.JobStep{Name: name, Status: models.StepPending})
+	}
+	if err := es.repository.UpdateJobSteps(ctx, jobID, steps); err != nil {
+		return fmt.Errorf("failed to initialize job steps: %w", err)
 	}
 
-	// Get relevant context from RAG
-	context, err := es.vectorStore.GetRelevantContext(ctx, job.CVContent, job.ProjectContent)
+	ctx, recorder := llm.WithUsageRecorder(ctx)
+	tracker := &stepTracker{repository: es.repository, jobID: jobID, pricing: &es.config.Pricing}
+	// Jobs routed to a running experiment are evaluated with their variant's model and prompts
+	evaluator, ctx, assignment := es.withExperiment(ctx, job)
+	result, err := evaluator.evaluateContent(ctx, job, tracker)
+	es.saveUsage(ctx, job, recorder)
 	if err != nil {
-		return fmt.Errorf("failed to get relevant context: %w", err)
+		return err
 	}
+	result.Experiment = assignment
+
+	// Versioned after the job's earlier results, which the repository moves to its history
+	now := time.Now()
+	result.Version = len(job.ResultHistory) + 1
+	if job.Result != nil {
+		result.Version++
+	}
+	result.JobID = jobID
+	result.EvaluatedAt = &now
+	for _, usage := range recorder.Usage() {
+		result.Models = append(result.Models, usage.Model)
+	}
+
+	// Save result to database
+	if err := es.repository.UpdateJobResult(ctx, jobID, claimToken, result); err != nil {
+		return fmt.Errorf("failed to update job result: %w", err)
+	}
+
+	return nil
+}
 
-	// Step 1: Extract structured info from CV
-	cvAnalysis, err := es.analyzeCV(ctx, job.CVContent, context)
+// ErrNoRecordedCalls is returned when replaying a job that has no audited LLM calls
+var ErrNoRecordedCalls = errors.New("job has no recorded LLM calls")
+
+// ReplayContent re-runs the evaluation pipeline on a job against the LLM responses recorded for it in the
+// audit log, without calling the provider or persisting anything. It also returns the steps whose prompts
+// no longer match the recording.
+func (es *EvaluationService) ReplayContent(ctx context.Context, job *models.EvaluationJob) (*models.EvaluationResult, []string, error) {
+	jobID := job.ID.Hex()
+	calls, err := es.repository.GetLLMCalls(ctx, repositories.LLMCallFilter{JobID: jobID})
 	if err != nil {
-		return fmt.Errorf("failed to analyze CV: %w", err)
+		return nil, nil, fmt.Errorf("failed to get recorded LLM calls: %w", err)
+	}
+	if len(calls) == 0 {
+		return nil, nil, ErrNoRecordedCalls
 	}
 
-	// Step 2: Evaluate CV against job requirements
-	cvEvaluation, err := es.evaluateCV(ctx, cvAnalysis, context)
+	client := audit.NewReplayClient(calls)
+	replay := *es
+	replay.llmClient = client
+	replay.judgeClient = client
+	replay.vectorStore = rag.NewEphemeralVectorStore(client, es.repository, &es.config.VectorDB)
+	replay.languages = NewLanguageService(client, es.promptService, es.config)
+	replay.replaying = true
+
+	ctx = tenant.WithOrgID(ctx, job.OrgID)
+	if job.Result != nil && job.Result.Experiment != nil {
+		ctx = es.variantPrompts(ctx, job.Result.Experiment)
+	}
+	result, err := replay.evaluateContent(audit.WithJob(ctx, jobID), job, nil)
 	if err != nil {
-		return fmt.Errorf("failed to evaluate CV: %w", err)
+		return nil, nil, err
+	}
+
+	return result, client.ChangedSteps(), nil
+}
+
+// EvaluateContent runs the evaluation pipeline on a job's content without persisting anything, with the data
+// of the job's organization
+func (es *EvaluationService) EvaluateContent(ctx context.Context, job *models.EvaluationJob) (*models.EvaluationResult, error) {
+	return es.evaluateContent(tenant.WithOrgID(ctx, job.OrgID), job, nil)
+}
+
+func (es *EvaluationService) evaluateContent(ctx context.Context, job *models.EvaluationJob, tracker *stepTracker) (*models.EvaluationResult, error) {
+	// Personal data is replaced before anything, translation included, reaches the provider
+	redactor := es.redactor(job)
+	redactedCV, redactedProject := redactor.Redact(job.CVContent), redactor.Redact(job.ProjectContent)
+
+	// Disallowed content never reaches the scoring prompts
+	if err := es.checkContent(ctx, redactedCV, redactedProject); err != nil {
+		return nil, err
 	}
 
-	// Step 3: Evaluate project report
-	projectEvaluation, err := es.evaluateProject(ctx, job.ProjectContent, context)
+	// Reject or translate documents the prompts cannot handle
+	cvContent, err := es.languages.Prepare(ctx, "CV", redactedCV)
 	if err != nil {
-		return fmt.Errorf("failed to evaluate project: %w", err)
+		return nil, err
+	}
+	projectContent, err := es.languages.Prepare(ctx, "project report", redactedProject)
+	if err != nil {
+		return nil, err
+	}
+
+	contexts, err := es.evaluationContext(ctx, job, cvContent, projectContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relevant context: %w", err)
+	}
+	tracker.recordRetrieval(ctx, contexts)
+
+	// Recorded so results can be compared across prompt versions
+	promptVersions := es.promptVersions(ctx)
+
+	// Criteria and weights come from the stored rubrics so they can change without a deploy,
+	// unless the request pinned other rubrics or weights
+	cvRubric, projectRubric, err := es.jobRubrics(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	// Take-home reports are often copied, so compare this one with earlier submissions before scoring.
+	// A failed comparison only leaves the result unflagged.
+	similarProjects, err := es.findSimilarProjects(ctx, job, redactedProject, tracker != nil)
+	if err != nil {
+		log.Printf("Warning: failed to compare project report of job %s: %v", job.ID.Hex(), err)
+	}
+
+	feedback := es.feedbackStyle(job)
+
+	// The CV chain and the project evaluation are independent, so run them concurrently
+	var (
+		cvEvaluation      *CVEvaluation
+		projectEvaluation *ProjectEvaluation
+		github            *models.GitHubAnalysis
+		githubErr         error
+	)
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		// Step 1: Extract structured info from CV
+		var cvAnalysis *CVAnalysis
+		err := tracker.run(groupCtx, models.StepAnalyzeCV, func(ctx context.Context) (err error) {
+			cvAnalysis, err = es.analyzeCV(ctx, cvContent, contexts[models.StepAnalyzeCV].Text)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to analyze CV: %w", err)
+		}
+
+		// Step 2: Evaluate CV against job requirements
+		err = tracker.run(groupCtx, models.StepEvaluateCV, func(ctx context.Context) (err error) {
+			cvEvaluation, err = es.evaluateCV(ctx, cvAnalysis, contexts[models.StepEvaluateCV].Text, cvRubric, feedback)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to evaluate CV: %w", err)
+		}
+		return nil
+	})
+	group.Go(func() error {
+		// Step 3: Evaluate project report, grounded on the candidate's GitHub repository when one was given.
+		// GitHub failures only leave the evaluation ungrounded.
+		err := tracker.run(groupCtx, models.StepEvaluateProject, func(ctx context.Context) (err error) {
+			github, githubErr = es.analyzeGitHub(ctx, job)
+			projectEvaluation, err = es.evaluateProject(ctx, projectContent, githubPromptText(github, redactor), contexts[models.StepEvaluateProject].Text, projectRubric, feedback)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to evaluate project: %w", err)
+		}
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	// Optional step: a second model reviews the scores and feedback. A failed review flags the result
+	// for manual review instead of failing the evaluation.
+	var review *models.EvaluationReview
+	if es.config.Judge.Enabled {
+		err = tracker.run(ctx, models.StepVerify, func(ctx context.Context) (err error) {
+			review, err = es.verifyEvaluation(ctx, cvEvaluation, projectEvaluation, cvRubric, projectRubric, feedback)
+			return err
+		})
+		if err != nil {
+			log.Printf("Warning: failed to verify evaluation of job %s: %v", job.ID.Hex(), err)
+			review = &models.EvaluationReview{Error: err.Error()}
+		}
 	}
 
 	// Step 4: Generate overall summary
-	overallSummary, err := es.generateOverallSummary(ctx, cvEvaluation, projectEvaluation)
+	var overallSummary string
+	err = tracker.run(ctx, models.StepSummary, func(ctx context.Context) (err error) {
+		overallSummary, err = es.generateOverallSummary(ctx, cvEvaluation, projectEvaluation, feedback)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to generate overall summary: %w", err)
+		return nil, fmt.Errorf("failed to generate overall summary: %w", err)
 	}
 
-	// Create final result
+	// Create final result, with any placeholders the model quoted put back for display
 	result := &models.EvaluationResult{
 		CVMatchRate:     cvEvaluation.MatchRate,
-		CVFeedback:      cvEvaluation.Feedback,
+		CVFeedback:      redactor.Restore(cvEvaluation.Feedback),
 		ProjectScore:    projectEvaluation.Score,
-		ProjectFeedback: projectEvaluation.Feedback,
-		OverallSummary:  overallSummary,
+		ProjectFeedback: redactor.Restore(projectEvaluation.Feedback),
+		OverallSummary:  redactor.Restore(overallSummary),
 		CVScores:        cvEvaluation.Scores,
 		ProjectScores:   projectEvaluation.Scores,
+		CVCriteria:      cvEvaluation.Criteria,
+		ProjectCriteria: projectEvaluation.Criteria,
+		Review:          review,
+		NeedsReview:     needsReview(review),
+		Redactions:      redactor.Mapping(),
+		PromptVersions:  promptVersions,
+		CVRubric:        rubricVersion(cvRubric),
+		ProjectRubric:   rubricVersion(projectRubric),
+		GitHub:          github,
+		SimilarProjects: similarProjects,
+	}
+	result.PlagiarismSuspected = len(similarProjects) > 0
+	if githubErr != nil {
+		result.GitHubError = githubErr.Error()
+	}
+	if job.Blind {
+		result.Blind = true
+		result.BlindCVContent = redactedCV
+		result.BlindProjectContent = redactedProject
+	}
+	if review != nil {
+		for i, issue := range review.Issues {
+			review.Issues[i] = redactor.Restore(issue)
+		}
 	}
+	var overallWeights *models.OverallWeights
+	if job.Weights != nil {
+		overallWeights = job.Weights.Overall
+	}
+	// The overall score is on the CV rubric's scale, so bring the project score onto it first
+	cvMax := es.scoringService.RubricScale(cvRubric).MaxScore
+	projectScore := es.scoringService.NormalizeScore(result.ProjectScore, es.scoringService.RubricScale(projectRubric).MaxScore) * cvMax
+	result.OverallScore = es.scoringService.CalculateOverallScore(cvEvaluation.Score, projectScore, overallWeights)
+	result.Scale, result.Display = es.scoringService.DisplayScores(result, cvRubric, projectRubric)
+	result.Recommendation = es.recommendation(ctx, job, es.scoringService.NormalizeScore(result.OverallScore, cvMax))
+
+	return result, nil
+}
 
-	// Save result to database
-	if err := es.repository.UpdateJobResult(ctx, jobID, result); err != nil {
-		return fmt.Errorf("failed to update job result: %w", err)
+// recommendation maps a job's overall score, as a fraction of the overall scale's maximum, to a hiring
+// recommendation with the thresholds calibrated for its job description, or the configured ones. A job
+// description that cannot be loaded only falls back to the configured thresholds.
+func (es *EvaluationService) recommendation(ctx context.Context, job *models.EvaluationJob, score float64) *models.HiringRecommendation {
+	recommendation := &models.HiringRecommendation{
+		Score: math.Round(score*1000) / 1000,
+		Thresholds: models.RecommendationThresholds{
+			StrongHire: es.config.Hiring.StrongHireThreshold,
+			Hire:       es.config.Hiring.HireThreshold,
+		},
+		Source: models.ThresholdsDefault,
 	}
 
-	return nil
+	id := job.JobDescriptionID
+	if id == "" {
+		if org := es.organization(ctx); org != nil {
+			id = org.DefaultJobDescriptionID
+		}
+	}
+	if id != "" {
+		jobDesc, err := es.repository.GetJobDescription(ctx, id)
+		if err != nil {
+			log.Printf("Warning: failed to load recommendation thresholds of job description %s: %v", id, err)
+		} else if jobDesc.RecommendationThresholds != nil {
+			recommendation.Thresholds = *jobDesc.RecommendationThresholds
+			recommendation.Source = models.ThresholdsJobDescription
+		}
+	}
+
+	recommendation.Decision = es.scoringService.Recommend(score, recommendation.Thresholds)
+	return recommendation
+}
+
+// promptVersions returns the active template version of each step prompt an evaluation renders
+func (es *EvaluationService) promptVersions(ctx context.Context) map[string]int {
+	names := []string{PromptAnalyzeCV, PromptEvaluateCV, PromptEvaluateProject, PromptOverallSummary}
+	if es.config.Judge.Enabled {
+		names = append(names, PromptVerify)
+	}
+
+	versions := make(map[string]int, len(names))
+	for _, name := range names {
+		tmpl, err := es.promptService.GetTemplate(ctx, name)
+		if err != nil {
+			// The step fails on the same error when it renders its prompt
+			continue
+		}
+		versions[name] = tmpl.Version
+	}
+	return versions
+}
+
+// redactor returns the redactor for a job's documents: a blind one for blind jobs, a PII one when
+// redaction is enabled, or else nil
+func (es *EvaluationService) redactor(job *models.EvaluationJob) *Redactor {
+	switch {
+	case job.Blind:
+		return NewBlindRedactor(job.CandidateName, DetectCandidateName(job.CVContent))
+	case es.config.Privacy.RedactPII:
+		return NewRedactor(job.CandidateName, DetectCandidateName(job.CVContent))
+	default:
+		return nil
+	}
+}
+
+// stepTracker records pipeline step progress on a persisted job. A nil tracker only runs the steps.
+type stepTracker struct {
+	repository repositories.Repository
+	jobID      string
+	pricing    *config.PricingConfig
+}
+
+// run executes one pipeline step in its own span, marking it running and then completed or failed.
+// fn receives the step's span context.
+func (t *stepTracker) run(ctx context.Context, name string, fn func(ctx context.Context) error) (err error) {
+	ctx, span := telemetry.Start(ctx, "evaluation."+name, trace.WithAttributes(attribute.String("evaluation.step", name)))
+	defer func() { telemetry.End(span, err) }()
+	ctx = audit.WithStep(ctx, name)
+
+	if t == nil {
+		return fn(ctx)
+	}
+
+	startedAt := time.Now()
+	t.record(ctx, models.JobStep{Name: name, Status: models.StepRunning, StartedAt: &startedAt})
+
+	ctx, recorder := llm.WithUsageRecorder(ctx)
+	err = fn(ctx)
+
+	completedAt := time.Now()
+	step := models.JobStep{Name: name, Status: models.StepCompleted, StartedAt: &startedAt, CompletedAt: &completedAt}
+	if err != nil {
+		step.Status = models.StepFailed
+		step.Error = err.Error()
+	}
+	if usage := priceUsage(t.pricing, recorder.Usage()); usage != nil {
+		step.Usage = &usage.TokenUsage
+	}
+	t.record(ctx, step)
+
+	return err
+}
+
+// record saves a step update; progress is informational, so failures are only logged
+func (t *stepTracker) record(ctx context.Context, step models.JobStep) {
+	// A failed sibling branch cancels ctx, but its outcome should still be recorded
+	if err := t.repository.UpdateJobStep(context.WithoutCancel(ctx), t.jobID, step); err != nil {
+		log.Printf("Warning: failed to record step %s of job %s: %v", step.Name, t.jobID, err)
+	}
+}
+
+// recordRetrieval saves what was retrieved as each step's context, in pipeline order. Like progress, it is
+// informational, so failures are only logged.
+func (t *stepTracker) recordRetrieval(ctx context.Context, contexts map[string]rag.StepContext) {
+	if t == nil {
+		return
+	}
+
+	var retrieval []models.StepRetrieval
+	for _, step := range models.PipelineSteps {
+		if context, ok := contexts[step]; ok {
+			retrieval = append(retrieval, context.Retrieval)
+		}
+	}
+	if err := t.repository.UpdateJobRetrieval(ctx, t.jobID, retrieval); err != nil {
+		log.Printf("Warning: failed to record retrieval of job %s: %v", t.jobID, err)
+	}
+}
+
+// evaluationContext returns the context of each evaluation step, keyed by step name. It uses the job's selected
+// job description, falling back to RAG context retrieved from the chunks of all stored job descriptions, along
+// with the reference documents each step retrieves from, as the job's retrieval options direct.
+func (es *EvaluationService) evaluationContext(ctx context.Context, job *models.EvaluationJob, cvContent, projectContent string) (map[string]rag.StepContext, error) {
+	if job.JobDescriptionID != "" {
+		return es.vectorStore.GetJobDescriptionContext(ctx, job.JobDescriptionID, cvContent, projectContent, job.RetrievalOptions)
+	}
+	if org := es.organization(ctx); org != nil && org.DefaultJobDescriptionID != "" {
+		return es.vectorStore.GetJobDescriptionContext(ctx, org.DefaultJobDescriptionID, cvContent, projectContent, job.RetrievalOptions)
+	}
+
+	return es.vectorStore.GetRelevantContext(ctx, cvContent, projectContent, job.RetrievalOptions)
+}
+
+// organization returns the organization the context is scoped to, or nil for unscoped contexts
+func (es *EvaluationService) organization(ctx context.Context) *models.Organization {
+	orgID := tenant.OrgID(ctx)
+	if orgID == "" {
+		return nil
+	}
+
+	org, err := es.repository.GetOrganization(ctx, orgID)
+	if err != nil {
+		log.Printf("Warning: failed to load organization %s: %v", orgID, err)
+		return nil
+	}
+	return org
+}
+
+// renderFitted renders a step's prompt with document quoted into field. When the prompt leaves no room for
+// the completion in the model's context window, the document is condensed to its facts and, if that is
+// not enough, cut to the passages most relevant to the job context until it does.
+func (es *EvaluationService) renderFitted(ctx context.Context, name, documentType string, data *PromptData, field *string, document string) (*RenderedPrompt, error) {
+	*field = QuoteDocument(document)
+	prompt, err := es.promptService.Render(ctx, name, *data)
+	if err != nil || es.config.LLM.ContextWindow <= 0 {
+		return prompt, err
+	}
+
+	// Quoting changes the document's length a little, so a second pass may be needed
+	condensed := false
+	budget := llm.CountTokens(document)
+	for attempt := 0; attempt < 3; attempt++ {
+		over := llm.CountTokens(prompt.Text) - (es.config.LLM.ContextWindow - llm.CompletionMaxTokens)
+		if over <= 0 {
+			break
+		}
+		budget -= over
+		if budget <= 0 {
+			return nil, fmt.Errorf("%s prompt does not fit in the %d-token context window even without its %s", name, es.config.LLM.ContextWindow, documentType)
+		}
+
+		if !condensed {
+			if document, err = es.condenseDocument(ctx, documentType, document, data.Context, budget); err != nil {
+				return nil, err
+			}
+			condensed = true
+		} else {
+			document, _ = rag.FitText(document, data.Context, budget)
+		}
+		*field = QuoteDocument(document)
+		if prompt, err = es.promptService.Render(ctx, name, *data); err != nil {
+			return nil, err
+		}
+	}
+	return prompt, nil
 }
 
 // analyzeCV extracts structured information from CV
 func (es *EvaluationService) analyzeCV(ctx context.Context, cvContent, context string) (*CVAnalysis, error) {
-	prompt := fmt.Sprintf(`Analyze the following CV and extract structured information:
-
-CV Content:
-%s
-
-Context:
-%s
-
-Please extract and return the following information in JSON format:
-{
-  "technical_skills": ["skill1", "skill2", ...],
-  "experience_years": number,
-  "projects": [
-    {
-      "name": "project_name",
-      "description": "project_description",
-      "technologies": ["tech1", "tech2", ...],
-      "impact": "impact_description"
-    }
-  ],
-  "achievements": ["achievement1", "achievement2", ...],
-  "education": "education_background",
-  "certifications": ["cert1", "cert2", ...]
-}`, cvContent, context)
-
-	response, err := es.llmClient.GenerateStructuredCompletionWithRetry(
-		ctx, prompt, 0.3, es.config.JobQueue.MaxRetries,
-	)
+	data := PromptData{Context: context}
+	prompt, err := es.renderFitted(ctx, PromptAnalyzeCV, "CV", &data, &data.CVContent, cvContent)
 	if err != nil {
 		return nil, err
 	}
 
 	var analysis CVAnalysis
-	if err := json.Unmarshal([]byte(response), &analysis); err != nil {
+	if _, err := llm.GenerateJSON(ctx, es.llmClient, prompt.Text, cvAnalysisSchema, prompt.Temperature(0.3), es.config.JobQueue.MaxRetries, &analysis); err != nil {
 		return nil, fmt.Errorf("failed to parse CV analysis: %w", err)
 	}
 
@@ -140,49 +543,40 @@ Please extract and return the following information in JSON format:
 }
 
 // evaluateCV evaluates CV against job requirements
-func (es *EvaluationService) evaluateCV(ctx context.Context, analysis *CVAnalysis, context string) (*CVEvaluation, error) {
-	prompt := fmt.Sprintf(`Evaluate the following CV analysis against job requirements:
-
-CV Analysis:
-%s
-
-Context:
-%s
-
-Evaluate based on these criteria (1-5 scale):
-1. Technical Skills Match (40%% weight): backend, databases, APIs, cloud, AI/LLM exposure
-2. Experience Level (25%% weight): years of experience and project complexity
-3. Relevant Achievements (20%% weight): impact and scale of past work
-4. Cultural/Collaboration Fit (15%% weight): communication, learning mindset, teamwork
-
-Return JSON format:
-{
-  "technical_skills_score": number,
-  "experience_level_score": number,
-  "achievements_score": number,
-  "cultural_fit_score": number,
-  "match_rate": number,
-  "feedback": "detailed_feedback_string"
-}`, analysis.String(), context)
-
-	response, err := es.llmClient.GenerateStructuredCompletionWithRetry(
-		ctx, prompt, 0.3, es.config.JobQueue.MaxRetries,
-	)
+func (es *EvaluationService) evaluateCV(ctx context.Context, analysis *CVAnalysis, context string, rubric *models.ScoringRubric, feedback feedbackStyle) (*CVEvaluation, error) {
+	criteria := promptCriteria(rubric)
+	data := PromptData{
+		CVAnalysis: analysis.String(),
+		Context:    context,
+		Criteria:   criteria,
+	}
+	feedback.apply(&data)
+	prompt, err := es.promptService.Render(ctx, PromptEvaluateCV, data)
 	if err != nil {
 		return nil, err
 	}
 
 	var evaluation CVEvaluation
-	if err := json.Unmarshal([]byte(response), &evaluation); err != nil {
+	schema := criteriaSchema("cv_evaluation", "Scores for a CV against the job requirements", criteria, true)
+	response, err := llm.GenerateJSON(ctx, es.llmClient, prompt.Text, schema, prompt.Temperature(0.3), es.config.JobQueue.MaxRetries, &evaluation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CV evaluation: %w", err)
+	}
+	evaluation.Criteria, err = parseCriterionScores(response, rubric)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse CV evaluation: %w", err)
 	}
+	es.scoreCV(&evaluation, rubric)
 
-	// Calculate weighted match rate and round to 2 decimal places
-	matchRate := (evaluation.TechnicalSkills*0.4 +
-		evaluation.ExperienceLevel*0.25 +
-		evaluation.Achievements*0.2 +
-		evaluation.CulturalFit*0.15) / 5.0
+	return &evaluation, nil
+}
+
+// scoreCV derives the totals of a CV evaluation from its criterion scores
+func (es *EvaluationService) scoreCV(evaluation *CVEvaluation, rubric *models.ScoringRubric) {
+	// Calculate the rubric-weighted match rate and the score on the rubric's scale, rounded to 2 decimal places
+	matchRate := es.scoringService.WeightedScore(rubric, evaluation.Criteria)
 	evaluation.MatchRate = math.Round(matchRate*100) / 100
+	evaluation.Score = math.Round(matchRate*es.scoringService.RubricScale(rubric).MaxScore*100) / 100
 
 	// Populate Scores struct
 	evaluation.Scores = models.CVScores{
@@ -191,56 +585,41 @@ Return JSON format:
 		Achievements:    evaluation.Achievements,
 		CulturalFit:     evaluation.CulturalFit,
 	}
-
-	return &evaluation, nil
 }
 
 // evaluateProject evaluates project report
-func (es *EvaluationService) evaluateProject(ctx context.Context, projectContent, context string) (*ProjectEvaluation, error) {
-	prompt := fmt.Sprintf(`Evaluate the following project report:
-
-Project Content:
-%s
-
-Context:
-%s
-
-Evaluate based on these criteria (1-5 scale):
-1. Correctness (30%% weight): prompt design, LLM chaining, RAG, error handling
-2. Code Quality (25%% weight): clean, modular, testable code
-3. Resilience (20%% weight): handles failures, retries, error handling
-4. Documentation (15%% weight): clear README, setup instructions, trade-offs
-5. Creativity/Bonus (10%% weight): extra features beyond requirements
-
-Return JSON format:
-{
-  "correctness_score": number,
-  "code_quality_score": number,
-  "resilience_score": number,
-  "documentation_score": number,
-  "creativity_score": number,
-  "overall_score": number,
-  "feedback": "detailed_feedback_string"
-}`, projectContent, context)
-
-	response, err := es.llmClient.GenerateStructuredCompletionWithRetry(
-		ctx, prompt, 0.3, es.config.JobQueue.MaxRetries,
-	)
+func (es *EvaluationService) evaluateProject(ctx context.Context, projectContent, github, context string, rubric *models.ScoringRubric, feedback feedbackStyle) (*ProjectEvaluation, error) {
+	criteria := promptCriteria(rubric)
+	data := PromptData{
+		GitHub:   github,
+		Context:  context,
+		Criteria: criteria,
+	}
+	feedback.apply(&data)
+	prompt, err := es.renderFitted(ctx, PromptEvaluateProject, "project report", &data, &data.ProjectContent, projectContent)
 	if err != nil {
 		return nil, err
 	}
 
 	var evaluation ProjectEvaluation
-	if err := json.Unmarshal([]byte(response), &evaluation); err != nil {
+	schema := criteriaSchema("project_evaluation", "Scores for a take-home project report", criteria, false)
+	response, err := llm.GenerateJSON(ctx, es.llmClient, prompt.Text, schema, prompt.Temperature(0.3), es.config.JobQueue.MaxRetries, &evaluation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse project evaluation: %w", err)
+	}
+	evaluation.Criteria, err = parseCriterionScores(response, rubric)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse project evaluation: %w", err)
 	}
+	es.scoreProject(&evaluation, rubric)
 
-	// Calculate weighted overall score and round to 2 decimal places
-	overallScore := (evaluation.Correctness*0.3 +
-		evaluation.CodeQuality*0.25 +
-		evaluation.Resilience*0.2 +
-		evaluation.Documentation*0.15 +
-		evaluation.Creativity*0.1)
+	return &evaluation, nil
+}
+
+// scoreProject derives the totals of a project evaluation from its criterion scores
+func (es *EvaluationService) scoreProject(evaluation *ProjectEvaluation, rubric *models.ScoringRubric) {
+	// Calculate the rubric-weighted score on the rubric's scale and round to 2 decimal places
+	overallScore := es.scoringService.WeightedScore(rubric, evaluation.Criteria) * es.scoringService.RubricScale(rubric).MaxScore
 	evaluation.Score = math.Round(overallScore*100) / 100
 
 	// Populate Scores struct
@@ -251,43 +630,52 @@ Return JSON format:
 		Documentation: evaluation.Documentation,
 		Creativity:    evaluation.Creativity,
 	}
+}
 
-	return &evaluation, nil
+// analyzeGitHub fetches the GitHub account or repository a job names. It returns nil without an error when
+// the job names none or the analysis is disabled.
+func (es *EvaluationService) analyzeGitHub(ctx context.Context, job *models.EvaluationJob) (*models.GitHubAnalysis, error) {
+	if es.replaying {
+		if job.Result == nil {
+			return nil, nil
+		}
+		return job.Result.GitHub, nil
+	}
+	if job.GitHub == "" || !es.github.Enabled() {
+		return nil, nil
+	}
+
+	analysis, err := es.github.Analyze(ctx, job.GitHub)
+	if err != nil {
+		log.Printf("Warning: failed to analyze GitHub %s of job %s: %v", job.GitHub, job.ID.Hex(), err)
+		return nil, err
+	}
+	return analysis, nil
+}
+
+// githubPromptText renders GitHub data for the project evaluation prompt. The README and descriptions are
+// written by the candidate, so they are redacted and fenced like the documents.
+func githubPromptText(analysis *models.GitHubAnalysis, redactor *Redactor) string {
+	if analysis == nil {
+		return ""
+	}
+	return QuoteDocument(redactor.Redact(formatGitHubAnalysis(analysis)))
 }
 
 // generateOverallSummary generates overall summary
-func (es *EvaluationService) generateOverallSummary(ctx context.Context, cvEval *CVEvaluation, projectEval *ProjectEvaluation) (string, error) {
-	prompt := fmt.Sprintf(`Generate an overall summary based on the following evaluations:
-
-CV Evaluation:
-- Match Rate: %.2f
-- Technical Skills: %.2f/5
-- Experience Level: %.2f/5
-- Achievements: %.2f/5
-- Cultural Fit: %.2f/5
-- Feedback: %s
-
-Project Evaluation:
-- Overall Score: %.2f/5
-- Correctness: %.2f/5
-- Code Quality: %.2f/5
-- Resilience: %.2f/5
-- Documentation: %.2f/5
-- Creativity: %.2f/5
-- Feedback: %s
-
-Generate a 3-5 sentence summary that includes:
-1. Overall assessment of the candidate
-2. Key strengths
-3. Areas for improvement
-4. Recommendation`,
-		cvEval.MatchRate, cvEval.TechnicalSkills, cvEval.ExperienceLevel,
-		cvEval.Achievements, cvEval.CulturalFit, cvEval.Feedback,
-		projectEval.Score, projectEval.Correctness, projectEval.CodeQuality,
-		projectEval.Resilience, projectEval.Documentation, projectEval.Creativity, projectEval.Feedback)
+func (es *EvaluationService) generateOverallSummary(ctx context.Context, cvEval *CVEvaluation, projectEval *ProjectEvaluation, feedback feedbackStyle) (string, error) {
+	data := PromptData{
+		CVEvaluation:      cvEval,
+		ProjectEvaluation: projectEval,
+	}
+	feedback.apply(&data)
+	prompt, err := es.promptService.Render(ctx, PromptOverallSummary, data)
+	if err != nil {
+		return "", err
+	}
 
 	summary, err := es.llmClient.GenerateCompletionWithRetry(
-		ctx, prompt, 0.3, es.config.JobQueue.MaxRetries,
+		ctx, prompt.Text, prompt.Temperature(0.3), es.config.JobQueue.MaxRetries,
 	)
 	if err != nil {
 		return "", err
@@ -296,6 +684,176 @@ Generate a 3-5 sentence summary that includes:
 	return summary, nil
 }
 
+// DescribeImprovement asks the LLM for a short narrative of what changed between two evaluations
+func (es *EvaluationService) DescribeImprovement(ctx context.Context, diff *models.EvaluationDiff) (string, error) {
+	prompt, err := es.promptService.Render(ctx, PromptEvaluationDiff, PromptData{Diff: diff})
+	if err != nil {
+		return "", err
+	}
+
+	return es.llmClient.GenerateCompletionWithRetry(ctx, prompt.Text, prompt.Temperature(0.3), es.config.JobQueue.MaxRetries)
+}
+
+// CheckLanguages fails fast with a LanguageError when a job's documents cannot be evaluated
+func (es *EvaluationService) CheckLanguages(job *models.EvaluationJob) error {
+	if err := es.languages.Check("CV", job.CVContent); err != nil {
+		return err
+	}
+	return es.languages.Check("project report", job.ProjectContent)
+}
+
+// CheckContent fails fast with a ModerationError when a job's documents contain disallowed content
+func (es *EvaluationService) CheckContent(ctx context.Context, job *models.EvaluationJob) error {
+	redactor := es.redactor(job)
+	return es.checkContent(ctx, redactor.Redact(job.CVContent), redactor.Redact(job.ProjectContent))
+}
+
+// checkContent moderates the documents as they would be sent to the provider
+func (es *EvaluationService) checkContent(ctx context.Context, cvContent, projectContent string) error {
+	if err := es.moderation.Check(ctx, "CV", cvContent); err != nil {
+		return err
+	}
+	return es.moderation.Check(ctx, "project report", projectContent)
+}
+
+// PreviewPrompt renders a prompt step against a stored job and, when execute is set, sends it to the LLM once
+// with the active template's model parameters. An empty templateText previews the active template.
+func (es *EvaluationService) PreviewPrompt(ctx context.Context, job *models.EvaluationJob, name, templateText string, execute bool) (*models.PromptPreviewResponse, error) {
+	tmpl, err := es.promptService.GetTemplate(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if templateText == "" {
+		templateText = tmpl.Template
+	}
+
+	// Preview what the provider would receive
+	redactor := es.redactor(job)
+	cvContent, projectContent := redactor.Redact(job.CVContent), redactor.Redact(job.ProjectContent)
+
+	data := PromptData{
+		CVContent:      QuoteDocument(cvContent),
+		ProjectContent: QuoteDocument(projectContent),
+	}
+
+	if name == PromptTranslate {
+		data.Document = NeutralizeDocument(cvContent)
+		data.Language = DetectLanguage(cvContent)
+	}
+
+	if name == PromptExtractFacts || name == PromptMergeFacts {
+		data.Document = QuoteDocument(cvContent)
+		data.DocumentType = "CV"
+	}
+
+	if name == PromptEvaluationDiff {
+		if job.Result == nil {
+			return nil, fmt.Errorf("job %s has no completed result to diff", job.ID.Hex())
+		}
+		// Without a second evaluation the job is compared with itself
+		data.Diff = DiffEvaluations(job, job)
+	}
+
+	if name == PromptFeedbackLetter {
+		data.Letter, _, err = es.letterPromptData(ctx, job, models.FeedbackLetterRequest{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if name != PromptOverallSummary && name != PromptTranslate && name != PromptEvaluationDiff && name != PromptVerify && name != PromptParseResume &&
+		name != PromptExtractFacts && name != PromptMergeFacts && name != PromptFeedbackLetter {
+		contexts, err := es.evaluationContext(ctx, job, cvContent, projectContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get relevant context: %w", err)
+		}
+		data.Context = contexts[name].Text
+	}
+
+	if name == PromptEvaluateCV || name == PromptEvaluateProject || name == PromptVerify {
+		cvRubric, projectRubric, err := es.jobRubrics(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case PromptEvaluateCV:
+			data.Criteria = promptCriteria(cvRubric)
+		case PromptEvaluateProject:
+			data.Criteria = promptCriteria(projectRubric)
+		default:
+			data.CVCriteria = promptCriteria(cvRubric)
+			data.ProjectCriteria = promptCriteria(projectRubric)
+		}
+	}
+
+	if name == PromptEvaluateProject && job.GitHub != "" {
+		github, err := es.analyzeGitHub(ctx, job)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze GitHub: %w", err)
+		}
+		data.GitHub = githubPromptText(github, redactor)
+	}
+
+	if name == PromptEvaluateCV {
+		// The analysis is only produced by a model call, so skip it for render-only previews
+		data.CVAnalysis = "(CV analysis is generated by the analyze_cv step at evaluation time)"
+		if execute {
+			analysis, err := es.analyzeCV(ctx, cvContent, data.Context)
+			if err != nil {
+				return nil, fmt.Errorf("failed to analyze CV: %w", err)
+			}
+			data.CVAnalysis = analysis.String()
+		}
+	}
+
+	if (name == PromptOverallSummary || name == PromptVerify) && job.Result != nil {
+		data.CVEvaluation = &CVEvaluation{
+			TechnicalSkills: job.Result.CVScores.TechnicalSkills,
+			ExperienceLevel: job.Result.CVScores.ExperienceLevel,
+			Achievements:    job.Result.CVScores.Achievements,
+			CulturalFit:     job.Result.CVScores.CulturalFit,
+			MatchRate:       job.Result.CVMatchRate,
+			Feedback:        job.Result.CVFeedback,
+			Criteria:        job.Result.CVCriteria,
+		}
+		data.ProjectEvaluation = &ProjectEvaluation{
+			Correctness:   job.Result.ProjectScores.Correctness,
+			CodeQuality:   job.Result.ProjectScores.CodeQuality,
+			Resilience:    job.Result.ProjectScores.Resilience,
+			Documentation: job.Result.ProjectScores.Documentation,
+			Creativity:    job.Result.ProjectScores.Creativity,
+			Score:         job.Result.ProjectScore,
+			Feedback:      job.Result.ProjectFeedback,
+			Criteria:      job.Result.ProjectCriteria,
+		}
+	}
+
+	prompt, err := es.promptService.Execute(templateText, data)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &models.PromptPreviewResponse{
+		Name:           name,
+		RenderedPrompt: prompt,
+	}
+	if !execute {
+		return preview, nil
+	}
+
+	temperature := promptTemperature(tmpl.ModelParams, 0.3)
+	if es.promptService.IsStructured(name) {
+		preview.Output, err = es.llmClient.GenerateStructuredCompletion(ctx, prompt, temperature)
+	} else {
+		preview.Output, err = es.llmClient.GenerateCompletion(ctx, prompt, temperature)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run prompt: %w", err)
+	}
+
+	return preview, nil
+}
+
 // Helper structs for evaluation
 type CVAnalysis struct {
 	TechnicalSkills []string  `json:"technical_skills"`
@@ -321,6 +879,10 @@ type CVEvaluation struct {
 	MatchRate       float64 `json:"match_rate"`
 	Feedback        string  `json:"feedback"`
 	Scores          models.CVScores
+
+	// Criteria holds every rubric criterion score; Score is the weighted total on a 1-5 scale
+	Criteria map[string]float64 `json:"-"`
+	Score    float64            `json:"-"`
 }
 
 type ProjectEvaluation struct {
@@ -332,6 +894,9 @@ type ProjectEvaluation struct {
 	Score         float64 `json:"overall_score"`
 	Feedback      string  `json:"feedback"`
 	Scores        models.ProjectScores
+
+	// Criteria holds every rubric criterion score
+	Criteria map[string]float64 `json:"-"`
 }
 
 func (cv *CVAnalysis) String() string {
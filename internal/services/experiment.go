@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"ai-cv-summarize/internal/llm"
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+)
+
+// ErrInvalidExperiment is returned for experiment definitions that cannot be run
+var ErrInvalidExperiment = errors.New("invalid experiment")
+
+// ErrExperimentRunning is returned when starting an experiment while another one runs
+var ErrExperimentRunning = errors.New("another experiment is running")
+
+// experimentVariants is the number of variants an experiment compares
+const experimentVariants = 2
+
+// ExperimentService runs prompt and model experiments: it stores them, routes evaluations to their variants
+// and compares the results
+type ExperimentService struct {
+	repository    repositories.Repository
+	promptService *PromptService
+	// newClient creates the client of a variant model, wrapped like the evaluation client
+	newClient func(model string) llm.LLMClient
+
+	mu      sync.Mutex
+	clients map[string]llm.LLMClient
+}
+
+func NewExperimentService(repository repositories.Repository, promptService *PromptService, newClient func(model string) llm.LLMClient) *ExperimentService {
+	return &ExperimentService{
+		repository:    repository,
+		promptService: promptService,
+		newClient:     newClient,
+		clients:       make(map[string]llm.LLMClient),
+	}
+}
+
+// Start validates an experiment and starts routing evaluations to it
+func (s *ExperimentService) Start(ctx context.Context, req models.ExperimentRequest) (*models.Experiment, error) {
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+
+	// Held so two experiments cannot start at once
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	running, err := s.running(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if running != nil {
+		return nil, fmt.Errorf("%w: %s", ErrExperimentRunning, running.ID.Hex())
+	}
+
+	experiment := &models.Experiment{
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      models.ExperimentRunning,
+		Variants:    req.Variants,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.repository.SaveExperiment(ctx, experiment); err != nil {
+		return nil, fmt.Errorf("failed to save experiment: %w", err)
+	}
+	return experiment, nil
+}
+
+// validate checks that an experiment has two named variants routing between 1% and 100% of evaluations in
+// total, pinned to saved prompt versions
+func (s *ExperimentService) validate(ctx context.Context, req models.ExperimentRequest) error {
+	if len(req.Variants) != experimentVariants {
+		return fmt.Errorf("%w: an experiment needs exactly %d variants", ErrInvalidExperiment, experimentVariants)
+	}
+
+	total := 0
+	names := make(map[string]bool, len(req.Variants))
+	for _, variant := range req.Variants {
+		if variant.Name == "" {
+			return fmt.Errorf("%w: every variant needs a name", ErrInvalidExperiment)
+		}
+		if names[variant.Name] {
+			return fmt.Errorf("%w: variant names must be unique", ErrInvalidExperiment)
+		}
+		names[variant.Name] = true
+
+		if variant.Percent < 1 || variant.Percent > 100 {
+			return fmt.Errorf("%w: percent of variant %s must be between 1 and 100", ErrInvalidExperiment, variant.Name)
+		}
+		total += variant.Percent
+
+		for name, number := range variant.PromptVersions {
+			if number < 0 {
+				return fmt.Errorf("%w: prompt version of %s must not be negative", ErrInvalidExperiment, name)
+			}
+			if _, ok := defaultPromptTemplates[name]; !ok {
+				return fmt.Errorf("%w: unknown prompt %s", ErrInvalidExperiment, name)
+			}
+			if number == 0 {
+				continue
+			}
+			if _, err := s.promptService.GetVersion(ctx, name, number); errors.Is(err, ErrUnknownPromptVersion) {
+				return fmt.Errorf("%w: prompt %s has no version %d", ErrInvalidExperiment, name, number)
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+	if total > 100 {
+		return fmt.Errorf("%w: the variants' percentages must not add up to more than 100", ErrInvalidExperiment)
+	}
+
+	return nil
+}
+
+// Stop stops routing evaluations to an experiment; its results stay available for comparison
+func (s *ExperimentService) Stop(ctx context.Context, id string) (*models.Experiment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	experiment, err := s.repository.GetExperiment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if experiment.Status == models.ExperimentStopped {
+		return experiment, nil
+	}
+
+	now := time.Now()
+	experiment.Status = models.ExperimentStopped
+	experiment.StoppedAt = &now
+	if err := s.repository.SaveExperiment(ctx, experiment); err != nil {
+		return nil, fmt.Errorf("failed to save experiment: %w", err)
+	}
+	return experiment, nil
+}
+
+// running returns the running experiment, or nil when none is
+func (s *ExperimentService) running(ctx context.Context) (*models.Experiment, error) {
+	experiments, err := s.repository.GetExperiments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get experiments: %w", err)
+	}
+	for _, experiment := range experiments {
+		if experiment.Status == models.ExperimentRunning {
+			return experiment, nil
+		}
+	}
+	return nil, nil
+}
+
+// Assign picks the variant of the running experiment a job is evaluated with, or returns nil when no
+// experiment runs or the job falls outside the variants' percentages. A job always lands in the same
+// bucket, so a retried evaluation keeps its variant.
+func (s *ExperimentService) Assign(ctx context.Context, job *models.EvaluationJob) (*models.Experiment, *models.ExperimentVariant, error) {
+	experiment, err := s.running(ctx)
+	if err != nil || experiment == nil {
+		return nil, nil, err
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(experiment.ID.Hex() + job.ID.Hex()))
+	bucket := int(h.Sum32() % 100)
+
+	for i, variant := range experiment.Variants {
+		if bucket < variant.Percent {
+			return experiment, &experiment.Variants[i], nil
+		}
+		bucket -= variant.Percent
+	}
+	return nil, nil, nil
+}
+
+// client returns the client of a variant model, creating it on first use
+func (s *ExperimentService) client(model string) llm.LLMClient {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, ok := s.clients[model]
+	if !ok {
+		client = s.newClient(model)
+		s.clients[model] = client
+	}
+	return client
+}
+
+// withExperiment routes a job to a variant of the running experiment, returning the service and context
+// that evaluate it with the variant's model and prompts, and the assignment to tag its result with. Jobs
+// outside the experiment, or whose experiment cannot be loaded, are evaluated as usual.
+func (es *EvaluationService) withExperiment(ctx context.Context, job *models.EvaluationJob) (*EvaluationService, context.Context, *models.ExperimentAssignment) {
+	if es.experiments == nil || job.Sandbox {
+		return es, ctx, nil
+	}
+
+	experiment, variant, err := es.experiments.Assign(ctx, job)
+	if err != nil {
+		log.Printf("Warning: failed to assign job %s to an experiment: %v", job.ID.Hex(), err)
+		return es, ctx, nil
+	}
+	if variant == nil {
+		return es, ctx, nil
+	}
+
+	evaluator := es
+	if variant.Model != "" {
+		routed := *es
+		routed.llmClient = es.experiments.client(variant.Model)
+		evaluator = &routed
+	}
+	assignment := &models.ExperimentAssignment{ExperimentID: experiment.ID.Hex(), Variant: variant.Name}
+	return evaluator, WithPromptVersions(ctx, variant.PromptVersions), assignment
+}
+
+// variantPrompts pins the prompts of the experiment variant a result was evaluated with, so replaying it
+// renders the same prompts. A deleted or unreadable experiment leaves the active templates.
+func (es *EvaluationService) variantPrompts(ctx context.Context, assignment *models.ExperimentAssignment) context.Context {
+	experiment, err := es.repository.GetExperiment(ctx, assignment.ExperimentID)
+	if err != nil {
+		log.Printf("Warning: failed to load experiment %s: %v", assignment.ExperimentID, err)
+		return ctx
+	}
+	for _, variant := range experiment.Variants {
+		if variant.Name == assignment.Variant {
+			return WithPromptVersions(ctx, variant.PromptVersions)
+		}
+	}
+	return ctx
+}
+
+// experimentSample is a completed evaluation of an experiment, scored as a percentage of its scale
+type experimentSample struct {
+	score    float64
+	decision string
+	pair     string
+	at       time.Time
+}
+
+// Compare summarizes the score distribution of each variant of an experiment and how often the variants
+// disagree on the same CV
+func (s *ExperimentService) Compare(ctx context.Context, experiment *models.Experiment) (*models.ExperimentComparison, error) {
+	jobs, err := s.repository.FindJobs(ctx, repositories.JobBulkFilter{
+		Status:    string(models.StatusCompleted),
+		NewerThan: experiment.CreatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find completed jobs: %w", err)
+	}
+
+	id := experiment.ID.Hex()
+	samples := map[string][]experimentSample{}
+	for _, job := range jobs {
+		if job.DeletedAt != nil || job.Result == nil || job.Result.Experiment == nil || job.Result.Experiment.ExperimentID != id {
+			continue
+		}
+		samples[job.Result.Experiment.Variant] = append(samples[job.Result.Experiment.Variant], experimentSampleOf(job))
+	}
+
+	comparison := &models.ExperimentComparison{ExperimentID: id}
+	for _, variant := range experiment.Variants {
+		comparison.Variants = append(comparison.Variants, variantStats(variant.Name, samples[variant.Name]))
+	}
+
+	// Pairs take the latest evaluation of a CV with each variant
+	first, second := latestByPair(samples[experiment.Variants[0].Name]), latestByPair(samples[experiment.Variants[1].Name])
+	var deltas []float64
+	disagreements := 0
+	for pair, a := range first {
+		b, ok := second[pair]
+		if !ok {
+			continue
+		}
+		deltas = append(deltas, b.score-a.score)
+		if a.decision != b.decision {
+			disagreements++
+		}
+	}
+	comparison.Pairs = len(deltas)
+	if len(deltas) > 0 {
+		comparison.DisagreementRate = round2(float64(disagreements) / float64(len(deltas)))
+		comparison.MeanScoreDelta = round2(mean(deltas))
+	}
+
+	return comparison, nil
+}
+
+// experimentSampleOf converts a job's overall score to a percentage of its scale and keys it by CV and job
+// description, like fairness samples
+func experimentSampleOf(job *models.EvaluationJob) experimentSample {
+	maxScore := defaultMaxScore
+	if job.Result.Scale != nil && job.Result.Scale.Overall.MaxScore > 0 {
+		maxScore = job.Result.Scale.Overall.MaxScore
+	}
+
+	sample := experimentSample{score: math.Min(job.Result.OverallScore/maxScore, 1) * 100, at: job.CreatedAt}
+	if job.Result.Recommendation != nil {
+		sample.decision = job.Result.Recommendation.Decision
+	}
+	if job.CompletedAt != nil {
+		sample.at = *job.CompletedAt
+	}
+
+	cvHash := job.CVHash
+	if cvHash == "" && job.CVContent != "" {
+		cvHash = HashContent(job.CVContent)
+	}
+	if cvHash != "" {
+		sample.pair = cvHash + "\x00" + job.JobDescriptionID
+	}
+	return sample
+}
+
+// variantStats summarizes the scores of one variant
+func variantStats(name string, samples []experimentSample) models.ExperimentVariantStats {
+	stats := models.ExperimentVariantStats{
+		Variant:      name,
+		Evaluations:  len(samples),
+		Distribution: make([]int, 10),
+	}
+	if len(samples) == 0 {
+		return stats
+	}
+
+	scores := make([]float64, 0, len(samples))
+	stats.Min, stats.Max = math.Inf(1), math.Inf(-1)
+	for _, sample := range samples {
+		scores = append(scores, sample.score)
+		stats.Min = math.Min(stats.Min, sample.score)
+		stats.Max = math.Max(stats.Max, sample.score)
+		stats.Distribution[int(math.Min(sample.score/10, 9))]++
+		if sample.decision != "" {
+			if stats.Recommendations == nil {
+				stats.Recommendations = map[string]int{}
+			}
+			stats.Recommendations[sample.decision]++
+		}
+	}
+	stats.Mean = round2(mean(scores))
+	stats.Median = round2(median(scores))
+	stats.StdDev = round2(stdDev(scores))
+	stats.Min = round2(stats.Min)
+	stats.Max = round2(stats.Max)
+	return stats
+}
+
+// latestByPair keeps the latest sample of each CV and job description
+func latestByPair(samples []experimentSample) map[string]experimentSample {
+	latest := map[string]experimentSample{}
+	for _, sample := range samples {
+		if sample.pair == "" {
+			continue
+		}
+		if current, ok := latest[sample.pair]; !ok || sample.at.After(current.at) {
+			latest[sample.pair] = sample
+		}
+	}
+	return latest
+}
@@ -0,0 +1,406 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+)
+
+// DefaultFairnessThreshold is the score difference, in percentage points, reported as drift by default
+const DefaultFairnessThreshold = 5.0
+
+const (
+	// minDriftSamples is the number of pairs, or of jobs in each cohort, below which no drift is reported
+	minDriftSamples = 5
+	// driftSignificance is the t statistic a difference must exceed to count as systematic, about 95% confidence
+	driftSignificance = 2.0
+)
+
+// ErrFairnessReportInProgress is returned when a fairness report is already running in this process
+var ErrFairnessReportInProgress = errors.New("fairness report already in progress")
+
+// fairnessDimension splits evaluations into cohorts. cohort returns an empty string for jobs that do not
+// belong to the dimension; the baseline cohort is used when present, or else the largest one.
+type fairnessDimension struct {
+	name     string
+	baseline string
+	cohort   func(job *models.EvaluationJob) string
+}
+
+var fairnessDimensions = []fairnessDimension{
+	{name: "blind", baseline: "regular", cohort: func(job *models.EvaluationJob) string {
+		if job.Blind {
+			return "blind"
+		}
+		return "regular"
+	}},
+	{name: "redaction", baseline: "unredacted", cohort: func(job *models.EvaluationJob) string {
+		// Blind jobs are always redacted and compared in their own dimension
+		if job.Blind {
+			return ""
+		}
+		if len(job.Result.Redactions) > 0 {
+			return "redacted"
+		}
+		return "unredacted"
+	}},
+	{name: "model", cohort: jobModels},
+	{name: "prompt_version", cohort: jobPromptVersions},
+}
+
+// jobModels names the models that served a job's latest evaluation attempt
+func jobModels(job *models.EvaluationJob) string {
+	if job.Usage == nil || len(job.Usage.Models) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(job.Usage.Models))
+	for _, usage := range job.Usage.Models {
+		names = append(names, usage.Model)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// jobPromptVersions lists the prompt template versions a job was evaluated with
+func jobPromptVersions(job *models.EvaluationJob) string {
+	if len(job.Result.PromptVersions) == 0 {
+		return ""
+	}
+
+	versions := make([]string, 0, len(job.Result.PromptVersions))
+	for name, version := range job.Result.PromptVersions {
+		versions = append(versions, fmt.Sprintf("%s=%d", name, version))
+	}
+	sort.Strings(versions)
+	return strings.Join(versions, ", ")
+}
+
+// fairnessSample is the overall score of one evaluation, as a percentage of its scale
+type fairnessSample struct {
+	job   *models.EvaluationJob
+	score float64
+	// pair identifies the CV and job description, so evaluations of the same CV can be compared directly
+	pair string
+}
+
+// FairnessService compares the score distributions of evaluation cohorts to find systematic score drift,
+// such as blind evaluations scoring lower than regular ones or a prompt version scoring higher than the last
+type FairnessService struct {
+	repository     repositories.Repository
+	scoringService *ScoringService
+
+	mu      sync.Mutex
+	running bool
+}
+
+func NewFairnessService(repository repositories.Repository, scoringService *ScoringService) *FairnessService {
+	return &FairnessService{
+		repository:     repository,
+		scoringService: scoringService,
+	}
+}
+
+// Start saves a new running report for the request. The returned report must be passed to Run.
+func (s *FairnessService) Start(ctx context.Context, req models.FairnessReportRequest) (*models.FairnessReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return nil, ErrFairnessReportInProgress
+	}
+
+	report := &models.FairnessReport{
+		Status:    models.FairnessReportRunning,
+		Threshold: req.Threshold,
+		StartedAt: time.Now(),
+	}
+	if report.Threshold <= 0 {
+		report.Threshold = DefaultFairnessThreshold
+	}
+	if req.Days > 0 {
+		since := report.StartedAt.AddDate(0, 0, -req.Days)
+		report.Since = &since
+	}
+	if err := s.repository.SaveFairnessReport(ctx, report); err != nil {
+		return nil, fmt.Errorf("failed to save fairness report: %w", err)
+	}
+
+	s.running = true
+	return report, nil
+}
+
+// Run computes a report returned by Start and saves the outcome
+func (s *FairnessService) Run(ctx context.Context, report *models.FairnessReport) error {
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	err := s.run(ctx, report)
+	if err != nil {
+		report.Status = models.FairnessReportFailed
+		report.Error = err.Error()
+	} else {
+		report.Status = models.FairnessReportCompleted
+	}
+	now := time.Now()
+	report.CompletedAt = &now
+
+	// Record the outcome even if the request that started the report has gone away
+	if saveErr := s.repository.SaveFairnessReport(context.Background(), report); saveErr != nil && err == nil {
+		err = fmt.Errorf("failed to save fairness report: %w", saveErr)
+	}
+
+	return err
+}
+
+func (s *FairnessService) run(ctx context.Context, report *models.FairnessReport) error {
+	filter := repositories.JobBulkFilter{Status: string(models.StatusCompleted)}
+	if report.Since != nil {
+		filter.NewerThan = *report.Since
+	}
+	jobs, err := s.repository.FindJobs(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to find completed jobs: %w", err)
+	}
+
+	samples := make([]fairnessSample, 0, len(jobs))
+	for _, job := range jobs {
+		// Sandbox scores come from the mock LLM
+		if job.DeletedAt != nil || job.Sandbox || job.Result == nil {
+			continue
+		}
+		samples = append(samples, s.sample(job))
+	}
+	report.JobsAnalyzed = len(samples)
+
+	for _, dimension := range fairnessDimensions {
+		result, drifts := compareCohorts(dimension, samples, report.Threshold)
+		if result != nil {
+			report.Dimensions = append(report.Dimensions, *result)
+			report.Drifts = append(report.Drifts, drifts...)
+		}
+	}
+
+	return nil
+}
+
+// sample converts a job's overall score to a percentage of its scale
+func (s *FairnessService) sample(job *models.EvaluationJob) fairnessSample {
+	maxScore := defaultMaxScore
+	if job.Result.Scale != nil && job.Result.Scale.Overall.MaxScore > 0 {
+		maxScore = job.Result.Scale.Overall.MaxScore
+	}
+
+	cvHash := job.CVHash
+	if cvHash == "" && job.CVContent != "" {
+		cvHash = HashContent(job.CVContent)
+	}
+	pair := ""
+	if cvHash != "" {
+		pair = cvHash + "\x00" + job.JobDescriptionID
+	}
+
+	return fairnessSample{
+		job:   job,
+		score: s.scoringService.NormalizeScore(job.Result.OverallScore, maxScore) * 100,
+		pair:  pair,
+	}
+}
+
+// compareCohorts compares each cohort of a dimension with its baseline. It returns nil when the samples
+// fall into fewer than two cohorts, along with a description of each drifting cohort.
+func compareCohorts(dimension fairnessDimension, samples []fairnessSample, threshold float64) (*models.FairnessDimension, []string) {
+	cohorts := map[string][]fairnessSample{}
+	for _, sample := range samples {
+		if value := dimension.cohort(sample.job); value != "" {
+			cohorts[value] = append(cohorts[value], sample)
+		}
+	}
+	if len(cohorts) < 2 {
+		return nil, nil
+	}
+
+	values := make([]string, 0, len(cohorts))
+	for value := range cohorts {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(cohorts[values[i]]) != len(cohorts[values[j]]) {
+			return len(cohorts[values[i]]) > len(cohorts[values[j]])
+		}
+		return values[i] < values[j]
+	})
+
+	baseline := dimension.baseline
+	if _, ok := cohorts[baseline]; !ok {
+		baseline = values[0]
+	}
+	baseScores := scoresOf(cohorts[baseline])
+	basePairs := pairScores(cohorts[baseline])
+
+	// The baseline is listed first
+	ordered := []string{baseline}
+	for _, value := range values {
+		if value != baseline {
+			ordered = append(ordered, value)
+		}
+	}
+
+	result := &models.FairnessDimension{Name: dimension.name, Baseline: baseline}
+	var drifts []string
+	for _, value := range ordered {
+		scores := scoresOf(cohorts[value])
+		cohort := models.FairnessCohort{
+			Value:  value,
+			Jobs:   len(scores),
+			Mean:   round2(mean(scores)),
+			Median: round2(median(scores)),
+			StdDev: round2(stdDev(scores)),
+		}
+		if value != baseline {
+			cohort.MeanDelta = round2(mean(scores) - mean(baseScores))
+
+			var deltas []float64
+			for pair, score := range pairScores(cohorts[value]) {
+				if baseScore, ok := basePairs[pair]; ok {
+					deltas = append(deltas, score-baseScore)
+				}
+			}
+			cohort.Pairs = len(deltas)
+			cohort.PairedDelta = round2(mean(deltas))
+
+			if description := drift(dimension.name, baseline, cohort, scores, baseScores, deltas, threshold); description != "" {
+				cohort.Drift = true
+				drifts = append(drifts, description)
+			}
+		}
+		result.Cohorts = append(result.Cohorts, cohort)
+	}
+
+	return result, drifts
+}
+
+// drift describes a cohort whose scores differ from the baseline's by at least threshold points with a
+// t statistic above driftSignificance, or returns an empty string. Paired CVs are preferred since they
+// rule out the cohorts simply holding stronger or weaker candidates.
+func drift(dimension, baseline string, cohort models.FairnessCohort, scores, baseScores, deltas []float64, threshold float64) string {
+	var delta, t float64
+	var basis string
+	switch {
+	case len(deltas) >= minDriftSamples:
+		delta = mean(deltas)
+		t = tStatistic(delta, stdDev(deltas)/math.Sqrt(float64(len(deltas))))
+		basis = fmt.Sprintf("across %d CVs evaluated both ways", len(deltas))
+	case len(scores) >= minDriftSamples && len(baseScores) >= minDriftSamples:
+		delta = mean(scores) - mean(baseScores)
+		standardError := math.Sqrt(variance(scores)/float64(len(scores)) + variance(baseScores)/float64(len(baseScores)))
+		t = tStatistic(delta, standardError)
+		basis = fmt.Sprintf("comparing %d with %d jobs", len(scores), len(baseScores))
+	default:
+		return ""
+	}
+
+	if math.Abs(delta) < threshold || math.Abs(t) < driftSignificance {
+		return ""
+	}
+
+	direction := "higher"
+	if delta < 0 {
+		direction = "lower"
+	}
+	return fmt.Sprintf("%s: %q scores %.1f points %s than %q %s", dimension, cohort.Value, math.Abs(delta), direction, baseline, basis)
+}
+
+// tStatistic divides a difference by its standard error; a difference without any spread is infinitely significant
+func tStatistic(delta, standardError float64) float64 {
+	if standardError == 0 {
+		if delta == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return delta / standardError
+}
+
+func scoresOf(samples []fairnessSample) []float64 {
+	scores := make([]float64, 0, len(samples))
+	for _, sample := range samples {
+		scores = append(scores, sample.score)
+	}
+	return scores
+}
+
+// pairScores averages the scores of each CV and job description in a cohort
+func pairScores(samples []fairnessSample) map[string]float64 {
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	for _, sample := range samples {
+		if sample.pair == "" {
+			continue
+		}
+		sums[sample.pair] += sample.score
+		counts[sample.pair]++
+	}
+
+	scores := make(map[string]float64, len(sums))
+	for pair, sum := range sums {
+		scores[pair] = sum / float64(counts[pair])
+	}
+	return scores
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// variance returns the sample variance of values
+func variance(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := mean(values)
+	var sum float64
+	for _, value := range values {
+		sum += (value - m) * (value - m)
+	}
+	return sum / float64(len(values)-1)
+}
+
+func stdDev(values []float64) float64 {
+	return math.Sqrt(variance(values))
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
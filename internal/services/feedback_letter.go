@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"ai-cv-summarize/internal/audit"
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/llm"
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+)
+
+// LetterPromptData holds the values of the feedback letter prompt. The feedback and summary are redacted,
+// and Candidate is the placeholder of the candidate's name, or empty when it is unknown or the job is blind.
+type LetterPromptData struct {
+	Type      string
+	Tone      string
+	ToneStyle string
+	Candidate string
+	Position  string
+	Company   string
+	Sender    string
+
+	CVFeedback      string
+	ProjectFeedback string
+	Summary         string
+}
+
+// letterToneInstructions tells the model how to write a letter of each tone
+var letterToneInstructions = map[string]string{
+	config.LetterToneWarm:   "Write warmly and encouragingly, in a personal voice.",
+	config.LetterToneFormal: "Write in a formal, professional register.",
+}
+
+// feedbackLetter is the feedback_letter response
+type feedbackLetter struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// ValidateFeedbackLetterRequest fails when a feedback letter request asks for an unknown type or tone
+func ValidateFeedbackLetterRequest(req models.FeedbackLetterRequest) error {
+	if req.Type != "" && req.Type != models.FeedbackLetterRejection && req.Type != models.FeedbackLetterFeedback {
+		return fmt.Errorf("invalid type %q, must be %s or %s", req.Type, models.FeedbackLetterRejection, models.FeedbackLetterFeedback)
+	}
+	if _, ok := letterToneInstructions[req.Tone]; req.Tone != "" && !ok {
+		return fmt.Errorf("invalid tone %q, must be %s or %s", req.Tone, config.LetterToneWarm, config.LetterToneFormal)
+	}
+	return nil
+}
+
+// GenerateFeedbackLetter drafts an email to the candidate of a completed job from its feedback and summary.
+// The model only sees them redacted, with a placeholder for the candidate's name that is filled in afterwards.
+func (es *EvaluationService) GenerateFeedbackLetter(ctx context.Context, job *models.EvaluationJob, req models.FeedbackLetterRequest) (*models.FeedbackLetter, error) {
+	data, redactor, err := es.letterPromptData(ctx, job, req)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, err := es.promptService.Render(ctx, PromptFeedbackLetter, PromptData{Letter: data})
+	if err != nil {
+		return nil, err
+	}
+
+	// Recorded in the job's LLM calls like its evaluation steps
+	ctx = audit.WithStep(audit.WithJob(ctx, job.ID.Hex()), PromptFeedbackLetter)
+	var letter feedbackLetter
+	if _, err := llm.GenerateJSON(ctx, es.llmClient, prompt.Text, feedbackLetterSchema, prompt.Temperature(0.5), es.config.JobQueue.MaxRetries, &letter); err != nil {
+		return nil, fmt.Errorf("failed to generate feedback letter: %w", err)
+	}
+
+	text := redactor.Restore(strings.TrimSpace(letter.Body))
+	return &models.FeedbackLetter{
+		JobID:         job.ID.Hex(),
+		Type:          data.Type,
+		Tone:          data.Tone,
+		Subject:       redactor.Restore(strings.TrimSpace(letter.Subject)),
+		Text:          text,
+		HTML:          letterHTML(text),
+		PromptVersion: prompt.Version,
+		GeneratedAt:   time.Now(),
+	}, nil
+}
+
+// letterPromptData prepares the feedback letter prompt of a completed job and the redactor that restores
+// the candidate's details in the response
+func (es *EvaluationService) letterPromptData(ctx context.Context, job *models.EvaluationJob, req models.FeedbackLetterRequest) (*LetterPromptData, *Redactor, error) {
+	if job.Result == nil {
+		return nil, nil, fmt.Errorf("job %s has no completed result", job.ID.Hex())
+	}
+
+	name := job.CandidateName
+	if name == "" {
+		name = DetectCandidateName(job.CVContent)
+	}
+	// Blind results are not tied back to the candidate, so neither is the letter
+	redactor := NewRedactor(name)
+	if job.Blind {
+		redactor = NewBlindRedactor(name)
+	}
+
+	data := &LetterPromptData{
+		Type:            req.Type,
+		Tone:            req.Tone,
+		Position:        req.Position,
+		Company:         req.CompanyName,
+		Sender:          req.SenderName,
+		CVFeedback:      redactor.Redact(job.Result.CVFeedback),
+		ProjectFeedback: redactor.Redact(job.Result.ProjectFeedback),
+		Summary:         redactor.Redact(job.Result.OverallSummary),
+	}
+	if data.Type == "" {
+		data.Type = models.FeedbackLetterRejection
+	}
+	if data.Tone == "" {
+		data.Tone = es.config.Feedback.LetterTone
+	}
+	data.ToneStyle = letterToneInstructions[data.Tone]
+	if data.Company == "" {
+		data.Company = es.config.Feedback.LetterCompany
+	}
+	if data.Sender == "" {
+		data.Sender = es.config.Feedback.LetterSender
+	}
+	if name != "" && !job.Blind {
+		data.Candidate = redactor.Redact(name)
+	}
+
+	if data.Position == "" && job.JobDescriptionID != "" {
+		// A deleted job description only leaves the position unnamed
+		jobDesc, err := es.repository.GetJobDescription(ctx, job.JobDescriptionID)
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			return nil, nil, fmt.Errorf("failed to load job description %s: %w", job.JobDescriptionID, err)
+		}
+		if err == nil {
+			data.Position = jobDesc.Title
+		}
+	}
+
+	return data, redactor, nil
+}
+
+// letterHTML renders a plain text letter as HTML paragraphs, keeping single line breaks
+func letterHTML(text string) string {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/models"
+)
+
+// feedbackStyle is how an evaluation's feedback and summary are written
+type feedbackStyle struct {
+	verbosity string
+	tone      string
+}
+
+// feedbackVerbosityInstructions and feedbackToneInstructions tell the model how to write feedback of each style
+var (
+	feedbackVerbosityInstructions = map[string]string{
+		config.FeedbackVerbosityDetailed: "Write a detailed narrative of one or two paragraphs that explains the reasoning behind the scores with specific evidence from the documents.",
+		config.FeedbackVerbosityBullets:  `Write 3-5 short bullet points, one line each starting with "- ", strengths first and then gaps.`,
+	}
+	feedbackToneInstructions = map[string]string{
+		config.FeedbackToneInternal:  "It is an internal note for the hiring team: be candid about weaknesses and risks, and refer to scores and the hiring recommendation freely.",
+		config.FeedbackToneCandidate: `It will be shared with the candidate: address them as "you", keep a constructive and respectful tone, frame weaknesses as areas to develop, and do not mention scores, other candidates or hiring decisions.`,
+	}
+)
+
+// feedbackStyle returns the feedback style a job asks for, falling back to the configured one
+func (es *EvaluationService) feedbackStyle(job *models.EvaluationJob) feedbackStyle {
+	style := feedbackStyle{verbosity: es.config.Feedback.Verbosity, tone: es.config.Feedback.Tone}
+	if job.FeedbackVerbosity != "" {
+		style.verbosity = job.FeedbackVerbosity
+	}
+	if job.FeedbackTone != "" {
+		style.tone = job.FeedbackTone
+	}
+	return style
+}
+
+// apply sets the style's fields of prompt data
+func (style feedbackStyle) apply(data *PromptData) {
+	data.FeedbackVerbosity = style.verbosity
+	data.FeedbackTone = style.tone
+	data.FeedbackStyle = feedbackVerbosityInstructions[style.verbosity] + " " + feedbackToneInstructions[style.tone]
+}
+
+// isDefault reports whether the style is the one evaluations were written in before styles could be chosen
+func (style feedbackStyle) isDefault() bool {
+	return style.verbosity == config.FeedbackVerbosityDetailed && style.tone == config.FeedbackToneInternal
+}
+
+// validateFeedbackStyle fails when a job asks for an unknown feedback verbosity or tone
+func validateFeedbackStyle(job *models.EvaluationJob) error {
+	if _, ok := feedbackVerbosityInstructions[job.FeedbackVerbosity]; job.FeedbackVerbosity != "" && !ok {
+		return fmt.Errorf("invalid feedback_verbosity %q, must be %s or %s", job.FeedbackVerbosity, config.FeedbackVerbosityDetailed, config.FeedbackVerbosityBullets)
+	}
+	if _, ok := feedbackToneInstructions[job.FeedbackTone]; job.FeedbackTone != "" && !ok {
+		return fmt.Errorf("invalid feedback_tone %q, must be %s or %s", job.FeedbackTone, config.FeedbackToneInternal, config.FeedbackToneCandidate)
+	}
+	return nil
+}
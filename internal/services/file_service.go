@@ -3,67 +3,315 @@ package services
 import (
 	"archive/zip"
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"log"
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+	"ai-cv-summarize/internal/tenant"
 
 	"github.com/ledongthuc/pdf"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// allowedMimeTypes lists the document types accepted for upload
+var allowedMimeTypes = map[string]bool{
+	"application/pdf": true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
+	"text/plain":         true,
+	"application/msword": true,
+	"application/rtf":    true,
+	"text/rtf":           true,
+	"application/vnd.oasis.opendocument.text": true,
+	"text/html":       true,
+	"text/markdown":   true,
+	"text/x-markdown": true,
+}
+
+// extensionMimeTypes maps supported file extensions to their MIME type
+var extensionMimeTypes = map[string]string{
+	".pdf":      "application/pdf",
+	".docx":     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".txt":      "text/plain",
+	".doc":      "application/msword",
+	".rtf":      "application/rtf",
+	".odt":      "application/vnd.oasis.opendocument.text",
+	".html":     "text/html",
+	".htm":      "text/html",
+	".md":       "text/markdown",
+	".markdown": "text/markdown",
+}
+
+// ErrFileTooLarge is returned for files over the maximum upload size
+var ErrFileTooLarge = errors.New("file size exceeds maximum allowed size")
+
+// ErrEmptyFile is returned for uploads without content
+var ErrEmptyFile = errors.New("file is empty")
+
+// ErrUnsupportedFileType is returned for files of a type that is not accepted, or whose content does not
+// match their extension
+var ErrUnsupportedFileType = errors.New("unsupported file type")
+
+// ErrInvalidFileName is returned for file references that are not the plain name of a stored upload
+var ErrInvalidFileName = errors.New("invalid file name")
+
+// ErrUnknownFile is returned for file IDs that do not refer to an upload of the organization
+var ErrUnknownFile = errors.New("unknown file")
+
+// uploadNamePattern matches the names files are stored under: no path separators and no leading dot
+var uploadNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,254}$`)
+
 type FileService struct {
-	uploadDir   string
-	maxFileSize int64
+	uploadDir      string
+	maxFileSize    int64
+	maxArchiveSize int64
+	ocr            *OCRService
+	scanner        *VirusScanner
+	repository     repositories.Repository
+
+	// fileLocks serialize storing a file against removing it, striped by file name, so a file is never
+	// removed while an upload of the same content records it again
+	fileLocks [64]sync.Mutex
 }
 
-func NewFileService(uploadDir string, maxFileSize int64) *FileService {
-	os.MkdirAll(uploadDir, 0755)
+// NewFileService stores uploads in cfg.UploadDir and their metadata in repository; ocr, which may be nil,
+// reads scanned PDFs, and scanner, which may be nil, checks uploads for malware
+func NewFileService(cfg *config.UploadConfig, ocr *OCRService, scanner *VirusScanner, repository repositories.Repository) *FileService {
+	os.MkdirAll(cfg.UploadDir, 0755)
 
 	return &FileService{
-		uploadDir:   uploadDir,
-		maxFileSize: maxFileSize,
+		uploadDir:      cfg.UploadDir,
+		maxFileSize:    cfg.MaxFileSize,
+		maxArchiveSize: cfg.MaxArchiveSize,
+		ocr:            ocr,
+		scanner:        scanner,
+		repository:     repository,
 	}
 }
 
-// SaveFile saves uploaded file and returns file path
-func (s *FileService) SaveFile(file *multipart.FileHeader) (string, error) {
+// SaveFile saves uploaded file and returns its upload record. The type is detected from the content, which
+// must match the extension; the Content-Type the client sent is not trusted.
+func (s *FileService) SaveFile(ctx context.Context, file *multipart.FileHeader) (*models.Upload, error) {
 	if file.Size > s.maxFileSize {
-		return "", errors.New("file size exceeds maximum allowed size")
+		return nil, ErrFileTooLarge
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	return s.SaveReader(ctx, file.Filename, src)
+}
+
+// MaxRequestSize is the largest multipart request that can carry the given number of files, leaving room
+// for the form encoding and other fields
+func (s *FileService) MaxRequestSize(files int) int64 {
+	return int64(files)*s.maxFileSize + 1<<20
+}
+
+// SaveBase64File decodes an inline base64 document and saves it like a regular upload
+func (s *FileService) SaveBase64File(ctx context.Context, doc models.InlineDocument) (*models.Upload, error) {
+	filename := filepath.Base(doc.Filename)
+	if filename == "." || filename == string(filepath.Separator) {
+		return nil, errors.New("invalid filename")
+	}
+
+	mimeType := doc.MimeType
+	if mimeType == "" {
+		mimeType = extensionMimeTypes[strings.ToLower(filepath.Ext(filename))]
+	}
+
+	if !allowedMimeTypes[mimeType] {
+		return nil, ErrUnsupportedFileType
+	}
+
+	if int64(base64.StdEncoding.DecodedLen(len(doc.Content))) > s.maxFileSize+2 {
+		return nil, ErrFileTooLarge
+	}
+
+	data, err := base64.StdEncoding.DecodeString(doc.Content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 content: %w", err)
+	}
+
+	return s.SaveReader(ctx, filename, bytes.NewReader(data))
+}
+
+// ValidateUpload checks the name, MIME type and size of a file before it is accepted for upload. The type
+// must be allowed and agree with the file extension.
+func (s *FileService) ValidateUpload(filename, mimeType string, size int64) error {
+	if filename == "." || filename == "/" || filename != filepath.Base(filename) {
+		return errors.New("invalid filename")
+	}
+	if size <= 0 {
+		return ErrEmptyFile
+	}
+	if size > s.maxFileSize {
+		return ErrFileTooLarge
+	}
+
+	expected, ok := extensionMimeTypes[strings.ToLower(filepath.Ext(filename))]
+	if !ok || !allowedMimeTypes[mimeType] {
+		return ErrUnsupportedFileType
 	}
+	// RTF and Markdown have more than one registered type
+	if mimeType != expected && !(expected == "application/rtf" && mimeType == "text/rtf") &&
+		!(expected == "text/markdown" && mimeType == "text/x-markdown") {
+		return fmt.Errorf("%w: %s does not match the extension %s", ErrUnsupportedFileType, mimeType, filepath.Ext(filename))
+	}
+	return nil
+}
+
+// SaveReader saves a file read from r like a regular upload. It fails as soon as more than the maximum size
+// has been read, when the virus scanner finds malware, and when the content is not of the type the file
+// name's extension stands for.
+func (s *FileService) SaveReader(ctx context.Context, filename string, r io.Reader) (*models.Upload, error) {
+	originalName := filepath.Base(filename)
+	if originalName == "." || originalName == string(filepath.Separator) {
+		return nil, errors.New("invalid filename")
+	}
+
+	// Write under a temporary name so a concurrent upload of the same file never reads a partial one
+	tmp, err := os.CreateTemp(s.uploadDir, ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
 
-	allowedTypes := map[string]bool{
-		"application/pdf": true,
-		"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
-		"text/plain": true,
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(r, s.maxFileSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size > s.maxFileSize {
+		return nil, ErrFileTooLarge
 	}
+	if size == 0 {
+		return nil, ErrEmptyFile
+	}
+	digest := hex.EncodeToString(hash.Sum(nil))
 
-	if !allowedTypes[file.Header.Get("Content-Type")] {
-		return "", errors.New("unsupported file type")
+	if s.scanner.Enabled() {
+		signature, err := s.scanner.ScanFile(ctx, tmp.Name())
+		if err != nil {
+			return nil, fmt.Errorf("virus scan failed: %w", err)
+		}
+		if signature != "" {
+			quarantined, err := s.scanner.quarantine(tmp.Name(), digest)
+			if err != nil {
+				log.Printf("Warning: failed to quarantine infected upload %s: %v", originalName, err)
+			} else {
+				log.Printf("Quarantined upload %s infected with %s as %s", originalName, signature, quarantined)
+			}
+			return nil, &InfectedFileError{Filename: originalName, Signature: signature}
+		}
 	}
 
-	filename := fmt.Sprintf("%d_%s", file.Size, file.Filename)
+	detected, err := detectMimeType(tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+	mimeType, err := checkFileType(originalName, detected)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store(ctx, tmp.Name(), originalName, mimeType, digest, size)
+}
+
+// store moves a validated temporary file to the name of its SHA-256 digest, keeping the original extension
+// that text extraction goes by, and records its metadata. An identical file is stored only once, and an
+// organization uploading it again reuses its record.
+func (s *FileService) store(ctx context.Context, tmpPath, originalName, mimeType, hash string, size int64) (*models.Upload, error) {
+	filename := hash + strings.ToLower(filepath.Ext(originalName))
 	filePath := filepath.Join(s.uploadDir, filename)
 
-	src, err := file.Open()
+	lock := s.fileLock(filename)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, err := os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
+		if err := os.Chmod(tmpPath, 0644); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(tmpPath, filePath); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	// The ID only sticks when the organization has no record of the file yet, which tells the two apart
+	id := primitive.NewObjectID()
+	upload, err := s.repository.RecordUpload(ctx, &models.Upload{
+		ID:           id,
+		Filename:     filename,
+		OriginalName: originalName,
+		Hash:         hash,
+		Size:         size,
+		MimeType:     mimeType,
+		CreatedAt:    time.Now(),
+	})
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to record upload: %w", err)
 	}
-	defer src.Close()
+	upload.Created = upload.ID == id
 
-	dst, err := os.Create(filePath)
+	return upload, nil
+}
+
+// ResolveUpload returns the organization's upload with the given ID, the opaque file reference /upload
+// returns. Files are only ever located by the names recorded when they were stored, so no client input
+// becomes part of a path.
+func (s *FileService) ResolveUpload(ctx context.Context, id string) (*models.Upload, error) {
+	upload, err := s.repository.GetUpload(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, fmt.Errorf("%w %q", ErrUnknownFile, id)
+	}
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to look up upload: %w", err)
+	}
+	if err := ValidateUploadName(upload.Filename); err != nil {
+		return nil, err
 	}
-	defer dst.Close()
+	return upload, nil
+}
 
-	if _, err = io.Copy(dst, src); err != nil {
-		return "", err
+// ValidateUploadName checks that a file name can only refer to a file directly in the upload directory:
+// a plain name without separators or "..", with a supported extension
+func ValidateUploadName(name string) error {
+	if !uploadNamePattern.MatchString(name) || strings.Contains(name, "..") {
+		return fmt.Errorf("%w %q: must be a plain file name, without a path", ErrInvalidFileName, name)
 	}
+	if MimeTypeFor(name) == "" {
+		return fmt.Errorf("%w %q: unsupported extension", ErrInvalidFileName, name)
+	}
+	return nil
+}
 
-	return filePath, nil
+// MimeTypeFor returns the MIME type of a supported file extension, or "" for unsupported ones
+func MimeTypeFor(filename string) string {
+	return extensionMimeTypes[strings.ToLower(filepath.Ext(filename))]
 }
 
 // ExtractTextFromFile extracts text from various file formats
@@ -75,8 +323,17 @@ func (s *FileService) ExtractTextFromFile(filePath string) (string, error) {
 		return s.extractTextFromPDF(filePath)
 	case ".docx":
 		return s.extractTextFromDOCX(filePath)
-	case ".txt":
+	case ".txt", ".md", ".markdown":
+		// Markdown reads well as it is, and the prompts handle its syntax
 		return s.extractTextFromTXT(filePath)
+	case ".doc":
+		return s.extractTextFromDOC(filePath)
+	case ".rtf":
+		return s.extractTextFromRTF(filePath)
+	case ".odt":
+		return s.extractTextFromODT(filePath)
+	case ".html", ".htm":
+		return s.extractTextFromHTML(filePath)
 	default:
 		return "", errors.New("unsupported file format")
 	}
@@ -105,6 +362,17 @@ func (s *FileService) extractTextFromPDF(filePath string) (string, error) {
 		text.WriteString("\n")
 	}
 
+	// Image-only scans have no text layer to speak of
+	if s.ocr.Enabled() && s.ocr.NeedsOCR(text.String()) {
+		recognized, err := s.ocr.ExtractPDF(context.Background(), filePath)
+		if err != nil {
+			return "", fmt.Errorf("PDF has no text layer and OCR failed: %w", err)
+		}
+		if len(strings.TrimSpace(recognized)) > len(strings.TrimSpace(text.String())) {
+			return recognized, nil
+		}
+	}
+
 	return text.String(), nil
 }
 
@@ -226,6 +494,74 @@ func (s *FileService) decodeXMLEntities(text string) string {
 	return text
 }
 
+// extractTextFromDOC reads a legacy Word 97-2003 document
+func (s *FileService) extractTextFromDOC(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	text, err := extractWord97Text(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to read DOC file: %w", err)
+	}
+	return text, nil
+}
+
+func (s *FileService) extractTextFromRTF(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	text, err := extractRTFText(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to read RTF file: %w", err)
+	}
+	return text, nil
+}
+
+func (s *FileService) extractTextFromODT(filePath string) (string, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open ODT file: %w", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if file.Name != "content.xml" {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to open content.xml: %w", err)
+		}
+		defer rc.Close()
+
+		text, err := extractODTText(rc)
+		if err != nil {
+			return "", fmt.Errorf("failed to read content.xml: %w", err)
+		}
+		return text, nil
+	}
+
+	return "", fmt.Errorf("content.xml not found in ODT file")
+}
+
+func (s *FileService) extractTextFromHTML(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	text, err := extractHTMLText(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read HTML file: %w", err)
+	}
+	return text, nil
+}
+
 func (s *FileService) extractTextFromTXT(filePath string) (string, error) {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
@@ -235,10 +571,103 @@ func (s *FileService) extractTextFromTXT(filePath string) (string, error) {
 	return string(content), nil
 }
 
-func (s *FileService) CleanupFile(filePath string) error {
-	return os.Remove(filePath)
+// UploadPath returns the path of an uploaded file by its name
+func (s *FileService) UploadPath(name string) string {
+	return filepath.Join(s.uploadDir, filepath.Base(name))
+}
+
+// CleanupFile removes a file saved for a request that failed. Only a record the request created is removed:
+// a record that already existed was returned to an earlier request, whose file ID must keep working. The file
+// stays while other organizations have uploaded it too, or while a job of the organization uses the same
+// content uploaded earlier.
+func (s *FileService) CleanupFile(ctx context.Context, upload *models.Upload) error {
+	if upload == nil || !upload.Created {
+		return nil
+	}
+	used, err := s.repository.FindReferencedFiles(ctx, []string{upload.Filename}, nil)
+	if err != nil || len(used) > 0 {
+		return err
+	}
+	return s.RemoveUpload(ctx, upload.Filename)
+}
+
+// RemoveUpload deletes the organization's record of an uploaded file, and the file itself once no other
+// organization has uploaded it; files already gone are ignored
+func (s *FileService) RemoveUpload(ctx context.Context, name string) error {
+	if name == "" {
+		return nil
+	}
+	name = filepath.Base(name)
+
+	lock := s.fileLock(name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, err := s.repository.DeleteUploads(ctx, []string{name}); err != nil {
+		return err
+	}
+	if others, err := s.repository.CountUploads(ctx, name); err != nil || others > 0 {
+		return err
+	}
+	if err := os.Remove(filepath.Join(s.uploadDir, name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// fileLock returns the lock guarding a stored file
+func (s *FileService) fileLock(name string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return &s.fileLocks[h.Sum32()%uint32(len(s.fileLocks))]
+}
+
+// RemoveJobUploads deletes the uploaded CV and project files of the jobs and returns how many were removed.
+// Stored files are deduplicated by content, so a file another job of the organization still uses is kept.
+func (s *FileService) RemoveJobUploads(ctx context.Context, jobs []*models.EvaluationJob) int {
+	jobIDs := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		jobIDs = append(jobIDs, job.ID.Hex())
+	}
+
+	removed := 0
+	seen := map[string]bool{}
+	for _, job := range jobs {
+		// Background tasks are unscoped; only the job's organization gives up its record of the files
+		jobCtx := ctx
+		if job.OrgID != "" {
+			jobCtx = tenant.WithOrgID(ctx, job.OrgID)
+		}
+		for _, name := range []string{job.CVFile, job.ProjectFile} {
+			if name == "" || seen[job.OrgID+"/"+name] {
+				continue
+			}
+			seen[job.OrgID+"/"+name] = true
+
+			used, err := s.repository.FindReferencedFiles(jobCtx, []string{name}, jobIDs)
+			if err != nil {
+				log.Printf("Error checking uploaded file %s of job %s: %v", name, job.ID.Hex(), err)
+				continue
+			}
+			if len(used) > 0 {
+				continue
+			}
+			if err := s.RemoveUpload(jobCtx, name); err != nil {
+				log.Printf("Error removing uploaded file %s of job %s: %v", name, job.ID.Hex(), err)
+				continue
+			}
+			removed++
+		}
+	}
+	return removed
 }
 
 func (s *FileService) GetFileInfo(filePath string) (os.FileInfo, error) {
 	return os.Stat(filePath)
 }
+
+// HashContent returns the SHA-256 hex digest of extracted document text, ignoring surrounding whitespace
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(content)))
+	return hex.EncodeToString(sum[:])
+}
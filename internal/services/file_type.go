@@ -0,0 +1,112 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Signatures of the binary document formats, found at the start of the file
+var (
+	pdfMagic = []byte("%PDF-")
+	zipMagic = []byte("PK\x03\x04")
+	oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	rtfMagic = []byte(`{\rtf`)
+)
+
+// detectMimeType identifies the type of a saved file from its content rather than the name or the type the
+// client declared. ZIP containers are opened to tell DOCX from ODT. Files without a known signature are
+// text/plain or text/html when they look like text, and application/octet-stream otherwise.
+func detectMimeType(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, pdfMagic):
+		return "application/pdf", nil
+	case bytes.HasPrefix(head, oleMagic):
+		// Word 97-2003 documents are OLE compound files, as are old Excel and PowerPoint files; the
+		// extractor rejects those
+		return "application/msword", nil
+	case bytes.HasPrefix(head, rtfMagic):
+		return "application/rtf", nil
+	case bytes.HasPrefix(head, zipMagic):
+		return detectZipMimeType(filePath), nil
+	}
+
+	mimeType := http.DetectContentType(head)
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	if mimeType == "text/plain" || mimeType == "text/html" {
+		return mimeType, nil
+	}
+	return "application/octet-stream", nil
+}
+
+// detectZipMimeType tells the ZIP based document formats apart by their entries: ODT names its type in a
+// mimetype entry and DOCX keeps its body in word/document.xml
+func detectZipMimeType(filePath string) string {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer reader.Close()
+
+	mimeType := "application/zip"
+	for _, file := range reader.File {
+		switch file.Name {
+		case "word/document.xml":
+			mimeType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		case "mimetype":
+			rc, err := file.Open()
+			if err != nil {
+				continue
+			}
+			declared, _ := io.ReadAll(io.LimitReader(rc, 128))
+			rc.Close()
+			if strings.TrimSpace(string(declared)) == "application/vnd.oasis.opendocument.text" {
+				return "application/vnd.oasis.opendocument.text"
+			}
+		}
+	}
+	return mimeType
+}
+
+// checkFileType verifies that a file's detected type is the one its extension stands for, and returns the
+// type to record. Plain text, Markdown and HTML have no signature and are told apart by the extension, so
+// their content only has to be text.
+func checkFileType(filename, detected string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	expected, ok := extensionMimeTypes[ext]
+	if !ok {
+		return "", ErrUnsupportedFileType
+	}
+
+	switch expected {
+	case "text/plain", "text/markdown", "text/html":
+		if detected == "text/plain" || detected == "text/html" {
+			return expected, nil
+		}
+	default:
+		if detected == expected {
+			return expected, nil
+		}
+	}
+	return "", fmt.Errorf("%w: the content is %s, which does not match the extension %s", ErrUnsupportedFileType, detected, ext)
+}
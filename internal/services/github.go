@@ -0,0 +1,431 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/models"
+)
+
+const (
+	// githubReadmeLimit caps the README excerpt kept for the prompt, in characters
+	githubReadmeLimit = 3000
+	// githubActivityWindow is how far back commit activity is counted
+	githubActivityWindow = 90 * 24 * time.Hour
+	// githubMaxResponse caps the size of a GitHub API response
+	githubMaxResponse = 1 << 20
+)
+
+var (
+	githubOwnerPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,38})$`)
+	githubRepoPattern  = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+)
+
+// ErrInvalidGitHubRef is returned for GitHub references that are not a username, "owner/repo" or a
+// github.com URL
+var ErrInvalidGitHubRef = errors.New("github must be a GitHub username, owner/repo or github.com URL")
+
+// ParseGitHubRef splits a GitHub username, "owner/repo" or github.com URL into its owner and repository.
+// repo is empty when ref names an account.
+func ParseGitHubRef(ref string) (owner, repo string, err error) {
+	ref = strings.TrimSpace(ref)
+	if strings.Contains(ref, "://") {
+		parsed, err := url.Parse(ref)
+		if err != nil || (parsed.Host != "github.com" && parsed.Host != "www.github.com") {
+			return "", "", ErrInvalidGitHubRef
+		}
+		ref = parsed.Path
+	} else {
+		ref = strings.TrimPrefix(strings.TrimPrefix(ref, "www."), "github.com/")
+	}
+
+	// Links into a repository, such as /tree/main, still name the repository
+	parts := strings.Split(strings.Trim(ref, "/"), "/")
+	owner = strings.TrimPrefix(parts[0], "@")
+	if len(parts) > 1 {
+		repo = strings.TrimSuffix(parts[1], ".git")
+	}
+	if !githubOwnerPattern.MatchString(owner) || (len(parts) > 1 && !githubRepoPattern.MatchString(repo)) {
+		return "", "", ErrInvalidGitHubRef
+	}
+	return owner, repo, nil
+}
+
+// githubError carries the HTTP status of a failed GitHub API request
+type githubError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *githubError) Error() string {
+	return fmt.Sprintf("GitHub API returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// isGitHubStatus reports whether err is a GitHub API error with one of the given statuses
+func isGitHubStatus(err error, statuses ...int) bool {
+	var gerr *githubError
+	if !errors.As(err, &gerr) {
+		return false
+	}
+	for _, status := range statuses {
+		if gerr.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// GitHubService fetches a candidate's repositories from the GitHub API to ground the project evaluation
+type GitHubService struct {
+	config     *config.GitHubConfig
+	httpClient *http.Client
+}
+
+// NewGitHubService returns a GitHub service; it does nothing unless cfg.Enabled is set
+func NewGitHubService(cfg *config.GitHubConfig) *GitHubService {
+	return &GitHubService{
+		config:     cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Enabled reports whether GitHub references are analyzed
+func (g *GitHubService) Enabled() bool {
+	return g != nil && g.config.Enabled
+}
+
+// Analyze fetches a repository, or for a username the account and its most recently pushed repositories
+// that are neither forks nor archived
+func (g *GitHubService) Analyze(ctx context.Context, ref string) (*models.GitHubAnalysis, error) {
+	owner, repo, err := ParseGitHubRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &models.GitHubAnalysis{Repositories: []models.GitHubRepository{}, FetchedAt: time.Now()}
+	if repo != "" {
+		repository, err := g.repository(ctx, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		analysis.Repositories = append(analysis.Repositories, *repository)
+		return analysis, nil
+	}
+
+	var user struct {
+		Login       string    `json:"login"`
+		Name        string    `json:"name"`
+		Bio         string    `json:"bio"`
+		PublicRepos int       `json:"public_repos"`
+		Followers   int       `json:"followers"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+	if err := g.get(ctx, "/users/"+owner, &user); err != nil {
+		if isGitHubStatus(err, http.StatusNotFound) {
+			return nil, fmt.Errorf("GitHub user %s not found", owner)
+		}
+		return nil, err
+	}
+	analysis.Profile = &models.GitHubProfile{
+		Login:       user.Login,
+		Name:        user.Name,
+		Bio:         user.Bio,
+		PublicRepos: user.PublicRepos,
+		Followers:   user.Followers,
+		CreatedAt:   user.CreatedAt,
+	}
+
+	var repos []struct {
+		Name     string `json:"name"`
+		Fork     bool   `json:"fork"`
+		Archived bool   `json:"archived"`
+	}
+	if err := g.get(ctx, "/users/"+owner+"/repos?type=owner&sort=pushed&per_page=30", &repos); err != nil {
+		return nil, err
+	}
+	for _, candidate := range repos {
+		if len(analysis.Repositories) >= g.config.MaxRepos {
+			break
+		}
+		if candidate.Fork || candidate.Archived {
+			continue
+		}
+		repository, err := g.repository(ctx, owner, candidate.Name)
+		if err != nil {
+			return nil, err
+		}
+		analysis.Repositories = append(analysis.Repositories, *repository)
+	}
+
+	return analysis, nil
+}
+
+// repository fetches a repository's metadata, languages, top-level files, README and commit activity
+func (g *GitHubService) repository(ctx context.Context, owner, name string) (*models.GitHubRepository, error) {
+	path := "/repos/" + owner + "/" + name
+
+	var meta struct {
+		FullName    string   `json:"full_name"`
+		HTMLURL     string   `json:"html_url"`
+		Description string   `json:"description"`
+		Topics      []string `json:"topics"`
+		License     *struct {
+			SPDXID string `json:"spdx_id"`
+		} `json:"license"`
+		Stars         int       `json:"stargazers_count"`
+		Forks         int       `json:"forks_count"`
+		OpenIssues    int       `json:"open_issues_count"`
+		Fork          bool      `json:"fork"`
+		Archived      bool      `json:"archived"`
+		DefaultBranch string    `json:"default_branch"`
+		CreatedAt     time.Time `json:"created_at"`
+		PushedAt      time.Time `json:"pushed_at"`
+	}
+	if err := g.get(ctx, path, &meta); err != nil {
+		if isGitHubStatus(err, http.StatusNotFound) {
+			return nil, fmt.Errorf("GitHub repository %s/%s not found", owner, name)
+		}
+		return nil, err
+	}
+	repository := &models.GitHubRepository{
+		FullName:    meta.FullName,
+		URL:         meta.HTMLURL,
+		Description: meta.Description,
+		Topics:      meta.Topics,
+		Stars:       meta.Stars,
+		Forks:       meta.Forks,
+		OpenIssues:  meta.OpenIssues,
+		Fork:        meta.Fork,
+		Archived:    meta.Archived,
+		CreatedAt:   meta.CreatedAt,
+		PushedAt:    meta.PushedAt,
+	}
+	if meta.License != nil {
+		repository.License = meta.License.SPDXID
+	}
+
+	var languages map[string]int64
+	if err := g.get(ctx, path+"/languages", &languages); err != nil {
+		return nil, err
+	}
+	repository.Languages = languageShares(languages)
+
+	// Empty repositories have no contents, README or commits
+	var contents []struct {
+		Name string `json:"name"`
+	}
+	if err := g.get(ctx, path+"/contents", &contents); err != nil && !isGitHubStatus(err, http.StatusNotFound) {
+		return nil, err
+	}
+	for _, entry := range contents {
+		repository.RootFiles = append(repository.RootFiles, entry.Name)
+	}
+
+	readme, err := g.fetch(ctx, path+"/readme", "application/vnd.github.raw")
+	if err != nil && !isGitHubStatus(err, http.StatusNotFound) {
+		return nil, err
+	}
+	repository.Readme = truncateText(strings.TrimSpace(string(readme)), githubReadmeLimit)
+
+	if err := g.commitActivity(ctx, path, meta.DefaultBranch, repository); err != nil && !isGitHubStatus(err, http.StatusNotFound, http.StatusConflict) {
+		return nil, err
+	}
+
+	return repository, nil
+}
+
+// commitActivity counts the recent commits and their authors on a repository's default branch
+func (g *GitHubService) commitActivity(ctx context.Context, path, branch string, repository *models.GitHubRepository) error {
+	type commit struct {
+		Commit struct {
+			Author struct {
+				Email string    `json:"email"`
+				Date  time.Time `json:"date"`
+			} `json:"author"`
+		} `json:"commit"`
+		Author *struct {
+			Login string `json:"login"`
+		} `json:"author"`
+	}
+
+	query := url.Values{
+		"sha":      {branch},
+		"since":    {time.Now().Add(-githubActivityWindow).UTC().Format(time.RFC3339)},
+		"per_page": {"100"},
+	}
+	var commits []commit
+	if err := g.get(ctx, path+"/commits?"+query.Encode(), &commits); err != nil {
+		return err
+	}
+
+	// Without recent commits, the latest commit still shows when work stopped
+	if len(commits) == 0 {
+		query.Del("since")
+		query.Set("per_page", "1")
+		if err := g.get(ctx, path+"/commits?"+query.Encode(), &commits); err != nil {
+			return err
+		}
+		if len(commits) > 0 {
+			last := commits[0].Commit.Author.Date
+			repository.LastCommitAt = &last
+		}
+		return nil
+	}
+
+	authors := map[string]bool{}
+	for _, c := range commits {
+		author := strings.ToLower(c.Commit.Author.Email)
+		if c.Author != nil && c.Author.Login != "" {
+			author = c.Author.Login
+		}
+		authors[author] = true
+	}
+	last := commits[0].Commit.Author.Date
+	repository.RecentCommits = len(commits)
+	repository.Contributors = len(authors)
+	repository.LastCommitAt = &last
+	return nil
+}
+
+// get sends a GET request to the GitHub API and decodes the JSON response into out
+func (g *GitHubService) get(ctx context.Context, path string, out interface{}) error {
+	body, err := g.fetch(ctx, path, "application/vnd.github+json")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode GitHub response: %w", err)
+	}
+	return nil
+}
+
+// fetch sends a GET request to the GitHub API and returns the response body
+func (g *GitHubService) fetch(ctx context.Context, path, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.config.APIURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if g.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.config.Token)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, githubMaxResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		var message struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &message) != nil || message.Message == "" {
+			message.Message = http.StatusText(resp.StatusCode)
+		}
+		return nil, &githubError{StatusCode: resp.StatusCode, Message: message.Message}
+	}
+
+	return body, nil
+}
+
+// languageShares converts the bytes of code per language into percentages, rounded to one decimal
+func languageShares(languages map[string]int64) map[string]float64 {
+	var total int64
+	for _, size := range languages {
+		total += size
+	}
+	if total == 0 {
+		return nil
+	}
+
+	shares := make(map[string]float64, len(languages))
+	for language, size := range languages {
+		shares[language] = math.Round(float64(size)/float64(total)*1000) / 10
+	}
+	return shares
+}
+
+// truncateText cuts text to at most limit characters, marking the cut
+func truncateText(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	return string([]rune(text)[:limit]) + "\n[truncated]"
+}
+
+// formatGitHubAnalysis renders GitHub data as plain text for the project evaluation prompt
+func formatGitHubAnalysis(analysis *models.GitHubAnalysis) string {
+	var sb strings.Builder
+	if profile := analysis.Profile; profile != nil {
+		sb.WriteString(fmt.Sprintf("Account: %s, %d public repositories, %d followers, member since %d\n",
+			profile.Login, profile.PublicRepos, profile.Followers, profile.CreatedAt.Year()))
+		if profile.Bio != "" {
+			sb.WriteString(fmt.Sprintf("Bio: %s\n", profile.Bio))
+		}
+		if len(analysis.Repositories) == 0 {
+			sb.WriteString("No original, active repositories.\n")
+		}
+	}
+
+	for _, repo := range analysis.Repositories {
+		sb.WriteString(fmt.Sprintf("\nRepository: %s\n", repo.FullName))
+		if repo.Description != "" {
+			sb.WriteString(fmt.Sprintf("Description: %s\n", repo.Description))
+		}
+		if len(repo.Topics) > 0 {
+			sb.WriteString(fmt.Sprintf("Topics: %s\n", strings.Join(repo.Topics, ", ")))
+		}
+		if len(repo.Languages) > 0 {
+			names := make([]string, 0, len(repo.Languages))
+			for name := range repo.Languages {
+				names = append(names, name)
+			}
+			sort.Slice(names, func(i, j int) bool { return repo.Languages[names[i]] > repo.Languages[names[j]] })
+			for i, name := range names {
+				names[i] = fmt.Sprintf("%s %.1f%%", name, repo.Languages[name])
+			}
+			sb.WriteString(fmt.Sprintf("Languages: %s\n", strings.Join(names, ", ")))
+		}
+		sb.WriteString(fmt.Sprintf("Stars: %d, forks: %d, open issues: %d\n", repo.Stars, repo.Forks, repo.OpenIssues))
+		if repo.License != "" {
+			sb.WriteString(fmt.Sprintf("License: %s\n", repo.License))
+		}
+		sb.WriteString(fmt.Sprintf("Created %s, last pushed %s\n", repo.CreatedAt.Format("2006-01-02"), repo.PushedAt.Format("2006-01-02")))
+		switch {
+		case repo.RecentCommits > 0:
+			sb.WriteString(fmt.Sprintf("Commits in the last 90 days: %d by %d author(s), latest %s\n",
+				repo.RecentCommits, repo.Contributors, repo.LastCommitAt.Format("2006-01-02")))
+		case repo.LastCommitAt != nil:
+			sb.WriteString(fmt.Sprintf("No commits in the last 90 days, latest %s\n", repo.LastCommitAt.Format("2006-01-02")))
+		}
+		if len(repo.RootFiles) > 0 {
+			sb.WriteString(fmt.Sprintf("Top-level files: %s\n", strings.Join(repo.RootFiles, ", ")))
+		}
+		if repo.Fork {
+			sb.WriteString("This repository is a fork.\n")
+		}
+		if repo.Readme != "" {
+			sb.WriteString(fmt.Sprintf("README:\n%s\n", repo.Readme))
+		}
+	}
+
+	return strings.TrimSpace(sb.String())
+}
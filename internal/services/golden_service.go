@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+)
+
+// GoldenService validates prompt/model changes by re-running curated jobs against their recorded results
+type GoldenService struct {
+	repository        repositories.Repository
+	evaluationService *EvaluationService
+}
+
+func NewGoldenService(repository repositories.Repository, evaluationService *EvaluationService) *GoldenService {
+	return &GoldenService{
+		repository:        repository,
+		evaluationService: evaluationService,
+	}
+}
+
+// AddGoldenJob snapshots a completed job's result as the expected output, with optional expected ranges
+// for its scores. The job itself is copied too, documents included, so it can be re-run after retention.
+func (gs *GoldenService) AddGoldenJob(ctx context.Context, req models.CreateGoldenJobRequest) (*models.GoldenJob, error) {
+	job, err := gs.repository.GetJobByID(ctx, req.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	if job.Status != models.StatusCompleted || job.Result == nil {
+		return nil, fmt.Errorf("job %s has no completed result", req.JobID)
+	}
+
+	scores := resultScores(job.Result)
+	for name, expected := range req.ExpectedRanges {
+		if _, ok := scores[name]; !ok {
+			return nil, fmt.Errorf("unknown score %q in expected_ranges", name)
+		}
+		if expected.Min > expected.Max {
+			return nil, fmt.Errorf("expected range of %s has min %g above max %g", name, expected.Min, expected.Max)
+		}
+	}
+
+	golden := &models.GoldenJob{
+		JobID:          req.JobID,
+		Label:          req.Label,
+		Result:         *job.Result,
+		ExpectedRanges: req.ExpectedRanges,
+		Source:         job,
+		CreatedAt:      time.Now(),
+	}
+
+	if err := gs.repository.CreateGoldenJob(ctx, golden); err != nil {
+		return nil, fmt.Errorf("failed to save golden job: %w", err)
+	}
+
+	return golden, nil
+}
+
+// Compare re-evaluates every golden job with the current prompts/model and reports score deltas.
+// With replay set, jobs are re-run against their recorded LLM responses instead, which isolates changes
+// to parsing and scoring from model variance. A comparison passes when every score stays in the golden
+// job's expected ranges, or, for a golden job without ranges, no individual score moves by more than tolerance.
+func (gs *GoldenService) Compare(ctx context.Context, tolerance float64, replay bool) (*models.GoldenComparisonReport, error) {
+	goldens, err := gs.repository.GetAllGoldenJobs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get golden jobs: %w", err)
+	}
+
+	report := &models.GoldenComparisonReport{
+		Replay:      replay,
+		Tolerance:   tolerance,
+		Total:       len(goldens),
+		Comparisons: []models.GoldenComparison{},
+		StartedAt:   time.Now(),
+	}
+
+	var totalAbsDelta float64
+	var deltaCount int
+
+	for _, golden := range goldens {
+		comparison := models.GoldenComparison{
+			GoldenID: golden.ID.Hex(),
+			JobID:    golden.JobID,
+			Label:    golden.Label,
+		}
+
+		current, changedSteps, err := gs.rerun(ctx, golden, replay)
+		comparison.ChangedSteps = changedSteps
+		if err != nil {
+			comparison.Error = err.Error()
+			report.Errors++
+			report.Drifts = append(report.Drifts, fmt.Sprintf("%s could not be re-evaluated: %v", goldenName(golden), err))
+			report.Comparisons = append(report.Comparisons, comparison)
+			continue
+		}
+
+		comparison.Deltas = scoreDeltas(&golden.Result, current)
+		for _, delta := range comparison.Deltas {
+			totalAbsDelta += math.Abs(delta)
+			deltaCount++
+			comparison.MaxAbsDelta = math.Max(comparison.MaxAbsDelta, math.Abs(delta))
+		}
+
+		comparison.WithinTolerance = comparison.MaxAbsDelta <= tolerance
+		comparison.OutOfRange = outOfRange(golden.ExpectedRanges, current)
+
+		if len(golden.ExpectedRanges) > 0 {
+			comparison.Passed = len(comparison.OutOfRange) == 0
+			for _, score := range comparison.OutOfRange {
+				report.Drifts = append(report.Drifts, fmt.Sprintf("%s: %s is %g, outside the expected %g to %g",
+					goldenName(golden), score.Score, score.Value, score.Expected.Min, score.Expected.Max))
+			}
+		} else {
+			comparison.Passed = comparison.WithinTolerance
+			if !comparison.Passed {
+				report.Drifts = append(report.Drifts, fmt.Sprintf("%s: scores moved by up to %g, more than the tolerance of %g",
+					goldenName(golden), comparison.MaxAbsDelta, tolerance))
+			}
+		}
+		if comparison.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+
+		report.Comparisons = append(report.Comparisons, comparison)
+	}
+
+	if deltaCount > 0 {
+		report.MeanAbsDelta = math.Round(totalAbsDelta/float64(deltaCount)*100) / 100
+	}
+
+	completedAt := time.Now()
+	report.CompletedAt = &completedAt
+	return report, nil
+}
+
+// goldenName names a golden job in drift messages by its label, or its source job
+func goldenName(golden *models.GoldenJob) string {
+	if golden.Label != "" {
+		return fmt.Sprintf("golden job %q", golden.Label)
+	}
+	return "golden job of " + golden.JobID
+}
+
+// outOfRange returns the scores of a result outside their expected ranges, sorted by name
+func outOfRange(expectedRanges map[string]models.ScoreRange, result *models.EvaluationResult) []models.OutOfRangeScore {
+	scores := resultScores(result)
+
+	var outside []models.OutOfRangeScore
+	for name, expected := range expectedRanges {
+		// A score the re-run no longer produces, e.g. a criterion dropped from the rubric, counts as 0
+		value := scores[name]
+		if value < expected.Min || value > expected.Max {
+			outside = append(outside, models.OutOfRangeScore{Score: name, Value: value, Expected: expected})
+		}
+	}
+
+	sort.Slice(outside, func(i, j int) bool { return outside[i].Score < outside[j].Score })
+	return outside
+}
+
+func (gs *GoldenService) rerun(ctx context.Context, golden *models.GoldenJob, replay bool) (*models.EvaluationResult, []string, error) {
+	job, err := gs.sourceJob(ctx, golden)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if replay {
+		return gs.evaluationService.ReplayContent(ctx, job)
+	}
+	result, err := gs.evaluationService.EvaluateContent(ctx, job)
+	return result, nil, err
+}
+
+// sourceJob returns the job a golden job re-runs: its snapshot, or for golden jobs added before snapshots
+// were taken, the live job as long as its documents are still there
+func (gs *GoldenService) sourceJob(ctx context.Context, golden *models.GoldenJob) (*models.EvaluationJob, error) {
+	if golden.Source != nil {
+		return golden.Source, nil
+	}
+
+	job, err := gs.repository.GetJobByID(ctx, golden.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source job: %w", err)
+	}
+	if job.ContentErasedAt != nil {
+		return nil, fmt.Errorf("the documents of source job %s were erased", golden.JobID)
+	}
+	return job, nil
+}
+
+// scoreDeltas returns current minus expected for every numeric score of a result
+func scoreDeltas(expected, current *models.EvaluationResult) map[string]float64 {
+	expectedScores := resultScores(expected)
+	currentScores := resultScores(current)
+
+	deltas := make(map[string]float64, len(expectedScores))
+	for name, value := range expectedScores {
+		deltas[name] = math.Round((currentScores[name]-value)*100) / 100
+	}
+
+	return deltas
+}
+
+// resultScores flattens the numeric scores of a result keyed by name
+func resultScores(result *models.EvaluationResult) map[string]float64 {
+	scores := map[string]float64{
+		"cv_match_rate":         result.CVMatchRate,
+		"project_score":         result.ProjectScore,
+		"overall_score":         result.OverallScore,
+		"cv.technical_skills":   result.CVScores.TechnicalSkills,
+		"cv.experience_level":   result.CVScores.ExperienceLevel,
+		"cv.achievements":       result.CVScores.Achievements,
+		"cv.cultural_fit":       result.CVScores.CulturalFit,
+		"project.correctness":   result.ProjectScores.Correctness,
+		"project.code_quality":  result.ProjectScores.CodeQuality,
+		"project.resilience":    result.ProjectScores.Resilience,
+		"project.documentation": result.ProjectScores.Documentation,
+		"project.creativity":    result.ProjectScores.Creativity,
+	}
+
+	// Custom rubric criteria have no dedicated field
+	for key, score := range result.CVCriteria {
+		scores["cv."+key] = score
+	}
+	for key, score := range result.ProjectCriteria {
+		scores["project."+key] = score
+	}
+
+	return scores
+}
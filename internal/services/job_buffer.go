@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	bufferedJobsKey      = "buffered_jobs"
+	bufferedJobKeyPrefix = "buffered_job:"
+)
+
+// JobBuffer holds evaluation jobs in Redis while the database is unavailable
+// and flushes them into the repository and queue once it reconnects
+type JobBuffer struct {
+	redisClient redis.UniversalClient
+	repository  repositories.Repository
+	jobQueue    *JobQueue
+	ttl         time.Duration
+
+	mu        sync.RWMutex
+	degraded  bool
+	lastError string
+}
+
+func NewJobBuffer(redisClient redis.UniversalClient, repository repositories.Repository, jobQueue *JobQueue, ttl time.Duration) *JobBuffer {
+	return &JobBuffer{
+		redisClient: redisClient,
+		repository:  repository,
+		jobQueue:    jobQueue,
+		ttl:         ttl,
+	}
+}
+
+// Buffer stores a job that could not be persisted; the job is dropped if not flushed within the TTL
+func (b *JobBuffer) Buffer(ctx context.Context, job *models.EvaluationJob, cause error) error {
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode buffered job: %w", err)
+	}
+
+	if err := b.redisClient.Set(ctx, bufferedJobKeyPrefix+job.ID.Hex(), payload, b.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to buffer job: %w", err)
+	}
+
+	if err := b.redisClient.RPush(ctx, bufferedJobsKey, job.ID.Hex()).Err(); err != nil {
+		return fmt.Errorf("failed to buffer job: %w", err)
+	}
+
+	b.setDegraded(true, cause)
+	log.Printf("Database unavailable, buffered job %s: %v", job.ID.Hex(), cause)
+	return nil
+}
+
+// Get returns a job that is still waiting in the buffer
+func (b *JobBuffer) Get(ctx context.Context, jobID string) (*models.EvaluationJob, error) {
+	payload, err := b.redisClient.Get(ctx, bufferedJobKeyPrefix+jobID).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var job models.EvaluationJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return nil, fmt.Errorf("failed to decode buffered job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// Status reports whether the service is degraded and how many jobs are buffered
+func (b *JobBuffer) Status(ctx context.Context) (bool, int64, string) {
+	buffered, _ := b.redisClient.LLen(ctx, bufferedJobsKey).Result()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.degraded || buffered > 0, buffered, b.lastError
+}
+
+// finalFlushTimeout bounds the flush FlushLoop makes when it is stopped
+const finalFlushTimeout = 10 * time.Second
+
+// FlushLoop periodically flushes buffered jobs once the database is reachable, until ctx is cancelled.
+// It flushes once more before returning, so callers close the repository only after it returned.
+func (b *JobBuffer) FlushLoop(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			// The loop's context is done, so the final flush gets its own
+			flushCtx, cancel := context.WithTimeout(context.Background(), finalFlushTimeout)
+			defer cancel()
+			if err := b.flush(flushCtx); err != nil {
+				log.Printf("Error flushing buffered jobs on shutdown: %v", err)
+			}
+			return
+		}
+
+		if err := b.flush(ctx); err != nil {
+			log.Printf("Error flushing buffered jobs: %v", err)
+		}
+	}
+}
+
+func (b *JobBuffer) flush(ctx context.Context) error {
+	buffered, err := b.redisClient.LLen(ctx, bufferedJobsKey).Result()
+	if err != nil || buffered == 0 {
+		return err
+	}
+
+	if err := b.repository.Ping(ctx); err != nil {
+		b.setDegraded(true, err)
+		return nil
+	}
+
+	for {
+		jobID, err := b.redisClient.LPop(ctx, bufferedJobsKey).Result()
+		if err == redis.Nil {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		job, err := b.Get(ctx, jobID)
+		if err == redis.Nil {
+			log.Printf("Buffered job %s expired before the database recovered", jobID)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = b.repository.CreateJob(ctx, job)
+		if errors.Is(err, repositories.ErrDuplicateIdempotencyKey) {
+			// The client retried with the same Idempotency-Key once the database was back
+			log.Printf("Dropped buffered job %s, its idempotency key was used by another job", jobID)
+			b.redisClient.Del(ctx, bufferedJobKeyPrefix+jobID)
+			continue
+		}
+		if err != nil {
+			// Put it back at the head and retry on the next tick
+			b.redisClient.LPush(ctx, bufferedJobsKey, jobID)
+			b.setDegraded(true, err)
+			return fmt.Errorf("failed to persist buffered job %s: %w", jobID, err)
+		}
+
+		b.redisClient.Del(ctx, bufferedJobKeyPrefix+jobID)
+
+		if err := b.jobQueue.AddJob(job); err != nil {
+			log.Printf("Error queueing flushed job %s: %v", jobID, err)
+		}
+
+		log.Printf("Flushed buffered job %s", jobID)
+	}
+
+	b.setDegraded(false, nil)
+	return nil
+}
+
+func (b *JobBuffer) setDegraded(degraded bool, cause error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.degraded = degraded
+	b.lastError = ""
+	if cause != nil {
+		b.lastError = cause.Error()
+	}
+}
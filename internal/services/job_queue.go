@@ -2,115 +2,443 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"runtime/debug"
+	"sync"
 	"time"
 
 	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/llm"
 	"ai-cv-summarize/internal/models"
 	"ai-cv-summarize/internal/repositories"
+	"ai-cv-summarize/internal/telemetry"
 
-	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type JobQueue struct {
-	redisClient       *redis.Client
-	repository        *repositories.MongoDBRepository
+	backend           QueueBackend
+	repository        repositories.Repository
 	evaluationService *EvaluationService
 	config            *config.Config
 }
 
-func NewJobQueue(redisClient *redis.Client, repository *repositories.MongoDBRepository, evaluationService *EvaluationService, config *config.Config) *JobQueue {
+func NewJobQueue(backend QueueBackend, repository repositories.Repository, evaluationService *EvaluationService, config *config.Config) *JobQueue {
 	return &JobQueue{
-		redisClient:       redisClient,
+		backend:           backend,
 		repository:        repository,
 		evaluationService: evaluationService,
 		config:            config,
 	}
 }
 
-// AddJob adds a job to the queue
-func (jq *JobQueue) AddJob(jobID string) error {
+// AddJob adds a job to the queue, or to the scheduled set when it should run later, and clears its
+// pending enqueue. When the push fails the job keeps its outbox entry and the dispatcher pushes it later,
+// so the error need not fail the request.
+func (jq *JobQueue) AddJob(job *models.EvaluationJob) error {
 	ctx := context.Background()
+	jobID := job.ID.Hex()
 
-	// Add job to Redis queue
-	return jq.redisClient.LPush(ctx, "evaluation_queue", jobID).Err()
+	// Add job to queue backend
+	if err := jq.enqueue(ctx, job); err != nil {
+		return err
+	}
+
+	if err := jq.repository.MarkJobEnqueued(ctx, jobID); err != nil {
+		// The dispatcher pushes the job again; the duplicate delivery finds the job no longer queued and is skipped
+		log.Printf("Error marking job %s enqueued: %v", jobID, err)
+	}
+	return nil
 }
 
-// ProcessJobs processes jobs from the queue
-func (jq *JobQueue) ProcessJobs() {
-	ctx := context.Background()
+// enqueue pushes a job to the queue, or schedules it when its RunAt is still ahead
+func (jq *JobQueue) enqueue(ctx context.Context, job *models.EvaluationJob) error {
+	if job.RunAt != nil && job.RunAt.After(time.Now()) {
+		return jq.backend.Schedule(ctx, job.ID.Hex(), *job.RunAt)
+	}
+	return jq.backend.Push(ctx, job.ID.Hex())
+}
+
+// ProcessJobs starts the worker pool and processes jobs from the queue until ctx is cancelled.
+// It returns once every worker has finished its current job.
+func (jq *JobQueue) ProcessJobs(ctx context.Context) {
+	workers := jq.workerCount()
+	log.Printf("Starting %d job workers", workers)
+
+	var wg sync.WaitGroup
+	for i := 1; i <= workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			jq.runWorker(ctx, worker)
+		}(i)
+	}
+	wg.Wait()
+	log.Println("Job workers stopped")
+}
+
+// workerCount returns the configured number of workers, at least one
+func (jq *JobQueue) workerCount() int {
+	if jq.config.JobQueue.Concurrency < 1 {
+		return 1
+	}
+	return jq.config.JobQueue.Concurrency
+}
+
+// runWorker pops and processes jobs until the context is cancelled
+func (jq *JobQueue) runWorker(ctx context.Context, worker int) {
+	// A job that has been popped runs to completion even during shutdown
+	jobCtx := context.WithoutCancel(ctx)
 
 	for {
 		// Block and wait for job
-		result, err := jq.redisClient.BRPop(ctx, 0, "evaluation_queue").Result()
+		jobID, err := jq.backend.Pop(ctx)
 		if err != nil {
-			log.Printf("Error waiting for job: %v", err)
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		if len(result) < 2 {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Worker %d: error waiting for job: %v", worker, err)
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+				return
+			}
 			continue
 		}
 
-		jobID := result[1]
-		log.Printf("Processing job: %s", jobID)
+		log.Printf("Worker %d: processing job: %s", worker, jobID)
 
 		// Process the job
-		if err := jq.processJob(ctx, jobID); err != nil {
-			log.Printf("Error processing job %s: %v", jobID, err)
+		if err := jq.safeProcessJob(jobCtx, jobID); err != nil {
+			log.Printf("Worker %d: error processing job %s: %v", worker, jobID, err)
 
 			// Increment retry count
-			if err := jq.repository.IncrementRetryCount(ctx, jobID); err != nil {
+			if err := jq.repository.IncrementRetryCount(jobCtx, jobID); err != nil {
 				log.Printf("Error incrementing retry count for job %s: %v", jobID, err)
 			}
 		}
+
+		// The job is finished either way; failures are recorded on the job rather than redelivered
+		if err := jq.backend.Ack(jobCtx, jobID); err != nil {
+			log.Printf("Worker %d: error acking job %s: %v", worker, jobID, err)
+		}
 	}
 }
 
-// processJob processes a single job
-func (jq *JobQueue) processJob(ctx context.Context, jobID string) error {
+// safeProcessJob runs processJob and turns a panic into a failed job so one bad job cannot take down its worker
+func (jq *JobQueue) safeProcessJob(ctx context.Context, jobID string) (err error) {
+	// Set by processJob once it claims the job; a panic before that leaves the job to its next delivery
+	var claimToken string
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered from panic processing job %s: %v\n%s", jobID, r, debug.Stack())
+			if claimToken != "" {
+				if updateErr := jq.repository.UpdateJobError(ctx, jobID, claimToken, models.ErrorTypeInternal, fmt.Sprintf("internal error: %v", r)); updateErr != nil {
+					log.Printf("Error updating job error: %v", updateErr)
+				}
+			}
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return jq.processJob(ctx, jobID, &claimToken)
+}
+
+// processJob processes a single job, storing its claim token in claimToken once claimed
+func (jq *JobQueue) processJob(ctx context.Context, jobID string, claimToken *string) (err error) {
 	// Get job from database
 	job, err := jq.repository.GetJobByID(ctx, jobID)
 	if err != nil {
 		return fmt.Errorf("failed to get job: %w", err)
 	}
 
+	// Continue the trace of the request that created the job across the queue
+	ctx, span := telemetry.Start(telemetry.WithTraceParent(ctx, job.TraceParent), "job.process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("job.id", jobID),
+			attribute.Int("job.retry_count", job.RetryCount),
+			attribute.String("queue.backend", jq.backend.Name()),
+		),
+	)
+	defer func() { telemetry.End(span, err) }()
+
 	// Check if job is already completed or failed
 	if job.Status == models.StatusCompleted || job.Status == models.StatusFailed {
 		return nil
 	}
 
+	// Claim the job; a duplicate delivery, or a job another worker claimed first, is left alone
+	claim, err := jq.repository.ClaimJob(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			log.Printf("Job %s is no longer queued, skipping", jobID)
+			return nil
+		}
+		return fmt.Errorf("failed to claim job: %w", err)
+	}
+	*claimToken = claim
+
 	// Check retry count
 	if job.RetryCount >= jq.config.JobQueue.MaxRetries {
-		return jq.repository.UpdateJobError(ctx, jobID, "Max retries exceeded")
+		return jq.repository.UpdateJobError(ctx, jobID, claim, models.ErrorTypeInternal, "Max retries exceeded")
 	}
 
-	// Update status to processing
-	if err := jq.repository.UpdateJobStatus(ctx, jobID, models.StatusProcessing); err != nil {
-		return fmt.Errorf("failed to update job status: %w", err)
+	// Bound the evaluation by the job timeout so the reaper never requeues a job a live worker still owns
+	evalCtx := ctx
+	if jq.config.JobQueue.Timeout > 0 {
+		var cancel context.CancelFunc
+		evalCtx, cancel = context.WithTimeout(ctx, jq.config.JobQueue.Timeout)
+		defer cancel()
 	}
 
 	// Run real AI evaluation using evaluation service
-	if err := jq.evaluationService.EvaluateCandidate(ctx, jobID); err != nil {
-		// Update job with error
-		if updateErr := jq.repository.UpdateJobError(ctx, jobID, err.Error()); updateErr != nil {
+	if err := jq.evaluationService.EvaluateCandidate(evalCtx, jobID, claim); err != nil {
+		// Update job with error; ctx outlives the deadline, so a timed-out job can still be recorded
+		errorType, message := classifyFailure(evalCtx, err, jq.config.JobQueue.Timeout)
+		span.SetAttributes(attribute.String("error.type", string(errorType)))
+		// A job requeued, deleted or erased while it ran is no longer ours to fail
+		if updateErr := jq.repository.UpdateJobError(ctx, jobID, claim, errorType, message); updateErr != nil && !errors.Is(updateErr, repositories.ErrNotFound) {
 			log.Printf("Error updating job error: %v", updateErr)
 		}
-		return fmt.Errorf("evaluation failed: %w", err)
+		return fmt.Errorf("evaluation failed (%s): %w", errorType, err)
 	}
 
 	log.Printf("Job %s completed successfully", jobID)
 	return nil
 }
 
+// classifyFailure tells an evaluation that ran past its deadline from provider and other errors, and
+// returns the error type and message to record on the job
+func classifyFailure(evalCtx context.Context, err error, timeout time.Duration) (models.ErrorType, string) {
+	switch {
+	case errors.Is(evalCtx.Err(), context.DeadlineExceeded):
+		return models.ErrorTypeTimeout, fmt.Sprintf("Evaluation timed out after %s", timeout)
+	case llm.IsProviderError(err) || errors.Is(err, context.DeadlineExceeded):
+		// A deadline other than the job's is a provider request timing out
+		return models.ErrorTypeProvider, err.Error()
+	default:
+		return models.ErrorTypeEvaluation, err.Error()
+	}
+}
+
+// ReapStuckJobs periodically requeues jobs left in processing past the job timeout, e.g. after a crash,
+// until ctx is cancelled
+func (jq *JobQueue) ReapStuckJobs(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || jq.config.JobQueue.Timeout <= 0 {
+		log.Println("Stuck-job reaper disabled")
+		return
+	}
+
+	for {
+		if err := jq.reapStuckJobs(ctx); err != nil {
+			log.Printf("Error reaping stuck jobs: %v", err)
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reapStuckJobs requeues every stuck job, or fails it once it has used up its retries
+func (jq *JobQueue) reapStuckJobs(ctx context.Context) error {
+	jobs, err := jq.repository.GetStuckJobs(ctx, time.Now().Add(-jq.config.JobQueue.Timeout))
+	if err != nil {
+		return fmt.Errorf("failed to find stuck jobs: %w", err)
+	}
+
+	for _, job := range jobs {
+		jobID := job.ID.Hex()
+
+		if job.RetryCount+1 >= jq.config.JobQueue.MaxRetries {
+			log.Printf("Job %s stuck in processing, max retries exceeded", jobID)
+			// Failed under the claim it was found with, so a worker finishing it meanwhile wins
+			if err := jq.repository.UpdateJobError(ctx, jobID, job.ClaimToken, models.ErrorTypeTimeout, "Job timed out: max retries exceeded"); err != nil && !errors.Is(err, repositories.ErrNotFound) {
+				log.Printf("Error failing stuck job %s: %v", jobID, err)
+			}
+			continue
+		}
+
+		if err := jq.repository.RequeueStuckJob(ctx, jobID); err != nil {
+			if err != repositories.ErrNotFound {
+				log.Printf("Error requeueing stuck job %s: %v", jobID, err)
+			}
+			continue
+		}
+
+		if err := jq.backend.Push(ctx, jobID); err != nil {
+			log.Printf("Error re-enqueueing stuck job %s: %v", jobID, err)
+			continue
+		}
+
+		log.Printf("Requeued job %s stuck in processing since %s", jobID, job.StartedAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// DispatchOutbox periodically pushes jobs whose enqueue is still pending, e.g. because the queue backend
+// was unreachable when they were created, until ctx is cancelled
+func (jq *JobQueue) DispatchOutbox(ctx context.Context) {
+	interval := jq.config.JobQueue.DispatchInterval
+	if interval <= 0 {
+		log.Println("Queue outbox dispatcher disabled")
+		return
+	}
+
+	for {
+		if err := jq.dispatchOutbox(ctx); err != nil {
+			log.Printf("Error dispatching queue outbox: %v", err)
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatchOutbox pushes every job left undispatched for longer than the dispatch interval, which gives
+// the request that created a job time to push it first
+func (jq *JobQueue) dispatchOutbox(ctx context.Context) error {
+	jobs, err := jq.repository.GetUndispatchedJobs(ctx, time.Now().Add(-jq.config.JobQueue.DispatchInterval))
+	if err != nil {
+		return fmt.Errorf("failed to find undispatched jobs: %w", err)
+	}
+
+	for _, job := range jobs {
+		jobID := job.ID.Hex()
+		if err := jq.enqueue(ctx, job); err != nil {
+			// The backend is most likely down; the remaining jobs wait for the next round
+			return fmt.Errorf("failed to enqueue job %s: %w", jobID, err)
+		}
+		if err := jq.repository.MarkJobEnqueued(ctx, jobID); err != nil {
+			log.Printf("Error marking job %s enqueued: %v", jobID, err)
+			continue
+		}
+		log.Printf("Dispatched job %s from the queue outbox", jobID)
+	}
+
+	return nil
+}
+
+// SweepLostJobs periodically re-enqueues queued jobs that are missing from the queue backend, e.g. after
+// the memory queue was lost in a restart or Redis data was flushed, until ctx is cancelled
+func (jq *JobQueue) SweepLostJobs(ctx context.Context) {
+	interval := jq.config.JobQueue.SweepInterval
+	if interval <= 0 {
+		log.Println("Lost-job sweeper disabled")
+		return
+	}
+
+	for {
+		if err := jq.sweepLostJobs(ctx); err != nil {
+			log.Printf("Error sweeping lost jobs: %v", err)
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sweepLostJobs pushes every dispatched job that has been queued, untouched, for longer than the sweep
+// interval and is not in the queue backend
+func (jq *JobQueue) sweepLostJobs(ctx context.Context) error {
+	jobs, err := jq.repository.GetPendingJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find pending jobs: %w", err)
+	}
+
+	cutoff := time.Now().Add(-jq.config.JobQueue.SweepInterval)
+	var candidates []*models.EvaluationJob
+	for _, job := range jobs {
+		// Jobs with a pending enqueue belong to the dispatcher, and sandbox jobs are never queued
+		if job.Status == models.StatusQueued && !job.EnqueuePending && !job.Sandbox && job.UpdatedAt.Before(cutoff) {
+			candidates = append(candidates, job)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// Read the queue after the jobs, so a job popped in between is no longer queued in the database
+	queued, err := jq.backend.JobIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list queued jobs: %w", err)
+	}
+	inQueue := make(map[string]bool, len(queued))
+	for _, jobID := range queued {
+		inQueue[jobID] = true
+	}
+
+	for _, job := range candidates {
+		jobID := job.ID.Hex()
+		if inQueue[jobID] {
+			continue
+		}
+
+		if err := jq.enqueue(ctx, job); err != nil {
+			return fmt.Errorf("failed to re-enqueue job %s: %w", jobID, err)
+		}
+		log.Printf("Re-enqueued job %s, queued since %s but missing from the queue", jobID, job.UpdatedAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// RunScheduler periodically moves scheduled jobs that are due onto the queue until ctx is cancelled
+func (jq *JobQueue) RunScheduler(ctx context.Context) {
+	interval := jq.config.JobQueue.SchedulerInterval
+	if interval <= 0 {
+		log.Println("Job scheduler disabled")
+		return
+	}
+
+	for {
+		// Drain every due job before waiting, a batch of them may come due at once
+		for {
+			promoted, err := jq.backend.PromoteDue(ctx, time.Now())
+			if err != nil {
+				log.Printf("Error promoting scheduled jobs: %v", err)
+				break
+			}
+			if promoted > 0 {
+				log.Printf("Queued %d scheduled jobs", promoted)
+			}
+			if promoted < scheduledPromoteBatch {
+				break
+			}
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // GetQueueStatus returns the current queue status
 func (jq *JobQueue) GetQueueStatus() (map[string]interface{}, error) {
 	ctx := context.Background()
 
 	// Get queue length
-	queueLength, err := jq.redisClient.LLen(ctx, "evaluation_queue").Result()
+	queueLength, err := jq.backend.Len(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled, err := jq.backend.ScheduledLen(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -123,31 +451,29 @@ func (jq *JobQueue) GetQueueStatus() (map[string]interface{}, error) {
 
 	return map[string]interface{}{
 		"queue_length": queueLength,
+		"scheduled":    scheduled,
 		"pending_jobs": len(pendingJobs),
 		"status":       "running",
+		"workers":      jq.workerCount(),
+		"backend":      jq.backend.Name(),
 	}, nil
 }
 
 // ClearQueue clears all jobs from the queue
 func (jq *JobQueue) ClearQueue() error {
 	ctx := context.Background()
-	return jq.redisClient.Del(ctx, "evaluation_queue").Err()
+	return jq.backend.Clear(ctx)
 }
 
 // GetJobFromQueue retrieves a job from the queue without removing it
 func (jq *JobQueue) GetJobFromQueue() (string, error) {
 	ctx := context.Background()
 
-	result, err := jq.redisClient.LIndex(ctx, "evaluation_queue", -1).Result()
-	if err != nil {
-		return "", err
-	}
-
-	return result, nil
+	return jq.backend.Peek(ctx)
 }
 
 // RemoveJobFromQueue removes a job from the queue
 func (jq *JobQueue) RemoveJobFromQueue(jobID string) error {
 	ctx := context.Background()
-	return jq.redisClient.LRem(ctx, "evaluation_queue", 0, jobID).Err()
+	return jq.backend.Remove(ctx, jobID)
 }
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/llm"
+	"ai-cv-summarize/internal/models"
+)
+
+// judgeVerdict is the verify_evaluation response
+type judgeVerdict struct {
+	Consistent      bool               `json:"consistent"`
+	Issues          []string           `json:"issues"`
+	CVScores        map[string]float64 `json:"cv_scores"`
+	ProjectScores   map[string]float64 `json:"project_scores"`
+	CVFeedback      string             `json:"cv_feedback"`
+	ProjectFeedback string             `json:"project_feedback"`
+}
+
+// pipelineSteps lists the steps tracked on a job, including verification when the judge is enabled
+func (es *EvaluationService) pipelineSteps() []string {
+	if !es.config.Judge.Enabled {
+		return models.PipelineSteps
+	}
+
+	steps := make([]string, 0, len(models.PipelineSteps)+1)
+	for _, name := range models.PipelineSteps {
+		if name == models.StepSummary {
+			steps = append(steps, models.StepVerify)
+		}
+		steps = append(steps, name)
+	}
+	return steps
+}
+
+// verifyEvaluation has the judge model review both evaluations against their rubrics. In correct mode the
+// judge's corrected scores and feedback replace the original ones and the totals are recalculated. Corrected
+// feedback keeps the job's feedback style.
+func (es *EvaluationService) verifyEvaluation(ctx context.Context, cvEval *CVEvaluation, projectEval *ProjectEvaluation, cvRubric, projectRubric *models.ScoringRubric, feedback feedbackStyle) (*models.EvaluationReview, error) {
+	data := PromptData{
+		CVEvaluation:      cvEval,
+		ProjectEvaluation: projectEval,
+		CVCriteria:        promptCriteria(cvRubric),
+		ProjectCriteria:   promptCriteria(projectRubric),
+	}
+	feedback.apply(&data)
+	prompt, err := es.promptService.Render(ctx, PromptVerify, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var verdict judgeVerdict
+	if _, err := llm.GenerateJSON(ctx, es.judgeClient, prompt.Text, verifySchema, prompt.Temperature(0), es.config.JobQueue.MaxRetries, &verdict); err != nil {
+		return nil, fmt.Errorf("failed to parse evaluation review: %w", err)
+	}
+
+	review := &models.EvaluationReview{
+		Consistent: verdict.Consistent && len(verdict.Issues) == 0,
+		Issues:     verdict.Issues,
+	}
+	if review.Consistent || es.config.Judge.Mode != config.JudgeModeCorrect {
+		return review, nil
+	}
+
+	cvCorrected := correctScores(cvEval.Criteria, verdict.CVScores, cvRubric, cvEval.setScore)
+	if feedback := strings.TrimSpace(verdict.CVFeedback); feedback != "" {
+		cvEval.Feedback = feedback
+		cvCorrected = true
+	}
+	if cvCorrected {
+		es.scoreCV(cvEval, cvRubric)
+	}
+
+	projectCorrected := correctScores(projectEval.Criteria, verdict.ProjectScores, projectRubric, projectEval.setScore)
+	if feedback := strings.TrimSpace(verdict.ProjectFeedback); feedback != "" {
+		projectEval.Feedback = feedback
+		projectCorrected = true
+	}
+	if projectCorrected {
+		es.scoreProject(projectEval, projectRubric)
+	}
+
+	review.Corrected = cvCorrected || projectCorrected
+	return review, nil
+}
+
+// correctScores applies the judge's corrected scores for the rubric's criteria, clamped to each criterion's
+// scale, and reports whether any score changed. Keys the rubric does not know are ignored.
+func correctScores(scores, corrections map[string]float64, rubric *models.ScoringRubric, set func(key string, score float64)) bool {
+	changed := false
+	for _, criterion := range rubric.Criteria {
+		key := criterionKey(criterion)
+		score, ok := corrections[key]
+		if !ok {
+			continue
+		}
+
+		maxScore := criterion.MaxScore
+		if maxScore <= 0 {
+			maxScore = defaultMaxScore
+		}
+		if score < 1 {
+			score = 1
+		}
+		if score > maxScore {
+			score = maxScore
+		}
+
+		if scores[key] != score {
+			set(key, score)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// needsReview reports whether a result should be checked by a person: the judge could not verify it,
+// or found inconsistencies it did not correct
+func needsReview(review *models.EvaluationReview) bool {
+	if review == nil {
+		return false
+	}
+	return review.Error != "" || (!review.Consistent && !review.Corrected)
+}
+
+// setScore updates a criterion score, keeping the legacy score fields in sync
+func (e *CVEvaluation) setScore(key string, score float64) {
+	e.Criteria[key] = score
+	switch key {
+	case "technical_skills":
+		e.TechnicalSkills = score
+	case "experience_level":
+		e.ExperienceLevel = score
+	case "achievements":
+		e.Achievements = score
+	case "cultural_fit":
+		e.CulturalFit = score
+	}
+}
+
+// setScore updates a criterion score, keeping the legacy score fields in sync
+func (e *ProjectEvaluation) setScore(key string, score float64) {
+	e.Criteria[key] = score
+	switch key {
+	case "correctness":
+		e.Correctness = score
+	case "code_quality":
+		e.CodeQuality = score
+	case "resilience":
+		e.Resilience = score
+	case "documentation":
+		e.Documentation = score
+	case "creativity":
+		e.Creativity = score
+	}
+}
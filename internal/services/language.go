@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/llm"
+)
+
+const (
+	// LanguageUndetermined is reported when a text is too short or too ambiguous to classify
+	LanguageUndetermined = "und"
+	// LanguageNoContent is reported for text that does not look like natural language, such as garbled PDF extraction
+	LanguageNoContent = "zxx"
+)
+
+// ErrLanguageUnsupported is wrapped by every LanguageError so callers can match it with errors.Is
+var ErrLanguageUnsupported = errors.New("LANGUAGE_UNSUPPORTED")
+
+// LanguageError reports a document written in a language the evaluation prompts do not support
+type LanguageError struct {
+	Document string
+	Language string
+}
+
+func (e *LanguageError) Error() string {
+	if e.Language == LanguageNoContent {
+		return fmt.Sprintf("%s does not contain readable text in a recognizable language", e.Document)
+	}
+	return fmt.Sprintf("%s is written in an unsupported language (%s)", e.Document, e.Language)
+}
+
+func (e *LanguageError) Unwrap() error {
+	return ErrLanguageUnsupported
+}
+
+// languageStopwords holds frequent, fairly distinctive function words for the Latin-script languages we recognize
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "with", "for", "to", "is", "was", "on", "as", "at", "by", "from", "this", "that"},
+	"id": {"dan", "yang", "dengan", "untuk", "dari", "ini", "pada", "ke", "sebagai", "dalam", "adalah", "tidak", "akan", "saya", "telah"},
+	"es": {"el", "los", "las", "del", "y", "con", "para", "por", "que", "una", "como", "más", "sus", "fue", "también"},
+	"fr": {"le", "les", "et", "des", "du", "pour", "avec", "une", "dans", "est", "sur", "au", "aux", "qui", "pas"},
+	"de": {"und", "der", "die", "das", "mit", "für", "von", "den", "ist", "auf", "ein", "eine", "nicht", "auch", "bei"},
+	"pt": {"os", "das", "do", "da", "em", "com", "uma", "não", "para", "dos", "pelo", "como", "mais", "foi", "são"},
+	"nl": {"het", "een", "van", "met", "voor", "op", "zijn", "niet", "ook", "bij", "naar", "werd", "door", "maar", "deze"},
+	"it": {"il", "della", "che", "per", "con", "del", "gli", "sono", "una", "nella", "alla", "anche", "come", "più", "delle"},
+}
+
+const (
+	// minLanguageLetters is the amount of text below which detection is not attempted
+	minLanguageLetters = 50
+	// minGibberishWords is the word count above which Latin text without a single stopword is treated as gibberish
+	minGibberishWords = 40
+)
+
+// DetectLanguage returns an ISO 639-1 code for the dominant language of text, or LanguageUndetermined.
+// Non-Latin scripts are mapped to their most common language; Latin-script text is classified by stopword frequency.
+func DetectLanguage(text string) string {
+	scripts := map[string]int{}
+	letters, visible := 0, 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			visible++
+		}
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			scripts["latin"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["ru"]++
+		case unicode.Is(unicode.Greek, r):
+			scripts["el"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			scripts["he"]++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			scripts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			scripts["zh"]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts["ko"]++
+		case unicode.Is(unicode.Thai, r):
+			scripts["th"]++
+		case unicode.Is(unicode.Devanagari, r):
+			scripts["hi"]++
+		}
+	}
+	if letters < minLanguageLetters {
+		return LanguageUndetermined
+	}
+	// Mostly symbols and digits usually means binary or mis-decoded content
+	if letters*10 < visible*4 {
+		return LanguageNoContent
+	}
+
+	// Japanese mixes kana with Han characters, so any significant kana share wins
+	if scripts["ja"]*10 >= letters {
+		return "ja"
+	}
+
+	dominant, dominantCount := "", 0
+	for script, count := range scripts {
+		if count > dominantCount {
+			dominant, dominantCount = script, count
+		}
+	}
+	if dominantCount*2 < letters {
+		return LanguageUndetermined
+	}
+	if dominant != "latin" {
+		return dominant
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	counts := map[string]int{}
+	for _, word := range words {
+		for lang, stopwords := range languageStopwords {
+			for _, stopword := range stopwords {
+				if word == stopword {
+					counts[lang]++
+				}
+			}
+		}
+	}
+
+	if len(counts) == 0 && len(words) >= minGibberishWords {
+		return LanguageNoContent
+	}
+
+	best, bestCount, runnerUp := LanguageUndetermined, 0, 0
+	for lang, count := range counts {
+		if count > bestCount {
+			best, bestCount, runnerUp = lang, count, bestCount
+		} else if count > runnerUp {
+			runnerUp = count
+		}
+	}
+
+	// Require a handful of hits and a clear margin before committing to a language
+	if bestCount < 3 || bestCount < runnerUp*3/2 {
+		return LanguageUndetermined
+	}
+
+	return best
+}
+
+// LanguageService rejects documents in unsupported languages or translates them when translation is enabled
+type LanguageService struct {
+	llmClient     llm.LLMClient
+	promptService *PromptService
+	config        *config.Config
+	supported     map[string]bool
+}
+
+func NewLanguageService(llmClient llm.LLMClient, promptService *PromptService, config *config.Config) *LanguageService {
+	supported := make(map[string]bool, len(config.Language.Supported))
+	for _, lang := range config.Language.Supported {
+		supported[lang] = true
+	}
+
+	return &LanguageService{
+		llmClient:     llmClient,
+		promptService: promptService,
+		config:        config,
+		supported:     supported,
+	}
+}
+
+// IsSupported reports whether lang can be evaluated without translation. Undetermined text is
+// let through, since short or list-like CVs often carry too few words to classify.
+func (ls *LanguageService) IsSupported(lang string) bool {
+	return lang == LanguageUndetermined || ls.supported[lang]
+}
+
+// Check fails fast with a LanguageError when text cannot be evaluated
+func (ls *LanguageService) Check(document, text string) error {
+	if ls.config.Language.TranslationEnabled {
+		return nil
+	}
+
+	if lang := DetectLanguage(text); !ls.IsSupported(lang) {
+		return &LanguageError{Document: document, Language: lang}
+	}
+
+	return nil
+}
+
+// Prepare returns text ready for evaluation, translating it to English when it is in an
+// unsupported language and translation is enabled
+func (ls *LanguageService) Prepare(ctx context.Context, document, text string) (string, error) {
+	lang := DetectLanguage(text)
+	if ls.IsSupported(lang) {
+		return text, nil
+	}
+	if !ls.config.Language.TranslationEnabled {
+		return "", &LanguageError{Document: document, Language: lang}
+	}
+
+	prompt, err := ls.promptService.Render(ctx, PromptTranslate, PromptData{
+		Document: NeutralizeDocument(text),
+		Language: lang,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	translation, err := ls.llmClient.GenerateCompletionWithRetry(ctx, prompt.Text, prompt.Temperature(0), ls.config.JobQueue.MaxRetries)
+	if err != nil {
+		return "", fmt.Errorf("failed to translate %s: %w", document, err)
+	}
+
+	return translation, nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQueueEmpty is returned by Peek when no job is queued
+var ErrQueueEmpty = errors.New("queue is empty")
+
+// MemoryQueueBackend is an in-process queue for local development and small deployments.
+// It is single-instance only: queued and scheduled jobs are not shared between servers, and are lost on restart
+// until the lost-job sweeper queues them again.
+type MemoryQueueBackend struct {
+	mu     sync.Mutex
+	items  []string
+	notify chan struct{}
+	// scheduled maps delayed job IDs to their run time
+	scheduled map[string]time.Time
+}
+
+func NewMemoryQueueBackend() *MemoryQueueBackend {
+	return &MemoryQueueBackend{
+		notify:    make(chan struct{}, 1),
+		scheduled: make(map[string]time.Time),
+	}
+}
+
+func (b *MemoryQueueBackend) Push(ctx context.Context, jobID string) error {
+	b.mu.Lock()
+	b.items = append(b.items, jobID)
+	b.mu.Unlock()
+
+	// Wake up a waiting Pop without blocking if one is already pending
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (b *MemoryQueueBackend) Schedule(ctx context.Context, jobID string, runAt time.Time) error {
+	b.mu.Lock()
+	b.scheduled[jobID] = runAt
+	b.mu.Unlock()
+
+	return nil
+}
+
+func (b *MemoryQueueBackend) PromoteDue(ctx context.Context, now time.Time) (int, error) {
+	b.mu.Lock()
+	var due []string
+	for jobID, runAt := range b.scheduled {
+		if !runAt.After(now) {
+			due = append(due, jobID)
+			delete(b.scheduled, jobID)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, jobID := range due {
+		b.Push(ctx, jobID)
+	}
+	return len(due), nil
+}
+
+func (b *MemoryQueueBackend) Pop(ctx context.Context) (string, error) {
+	for {
+		b.mu.Lock()
+		if len(b.items) > 0 {
+			jobID := b.items[0]
+			b.items = b.items[1:]
+			remaining := len(b.items)
+			b.mu.Unlock()
+
+			// Pass the wake-up on to other waiters while items remain
+			if remaining > 0 {
+				select {
+				case b.notify <- struct{}{}:
+				default:
+				}
+			}
+
+			return jobID, nil
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-b.notify:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+func (b *MemoryQueueBackend) Peek(ctx context.Context) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.items) == 0 {
+		return "", ErrQueueEmpty
+	}
+
+	return b.items[0], nil
+}
+
+// Ack is a no-op: popped jobs are not tracked, so a job interrupted by a restart is recovered by the reaper
+func (b *MemoryQueueBackend) Ack(ctx context.Context, jobID string) error {
+	return nil
+}
+
+func (b *MemoryQueueBackend) Remove(ctx context.Context, jobID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	kept := b.items[:0]
+	for _, item := range b.items {
+		if item != jobID {
+			kept = append(kept, item)
+		}
+	}
+	b.items = kept
+	delete(b.scheduled, jobID)
+
+	return nil
+}
+
+// JobIDs returns the queued and scheduled job IDs; popped jobs are not tracked
+func (b *MemoryQueueBackend) JobIDs(ctx context.Context) ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ids := append([]string(nil), b.items...)
+	for jobID := range b.scheduled {
+		ids = append(ids, jobID)
+	}
+	return ids, nil
+}
+
+func (b *MemoryQueueBackend) Len(ctx context.Context) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return int64(len(b.items)), nil
+}
+
+func (b *MemoryQueueBackend) ScheduledLen(ctx context.Context) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return int64(len(b.scheduled)), nil
+}
+
+func (b *MemoryQueueBackend) Clear(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.items = nil
+	b.scheduled = make(map[string]time.Time)
+
+	return nil
+}
+
+func (b *MemoryQueueBackend) Name() string {
+	return "memory"
+}
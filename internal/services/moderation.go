@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"ai-cv-summarize/internal/config"
+)
+
+// Moderation providers, see config.ModerationConfig
+const (
+	ModerationNone   = "none"
+	ModerationLocal  = "local"
+	ModerationOpenAI = "openai"
+)
+
+// ErrContentRejected is wrapped by every ModerationError so callers can match it with errors.Is
+var ErrContentRejected = errors.New("CONTENT_REJECTED")
+
+// ModerationError reports a document that contains content the service refuses to evaluate
+type ModerationError struct {
+	Document   string
+	Categories []string
+}
+
+func (e *ModerationError) Error() string {
+	return fmt.Sprintf("%s contains disallowed content (%s)", e.Document, strings.Join(e.Categories, ", "))
+}
+
+func (e *ModerationError) Unwrap() error {
+	return ErrContentRejected
+}
+
+// Moderator classifies text with a remote moderation model, returning the categories it was flagged for
+type Moderator interface {
+	Moderate(ctx context.Context, text string) ([]string, error)
+}
+
+// moderationRules are the built-in local rules. They only match unambiguous threats and incitement, since
+// a CV may legitimately mention violence or abuse, e.g. in trust and safety work.
+var moderationRules = []struct {
+	category string
+	pattern  *regexp.Regexp
+}{
+	{"violence", regexp.MustCompile(`(?i)\b(?:i|we)(?:'ll| will| am going to| are going to| am gonna| are gonna)\s+(?:kill|murder|shoot|stab|hurt)\s+(?:you|them|him|her|everyone|everybody|your)\b`)},
+	{"self-harm", regexp.MustCompile(`(?i)\b(?:you should|go|just) kill yourself\b|\bi (?:will|am going to|want to) kill myself\b`)},
+}
+
+// ModerationService rejects documents with disallowed content before they reach the scoring prompts
+type ModerationService struct {
+	provider     string
+	remote       Moderator
+	blockedTerms *regexp.Regexp
+}
+
+// NewModerationService returns the moderation check for the configured provider. remote is only used
+// with the openai provider and may be nil, e.g. for sandbox evaluations, leaving the local rules.
+func NewModerationService(cfg *config.Config, remote Moderator) *ModerationService {
+	ms := &ModerationService{provider: cfg.Moderation.Provider}
+	if ms.provider == ModerationOpenAI {
+		ms.remote = remote
+	}
+
+	if len(cfg.Moderation.BlockedTerms) > 0 {
+		terms := make([]string, len(cfg.Moderation.BlockedTerms))
+		for i, term := range cfg.Moderation.BlockedTerms {
+			terms[i] = regexp.QuoteMeta(term)
+		}
+		ms.blockedTerms = regexp.MustCompile(`(?i)\b(?:` + strings.Join(terms, "|") + `)\b`)
+	}
+
+	return ms
+}
+
+// Enabled reports whether documents are checked at all
+func (ms *ModerationService) Enabled() bool {
+	return ms != nil && ms.provider != "" && ms.provider != ModerationNone
+}
+
+// Check fails fast with a ModerationError when text contains disallowed content. Remote failures are
+// logged and fall back to the local rules, so a moderation outage does not stop evaluations.
+func (ms *ModerationService) Check(ctx context.Context, document, text string) error {
+	if !ms.Enabled() || strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	categories := ms.localCategories(text)
+	if ms.remote != nil {
+		flagged, err := ms.remote.Moderate(ctx, text)
+		if err != nil {
+			log.Printf("Warning: moderation check of %s failed, using local rules only: %v", document, err)
+		}
+		for _, category := range flagged {
+			categories = appendUnique(categories, category)
+		}
+	}
+
+	if len(categories) > 0 {
+		return &ModerationError{Document: document, Categories: categories}
+	}
+	return nil
+}
+
+// localCategories returns the categories the built-in rules and the configured blocked terms match
+func (ms *ModerationService) localCategories(text string) []string {
+	var categories []string
+	for _, rule := range moderationRules {
+		if rule.pattern.MatchString(text) {
+			categories = appendUnique(categories, rule.category)
+		}
+	}
+	if ms.blockedTerms != nil && ms.blockedTerms.MatchString(text) {
+		categories = appendUnique(categories, "blocked_term")
+	}
+	return categories
+}
+
+// appendUnique appends value to values unless it is already present
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
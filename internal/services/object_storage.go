@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-cv-summarize/internal/config"
+)
+
+// ErrInvalidUploadID is returned for upload IDs that were not issued by PresignUpload
+var ErrInvalidUploadID = errors.New("invalid upload ID")
+
+// ErrUploadNotFound is returned when nothing was uploaded to a presigned URL, or it has been confirmed already
+var ErrUploadNotFound = errors.New("upload not found")
+
+// uploadIDPattern matches the object keys PresignUpload issues: the prefix, a random directory and the file name
+var uploadIDPattern = regexp.MustCompile(`^[0-9a-f]{32}/[^/]+$`)
+
+// ObjectStorage issues presigned URLs for, and reads, objects in an S3-compatible bucket. Google Cloud Storage
+// is used through its XML API with HMAC keys. Requests are signed with AWS Signature Version 4.
+type ObjectStorage struct {
+	config     *config.ObjectStorageConfig
+	httpClient *http.Client
+}
+
+// NewObjectStorage returns an object storage client; it does nothing unless cfg.Bucket is set
+func NewObjectStorage(cfg *config.ObjectStorageConfig) *ObjectStorage {
+	return &ObjectStorage{
+		config:     cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Enabled reports whether a bucket is configured for direct uploads
+func (s *ObjectStorage) Enabled() bool {
+	return s != nil && s.config.Bucket != ""
+}
+
+// PresignedUpload is a URL a client can PUT one file to, with the headers it must send
+type PresignedUpload struct {
+	UploadID  string
+	URL       string
+	Headers   map[string]string
+	ExpiresAt time.Time
+}
+
+// PresignUpload issues a URL for uploading a file of the given size and MIME type. The size and type are
+// signed, so the storage rejects a PUT with any other Content-Length or Content-Type.
+func (s *ObjectStorage) PresignUpload(filename, mimeType string, size int64) (*PresignedUpload, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	uploadID := hex.EncodeToString(b) + "/" + filename
+
+	headers := map[string]string{
+		"Content-Length": strconv.FormatInt(size, 10),
+		"Content-Type":   mimeType,
+	}
+	now := time.Now().UTC()
+	signed, err := s.presign(http.MethodPut, s.objectKey(uploadID), nil, headers, s.config.PresignExpiry, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Browsers set Content-Length themselves, so only the type has to be sent explicitly
+	return &PresignedUpload{
+		UploadID:  uploadID,
+		URL:       signed,
+		Headers:   map[string]string{"Content-Type": mimeType},
+		ExpiresAt: now.Add(s.config.PresignExpiry),
+	}, nil
+}
+
+// UploadedObject describes an object uploaded to a presigned URL
+type UploadedObject struct {
+	Filename    string
+	Size        int64
+	ContentType string
+}
+
+// StatUpload returns the size and type of an uploaded object
+func (s *ObjectStorage) StatUpload(ctx context.Context, uploadID string) (*UploadedObject, error) {
+	resp, err := s.do(ctx, http.MethodHead, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return &UploadedObject{
+		Filename:    path.Base(uploadID),
+		Size:        resp.ContentLength,
+		ContentType: strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0]),
+	}, nil
+}
+
+// OpenUpload returns the contents of an uploaded object; the caller closes it
+func (s *ObjectStorage) OpenUpload(ctx context.Context, uploadID string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// DeleteUpload removes an uploaded object
+func (s *ObjectStorage) DeleteUpload(ctx context.Context, uploadID string) error {
+	resp, err := s.do(ctx, http.MethodDelete, uploadID)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// listObjectsResult is the part of a ListObjectsV2 response the cleanup reads
+type listObjectsResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// ListUploadsBefore returns the IDs of the objects uploaded to presigned URLs before the given time. Objects
+// are removed once confirmed, so these are uploads that were abandoned.
+func (s *ObjectStorage) ListUploadsBefore(ctx context.Context, before time.Time) ([]string, error) {
+	prefix := s.objectKey("")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	uploadIDs := []string{}
+	params := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		signed, err := s.presign(http.MethodGet, "", params, nil, time.Minute, time.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, signed, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("object storage request failed: %w", err)
+		}
+		var result listObjectsResult
+		if resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			resp.Body.Close()
+			return nil, fmt.Errorf("object storage returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode object listing: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			uploadID := strings.TrimPrefix(object.Key, prefix)
+			if uploadIDPattern.MatchString(uploadID) && object.LastModified.Before(before) {
+				uploadIDs = append(uploadIDs, uploadID)
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return uploadIDs, nil
+		}
+		params.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// do sends a request for an uploaded object through a short-lived presigned URL. Responses other than 2xx
+// are returned as errors, a missing object as ErrUploadNotFound.
+func (s *ObjectStorage) do(ctx context.Context, method, uploadID string) (*http.Response, error) {
+	if !uploadIDPattern.MatchString(uploadID) {
+		return nil, ErrInvalidUploadID
+	}
+
+	signed, err := s.presign(method, s.objectKey(uploadID), nil, nil, time.Minute, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, signed, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("object storage request failed: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, ErrUploadNotFound
+		}
+		return nil, fmt.Errorf("object storage returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// objectKey returns the key of an uploaded object under the configured prefix
+func (s *ObjectStorage) objectKey(uploadID string) string {
+	return path.Join(s.config.Prefix, uploadID)
+}
+
+// presign returns a URL for a request on the object with the given key, or on the bucket when the key is
+// empty, signed with the query parameters of AWS Signature Version 4. params are added to the query, and
+// headers are signed and must be sent with the request.
+func (s *ObjectStorage) presign(method, key string, params url.Values, headers map[string]string, expiry time.Duration, now time.Time) (string, error) {
+	endpoint, err := url.Parse(s.config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return "", fmt.Errorf("invalid object storage endpoint %q", s.config.Endpoint)
+	}
+
+	host, objectPath := endpoint.Host, "/"+key
+	if s.config.PathStyle {
+		objectPath = "/" + s.config.Bucket + objectPath
+	} else {
+		host = s.config.Bucket + "." + host
+	}
+	objectPath = strings.TrimSuffix(endpoint.Path, "/") + objectPath
+
+	signedHeaders := map[string]string{"host": host}
+	for name, value := range headers {
+		signedHeaders[strings.ToLower(name)] = value
+	}
+	names := make([]string, 0, len(signedHeaders))
+	for name := range signedHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(signedHeaders[name]) + "\n")
+	}
+
+	date := now.Format("20060102")
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	query := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {s.config.AccessKeyID + "/" + scope},
+		"X-Amz-Date":          {now.Format("20060102T150405Z")},
+		"X-Amz-Expires":       {strconv.Itoa(int(expiry.Seconds()))},
+		"X-Amz-SignedHeaders": {strings.Join(names, ";")},
+	}
+	for name, values := range params {
+		query[name] = values
+	}
+	// url.Values encodes spaces as "+", which SigV4 requires as "%20"
+	canonicalQuery := strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		uriEncodePath(objectPath),
+		canonicalQuery,
+		canonicalHeaders.String(),
+		strings.Join(names, ";"),
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + now.Format("20060102T150405Z") + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	for _, part := range []string{s.config.Region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return endpoint.Scheme + "://" + host + uriEncodePath(objectPath) + "?" + canonicalQuery + "&X-Amz-Signature=" + signature, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncodePath percent-encodes every byte of a path except unreserved characters and slashes, as SigV4 expects
+func uriEncodePath(p string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			encoded.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&encoded, "%%%02X", c)
+	}
+	return encoded.String()
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"ai-cv-summarize/internal/config"
+)
+
+// OCRService recognizes the text of scanned PDFs by rendering their pages with pdftoppm and reading them
+// with Tesseract
+type OCRService struct {
+	config *config.OCRConfig
+}
+
+// NewOCRService returns an OCR service; it does nothing unless cfg.Enabled is set
+func NewOCRService(cfg *config.OCRConfig) *OCRService {
+	return &OCRService{config: cfg}
+}
+
+// Enabled reports whether scanned PDFs are OCRed
+func (o *OCRService) Enabled() bool {
+	return o != nil && o.config.Enabled
+}
+
+// Available checks that the OCR binaries can be found
+func (o *OCRService) Available() error {
+	for _, path := range []string{o.config.PDFToPPMPath, o.config.TesseractPath} {
+		if _, err := exec.LookPath(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NeedsOCR reports whether text extracted from a PDF's text layer is too thin to be the whole document,
+// as with image-only scans
+func (o *OCRService) NeedsOCR(text string) bool {
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters < o.config.MinTextLength
+}
+
+// ExtractPDF returns the recognized text of the first pages of a PDF, in page order
+func (o *OCRService) ExtractPDF(ctx context.Context, filePath string) (string, error) {
+	if o.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.config.Timeout)
+		defer cancel()
+	}
+
+	dir, err := os.MkdirTemp("", "ocr-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create OCR work directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Grayscale renders keep the page images small without hurting recognition
+	args := []string{"-r", strconv.Itoa(o.config.DPI), "-gray", "-png"}
+	if o.config.MaxPages > 0 {
+		args = append(args, "-l", strconv.Itoa(o.config.MaxPages))
+	}
+	args = append(args, filePath, filepath.Join(dir, "page"))
+	if _, err := o.run(ctx, o.config.PDFToPPMPath, args...); err != nil {
+		return "", fmt.Errorf("failed to render PDF pages: %w", err)
+	}
+
+	// pdftoppm zero-pads page numbers, so lexical order is page order
+	pages, err := filepath.Glob(filepath.Join(dir, "page*.png"))
+	if err != nil {
+		return "", err
+	}
+	if len(pages) == 0 {
+		return "", errors.New("PDF has no pages to recognize")
+	}
+	sort.Strings(pages)
+
+	var text strings.Builder
+	for _, page := range pages {
+		content, err := o.run(ctx, o.config.TesseractPath, page, "stdout", "-l", o.config.Languages)
+		if err != nil {
+			return "", fmt.Errorf("failed to recognize text of %s: %w", filepath.Base(page), err)
+		}
+		text.Write(content)
+		text.WriteString("\n")
+	}
+
+	return text.String(), nil
+}
+
+// run executes an OCR tool and returns its standard output, or an error quoting its standard error
+func (o *OCRService) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%s timed out: %w", name, ctx.Err())
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s: %w: %s", name, err, message)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/rag"
+	"ai-cv-summarize/internal/repositories"
+)
+
+// findSimilarProjects embeds a job's project report and compares it with the reports submitted before it,
+// returning those at least as similar as the plagiarism threshold, most similar first. When persist is set
+// the embedding is stored first so later submissions are compared with this one too. Reports of the same
+// candidate, recognised by candidate ID or identical CV, are not matches: re-applications reuse their work.
+func (es *EvaluationService) findSimilarProjects(ctx context.Context, job *models.EvaluationJob, projectContent string, persist bool) ([]models.SimilarProject, error) {
+	if es.replaying {
+		if job.Result == nil {
+			return nil, nil
+		}
+		return job.Result.SimilarProjects, nil
+	}
+	if !es.config.Plagiarism.Enabled || strings.TrimSpace(projectContent) == "" {
+		return nil, nil
+	}
+
+	model := es.llmClient.EmbeddingModel()
+	embedding, err := es.llmClient.GenerateEmbedding(ctx, projectContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed project report: %w", err)
+	}
+
+	jobID := job.ID.Hex()
+	if persist {
+		if err := es.repository.UpdateJobProjectEmbedding(ctx, jobID, embedding, model); err != nil {
+			return nil, fmt.Errorf("failed to save project embedding: %w", err)
+		}
+	}
+
+	earlier, err := es.repository.FindProjectEmbeddings(ctx, model, job.Sandbox)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project embeddings: %w", err)
+	}
+
+	matches := []models.SimilarProject{}
+	for _, other := range earlier {
+		if other.JobID == jobID || sameCandidate(job, other) {
+			continue
+		}
+		similarity := rag.CosineSimilarity(embedding, other.Embedding)
+		identical := job.ProjectHash != "" && other.ProjectHash == job.ProjectHash
+		if similarity < es.config.Plagiarism.Threshold && !identical {
+			continue
+		}
+		matches = append(matches, models.SimilarProject{
+			JobID:      other.JobID,
+			Similarity: math.Round(similarity*1000) / 1000,
+			Identical:  identical,
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Identical != matches[j].Identical {
+			return matches[i].Identical
+		}
+		return matches[i].Similarity > matches[j].Similarity
+	})
+	if limit := es.config.Plagiarism.MaxMatches; limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// sameCandidate reports whether a stored project embedding belongs to the candidate of job
+func sameCandidate(job *models.EvaluationJob, other *repositories.ProjectEmbedding) bool {
+	return (job.CandidateID != "" && other.CandidateID == job.CandidateID) ||
+		(job.CVHash != "" && other.CVHash == job.CVHash)
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"text/template"
+	"time"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+)
+
+// ErrUnknownPrompt is returned for template names that do not match an evaluation step
+var ErrUnknownPrompt = errors.New("unknown prompt template")
+
+// ErrInvalidPrompt is returned when template text fails to parse or its model parameters are out of range
+var ErrInvalidPrompt = errors.New("invalid prompt template")
+
+// ErrUnknownPromptVersion is returned for template versions that were never saved
+var ErrUnknownPromptVersion = errors.New("unknown prompt template version")
+
+// RenderedPrompt is a step's prompt rendered from its active template
+type RenderedPrompt struct {
+	Text string
+	// Version is the template version the prompt was rendered from, 0 for the built-in default
+	Version int
+	params  *models.PromptModelParams
+}
+
+// Temperature returns the template's temperature, or fallback when the template does not set one
+func (p *RenderedPrompt) Temperature(fallback float32) float32 {
+	return promptTemperature(p.params, fallback)
+}
+
+// promptTemperature returns the temperature set by model parameters, or fallback
+func promptTemperature(params *models.PromptModelParams, fallback float32) float32 {
+	if params != nil && params.Temperature != nil {
+		return *params.Temperature
+	}
+	return fallback
+}
+
+// PromptService loads prompt templates from the repository, falling back to the built-in defaults
+type PromptService struct {
+	repository repositories.Repository
+}
+
+func NewPromptService(repository repositories.Repository) *PromptService {
+	return &PromptService{
+		repository: repository,
+	}
+}
+
+// promptVersionsKey is the context key of the template versions pinned by WithPromptVersions
+type promptVersionsKey struct{}
+
+// WithPromptVersions pins the template version each named step renders in ctx instead of its active
+// template; version 0 is the built-in default
+func WithPromptVersions(ctx context.Context, versions map[string]int) context.Context {
+	if len(versions) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, promptVersionsKey{}, versions)
+}
+
+// IsStructured reports whether the step expects a JSON response
+func (ps *PromptService) IsStructured(name string) bool {
+	return structuredPrompts[name]
+}
+
+// GetTemplate returns the active template for a step, or the built-in default when none is stored. A version
+// pinned in ctx by WithPromptVersions is returned instead.
+func (ps *PromptService) GetTemplate(ctx context.Context, name string) (*models.PromptTemplate, error) {
+	defaultTemplate, ok := defaultPromptTemplates[name]
+	if !ok {
+		return nil, ErrUnknownPrompt
+	}
+
+	if pinned, ok := ctx.Value(promptVersionsKey{}).(map[string]int); ok {
+		if number, ok := pinned[name]; ok && number > 0 {
+			version, err := ps.GetVersion(ctx, name, number)
+			if err != nil {
+				return nil, err
+			}
+			return &models.PromptTemplate{
+				Name:        name,
+				Description: version.Description,
+				Template:    version.Template,
+				ModelParams: version.ModelParams,
+				Version:     version.Version,
+			}, nil
+		} else if ok {
+			return &models.PromptTemplate{Name: name, Template: defaultTemplate, IsDefault: true}, nil
+		}
+	}
+
+	stored, err := ps.repository.GetPromptTemplate(ctx, name)
+	if err == nil {
+		return stored, nil
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	return &models.PromptTemplate{
+		Name:      name,
+		Template:  defaultTemplate,
+		IsDefault: true,
+	}, nil
+}
+
+// ListTemplates returns the active template for every step
+func (ps *PromptService) ListTemplates(ctx context.Context) ([]*models.PromptTemplate, error) {
+	stored, err := ps.repository.GetAllPromptTemplates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt templates: %w", err)
+	}
+
+	storedByName := make(map[string]*models.PromptTemplate, len(stored))
+	for _, tmpl := range stored {
+		storedByName[tmpl.Name] = tmpl
+	}
+
+	names := make([]string, 0, len(defaultPromptTemplates))
+	for name := range defaultPromptTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	templates := make([]*models.PromptTemplate, 0, len(names))
+	for _, name := range names {
+		if tmpl, ok := storedByName[name]; ok {
+			templates = append(templates, tmpl)
+			continue
+		}
+		templates = append(templates, &models.PromptTemplate{
+			Name:      name,
+			Template:  defaultPromptTemplates[name],
+			IsDefault: true,
+		})
+	}
+
+	return templates, nil
+}
+
+// SaveTemplate validates a template for a step and saves it as a new version, which becomes active
+func (ps *PromptService) SaveTemplate(ctx context.Context, name, description, text string, params *models.PromptModelParams) (*models.PromptTemplate, error) {
+	if _, ok := defaultPromptTemplates[name]; !ok {
+		return nil, ErrUnknownPrompt
+	}
+
+	if _, err := parsePrompt(text); err != nil {
+		return nil, err
+	}
+	if err := validateModelParams(params); err != nil {
+		return nil, err
+	}
+
+	number, err := ps.nextVersion(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &models.PromptTemplateVersion{
+		Name:        name,
+		Version:     number,
+		Description: description,
+		Template:    text,
+		ModelParams: params,
+		CreatedAt:   time.Now(),
+	}
+	if err := ps.repository.CreatePromptTemplateVersion(ctx, version); err != nil {
+		return nil, fmt.Errorf("failed to save prompt template version: %w", err)
+	}
+
+	return ps.activate(ctx, version)
+}
+
+// nextVersion returns the number for a step's next saved version. Templates stored before versioning
+// are version 0, so numbering starts at 1 either way.
+func (ps *PromptService) nextVersion(ctx context.Context, name string) (int, error) {
+	latest := 0
+	if active, err := ps.repository.GetPromptTemplate(ctx, name); err == nil {
+		latest = active.Version
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		return 0, fmt.Errorf("failed to get prompt template: %w", err)
+	}
+
+	versions, err := ps.repository.GetPromptTemplateVersions(ctx, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get prompt template versions: %w", err)
+	}
+	if len(versions) > 0 && versions[0].Version > latest {
+		latest = versions[0].Version
+	}
+
+	return latest + 1, nil
+}
+
+// ListVersions returns every saved version of a step's template, newest first
+func (ps *PromptService) ListVersions(ctx context.Context, name string) ([]*models.PromptTemplateVersion, error) {
+	if _, ok := defaultPromptTemplates[name]; !ok {
+		return nil, ErrUnknownPrompt
+	}
+
+	versions, err := ps.repository.GetPromptTemplateVersions(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt template versions: %w", err)
+	}
+	if versions == nil {
+		versions = []*models.PromptTemplateVersion{}
+	}
+	return versions, nil
+}
+
+// GetVersion returns one saved version of a step's template
+func (ps *PromptService) GetVersion(ctx context.Context, name string, number int) (*models.PromptTemplateVersion, error) {
+	if _, ok := defaultPromptTemplates[name]; !ok {
+		return nil, ErrUnknownPrompt
+	}
+
+	version, err := ps.repository.GetPromptTemplateVersion(ctx, name, number)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrUnknownPromptVersion
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt template version: %w", err)
+	}
+	return version, nil
+}
+
+// ActivateVersion makes a saved version the active template again, e.g. to roll back a prompt change
+func (ps *PromptService) ActivateVersion(ctx context.Context, name string, number int) (*models.PromptTemplate, error) {
+	version, err := ps.GetVersion(ctx, name, number)
+	if err != nil {
+		return nil, err
+	}
+	return ps.activate(ctx, version)
+}
+
+// activate stores a version as the step's active template
+func (ps *PromptService) activate(ctx context.Context, version *models.PromptTemplateVersion) (*models.PromptTemplate, error) {
+	tmpl := &models.PromptTemplate{
+		Name:        version.Name,
+		Description: version.Description,
+		Template:    version.Template,
+		ModelParams: version.ModelParams,
+		Version:     version.Version,
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := ps.repository.UpsertPromptTemplate(ctx, tmpl); err != nil {
+		return nil, fmt.Errorf("failed to save prompt template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// ResetTemplate deletes the active template so the built-in default applies again; saved versions are kept
+func (ps *PromptService) ResetTemplate(ctx context.Context, name string) error {
+	if _, ok := defaultPromptTemplates[name]; !ok {
+		return ErrUnknownPrompt
+	}
+
+	err := ps.repository.DeletePromptTemplate(ctx, name)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Render renders the active template for a step
+func (ps *PromptService) Render(ctx context.Context, name string, data PromptData) (*RenderedPrompt, error) {
+	tmpl, err := ps.GetTemplate(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt %s: %w", name, err)
+	}
+
+	text, err := ps.Execute(tmpl.Template, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RenderedPrompt{Text: text, Version: tmpl.Version, params: tmpl.ModelParams}, nil
+}
+
+// Execute parses and renders template text against data
+func (ps *PromptService) Execute(text string, data PromptData) (string, error) {
+	tmpl, err := parsePrompt(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// validateModelParams checks model parameters against the range providers accept
+func validateModelParams(params *models.PromptModelParams) error {
+	if params == nil {
+		return nil
+	}
+	if t := params.Temperature; t != nil && (*t < 0 || *t > 2) {
+		return fmt.Errorf("%w: temperature must be between 0 and 2", ErrInvalidPrompt)
+	}
+	return nil
+}
+
+func parsePrompt(text string) (*template.Template, error) {
+	tmpl, err := template.New("prompt").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrompt, err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,283 @@
+package services
+
+import "ai-cv-summarize/internal/models"
+
+// Prompt template names, one per evaluation step
+const (
+	PromptAnalyzeCV       = "analyze_cv"
+	PromptEvaluateCV      = "evaluate_cv"
+	PromptEvaluateProject = "evaluate_project"
+	PromptOverallSummary  = "overall_summary"
+	PromptTranslate       = "translate"
+	PromptEvaluationDiff  = "evaluation_diff"
+	PromptVerify          = "verify_evaluation"
+	PromptParseResume     = "parse_resume"
+	PromptExtractFacts    = "extract_facts"
+	PromptMergeFacts      = "merge_facts"
+	PromptFeedbackLetter  = "feedback_letter"
+)
+
+// PromptData holds the values available to prompt templates. Not every field is set for every step.
+type PromptData struct {
+	CVContent         string
+	ProjectContent    string
+	Context           string
+	CVAnalysis        string
+	CVEvaluation      *CVEvaluation
+	ProjectEvaluation *ProjectEvaluation
+
+	// Document and Language are set for the translation step
+	Document string
+	Language string
+
+	// DocumentType is set with Document for the fact extraction and merge steps of long documents, which
+	// take a part of a CV or project report or the facts extracted from several parts
+	DocumentType string
+
+	// Diff is set for the evaluation diff narrative
+	Diff *models.EvaluationDiff
+
+	// GitHub is set for the project evaluation step when the job names a GitHub account or repository
+	GitHub string
+
+	// Criteria is set for the CV and project evaluation steps from the active scoring rubric
+	Criteria []PromptCriterion
+
+	// CVCriteria and ProjectCriteria are set for the verification step, next to both evaluations
+	CVCriteria      []PromptCriterion
+	ProjectCriteria []PromptCriterion
+
+	// FeedbackVerbosity (detailed or bullets), FeedbackTone (internal or candidate) and FeedbackStyle, the
+	// instructions they amount to, are set for the evaluation, verification and summary steps
+	FeedbackVerbosity string
+	FeedbackTone      string
+	FeedbackStyle     string
+
+	// Letter is set for the feedback letter
+	Letter *LetterPromptData
+}
+
+// structuredPrompts lists the steps whose responses must be JSON
+var structuredPrompts = map[string]bool{
+	PromptAnalyzeCV:       true,
+	PromptEvaluateCV:      true,
+	PromptEvaluateProject: true,
+	PromptVerify:          true,
+	PromptParseResume:     true,
+	PromptFeedbackLetter:  true,
+}
+
+// defaultPromptTemplates are used when no template has been stored for a step
+var defaultPromptTemplates = map[string]string{
+	PromptAnalyzeCV: `Analyze the following CV and extract structured information:
+
+CV Content:
+{{.CVContent}}
+{{with .Context}}
+Context:
+{{.}}
+{{end}}
+Please extract and return the following information in JSON format:
+{
+  "technical_skills": ["skill1", "skill2", ...],
+  "experience_years": number,
+  "projects": [
+    {
+      "name": "project_name",
+      "description": "project_description",
+      "technologies": ["tech1", "tech2", ...],
+      "impact": "impact_description"
+    }
+  ],
+  "achievements": ["achievement1", "achievement2", ...],
+  "education": "education_background",
+  "certifications": ["cert1", "cert2", ...]
+}`,
+
+	PromptEvaluateCV: `Evaluate the following CV analysis against job requirements:
+
+CV Analysis:
+{{.CVAnalysis}}
+{{with .Context}}
+Context:
+{{.}}
+{{end}}
+Evaluate based on these criteria:
+{{range .Criteria}}{{.Number}}. {{.Name}} ({{printf "%.0f" .WeightPercent}}% weight, 1-{{.MaxScore}} scale): {{.Description}}
+{{end}}{{with .FeedbackStyle}}
+Feedback style: {{.}}
+{{end}}
+Return JSON format:
+{
+{{range .Criteria}}  "{{.Key}}_score": number,
+{{end}}  "match_rate": number,
+  "feedback": "feedback_string"
+}`,
+
+	PromptEvaluateProject: `Evaluate the following project report:
+
+Project Content:
+{{.ProjectContent}}
+{{with .GitHub}}
+GitHub Repository Data (use it to ground the code quality, resilience and documentation scores in the actual code):
+{{.}}
+{{end}}{{with .Context}}
+Context:
+{{.}}
+{{end}}
+Evaluate based on these criteria:
+{{range .Criteria}}{{.Number}}. {{.Name}} ({{printf "%.0f" .WeightPercent}}% weight, 1-{{.MaxScore}} scale): {{.Description}}
+{{end}}{{with .FeedbackStyle}}
+Feedback style: {{.}}
+{{end}}
+Return JSON format:
+{
+{{range .Criteria}}  "{{.Key}}_score": number,
+{{end}}  "feedback": "feedback_string"
+}`,
+
+	PromptOverallSummary: `Generate an overall summary based on the following evaluations:
+{{with .CVEvaluation}}
+CV Evaluation:
+- Match Rate: {{printf "%.2f" .MatchRate}}
+- Technical Skills: {{printf "%.2f" .TechnicalSkills}}/5
+- Experience Level: {{printf "%.2f" .ExperienceLevel}}/5
+- Achievements: {{printf "%.2f" .Achievements}}/5
+- Cultural Fit: {{printf "%.2f" .CulturalFit}}/5
+- Feedback: {{.Feedback}}
+{{end}}{{with .ProjectEvaluation}}
+Project Evaluation:
+- Overall Score: {{printf "%.2f" .Score}}/5
+- Correctness: {{printf "%.2f" .Correctness}}/5
+- Code Quality: {{printf "%.2f" .CodeQuality}}/5
+- Resilience: {{printf "%.2f" .Resilience}}/5
+- Documentation: {{printf "%.2f" .Documentation}}/5
+- Creativity: {{printf "%.2f" .Creativity}}/5
+- Feedback: {{.Feedback}}
+{{end}}
+Generate a {{if eq .FeedbackVerbosity "bullets"}}summary of 3-5 bullet points{{else}}3-5 sentence summary{{end}} that includes:
+1. Overall assessment of the candidate
+2. Key strengths
+3. Areas for improvement{{if ne .FeedbackTone "candidate"}}
+4. Recommendation{{end}}{{with .FeedbackStyle}}
+
+Style: {{.}}{{end}}`,
+
+	PromptTranslate: `Translate the following document from language code "{{.Language}}" to English.
+Preserve the structure, headings, lists, names, dates and technical terms. Return only the translated text.
+
+Document:
+{{.Document}}`,
+
+	PromptEvaluationDiff: `Two evaluations of the same candidate are compared below: an earlier one and a newer one made after the candidate updated their CV and/or project report.
+
+Score changes (earlier -> newer):
+{{range $name, $change := .Diff.Scores}}- {{$name}}: {{printf "%.2f" $change.From}} -> {{printf "%.2f" $change.To}} ({{printf "%+.2f" $change.Delta}})
+{{end}}
+Feedback changes:
+{{range .Diff.FeedbackChanges}}{{.Field}}:
+{{range .Added}}+ {{.}}
+{{end}}{{range .Removed}}- {{.}}
+{{end}}{{else}}No feedback changes.
+{{end}}
+Write a 2-4 sentence "what improved" summary for a recruiter:
+1. The most significant improvements
+2. Any regressions
+3. Whether the candidate is overall stronger than before
+Only describe changes listed above.`,
+
+	PromptVerify: `You are reviewing another model's evaluation of a job candidate. Check the scores and feedback against the scoring rubrics.
+{{with .CVEvaluation}}
+CV rubric:
+{{range $.CVCriteria}}- {{.Key}}: {{.Name}} (1-{{.MaxScore}} scale): {{.Description}}
+{{end}}
+CV scores:
+{{range $key, $score := .Criteria}}- {{$key}}: {{printf "%.2f" $score}}
+{{end}}
+CV feedback: {{.Feedback}}
+{{end}}{{with .ProjectEvaluation}}
+Project rubric:
+{{range $.ProjectCriteria}}- {{.Key}}: {{.Name}} (1-{{.MaxScore}} scale): {{.Description}}
+{{end}}
+Project scores:
+{{range $key, $score := .Criteria}}- {{$key}}: {{printf "%.2f" $score}}
+{{end}}
+Project feedback: {{.Feedback}}
+{{end}}
+Look for inconsistencies such as:
+1. Feedback that contradicts a score, e.g. glowing feedback with a low score or harsh feedback with a high score
+2. Scores outside a criterion's scale
+3. Scores that do not match the rubric's description of the criterion
+{{with .FeedbackStyle}}
+Corrected feedback must keep the original style: {{.}}
+{{end}}
+Return JSON format:
+{
+  "consistent": true or false,
+  "issues": ["one sentence per inconsistency found"],
+  "cv_scores": {"criterion key": corrected score, only for CV scores that should change},
+  "project_scores": {"criterion key": corrected score, only for project scores that should change},
+  "cv_feedback": "corrected CV feedback, or empty to keep it",
+  "project_feedback": "corrected project feedback, or empty to keep it"
+}`,
+	PromptExtractFacts: `The following is one part of a candidate's {{.DocumentType}}, too long to evaluate in one piece.
+List every fact from this part that matters for evaluating the candidate: skills and technologies, roles with their dates and responsibilities, projects and what was built, measurable results, education and certifications. For a project report also include design decisions, error handling, testing and documentation.
+Keep names, numbers, dates and technical terms exactly as written. Do not evaluate or add anything that is not stated. Return only the facts as a bullet list.
+
+Part:
+{{.Document}}`,
+
+	PromptMergeFacts: `The following facts were extracted from consecutive parts of a candidate's {{.DocumentType}}.
+Merge them into one bullet list: combine duplicates and entries about the same role, project or skill, and keep every distinct fact with its names, numbers and dates. Do not evaluate or add anything that is not stated. Return only the merged bullet list.
+
+Facts:
+{{.Document}}`,
+	PromptFeedbackLetter: `Write a {{.Letter.Tone}} email to a job candidate {{if eq .Letter.Type "rejection"}}who will not move forward in the hiring process{{else}}sharing feedback on their application{{end}}{{with .Letter.Position}} for the {{.}} position{{end}}{{with .Letter.Company}} at {{.}}{{end}}.
+
+{{if eq .Letter.Type "rejection"}}Thank them for their time, tell them clearly but kindly that they will not move forward, then{{else}}Thank them for their application, then{{end}} share two or three specific strengths and two or three concrete areas to develop, based only on the evaluation notes below.
+{{.Letter.ToneStyle}}
+Greet the candidate as {{with .Letter.Candidate}}{{.}}{{else}}"candidate"{{end}}{{with .Letter.Sender}} and sign the email as {{.}}{{end}}.
+Do not mention scores, ratings, other candidates or internal recommendations, do not quote the notes, and do not add anything they do not support. Keep the email under 250 words.
+
+Evaluation notes (internal):
+CV: {{.Letter.CVFeedback}}
+Project: {{.Letter.ProjectFeedback}}
+Summary: {{.Letter.Summary}}
+
+Return JSON format:
+{
+  "subject": "email subject line",
+  "body": "email body as plain text, with paragraphs separated by blank lines"
+}`,
+	PromptParseResume: `Extract the employment history, education and skills from the following CV.
+
+CV Content:
+{{.CVContent}}
+
+Write dates as "YYYY-MM", or "YYYY" when the CV only gives a year. Use "present" as the end date of a current position. Leave a field empty when the CV does not state it; do not guess.
+
+Return JSON format:
+{
+  "location": "candidate's city and country",
+  "experience": [
+    {
+      "title": "job_title",
+      "company": "company_name",
+      "location": "job_location",
+      "start_date": "YYYY-MM",
+      "end_date": "YYYY-MM or present",
+      "description": "one or two sentence summary of the role"
+    }
+  ],
+  "education": [
+    {
+      "institution": "institution_name",
+      "degree": "degree",
+      "field": "field_of_study",
+      "start_date": "YYYY",
+      "end_date": "YYYY"
+    }
+  ],
+  "skills": ["skill1", "skill2", ...]
+}`,
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// evaluationStreamKey is the Redis stream holding queued job IDs
+	evaluationStreamKey = "evaluation_stream"
+	// evaluationGroup is the consumer group shared by every server instance
+	evaluationGroup = "evaluation_workers"
+	// legacyQueueKey is the Redis list used before the queue moved to a stream
+	legacyQueueKey = "evaluation_queue"
+	// scheduledJobsKey is the Redis sorted set of delayed job IDs, scored by their run time in Unix milliseconds
+	scheduledJobsKey = "evaluation_scheduled"
+)
+
+// scheduledPromoteBatch caps the due jobs moved onto the queue per promotion round
+const scheduledPromoteBatch = 100
+
+// redisPopTimeout bounds each blocking read so Pop can return when its context is cancelled
+const redisPopTimeout = 5 * time.Second
+
+// QueueBackend stores queued job IDs in FIFO order
+type QueueBackend interface {
+	// Push appends a job ID to the queue
+	Push(ctx context.Context, jobID string) error
+	// Schedule holds a job ID back until runAt, when PromoteDue moves it onto the queue
+	Schedule(ctx context.Context, jobID string, runAt time.Time) error
+	// PromoteDue pushes the scheduled jobs due by now and returns how many it moved
+	PromoteDue(ctx context.Context, now time.Time) (int, error)
+	// Pop blocks until a job ID is available or ctx is done
+	Pop(ctx context.Context) (string, error)
+	// Peek returns the next job ID without removing it
+	Peek(ctx context.Context) (string, error)
+	// Ack marks a popped job as handled so it is not redelivered
+	Ack(ctx context.Context, jobID string) error
+	// Remove deletes every occurrence of a job ID from the queue
+	Remove(ctx context.Context, jobID string) error
+	// JobIDs returns the IDs of every job in the queue, including popped jobs not acked yet and scheduled jobs
+	JobIDs(ctx context.Context) ([]string, error)
+	Len(ctx context.Context) (int64, error)
+	// ScheduledLen returns the number of scheduled jobs not due yet
+	ScheduledLen(ctx context.Context) (int64, error)
+	Clear(ctx context.Context) error
+	Name() string
+}
+
+// RedisQueueBackend is a Redis Streams queue shared by all server instances through a consumer group.
+// Popped jobs stay pending until acked; jobs left unacked longer than the claim timeout, e.g. by a crashed
+// instance, are reclaimed by the next consumer that pops.
+type RedisQueueBackend struct {
+	redisClient  redis.UniversalClient
+	consumer     string
+	claimTimeout time.Duration
+
+	mu sync.Mutex
+	// inFlight maps popped job IDs to the stream message IDs delivered for them until they are acked. A job
+	// pushed twice, e.g. by the outbox dispatcher, is delivered once per message and every copy is acked.
+	inFlight map[string][]string
+}
+
+func NewRedisQueueBackend(redisClient redis.UniversalClient, claimTimeout time.Duration) *RedisQueueBackend {
+	return &RedisQueueBackend{
+		redisClient:  redisClient,
+		consumer:     consumerName(),
+		claimTimeout: claimTimeout,
+		inFlight:     make(map[string][]string),
+	}
+}
+
+// consumerName names this instance in the consumer group. The random suffix keeps it unique where
+// hostnames and PIDs repeat, e.g. containers that all run as PID 1 on the same host name.
+func consumerName() string {
+	hostname, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(b))
+}
+
+// Setup creates the consumer group and moves any jobs left in the legacy list queue onto the stream
+func (b *RedisQueueBackend) Setup(ctx context.Context) error {
+	if err := b.ensureGroup(ctx); err != nil {
+		return err
+	}
+
+	for {
+		jobID, err := b.redisClient.RPop(ctx, legacyQueueKey).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to migrate legacy queue: %w", err)
+		}
+		if err := b.Push(ctx, jobID); err != nil {
+			return fmt.Errorf("failed to migrate legacy queue: %w", err)
+		}
+	}
+}
+
+// ensureGroup creates the stream and consumer group if they do not exist yet
+func (b *RedisQueueBackend) ensureGroup(ctx context.Context) error {
+	err := b.redisClient.XGroupCreateMkStream(ctx, evaluationStreamKey, evaluationGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	return nil
+}
+
+func (b *RedisQueueBackend) Push(ctx context.Context, jobID string) error {
+	return b.redisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: evaluationStreamKey,
+		Values: map[string]interface{}{"job_id": jobID},
+	}).Err()
+}
+
+func (b *RedisQueueBackend) Schedule(ctx context.Context, jobID string, runAt time.Time) error {
+	return b.redisClient.ZAdd(ctx, scheduledJobsKey, redis.Z{Score: float64(runAt.UnixMilli()), Member: jobID}).Err()
+}
+
+// PromoteDue moves due jobs from the scheduled set onto the stream. Each job is pushed by the instance
+// whose ZREM removed it, so instances promoting concurrently do not queue it twice.
+func (b *RedisQueueBackend) PromoteDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := b.redisClient.ZRangeByScore(ctx, scheduledJobsKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: scheduledPromoteBatch,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	promoted := 0
+	for _, jobID := range due {
+		removed, err := b.redisClient.ZRem(ctx, scheduledJobsKey, jobID).Result()
+		if err != nil {
+			return promoted, err
+		}
+		if removed == 0 {
+			continue
+		}
+
+		if err := b.Push(ctx, jobID); err != nil {
+			// Put it back so the next round retries it
+			b.redisClient.ZAdd(ctx, scheduledJobsKey, redis.Z{Score: float64(now.UnixMilli()), Member: jobID})
+			return promoted, err
+		}
+		promoted++
+	}
+
+	return promoted, nil
+}
+
+func (b *RedisQueueBackend) Pop(ctx context.Context) (string, error) {
+	for {
+		// Take over a job abandoned by another consumer before reading new ones
+		if b.claimTimeout > 0 {
+			messages, _, err := b.redisClient.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+				Stream:   evaluationStreamKey,
+				Group:    evaluationGroup,
+				MinIdle:  b.claimTimeout,
+				Start:    "0-0",
+				Count:    1,
+				Consumer: b.consumer,
+			}).Result()
+			if err != nil && !isNoGroup(err) {
+				return "", err
+			}
+			if len(messages) > 0 {
+				return b.track(messages[0]), nil
+			}
+		}
+
+		// Block in short rounds so a cancelled context is noticed promptly
+		streams, err := b.redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    evaluationGroup,
+			Consumer: b.consumer,
+			Streams:  []string{evaluationStreamKey, ">"},
+			Count:    1,
+			Block:    redisPopTimeout,
+		}).Result()
+		if err == redis.Nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			continue
+		}
+		if isNoGroup(err) {
+			// The stream was cleared; recreate the group and read again
+			if err := b.ensureGroup(ctx); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if len(streams) == 0 || len(streams[0].Messages) == 0 {
+			continue
+		}
+
+		return b.track(streams[0].Messages[0]), nil
+	}
+}
+
+// track records a delivered message so Ack can find it and returns its job ID
+func (b *RedisQueueBackend) track(message redis.XMessage) string {
+	jobID, _ := message.Values["job_id"].(string)
+
+	b.mu.Lock()
+	b.inFlight[jobID] = append(b.inFlight[jobID], message.ID)
+	b.mu.Unlock()
+
+	return jobID
+}
+
+func (b *RedisQueueBackend) Ack(ctx context.Context, jobID string) error {
+	b.mu.Lock()
+	messageIDs := b.inFlight[jobID]
+	delete(b.inFlight, jobID)
+	b.mu.Unlock()
+
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	if err := b.redisClient.XAck(ctx, evaluationStreamKey, evaluationGroup, messageIDs...).Err(); err != nil {
+		return err
+	}
+	return b.redisClient.XDel(ctx, evaluationStreamKey, messageIDs...).Err()
+}
+
+// Peek returns the oldest job not yet delivered to any consumer
+func (b *RedisQueueBackend) Peek(ctx context.Context) (string, error) {
+	lastDelivered := "0-0"
+	groups, err := b.redisClient.XInfoGroups(ctx, evaluationStreamKey).Result()
+	if err != nil && !isNoStream(err) {
+		return "", err
+	}
+	for _, group := range groups {
+		if group.Name == evaluationGroup {
+			lastDelivered = group.LastDeliveredID
+		}
+	}
+
+	messages, err := b.redisClient.XRangeN(ctx, evaluationStreamKey, "("+lastDelivered, "+", 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(messages) == 0 {
+		return "", ErrQueueEmpty
+	}
+
+	jobID, _ := messages[0].Values["job_id"].(string)
+	return jobID, nil
+}
+
+func (b *RedisQueueBackend) Remove(ctx context.Context, jobID string) error {
+	messages, err := b.redisClient.XRange(ctx, evaluationStreamKey, "-", "+").Result()
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	for _, message := range messages {
+		if message.Values["job_id"] == jobID {
+			ids = append(ids, message.ID)
+		}
+	}
+	if err := b.redisClient.ZRem(ctx, scheduledJobsKey, jobID).Err(); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := b.redisClient.XAck(ctx, evaluationStreamKey, evaluationGroup, ids...).Err(); err != nil {
+		return err
+	}
+	return b.redisClient.XDel(ctx, evaluationStreamKey, ids...).Err()
+}
+
+// JobIDs returns the job IDs left on the stream, which holds popped jobs until they are acked, and the
+// scheduled job IDs
+func (b *RedisQueueBackend) JobIDs(ctx context.Context) ([]string, error) {
+	messages, err := b.redisClient.XRange(ctx, evaluationStreamKey, "-", "+").Result()
+	if err != nil {
+		return nil, err
+	}
+	scheduled, err := b.redisClient.ZRange(ctx, scheduledJobsKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(messages)+len(scheduled))
+	for _, message := range messages {
+		if jobID, ok := message.Values["job_id"].(string); ok {
+			ids = append(ids, jobID)
+		}
+	}
+	return append(ids, scheduled...), nil
+}
+
+// Len returns the number of jobs waiting for delivery; acked jobs are deleted from the stream,
+// so this is the stream length minus the jobs currently being processed
+func (b *RedisQueueBackend) Len(ctx context.Context) (int64, error) {
+	length, err := b.redisClient.XLen(ctx, evaluationStreamKey).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	pending, err := b.redisClient.XPending(ctx, evaluationStreamKey, evaluationGroup).Result()
+	if err != nil {
+		if isNoGroup(err) {
+			return length, nil
+		}
+		return 0, err
+	}
+
+	return length - pending.Count, nil
+}
+
+// ScheduledLen returns the number of jobs in the scheduled set
+func (b *RedisQueueBackend) ScheduledLen(ctx context.Context) (int64, error) {
+	return b.redisClient.ZCard(ctx, scheduledJobsKey).Result()
+}
+
+func (b *RedisQueueBackend) Clear(ctx context.Context) error {
+	if err := b.redisClient.Del(ctx, scheduledJobsKey).Err(); err != nil {
+		return err
+	}
+	if err := b.redisClient.Del(ctx, evaluationStreamKey).Err(); err != nil {
+		return err
+	}
+	return b.ensureGroup(ctx)
+}
+
+func (b *RedisQueueBackend) Name() string {
+	return "redis"
+}
+
+func isNoGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOGROUP")
+}
+
+func isNoStream(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "no such key")
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kinds of personal data, used as the prefix of redaction placeholders such as [EMAIL_1]
+const (
+	PIIName    = "NAME"
+	PIIEmail   = "EMAIL"
+	PIIPhone   = "PHONE"
+	PIIAddress = "ADDRESS"
+	PIIPhoto   = "PHOTO"
+	// Kinds only removed in blind mode
+	PIIGender     = "GENDER"
+	PIIAge        = "AGE"
+	PIIUniversity = "UNIVERSITY"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// phonePattern is loose on purpose; matches with too few or too many digits are skipped
+	phonePattern = regexp.MustCompile(`\+?\(?\d[\d \t().\-]{7,}\d`)
+	// Labelled lines such as "Address: ..." or "Alamat: ..."; the value is the second group
+	addressLinePattern = regexp.MustCompile(`(?im)^([ \t]*(?:home address|address|alamat|domicile|domisili)[ \t]*[:\-][ \t]*)(\S.*?)[ \t]*$`)
+	// Indonesian street names ("Jl. Sudirman No. 5") and Western street addresses ("12 Baker Street")
+	streetPattern = regexp.MustCompile(`\b(?:Jl\.|Jln\.?|Jalan)[ \t]+[A-Z][^\n,;]*|\b\d{1,5}[ \t]+(?:[A-Z][A-Za-z]*[ \t]+){1,3}(?:(?:Street|Road|Avenue|Lane|Boulevard|Drive|Way|Court|Place)\b|(?:St|Rd|Ave|Blvd)\.)`)
+	// Image file names and labelled photo lines, which typically come from embedded CV photos
+	photoFilePattern = regexp.MustCompile(`(?i)\b[\w\-]+\.(?:jpe?g|png|gif|bmp|heic|webp|tiff?)\b`)
+	photoLinePattern = regexp.MustCompile(`(?im)^([ \t]*(?:photo|foto|picture|image)[ \t]*:[ \t]*)(\S.*?)[ \t]*$`)
+	// nameLinePattern matches a labelled name line such as "Name: Jane Doe" or "Nama: Budi Santoso"
+	nameLinePattern = regexp.MustCompile(`(?im)^[ \t]*(?:full name|name|nama|nama lengkap)[ \t]*:[ \t]*(\S.*?)[ \t]*$`)
+
+	// Labelled personal details that hint at gender or age, such as "Gender: Female" or "Date of birth: ..."
+	genderLinePattern = regexp.MustCompile(`(?im)^([ \t]*(?:gender|sex|jenis kelamin|marital status|status pernikahan)[ \t]*:[ \t]*)(\S.*?)[ \t]*$`)
+	ageLinePattern    = regexp.MustCompile(`(?im)^([ \t]*(?:age|umur|usia|date of birth|birth date|birthday|dob|born|tanggal lahir|tempat,? tanggal lahir|ttl)[ \t]*:[ \t]*)(\S.*?)[ \t]*$`)
+	agePattern        = regexp.MustCompile(`(?i)\b\d{2}[ \t]*(?:years|yrs)[ \t]+old\b|\b(?:usia|umur|berusia)[ \t]+\d{2}[ \t]+tahun\b|\bborn[ \t]+(?:in|on)[ \t]+[^\n,;]*?\d{4}`)
+	// Honorifics, dropped together with the following space
+	honorificPattern = regexp.MustCompile(`\b(?:Mr|Mrs|Ms|Miss|Mx)\.?[ \t]+`)
+	// Institutions such as "Stanford University", "University of Toronto" or "Universitas Gadjah Mada"
+	universityPattern = regexp.MustCompile(`\b(?:(?:University|Institute|College)[ \t]+of|Universitas|Institut|Politeknik|Sekolah[ \t]+Tinggi)(?:[ \t]+(?:(?:of|and|dan|de)[ \t]+)?[A-Z][\w'&-]*){1,5}|\b(?:[A-Z][\w'.&-]*[ \t]+){1,4}(?:University|College|Institute[ \t]+of[ \t]+Technology|Polytechnic)\b`)
+)
+
+// genderedWords maps gendered pronouns to neutral ones for blind evaluations
+var genderedWords = map[string]string{
+	"he": "they", "she": "they", "him": "them", "his": "their", "her": "their", "hers": "theirs",
+	"himself": "themselves", "herself": "themselves",
+}
+
+var genderedWordPattern = regexp.MustCompile(`(?i)\b(?:he|she|him|his|her|hers|himself|herself)\b`)
+
+// nameHeadingWords are words that make a CV's first line a heading or a job title rather than a name
+var nameHeadingWords = map[string]bool{
+	"curriculum": true, "vitae": true, "resume": true, "résumé": true, "cv": true, "profile": true,
+	"summary": true, "contact": true, "daftar": true, "riwayat": true, "hidup": true,
+	"engineer": true, "developer": true, "manager": true, "designer": true, "analyst": true,
+	"scientist": true, "consultant": true, "intern": true, "lead": true, "senior": true, "junior": true,
+}
+
+// DetectCandidateName returns the candidate's name from a CV: a labelled "Name:" line, or else a first
+// line that looks like a person's name. It returns an empty string when neither is found.
+func DetectCandidateName(cv string) string {
+	if match := nameLinePattern.FindStringSubmatch(cv); match != nil {
+		return match[1]
+	}
+
+	for _, line := range strings.Split(cv, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if looksLikeName(line) {
+			return line
+		}
+		return ""
+	}
+	return ""
+}
+
+// looksLikeName reports whether a line is two to four capitalized words made of letters only
+func looksLikeName(line string) bool {
+	words := strings.Fields(line)
+	if len(words) < 2 || len(words) > 4 || len(line) > 50 {
+		return false
+	}
+	for _, word := range words {
+		if nameHeadingWords[strings.ToLower(word)] {
+			return false
+		}
+		for i, r := range word {
+			if i == 0 && !unicode.IsUpper(r) {
+				return false
+			}
+			if !unicode.IsLetter(r) && r != '.' && r != '\'' && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Redactor replaces personal data in documents with numbered placeholders and keeps the mapping so model
+// output quoting a placeholder can be restored for display. A single Redactor should cover all documents
+// of a job so a value gets the same placeholder everywhere. A nil Redactor leaves text unchanged.
+//
+// A blind Redactor also removes gender hints, age and university names, and never restores anything, so
+// the people reading a blind result stay as blind as the model.
+type Redactor struct {
+	blind bool
+	names []string
+	// values maps each redacted value to its placeholder; mapping maps each placeholder back to the value
+	values  map[string]string
+	mapping map[string]string
+	counts  map[string]int
+}
+
+// NewRedactor creates a Redactor for a candidate with the given names; empty names are ignored.
+// Each name is also redacted word by word, so "Jane" alone maps to the placeholder of "Jane Doe".
+func NewRedactor(names ...string) *Redactor {
+	r := &Redactor{
+		values:  map[string]string{},
+		mapping: map[string]string{},
+		counts:  map[string]int{},
+	}
+
+	for _, name := range names {
+		name = strings.Join(strings.Fields(name), " ")
+		if name == "" {
+			continue
+		}
+		placeholder := r.placeholder(PIIName, name)
+		r.names = append(r.names, name)
+		for _, part := range strings.Fields(name) {
+			part = strings.Trim(part, ".,")
+			if len([]rune(part)) < 3 {
+				continue
+			}
+			if _, ok := r.values[part]; !ok {
+				r.values[part] = placeholder
+				r.names = append(r.names, part)
+			}
+		}
+	}
+	// Full names go before their parts
+	sort.SliceStable(r.names, func(i, j int) bool { return len(r.names[i]) > len(r.names[j]) })
+
+	return r
+}
+
+// NewBlindRedactor creates a blind Redactor for a candidate with the given names
+func NewBlindRedactor(names ...string) *Redactor {
+	r := NewRedactor(names...)
+	r.blind = true
+	return r
+}
+
+// Redact returns text with names, email addresses, phone numbers, street addresses and photo references
+// replaced by placeholders
+func (r *Redactor) Redact(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+
+	text = emailPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return r.placeholder(PIIEmail, match)
+	})
+	text = r.replaceLineValues(text, photoLinePattern, PIIPhoto)
+	text = photoFilePattern.ReplaceAllStringFunc(text, func(match string) string {
+		return r.placeholder(PIIPhoto, match)
+	})
+	text = r.replaceLineValues(text, addressLinePattern, PIIAddress)
+	text = streetPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return r.placeholder(PIIAddress, strings.TrimSpace(match))
+	})
+	text = phonePattern.ReplaceAllStringFunc(text, func(match string) string {
+		if !isPhoneNumber(match) {
+			return match
+		}
+		return r.placeholder(PIIPhone, strings.TrimSpace(match))
+	})
+	for _, name := range r.names {
+		text = replaceWord(text, name, r.values[name])
+	}
+	if r.blind {
+		text = r.redactBlind(text)
+	}
+
+	return text
+}
+
+// isPhoneNumber reports whether a phonePattern match has as many digits as a phone number. Fewer digits
+// are usually dates, year ranges or amounts.
+func isPhoneNumber(match string) bool {
+	digits := 0
+	for _, c := range match {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits >= 9 && digits <= 15
+}
+
+// redactBlind removes the details only hidden in blind mode
+func (r *Redactor) redactBlind(text string) string {
+	text = r.replaceLineValues(text, genderLinePattern, PIIGender)
+	text = r.replaceLineValues(text, ageLinePattern, PIIAge)
+	text = agePattern.ReplaceAllStringFunc(text, func(match string) string {
+		return r.placeholder(PIIAge, match)
+	})
+	text = universityPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return r.placeholder(PIIUniversity, strings.TrimSpace(match))
+	})
+	text = honorificPattern.ReplaceAllString(text, "")
+	return genderedWordPattern.ReplaceAllStringFunc(text, func(match string) string {
+		neutral := genderedWords[strings.ToLower(match)]
+		if unicode.IsUpper([]rune(match)[0]) {
+			return strings.ToUpper(neutral[:1]) + neutral[1:]
+		}
+		return neutral
+	})
+}
+
+// Restore replaces the placeholders in text with the values they stand for, except in blind mode
+func (r *Redactor) Restore(text string) string {
+	if r == nil || r.blind || len(r.mapping) == 0 || text == "" {
+		return text
+	}
+
+	pairs := make([]string, 0, 2*len(r.mapping))
+	for placeholder, value := range r.mapping {
+		pairs = append(pairs, placeholder, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Mapping returns the placeholders used so far and the values they replaced, or nil when nothing was
+// redacted or in blind mode
+func (r *Redactor) Mapping() map[string]string {
+	if r == nil || r.blind || len(r.mapping) == 0 {
+		return nil
+	}
+
+	mapping := make(map[string]string, len(r.mapping))
+	for placeholder, value := range r.mapping {
+		mapping[placeholder] = value
+	}
+	return mapping
+}
+
+// placeholder returns the placeholder of a value, numbering a new one per kind on first use
+func (r *Redactor) placeholder(kind, value string) string {
+	if placeholder, ok := r.values[value]; ok {
+		return placeholder
+	}
+
+	r.counts[kind]++
+	placeholder := fmt.Sprintf("[%s_%d]", kind, r.counts[kind])
+	r.values[value] = placeholder
+	r.mapping[placeholder] = value
+	return placeholder
+}
+
+// replaceLineValues redacts the value group of a labelled-line pattern, keeping the label
+func (r *Redactor) replaceLineValues(text string, pattern *regexp.Regexp, kind string) string {
+	return pattern.ReplaceAllStringFunc(text, func(line string) string {
+		match := pattern.FindStringSubmatch(line)
+		return match[1] + r.placeholder(kind, match[2])
+	})
+}
+
+// replaceWord replaces whole-word occurrences of word in text. Go's \b only knows ASCII word characters,
+// so boundaries are checked by hand to handle names such as "José".
+func replaceWord(text, word, replacement string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(text, word)
+		if i < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+		end := i + len(word)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordRune(before) || isWordRune(after) {
+			b.WriteString(text[:end])
+		} else {
+			b.WriteString(text[:i])
+			b.WriteString(replacement)
+		}
+		text = text[end:]
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
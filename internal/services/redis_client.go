@@ -0,0 +1,112 @@
+package services
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedisClient builds a Redis client from a connection URL. Supported forms:
+//
+//	redis://[user:password@]host:port[/db]             single node, rediss:// for TLS
+//	redis-cluster://[user:password@]host:port?addr=host2:port  cluster, rediss-cluster:// for TLS
+//	redis-sentinel://[user:password@]host1:port,host2:port/master[/db]  sentinel, rediss-sentinel:// for TLS
+//
+// Sentinel URLs accept a sentinel_password query parameter when the sentinels use their own password.
+func NewRedisClient(redisURL string) (redis.UniversalClient, error) {
+	scheme, _, found := strings.Cut(redisURL, "://")
+	if !found {
+		return nil, fmt.Errorf("invalid Redis URL %q: missing scheme", redisURL)
+	}
+
+	switch scheme {
+	case "redis", "rediss", "unix":
+		opts, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Redis URL: %w", err)
+		}
+		return redis.NewClient(opts), nil
+
+	case "redis-cluster", "rediss-cluster":
+		opts, err := redis.ParseClusterURL(strings.TrimSuffix(scheme, "-cluster") + strings.TrimPrefix(redisURL, scheme))
+		if err != nil {
+			return nil, fmt.Errorf("invalid Redis cluster URL: %w", err)
+		}
+		return redis.NewClusterClient(opts), nil
+
+	case "redis-sentinel", "rediss-sentinel":
+		opts, err := parseSentinelURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Redis sentinel URL: %w", err)
+		}
+		return redis.NewFailoverClient(opts), nil
+
+	default:
+		return nil, fmt.Errorf("invalid Redis URL %q: unsupported scheme %q", redisURL, scheme)
+	}
+}
+
+// parseSentinelURL reads a redis-sentinel://[user:password@]host1:port,host2:port/master[/db] URL
+func parseSentinelURL(redisURL string) (*redis.FailoverOptions, error) {
+	// url.Parse rejects a comma-separated host list, so split the sentinel addresses off first
+	scheme, rest, _ := strings.Cut(redisURL, "://")
+	authority, path := rest, ""
+	if i := strings.IndexAny(rest, "/?"); i >= 0 {
+		authority, path = rest[:i], rest[i:]
+	}
+	userinfo, hosts := "", authority
+	if i := strings.LastIndex(authority, "@"); i >= 0 {
+		userinfo, hosts = authority[:i+1], authority[i+1:]
+	}
+
+	u, err := url.Parse(scheme + "://" + userinfo + "sentinel" + path)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &redis.FailoverOptions{}
+	for _, addr := range strings.Split(hosts, ",") {
+		if addr == "" {
+			continue
+		}
+		if !strings.Contains(addr, ":") {
+			addr += ":26379"
+		}
+		opts.SentinelAddrs = append(opts.SentinelAddrs, addr)
+	}
+	if len(opts.SentinelAddrs) == 0 {
+		return nil, fmt.Errorf("no sentinel addresses")
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if segments[0] == "" {
+		return nil, fmt.Errorf("missing master name")
+	}
+	opts.MasterName = segments[0]
+	if len(segments) > 1 {
+		db, err := strconv.Atoi(segments[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid database number %q", segments[1])
+		}
+		opts.DB = db
+	}
+	if len(segments) > 2 {
+		return nil, fmt.Errorf("unexpected path %q", u.Path)
+	}
+
+	if u.User != nil {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+	}
+	opts.SentinelPassword = u.Query().Get("sentinel_password")
+
+	if u.Scheme == "rediss-sentinel" {
+		opts.TLSConfig = &tls.Config{}
+	}
+
+	return opts, nil
+}
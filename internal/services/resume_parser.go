@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-cv-summarize/internal/llm"
+	"ai-cv-summarize/internal/models"
+)
+
+var (
+	// Profile links, with or without a scheme; trailing punctuation is trimmed separately
+	resumeLinkPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>()"']+|\b(?:www\.)?(?:linkedin\.com|github\.com|gitlab\.com|bitbucket\.org|behance\.net|dribbble\.com|medium\.com)/[^\s<>()"']+`)
+	// Labelled skill lines such as "Skills: Go, Docker" or "Keahlian: Python; SQL"
+	skillsLinePattern = regexp.MustCompile(`(?im)^[ \t]*(?:technical skills|skills|tech stack|technologies|keahlian|kemampuan)[ \t]*:[ \t]*(\S.*?)[ \t]*$`)
+	skillSeparator    = regexp.MustCompile(`[,;|•·]`)
+	// Dates the model may return despite being asked for YYYY-MM
+	isoMonthPattern     = regexp.MustCompile(`^(\d{4})[-/.](\d{1,2})(?:[-/.]\d{1,2})?$`)
+	numericMonthPattern = regexp.MustCompile(`^(\d{1,2})[-/.](\d{4})$`)
+	namedMonthPattern   = regexp.MustCompile(`^([A-Za-z]+)\.?,?[ \t]+(\d{4})$`)
+	yearPattern         = regexp.MustCompile(`^(\d{4})$`)
+)
+
+// resumeMonths maps English and Indonesian month names and their abbreviations to month numbers
+var resumeMonths = map[string]int{
+	"jan": 1, "january": 1, "januari": 1,
+	"feb": 2, "february": 2, "februari": 2,
+	"mar": 3, "march": 3, "maret": 3,
+	"apr": 4, "april": 4,
+	"may": 5, "mei": 5,
+	"jun": 6, "june": 6, "juni": 6,
+	"jul": 7, "july": 7, "juli": 7,
+	"aug": 8, "august": 8, "agu": 8, "agt": 8, "agustus": 8,
+	"sep": 9, "sept": 9, "september": 9,
+	"oct": 10, "october": 10, "okt": 10, "oktober": 10,
+	"nov": 11, "november": 11, "nopember": 11,
+	"dec": 12, "december": 12, "des": 12, "desember": 12,
+}
+
+// currentDateWords mark the end date of a position the candidate still holds
+var currentDateWords = map[string]bool{
+	"present": true, "current": true, "now": true, "today": true, "ongoing": true, "sekarang": true, "saat ini": true,
+}
+
+// resumeExtraction is the parse_resume response
+type resumeExtraction struct {
+	Location   string `json:"location"`
+	Experience []struct {
+		Title       string `json:"title"`
+		Company     string `json:"company"`
+		Location    string `json:"location"`
+		StartDate   string `json:"start_date"`
+		EndDate     string `json:"end_date"`
+		Description string `json:"description"`
+	} `json:"experience"`
+	Education []struct {
+		Institution string `json:"institution"`
+		Degree      string `json:"degree"`
+		Field       string `json:"field"`
+		StartDate   string `json:"start_date"`
+		EndDate     string `json:"end_date"`
+	} `json:"education"`
+	Skills []string `json:"skills"`
+}
+
+// ParseResume returns the normalized, structured content of a job's CV. Contact details and labelled skill
+// lines are read locally; the employment history, education and remaining skills come from the parse_resume
+// prompt, which sees the CV redacted like every other step. Contact details are left out for blind jobs.
+func (es *EvaluationService) ParseResume(ctx context.Context, job *models.EvaluationJob) (*models.ParsedResume, error) {
+	redactor := es.redactor(job)
+	cvContent := redactor.Redact(job.CVContent)
+	if err := es.moderation.Check(ctx, "CV", cvContent); err != nil {
+		return nil, err
+	}
+
+	var data PromptData
+	prompt, err := es.renderFitted(ctx, PromptParseResume, "CV", &data, &data.CVContent, cvContent)
+	if err != nil {
+		return nil, err
+	}
+
+	var extraction resumeExtraction
+	if _, err := llm.GenerateJSON(ctx, es.llmClient, prompt.Text, parsedResumeSchema, prompt.Temperature(0.1), es.config.JobQueue.MaxRetries, &extraction); err != nil {
+		return nil, fmt.Errorf("failed to parse resume: %w", err)
+	}
+
+	now := time.Now()
+	parsed := &models.ParsedResume{
+		Experience: []models.ResumeExperience{},
+		Education:  []models.ResumeEducation{},
+		ParsedAt:   now,
+	}
+	if !job.Blind {
+		parsed.Contact = parseResumeContact(job)
+		parsed.Contact.Location = redactor.Restore(strings.TrimSpace(extraction.Location))
+	}
+
+	for _, item := range extraction.Experience {
+		experience := models.ResumeExperience{
+			Title:       redactor.Restore(strings.TrimSpace(item.Title)),
+			Company:     redactor.Restore(strings.TrimSpace(item.Company)),
+			Location:    redactor.Restore(strings.TrimSpace(item.Location)),
+			StartDate:   normalizeResumeDate(item.StartDate),
+			Description: redactor.Restore(strings.TrimSpace(item.Description)),
+		}
+		if currentDateWords[strings.ToLower(strings.TrimSpace(item.EndDate))] {
+			experience.Current = true
+		} else {
+			experience.EndDate = normalizeResumeDate(item.EndDate)
+		}
+		if experience.Title == "" && experience.Company == "" {
+			continue
+		}
+		parsed.Experience = append(parsed.Experience, experience)
+	}
+
+	for _, item := range extraction.Education {
+		education := models.ResumeEducation{
+			Institution: redactor.Restore(strings.TrimSpace(item.Institution)),
+			Degree:      strings.TrimSpace(item.Degree),
+			Field:       strings.TrimSpace(item.Field),
+			StartDate:   normalizeResumeDate(item.StartDate),
+			EndDate:     normalizeResumeDate(item.EndDate),
+		}
+		if education.Institution == "" && education.Degree == "" {
+			continue
+		}
+		parsed.Education = append(parsed.Education, education)
+	}
+
+	parsed.Skills = mergeSkills(labelledSkills(job.CVContent), extraction.Skills)
+	parsed.ExperienceMonths = experienceMonths(parsed.Experience, now)
+
+	return parsed, nil
+}
+
+// parseResumeContact reads a candidate's name, email address, phone number and profile links from the CV
+func parseResumeContact(job *models.EvaluationJob) models.ResumeContact {
+	contact := models.ResumeContact{
+		Name:  job.CandidateName,
+		Email: emailPattern.FindString(job.CVContent),
+	}
+	if contact.Name == "" {
+		contact.Name = DetectCandidateName(job.CVContent)
+	}
+	for _, match := range phonePattern.FindAllString(job.CVContent, -1) {
+		if isPhoneNumber(match) {
+			contact.Phone = strings.TrimSpace(match)
+			break
+		}
+	}
+	for _, match := range resumeLinkPattern.FindAllString(job.CVContent, -1) {
+		contact.Links = appendUnique(contact.Links, strings.TrimRight(match, ".,;:"))
+	}
+	return contact
+}
+
+// labelledSkills returns the skills listed on labelled lines such as "Skills: Go, Docker"
+func labelledSkills(cv string) []string {
+	var skills []string
+	for _, match := range skillsLinePattern.FindAllStringSubmatch(cv, -1) {
+		skills = append(skills, skillSeparator.Split(match[1], -1)...)
+	}
+	return skills
+}
+
+// mergeSkills combines skill lists in order, trimming entries and dropping case-insensitive duplicates
+func mergeSkills(lists ...[]string) []string {
+	skills := []string{}
+	seen := map[string]bool{}
+	for _, list := range lists {
+		for _, skill := range list {
+			skill = strings.Trim(strings.TrimSpace(skill), ".-")
+			key := strings.ToLower(skill)
+			if skill == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			skills = append(skills, skill)
+		}
+	}
+	return skills
+}
+
+// normalizeResumeDate returns a date as "YYYY-MM", or "YYYY" when only the year is known. Dates it cannot
+// read are dropped rather than passed on in an unknown format.
+func normalizeResumeDate(value string) string {
+	value = strings.TrimSpace(value)
+	if match := isoMonthPattern.FindStringSubmatch(value); match != nil {
+		return formatResumeMonth(match[1], match[2])
+	}
+	if match := numericMonthPattern.FindStringSubmatch(value); match != nil {
+		return formatResumeMonth(match[2], match[1])
+	}
+	if match := namedMonthPattern.FindStringSubmatch(value); match != nil {
+		if month, ok := resumeMonths[strings.ToLower(match[1])]; ok {
+			return fmt.Sprintf("%s-%02d", match[2], month)
+		}
+	}
+	if yearPattern.MatchString(value) {
+		return value
+	}
+	return ""
+}
+
+// formatResumeMonth formats a year and month, keeping only the year when the month is out of range
+func formatResumeMonth(year, month string) string {
+	m, _ := strconv.Atoi(month)
+	if m < 1 || m > 12 {
+		return year
+	}
+	return fmt.Sprintf("%s-%02d", year, m)
+}
+
+// resumeMonthIndex converts a normalized date to a month count. A bare year is read as its first month, or
+// its last when end is set.
+func resumeMonthIndex(date string, end bool) (int, bool) {
+	if len(date) < 4 {
+		return 0, false
+	}
+	year, err := strconv.Atoi(date[:4])
+	if err != nil {
+		return 0, false
+	}
+	month := 1
+	if end {
+		month = 12
+	}
+	if len(date) == 7 {
+		month, _ = strconv.Atoi(date[5:])
+	}
+	return year*12 + month - 1, true
+}
+
+// experienceMonths totals the months covered by positions with known dates, counting overlapping positions
+// once. Both the start and end month count, so "2020-01" to "2020-12" is 12 months.
+func experienceMonths(experience []models.ResumeExperience, now time.Time) int {
+	type period struct{ start, end int }
+	var periods []period
+	for _, item := range experience {
+		start, ok := resumeMonthIndex(item.StartDate, false)
+		if !ok {
+			continue
+		}
+		end := now.Year()*12 + int(now.Month()) - 1
+		if !item.Current {
+			if end, ok = resumeMonthIndex(item.EndDate, true); !ok {
+				continue
+			}
+		}
+		if end >= start {
+			periods = append(periods, period{start, end})
+		}
+	}
+
+	sort.Slice(periods, func(i, j int) bool { return periods[i].start < periods[j].start })
+	total, covered := 0, -1
+	for _, p := range periods {
+		if p.start <= covered {
+			p.start = covered + 1
+		}
+		if p.end >= p.start {
+			total += p.end - p.start + 1
+			covered = p.end
+		}
+	}
+	return total
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/repositories"
+)
+
+// RetentionService permanently removes soft-deleted jobs and erases the documents of old jobs
+type RetentionService struct {
+	repository  repositories.Repository
+	fileService *FileService
+	config      *config.Config
+}
+
+func NewRetentionService(repository repositories.Repository, fileService *FileService, cfg *config.Config) *RetentionService {
+	return &RetentionService{
+		repository:  repository,
+		fileService: fileService,
+		config:      cfg,
+	}
+}
+
+// PurgeDeletedJobs hard-deletes the jobs, live or archived, soft-deleted before the given time along with
+// their uploaded files
+func (s *RetentionService) PurgeDeletedJobs(ctx context.Context, deletedBefore time.Time, dryRun bool) (*models.PurgeJobsResponse, error) {
+	filter := repositories.JobBulkFilter{DeletedBefore: deletedBefore}
+	archivedFilter := filter
+	archivedFilter.Archived = true
+
+	jobs, err := s.repository.FindJobs(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find deleted jobs: %w", err)
+	}
+	archived, err := s.repository.FindJobs(ctx, archivedFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find deleted archived jobs: %w", err)
+	}
+	jobs = append(jobs, archived...)
+
+	response := &models.PurgeJobsResponse{
+		DryRun:  dryRun,
+		Matched: len(jobs),
+		JobIDs:  make([]string, 0, len(jobs)),
+	}
+	for _, job := range jobs {
+		response.JobIDs = append(response.JobIDs, job.ID.Hex())
+	}
+	if dryRun {
+		return response, nil
+	}
+
+	// Files go first so a failure leaves the jobs in place to retry
+	response.FilesRemoved = s.fileService.RemoveJobUploads(ctx, jobs)
+	if response.Purged, err = s.repository.DeleteJobs(ctx, filter); err != nil {
+		return response, fmt.Errorf("failed to purge deleted jobs: %w", err)
+	}
+	purged, err := s.repository.DeleteJobs(ctx, archivedFilter)
+	response.Purged += purged
+	if err != nil {
+		return response, fmt.Errorf("failed to purge deleted archived jobs: %w", err)
+	}
+
+	return response, nil
+}
+
+// RemoveJobs deletes the jobs matching filter, with their uploaded files, or archives them. Jobs still
+// queued or processing belong to the workers, so matching any of them refuses the whole request with
+// ErrJobsInProgress.
+func (s *RetentionService) RemoveJobs(ctx context.Context, filter repositories.JobBulkFilter, archive, dryRun bool) (*models.BulkDeleteJobsResponse, error) {
+	jobs, err := s.repository.FindJobs(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find matching jobs: %w", err)
+	}
+
+	response := &models.BulkDeleteJobsResponse{
+		Action:  "delete",
+		DryRun:  dryRun,
+		Matched: len(jobs),
+		JobIDs:  make([]string, 0, len(jobs)),
+	}
+	if archive {
+		response.Action = "archive"
+	}
+	for _, job := range jobs {
+		if job.Status == models.StatusQueued || job.Status == models.StatusProcessing {
+			return nil, fmt.Errorf("%w: job %s is %s", ErrJobsInProgress, job.ID.Hex(), job.Status)
+		}
+		response.JobIDs = append(response.JobIDs, job.ID.Hex())
+	}
+	if dryRun || len(jobs) == 0 {
+		return response, nil
+	}
+
+	// Only the jobs checked above, not ones created since
+	filter = repositories.JobBulkFilter{IDs: response.JobIDs}
+	if archive {
+		if response.Affected, err = s.repository.ArchiveJobs(ctx, filter); err != nil {
+			return response, fmt.Errorf("failed to archive jobs: %w", err)
+		}
+		return response, nil
+	}
+
+	// Files go first so a failure leaves the jobs in place to retry
+	s.fileService.RemoveJobUploads(ctx, jobs)
+	if response.Affected, err = s.repository.DeleteJobs(ctx, filter); err != nil {
+		return response, fmt.Errorf("failed to delete jobs: %w", err)
+	}
+	return response, nil
+}
+
+// Run applies the retention policy periodically until ctx is cancelled
+func (s *RetentionService) Run(ctx context.Context) {
+	retention := s.config.Retention
+	if retention.Days <= 0 || retention.Interval <= 0 {
+		log.Println("Retention policy disabled")
+		return
+	}
+
+	for {
+		if err := s.applyPolicy(ctx); err != nil {
+			log.Printf("Error applying retention policy: %v", err)
+		}
+
+		select {
+		case <-time.After(retention.Interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// applyPolicy erases the documents and uploaded files of finished jobs, live or archived, created before
+// the retention period, keeping their results, and purges jobs soft-deleted before it
+func (s *RetentionService) applyPolicy(ctx context.Context) error {
+	cutoff := time.Now().AddDate(0, 0, -s.config.Retention.Days)
+
+	jobs, err := s.repository.FindJobs(ctx, repositories.JobBulkFilter{OlderThan: cutoff, WithContent: true})
+	if err != nil {
+		return fmt.Errorf("failed to find expired jobs: %w", err)
+	}
+	archived, err := s.repository.FindJobs(ctx, repositories.JobBulkFilter{OlderThan: cutoff, WithContent: true, Archived: true})
+	if err != nil {
+		return fmt.Errorf("failed to find expired archived jobs: %w", err)
+	}
+	jobs = append(jobs, archived...)
+	if len(jobs) > 0 {
+		ids := make([]string, 0, len(jobs))
+		for _, job := range jobs {
+			ids = append(ids, job.ID.Hex())
+		}
+
+		files := s.fileService.RemoveJobUploads(ctx, jobs)
+		erased, err := s.repository.EraseJobContent(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to erase expired job content: %w", err)
+		}
+		log.Printf("Retention: erased the documents of %d jobs and removed %d files", erased, files)
+	}
+
+	purged, err := s.PurgeDeletedJobs(ctx, cutoff, false)
+	if err != nil {
+		return err
+	}
+	if purged.Purged > 0 {
+		log.Printf("Retention: purged %d deleted jobs", purged.Purged)
+	}
+
+	return nil
+}
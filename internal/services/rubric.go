@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-cv-summarize/internal/models"
+	"ai-cv-summarize/internal/rag"
+)
+
+// Names of the rubrics that drive the CV and project evaluation steps
+const (
+	CVRubricName      = "default"
+	ProjectRubricName = "project-default"
+)
+
+// legacyCriterionKeys maps the names of criteria stored before rubrics carried keys to their score fields
+var legacyCriterionKeys = map[string]string{
+	"Technical Skills Match":     "technical_skills",
+	"Experience Level":           "experience_level",
+	"Relevant Achievements":      "achievements",
+	"Cultural/Collaboration Fit": "cultural_fit",
+	"Correctness":                "correctness",
+	"Code Quality":               "code_quality",
+	"Resilience":                 "resilience",
+	"Documentation":              "documentation",
+	"Creativity":                 "creativity",
+}
+
+// PromptCriterion is a rubric criterion as presented to prompt templates
+type PromptCriterion struct {
+	Number        int
+	Key           string
+	Name          string
+	Description   string
+	WeightPercent float64
+	MaxScore      float64
+}
+
+// defaultCVRubric is used when no CV rubric is stored
+func defaultCVRubric() *models.ScoringRubric {
+	return &models.ScoringRubric{
+		Name:        CVRubricName,
+		Description: "Default scoring rubric for candidate evaluation",
+		Criteria: []models.RubricCriteria{
+			{Key: "technical_skills", Name: "Technical Skills Match", Description: "Alignment with job requirements (backend, databases, APIs, cloud, AI/LLM)", Weight: 0.4, MaxScore: 5.0},
+			{Key: "experience_level", Name: "Experience Level", Description: "Years of experience and project complexity", Weight: 0.25, MaxScore: 5.0},
+			{Key: "achievements", Name: "Relevant Achievements", Description: "Impact of past work (scaling, performance, adoption)", Weight: 0.2, MaxScore: 5.0},
+			{Key: "cultural_fit", Name: "Cultural/Collaboration Fit", Description: "Communication, learning mindset, teamwork/leadership", Weight: 0.15, MaxScore: 5.0},
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
+// defaultProjectRubric is used when no project rubric is stored
+func defaultProjectRubric() *models.ScoringRubric {
+	return &models.ScoringRubric{
+		Name:        ProjectRubricName,
+		Description: "Default scoring rubric for take-home project evaluation",
+		Criteria: []models.RubricCriteria{
+			{Key: "correctness", Name: "Correctness", Description: "Prompt design, LLM chaining, RAG, error handling", Weight: 0.3, MaxScore: 5.0},
+			{Key: "code_quality", Name: "Code Quality", Description: "Clean, modular, testable code", Weight: 0.25, MaxScore: 5.0},
+			{Key: "resilience", Name: "Resilience", Description: "Handles failures, retries, error handling", Weight: 0.2, MaxScore: 5.0},
+			{Key: "documentation", Name: "Documentation", Description: "Clear README, setup instructions, trade-offs", Weight: 0.15, MaxScore: 5.0},
+			{Key: "creativity", Name: "Creativity", Description: "Extra features beyond requirements", Weight: 0.1, MaxScore: 5.0},
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
+// criterionKey returns the response field prefix for a criterion, e.g. "technical_skills" for "technical_skills_score"
+func criterionKey(criterion models.RubricCriteria) string {
+	if criterion.Key != "" {
+		return criterion.Key
+	}
+	if key, ok := legacyCriterionKeys[criterion.Name]; ok {
+		return key
+	}
+
+	// Derive a snake_case key from the name
+	var sb strings.Builder
+	for _, r := range strings.ToLower(criterion.Name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		} else if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "_") {
+			sb.WriteByte('_')
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "_")
+}
+
+// loadRubric fetches a stored rubric by name, falling back to the built-in one when it is missing or has no usable criteria.
+// An organization's default rubric for the step takes precedence over the named one.
+func (es *EvaluationService) loadRubric(ctx context.Context, name string, fallback func() *models.ScoringRubric) *models.ScoringRubric {
+	var (
+		rubric *models.ScoringRubric
+		err    error
+	)
+	if id := es.organizationRubricID(ctx, name); id != "" {
+		rubric, err = es.repository.GetScoringRubric(ctx, id)
+	} else {
+		rubric, err = es.repository.GetScoringRubricByName(ctx, name)
+	}
+	if err != nil || rubric == nil || len(rubric.Criteria) == 0 {
+		return fallback()
+	}
+
+	var totalWeight float64
+	for _, criterion := range rubric.Criteria {
+		totalWeight += criterion.Weight
+	}
+	if totalWeight <= 0 {
+		log.Printf("Warning: rubric %s has no positive weights, using the built-in rubric", name)
+		return fallback()
+	}
+
+	return rubric
+}
+
+// jobRubrics resolves the CV and project rubrics of a job: the rubrics it pins, else the stored defaults,
+// with its weight overrides applied
+func (es *EvaluationService) jobRubrics(ctx context.Context, job *models.EvaluationJob) (cvRubric, projectRubric *models.ScoringRubric, err error) {
+	var cvWeights, projectWeights map[string]float64
+	if job.Weights != nil {
+		cvWeights, projectWeights = job.Weights.CV, job.Weights.Project
+	}
+
+	cvRubric, err = es.jobRubric(ctx, "CV", CVRubricName, job.CVRubricID, cvWeights, defaultCVRubric)
+	if err != nil {
+		return nil, nil, err
+	}
+	projectRubric, err = es.jobRubric(ctx, "project", ProjectRubricName, job.ProjectRubricID, projectWeights, defaultProjectRubric)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cvRubric, projectRubric, nil
+}
+
+// jobRubric loads the rubric pinned by rubricID, or the named default rubric, and applies weight overrides.
+// kind names the step in errors.
+func (es *EvaluationService) jobRubric(ctx context.Context, kind, name, rubricID string, weights map[string]float64, fallback func() *models.ScoringRubric) (*models.ScoringRubric, error) {
+	var rubric *models.ScoringRubric
+	if rubricID == "" {
+		rubric = es.loadRubric(ctx, name, fallback)
+	} else {
+		var err error
+		if rubric, err = es.repository.GetScoringRubric(ctx, rubricID); err != nil {
+			return nil, fmt.Errorf("%s rubric %s not found", kind, rubricID)
+		}
+		if len(rubric.Criteria) == 0 {
+			return nil, fmt.Errorf("%s rubric %s has no criteria", kind, rubricID)
+		}
+	}
+
+	rubric, err := es.scoringService.ApplyWeights(rubric, weights)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s weights: %w", kind, err)
+	}
+	return rubric, nil
+}
+
+// CheckScoring fails when a job's scoring options cannot be applied, e.g. a pinned rubric does not exist
+// or a weight names a criterion the rubric lacks
+func (es *EvaluationService) CheckScoring(ctx context.Context, job *models.EvaluationJob) error {
+	if _, _, err := es.jobRubrics(ctx, job); err != nil {
+		return err
+	}
+	if job.Weights != nil {
+		if err := es.scoringService.ValidateOverallWeights(job.Weights.Overall); err != nil {
+			return fmt.Errorf("invalid overall weights: %w", err)
+		}
+	}
+	if err := rag.ValidateRetrievalOptions(job.RetrievalOptions); err != nil {
+		return fmt.Errorf("invalid retrieval options: %w", err)
+	}
+	return validateFeedbackStyle(job)
+}
+
+// organizationRubricID returns the context organization's default rubric for the named step, if it has one
+func (es *EvaluationService) organizationRubricID(ctx context.Context, name string) string {
+	org := es.organization(ctx)
+	if org == nil {
+		return ""
+	}
+
+	switch name {
+	case CVRubricName:
+		return org.DefaultCVRubricID
+	case ProjectRubricName:
+		return org.DefaultProjectRubricID
+	}
+	return ""
+}
+
+// rubricVersion identifies a rubric as a result was scored with it, fingerprinting the parts that affect scoring
+func rubricVersion(rubric *models.ScoringRubric) *models.RubricVersion {
+	version := &models.RubricVersion{Name: rubric.Name}
+	if !rubric.ID.IsZero() {
+		version.ID = rubric.ID.Hex()
+	}
+	if scoring, err := json.Marshal([]interface{}{rubric.Criteria, rubric.Scale}); err == nil {
+		sum := sha256.Sum256(scoring)
+		version.Hash = hex.EncodeToString(sum[:8])
+	}
+	return version
+}
+
+// promptCriteria lists a rubric's criteria for prompt templates
+func promptCriteria(rubric *models.ScoringRubric) []PromptCriterion {
+	var totalWeight float64
+	for _, criterion := range rubric.Criteria {
+		totalWeight += criterion.Weight
+	}
+
+	criteria := make([]PromptCriterion, 0, len(rubric.Criteria))
+	for i, criterion := range rubric.Criteria {
+		maxScore := criterion.MaxScore
+		if maxScore <= 0 {
+			maxScore = 5
+		}
+		criteria = append(criteria, PromptCriterion{
+			Number:        i + 1,
+			Key:           criterionKey(criterion),
+			Name:          criterion.Name,
+			Description:   criterion.Description,
+			WeightPercent: criterion.Weight / totalWeight * 100,
+			MaxScore:      maxScore,
+		})
+	}
+
+	return criteria
+}
+
+// parseCriterionScores reads the "<key>_score" field for every rubric criterion from a structured response.
+// Missing scores count as zero.
+func parseCriterionScores(response string, rubric *models.ScoringRubric) (map[string]float64, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(response), &fields); err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float64, len(rubric.Criteria))
+	for _, criterion := range rubric.Criteria {
+		key := criterionKey(criterion)
+		switch value := fields[key+"_score"].(type) {
+		case float64:
+			scores[key] = value
+		case nil:
+			scores[key] = 0
+		default:
+			return nil, fmt.Errorf("%s_score is not a number", key)
+		}
+	}
+
+	return scores, nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"encoding/json"
+
+	"ai-cv-summarize/internal/llm"
+)
+
+// cvAnalysisSchema constrains the analyze_cv response
+var cvAnalysisSchema = &llm.Schema{
+	Name:        "cv_analysis",
+	Description: "Structured information extracted from a CV",
+	Definition: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "technical_skills": {"type": "array", "items": {"type": "string"}},
+    "experience_years": {"type": "integer"},
+    "projects": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "description": {"type": "string"},
+          "technologies": {"type": "array", "items": {"type": "string"}},
+          "impact": {"type": "string"}
+        },
+        "required": ["name", "description", "technologies", "impact"]
+      }
+    },
+    "achievements": {"type": "array", "items": {"type": "string"}},
+    "education": {"type": "string"},
+    "certifications": {"type": "array", "items": {"type": "string"}}
+  },
+  "required": ["technical_skills", "experience_years", "projects", "achievements", "education", "certifications"]
+}`),
+}
+
+// feedbackLetterSchema constrains the feedback_letter response
+var feedbackLetterSchema = &llm.Schema{
+	Name:        "feedback_letter",
+	Description: "An email to a job candidate",
+	Definition: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "subject": {"type": "string"},
+    "body": {"type": "string"}
+  },
+  "required": ["subject", "body"]
+}`),
+}
+
+// parsedResumeSchema constrains the parse_resume response
+var parsedResumeSchema = &llm.Schema{
+	Name:        "parsed_resume",
+	Description: "Employment history, education and skills extracted from a CV",
+	Definition: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "location": {"type": "string"},
+    "experience": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "title": {"type": "string"},
+          "company": {"type": "string"},
+          "location": {"type": "string"},
+          "start_date": {"type": "string"},
+          "end_date": {"type": "string"},
+          "description": {"type": "string"}
+        },
+        "required": ["title", "company", "start_date", "end_date"]
+      }
+    },
+    "education": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "institution": {"type": "string"},
+          "degree": {"type": "string"},
+          "field": {"type": "string"},
+          "start_date": {"type": "string"},
+          "end_date": {"type": "string"}
+        },
+        "required": ["institution"]
+      }
+    },
+    "skills": {"type": "array", "items": {"type": "string"}}
+  },
+  "required": ["experience", "education", "skills"]
+}`),
+}
+
+// verifySchema constrains the verify_evaluation response
+var verifySchema = &llm.Schema{
+	Name:        "evaluation_review",
+	Description: "Consistency review of a candidate evaluation",
+	Definition: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "consistent": {"type": "boolean"},
+    "issues": {"type": "array", "items": {"type": "string"}},
+    "cv_scores": {"type": "object", "additionalProperties": {"type": "number"}},
+    "project_scores": {"type": "object", "additionalProperties": {"type": "number"}},
+    "cv_feedback": {"type": "string"},
+    "project_feedback": {"type": "string"}
+  },
+  "required": ["consistent", "issues"]
+}`),
+}
+
+// criteriaSchema constrains an evaluation response to one "<key>_score" per rubric criterion plus feedback
+func criteriaSchema(name, description string, criteria []PromptCriterion, withMatchRate bool) *llm.Schema {
+	properties := map[string]interface{}{
+		"feedback": map[string]interface{}{"type": "string"},
+	}
+	required := []string{}
+
+	for _, criterion := range criteria {
+		field := criterion.Key + "_score"
+		properties[field] = map[string]interface{}{
+			"type":        "number",
+			"minimum":     1,
+			"maximum":     criterion.MaxScore,
+			"description": criterion.Name,
+		}
+		required = append(required, field)
+	}
+	if withMatchRate {
+		properties["match_rate"] = map[string]interface{}{"type": "number"}
+	}
+	required = append(required, "feedback")
+
+	definition, _ := json.Marshal(map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	})
+
+	return &llm.Schema{
+		Name:        name,
+		Description: description,
+		Definition:  definition,
+	}
+}
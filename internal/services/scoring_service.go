@@ -8,11 +8,14 @@ import (
 	"ai-cv-summarize/internal/repositories"
 )
 
+// defaultMaxScore is the raw scale of step scores for rubrics without a scale
+const defaultMaxScore = 5.0
+
 type ScoringService struct {
-	repository *repositories.MongoDBRepository
+	repository repositories.Repository
 }
 
-func NewScoringService(repository *repositories.MongoDBRepository) *ScoringService {
+func NewScoringService(repository repositories.Repository) *ScoringService {
 	return &ScoringService{
 		repository: repository,
 	}
@@ -41,6 +44,86 @@ func (ss *ScoringService) CalculateProjectScore(scores models.ProjectScores) flo
 	return math.Round(weightedSum*100) / 100 // Round to 2 decimal places
 }
 
+// WeightedScore combines criterion scores with the rubric weights. Each score is taken as a fraction of its
+// criterion's max score, so the result is between 0 and 1.
+func (ss *ScoringService) WeightedScore(rubric *models.ScoringRubric, scores map[string]float64) float64 {
+	var weightedSum, totalWeight float64
+	for _, criterion := range rubric.Criteria {
+		maxScore := criterion.MaxScore
+		if maxScore <= 0 {
+			maxScore = defaultMaxScore
+		}
+		weightedSum += criterion.Weight * ss.NormalizeScore(scores[criterionKey(criterion)], maxScore)
+		totalWeight += criterion.Weight
+	}
+
+	if totalWeight <= 0 {
+		return 0
+	}
+	return weightedSum / totalWeight
+}
+
+// RubricScale returns a rubric's scale with unset values defaulted: scores out of 5, displayed as is
+func (ss *ScoringService) RubricScale(rubric *models.ScoringRubric) models.RubricScale {
+	scale := models.RubricScale{MaxScore: defaultMaxScore}
+	if rubric.Scale != nil && rubric.Scale.MaxScore > 0 {
+		scale.MaxScore = rubric.Scale.MaxScore
+	}
+	scale.DisplayMax = scale.MaxScore
+	if rubric.Scale != nil && rubric.Scale.DisplayMax > 0 {
+		scale.DisplayMax = rubric.Scale.DisplayMax
+	}
+	return scale
+}
+
+// DisplayScore converts a score between 0 and maxScore to the display scale, rounded to 2 decimal places
+func (ss *ScoringService) DisplayScore(score, maxScore float64, scale models.RubricScale) float64 {
+	return math.Round(ss.NormalizeScore(score, maxScore)*scale.DisplayMax*100) / 100
+}
+
+// DisplayScores converts a result's step, overall and criterion scores to the display scales of its rubrics.
+// The overall score is on the CV rubric's scale.
+func (ss *ScoringService) DisplayScores(result *models.EvaluationResult, cvRubric, projectRubric *models.ScoringRubric) (*models.ResultScale, *models.DisplayScores) {
+	scale := &models.ResultScale{
+		CV:      ss.RubricScale(cvRubric),
+		Project: ss.RubricScale(projectRubric),
+	}
+	scale.Overall = scale.CV
+
+	display := &models.DisplayScores{
+		CVScore:         ss.DisplayScore(result.CVMatchRate, 1, scale.CV),
+		ProjectScore:    ss.DisplayScore(result.ProjectScore, scale.Project.MaxScore, scale.Project),
+		OverallScore:    ss.DisplayScore(result.OverallScore, scale.Overall.MaxScore, scale.Overall),
+		CVCriteria:      ss.displayCriteria(result.CVCriteria, cvRubric, scale.CV),
+		ProjectCriteria: ss.displayCriteria(result.ProjectCriteria, projectRubric, scale.Project),
+	}
+
+	return scale, display
+}
+
+// displayCriteria converts criterion scores, each out of its criterion's max score, to the display scale
+func (ss *ScoringService) displayCriteria(scores map[string]float64, rubric *models.ScoringRubric, scale models.RubricScale) map[string]float64 {
+	if len(scores) == 0 {
+		return nil
+	}
+
+	display := make(map[string]float64, len(scores))
+	for _, criterion := range rubric.Criteria {
+		key := criterionKey(criterion)
+		score, ok := scores[key]
+		if !ok {
+			continue
+		}
+		maxScore := criterion.MaxScore
+		if maxScore <= 0 {
+			maxScore = defaultMaxScore
+		}
+		display[key] = ss.DisplayScore(score, maxScore, scale)
+	}
+
+	return display
+}
+
 // NormalizeScore normalizes a score to a 0-1 range
 func (ss *ScoringService) NormalizeScore(score, maxScore float64) float64 {
 	if maxScore == 0 {
@@ -49,14 +132,82 @@ func (ss *ScoringService) NormalizeScore(score, maxScore float64) float64 {
 	return math.Min(score/maxScore, 1.0)
 }
 
-// CalculateOverallScore calculates the overall candidate score
-func (ss *ScoringService) CalculateOverallScore(cvScore, projectScore float64) float64 {
-	// 60% CV score, 40% project score
-	overallScore := (cvScore * 0.6) + (projectScore * 0.4)
+// ApplyWeights returns a copy of the rubric with the weights of the criteria named by key replaced.
+// It fails on unknown keys, negative weights, or when no weight remains positive.
+func (ss *ScoringService) ApplyWeights(rubric *models.ScoringRubric, weights map[string]float64) (*models.ScoringRubric, error) {
+	if len(weights) == 0 {
+		return rubric, nil
+	}
+
+	weighted := *rubric
+	weighted.Criteria = make([]models.RubricCriteria, len(rubric.Criteria))
+	copy(weighted.Criteria, rubric.Criteria)
+
+	applied := 0
+	var totalWeight float64
+	for i, criterion := range weighted.Criteria {
+		if weight, ok := weights[criterionKey(criterion)]; ok {
+			if weight < 0 {
+				return nil, fmt.Errorf("weight of %s must not be negative", criterionKey(criterion))
+			}
+			weighted.Criteria[i].Weight = weight
+			applied++
+		}
+		totalWeight += weighted.Criteria[i].Weight
+	}
+
+	if applied < len(weights) {
+		for key := range weights {
+			if !hasCriterion(rubric, key) {
+				return nil, fmt.Errorf("rubric %s has no criterion %s", rubric.Name, key)
+			}
+		}
+	}
+	if totalWeight <= 0 {
+		return nil, fmt.Errorf("at least one criterion of rubric %s needs a positive weight", rubric.Name)
+	}
+
+	return &weighted, nil
+}
+
+// hasCriterion reports whether the rubric has a criterion with the given key
+func hasCriterion(rubric *models.ScoringRubric, key string) bool {
+	for _, criterion := range rubric.Criteria {
+		if criterionKey(criterion) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateOverallWeights checks that CV and project weights can be combined into an overall score
+func (ss *ScoringService) ValidateOverallWeights(weights *models.OverallWeights) error {
+	if weights == nil {
+		return nil
+	}
+	if weights.CV < 0 || weights.Project < 0 {
+		return fmt.Errorf("overall weights must not be negative")
+	}
+	if weights.CV+weights.Project <= 0 {
+		return fmt.Errorf("at least one overall weight must be positive")
+	}
+	return nil
+}
+
+// CalculateOverallScore calculates the overall candidate score, weighing the CV and project scores with
+// weights, or 60% CV and 40% project when weights is nil
+func (ss *ScoringService) CalculateOverallScore(cvScore, projectScore float64, weights *models.OverallWeights) float64 {
+	w := models.OverallWeights{CV: 0.6, Project: 0.4}
+	if weights != nil && weights.CV+weights.Project > 0 {
+		w = *weights
+	}
+
+	overallScore := (cvScore*w.CV + projectScore*w.Project) / (w.CV + w.Project)
 	return math.Round(overallScore*100) / 100
 }
 
-// GetScoreInterpretation returns a human-readable interpretation of the score
+// GetScoreInterpretation returns a human-readable interpretation of the score. Hiring decisions use
+// Recommend with calibrated thresholds instead.
 func (ss *ScoringService) GetScoreInterpretation(score float64) string {
 	switch {
 	case score >= 4.5:
@@ -74,6 +225,27 @@ func (ss *ScoringService) GetScoreInterpretation(score float64) string {
 	}
 }
 
+// ValidateRecommendationThresholds checks that thresholds are fractions with the hire threshold above zero
+// and not above the strong hire threshold
+func ValidateRecommendationThresholds(thresholds models.RecommendationThresholds) error {
+	if thresholds.Hire <= 0 || thresholds.Hire > thresholds.StrongHire || thresholds.StrongHire > 1 {
+		return fmt.Errorf("thresholds must satisfy 0 < hire <= strong_hire <= 1")
+	}
+	return nil
+}
+
+// Recommend maps an overall score, as a fraction of the overall scale's maximum, to a hiring recommendation
+func (ss *ScoringService) Recommend(score float64, thresholds models.RecommendationThresholds) string {
+	switch {
+	case score >= thresholds.StrongHire:
+		return models.RecommendationStrongHire
+	case score >= thresholds.Hire:
+		return models.RecommendationHire
+	default:
+		return models.RecommendationNoHire
+	}
+}
+
 // ValidateScore validates if a score is within acceptable range
 func (ss *ScoringService) ValidateScore(score float64) error {
 	if score < 0 || score > 5 {
@@ -103,6 +275,7 @@ func (ss *ScoringService) GetScoreBreakdown(scores models.CVScores, projectScore
 		"overall_score": ss.CalculateOverallScore(
 			ss.CalculateCVScore(scores),
 			ss.CalculateProjectScore(projectScores),
+			nil,
 		),
 	}
 }
@@ -112,6 +285,7 @@ func (ss *ScoringService) GenerateScoreReport(result *models.EvaluationResult) m
 	overallScore := ss.CalculateOverallScore(
 		ss.CalculateCVScore(result.CVScores),
 		ss.CalculateProjectScore(result.ProjectScores),
+		nil,
 	)
 
 	return map[string]interface{}{
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/repositories"
+	"ai-cv-summarize/internal/tenant"
+)
+
+// cleanupBatchSize caps the number of file names looked up in one query
+const cleanupBatchSize = 500
+
+// UploadCleanupService deletes uploaded files that no evaluation job uses once they are older than the
+// orphan TTL: uploads that were never evaluated, files left behind by purged jobs and direct uploads that
+// were never confirmed
+type UploadCleanupService struct {
+	repository    repositories.Repository
+	fileService   *FileService
+	objectStorage *ObjectStorage
+	config        *config.UploadConfig
+}
+
+func NewUploadCleanupService(repository repositories.Repository, fileService *FileService, objectStorage *ObjectStorage, cfg *config.UploadConfig) *UploadCleanupService {
+	return &UploadCleanupService{
+		repository:    repository,
+		fileService:   fileService,
+		objectStorage: objectStorage,
+		config:        cfg,
+	}
+}
+
+// Run removes orphaned uploads periodically until ctx is cancelled
+func (s *UploadCleanupService) Run(ctx context.Context) {
+	if s.config.OrphanTTL <= 0 || s.config.CleanupInterval <= 0 {
+		log.Println("Orphaned upload cleanup disabled")
+		return
+	}
+
+	for {
+		if err := s.cleanup(ctx); err != nil {
+			log.Printf("Error cleaning up orphaned uploads: %v", err)
+		}
+
+		select {
+		case <-time.After(s.config.CleanupInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// cleanup drops the upload records no job of their organization uses, then deletes the files that are
+// left without a record or a job, and the abandoned objects in the bucket
+func (s *UploadCleanupService) cleanup(ctx context.Context) error {
+	cutoff := time.Now().Add(-s.config.OrphanTTL)
+
+	records, err := s.removeOrphanedRecords(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	files, err := s.removeUnusedFiles(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	objects, err := s.removeAbandonedObjects(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	if records+files+objects > 0 {
+		log.Printf("Upload cleanup: removed %d upload records, %d files and %d abandoned direct uploads", records, files, objects)
+	}
+	return nil
+}
+
+// removeOrphanedRecords deletes the records of files uploaded before the cutoff that no job of the
+// uploading organization uses, and returns how many were deleted
+func (s *UploadCleanupService) removeOrphanedRecords(ctx context.Context, cutoff time.Time) (int64, error) {
+	uploads, err := s.repository.FindUploadsBefore(ctx, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find old uploads: %w", err)
+	}
+
+	byOrg := map[string][]string{}
+	for _, upload := range uploads {
+		byOrg[upload.OrgID] = append(byOrg[upload.OrgID], upload.Filename)
+	}
+
+	var removed int64
+	for orgID, filenames := range byOrg {
+		// Background tasks are unscoped; a file is only in use for the organization whose job uses it
+		orgCtx := ctx
+		if orgID != "" {
+			orgCtx = tenant.WithOrgID(ctx, orgID)
+		}
+		orphaned, err := s.unusedFiles(orgCtx, filenames)
+		if err != nil {
+			return removed, err
+		}
+		if len(orphaned) == 0 {
+			continue
+		}
+		deleted, err := s.repository.DeleteUploads(orgCtx, orphaned)
+		if err != nil {
+			return removed, fmt.Errorf("failed to delete orphaned upload records: %w", err)
+		}
+		removed += deleted
+	}
+	return removed, nil
+}
+
+// removeUnusedFiles deletes the files in the upload directory last written before the cutoff that have no
+// upload record and no job, and returns how many were deleted. This also catches files saved before
+// uploads were recorded, and temporary files of interrupted uploads.
+func (s *UploadCleanupService) removeUnusedFiles(ctx context.Context, cutoff time.Time) (int64, error) {
+	entries, err := os.ReadDir(s.fileService.uploadDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read upload directory: %w", err)
+	}
+
+	var removed int64
+	remove := func(name string) {
+		if err := os.Remove(filepath.Join(s.fileService.uploadDir, name)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error removing orphaned upload %s: %v", name, err)
+			return
+		}
+		removed++
+	}
+
+	var candidates []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if strings.HasPrefix(entry.Name(), ".upload-") {
+			remove(entry.Name())
+			continue
+		}
+		candidates = append(candidates, entry.Name())
+	}
+
+	unused, err := s.unusedFiles(ctx, candidates)
+	if err != nil {
+		return removed, err
+	}
+	for _, name := range unused {
+		// A record means an organization uploaded the file again since its old record was dropped
+		records, err := s.repository.CountUploads(ctx, name)
+		if err != nil {
+			return removed, fmt.Errorf("failed to count upload records: %w", err)
+		}
+		if records == 0 {
+			remove(name)
+		}
+	}
+	return removed, nil
+}
+
+// removeAbandonedObjects deletes the objects uploaded to presigned URLs before the cutoff, which were never
+// confirmed since confirming removes them, and returns how many were deleted
+func (s *UploadCleanupService) removeAbandonedObjects(ctx context.Context, cutoff time.Time) (int64, error) {
+	if !s.objectStorage.Enabled() {
+		return 0, nil
+	}
+
+	uploadIDs, err := s.objectStorage.ListUploadsBefore(ctx, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list direct uploads: %w", err)
+	}
+
+	var removed int64
+	for _, uploadID := range uploadIDs {
+		if err := s.objectStorage.DeleteUpload(ctx, uploadID); err != nil {
+			log.Printf("Error removing abandoned direct upload %s: %v", uploadID, err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// unusedFiles returns the files no job in ctx's organization uses, looking them up in batches
+func (s *UploadCleanupService) unusedFiles(ctx context.Context, filenames []string) ([]string, error) {
+	var unused []string
+	for start := 0; start < len(filenames); start += cleanupBatchSize {
+		batch := filenames[start:min(start+cleanupBatchSize, len(filenames))]
+		referenced, err := s.repository.FindReferencedFiles(ctx, batch, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find files used by jobs: %w", err)
+		}
+		for _, name := range batch {
+			if !slices.Contains(referenced, name) {
+				unused = append(unused, name)
+			}
+		}
+	}
+	return unused, nil
+}
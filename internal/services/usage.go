@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"ai-cv-summarize/internal/config"
+	"ai-cv-summarize/internal/llm"
+	"ai-cv-summarize/internal/models"
+)
+
+// priceUsage converts recorded LLM usage into token counts and estimated cost, or returns nil when
+// no call reported usage
+func priceUsage(pricing *config.PricingConfig, usage []llm.Usage) *models.JobUsage {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	jobUsage := &models.JobUsage{Models: make([]models.ModelUsage, 0, len(usage))}
+	for _, u := range usage {
+		price := modelPrice(pricing, u.Model)
+		modelUsage := models.ModelUsage{
+			Model: u.Model,
+			TokenUsage: models.TokenUsage{
+				Calls:            u.Calls,
+				PromptTokens:     u.PromptTokens,
+				CompletionTokens: u.CompletionTokens,
+				TotalTokens:      u.PromptTokens + u.CompletionTokens,
+				EstimatedCostUSD: (float64(u.PromptTokens)*price.Prompt + float64(u.CompletionTokens)*price.Completion) / 1000,
+			},
+		}
+		jobUsage.Models = append(jobUsage.Models, modelUsage)
+		jobUsage.Add(modelUsage.TokenUsage)
+	}
+
+	return jobUsage
+}
+
+// modelPrice looks a model up by exact name, then by the longest configured prefix so dated versions
+// such as gpt-4-0613 use their family's price. Provider prefixes like "openai/" are ignored; unknown models are free.
+func modelPrice(pricing *config.PricingConfig, model string) config.ModelPrice {
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	if price, ok := pricing.Models[model]; ok {
+		return price
+	}
+
+	var (
+		match string
+		price config.ModelPrice
+	)
+	for name, candidate := range pricing.Models {
+		if strings.HasPrefix(model, name) && len(name) > len(match) {
+			match, price = name, candidate
+		}
+	}
+	return price
+}
+
+// saveUsage stores the usage of an evaluation attempt on the job and adds it to the daily totals.
+// Usage is informational, so failures are only logged.
+func (es *EvaluationService) saveUsage(ctx context.Context, job *models.EvaluationJob, recorder *llm.UsageRecorder) {
+	usage := priceUsage(&es.config.Pricing, recorder.Usage())
+	if usage == nil {
+		return
+	}
+
+	// Tokens spent by a failed or timed-out attempt are still billed, so record them regardless
+	ctx = context.WithoutCancel(ctx)
+	jobID := job.ID.Hex()
+	if err := es.repository.UpdateJobUsage(ctx, jobID, usage); err != nil {
+		log.Printf("Warning: failed to record usage of job %s: %v", jobID, err)
+	}
+	if err := es.repository.IncrementUsageTotals(ctx, time.Now().UTC().Format("2006-01-02"), job.OrgID, usage.Models); err != nil {
+		log.Printf("Warning: failed to update usage totals for job %s: %v", jobID, err)
+	}
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Legacy .doc files are Compound File Binary containers holding a WordDocument stream. Only what text
+// extraction needs is read: the container's sector chains and directory, the FIB and the piece table.
+
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+const (
+	cfbEndOfChain = 0xFFFFFFFE
+	cfbFreeSect   = 0xFFFFFFFF
+	// cfbMaxSectors bounds chain walks so a corrupt file with a looping chain cannot hang extraction
+	cfbMaxSectors = 1 << 20
+)
+
+// compoundFile is a parsed Compound File Binary container
+type compoundFile struct {
+	data           []byte
+	sectorSize     int
+	miniSectorSize int
+	miniCutoff     uint32
+	fat            []uint32
+	miniFAT        []uint32
+	miniStream     []byte
+	streams        map[string]cfbEntry
+}
+
+// cfbEntry is a stream in the container's directory
+type cfbEntry struct {
+	start uint32
+	size  uint32
+}
+
+// openCompoundFile parses the header, allocation tables and directory of a Compound File Binary container
+func openCompoundFile(data []byte) (*compoundFile, error) {
+	if len(data) < 512 || !bytes.Equal(data[:8], cfbSignature) {
+		return nil, errors.New("not a Word 97-2003 document")
+	}
+
+	le := binary.LittleEndian
+	cf := &compoundFile{
+		data:           data,
+		sectorSize:     1 << le.Uint16(data[0x1E:]),
+		miniSectorSize: 1 << le.Uint16(data[0x20:]),
+		miniCutoff:     le.Uint32(data[0x38:]),
+		streams:        map[string]cfbEntry{},
+	}
+	if cf.sectorSize != 512 && cf.sectorSize != 4096 {
+		return nil, fmt.Errorf("unsupported sector size %d", cf.sectorSize)
+	}
+
+	// The first 109 FAT sector numbers are in the header, the rest in a chain of DIFAT sectors
+	var fatSectors []uint32
+	for i := 0; i < 109; i++ {
+		if sector := le.Uint32(data[0x4C+i*4:]); sector != cfbFreeSect {
+			fatSectors = append(fatSectors, sector)
+		}
+	}
+	perSector := cf.sectorSize/4 - 1
+	for sector, n := le.Uint32(data[0x44:]), 0; sector != cfbEndOfChain && sector != cfbFreeSect && n < cfbMaxSectors; n++ {
+		block, err := cf.sector(sector)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < perSector; i++ {
+			if entry := le.Uint32(block[i*4:]); entry != cfbFreeSect {
+				fatSectors = append(fatSectors, entry)
+			}
+		}
+		sector = le.Uint32(block[perSector*4:])
+	}
+	for _, sector := range fatSectors {
+		block, err := cf.sector(sector)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < cf.sectorSize; i += 4 {
+			cf.fat = append(cf.fat, le.Uint32(block[i:]))
+		}
+	}
+
+	directory, err := cf.chain(le.Uint32(data[0x30:]), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var root cfbEntry
+	for offset := 0; offset+128 <= len(directory); offset += 128 {
+		entry := directory[offset : offset+128]
+		nameLength := int(le.Uint16(entry[64:]))
+		if nameLength < 2 || nameLength > 64 {
+			continue
+		}
+		units := make([]uint16, nameLength/2-1)
+		for i := range units {
+			units[i] = le.Uint16(entry[i*2:])
+		}
+		parsed := cfbEntry{start: le.Uint32(entry[116:]), size: le.Uint32(entry[120:])}
+		switch entry[66] {
+		case 5:
+			root = parsed
+		case 2:
+			cf.streams[string(utf16.Decode(units))] = parsed
+		}
+	}
+
+	// Streams smaller than the cutoff live in the mini stream, which is stored as the root entry's data
+	if miniFAT, err := cf.chain(le.Uint32(data[0x3C:]), 0); err == nil {
+		for i := 0; i+4 <= len(miniFAT); i += 4 {
+			cf.miniFAT = append(cf.miniFAT, le.Uint32(miniFAT[i:]))
+		}
+	}
+	if root.size > 0 {
+		if cf.miniStream, err = cf.chain(root.start, int(root.size)); err != nil {
+			return nil, fmt.Errorf("failed to read mini stream: %w", err)
+		}
+	}
+
+	return cf, nil
+}
+
+// sector returns the bytes of a regular sector
+func (cf *compoundFile) sector(n uint32) ([]byte, error) {
+	offset := (int(n) + 1) * cf.sectorSize
+	if n >= cfbMaxSectors || offset+cf.sectorSize > len(cf.data) {
+		return nil, fmt.Errorf("sector %d is out of range", n)
+	}
+	return cf.data[offset : offset+cf.sectorSize], nil
+}
+
+// chain concatenates the regular sectors of a chain, truncated to size when it is positive
+func (cf *compoundFile) chain(start uint32, size int) ([]byte, error) {
+	var out []byte
+	for sector, n := start, 0; sector != cfbEndOfChain && sector != cfbFreeSect; n++ {
+		if n >= cfbMaxSectors || int(sector) >= len(cf.fat) {
+			return nil, errors.New("corrupt sector chain")
+		}
+		block, err := cf.sector(sector)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, block...)
+		if size > 0 && len(out) >= size {
+			return out[:size], nil
+		}
+		sector = cf.fat[sector]
+	}
+	return out, nil
+}
+
+// stream returns the contents of a named stream
+func (cf *compoundFile) stream(name string) ([]byte, error) {
+	entry, ok := cf.streams[name]
+	if !ok {
+		return nil, fmt.Errorf("stream %s not found", name)
+	}
+	if entry.size >= cf.miniCutoff {
+		return cf.chain(entry.start, int(entry.size))
+	}
+
+	var out []byte
+	for sector, n := entry.start, 0; sector != cfbEndOfChain && sector != cfbFreeSect && len(out) < int(entry.size); n++ {
+		offset := int(sector) * cf.miniSectorSize
+		if n >= cfbMaxSectors || int(sector) >= len(cf.miniFAT) || offset+cf.miniSectorSize > len(cf.miniStream) {
+			return nil, errors.New("corrupt mini sector chain")
+		}
+		out = append(out, cf.miniStream[offset:offset+cf.miniSectorSize]...)
+		sector = cf.miniFAT[sector]
+	}
+	if len(out) < int(entry.size) {
+		return nil, fmt.Errorf("stream %s is truncated", name)
+	}
+	return out[:entry.size], nil
+}
+
+// extractWord97Text returns the text of a Word 97-2003 document by following its piece table
+func extractWord97Text(data []byte) (string, error) {
+	cf, err := openCompoundFile(data)
+	if err != nil {
+		return "", err
+	}
+	document, err := cf.stream("WordDocument")
+	if err != nil {
+		return "", err
+	}
+
+	le := binary.LittleEndian
+	if len(document) < 0x1AA || le.Uint16(document) != 0xA5EC {
+		return "", errors.New("not a Word 97-2003 document")
+	}
+	flags := le.Uint16(document[0x0A:])
+	if flags&0x0100 != 0 {
+		return "", errors.New("document is password protected")
+	}
+	tableName := "0Table"
+	if flags&0x0200 != 0 {
+		tableName = "1Table"
+	}
+	table, err := cf.stream(tableName)
+	if err != nil {
+		return "", err
+	}
+
+	fcClx, lcbClx := le.Uint32(document[0x1A2:]), le.Uint32(document[0x1A6:])
+	if uint64(fcClx)+uint64(lcbClx) > uint64(len(table)) {
+		return "", errors.New("piece table is out of range")
+	}
+	clx := table[fcClx : fcClx+lcbClx]
+
+	// Skip the formatting (Prc) entries that precede the piece table (Pcdt)
+	for len(clx) > 0 && clx[0] == 0x01 {
+		if len(clx) < 3 {
+			return "", errors.New("corrupt piece table")
+		}
+		skip := 3 + int(int16(le.Uint16(clx[1:])))
+		if skip < 3 || skip > len(clx) {
+			return "", errors.New("corrupt piece table")
+		}
+		clx = clx[skip:]
+	}
+	if len(clx) < 5 || clx[0] != 0x02 {
+		return "", errors.New("piece table not found")
+	}
+	plc := clx[5:]
+	if size := int(le.Uint32(clx[1:])); size < len(plc) {
+		plc = plc[:size]
+	}
+	pieces := (len(plc) - 4) / 12
+	if pieces <= 0 {
+		return "", errors.New("piece table is empty")
+	}
+
+	windows1252 := charmap.Windows1252.NewDecoder()
+	var text strings.Builder
+	for i := 0; i < pieces; i++ {
+		start, end := le.Uint32(plc[i*4:]), le.Uint32(plc[(i+1)*4:])
+		if end <= start {
+			continue
+		}
+		length := int(end - start)
+		fc := le.Uint32(plc[(pieces+1)*4+i*8+2:])
+
+		// Compressed pieces store one Windows-1252 byte per character at half the offset
+		if fc&0x40000000 != 0 {
+			offset := int(fc&^0x40000000) / 2
+			if offset+length > len(document) {
+				return "", errors.New("text piece is out of range")
+			}
+			decoded, err := windows1252.Bytes(document[offset : offset+length])
+			if err != nil {
+				return "", err
+			}
+			text.Write(decoded)
+			continue
+		}
+
+		offset := int(fc)
+		if offset+length*2 > len(document) {
+			return "", errors.New("text piece is out of range")
+		}
+		units := make([]uint16, length)
+		for j := range units {
+			units[j] = le.Uint16(document[offset+j*2:])
+		}
+		text.WriteString(string(utf16.Decode(units)))
+	}
+
+	return tidyExtractedText(cleanWordText(text.String())), nil
+}
+
+// cleanWordText converts Word's paragraph, cell and break marks to plain text and keeps only the displayed
+// result of fields, dropping their instructions
+func cleanWordText(raw string) string {
+	var text strings.Builder
+	// Fields nest; each open field records whether its instruction part is still being read
+	var inInstruction []bool
+	for _, r := range raw {
+		switch r {
+		case 0x13:
+			inInstruction = append(inInstruction, true)
+			continue
+		case 0x14:
+			if len(inInstruction) > 0 {
+				inInstruction[len(inInstruction)-1] = false
+			}
+			continue
+		case 0x15:
+			if len(inInstruction) > 0 {
+				inInstruction = inInstruction[:len(inInstruction)-1]
+			}
+			continue
+		}
+		if len(inInstruction) > 0 && inInstruction[len(inInstruction)-1] {
+			continue
+		}
+
+		switch {
+		case r == '\r' || r == 0x0B || r == 0x0C:
+			text.WriteByte('\n')
+		case r == 0x07:
+			text.WriteByte('\t')
+		case r == '\t' || r >= 0x20:
+			text.WriteRune(r)
+		}
+	}
+	return text.String()
+}
@@ -0,0 +1,99 @@
+package telemetry
+
+import (
+	"context"
+	"strings"
+
+	"ai-cv-summarize/internal/llm"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapLLMClient records a span for every LLM call made through the client
+func WrapLLMClient(next llm.LLMClient) llm.LLMClient {
+	return &llmClient{next: next}
+}
+
+// llmClient wraps an LLM client with tracing
+type llmClient struct {
+	next llm.LLMClient
+}
+
+// start begins an LLM call span; prompt text is left out since CVs contain personal data
+func (c *llmClient) start(ctx context.Context, operation string, prompt string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	attributes = append(attributes,
+		attribute.String("llm.operation", operation),
+		attribute.Int("llm.prompt_length", len(prompt)),
+	)
+	return Start(ctx, "llm."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+}
+
+func (c *llmClient) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	ctx, span := c.start(ctx, "embedding", text, attribute.String("llm.model", c.next.EmbeddingModel()))
+	embedding, err := c.next.GenerateEmbedding(ctx, text)
+	End(span, err)
+	return embedding, err
+}
+
+func (c *llmClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	ctx, span := c.start(ctx, "embeddings", strings.Join(texts, ""),
+		attribute.String("llm.model", c.next.EmbeddingModel()), attribute.Int("llm.batch_size", len(texts)))
+	embeddings, err := c.next.GenerateEmbeddings(ctx, texts)
+	End(span, err)
+	return embeddings, err
+}
+
+func (c *llmClient) EmbeddingModel() string {
+	return c.next.EmbeddingModel()
+}
+
+func (c *llmClient) Ping(ctx context.Context) error {
+	ctx, span := Start(ctx, "llm.ping", trace.WithSpanKind(trace.SpanKindClient))
+	err := c.next.Ping(ctx)
+	End(span, err)
+	return err
+}
+
+func (c *llmClient) GenerateCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
+	ctx, span := c.start(ctx, "completion", prompt, attribute.Float64("llm.temperature", float64(temperature)))
+	response, err := c.next.GenerateCompletion(ctx, prompt, temperature)
+	End(span, err)
+	return response, err
+}
+
+func (c *llmClient) GenerateStructuredCompletion(ctx context.Context, prompt string, temperature float32) (string, error) {
+	ctx, span := c.start(ctx, "structured_completion", prompt, attribute.Float64("llm.temperature", float64(temperature)))
+	response, err := c.next.GenerateStructuredCompletion(ctx, prompt, temperature)
+	End(span, err)
+	return response, err
+}
+
+func (c *llmClient) GenerateSchemaCompletion(ctx context.Context, prompt string, schema *llm.Schema, temperature float32) (string, error) {
+	ctx, span := c.start(ctx, "schema_completion", prompt,
+		attribute.Float64("llm.temperature", float64(temperature)),
+		attribute.String("llm.schema", schema.Name),
+	)
+	response, err := c.next.GenerateSchemaCompletion(ctx, prompt, schema, temperature)
+	End(span, err)
+	return response, err
+}
+
+// The retry variants retry through the wrapper so every attempt gets its own span
+func (c *llmClient) GenerateCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return llm.Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateCompletion(ctx, prompt, temperature)
+	})
+}
+
+func (c *llmClient) GenerateStructuredCompletionWithRetry(ctx context.Context, prompt string, temperature float32, maxRetries int) (string, error) {
+	return llm.Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateStructuredCompletion(ctx, prompt, temperature)
+	})
+}
+
+func (c *llmClient) GenerateSchemaCompletionWithRetry(ctx context.Context, prompt string, schema *llm.Schema, temperature float32, maxRetries int) (string, error) {
+	return llm.Retry(ctx, maxRetries, func(ctx context.Context) (string, error) {
+		return c.GenerateSchemaCompletion(ctx, prompt, schema, temperature)
+	})
+}
@@ -0,0 +1,38 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+)
+
+// MongoMonitor returns a command monitor recording a span per MongoDB command, chained with next when set
+func MongoMonitor(next *event.CommandMonitor) *event.CommandMonitor {
+	tracing := otelmongo.NewMonitor()
+	if next == nil {
+		return tracing
+	}
+
+	return &event.CommandMonitor{
+		// Start the span first so latency added by next, e.g. fault injection, is part of it
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			tracing.Started(ctx, evt)
+			if next.Started != nil {
+				next.Started(ctx, evt)
+			}
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			if next.Succeeded != nil {
+				next.Succeeded(ctx, evt)
+			}
+			tracing.Succeeded(ctx, evt)
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			if next.Failed != nil {
+				next.Failed(ctx, evt)
+			}
+			tracing.Failed(ctx, evt)
+		},
+	}
+}
@@ -0,0 +1,25 @@
+// Package tenant carries the organization a request or job acts for through a context
+package tenant
+
+import "context"
+
+type orgIDKey struct{}
+
+// WithOrgID returns a context scoped to an organization
+func WithOrgID(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, orgIDKey{}, orgID)
+}
+
+// OrgID returns the organization a context is scoped to, or "" when it is unscoped.
+// Unscoped contexts, e.g. startup tasks and admin tools, see every tenant's data.
+func OrgID(ctx context.Context) string {
+	orgID, _ := ctx.Value(orgIDKey{}).(string)
+	return orgID
+}
+
+// Scope returns the organization a context is scoped to and whether it is scoped at all. A context
+// scoped to "" acts for no organization: it sees the global shared data but no other tenant's.
+func Scope(ctx context.Context) (orgID string, scoped bool) {
+	orgID, scoped = ctx.Value(orgIDKey{}).(string)
+	return orgID, scoped
+}
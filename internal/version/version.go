@@ -0,0 +1,9 @@
+package version
+
+// Build information, overridden at build time via -ldflags, e.g.
+// go build -ldflags "-X ai-cv-summarize/internal/version.Commit=$(git rev-parse HEAD)"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)